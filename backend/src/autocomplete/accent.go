@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Per-accent statistics: word counts of the baselines of clips with each
+// accent and of all clips with any accent, and the confusion pairs finalized
+// clips of each accent were corrected with
+const (
+	accentVocabKeyPrefix     = redisKeyPrefix + "accent:vocab:"
+	accentAllVocabKey        = redisKeyPrefix + "accent:all"
+	accentConfusionKeyPrefix = redisKeyPrefix + "accent:confusion:"
+)
+
+// accentConfusionKey holds the words a heard word was corrected to in clips of an accent
+func accentConfusionKey(accent, heard string) string {
+	return accentConfusionKeyPrefix + accent + ":" + heard
+}
+
+// learnAccentVocabulary counts a clip's baseline words under its accent
+func (s *AutocompleteService) learnAccentVocabulary(ctx context.Context, data *models.AutocompleteData) error {
+	if data.Accent == "" {
+		return nil
+	}
+	words := services.TranscriptWords(data.FinalTranscription)
+	if len(words) == 0 {
+		return nil
+	}
+
+	pipe := s.RedisClient.Pipeline()
+	for _, word := range words {
+		word = strings.ToLower(word)
+		pipe.ZIncrBy(ctx, accentAllVocabKey, 1, word)
+		pipe.ZIncrBy(ctx, accentVocabKeyPrefix+data.Accent, 1, word)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
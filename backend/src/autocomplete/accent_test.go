@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestLearnAccentVocabularyWithoutRedis(t *testing.T) {
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	tests := []struct {
+		name    string
+		data    *models.AutocompleteData
+		wantErr bool
+	}{
+		{"no accent", &models.AutocompleteData{FinalTranscription: "saya makan"}, false},
+		{"no words", &models.AutocompleteData{Accent: "kelantan"}, false},
+		{"accent", &models.AutocompleteData{FinalTranscription: "saya makan", Accent: "kelantan"}, true},
+	}
+	for _, tt := range tests {
+		if err := s.learnAccentVocabulary(context.Background(), tt.data); (err != nil) != tt.wantErr {
+			t.Errorf("%s: learnAccentVocabulary() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
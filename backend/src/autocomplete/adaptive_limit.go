@@ -0,0 +1,153 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bounds and tuning of the adaptive limiter
+const (
+	adaptiveInitialLimit = 20
+	adaptiveMinLimit     = 4
+	adaptiveMaxLimit     = 1000
+	adaptiveSmoothing    = 0.2
+	adaptiveLongWindow   = 600 // samples averaged into the long-term RTT
+	adaptiveShortWindow  = 10  // samples averaged into the short-term RTT
+)
+
+// adaptiveLimiter is a gradient-based concurrency limiter in the style of
+// Netflix's concurrency-limits Gradient2: it compares short-term latency to the
+// long-term baseline, shrinking the limit as latency rises and growing it again
+// (by roughly sqrt(limit) of queueing headroom) once latency recovers.
+type adaptiveLimiter struct {
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	shortRTT float64
+	longRTT  float64
+	rejected int64
+	samples  int64
+}
+
+func newAdaptiveLimiter() *adaptiveLimiter {
+	return &adaptiveLimiter{limit: adaptiveInitialLimit}
+}
+
+// acquire admits a request if fewer than limit requests are in flight
+func (l *adaptiveLimiter) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if float64(l.inFlight) >= l.limit {
+		l.rejected++
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+// release records the request's latency and adjusts the limit
+func (l *adaptiveLimiter) release(rtt time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inFlight := l.inFlight
+	l.inFlight--
+	l.samples++
+
+	sample := float64(rtt.Nanoseconds())
+	if l.longRTT == 0 {
+		l.shortRTT, l.longRTT = sample, sample
+		return
+	}
+	l.shortRTT += (sample - l.shortRTT) / adaptiveShortWindow
+	l.longRTT += (sample - l.longRTT) / adaptiveLongWindow
+
+	// After a latency spike the baseline would lag for a long time; let it
+	// drift down quickly once short-term latency is well below it
+	if l.longRTT/l.shortRTT > 2 {
+		l.longRTT *= 0.95
+	}
+
+	// Don't grow the limit while the route is not using it
+	if float64(inFlight) < l.limit/2 {
+		return
+	}
+
+	gradient := math.Max(0.5, math.Min(1.0, l.longRTT/l.shortRTT))
+	newLimit := l.limit*gradient + math.Sqrt(l.limit)
+	newLimit = l.limit*(1-adaptiveSmoothing) + newLimit*adaptiveSmoothing
+	l.limit = math.Max(adaptiveMinLimit, math.Min(adaptiveMaxLimit, newLimit))
+}
+
+// adaptiveLimits holds one limiter per route
+type adaptiveLimits struct {
+	mu     sync.Mutex
+	routes map[string]*adaptiveLimiter
+}
+
+// limiterFor returns the route's limiter, creating it on first use
+func (a *adaptiveLimits) limiterFor(route string) *adaptiveLimiter {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limiter, exists := a.routes[route]
+	if !exists {
+		limiter = newAdaptiveLimiter()
+		a.routes[route] = limiter
+	}
+	return limiter
+}
+
+// adaptiveLimitMiddleware sheds load per route once the adaptive limit is
+// reached, protecting Redis and the trie locks during traffic spikes
+func (s *AutocompleteService) adaptiveLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" || route == suggestStreamPath {
+			c.Next() // Unmatched routes fall through to the 404 handler; stream sessions have no latency to sample
+			return
+		}
+
+		limiter := s.adaptive.limiterFor(route)
+		if !limiter.acquire() {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "concurrency limit reached, retry later"})
+			return
+		}
+
+		start := time.Now()
+		defer func() { limiter.release(time.Since(start)) }()
+
+		c.Next()
+	}
+}
+
+// adaptiveStats reports each route's current limit and latency baselines
+func (s *AutocompleteService) adaptiveStats() map[string]interface{} {
+	if s.adaptive == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+
+	s.adaptive.mu.Lock()
+	defer s.adaptive.mu.Unlock()
+
+	routes := make(map[string]interface{}, len(s.adaptive.routes))
+	for route, limiter := range s.adaptive.routes {
+		limiter.mu.Lock()
+		routes[route] = map[string]interface{}{
+			"limit":        int(limiter.limit),
+			"in_flight":    limiter.inFlight,
+			"short_rtt_ms": limiter.shortRTT / 1e6,
+			"long_rtt_ms":  limiter.longRTT / 1e6,
+			"samples":      limiter.samples,
+			"rejected":     limiter.rejected,
+		}
+		limiter.mu.Unlock()
+	}
+
+	return map[string]interface{}{"enabled": true, "routes": routes}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiterAcquire(t *testing.T) {
+	limiter := newAdaptiveLimiter()
+	for i := 0; i < adaptiveInitialLimit; i++ {
+		if !limiter.acquire() {
+			t.Fatalf("acquire %d rejected below the limit", i)
+		}
+	}
+	if limiter.acquire() || limiter.rejected != 1 {
+		t.Errorf("acquire at the limit admitted, %d rejected", limiter.rejected)
+	}
+	limiter.release(time.Millisecond)
+	if !limiter.acquire() {
+		t.Error("acquire after a release rejected")
+	}
+}
+
+func TestAdaptiveLimiterRelease(t *testing.T) {
+	tests := []struct {
+		name     string
+		inFlight int
+		warmup   time.Duration
+		rtt      time.Duration
+		rounds   int
+		check    func(limit float64) bool
+		want     string
+	}{
+		{"steady latency grows the limit", adaptiveInitialLimit, time.Millisecond, time.Millisecond, 50,
+			func(limit float64) bool { return limit > adaptiveInitialLimit }, "above the initial limit"},
+		{"idle route keeps the limit", 1, time.Millisecond, time.Millisecond, 50,
+			func(limit float64) bool { return limit == adaptiveInitialLimit }, "the initial limit"},
+		{"rising latency shrinks the limit", adaptiveInitialLimit, time.Millisecond, 50 * time.Millisecond, 50,
+			func(limit float64) bool { return limit < adaptiveInitialLimit }, "below the initial limit"},
+		{"limit never drops under the minimum", adaptiveInitialLimit, time.Millisecond, time.Second, 2000,
+			func(limit float64) bool { return limit >= adaptiveMinLimit }, "at least the minimum"},
+		{"limit never exceeds the maximum", adaptiveMaxLimit, time.Millisecond, time.Millisecond, 5000,
+			func(limit float64) bool { return limit <= adaptiveMaxLimit }, "at most the maximum"},
+	}
+	for _, tt := range tests {
+		limiter := newAdaptiveLimiter()
+		limiter.inFlight = 1
+		limiter.release(tt.warmup) // Sets the baseline without moving the limit
+
+		for i := 0; i < tt.rounds; i++ {
+			limiter.inFlight = tt.inFlight
+			limiter.release(tt.rtt)
+		}
+		if !tt.check(limiter.limit) {
+			t.Errorf("%s: limit = %.1f, want %s", tt.name, limiter.limit, tt.want)
+		}
+	}
+}
+
+func TestAdaptiveLimitsPerRoute(t *testing.T) {
+	limits := &adaptiveLimits{routes: make(map[string]*adaptiveLimiter)}
+	suggest := limits.limiterFor("/suggest/prefix")
+	if limits.limiterFor("/suggest/prefix") != suggest {
+		t.Error("limiterFor returned a new limiter for a known route")
+	}
+	if limits.limiterFor("/initialize") == suggest {
+		t.Error("two routes share a limiter")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/services"
+)
+
+func (s *AutocompleteService) handleListVersions(c *gin.Context) {
+	audioID := c.Query("audio_id")
+
+	c.JSON(http.StatusOK, gin.H{
+		"audio_id": audioID,
+		"versions": services.ListVersions(audioID),
+	})
+}
+
+func (s *AutocompleteService) handleVersionsDiff(c *gin.Context) {
+	from, err := parseOptionalInt(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a version number"})
+		return
+	}
+	to, err := parseOptionalInt(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a version number"})
+		return
+	}
+
+	diff, err := services.DiffVersions(c.Query("audio_id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+func (s *AutocompleteService) handleTrieDump(c *gin.Context) {
+	audioID := c.Query("audio_id")
+	prefix := c.Query("prefix")
+
+	trie, err := s.clipTrie(context.Background(), audioID, prefix)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	subtree, found := trie.Subtree(prefix)
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no indexed words start with prefix " + prefix})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"audio_id": trie.AudioClipID,
+		"prefix":   prefix,
+		"subtree":  subtree,
+	})
+}
+
+// parseOptionalInt parses a query value, treating an empty value as zero
+func parseOptionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleVersionsDiff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan"})
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya minum"})
+
+	router := gin.New()
+	router.GET("/admin/versions/diff", (&AutocompleteService{}).handleVersionsDiff)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"?audio_id=clip", http.StatusOK},
+		{"?audio_id=clip&from=1&to=2", http.StatusOK},
+		{"?audio_id=clip&from=one", http.StatusBadRequest},
+		{"?audio_id=clip&to=2.5", http.StatusBadRequest},
+		{"?audio_id=clip&from=1&to=7", http.StatusNotFound},
+		{"?audio_id=missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/versions/diff"+tt.query, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.query, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
+
+func TestHandleTrieDump(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan"})
+
+	router := gin.New()
+	router.GET("/admin/trie", (&AutocompleteService{}).handleTrieDump)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"?audio_id=clip", http.StatusOK},
+		{"?audio_id=clip&prefix=mak", http.StatusOK},
+		{"?audio_id=clip&prefix=x", http.StatusNotFound},
+		{"?audio_id=missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/trie"+tt.query, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.query, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
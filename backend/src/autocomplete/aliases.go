@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/services"
+)
+
+// clipAliasesKey maps each registered alias onto the clip id it resolves to
+const clipAliasesKey = redisKeyPrefix + "aliases"
+
+// aliasUpdatesChannel announces alias changes, so every replica reloads the
+// table from Redis immediately
+const aliasUpdatesChannel = redisKeyPrefix + "aliases:updates"
+
+// loadClipAliases mirrors the alias table stored in Redis into memory
+func (s *AutocompleteService) loadClipAliases(ctx context.Context) error {
+	aliases, err := s.RedisClient.HGetAll(ctx, clipAliasesKey).Result()
+	if err != nil {
+		return err
+	}
+	services.SetClipAliases(aliases)
+	return nil
+}
+
+// watchAliasUpdates reloads the alias table when another replica changes it, until ctx ends
+func (s *AutocompleteService) watchAliasUpdates(ctx context.Context) {
+	pubsub := s.RedisClient.Subscribe(ctx, aliasUpdatesChannel)
+	defer pubsub.Close()
+
+	for range pubsub.Channel() {
+		if err := s.loadClipAliases(ctx); err != nil {
+			log.Printf("Error reloading clip aliases: %v", err)
+		}
+	}
+}
+
+// publishAliasChange reloads the alias table locally and notifies the other replicas
+func (s *AutocompleteService) publishAliasChange(ctx context.Context) error {
+	if err := s.loadClipAliases(ctx); err != nil {
+		return err
+	}
+	return s.RedisClient.Publish(ctx, aliasUpdatesChannel, "").Err()
+}
+
+// handleListAliases lists every registered alias with the clip it resolves to
+func (s *AutocompleteService) handleListAliases(c *gin.Context) {
+	aliases, err := s.RedisClient.HGetAll(context.Background(), clipAliasesKey).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"aliases": aliases})
+}
+
+// handleAddAlias registers an alias for a clip, e.g. the frontend's clip UUID
+// for the orchestrator's job ID, so requests naming either reach one index.
+// The clip id is resolved first, so aliases never chain. An id that is itself
+// indexed, or that other aliases resolve to, can't become an alias.
+func (s *AutocompleteService) handleAddAlias(c *gin.Context) {
+	var request struct {
+		Alias   string `json:"alias" binding:"required"`
+		AudioID string `json:"audio_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := context.Background()
+	audioID := services.NormalizeAudioID(request.AudioID)
+	if request.Alias == audioID || request.Alias == services.GlobalAudioID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "alias must differ from the clip id"})
+		return
+	}
+
+	aliases, err := s.RedisClient.HGetAll(ctx, clipAliasesKey).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for alias, target := range aliases {
+		if target == request.Alias {
+			c.JSON(http.StatusConflict, gin.H{"error": request.Alias + " is the target of alias " + alias})
+			return
+		}
+	}
+	version, err := s.clipVersion(ctx, request.Alias)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, aliased := aliases[request.Alias]; !aliased && version > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "clip " + request.Alias + " has its own index"})
+		return
+	}
+
+	if err := s.RedisClient.HSet(ctx, clipAliasesKey, request.Alias, audioID).Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.publishAliasChange(ctx); err != nil {
+		log.Printf("Error propagating clip aliases: %v", err)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "registered", "alias": request.Alias, "audio_id": audioID})
+}
+
+// handleDeleteAlias removes an alias; the clip it resolved to is untouched
+func (s *AutocompleteService) handleDeleteAlias(c *gin.Context) {
+	alias := c.Param("alias")
+	ctx := context.Background()
+
+	removed, err := s.RedisClient.HDel(ctx, clipAliasesKey, alias).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no alias " + alias})
+		return
+	}
+	if err := s.publishAliasChange(ctx); err != nil {
+		log.Printf("Error propagating clip aliases: %v", err)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "removed", "alias": alias})
+}
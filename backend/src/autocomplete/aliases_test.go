@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/services"
+)
+
+func TestHandleAddAliasRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer services.SetClipAliases(nil)
+	services.SetClipAliases(map[string]string{"job-7": "clip-uuid"})
+	router := gin.New()
+	router.POST("/admin/aliases", (&AutocompleteService{RedisClient: unreachableRedis(t)}).handleAddAlias)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"no body", "", http.StatusBadRequest},
+		{"no audio id", `{"alias": "job-8"}`, http.StatusBadRequest},
+		{"no alias", `{"audio_id": "clip-uuid"}`, http.StatusBadRequest},
+		{"alias of itself", `{"alias": "clip-uuid", "audio_id": "clip-uuid"}`, http.StatusBadRequest},
+		{"alias of its own alias", `{"alias": "clip-uuid", "audio_id": "job-7"}`, http.StatusBadRequest},
+		{"alias of the global clip", `{"alias": "global", "audio_id": ""}`, http.StatusBadRequest},
+		{"redis unreachable", `{"alias": "job-8", "audio_id": "clip-uuid"}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/admin/aliases", strings.NewReader(tt.body)))
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.wantStatus, recorder.Body)
+		}
+	}
+}
+
+func TestAliasHandlersWithoutRedis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	router := gin.New()
+	router.GET("/admin/aliases", s.handleListAliases)
+	router.DELETE("/admin/aliases/:alias", s.handleDeleteAlias)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/admin/aliases"},
+		{http.MethodDelete, "/admin/aliases/job-7"},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+		if recorder.Code != http.StatusInternalServerError {
+			t.Errorf("%s %s: status %d, want 500: %s", tt.method, tt.path, recorder.Code, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/services"
+)
+
+// handleAlign maps a playback time to the clip's word position spoken then,
+// with the candidates the ASR models heard there, so clicking the waveform can
+// jump the editor to that word's suggestion context
+func (s *AutocompleteService) handleAlign(c *gin.Context) {
+	audioID := c.Query("audio_id")
+	t, err := strconv.ParseFloat(c.Query("t"), 64)
+	if err != nil || t < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "t must be a non-negative playback time in seconds"})
+		return
+	}
+
+	ctx := context.Background()
+	data, err := s.clipTranscripts(ctx, audioID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no transcripts stored for clip " + services.NormalizeAudioID(audioID)})
+		return
+	}
+
+	position, method, segmentID, err := services.WordAtTime(data, t)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	candidates, err := s.positionCandidates(ctx, audioID, strconv.Itoa(position))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"audio_id":   services.NormalizeAudioID(audioID),
+		"t":          t,
+		"word_index": position,
+		"method":     method,
+		"candidates": candidates,
+	}
+	if words := services.TranscriptWords(data.FinalTranscription); position < len(words) {
+		response["word"] = words[position]
+	}
+	if segmentID != "" {
+		response["segment_id"] = segmentID
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleAlign(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("timed", &models.AutocompleteData{
+		FinalTranscription: "saya makan nasi",
+		WordTimestamps:     []models.WordTimestamp{{StartTime: 0, EndTime: 0.4}, {StartTime: 0.5, EndTime: 0.9}, {StartTime: 1, EndTime: 1.4}},
+	})
+	services.BuildAndCacheData("segmented", &models.AutocompleteData{
+		FinalTranscription: "saya makan nasi",
+		Segments:           []models.AudioSegment{{ID: "intro", StartTime: 0, EndTime: 3, StartWord: 0, EndWord: 2}},
+	})
+	services.BuildAndCacheData("untimed", &models.AutocompleteData{FinalTranscription: "saya makan nasi"})
+
+	s := &AutocompleteService{}
+	router := gin.New()
+	router.GET("/align", s.handleAlign)
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantWord   string
+		wantMethod string
+		wantID     string
+	}{
+		{"?audio_id=timed&t=0.6", http.StatusOK, "makan", services.AlignTimestamp, ""},
+		{"?audio_id=segmented&t=2.5", http.StatusOK, "nasi", services.AlignSegment, "intro"},
+		{"?audio_id=segmented&t=4", http.StatusNotFound, "", "", ""},
+		{"?audio_id=untimed&t=1", http.StatusNotFound, "", "", ""},
+		{"?audio_id=other&t=1", http.StatusNotFound, "", "", ""},
+		{"?audio_id=timed&t=-1", http.StatusBadRequest, "", "", ""},
+		{"?audio_id=timed", http.StatusBadRequest, "", "", ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/align"+tt.query, nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.query, w.Code, tt.wantStatus, w.Body)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var response struct {
+			Word       string                  `json:"word"`
+			Method     string                  `json:"method"`
+			SegmentID  string                  `json:"segment_id"`
+			Candidates []models.WordSuggestion `json:"candidates"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Word != tt.wantWord || response.Method != tt.wantMethod || response.SegmentID != tt.wantID {
+			t.Errorf("%s: aligned to %q by %s (%q), want %q by %s (%q)", tt.query, response.Word, response.Method, response.SegmentID, tt.wantWord, tt.wantMethod, tt.wantID)
+		}
+		if len(response.Candidates) == 0 || response.Candidates[0].Text != tt.wantWord {
+			t.Errorf("%s: candidates = %v, want %q first", tt.query, response.Candidates, tt.wantWord)
+		}
+	}
+}
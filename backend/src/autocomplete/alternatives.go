@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// handleSentenceAlternatives returns every model's complete transcript of a clip
+// aligned to the baseline with per-word diff markers, so a whole sentence can
+// be switched to one model's version
+func (s *AutocompleteService) handleSentenceAlternatives(c *gin.Context) {
+	audioID := c.Query("audio_id")
+
+	data, err := s.clipTranscripts(context.Background(), audioID)
+	if err == redis.Nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no transcripts stored for clip " + services.NormalizeAudioID(audioID)})
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if !s.Stateless {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, services.AlignSentences(audioID, data))
+}
+
+// clipTranscripts returns the payload a clip was built from: from the cache
+// normally, or from Redis in stateless mode (redis.Nil when it isn't stored)
+func (s *AutocompleteService) clipTranscripts(ctx context.Context, audioID string) (*models.AutocompleteData, error) {
+	if !s.Stateless {
+		return services.ClipTranscripts(audioID)
+	}
+
+	audioID = services.NormalizeAudioID(audioID)
+	s.touchClip(audioID)
+	raw, err := s.clipReadClient(audioID).Get(ctx, clipTranscriptsKey(audioID)).Bytes()
+	if err == redis.Nil {
+		// Clips named in a request body miss the archive middleware
+		if rehydrated, rehydrateErr := s.rehydrateClip(ctx, audioID); rehydrateErr != nil {
+			return nil, rehydrateErr
+		} else if rehydrated {
+			raw, err = s.clipClient(audioID).Get(ctx, clipTranscriptsKey(audioID)).Bytes()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	var data models.AutocompleteData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleSentenceAlternatives(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{
+		FinalTranscription: "saya makan",
+		ASRAlternatives:    map[string]string{"whisper": "saya makna"},
+	})
+
+	s := &AutocompleteService{}
+	router := gin.New()
+	router.GET("/alternatives/sentences", s.handleSentenceAlternatives)
+
+	tests := []struct {
+		query       string
+		wantStatus  int
+		wantChanged int
+	}{
+		{"?audio_id=clip", http.StatusOK, 1},
+		{"?audio_id=other", http.StatusNotFound, 0},
+		{"", http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/alternatives/sentences"+tt.query, nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.query, w.Code, tt.wantStatus, w.Body)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var response models.SentenceAlternatives
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if len(response.Models) != 1 || response.Models[0].Changed != tt.wantChanged {
+			t.Errorf("%s: models = %+v, want whisper with %d changed", tt.query, response.Models, tt.wantChanged)
+		}
+	}
+}
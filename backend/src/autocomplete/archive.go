@@ -0,0 +1,479 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// archivedClipsKey maps each archived clip to when it was archived, so any
+// replica knows to re-hydrate it
+const archivedClipsKey = redisKeyPrefix + "archived"
+
+// errArchiveMissing is returned by an archive store without the requested object
+var errArchiveMissing = errors.New("archived clip not found in cold storage")
+
+// archiveStore is the object storage idle clips are moved to
+type archiveStore interface {
+	put(ctx context.Context, name string, data []byte) error
+	get(ctx context.Context, name string) ([]byte, error)
+	remove(ctx context.Context, name string) error
+}
+
+// dirArchive stores archived clips as files, e.g. on a mounted bucket
+type dirArchive struct {
+	dir string
+}
+
+func (a *dirArchive) put(ctx context.Context, name string, data []byte) error {
+	path := filepath.Join(a.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (a *dirArchive) get(ctx context.Context, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(a.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errArchiveMissing
+	}
+	return data, err
+}
+
+func (a *dirArchive) remove(ctx context.Context, name string) error {
+	err := os.Remove(filepath.Join(a.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// httpArchive stores archived clips with PUT, GET and DELETE under a base
+// URL, sending an optional Authorization header
+type httpArchive struct {
+	baseURL       string
+	authorization string
+	client        *http.Client
+}
+
+func (a *httpArchive) do(ctx context.Context, method, name string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+"/"+name, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if a.authorization != "" {
+		req.Header.Set("Authorization", a.authorization)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/gzip")
+	}
+	return a.client.Do(req)
+}
+
+func (a *httpArchive) put(ctx context.Context, name string, data []byte) error {
+	resp, err := a.do(ctx, http.MethodPut, name, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("archive store returned %s for PUT %s", resp.Status, name)
+	}
+	return nil
+}
+
+func (a *httpArchive) get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := a.do(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errArchiveMissing
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("archive store returned %s for GET %s", resp.Status, name)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (a *httpArchive) remove(ctx context.Context, name string) error {
+	resp, err := a.do(ctx, http.MethodDelete, name, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("archive store returned %s for DELETE %s", resp.Status, name)
+	}
+	return nil
+}
+
+// clipArchiver moves stateless clips idle for longer than after out of Redis
+type clipArchiver struct {
+	store archiveStore
+	after time.Duration
+	batch int
+
+	mu         sync.Mutex
+	archived   int64
+	rehydrated int64
+	lastErr    error
+}
+
+// clipArchive is the gzipped JSON object holding an archived clip's keys as
+// Redis DUMP payloads, which only restore onto the same or a newer Redis version
+type clipArchive struct {
+	AudioID    string        `json:"audio_id"`
+	ArchivedAt time.Time     `json:"archived_at"`
+	Keys       []archivedKey `json:"keys"`
+}
+
+// archivedKey is one of an archived clip's keys, named by its suffix in the
+// clip's namespace
+type archivedKey struct {
+	Suffix string `json:"suffix"`
+	Dump   []byte `json:"dump"`
+}
+
+// archiveObjectName names an archived clip's object; escaping keeps any audio
+// ID inside the store's directory
+func archiveObjectName(audioID string) string {
+	return url.PathEscape(audioID) + ".json.gz"
+}
+
+// configureArchive enables archival of idle stateless clips when ARCHIVE_DIR or
+// ARCHIVE_URL names cold storage. Clip keys then outlive ARCHIVE_AFTER without
+// the TTL cap, so the archiver rather than expiry bounds Redis memory.
+func (s *AutocompleteService) configureArchive() error {
+	var store archiveStore
+	switch {
+	case os.Getenv("ARCHIVE_DIR") != "":
+		dir := os.Getenv("ARCHIVE_DIR")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		store = &dirArchive{dir: dir}
+	case os.Getenv("ARCHIVE_URL") != "":
+		store = &httpArchive{
+			baseURL:       strings.TrimSuffix(os.Getenv("ARCHIVE_URL"), "/"),
+			authorization: os.Getenv("ARCHIVE_AUTHORIZATION"),
+			client:        &http.Client{Timeout: 30 * time.Second},
+		}
+	default:
+		return nil
+	}
+	if !s.Stateless {
+		log.Printf("Clip archiving needs STATELESS_MODE=true, since only stateless clips keep their index in Redis; disabled")
+		return nil
+	}
+
+	s.archive = &clipArchiver{
+		store: store,
+		after: envDuration("ARCHIVE_AFTER", 7*24*time.Hour),
+		batch: envInt("ARCHIVE_BATCH", 100),
+	}
+	interval := envDuration("ARCHIVE_INTERVAL", time.Hour)
+
+	// A clip's keys last until it has been idle for a full archive interval
+	// past ARCHIVE_AFTER, so they only expire if the archiver stops running
+	clipIndexTTL = s.archive.after + 2*interval
+	s.touches = newClipTouches(0, envDuration("CLIP_TTL_REFRESH_INTERVAL", time.Minute), true)
+
+	s.jobs = append(s.jobs, &backgroundJob{
+		name:     "clip_archive",
+		interval: interval,
+		run: func(ctx context.Context) error {
+			_, err := s.archiveIdleClips(ctx)
+			return err
+		},
+	})
+	log.Printf("Clip archiving enabled: clips idle for %s move to cold storage", s.archive.after)
+	return nil
+}
+
+// archiveIdleClips archives up to a batch of clips whose words hash has gone
+// unused for the archive threshold, judged by OBJECT IDLETIME
+func (s *AutocompleteService) archiveIdleClips(ctx context.Context) ([]string, error) {
+	var archived []string
+	err := func() error {
+		for _, client := range s.redisClients() {
+			iter := client.Scan(ctx, 0, clipKeyPrefix("*")+"words", 1000).Iterator()
+			for iter.Next(ctx) && len(archived) < s.archive.batch {
+				audioID := clipIDFromKey(iter.Val())
+				if audioID == services.GlobalAudioID {
+					continue // The global clip backs every unscoped suggestion
+				}
+				idle, err := client.ObjectIdleTime(ctx, iter.Val()).Result()
+				if err == redis.Nil || err == nil && idle < s.archive.after {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				moved, err := s.archiveClip(ctx, audioID)
+				if err != nil {
+					return fmt.Errorf("failed to archive clip %s: %w", audioID, err)
+				}
+				if moved {
+					archived = append(archived, audioID)
+				}
+			}
+			if err := iter.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+
+	s.archive.mu.Lock()
+	s.archive.archived += int64(len(archived))
+	s.archive.lastErr = err
+	s.archive.mu.Unlock()
+	if len(archived) > 0 {
+		log.Printf("Archived %d idle clips to cold storage: %v", len(archived), archived)
+	}
+	return archived, err
+}
+
+// archiveClip writes the clip's keys to cold storage and removes them from
+// Redis, reporting false when the clip is locked or has nothing to archive
+func (s *AutocompleteService) archiveClip(ctx context.Context, audioID string) (bool, error) {
+	lock, err := s.acquireClipLock(ctx, audioID, 0)
+	if err == errClipLocked {
+		return false, nil // Being rebuilt, so not idle
+	}
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := lock.release(ctx); err != nil {
+			log.Printf("Error releasing clip lock: %v", err)
+		}
+	}()
+
+	client := s.clipClient(audioID)
+	keys, err := s.liveClipKeys(ctx, client, audioID)
+	if err != nil || len(keys) == 0 {
+		return false, err
+	}
+	pipe := client.Pipeline()
+	dumps := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		dumps[i] = pipe.Dump(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return false, err
+	}
+
+	archive := clipArchive{AudioID: audioID, ArchivedAt: time.Now()}
+	for i, key := range keys {
+		dump, err := dumps[i].Result()
+		if err == redis.Nil {
+			continue // Expired since the scan
+		}
+		archive.Keys = append(archive.Keys, archivedKey{
+			Suffix: strings.TrimPrefix(key, clipKeyPrefix(audioID)),
+			Dump:   []byte(dump),
+		})
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(archive); err != nil {
+		return false, err
+	}
+	if err := gz.Close(); err != nil {
+		return false, err
+	}
+	if err := s.archive.store.put(ctx, archiveObjectName(audioID), buf.Bytes()); err != nil {
+		return false, err
+	}
+
+	// Mark the clip archived before its keys go, so a reader that misses them
+	// already knows to re-hydrate
+	if err := s.RedisClient.HSet(ctx, archivedClipsKey, audioID, archive.ArchivedAt.Unix()).Err(); err != nil {
+		return false, err
+	}
+	return true, client.Del(ctx, keys...).Err()
+}
+
+// rehydrateClip restores an archived clip's keys to Redis, reporting false
+// when the clip isn't archived
+func (s *AutocompleteService) rehydrateClip(ctx context.Context, audioID string) (bool, error) {
+	if s.archive == nil {
+		return false, nil
+	}
+	audioID = services.NormalizeAudioID(audioID)
+	archived, err := s.RedisClient.HExists(ctx, archivedClipsKey, audioID).Result()
+	if err != nil || !archived {
+		return false, err
+	}
+
+	lock, err := s.acquireClipLock(ctx, audioID, initLockWait)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := lock.release(ctx); err != nil {
+			log.Printf("Error releasing clip lock: %v", err)
+		}
+	}()
+
+	// Another request may have re-hydrated the clip while this one waited
+	if archived, err := s.RedisClient.HExists(ctx, archivedClipsKey, audioID).Result(); err != nil || !archived {
+		return false, err
+	}
+
+	raw, err := s.archive.store.get(ctx, archiveObjectName(audioID))
+	if err == errArchiveMissing {
+		log.Printf("Archived clip %s is missing from cold storage, forgetting it", audioID)
+		return false, s.RedisClient.HDel(ctx, archivedClipsKey, audioID).Err()
+	}
+	if err != nil {
+		return false, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return false, err
+	}
+	var archive clipArchive
+	if err := json.NewDecoder(gz).Decode(&archive); err != nil {
+		return false, err
+	}
+
+	pipe := s.clipClient(audioID).TxPipeline()
+	for _, key := range archive.Keys {
+		pipe.RestoreReplace(ctx, clipKeyPrefix(audioID)+key.Suffix, clipIndexTTL, string(key.Dump))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	if err := s.RedisClient.HDel(ctx, archivedClipsKey, audioID).Err(); err != nil {
+		return false, err
+	}
+	if err := s.archive.store.remove(ctx, archiveObjectName(audioID)); err != nil {
+		log.Printf("Error removing re-hydrated clip %s from cold storage: %v", audioID, err)
+	}
+
+	s.archive.mu.Lock()
+	s.archive.rehydrated++
+	s.archive.mu.Unlock()
+	log.Printf("Re-hydrated clip %s from cold storage (%d keys)", audioID, len(archive.Keys))
+	return true, nil
+}
+
+// dropArchivedClip forgets a clip's archive, e.g. because it was initialized again
+func (s *AutocompleteService) dropArchivedClip(ctx context.Context, audioID string) error {
+	if s.archive == nil {
+		return nil
+	}
+	removed, err := s.RedisClient.HDel(ctx, archivedClipsKey, audioID).Result()
+	if err != nil || removed == 0 {
+		return err
+	}
+	return s.archive.store.remove(ctx, archiveObjectName(audioID))
+}
+
+// archiveMiddleware re-hydrates an archived clip named by the request's
+// audio_id before the handler reads it. Initializing a clip replaces its
+// archive instead, and archiving a clip on demand must not restore it first.
+func (s *AutocompleteService) archiveMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		audioID := c.Param("audio_id")
+		if audioID == "" {
+			audioID = c.Query("audio_id")
+		}
+		if audioID == "" || strings.HasPrefix(path, "/initialize") || path == "/admin/clips/:audio_id/archive" {
+			c.Next()
+			return
+		}
+
+		if _, err := s.rehydrateClip(c.Request.Context(), audioID); err != nil {
+			log.Printf("Error re-hydrating clip %s: %v", audioID, err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "clip is archived and could not be restored: " + err.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// handleArchiveClip archives one clip now, however recently it was used
+func (s *AutocompleteService) handleArchiveClip(c *gin.Context) {
+	if s.archive == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "clip archiving is disabled; set ARCHIVE_DIR or ARCHIVE_URL"})
+		return
+	}
+	audioID := c.Param("audio_id")
+	if audioID == services.GlobalAudioID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the global clip cannot be archived"})
+		return
+	}
+
+	moved, err := s.archiveClip(context.Background(), audioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !moved {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no data stored for clip " + audioID + ", or it is being initialized"})
+		return
+	}
+	s.archive.mu.Lock()
+	s.archive.archived++
+	s.archive.mu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"status": "archived", "audio_id": audioID})
+}
+
+// handleArchiveStatus lists the archived clips and the archiver's counters
+func (s *AutocompleteService) handleArchiveStatus(c *gin.Context) {
+	if s.archive == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	archived, err := s.RedisClient.HGetAll(context.Background(), archivedClipsKey).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	clips := make(map[string]time.Time, len(archived))
+	for audioID, at := range archived {
+		unix, _ := strconv.ParseInt(at, 10, 64)
+		clips[audioID] = time.Unix(unix, 0)
+	}
+
+	s.archive.mu.Lock()
+	defer s.archive.mu.Unlock()
+	status := gin.H{
+		"enabled":       true,
+		"archive_after": s.archive.after.String(),
+		"clips":         clips,
+		"archived":      s.archive.archived,
+		"rehydrated":    s.archive.rehydrated,
+	}
+	if s.archive.lastErr != nil {
+		status["last_error"] = s.archive.lastErr.Error()
+	}
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestArchiveObjectName(t *testing.T) {
+	tests := []struct {
+		audioID string
+		want    string
+	}{
+		{"clip", "clip.json.gz"},
+		{"../etc/passwd", "..%2Fetc%2Fpasswd.json.gz"},
+		{"a b", "a%20b.json.gz"},
+	}
+	for _, tt := range tests {
+		got := archiveObjectName(tt.audioID)
+		if got != tt.want || filepath.Base(got) != got {
+			t.Errorf("archiveObjectName(%q) = %q, want %q", tt.audioID, got, tt.want)
+		}
+	}
+}
+
+// httpObjectStore serves PUT, GET and DELETE of objects from memory, checking
+// the Authorization header
+func httpObjectStore(t *testing.T, authorization string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authorization {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, exists := objects[r.URL.Path]
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		case http.MethodDelete:
+			if _, exists := objects[r.URL.Path]; !exists {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(objects, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestArchiveStores(t *testing.T) {
+	server := httpObjectStore(t, "Bearer secret")
+	tests := []struct {
+		name    string
+		store   archiveStore
+		wantErr bool // Every call fails, e.g. unauthorized
+	}{
+		{"directory", &dirArchive{dir: t.TempDir()}, false},
+		{"http", &httpArchive{baseURL: server.URL, authorization: "Bearer secret", client: server.Client()}, false},
+		{"http unauthorized", &httpArchive{baseURL: server.URL, client: server.Client()}, true},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		if err := tt.store.put(ctx, "clip.json.gz", []byte("archived")); (err != nil) != tt.wantErr {
+			t.Errorf("%s: put() error = %v", tt.name, err)
+		}
+		if tt.wantErr {
+			if _, err := tt.store.get(ctx, "clip.json.gz"); err == nil || errors.Is(err, errArchiveMissing) {
+				t.Errorf("%s: get() error = %v, want a store error", tt.name, err)
+			}
+			continue
+		}
+		if data, err := tt.store.get(ctx, "clip.json.gz"); err != nil || string(data) != "archived" {
+			t.Errorf("%s: get() = %q, %v", tt.name, data, err)
+		}
+		if _, err := tt.store.get(ctx, "other.json.gz"); !errors.Is(err, errArchiveMissing) {
+			t.Errorf("%s: get() of a missing object error = %v, want %v", tt.name, err, errArchiveMissing)
+		}
+		for i := 0; i < 2; i++ { // Removing a removed object is fine
+			if err := tt.store.remove(ctx, "clip.json.gz"); err != nil {
+				t.Errorf("%s: remove() %d error = %v", tt.name, i+1, err)
+			}
+		}
+		if _, err := tt.store.get(ctx, "clip.json.gz"); !errors.Is(err, errArchiveMissing) {
+			t.Errorf("%s: get() after remove() error = %v", tt.name, err)
+		}
+	}
+}
+
+func TestConfigureArchive(t *testing.T) {
+	defer func(ttl time.Duration) { clipIndexTTL = ttl }(clipIndexTTL)
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		stateless bool
+		want      string // The store's type, "" when archiving stays off
+	}{
+		{"not configured", nil, true, ""},
+		{"directory", map[string]string{"ARCHIVE_DIR": "dir"}, true, "*main.dirArchive"},
+		{"url", map[string]string{"ARCHIVE_URL": "http://store/clips/"}, true, "*main.httpArchive"},
+		{"directory wins", map[string]string{"ARCHIVE_DIR": "dir", "ARCHIVE_URL": "http://store"}, true, "*main.dirArchive"},
+		{"stateful", map[string]string{"ARCHIVE_DIR": "dir"}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Setenv("ARCHIVE_DIR", "")
+		t.Setenv("ARCHIVE_URL", "")
+		t.Setenv("ARCHIVE_AFTER", "48h")
+		t.Setenv("ARCHIVE_INTERVAL", "1h")
+		for name, value := range tt.env {
+			if name == "ARCHIVE_DIR" {
+				value = filepath.Join(t.TempDir(), value)
+			}
+			t.Setenv(name, value)
+		}
+
+		service := &AutocompleteService{Stateless: tt.stateless}
+		if err := service.configureArchive(); err != nil {
+			t.Errorf("%s: configureArchive() error = %v", tt.name, err)
+			continue
+		}
+		got := ""
+		if service.archive != nil {
+			got = fmt.Sprintf("%T", service.archive.store)
+		}
+		if got != tt.want {
+			t.Errorf("%s: store = %q, want %q", tt.name, got, tt.want)
+			continue
+		}
+		if service.archive == nil {
+			if len(service.jobs) != 0 {
+				t.Errorf("%s: %d jobs registered with archiving off", tt.name, len(service.jobs))
+			}
+			continue
+		}
+		if service.archive.after != 48*time.Hour || clipIndexTTL != 50*time.Hour || service.touches == nil || len(service.jobs) != 1 {
+			t.Errorf("%s: archive after %v, clip TTL %v, touches %v, %d jobs", tt.name, service.archive.after, clipIndexTTL, service.touches, len(service.jobs))
+		}
+		if store, ok := service.archive.store.(*httpArchive); ok && strings.HasSuffix(store.baseURL, "/") {
+			t.Errorf("%s: base URL %q keeps its trailing slash", tt.name, store.baseURL)
+		}
+	}
+}
+
+func TestArchiveMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	routes := func(service *AutocompleteService) *gin.Engine {
+		router := gin.New()
+		router.Use(service.archiveMiddleware())
+		router.GET("/suggest/prefix", ok)
+		router.POST("/initialize/:audio_id", ok)
+		router.POST("/admin/clips/:audio_id/archive", ok)
+		router.GET("/clips/:audio_id/occurrences", ok)
+		return router
+	}
+	disabled := routes(&AutocompleteService{RedisClient: unreachableRedis(t)})
+	enabled := routes(&AutocompleteService{RedisClient: unreachableRedis(t), archive: &clipArchiver{store: &dirArchive{dir: t.TempDir()}}})
+
+	tests := []struct {
+		name   string
+		router *gin.Engine
+		method string
+		path   string
+		want   int
+	}{
+		{"archiving off", disabled, http.MethodGet, "/suggest/prefix?audio_id=clip", http.StatusOK},
+		{"no clip", enabled, http.MethodGet, "/suggest/prefix", http.StatusOK},
+		{"initialize", enabled, http.MethodPost, "/initialize/clip", http.StatusOK},
+		{"archive on demand", enabled, http.MethodPost, "/admin/clips/clip/archive", http.StatusOK},
+		{"query clip", enabled, http.MethodGet, "/suggest/prefix?audio_id=clip", http.StatusServiceUnavailable},
+		{"path clip", enabled, http.MethodGet, "/clips/clip/occurrences", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		tt.router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
+
+func TestArchiveHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		archive *clipArchiver
+		method  string
+		path    string
+		want    int
+	}{
+		{"archive while disabled", nil, http.MethodPost, "/admin/clips/clip/archive", http.StatusNotFound},
+		{"archive the global clip", &clipArchiver{}, http.MethodPost, "/admin/clips/global/archive", http.StatusBadRequest},
+		{"archive without Redis", &clipArchiver{}, http.MethodPost, "/admin/clips/clip/archive", http.StatusInternalServerError},
+		{"status while disabled", nil, http.MethodGet, "/admin/archive", http.StatusOK},
+		{"status without Redis", &clipArchiver{}, http.MethodGet, "/admin/archive", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		service := &AutocompleteService{RedisClient: unreachableRedis(t), archive: tt.archive}
+		router := gin.New()
+		router.POST("/admin/clips/:audio_id/archive", service.handleArchiveClip)
+		router.GET("/admin/archive", service.handleArchiveStatus)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Suggest backends the merge layer can query: the global Redis prefix index,
+// the clip's trie, the packaged dictionary and an external LLM completer
+const (
+	backendRedis      = "redis"
+	backendTrie       = "trie"
+	backendDictionary = "dictionary"
+	backendLLM        = "llm"
+)
+
+// defaultBackendWeights apply to backends enabled without an explicit weight.
+// The dictionary and LLM know nothing about the clip, so they count for less.
+var defaultBackendWeights = map[string]float64{
+	backendRedis:      1,
+	backendTrie:       1,
+	backendDictionary: 0.3,
+	backendLLM:        0.5,
+}
+
+// suggestBackends maps each enabled backend to its merge weight, set from
+// SUGGEST_BACKENDS. With a single backend no merge happens.
+var suggestBackends = map[string]float64{backendRedis: 1}
+
+// LLM completer endpoint (LLM_SUGGEST_URL) and its client, bounded by LLM_SUGGEST_TIMEOUT
+var (
+	llmSuggestURL string
+	llmClient     = &http.Client{Timeout: 300 * time.Millisecond}
+)
+
+// configureSuggestBackends parses a backend list such as "redis,trie:0.8,dictionary"
+func configureSuggestBackends(spec string) error {
+	backends, err := parseBackends(spec, nil)
+	if err != nil {
+		return err
+	}
+	if len(backends) == 0 {
+		return nil
+	}
+	if _, enabled := backends[backendLLM]; enabled && os.Getenv("LLM_SUGGEST_URL") == "" {
+		return fmt.Errorf("the llm backend needs LLM_SUGGEST_URL")
+	}
+
+	suggestBackends = backends
+	llmSuggestURL = os.Getenv("LLM_SUGGEST_URL")
+	llmClient.Timeout = envDuration("LLM_SUGGEST_TIMEOUT", llmClient.Timeout)
+	return nil
+}
+
+// parseBackends reads a comma-separated backend list with optional ":weight"
+// suffixes. Weights missing from the list come from weights, else the defaults.
+func parseBackends(spec string, weights map[string]float64) (map[string]float64, error) {
+	backends := make(map[string]float64)
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, weightParam, hasWeight := strings.Cut(entry, ":")
+		weight, known := defaultBackendWeights[name]
+		if !known {
+			return nil, fmt.Errorf("unknown suggest backend %q", name)
+		}
+		if configured, exists := weights[name]; exists {
+			weight = configured
+		}
+		if hasWeight {
+			parsed, err := strconv.ParseFloat(weightParam, 64)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid weight %q for suggest backend %s", weightParam, name)
+			}
+			weight = parsed
+		}
+		backends[name] = weight
+	}
+	return backends, nil
+}
+
+// suggestBudget bounds a suggest lookup when the request sets no budget_ms,
+// from SUGGEST_BUDGET. Zero waits for every backend.
+var suggestBudget time.Duration
+
+// parseSuggestBudget reads budget_ms, defaulting to suggestBudget
+func parseSuggestBudget(param string) (time.Duration, bool) {
+	if param == "" {
+		return suggestBudget, true
+	}
+	ms, err := strconv.Atoi(param)
+	if err != nil || ms <= 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// backendResult is one backend's ranked words, or the error it failed with
+type backendResult struct {
+	name        string
+	suggestions []models.WordSuggestion
+	err         error
+}
+
+// getMergedSuggestions queries the backends concurrently and merges their
+// results: words are deduplicated by normalized text and scored by the
+// weighted mean of their scores over all queried backends, so a word missing
+// from a backend scores 0 there. Each suggestion lists the per-backend scores
+// it was merged from. Backends that fail are reported in the returned map and
+// the rest are merged anyway. When ctx is done before every backend answers,
+// the backends that did are merged and the others are returned as pending.
+func (s *AutocompleteService) getMergedSuggestions(ctx context.Context, tenant, prefix, audioID string, backends map[string]float64, maxResults int) ([]map[string]interface{}, map[string]string, []string, error) {
+	fetch := suggestFetchCount(tenant, maxResults) * 2
+
+	results := make(chan backendResult, len(backends))
+	for name := range backends {
+		go func(name string) {
+			suggestions, err := s.queryBackend(ctx, name, prefix, audioID, fetch)
+			results <- backendResult{name: name, suggestions: suggestions, err: err}
+		}(name)
+	}
+
+	answered := make(map[string]backendResult, len(backends))
+collect:
+	for len(answered) < len(backends) {
+		select {
+		case result := <-results:
+			answered[result.name] = result
+		case <-ctx.Done():
+			break collect
+		}
+	}
+	var pending []string
+	for name := range backends {
+		if _, done := answered[name]; !done {
+			pending = append(pending, name)
+		}
+	}
+	sort.Strings(pending)
+
+	type merged struct {
+		text       string
+		lead       float64 // Weighted score of the backend whose spelling is shown
+		score      float64
+		perBackend map[string]float64
+	}
+	var totalWeight float64
+	for _, weight := range backends {
+		totalWeight += weight
+	}
+
+	words := make(map[string]*merged)
+	backendErrors := make(map[string]string)
+	for _, result := range answered {
+		if result.err != nil {
+			backendErrors[result.name] = result.err.Error()
+			continue
+		}
+		weight := backends[result.name]
+		for _, suggestion := range result.suggestions {
+			key := strings.ToLower(services.NormalizeText(suggestion.Text))
+			if key == "" {
+				continue
+			}
+			word, exists := words[key]
+			if !exists {
+				word = &merged{perBackend: make(map[string]float64)}
+				words[key] = word
+			}
+			// A backend may return several spellings of one word; its best counts
+			if previous, seen := word.perBackend[result.name]; seen {
+				if suggestion.Confidence <= previous {
+					continue
+				}
+				word.score -= weight * previous / totalWeight
+			}
+			word.perBackend[result.name] = suggestion.Confidence
+			word.score += weight * suggestion.Confidence / totalWeight
+			if weighted := weight * suggestion.Confidence; weighted > word.lead || word.text == "" {
+				word.lead = weighted
+				word.text = suggestion.Text
+			}
+		}
+	}
+	if len(backendErrors) == len(backends) {
+		return nil, backendErrors, nil, fmt.Errorf("every suggest backend failed")
+	}
+
+	ranked := make([]*merged, 0, len(words))
+	for _, word := range words {
+		ranked = append(ranked, word)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].text < ranked[j].text
+	})
+
+	candidates := make([]models.WordSuggestion, len(ranked))
+	byText := make(map[string]*merged, len(ranked))
+	for i, word := range ranked {
+		candidates[i] = models.WordSuggestion{Text: word.text, Confidence: word.score}
+		byText[word.text] = word
+	}
+	candidates = services.ApplySuggestFilters(tenant, candidates)
+	if len(candidates) > maxResults {
+		candidates = candidates[:maxResults]
+	}
+
+	suggestions := make([]map[string]interface{}, len(candidates))
+	for i, candidate := range candidates {
+		suggestions[i] = map[string]interface{}{
+			"text":       candidate.Text,
+			"confidence": candidate.Confidence,
+			"backends":   byText[candidate.Text].perBackend,
+		}
+	}
+	return suggestions, backendErrors, pending, nil
+}
+
+// queryBackend returns one backend's top words for the prefix
+func (s *AutocompleteService) queryBackend(ctx context.Context, name, prefix, audioID string, limit int) ([]models.WordSuggestion, error) {
+	switch name {
+	case backendRedis:
+		results, err := s.rankedPrefix(ctx, prefix, limit)
+		if err != nil {
+			return nil, err
+		}
+		suggestions := make([]models.WordSuggestion, len(results))
+		for i, result := range results {
+			suggestions[i] = models.WordSuggestion{Text: result.Member.(string), Confidence: result.Score}
+		}
+		return suggestions, nil
+	case backendTrie:
+		trie, err := s.clipTrie(ctx, audioID, prefix)
+		if err != nil {
+			return nil, err
+		}
+		return trie.SearchSuggestions(prefix, limit), nil
+	case backendDictionary:
+		return services.DictionaryMatches(prefix, limit)
+	case backendLLM:
+		return queryLLM(ctx, prefix, audioID, limit)
+	}
+	return nil, fmt.Errorf("unknown suggest backend %q", name)
+}
+
+// queryLLM asks the external completer at LLM_SUGGEST_URL for completions. It
+// is sent {"prefix", "audio_id", "max_results"} and must answer with
+// {"suggestions": [{"text", "confidence"}]}.
+func queryLLM(ctx context.Context, prefix, audioID string, limit int) ([]models.WordSuggestion, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"prefix":      prefix,
+		"audio_id":    audioID,
+		"max_results": limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, llmSuggestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := llmClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("llm completer returned status %d", response.StatusCode)
+	}
+
+	var decoded struct {
+		Suggestions []models.WordSuggestion `json:"suggestions"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode llm completions: %w", err)
+	}
+
+	// Only completions of what was typed are usable
+	suggestions := decoded.Suggestions[:0]
+	for _, suggestion := range decoded.Suggestions {
+		if strings.HasPrefix(strings.ToLower(suggestion.Text), strings.ToLower(prefix)) {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestParseBackends(t *testing.T) {
+	tests := []struct {
+		spec    string
+		weights map[string]float64
+		want    map[string]float64
+		wantErr bool
+	}{
+		{"", nil, map[string]float64{}, false},
+		{"redis", nil, map[string]float64{"redis": 1}, false},
+		{" redis , dictionary ,", nil, map[string]float64{"redis": 1, "dictionary": 0.3}, false},
+		{"trie:0.8,llm", nil, map[string]float64{"trie": 0.8, "llm": 0.5}, false},
+		{"llm", map[string]float64{"llm": 2}, map[string]float64{"llm": 2}, false},
+		{"llm:0.1", map[string]float64{"llm": 2}, map[string]float64{"llm": 0.1}, false},
+		{"redis,search", nil, nil, true},
+		{"trie:0", nil, nil, true},
+		{"trie:heavy", nil, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseBackends(tt.spec, tt.weights)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBackends(%q) error = %v, want error %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseBackends(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestConfigureSuggestBackends(t *testing.T) {
+	defer func(backends map[string]float64, url string) {
+		suggestBackends, llmSuggestURL = backends, url
+	}(suggestBackends, llmSuggestURL)
+
+	tests := []struct {
+		spec    string
+		url     string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{"", "", map[string]float64{"redis": 1}, false},
+		{"redis,trie", "", map[string]float64{"redis": 1, "trie": 1}, false},
+		{"redis,llm", "", nil, true},
+		{"redis,llm", "http://completer", map[string]float64{"redis": 1, "llm": 0.5}, false},
+		{"redis,unknown", "", nil, true},
+	}
+	for _, tt := range tests {
+		suggestBackends = map[string]float64{"redis": 1}
+		t.Setenv("LLM_SUGGEST_URL", tt.url)
+		err := configureSuggestBackends(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("configureSuggestBackends(%q) error = %v, want error %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			if !reflect.DeepEqual(suggestBackends, map[string]float64{"redis": 1}) {
+				t.Errorf("configureSuggestBackends(%q) changed the backends to %v despite the error", tt.spec, suggestBackends)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(suggestBackends, tt.want) {
+			t.Errorf("configureSuggestBackends(%q) = %v, want %v", tt.spec, suggestBackends, tt.want)
+		}
+	}
+}
+
+// llmCompleter serves the given completions, or fails with status when it is set
+func llmCompleter(t *testing.T, status int, completions []models.WordSuggestion) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"suggestions": completions})
+	}))
+	t.Cleanup(server.Close)
+
+	url := llmSuggestURL
+	llmSuggestURL = server.URL
+	t.Cleanup(func() { llmSuggestURL = url })
+}
+
+func TestQueryLLM(t *testing.T) {
+	completions := []models.WordSuggestion{
+		{Text: "Makan", Confidence: 0.9},
+		{Text: "tidur", Confidence: 0.8}, // Not a completion of the prefix
+		{Text: "makanan", Confidence: 0.6},
+		{Text: "makna", Confidence: 0.4},
+	}
+	tests := []struct {
+		status  int
+		limit   int
+		want    []string
+		wantErr bool
+	}{
+		{0, 5, []string{"Makan", "makanan", "makna"}, false},
+		{0, 2, []string{"Makan", "makanan"}, false},
+		{http.StatusServiceUnavailable, 5, nil, true},
+	}
+	for _, tt := range tests {
+		llmCompleter(t, tt.status, completions)
+		suggestions, err := queryLLM(context.Background(), "mak", "clip", tt.limit)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("status %d: queryLLM() error = %v, want error %v", tt.status, err, tt.wantErr)
+			continue
+		}
+		var got []string
+		for _, suggestion := range suggestions {
+			got = append(got, suggestion.Text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("status %d, limit %d: queryLLM() = %v, want %v", tt.status, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestGetMergedSuggestions(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "makan makna minum", ConfidenceScore: 0.8})
+	services.BuildAndCacheData("unscored", &models.AutocompleteData{FinalTranscription: "makan makna minum"})
+	llmCompleter(t, 0, []models.WordSuggestion{
+		{Text: "MAKAN", Confidence: 0.9},
+		{Text: "makanan", Confidence: 0.6},
+	})
+
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	ctx := context.Background()
+	trie, err := s.queryBackend(ctx, backendTrie, "mak", "clip", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trieScores := make(map[string]float64)
+	for _, suggestion := range trie {
+		trieScores[suggestion.Text] = suggestion.Confidence
+	}
+
+	tests := []struct {
+		name       string
+		audioID    string
+		backends   map[string]float64
+		want       map[string]map[string]float64 // Backend scores of each merged word
+		wantErrors []string
+		wantErr    bool
+	}{
+		{"trie and llm", "clip", map[string]float64{"trie": 1, "llm": 0.5}, map[string]map[string]float64{
+			"makan":   {"trie": trieScores["makan"], "llm": 0.9},
+			"makna":   {"trie": trieScores["makna"]},
+			"makanan": {"llm": 0.6},
+		}, nil, false},
+		{"words scored 0 keep their spelling", "unscored", map[string]float64{"trie": 1}, map[string]map[string]float64{
+			"makan": {"trie": 0},
+			"makna": {"trie": 0},
+		}, nil, false},
+		{"failed backend scores 0", "clip", map[string]float64{"redis": 1, "llm": 1}, map[string]map[string]float64{
+			"MAKAN":   {"llm": 0.9}, // Spelled as the backend it came from
+			"makanan": {"llm": 0.6},
+		}, []string{"redis"}, false},
+		{"every backend failed", "clip", map[string]float64{"redis": 1}, nil, []string{"redis"}, true},
+	}
+	for _, tt := range tests {
+		suggestions, backendErrors, pending, err := s.getMergedSuggestions(ctx, "", "mak", tt.audioID, tt.backends, 10)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		var failed []string
+		for name := range backendErrors {
+			failed = append(failed, name)
+		}
+		sort.Strings(failed)
+		if !reflect.DeepEqual(failed, tt.wantErrors) || len(pending) != 0 {
+			t.Errorf("%s: failed %v pending %v, want failed %v", tt.name, failed, pending, tt.wantErrors)
+		}
+		if tt.wantErr {
+			continue
+		}
+
+		var totalWeight float64
+		for _, weight := range tt.backends {
+			totalWeight += weight
+		}
+		got := make(map[string]map[string]float64)
+		previous := math.Inf(1)
+		for _, suggestion := range suggestions {
+			text := suggestion["text"].(string)
+			perBackend := suggestion["backends"].(map[string]float64)
+			got[text] = perBackend
+
+			var want float64
+			for name, score := range perBackend {
+				want += tt.backends[name] * score / totalWeight
+			}
+			confidence := suggestion["confidence"].(float64)
+			if math.Abs(confidence-want) > 1e-9 {
+				t.Errorf("%s: %s confidence = %v, want the weighted mean %v", tt.name, text, confidence, want)
+			}
+			if confidence > previous {
+				t.Errorf("%s: %s ranked below a lower score", tt.name, text)
+			}
+			previous = confidence
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: merged %v, want %v", tt.name, fmt.Sprint(got), fmt.Sprint(tt.want))
+		}
+	}
+}
+
+func TestParseSuggestBudget(t *testing.T) {
+	defer func(budget time.Duration) { suggestBudget = budget }(suggestBudget)
+	suggestBudget = 80 * time.Millisecond
+
+	tests := []struct {
+		param  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 80 * time.Millisecond, true},
+		{"25", 25 * time.Millisecond, true},
+		{"0", 0, false},
+		{"-5", 0, false},
+		{"fast", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSuggestBudget(tt.param)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseSuggestBudget(%q) = %v, %v, want %v, %v", tt.param, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestGetMergedSuggestionsPastBudget(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "makan makna minum", ConfidenceScore: 0.8})
+
+	// An LLM that answers long after the budget
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) // Lets the server notice the client giving up
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+	defer func(url string) { llmSuggestURL = url }(llmSuggestURL)
+	llmSuggestURL = server.URL
+
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	suggestions, backendErrors, pending, err := s.getMergedSuggestions(ctx, "", "mak", "clip", map[string]float64{"trie": 1, "llm": 1}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pending, []string{"llm"}) || len(backendErrors) != 0 {
+		t.Errorf("pending %v, errors %v, want only llm pending", pending, backendErrors)
+	}
+	var words []string
+	for _, suggestion := range suggestions {
+		words = append(words, suggestion["text"].(string))
+	}
+	sort.Strings(words)
+	if want := []string{"makan", "makna"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("merged %v, want the trie's %v", words, want)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runBench replays keystroke traces against a running instance. Each word of
+// the sample transcripts becomes one trace: the sequence of prefixes a user
+// produces while typing it, sent one after another like a real typist.
+func runBench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	target := flags.String("target", "http://localhost:8007", "base URL of the instance under test")
+	transcripts := flags.String("transcripts", "", "text file of sample transcripts, one per line (required)")
+	audioID := flags.String("audio-id", "", "clip to query (global when empty)")
+	concurrency := flags.Int("concurrency", 10, "number of simulated typists")
+	duration := flags.Duration("duration", 30*time.Second, "how long to run")
+	maxResults := flags.Int("max-results", 5, "max_results sent with each query")
+	flags.Parse(args)
+
+	if *transcripts == "" || *concurrency < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	traces, err := loadKeystrokeTraces(*transcripts)
+	if err != nil {
+		log.Fatalf("Failed to load transcripts: %v", err)
+	}
+	if len(traces) == 0 {
+		log.Fatalf("No words found in %s", *transcripts)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	deadline := time.Now().Add(*duration)
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		failures  int
+		wg        sync.WaitGroup
+	)
+
+	for worker := 0; worker < *concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			var local []time.Duration
+			localFailures := 0
+			for i := worker; time.Now().Before(deadline); i++ {
+				for _, prefix := range traces[i%len(traces)] {
+					query := url.Values{}
+					query.Set("prefix", prefix)
+					query.Set("max_results", fmt.Sprint(*maxResults))
+					if *audioID != "" {
+						query.Set("audio_id", *audioID)
+					}
+
+					start := time.Now()
+					resp, err := client.Get(*target + "/suggest/prefix?" + query.Encode())
+					if err != nil {
+						localFailures++
+						continue
+					}
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+					if resp.StatusCode != http.StatusOK {
+						localFailures++
+						continue
+					}
+					local = append(local, time.Since(start))
+				}
+			}
+
+			mu.Lock()
+			latencies = append(latencies, local...)
+			failures += localFailures
+			mu.Unlock()
+		}(worker)
+	}
+	wg.Wait()
+
+	reportBench(latencies, failures, *duration)
+}
+
+// loadKeystrokeTraces turns every word of the transcripts into its prefix sequence
+func loadKeystrokeTraces(path string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var traces [][]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		for _, word := range strings.Fields(scanner.Text()) {
+			runes := []rune(word)
+			trace := make([]string, 0, len(runes))
+			for i := 1; i <= len(runes); i++ {
+				trace = append(trace, string(runes[:i]))
+			}
+			traces = append(traces, trace)
+		}
+	}
+
+	return traces, scanner.Err()
+}
+
+// reportBench prints throughput and latency percentiles for the run
+func reportBench(latencies []time.Duration, failures int, duration time.Duration) {
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	total := len(latencies) + failures
+	fmt.Printf("requests:   %d (%d failed)\n", total, failures)
+	fmt.Printf("throughput: %.1f req/s\n", float64(total)/duration.Seconds())
+	if len(latencies) == 0 {
+		return
+	}
+	fmt.Printf("p50:        %v\n", percentile(latencies, 50))
+	fmt.Printf("p95:        %v\n", percentile(latencies, 95))
+	fmt.Printf("p99:        %v\n", percentile(latencies, 99))
+	fmt.Printf("max:        %v\n", latencies[len(latencies)-1])
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadKeystrokeTraces(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    [][]string
+	}{
+		{"one word", "makan\n", [][]string{{"m", "ma", "mak", "maka", "makan"}}},
+		{"words across lines", "saya  nak\n\nteh\n", [][]string{{"s", "sa", "say", "saya"}, {"n", "na", "nak"}, {"t", "te", "teh"}}},
+		{"multi-byte letters", "café", [][]string{{"c", "ca", "caf", "café"}}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "transcripts.txt")
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := loadKeystrokeTraces(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: loadKeystrokeTraces() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := loadKeystrokeTraces(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("loadKeystrokeTraces of a missing file returned no error")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		latencies []time.Duration
+		p         int
+		want      time.Duration
+	}{
+		{sorted, 0, 1},
+		{sorted, 50, 5},
+		{sorted, 51, 6},
+		{sorted, 90, 9},
+		{sorted, 99, 10},
+		{sorted, 100, 10},
+		{[]time.Duration{7}, 50, 7},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.latencies, tt.p); got != tt.want {
+			t.Errorf("percentile(%v, %d) = %v, want %v", tt.latencies, tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/services"
+)
+
+// blocklistUpdatesChannel carries the tenant whose blocklist changed, so every
+// replica reloads it from Redis immediately
+const blocklistUpdatesChannel = redisKeyPrefix + "blocklist:updates"
+
+// blocklistKey is the Redis set holding a tenant's blocked words
+func blocklistKey(tenant string) string {
+	return redisKeyPrefix + "blocklist:" + tenant
+}
+
+// requestTenant reads the tenant from the X-Tenant-ID header or tenant query parameter
+func requestTenant(c *gin.Context) string {
+	if tenant := c.GetHeader("X-Tenant-ID"); tenant != "" {
+		return tenant
+	}
+	return services.NormalizeTenant(c.Query("tenant"))
+}
+
+// loadBlocklists mirrors every tenant blocklist stored in Redis into memory
+func (s *AutocompleteService) loadBlocklists(ctx context.Context) error {
+	iter := s.RedisClient.Scan(ctx, 0, blocklistKey("*"), 1000).Iterator()
+	for iter.Next(ctx) {
+		tenant := strings.TrimPrefix(iter.Val(), blocklistKey(""))
+		if err := s.reloadBlocklist(ctx, tenant); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// reloadBlocklist refreshes one tenant's in-memory blocklist from Redis
+func (s *AutocompleteService) reloadBlocklist(ctx context.Context, tenant string) error {
+	words, err := s.RedisClient.SMembers(ctx, blocklistKey(tenant)).Result()
+	if err != nil {
+		return err
+	}
+	services.SetBlocklist(tenant, words)
+	return nil
+}
+
+// watchBlocklistUpdates reloads tenants announced on the updates channel until ctx ends
+func (s *AutocompleteService) watchBlocklistUpdates(ctx context.Context) {
+	pubsub := s.RedisClient.Subscribe(ctx, blocklistUpdatesChannel)
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		if err := s.reloadBlocklist(ctx, msg.Payload); err != nil {
+			log.Printf("Error reloading blocklist for tenant %s: %v", msg.Payload, err)
+		}
+	}
+}
+
+// publishBlocklistChange reloads the tenant locally and notifies the other replicas
+func (s *AutocompleteService) publishBlocklistChange(ctx context.Context, tenant string) error {
+	if err := s.reloadBlocklist(ctx, tenant); err != nil {
+		return err
+	}
+	return s.RedisClient.Publish(ctx, blocklistUpdatesChannel, tenant).Err()
+}
+
+func (s *AutocompleteService) handleGetBlocklist(c *gin.Context) {
+	tenant := c.Param("tenant")
+
+	words, err := s.RedisClient.SMembers(context.Background(), blocklistKey(tenant)).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tenant": tenant,
+		"words":  words,
+	})
+}
+
+func (s *AutocompleteService) handleAddBlocklistWords(c *gin.Context) {
+	tenant := c.Param("tenant")
+
+	var request struct {
+		Words []string `json:"words" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	members := make([]interface{}, 0, len(request.Words))
+	for _, word := range request.Words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			members = append(members, word)
+		}
+	}
+	if len(members) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no words given"})
+		return
+	}
+
+	ctx := context.Background()
+	added, err := s.RedisClient.SAdd(ctx, blocklistKey(tenant), members...).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.publishBlocklistChange(ctx, tenant); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tenant": tenant,
+		"added":  added,
+	})
+}
+
+func (s *AutocompleteService) handleDeleteBlocklistWord(c *gin.Context) {
+	tenant := c.Param("tenant")
+	ctx := context.Background()
+
+	removed, err := s.RedisClient.SRem(ctx, blocklistKey(tenant), strings.ToLower(c.Param("word"))).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "word is not on the blocklist"})
+		return
+	}
+	if err := s.publishBlocklistChange(ctx, tenant); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "removed"})
+}
+
+func (s *AutocompleteService) handleClearBlocklist(c *gin.Context) {
+	tenant := c.Param("tenant")
+	ctx := context.Background()
+
+	if err := s.RedisClient.Del(ctx, blocklistKey(tenant)).Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.publishBlocklistChange(ctx, tenant); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "cleared"})
+}
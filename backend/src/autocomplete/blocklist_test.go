@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		header string
+		query  string
+		want   string
+	}{
+		{"", "", "default"},
+		{"", "?tenant=school", "school"},
+		{"clinic", "?tenant=school", "clinic"},
+		{"clinic", "", "clinic"},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/suggest/prefix"+tt.query, nil)
+		if tt.header != "" {
+			c.Request.Header.Set("X-Tenant-ID", tt.header)
+		}
+		if got := requestTenant(c); got != tt.want {
+			t.Errorf("header %q query %q: requestTenant() = %q, want %q", tt.header, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestHandleAddBlocklistWordsRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin/blocklist/:tenant", (&AutocompleteService{RedisClient: unreachableRedis(t)}).handleAddBlocklistWords)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no body", ""},
+		{"no words field", `{}`},
+		{"only blank words", `{"words": ["", "  "]}`},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/admin/blocklist/school", strings.NewReader(tt.body)))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400: %s", tt.name, recorder.Code, recorder.Body)
+		}
+	}
+}
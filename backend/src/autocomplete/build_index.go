@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"autocomplete/services"
+)
+
+// runBuildIndex ingests a directory of saved orchestrator outputs offline. Each
+// <audio_id>.json file becomes one clip; its trie is written as a snapshot and,
+// with -redis, its words are stored in Redis exactly as /initialize would.
+func runBuildIndex(args []string) {
+	flags := flag.NewFlagSet("build-index", flag.ExitOnError)
+	inputDir := flags.String("input", "", "directory of orchestrator JSON outputs (required)")
+	outputDir := flags.String("output", "snapshots", "directory to write clip snapshots to")
+	writeRedis := flags.Bool("redis", false, "also store words in Redis (uses REDIS_URL)")
+	flags.Parse(args)
+
+	if err := services.ConfigureTextNormalization(os.Getenv("TEXT_NORMALIZATION")); err != nil {
+		log.Fatalf("Failed to configure text normalization: %v", err)
+	}
+	if err := services.ConfigurePIIRedaction(os.Getenv("PII_REDACTION"), os.Getenv("PII_RULES")); err != nil {
+		log.Fatalf("Failed to configure PII redaction: %v", err)
+	}
+
+	if *inputDir == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	files, err := filepath.Glob(filepath.Join(*inputDir, "*.json"))
+	if err != nil {
+		log.Fatalf("Failed to list input directory: %v", err)
+	}
+	if len(files) == 0 {
+		log.Fatalf("No orchestrator outputs found in %s", *inputDir)
+	}
+
+	if err := os.MkdirAll(*outputDir, 0o755); err != nil {
+		log.Fatalf("Failed to create output directory: %v", err)
+	}
+
+	ctx := context.Background()
+	var service *AutocompleteService
+	if *writeRedis {
+		service = &AutocompleteService{RedisClient: connectRedis(ctx), TopK: topKSetting()}
+	}
+
+	built, failed := 0, 0
+	for _, file := range files {
+		audioID := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			log.Printf("Skipping %s: %v", file, err)
+			failed++
+			continue
+		}
+		data, err := services.ParseOrchestratorOutput(raw)
+		if err != nil {
+			log.Printf("Skipping %s: %v", file, err)
+			failed++
+			continue
+		}
+
+		if service != nil {
+			if _, err := service.ingest(ctx, audioID, data); err != nil {
+				log.Printf("Skipping %s: %v", file, err)
+				failed++
+				continue
+			}
+		} else {
+			data, _ = services.RedactAutocompleteData(services.NormalizeAutocompleteData(data))
+			services.BuildAndCacheData(audioID, data)
+		}
+
+		snapshot, err := services.SnapshotClip(audioID)
+		if err != nil {
+			log.Printf("Skipping %s: %v", file, err)
+			failed++
+			continue
+		}
+		if err := services.WriteSnapshot(filepath.Join(*outputDir, audioID+".json"), snapshot); err != nil {
+			log.Printf("Skipping %s: %v", file, err)
+			failed++
+			continue
+		}
+
+		// Drop the clip once written so large corpora don't accumulate in memory
+		services.PurgeClip(audioID)
+		built++
+	}
+
+	log.Printf("Built %d clip indexes into %s (%d failed)", built, *outputDir, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// caretEdit is a token being edited with the caret inside it, e.g. "ter|ma":
+// left is the text before the caret and right the text after it, both normalized
+type caretEdit struct {
+	token string
+	left  string
+	right string
+	caret int
+}
+
+// parseCaretEdit reads the token and caret parameters. It returns nil when no
+// token is given; the caret is a rune offset and defaults to the token's end.
+func parseCaretEdit(token, caretParam string) (*caretEdit, error) {
+	if token == "" {
+		if caretParam != "" {
+			return nil, fmt.Errorf("caret requires token")
+		}
+		return nil, nil
+	}
+
+	runes := []rune(token)
+	caret := len(runes)
+	if caretParam != "" {
+		parsed, err := strconv.Atoi(caretParam)
+		if err != nil || parsed < 0 || parsed > len(runes) {
+			return nil, fmt.Errorf("caret must be between 0 and %d", len(runes))
+		}
+		caret = parsed
+	}
+
+	edit := &caretEdit{
+		left:  services.NormalizeQuery(string(runes[:caret])),
+		right: services.NormalizeText(string(runes[caret:])),
+	}
+	edit.token = edit.left + edit.right
+	edit.caret = utf8.RuneCountInString(edit.left)
+	if edit.token == "" {
+		return nil, fmt.Errorf("token parameter required")
+	}
+	return edit, nil
+}
+
+// caretCandidates bounds how many words under the text before the caret are read
+const caretCandidates = 200
+
+// getCaretSuggestions proposes words for a token edited at the caret. Candidates
+// keep the text before the caret. A word that only inserts text at the caret,
+// such as "terima" for "ter|ma", matches outright; other words are scored by
+// the weighted edit distance between the text after the caret and the rest of
+// the word, within the fuzzy typo budget. Each suggestion carries the caret
+// position to restore after replacing the token with it.
+func (s *AutocompleteService) getCaretSuggestions(ctx context.Context, tenant string, edit *caretEdit, layout *services.KeyboardLayout, maxResults int) ([]map[string]interface{}, error) {
+	lookup := edit.left
+	if lookup == "" {
+		first, _ := utf8.DecodeRuneInString(edit.right)
+		lookup = string(first)
+	}
+
+	candidates, err := s.readClient().ZRevRangeWithScores(ctx, indexedPrefixKey(lookup), 0, caretCandidates-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	matches, carets := matchCaretEdit(edit, candidates, layout)
+	if fetch := suggestFetchCount(tenant, maxResults); len(matches) > fetch {
+		matches = matches[:fetch]
+	}
+
+	suggestions := formatSuggestions(tenant, matches, maxResults)
+	for _, suggestion := range suggestions {
+		suggestion["caret"] = carets[suggestion["text"].(string)]
+	}
+	return suggestions, nil
+}
+
+// matchCaretEdit keeps the candidates that fit the edit, best first, with the
+// caret position of each
+func matchCaretEdit(edit *caretEdit, candidates []redis.Z, layout *services.KeyboardLayout) ([]redis.Z, map[string]int) {
+	budget := services.MaxFuzzyDistance(edit.token)
+	carets := make(map[string]int)
+	var matches []redis.Z
+	for _, candidate := range candidates {
+		word := candidate.Member.(string)
+		if !strings.HasPrefix(word, edit.left) {
+			continue
+		}
+		rest := strings.TrimPrefix(word, edit.left)
+
+		distance := 0.0
+		caret := utf8.RuneCountInString(word)
+		if strings.HasSuffix(rest, edit.right) {
+			caret -= utf8.RuneCountInString(edit.right)
+		} else if distance = services.EditDistance(edit.right, rest, layout); distance > budget {
+			continue
+		}
+
+		carets[word] = caret
+		matches = append(matches, redis.Z{Member: word, Score: candidate.Score / (1 + distance)})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches, carets
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+func TestParseCaretEdit(t *testing.T) {
+	tests := []struct {
+		token, caret string
+		want         *caretEdit
+		wantErr      bool
+	}{
+		{"", "", nil, false},
+		{"", "2", nil, true},
+		{"terma", "3", &caretEdit{token: "terma", left: "ter", right: "ma", caret: 3}, false},
+		{"terma", "", &caretEdit{token: "terma", left: "terma", right: "", caret: 5}, false},
+		{"terma", "0", &caretEdit{token: "terma", left: "", right: "terma", caret: 0}, false},
+		{"kuéh", "3", &caretEdit{token: "kuéh", left: "kué", right: "h", caret: 3}, false},
+		{"terma", "6", nil, true},
+		{"terma", "-1", nil, true},
+		{"terma", "end", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseCaretEdit(tt.token, tt.caret)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseCaretEdit(%q, %q) = %+v, %v, want %+v (error %v)", tt.token, tt.caret, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestMatchCaretEdit(t *testing.T) {
+	layout, _ := services.KeyboardLayoutFor(services.KeyboardQWERTY)
+	candidates := []redis.Z{
+		{Member: "terima", Score: 0.9},
+		{Member: "teruk", Score: 0.8},
+		{Member: "tersenyum", Score: 0.7},
+		{Member: "makan", Score: 0.6},
+		{Member: "terma", Score: 0.5},
+	}
+
+	tests := []struct {
+		name       string
+		edit       *caretEdit
+		want       []string
+		wantCarets []int
+	}{
+		// "ter|ma": inserting at the caret matches outright, a two letter typo
+		// after it fits the budget at a third of its score
+		{"mid-word", &caretEdit{token: "terma", left: "ter", right: "ma", caret: 3},
+			[]string{"terima", "terma", "teruk"}, []int{4, 3, 5}},
+		{"caret at the end", &caretEdit{token: "ter", left: "ter", right: "", caret: 3},
+			[]string{"terima", "teruk", "tersenyum", "terma"}, []int{6, 5, 9, 5}},
+		{"caret at the start", &caretEdit{token: "ma", left: "", right: "ma", caret: 0},
+			[]string{"terima", "terma"}, []int{4, 3}},
+		{"nothing fits", &caretEdit{token: "xyzzy", left: "xy", right: "zzy", caret: 2}, nil, nil},
+	}
+	for _, tt := range tests {
+		matches, carets := matchCaretEdit(tt.edit, candidates, layout)
+		var got []string
+		var gotCarets []int
+		for _, match := range matches {
+			got = append(got, match.Member.(string))
+			gotCarets = append(gotCarets, carets[match.Member.(string)])
+		}
+		if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(gotCarets, tt.wantCarets) {
+			t.Errorf("%s: matchCaretEdit() = %v at %v, want %v at %v", tt.name, got, gotCarets, tt.want, tt.wantCarets)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// caseFoldKeyPrefix indexes words in their original case by the lower-cased
+// prefixes of the word, so one lookup finds every casing of a prefix
+const caseFoldKeyPrefix = redisKeyPrefix + "casefold:"
+
+// caseFetch is the minimum number of case-folded matches read before ranking,
+// so exact-case words can climb past more frequent words of another case
+const caseFetch = 20
+
+// getCaseSensitiveSuggestions returns words starting with the prefix in any
+// case, ranking the ones that match the typed capitalization first, so "Ma"
+// puts "Malaysia" ahead of "makan" and "ma" does the reverse. Each suggestion
+// reports whether it matched the case in "case_match".
+func (s *AutocompleteService) getCaseSensitiveSuggestions(ctx context.Context, tenant, prefix string, maxResults int) ([]map[string]interface{}, error) {
+	fetch := suggestFetchCount(tenant, maxResults)
+	if fetch < caseFetch {
+		fetch = caseFetch
+	}
+
+	key := caseFoldKeyPrefix + indexedRunes(strings.ToLower(prefix))
+	results, err := s.readClient().ZRevRangeWithScores(ctx, key, 0, int64(fetch-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := formatSuggestions(tenant, rankByCase(results, prefix), maxResults)
+	for _, suggestion := range suggestions {
+		suggestion["case_match"] = strings.HasPrefix(suggestion["text"].(string), prefix)
+	}
+	return suggestions, nil
+}
+
+// rankByCase keeps the results starting with prefix in any case, moving those
+// that match its capitalization ahead of the rest. The index only goes
+// prefixIndexDepth characters deep, so longer prefixes are checked against the
+// whole word here.
+func rankByCase(results []redis.Z, prefix string) []redis.Z {
+	folded := strings.ToLower(prefix)
+	matched := results[:0]
+	for _, result := range results {
+		if strings.HasPrefix(strings.ToLower(result.Member.(string)), folded) {
+			matched = append(matched, result)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return strings.HasPrefix(matched[i].Member.(string), prefix) && !strings.HasPrefix(matched[j].Member.(string), prefix)
+	})
+	return matched
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestRankByCase(t *testing.T) {
+	results := func() []redis.Z {
+		return []redis.Z{
+			{Member: "makan", Score: 0.9},
+			{Member: "Malaysia", Score: 0.8},
+			{Member: "MALAM", Score: 0.7},
+			{Member: "malaysian", Score: 0.6},
+		}
+	}
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"ma", []string{"makan", "malaysian", "Malaysia", "MALAM"}},
+		{"Ma", []string{"Malaysia", "makan", "MALAM", "malaysian"}},
+		{"MA", []string{"MALAM", "makan", "Malaysia", "malaysian"}},
+		{"Malaysia", []string{"Malaysia", "malaysian"}}, // Past the index depth
+		{"x", []string{}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, result := range rankByCase(results(), tt.prefix) {
+			got = append(got, result.Member.(string))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rankByCase(%q) = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestPlanBatchIndexesCaseFolded(t *testing.T) {
+	plan := planBatch([]wordWrite{{word: "Malaysia", confidence: 0.8}, {word: "makan", confidence: 0.9}})
+	groups := plan.groupsByFamily[caseFoldKeyPrefix]
+
+	tests := []struct {
+		key  string
+		want map[string]float64
+	}{
+		{"m", map[string]float64{"Malaysia": 0.8, "makan": 0.9}},
+		{"mal", map[string]float64{"Malaysia": 0.8}},
+		{"Mal", nil},
+	}
+	for _, tt := range tests {
+		if got := groups[tt.key]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("case-folded members of %q = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+	if _, exists := plan.prefixMembers["Mal"]; !exists {
+		t.Error("the prefix index lost the word's own casing")
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+)
+
+// Size limits for one /initialize or chunk request, set from MAX_INITIALIZE_WORDS
+// and MAX_INITIALIZE_BYTES in runServer
+var (
+	maxInitializeWords       = 5000
+	maxInitializeBytes int64 = 1 << 20
+)
+
+// chunkJobTTL bounds how long a chunked upload may take before its parts are dropped
+const chunkJobTTL = time.Hour
+
+// chunkJobKey holds a chunked upload's parts, one field per sequence number
+func chunkJobKey(jobID string) string {
+	return redisKeyPrefix + "job:" + jobID + ":chunks"
+}
+
+// ingestChunk is one part of a long recording's transcription
+type ingestChunk struct {
+	JobID              string            `json:"job_id"`
+	AudioID            string            `json:"audio_id"`
+	Seq                int               `json:"seq"`
+	Total              int               `json:"total"`
+	FinalTranscription string            `json:"final_transcription"`
+	ConfidenceScore    float64           `json:"confidence_score"`
+	DetectedParticles  []string          `json:"detected_particles"`
+	AsrAlternatives    map[string]string `json:"asr_alternatives"`
+}
+
+// limitRequestBody caps the body so an oversized payload fails while decoding
+// instead of being buffered whole
+func limitRequestBody(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxInitializeBytes)
+}
+
+// bindErrorStatus maps a body decoding error onto 413 when the size cap was hit
+func bindErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// checkTranscriptSize rejects payloads whose transcription, any alternative or
+// particle list holds more than maxInitializeWords words
+func checkTranscriptSize(data *models.AutocompleteData) error {
+	alternatives := make(map[string]int, len(data.ASRAlternatives))
+	for model, transcription := range data.ASRAlternatives {
+		alternatives[model] = len(strings.Fields(transcription))
+	}
+	return checkWordCounts(len(strings.Fields(data.FinalTranscription)), alternatives, len(data.DetectedParticles))
+}
+
+// checkWordCounts applies checkTranscriptSize's limits to the word counts of a
+// payload, e.g. one assembled from streamed records
+func checkWordCounts(transcript int, alternatives map[string]int, particles int) error {
+	if transcript > maxInitializeWords {
+		return fmt.Errorf("final_transcription has %d words, limit is %d; use /initialize/chunks for long recordings", transcript, maxInitializeWords)
+	}
+	for model, n := range alternatives {
+		if n > maxInitializeWords {
+			return fmt.Errorf("%s alternative has %d words, limit is %d; use /initialize/chunks for long recordings", model, n, maxInitializeWords)
+		}
+	}
+	if particles > maxInitializeWords {
+		return fmt.Errorf("detected_particles has %d entries, limit is %d", particles, maxInitializeWords)
+	}
+	return nil
+}
+
+// handleInitializeChunk stores one part of a chunked upload. The request that
+// delivers the last missing part assembles the job and initializes the clip;
+// earlier parts are acknowledged with 202.
+func (s *AutocompleteService) handleInitializeChunk(c *gin.Context) {
+	var chunk ingestChunk
+	limitRequestBody(c)
+	if err := c.ShouldBindJSON(&chunk); err != nil {
+		c.JSON(bindErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if chunk.JobID == "" || chunk.Total <= 0 || chunk.Seq < 0 || chunk.Seq >= chunk.Total {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "job_id, total > 0 and 0 <= seq < total are required"})
+		return
+	}
+	if err := checkTranscriptSize(&models.AutocompleteData{
+		FinalTranscription: chunk.FinalTranscription,
+		DetectedParticles:  chunk.DetectedParticles,
+		ASRAlternatives:    chunk.AsrAlternatives,
+	}); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+
+	encoded, err := json.Marshal(chunk)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Storing the part and counting the parts in one transaction means exactly
+	// one request observes the job complete, even across replicas
+	ctx := context.Background()
+	key := chunkJobKey(chunk.JobID)
+	pipe := s.RedisClient.TxPipeline()
+	stored := pipe.HSetNX(ctx, key, strconv.Itoa(chunk.Seq), encoded)
+	received := pipe.HLen(ctx, key)
+	pipe.Expire(ctx, key, chunkJobTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !stored.Val() || received.Val() < int64(chunk.Total) {
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":    "pending",
+			"job_id":    chunk.JobID,
+			"received":  received.Val(),
+			"total":     chunk.Total,
+			"duplicate": !stored.Val(),
+		})
+		return
+	}
+
+	data, audioID, err := s.assembleChunks(ctx, chunk.JobID, chunk.Total)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.RedisClient.Del(ctx, key)
+
+	s.initializeClip(c, audioID, data)
+}
+
+// assembleChunks reads a job's parts and joins them into one payload
+func (s *AutocompleteService) assembleChunks(ctx context.Context, jobID string, total int) (*models.AutocompleteData, string, error) {
+	fields, err := s.RedisClient.HGetAll(ctx, chunkJobKey(jobID)).Result()
+	if err != nil {
+		return nil, "", err
+	}
+	return joinChunks(jobID, total, fields)
+}
+
+// joinChunks joins a job's stored parts in sequence order. The confidence is
+// the word-weighted mean of the parts' confidences.
+func joinChunks(jobID string, total int, fields map[string]string) (*models.AutocompleteData, string, error) {
+	chunks := make([]ingestChunk, 0, len(fields))
+	for _, encoded := range fields {
+		var chunk ingestChunk
+		if err := json.Unmarshal([]byte(encoded), &chunk); err != nil {
+			return nil, "", fmt.Errorf("corrupt chunk in job %s: %w", jobID, err)
+		}
+		if chunk.Total != total {
+			return nil, "", fmt.Errorf("job %s chunks disagree on total (%d vs %d)", jobID, chunk.Total, total)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) == 0 {
+		return nil, "", fmt.Errorf("job %s has no chunks", jobID)
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].Seq < chunks[j].Seq })
+
+	audioID := chunks[0].AudioID
+	var transcription []string
+	alternatives := make(map[string][]string)
+	particles := []string{}
+	seenParticles := make(map[string]bool)
+	var weightedConfidence float64
+	var words int
+
+	for _, chunk := range chunks {
+		if chunk.AudioID != audioID {
+			return nil, "", fmt.Errorf("job %s chunks disagree on audio_id", jobID)
+		}
+		if chunk.FinalTranscription != "" {
+			transcription = append(transcription, chunk.FinalTranscription)
+		}
+		n := len(strings.Fields(chunk.FinalTranscription))
+		weightedConfidence += chunk.ConfidenceScore * float64(n)
+		words += n
+
+		for model, alternative := range chunk.AsrAlternatives {
+			if alternative != "" {
+				alternatives[model] = append(alternatives[model], alternative)
+			}
+		}
+		for _, particle := range chunk.DetectedParticles {
+			if !seenParticles[particle] {
+				seenParticles[particle] = true
+				particles = append(particles, particle)
+			}
+		}
+	}
+
+	data := &models.AutocompleteData{
+		FinalTranscription: strings.Join(transcription, " "),
+		DetectedParticles:  particles,
+		ASRAlternatives:    make(map[string]string, len(alternatives)),
+	}
+	if words > 0 {
+		data.ConfidenceScore = weightedConfidence / float64(words)
+	}
+	for model, parts := range alternatives {
+		data.ASRAlternatives[model] = strings.Join(parts, " ")
+	}
+
+	return data, audioID, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+)
+
+func TestCheckTranscriptSize(t *testing.T) {
+	defer func(words int) { maxInitializeWords = words }(maxInitializeWords)
+	maxInitializeWords = 3
+
+	tests := []struct {
+		name    string
+		data    models.AutocompleteData
+		wantErr string
+	}{
+		{"within the limit", models.AutocompleteData{FinalTranscription: "saya nak makan", DetectedParticles: []string{"lah"}}, ""},
+		{"long transcription", models.AutocompleteData{FinalTranscription: "saya nak makan nasi"}, "final_transcription has 4 words"},
+		{"long alternative", models.AutocompleteData{ASRAlternatives: map[string]string{"whisper": "a b c d"}}, "whisper alternative has 4 words"},
+		{"many particles", models.AutocompleteData{DetectedParticles: []string{"lah", "kan", "pun", "eh"}}, "detected_particles has 4 entries"},
+	}
+	for _, tt := range tests {
+		err := checkTranscriptSize(&tt.data)
+		if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: checkTranscriptSize() = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func encodedChunk(t *testing.T, chunk ingestChunk) string {
+	t.Helper()
+	encoded, err := json.Marshal(chunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(encoded)
+}
+
+func TestJoinChunks(t *testing.T) {
+	first := ingestChunk{AudioID: "clip", Seq: 0, Total: 2, FinalTranscription: "saya nak", ConfidenceScore: 0.9,
+		DetectedParticles: []string{"lah"}, AsrAlternatives: map[string]string{"whisper": "saya na"}}
+	second := ingestChunk{AudioID: "clip", Seq: 1, Total: 2, FinalTranscription: "makan nasi lemak", ConfidenceScore: 0.4,
+		DetectedParticles: []string{"lah", "kan"}, AsrAlternatives: map[string]string{"whisper": "makan nasi", "wav2vec": "makan"}}
+
+	fields := map[string]string{"1": encodedChunk(t, second), "0": encodedChunk(t, first)}
+	data, audioID, err := joinChunks("job", 2, fields)
+	if err != nil {
+		t.Fatalf("joinChunks() error: %v", err)
+	}
+	want := &models.AutocompleteData{
+		FinalTranscription: "saya nak makan nasi lemak",
+		ConfidenceScore:    (0.9*2 + 0.4*3) / 5,
+		DetectedParticles:  []string{"lah", "kan"},
+		ASRAlternatives:    map[string]string{"whisper": "saya na makan nasi", "wav2vec": "makan"},
+	}
+	if audioID != "clip" || !reflect.DeepEqual(data, want) {
+		t.Errorf("joinChunks() = %q %+v, want clip %+v", audioID, data, want)
+	}
+
+	other := second
+	other.AudioID = "other"
+	tests := []struct {
+		name   string
+		fields map[string]string
+	}{
+		{"no chunks", map[string]string{}},
+		{"corrupt chunk", map[string]string{"0": encodedChunk(t, first), "1": "{"}},
+		{"totals disagree", map[string]string{"0": encodedChunk(t, first), "1": encodedChunk(t, ingestChunk{AudioID: "clip", Seq: 1, Total: 3})}},
+		{"audio ids disagree", map[string]string{"0": encodedChunk(t, first), "1": encodedChunk(t, other)}},
+	}
+	for _, tt := range tests {
+		if _, _, err := joinChunks("job", 2, tt.fields); err == nil {
+			t.Errorf("%s: joinChunks() succeeded", tt.name)
+		}
+	}
+}
+
+func TestHandleInitializeChunkRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer func(words int, bytes int64) { maxInitializeWords, maxInitializeBytes = words, bytes }(maxInitializeWords, maxInitializeBytes)
+	maxInitializeWords, maxInitializeBytes = 3, 256
+
+	s := &AutocompleteService{}
+	router := gin.New()
+	router.POST("/initialize/chunks", s.handleInitializeChunk)
+
+	chunk := func(jobID string, seq, total int, transcription string) string {
+		return fmt.Sprintf(`{"job_id":%q,"audio_id":"clip","seq":%d,"total":%d,"final_transcription":%q}`, jobID, seq, total, transcription)
+	}
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"not JSON", "{", http.StatusBadRequest},
+		{"no job", chunk("", 0, 1, "makan"), http.StatusBadRequest},
+		{"no total", chunk("job", 0, 0, "makan"), http.StatusBadRequest},
+		{"seq past total", chunk("job", 2, 2, "makan"), http.StatusBadRequest},
+		{"negative seq", chunk("job", -1, 2, "makan"), http.StatusBadRequest},
+		{"too many words", chunk("job", 0, 2, "saya nak makan nasi"), http.StatusRequestEntityTooLarge},
+		{"body too large", chunk("job", 0, 2, strings.Repeat("a", 300)), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/initialize/chunks", strings.NewReader(tt.body)))
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.want, w.Body)
+		}
+	}
+}
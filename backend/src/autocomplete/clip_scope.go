@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Suggestion spaces a plain prefix lookup can answer from
+const (
+	scopeClip   = "clip"
+	scopeGlobal = "global"
+)
+
+// defaultSuggestScope is the scope of lookups naming an audio_id without a
+// scope parameter, from SUGGEST_SCOPE (default clip)
+var defaultSuggestScope = func() string {
+	if scope := os.Getenv("SUGGEST_SCOPE"); scope == scopeGlobal {
+		return scopeGlobal
+	}
+	return scopeClip
+}()
+
+// clipTopKKey is a stateless clip's own prefix index: one packed top-k field
+// per prefix of its words, like the global read model in topKKey
+func clipTopKKey(audioID string) string { return clipKeyPrefix(audioID) + "topk" }
+
+// clipTopKSize is how many words each prefix of a clip's index keeps: the
+// largest max_results, with room for suggest filters to drop some
+const clipTopKSize = 2 * services.MaxSuggestResults
+
+// suggestScope is a lookup's suggestion space, whether it was asked for, and
+// whether a clip-scoped lookup tops up its results from the global pool
+type suggestScope struct {
+	clip      bool
+	requested bool
+	fallback  bool
+}
+
+// parseSuggestScope reads scope and fallback. Lookups naming an audio_id
+// default to the clip's own words; lookups without one always use the global
+// pool.
+func parseSuggestScope(c *gin.Context) (suggestScope, error) {
+	scope := c.Query("scope")
+	requested := scope != ""
+	if !requested {
+		scope = scopeGlobal
+		if c.Query("audio_id") != "" {
+			scope = defaultSuggestScope
+		}
+	}
+	if scope != scopeClip && scope != scopeGlobal {
+		return suggestScope{}, fmt.Errorf("scope must be clip or global")
+	}
+	if scope == scopeClip && c.Query("audio_id") == "" {
+		return suggestScope{}, fmt.Errorf("scope=clip requires audio_id")
+	}
+
+	fallback := c.Query("fallback")
+	if fallback != "" && fallback != scopeGlobal {
+		return suggestScope{}, fmt.Errorf("fallback must be global")
+	}
+	if fallback != "" && scope != scopeClip {
+		return suggestScope{}, fmt.Errorf("fallback=global requires scope=clip")
+	}
+	return suggestScope{clip: scope == scopeClip, requested: requested, fallback: fallback == scopeGlobal}, nil
+}
+
+// getClipScopedSuggestions answers a prefix from the clip's own words only,
+// so one recording's vocabulary never bleeds into another's. With fallback,
+// slots the clip can't fill come from the global pool, and a clip that isn't
+// initialized answers from the global pool alone.
+func (s *AutocompleteService) getClipScopedSuggestions(ctx context.Context, tenant, prefix, audioID string, fallback bool, maxResults int) ([]map[string]interface{}, error) {
+	ranked, err := s.rankedClipPrefix(ctx, audioID, prefix, suggestFetchCount(tenant, maxResults))
+	if err != nil {
+		if fallback {
+			return s.getPrefixSuggestions(ctx, tenant, prefix, maxResults)
+		}
+		return nil, err
+	}
+
+	ranked = services.ApplySuggestFilters(tenant, ranked)
+	if len(ranked) > maxResults {
+		ranked = ranked[:maxResults]
+	}
+	suggestions := make([]map[string]interface{}, 0, maxResults)
+	seen := make(map[string]bool, len(ranked))
+	for _, suggestion := range ranked {
+		seen[strings.ToLower(suggestion.Text)] = true
+		suggestions = append(suggestions, map[string]interface{}{
+			"text":       suggestion.Text,
+			"confidence": suggestion.Confidence,
+			"scope":      scopeClip,
+		})
+	}
+	if !fallback || len(suggestions) >= maxResults {
+		return suggestions, nil
+	}
+
+	global, err := s.getPrefixSuggestions(ctx, tenant, prefix, maxResults)
+	if err != nil {
+		return nil, err
+	}
+	for _, suggestion := range global {
+		if len(suggestions) >= maxResults {
+			break
+		}
+		text, _ := suggestion["text"].(string)
+		if seen[strings.ToLower(text)] {
+			continue
+		}
+		seen[strings.ToLower(text)] = true
+		suggestion["scope"] = scopeGlobal
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, nil
+}
+
+// rankedClipPrefix returns up to count of the clip's words starting with
+// prefix, best first: from its trie, or from its prefix index when stateless.
+// Prefixes deeper than the index are filtered from their deepest indexed prefix.
+func (s *AutocompleteService) rankedClipPrefix(ctx context.Context, audioID, prefix string, count int) ([]models.WordSuggestion, error) {
+	if !s.Stateless {
+		trie, err := services.GetPrefixTrie(audioID)
+		if err != nil {
+			return nil, err
+		}
+		return bestPerWord(trie.SearchSuggestions(prefix, count)), nil
+	}
+
+	audioID = services.NormalizeAudioID(audioID)
+	s.touchClip(audioID)
+	pipe := s.clipReadClient(audioID).Pipeline()
+	exists := pipe.Exists(ctx, clipTopKKey(audioID))
+	packed := pipe.HGet(ctx, clipTopKKey(audioID), indexedRunes(prefix))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if exists.Val() == 0 {
+		return nil, fmt.Errorf("%w for clip %s, please initialize first", services.ErrClipNotInitialized, audioID)
+	}
+
+	var ranked []models.WordSuggestion
+	for _, result := range unpackTopK(packed.Val()) {
+		if len(ranked) >= count {
+			break
+		}
+		if word := result.Member.(string); strings.HasPrefix(word, prefix) {
+			ranked = append(ranked, models.WordSuggestion{Text: word, Confidence: result.Score})
+		}
+	}
+	return ranked, nil
+}
+
+// clipTopKFields builds a clip's prefix index from each word's suggestions:
+// every prefix of a word, up to prefixIndexDepth runes, lists it with its best
+// confidence
+func clipTopKFields(words map[string][]models.WordSuggestion) map[string]interface{} {
+	byPrefix := make(map[string][]models.WordSuggestion)
+	for word, suggestions := range words {
+		best := bestPerWord(suggestions)
+		if len(best) == 0 {
+			continue
+		}
+		runes := []rune(indexedRunes(word))
+		for i := 1; i <= len(runes); i++ {
+			byPrefix[string(runes[:i])] = append(byPrefix[string(runes[:i])], best[0])
+		}
+	}
+
+	fields := make(map[string]interface{}, len(byPrefix))
+	for prefix, suggestions := range byPrefix {
+		ranked := bestPerWord(suggestions)
+		if len(ranked) > clipTopKSize {
+			ranked = ranked[:clipTopKSize]
+		}
+		results := make([]redis.Z, len(ranked))
+		for i, suggestion := range ranked {
+			results[i] = redis.Z{Member: suggestion.Text, Score: suggestion.Confidence}
+		}
+		fields[prefix] = packTopK(results)
+	}
+	return fields
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// clipBuildKey records how long the clip's last index build took and when it
+// finished
+func clipBuildKey(audioID string) string { return clipKeyPrefix(audioID) + "build" }
+
+// recordClipBuild stores the duration of an index build of the clip
+func (s *AutocompleteService) recordClipBuild(ctx context.Context, audioID string, duration time.Duration) error {
+	pipe := s.clipClient(audioID).Pipeline()
+	pipe.HSet(ctx, clipBuildKey(audioID), "duration_ms", float64(duration.Microseconds())/1000, "built_at", time.Now().Unix())
+	pipe.Expire(ctx, clipBuildKey(audioID), clipIndexTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// handleClipStats characterizes a clip without exporting it: word count,
+// vocabulary size, per-model coverage, mean agreement, particle count and how
+// long its index took to build
+func (s *AutocompleteService) handleClipStats(c *gin.Context) {
+	audioID := services.NormalizeAudioID(c.Param("audio_id"))
+	ctx := context.Background()
+
+	positionMap, err := s.clipPositionMap(ctx, audioID)
+	if err == errClipNotIndexed || err != nil && !s.Stateless {
+		c.JSON(http.StatusNotFound, gin.H{"error": "autocomplete not initialized for clip " + audioID + ", please initialize first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	particles, err := services.ParticleLexicon()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"audio_id": audioID,
+		"stats":    services.ClipStats(positionMap, particles),
+	}
+
+	// Builds from before build times were recorded, or restored from a snapshot, have none
+	build, err := s.clipClient(audioID).HGetAll(ctx, clipBuildKey(audioID)).Result()
+	if err != nil && err != redis.Nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(build) > 0 {
+		duration, _ := strconv.ParseFloat(build["duration_ms"], 64)
+		builtAt, _ := strconv.ParseInt(build["built_at"], 10, 64)
+		response["build"] = gin.H{"duration_ms": duration, "built_at": time.Unix(builtAt, 0).UTC()}
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleClipStatsWithoutRedis(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "pergi lah", ConfidenceScore: 0.9})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/clips/:audio_id/stats", (&AutocompleteService{RedisClient: unreachableRedis(t)}).handleClipStats)
+	stateless := gin.New()
+	stateless.GET("/clips/:audio_id/stats", (&AutocompleteService{Stateless: true, RedisClient: unreachableRedis(t)}).handleClipStats)
+
+	tests := []struct {
+		name   string
+		router *gin.Engine
+		path   string
+		want   int
+	}{
+		{"unknown clip", router, "/clips/other/stats", http.StatusNotFound},
+		// The clip is cached, but its build time lives in Redis
+		{"cached clip", router, "/clips/clip/stats", http.StatusInternalServerError},
+		{"stateless", stateless, "/clips/clip/stats", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		tt.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
+
+func TestRecordClipBuildWithoutRedis(t *testing.T) {
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	if err := s.recordClipBuild(context.Background(), "clip", time.Second); err == nil {
+		t.Error("recordClipBuild() succeeded without Redis")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// clipCreatedKey expires when a clip's sliding expiration runs out: at the
+// TTL cap after its last initialize
+func clipCreatedKey(audioID string) string { return clipKeyPrefix(audioID) + "created" }
+
+// clipTouches slides the expiration of clips while they are queried. Each
+// replica refreshes a clip's TTLs at most once per interval.
+type clipTouches struct {
+	cap      time.Duration // 0 slides without a cap, while clips are archived instead
+	interval time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// newClipTouches slides clip expiration up to ttlCap after initialize. A cap of
+// 0 keeps fixed expiration, unless the clips are archived: then it slides uncapped.
+func newClipTouches(ttlCap, interval time.Duration, archived bool) *clipTouches {
+	if ttlCap <= 0 && !archived {
+		return nil
+	}
+	if archived {
+		ttlCap = 0
+	}
+	return &clipTouches{cap: ttlCap, interval: interval, last: make(map[string]time.Time)}
+}
+
+// due reports whether the clip's TTLs should be refreshed now, and if so
+// records the refresh
+func (t *clipTouches) due(audioID string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, exists := t.last[audioID]; exists && now.Sub(last) < t.interval {
+		return false
+	}
+	if len(t.last) >= maxTrackedTouches {
+		for id, last := range t.last {
+			if now.Sub(last) >= t.interval {
+				delete(t.last, id)
+			}
+		}
+	}
+	t.last[audioID] = now
+	return true
+}
+
+// maxTrackedTouches bounds the refresh times kept before stale ones are dropped
+const maxTrackedTouches = 10000
+
+// touchClip pushes back the expiration of a queried clip's keys to
+// clipIndexTTL from now, but never past the TTL cap. The refresh runs in the
+// background so typing never waits on it.
+func (s *AutocompleteService) touchClip(audioID string) {
+	if s.touches == nil || audioID == "" {
+		return
+	}
+	audioID = services.NormalizeAudioID(audioID)
+	if !s.touches.due(audioID, time.Now()) {
+		return
+	}
+	go func() {
+		if err := s.refreshClipTTLs(context.Background(), audioID); err != nil {
+			log.Printf("Error refreshing TTLs of clip %s: %v", audioID, err)
+		}
+	}()
+}
+
+// refreshClipTTLs applies one sliding-expiration refresh. Clips initialized
+// before the cap was configured start their cap window now.
+func (s *AutocompleteService) refreshClipTTLs(ctx context.Context, audioID string) error {
+	client := s.clipClient(audioID)
+	if s.touches.cap == 0 {
+		return expireClipKeys(ctx, client, audioID, clipIndexTTL)
+	}
+	pipe := client.Pipeline()
+	pipe.SetNX(ctx, clipCreatedKey(audioID), time.Now().Unix(), s.touches.cap)
+	remaining := pipe.PTTL(ctx, clipCreatedKey(audioID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	ttl := clipIndexTTL
+	if remaining.Val() < ttl {
+		ttl = remaining.Val()
+	}
+	if ttl <= 0 {
+		return nil // Past the cap, so the keys expire on their current schedule
+	}
+
+	return expireClipKeys(ctx, client, audioID, ttl)
+}
+
+// expireClipKeys sets the TTL of the clip's expiring keys
+func expireClipKeys(ctx context.Context, client *redis.Client, audioID string, ttl time.Duration) error {
+	pipe := client.Pipeline()
+	for _, key := range clipExpiringKeys(audioID) {
+		pipe.Expire(ctx, key, ttl) // A no-op for keys the clip doesn't have
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// startClipTTLCap opens a freshly initialized clip's cap window
+func (s *AutocompleteService) startClipTTLCap(ctx context.Context, audioID string) error {
+	if s.touches == nil || s.touches.cap == 0 {
+		return nil
+	}
+	return s.clipClient(audioID).Set(ctx, clipCreatedKey(audioID), time.Now().Unix(), s.touches.cap).Err()
+}
+
+// clipExpiringKeys lists a clip's keys that expire clipIndexTTL after they
+// were last written
+func clipExpiringKeys(audioID string) []string {
+	return append(clipIndexKeys(audioID), clipTranscriptsKey(audioID), clipCorrectionsKey(audioID), clipVersionKey(audioID), clipBuildKey(audioID))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNewClipTouches(t *testing.T) {
+	tests := []struct {
+		name     string
+		cap      time.Duration
+		archived bool
+		wantNil  bool
+		wantCap  time.Duration
+	}{
+		{"fixed expiration", 0, false, true, 0},
+		{"capped", 6 * time.Hour, false, false, 6 * time.Hour},
+		{"archived", 0, true, false, 0},
+		{"archived ignores the cap", 6 * time.Hour, true, false, 0},
+	}
+	for _, tt := range tests {
+		touches := newClipTouches(tt.cap, time.Minute, tt.archived)
+		if (touches == nil) != tt.wantNil {
+			t.Errorf("%s: newClipTouches() = %v, want nil %v", tt.name, touches, tt.wantNil)
+			continue
+		}
+		if touches != nil && touches.cap != tt.wantCap {
+			t.Errorf("%s: cap = %v, want %v", tt.name, touches.cap, tt.wantCap)
+		}
+	}
+}
+
+func TestClipTouchesDue(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name    string
+		audioID string
+		at      time.Duration
+		want    bool
+	}{
+		{"first query", "clip-a", 0, true},
+		{"within the interval", "clip-a", 30 * time.Second, false},
+		{"another clip", "clip-b", 30 * time.Second, true},
+		{"interval passed", "clip-a", time.Minute, true},
+		{"interval restarted", "clip-a", time.Minute + 30*time.Second, false},
+	}
+	touches := newClipTouches(time.Hour, time.Minute, false)
+	for _, tt := range tests {
+		if got := touches.due(tt.audioID, start.Add(tt.at)); got != tt.want {
+			t.Errorf("%s: due(%q) = %v, want %v", tt.name, tt.audioID, got, tt.want)
+		}
+	}
+}
+
+func TestClipTouchesDropStale(t *testing.T) {
+	touches := newClipTouches(time.Hour, time.Minute, false)
+	start := time.Now()
+	for i := 0; i < maxTrackedTouches; i++ {
+		touches.last[fmt.Sprintf("clip-%d", i)] = start
+	}
+	touches.due("fresh", start.Add(time.Minute))
+	if len(touches.last) != 1 {
+		t.Errorf("%d refresh times kept, want only the fresh clip's", len(touches.last))
+	}
+}
+
+func TestClipExpiringKeys(t *testing.T) {
+	keys := clipExpiringKeys("clip")
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if seen[key] {
+			t.Errorf("clipExpiringKeys() lists %s twice", key)
+		}
+		seen[key] = true
+	}
+	for _, key := range []string{clipTranscriptsKey("clip"), clipCorrectionsKey("clip"), clipVersionKey("clip"), clipBuildKey("clip")} {
+		if !seen[key] {
+			t.Errorf("clipExpiringKeys() = %v, missing %s", keys, key)
+		}
+	}
+	if seen[clipCreatedKey("clip")] {
+		t.Error("clipExpiringKeys() slides the cap window key")
+	}
+}
+
+func TestClipTTLWithoutRedis(t *testing.T) {
+	tests := []struct {
+		name    string
+		touches *clipTouches
+		wantErr bool
+	}{
+		{"fixed expiration", nil, false},
+		{"archived", newClipTouches(0, time.Minute, true), true},
+		{"capped", newClipTouches(time.Hour, time.Minute, false), true},
+	}
+	for _, tt := range tests {
+		service := &AutocompleteService{RedisClient: unreachableRedis(t), touches: tt.touches}
+		// Without a cap, initialize has no window to open
+		wantStartErr := tt.touches != nil && tt.touches.cap > 0
+		if err := service.startClipTTLCap(context.Background(), "clip"); (err != nil) != wantStartErr {
+			t.Errorf("%s: startClipTTLCap() error = %v, want error %v", tt.name, err, wantStartErr)
+		}
+		if tt.touches == nil {
+			service.touchClip("clip") // A no-op without sliding expiration
+			continue
+		}
+		if err := service.refreshClipTTLs(context.Background(), "clip"); (err != nil) != tt.wantErr {
+			t.Errorf("%s: refreshClipTTLs() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// clipVersionKey counts the mutations of a clip's index (initializes and
+// replacements), shared by every replica. Its value is the clip's ETag.
+func clipVersionKey(audioID string) string { return clipKeyPrefix(audioID) + "version" }
+
+// clipVersion is the clip's current index version, 0 for a clip never indexed
+// (or whose version has expired)
+func (s *AutocompleteService) clipVersion(ctx context.Context, audioID string) (int64, error) {
+	version, err := s.clipClient(audioID).Get(ctx, clipVersionKey(audioID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+// bumpClipVersion records a mutation of the clip's index, returning the new version
+func (s *AutocompleteService) bumpClipVersion(ctx context.Context, audioID string) (int64, error) {
+	pipe := s.clipClient(audioID).Pipeline()
+	version := pipe.Incr(ctx, clipVersionKey(audioID))
+	pipe.Expire(ctx, clipVersionKey(audioID), clipIndexTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return version.Val(), nil
+}
+
+// setClipETag reports a clip's index version as the response's ETag
+func setClipETag(c *gin.Context, version int64) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// checkIfMatch enforces optimistic concurrency on a mutation of a clip. A
+// stale If-Match is rejected with 412 and the current ETag, so the editor can
+// reload before retrying. Without If-Match the write goes ahead, unless
+// REQUIRE_IF_MATCH is set and the clip already exists (428). Callers must
+// hold the clip's lock, so the version can't change before they write.
+func (s *AutocompleteService) checkIfMatch(ctx context.Context, c *gin.Context, audioID string) bool {
+	version, err := s.clipVersion(ctx, audioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if s.RequireIfMatch && version > 0 {
+			setClipETag(c, version)
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match required: send the ETag of the clip version being edited"})
+			return false
+		}
+		return true
+	}
+	if !etagMatches(header, version) {
+		setClipETag(c, version)
+		c.JSON(http.StatusPreconditionFailed, gin.H{
+			"error":   "clip " + audioID + " changed since it was read",
+			"version": version,
+		})
+		return false
+	}
+	return true
+}
+
+// etagMatches reports whether an If-Match header names the version. "*"
+// matches any clip that exists; weak tags compare by value.
+func etagMatches(header string, version int64) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" {
+			if version > 0 {
+				return true
+			}
+			continue
+		}
+		if unquoted, err := strconv.Unquote(tag); err == nil {
+			tag = unquoted
+		}
+		if tag == strconv.FormatInt(version, 10) {
+			return true
+		}
+	}
+	return false
+}
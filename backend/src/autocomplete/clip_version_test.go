@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int64
+		want    bool
+	}{
+		{`"3"`, 3, true},
+		{`"3"`, 4, false},
+		{`3`, 3, true},
+		{`W/"3"`, 3, true},
+		{`"1", "3"`, 3, true},
+		{`"1", "2"`, 3, false},
+		{`*`, 3, true},
+		{`*`, 0, false},
+		{`"0"`, 0, true},
+		{``, 0, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, tt.version); got != tt.want {
+			t.Errorf("etagMatches(%q, %d) = %v, want %v", tt.header, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestSetClipETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		version int64
+		want    string
+	}{
+		{0, `"0"`},
+		{12, `"12"`},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		setClipETag(c, tt.version)
+		got := recorder.Header().Get("ETag")
+		if got != tt.want || !etagMatches(got, tt.version) {
+			t.Errorf("setClipETag(%d) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestCheckIfMatchWithoutRedis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/replace", nil)
+	c.Request.Header.Set("If-Match", `"1"`)
+
+	service := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	if service.checkIfMatch(context.Background(), c, "clip") || recorder.Code != http.StatusInternalServerError {
+		t.Errorf("checkIfMatch() without Redis let the write through or answered %d", recorder.Code)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// handleCloneClip indexes a copy of a clip under a new id, e.g. to compare two
+// ranking setups on identical data. The copy is rebuilt from the clip's current
+// transcripts, corrections included; review sessions are not copied, and the
+// words are not counted again in the global index.
+func (s *AutocompleteService) handleCloneClip(c *gin.Context) {
+	var request struct {
+		Target string `json:"target" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := context.Background()
+	source := services.NormalizeAudioID(c.Param("audio_id"))
+	target := services.NormalizeAudioID(request.Target)
+	if target == source {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target must differ from the clip id"})
+		return
+	}
+
+	data, err := s.clipTranscripts(ctx, source)
+	if err == redis.Nil || err != nil && !s.Stateless {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no transcripts stored for clip " + source})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	lock, err := s.acquireClipLock(ctx, target, initLockWait)
+	if err == errClipLocked {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := lock.release(ctx); err != nil {
+			log.Printf("Error releasing clip lock: %v", err)
+		}
+	}()
+
+	// Cloning never overwrites an indexed clip
+	version, err := s.clipVersion(ctx, target)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if version > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "clip " + target + " already exists"})
+		return
+	}
+
+	start := time.Now()
+	err = s.indexClip(ctx, target, data)
+	s.ingests.record(time.Since(start), err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{"status": "cloned", "audio_id": source, "target": target}
+	if version, err := s.clipVersion(ctx, target); err == nil {
+		setClipETag(c, version)
+		response["version"] = version
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleCloneClipRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	defer services.SetClipAliases(nil)
+	services.SetClipAliases(map[string]string{"job-7": "clip"})
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "makan minum", ConfidenceScore: 0.9})
+
+	router := gin.New()
+	router.POST("/admin/clips/:audio_id/clone", (&AutocompleteService{RedisClient: unreachableRedis(t)}).handleCloneClip)
+	stateless := gin.New()
+	stateless.POST("/admin/clips/:audio_id/clone", (&AutocompleteService{Stateless: true, RedisClient: unreachableRedis(t)}).handleCloneClip)
+
+	tests := []struct {
+		name       string
+		router     *gin.Engine
+		audioID    string
+		body       string
+		wantStatus int
+	}{
+		{"no body", router, "clip", "", http.StatusBadRequest},
+		{"no target", router, "clip", `{}`, http.StatusBadRequest},
+		{"target is the clip", router, "clip", `{"target": "clip"}`, http.StatusBadRequest},
+		{"target is an alias of the clip", router, "clip", `{"target": "job-7"}`, http.StatusBadRequest},
+		{"clip not indexed", router, "missing", `{"target": "copy"}`, http.StatusNotFound},
+		{"lock needs redis", router, "clip", `{"target": "copy"}`, http.StatusInternalServerError},
+		{"stateless transcripts need redis", stateless, "clip", `{"target": "copy"}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		tt.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/admin/clips/"+tt.audioID+"/clone", strings.NewReader(tt.body)))
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.wantStatus, recorder.Body)
+		}
+	}
+}
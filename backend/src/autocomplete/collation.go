@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/collate"
+
+	"autocomplete/services"
+)
+
+// Orders a suggestion listing can be returned in
+const (
+	sortRank  = "rank"
+	sortAlpha = "alpha"
+)
+
+// defaultCollation orders sort=alpha listings without a collation or lang
+// parameter, from DEFAULT_COLLATION (default Malay)
+var defaultCollation = func() string {
+	if name := os.Getenv("DEFAULT_COLLATION"); name != "" {
+		return name
+	}
+	return services.CollationMalay
+}()
+
+// parseListingCollator reads sort and collation, returning nil for rank
+// order. Without a collation, a lang parameter picks its language's.
+func parseListingCollator(c *gin.Context) (*collate.Collator, error) {
+	switch c.DefaultQuery("sort", sortRank) {
+	case sortRank:
+		return nil, nil
+	case sortAlpha:
+	default:
+		return nil, fmt.Errorf("sort must be rank or alpha")
+	}
+
+	name := c.Query("collation")
+	if name == "" {
+		name = services.LanguageCollation(c.Query("lang"))
+	}
+	if name == "" {
+		name = defaultCollation
+	}
+	return services.NewCollator(name)
+}
+
+// collateSuggestions orders suggestions by their text in the collator's
+// alphabetical order. Ties keep their rank order.
+func collateSuggestions(suggestions []map[string]interface{}, collator *collate.Collator) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		left, _ := suggestions[i]["text"].(string)
+		right, _ := suggestions[j]["text"].(string)
+		return collator.CompareString(left, right) < 0
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseListingCollator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query   string
+		wantNil bool
+		wantErr bool
+		words   []string // Ordered by the collator, when there is one
+		want    []string
+	}{
+		{"", true, false, nil, nil},
+		{"?sort=rank", true, false, nil, nil},
+		{"?sort=size", true, true, nil, nil},
+		{"?sort=alpha", false, false, []string{"Zaman", "apa"}, []string{"apa", "Zaman"}},
+		{"?sort=alpha&lang=zh", false, false, []string{"中", "爱"}, []string{"爱", "中"}},
+		{"?sort=alpha&lang=zh&collation=zh-stroke", false, false, []string{"爱", "中"}, []string{"中", "爱"}},
+		{"?sort=alpha&collation=fr", true, true, nil, nil},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/suggest/prefix"+tt.query, nil)
+		collator, err := parseListingCollator(c)
+		if (err != nil) != tt.wantErr || (collator == nil) != tt.wantNil {
+			t.Errorf("%q: parseListingCollator() = %v, %v", tt.query, collator, err)
+			continue
+		}
+		if collator == nil {
+			continue
+		}
+		suggestions := make([]map[string]interface{}, len(tt.words))
+		for i, word := range tt.words {
+			suggestions[i] = map[string]interface{}{"text": word}
+		}
+		collateSuggestions(suggestions, collator)
+		var got []string
+		for _, suggestion := range suggestions {
+			got = append(got, suggestion["text"].(string))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: collated %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCollateSuggestionsKeepsRankOnTies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/suggest/prefix?sort=alpha", nil)
+	collator, err := parseListingCollator(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suggestions := []map[string]interface{}{
+		{"text": "makan", "source": "whisper"},
+		{"text": "lapar"},
+		{"text": "makan", "source": "wav2vec"},
+	}
+	collateSuggestions(suggestions, collator)
+	var got []interface{}
+	for _, suggestion := range suggestions {
+		got = append(got, suggestion["source"])
+	}
+	if want := []interface{}{nil, "whisper", "wav2vec"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("collated sources %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// envInt reads a positive integer setting, falling back to def when unset or invalid
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
+
+// envDuration reads a non-negative duration setting such as "250ms", falling
+// back to def when unset or invalid
+func envDuration(name string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || value < 0 {
+		return def
+	}
+	return value
+}
+
+// envFraction reads a setting between 0 and 1, falling back to def when unset or invalid
+func envFraction(name string, def float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil || value < 0 || value > 1 {
+		return def
+	}
+	return value
+}
+
+// redisOptions parses a Redis URL and applies the client tuning settings. The
+// go-redis defaults (10 connections per CPU, 3s read timeout) collapse under
+// classroom-scale concurrent typing, so every knob can be overridden:
+//
+//	REDIS_POOL_SIZE, REDIS_MIN_IDLE_CONNS, REDIS_POOL_TIMEOUT,
+//	REDIS_DIAL_TIMEOUT, REDIS_READ_TIMEOUT, REDIS_WRITE_TIMEOUT,
+//	REDIS_MAX_RETRIES, REDIS_MIN_RETRY_BACKOFF, REDIS_MAX_RETRY_BACKOFF
+func redisOptions(redisURL string) (*redis.Options, error) {
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, err
+	}
+
+	opt.PoolSize = envInt("REDIS_POOL_SIZE", opt.PoolSize)
+	opt.MinIdleConns = envInt("REDIS_MIN_IDLE_CONNS", opt.MinIdleConns)
+	opt.PoolTimeout = envDuration("REDIS_POOL_TIMEOUT", opt.PoolTimeout)
+	opt.DialTimeout = envDuration("REDIS_DIAL_TIMEOUT", opt.DialTimeout)
+	opt.ReadTimeout = envDuration("REDIS_READ_TIMEOUT", opt.ReadTimeout)
+	opt.WriteTimeout = envDuration("REDIS_WRITE_TIMEOUT", opt.WriteTimeout)
+	opt.MinRetryBackoff = envDuration("REDIS_MIN_RETRY_BACKOFF", opt.MinRetryBackoff)
+	opt.MaxRetryBackoff = envDuration("REDIS_MAX_RETRY_BACKOFF", opt.MaxRetryBackoff)
+
+	// Zero retries is a valid policy, so it can't go through envInt
+	if retries, err := strconv.Atoi(os.Getenv("REDIS_MAX_RETRIES")); err == nil {
+		opt.MaxRetries = retries
+		if retries == 0 {
+			opt.MaxRetries = -1 // go-redis treats 0 as "use the default of 3"
+		}
+	}
+
+	return opt, nil
+}
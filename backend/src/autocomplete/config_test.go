@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnvSettings(t *testing.T) {
+	tests := []struct {
+		value        string
+		wantInt      int
+		wantDuration time.Duration
+		wantFraction float64
+	}{
+		{"", 7, time.Second, 0.5},
+		{"3", 3, time.Second, 0.5},
+		{"0", 7, 0, 0},
+		{"-2", 7, time.Second, 0.5},
+		{"0.25", 7, time.Second, 0.25},
+		{"250ms", 7, 250 * time.Millisecond, 0.5},
+		{"-1s", 7, time.Second, 0.5},
+		{"0s", 7, 0, 0.5},
+		{"lots", 7, time.Second, 0.5},
+	}
+	for _, tt := range tests {
+		t.Setenv("AUTOCOMPLETE_TEST_SETTING", tt.value)
+		if got := envInt("AUTOCOMPLETE_TEST_SETTING", 7); got != tt.wantInt {
+			t.Errorf("envInt(%q) = %d, want %d", tt.value, got, tt.wantInt)
+		}
+		if got := envDuration("AUTOCOMPLETE_TEST_SETTING", time.Second); got != tt.wantDuration {
+			t.Errorf("envDuration(%q) = %v, want %v", tt.value, got, tt.wantDuration)
+		}
+		if got := envFraction("AUTOCOMPLETE_TEST_SETTING", 0.5); got != tt.wantFraction {
+			t.Errorf("envFraction(%q) = %v, want %v", tt.value, got, tt.wantFraction)
+		}
+	}
+}
+
+func TestRedisOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantPool    int
+		wantRead    time.Duration
+		wantRetries int
+	}{
+		{"URL only", nil, 0, 0, 0},
+		{"pool and timeout", map[string]string{"REDIS_POOL_SIZE": "200", "REDIS_READ_TIMEOUT": "500ms"}, 200, 500 * time.Millisecond, 0},
+		{"retries", map[string]string{"REDIS_MAX_RETRIES": "5"}, 0, 0, 5},
+		{"no retries", map[string]string{"REDIS_MAX_RETRIES": "0"}, 0, 0, -1},
+		{"invalid settings are ignored", map[string]string{"REDIS_POOL_SIZE": "-4", "REDIS_READ_TIMEOUT": "soon", "REDIS_MAX_RETRIES": "x"}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		for _, name := range []string{"REDIS_POOL_SIZE", "REDIS_READ_TIMEOUT", "REDIS_MAX_RETRIES"} {
+			t.Setenv(name, tt.env[name])
+		}
+		opt, err := redisOptions("redis://localhost:6379/2")
+		if err != nil {
+			t.Fatalf("%s: redisOptions() error: %v", tt.name, err)
+		}
+		if opt.Addr != "localhost:6379" || opt.DB != 2 || opt.PoolSize != tt.wantPool || opt.ReadTimeout != tt.wantRead || opt.MaxRetries != tt.wantRetries {
+			t.Errorf("%s: redisOptions() = addr %s db %d, pool %d, read %v, retries %d", tt.name, opt.Addr, opt.DB, opt.PoolSize, opt.ReadTimeout, opt.MaxRetries)
+		}
+	}
+
+	if _, err := redisOptions("http://localhost:6379"); err == nil {
+		t.Error("redisOptions() accepted a non-Redis URL")
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// ngramKeyPrefix holds, per context of one or two words, the words ingested
+// baselines followed it with
+const ngramKeyPrefix = redisKeyPrefix + "ngram:"
+
+// contextFetch is the minimum number of prefix matches read before context
+// reranking, so words that fit the window can climb from below the top few
+const contextFetch = 20
+
+// learnContextNgrams counts the bigrams and trigrams of a clip's baseline
+func (s *AutocompleteService) learnContextNgrams(ctx context.Context, data *models.AutocompleteData) error {
+	counts := services.NgramCounts(services.TranscriptWords(data.FinalTranscription))
+	if len(counts) == 0 {
+		return nil
+	}
+
+	pipe := s.RedisClient.Pipeline()
+	for preceding, followers := range counts {
+		for next, count := range followers {
+			pipe.ZIncrBy(ctx, ngramKeyPrefix+preceding, count, next)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// getContextSuggestions reranks prefix matches by how well each fits the
+// accepted words around the target (services.ContextFit over the ingested
+// n-gram counts), reported per suggestion as "context_fit"
+func (s *AutocompleteService) getContextSuggestions(ctx context.Context, tenant, prefix string, window services.ContextWindow, maxResults int) ([]map[string]interface{}, error) {
+	fetch := suggestFetchCount(tenant, maxResults)
+	if fetch < contextFetch {
+		fetch = contextFetch
+	}
+	results, err := s.rankedPrefix(ctx, prefix, fetch)
+	if err != nil || len(results) == 0 {
+		return formatSuggestions(tenant, results, maxResults), err
+	}
+
+	pipe := s.readClient().Pipeline()
+	ngrams := make([][]services.ContextNgram, len(results))
+	counts := make([][]*redis.FloatCmd, len(results))
+	for i, result := range results {
+		ngrams[i] = window.Ngrams(result.Member.(string))
+		for _, ngram := range ngrams[i] {
+			counts[i] = append(counts[i], pipe.ZScore(ctx, ngramKeyPrefix+ngram.Context, ngram.Next))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	fits := make(map[string]float64, len(results))
+	reranked := make([]redis.Z, len(results))
+	for i, result := range results {
+		word := result.Member.(string)
+		values := make([]float64, len(counts[i]))
+		for j, count := range counts[i] {
+			values[j] = count.Val()
+		}
+		fits[word] = services.ContextFit(ngrams[i], values)
+		reranked[i] = redis.Z{Member: word, Score: result.Score + fits[word]}
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+
+	suggestions := formatSuggestions(tenant, reranked, maxResults)
+	for _, suggestion := range suggestions {
+		suggestion["context_fit"] = fits[suggestion["text"].(string)]
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestContextWindowWithoutRedis(t *testing.T) {
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		data    *models.AutocompleteData
+		wantErr bool
+	}{
+		{"no words", &models.AutocompleteData{}, false},
+		{"one word has no n-grams", &models.AutocompleteData{FinalTranscription: "makan"}, false},
+		{"baseline", &models.AutocompleteData{FinalTranscription: "saya makan"}, true},
+	}
+	for _, tt := range tests {
+		if err := s.learnContextNgrams(ctx, tt.data); (err != nil) != tt.wantErr {
+			t.Errorf("%s: learnContextNgrams() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	window := services.ParseContextWindow("saya", "nasi")
+	if _, err := s.getContextSuggestions(ctx, "", "mak", window, 5); err == nil {
+		t.Error("getContextSuggestions() succeeded without Redis")
+	}
+}
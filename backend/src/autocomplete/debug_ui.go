@@ -0,0 +1,19 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// debugUIPage is a single-page UI for trying the suggest endpoints, browsing a
+// clip's positions and inspecting its index without the frontend stack.
+// It is served at /debug/ui when DEBUG_UI=true.
+//
+//go:embed debugui/index.html
+var debugUIPage []byte
+
+func handleDebugUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", debugUIPage)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleDebugUI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/debug/ui", handleDebugUI)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/ui", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", contentType)
+	}
+
+	// The title and the routes the page calls
+	body := w.Body.String()
+	for _, path := range []string{"<title>Autocomplete debug UI", "/suggest/prefix", "/alternatives/sentences", "/admin/trie", "/admin/stats", "/admin/versions"} {
+		if !strings.Contains(body, path) {
+			t.Errorf("debug UI is missing %q", path)
+		}
+	}
+}
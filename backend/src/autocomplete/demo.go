@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/services"
+)
+
+// demoSamples are the clips a demo instance serves, one gen-format payload per line
+//
+//go:embed demo/samples.jsonl
+var demoSamples []byte
+
+// demoWritePaths are the only non-GET routes a demo instance accepts: suggestion
+// feedback and read-only checks
+var demoWritePaths = map[string]bool{
+	"/suggest/accept":     true,
+	"/validate/particles": true,
+}
+
+// demoLimiterIdle is how long a client's bucket is kept after its last request
+const demoLimiterIdle = time.Minute
+
+// seedDemoClips ingests the embedded sample clips, returning how many were indexed
+func (s *AutocompleteService) seedDemoClips(ctx context.Context) (int, error) {
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(demoSamples))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var payload genPayload
+		if err := json.Unmarshal(scanner.Bytes(), &payload); err != nil {
+			return count, err
+		}
+		payload.Topics = services.NormalizeTopics(payload.Topics)
+		if _, err := s.ingest(ctx, payload.AudioID, payload.AutocompleteData); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, scanner.Err()
+}
+
+// demoMiddleware hides the admin and debug routes and rejects every write
+// except those in demoWritePaths, so a public demo can't change the index
+func demoMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if strings.HasPrefix(path, "/admin") || strings.HasPrefix(path, "/debug") {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not available in demo mode"})
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if !demoWritePaths[path] {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "read-only in demo mode"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// demoLimiter is a token bucket per client IP: each client may burst up to
+// burst requests, refilled at rate per second
+type demoLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*demoBucket
+	pruned  time.Time
+}
+
+type demoBucket struct {
+	tokens float64
+	seen   time.Time
+}
+
+func newDemoLimiter(rate, burst int) *demoLimiter {
+	return &demoLimiter{
+		rate:    float64(rate),
+		burst:   float64(burst),
+		buckets: make(map[string]*demoBucket),
+		pruned:  time.Now(),
+	}
+}
+
+// allow takes a token from the client's bucket, if it has one
+func (l *demoLimiter) allow(client string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.pruned) > demoLimiterIdle {
+		for key, bucket := range l.buckets {
+			if now.Sub(bucket.seen) > demoLimiterIdle {
+				delete(l.buckets, key)
+			}
+		}
+		l.pruned = now
+	}
+
+	bucket, exists := l.buckets[client]
+	if !exists {
+		bucket = &demoBucket{tokens: l.burst, seen: now}
+		l.buckets[client] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.seen).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.seen = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// middleware rejects requests from clients over their rate with 429
+func (l *demoLimiter) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.allow(c.ClientIP(), time.Now()) {
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded, slow down"})
+			return
+		}
+		c.Next()
+	}
+}
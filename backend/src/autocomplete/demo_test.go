@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/services"
+)
+
+func TestDemoSamples(t *testing.T) {
+	scanner := bufio.NewScanner(bytes.NewReader(demoSamples))
+	seen := make(map[string]bool)
+	for scanner.Scan() {
+		var payload genPayload
+		if err := json.Unmarshal(scanner.Bytes(), &payload); err != nil {
+			t.Fatalf("sample %s: %v", scanner.Bytes(), err)
+		}
+		if payload.AudioID == "" || seen[payload.AudioID] || payload.AutocompleteData == nil {
+			t.Errorf("sample %q has no data or a missing or repeated audio_id", payload.AudioID)
+			continue
+		}
+		seen[payload.AudioID] = true
+		if len(services.TranscriptWords(payload.FinalTranscription)) == 0 {
+			t.Errorf("sample %s has no baseline words", payload.AudioID)
+		}
+	}
+	if len(seen) == 0 {
+		t.Error("no demo samples are embedded")
+	}
+}
+
+func TestDemoMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(demoMiddleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/suggest/prefix", ok)
+	router.POST("/suggest/accept", ok)
+	router.POST("/validate/particles", ok)
+	router.POST("/initialize", ok)
+	router.DELETE("/clips/:audio_id", ok)
+	router.GET("/admin/stats", ok)
+	router.GET("/debug/ui", ok)
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/suggest/prefix", http.StatusOK},
+		{http.MethodPost, "/suggest/accept", http.StatusOK},
+		{http.MethodPost, "/validate/particles", http.StatusOK},
+		{http.MethodPost, "/initialize", http.StatusForbidden},
+		{http.MethodDelete, "/clips/demo-kedai", http.StatusForbidden},
+		{http.MethodGet, "/admin/stats", http.StatusNotFound},
+		{http.MethodGet, "/debug/ui", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestDemoLimiterAllow(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name    string
+		at      []time.Duration // Request times of one client after start
+		allowed int
+	}{
+		{"within the burst", []time.Duration{0, 0, 0}, 3},
+		{"burst exhausted", []time.Duration{0, 0, 0, 0, 0}, 3},
+		{"refilled", []time.Duration{0, 0, 0, 0, time.Second}, 4},
+		{"refill capped at the burst", []time.Duration{0, time.Hour, time.Hour, time.Hour, time.Hour}, 4},
+	}
+	for _, tt := range tests {
+		limiter := newDemoLimiter(1, 3)
+		allowed := 0
+		for _, at := range tt.at {
+			if limiter.allow("client", start.Add(at)) {
+				allowed++
+			}
+		}
+		if allowed != tt.allowed {
+			t.Errorf("%s: allowed %d of %d requests, want %d", tt.name, allowed, len(tt.at), tt.allowed)
+		}
+		if !limiter.allow("other", start.Add(tt.at[len(tt.at)-1])) {
+			t.Errorf("%s: another client was limited", tt.name)
+		}
+	}
+}
+
+func TestDemoLimiterPrunesIdleClients(t *testing.T) {
+	limiter := newDemoLimiter(1, 3)
+	start := limiter.pruned
+	limiter.allow("idle", start)
+	limiter.allow("active", start.Add(demoLimiterIdle))
+	limiter.allow("active", start.Add(demoLimiterIdle+time.Second))
+	if _, kept := limiter.buckets["idle"]; kept {
+		t.Error("the idle client's bucket was kept")
+	}
+	if _, kept := limiter.buckets["active"]; !kept {
+		t.Error("the active client's bucket was pruned")
+	}
+}
+
+func TestDemoLimiterMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(newDemoLimiter(1, 1).middleware())
+	router.GET("/suggest/prefix", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/suggest/prefix", nil))
+		if w.Code != want {
+			t.Errorf("request %d: status = %d, want %d", i+1, w.Code, want)
+		}
+		if want == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("request %d: no Retry-After header", i+1)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// handoffIndexKey lists drained replicas' handoff snapshots by drain time;
+// each snapshot is stored under handoffKey
+const handoffIndexKey = redisKeyPrefix + "handoff"
+
+// handoffKey holds the in-memory state a drained replica handed off
+func handoffKey(replica string) string { return handoffIndexKey + ":" + replica }
+
+// drainPollInterval is how often a drain checks for in-flight work
+const drainPollInterval = 100 * time.Millisecond
+
+// drainState takes a replica out of rotation: once draining, /readyz fails so
+// load balancers stop sending new work, and requests already in flight are
+// counted down before the replica's sessions are handed off
+type drainState struct {
+	timeout    time.Duration
+	sessionTTL time.Duration
+	handoffTTL time.Duration
+
+	draining atomic.Bool
+	inFlight atomic.Int64
+
+	once   sync.Once
+	done   chan struct{}
+	report gin.H
+}
+
+func newDrainState() *drainState {
+	return &drainState{
+		timeout:    envDuration("DRAIN_TIMEOUT", 30*time.Second),
+		sessionTTL: envDuration("SESSION_MERGE_TTL", 24*time.Hour),
+		handoffTTL: envDuration("DRAIN_HANDOFF_TTL", time.Hour),
+		done:       make(chan struct{}),
+	}
+}
+
+// drainMiddleware counts the requests in flight, apart from probes and the
+// drain request itself
+func (s *AutocompleteService) drainMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.FullPath() {
+		case "/health", "/readyz", "/admin/drain":
+			c.Next()
+			return
+		}
+		s.drain.inFlight.Add(1)
+		defer s.drain.inFlight.Add(-1)
+		c.Next()
+	}
+}
+
+// handleDrain drains the replica and answers once it is safe to stop. A
+// drain runs once; later calls wait for it and return the same report.
+func (s *AutocompleteService) handleDrain(c *gin.Context) {
+	timeout := s.drain.timeout
+	if param := c.Query("timeout"); param != "" {
+		parsed, err := time.ParseDuration(param)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "timeout must be a duration such as 30s"})
+			return
+		}
+		timeout = parsed
+	}
+
+	c.JSON(http.StatusOK, s.drainReplica(context.Background(), timeout))
+}
+
+// drainReplica marks the replica not ready, waits up to timeout for in-flight
+// requests and suggest streams to finish, then hands off its review sessions
+// and in-memory clips through Redis
+func (s *AutocompleteService) drainReplica(ctx context.Context, timeout time.Duration) gin.H {
+	d := s.drain
+	d.once.Do(func() {
+		defer close(d.done)
+		start := time.Now()
+		d.draining.Store(true)
+		log.Printf("Draining: waiting up to %s for in-flight work", timeout)
+
+		deadline := start.Add(timeout)
+		for (d.inFlight.Load() > 0 || s.streams.active.Load() > 0) && time.Now().Before(deadline) {
+			time.Sleep(drainPollInterval)
+		}
+		report := gin.H{
+			"status":    "drained",
+			"replica":   s.leader.id,
+			"waited_ms": time.Since(start).Milliseconds(),
+			"timed_out": d.inFlight.Load() > 0 || s.streams.active.Load() > 0,
+			"in_flight": d.inFlight.Load(),
+			"streams":   s.streams.active.Load(),
+		}
+
+		if !s.Stateless {
+			// Review state not yet published goes where the other replicas merge it from
+			if err := s.publishSessions(ctx, s.leader.id, d.sessionTTL); err != nil {
+				log.Printf("Error publishing sessions while draining: %v", err)
+				report["session_error"] = err.Error()
+			}
+
+			clips, err := s.pushHandoff(ctx)
+			if err != nil {
+				log.Printf("Error handing off clips while draining: %v", err)
+				report["handoff_error"] = err.Error()
+			}
+			report["clips_handed_off"] = clips
+		}
+
+		d.report = report
+		log.Printf("Drained: %v", report)
+	})
+	<-d.done
+	return d.report
+}
+
+// pushHandoff stores a snapshot of every cached clip in Redis for the
+// replacement replica (RESTORE_FROM=redis), returning the clips it holds
+func (s *AutocompleteService) pushHandoff(ctx context.Context) (int, error) {
+	snapshot := services.SnapshotAll()
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		return 0, err
+	}
+
+	pipe := s.RedisClient.TxPipeline()
+	pipe.Set(ctx, handoffKey(s.leader.id), encoded, s.drain.handoffTTL)
+	pipe.ZAdd(ctx, handoffIndexKey, &redis.Z{Score: float64(snapshot.CreatedAt.Unix()), Member: s.leader.id})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return len(snapshot.Clips), nil
+}
+
+// restoreHandoffs restores the snapshots drained replicas handed off, oldest
+// first so a clip handed off more than once ends at its latest state. Expired
+// snapshots are dropped from the index.
+func (s *AutocompleteService) restoreHandoffs(ctx context.Context) (int, error) {
+	replicas, err := s.RedisClient.ZRange(ctx, handoffIndexKey, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	var snapshots []*models.ServiceSnapshot
+	for _, replica := range replicas {
+		encoded, err := s.RedisClient.Get(ctx, handoffKey(replica)).Bytes()
+		if err == redis.Nil {
+			s.RedisClient.ZRem(ctx, handoffIndexKey, replica)
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		snapshot := &models.ServiceSnapshot{}
+		if err := json.Unmarshal(encoded, snapshot); err != nil {
+			log.Printf("Skipping malformed handoff from %s: %v", replica, err)
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt) })
+
+	restored := 0
+	for _, snapshot := range snapshots {
+		restored += services.RestoreSnapshot(snapshot)
+	}
+	return restored, nil
+}
+
+// shutdownOnSignal drains the replica on SIGTERM or SIGINT, unless an
+// /admin/drain already did, then stops the server once open connections
+// close. The returned channel is closed when shutdown completes.
+func (s *AutocompleteService) shutdownOnSignal(server *http.Server) <-chan struct{} {
+	stopped := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	go func() {
+		defer close(stopped)
+		sig := <-signals
+		log.Printf("Received %s, shutting down", sig)
+
+		ctx := context.Background()
+		s.drainReplica(ctx, s.drain.timeout)
+		if s.embeddings != nil {
+			s.embeddings.stop()
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, s.drain.timeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Error shutting down server: %v", err)
+		}
+	}()
+	return stopped
+}
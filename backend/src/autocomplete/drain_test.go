@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// drainingService is a service with drain state, for drains without Redis
+func drainingService(t *testing.T, stateless bool) *AutocompleteService {
+	t.Helper()
+	return &AutocompleteService{
+		Stateless:   stateless,
+		RedisClient: unreachableRedis(t),
+		drain:       newDrainState(),
+		streams:     &suggestStreams{},
+		leader:      &leaderElector{id: "replica-a"},
+	}
+}
+
+func TestDrainMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := drainingService(t, true)
+	var inFlight int64
+	router := gin.New()
+	router.Use(service.drainMiddleware())
+	seen := func(c *gin.Context) { inFlight = service.drain.inFlight.Load() }
+	router.GET("/health", seen)
+	router.GET("/readyz", seen)
+	router.POST("/admin/drain", seen)
+	router.GET("/suggest/prefix", seen)
+
+	tests := []struct {
+		method string
+		path   string
+		want   int64
+	}{
+		{http.MethodGet, "/health", 0},
+		{http.MethodGet, "/readyz", 0},
+		{http.MethodPost, "/admin/drain", 0},
+		{http.MethodGet, "/suggest/prefix", 1},
+	}
+	for _, tt := range tests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+		if inFlight != tt.want {
+			t.Errorf("%s %s: %d in flight during the request, want %d", tt.method, tt.path, inFlight, tt.want)
+		}
+		if after := service.drain.inFlight.Load(); after != 0 {
+			t.Errorf("%s %s: %d in flight after the request", tt.method, tt.path, after)
+		}
+	}
+}
+
+func TestDrainReplica(t *testing.T) {
+	tests := []struct {
+		name         string
+		stateless    bool
+		inFlight     int64
+		wantTimedOut bool
+		wantHandoff  bool // Whether the drain tried handing off in-memory state
+	}{
+		{"idle stateless", true, 0, false, false},
+		{"busy stateless", true, 1, true, false},
+		{"stateful without Redis", false, 0, false, true},
+	}
+	for _, tt := range tests {
+		service := drainingService(t, tt.stateless)
+		service.drain.inFlight.Store(tt.inFlight)
+
+		report := service.drainReplica(context.Background(), 0)
+		if !service.drain.draining.Load() {
+			t.Errorf("%s: replica not marked draining", tt.name)
+		}
+		if report["status"] != "drained" || report["replica"] != "replica-a" || report["timed_out"] != tt.wantTimedOut {
+			t.Errorf("%s: drainReplica() = %v", tt.name, report)
+		}
+		if _, handedOff := report["handoff_error"]; handedOff != tt.wantHandoff {
+			t.Errorf("%s: drainReplica() = %v, want a handoff attempt %v", tt.name, report, tt.wantHandoff)
+		}
+
+		// A later drain returns the first one's report without waiting again
+		service.drain.inFlight.Store(1)
+		if again := service.drainReplica(context.Background(), time.Hour); again["timed_out"] != tt.wantTimedOut {
+			t.Errorf("%s: second drainReplica() = %v", tt.name, again)
+		}
+	}
+}
+
+func TestHandleDrain(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusOK},
+		{"?timeout=0s", http.StatusOK},
+		{"?timeout=soon", http.StatusBadRequest},
+		{"?timeout=-1s", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		service := drainingService(t, true)
+		router := gin.New()
+		router.POST("/admin/drain", service.handleDrain)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/admin/drain"+tt.query, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("timeout %q: status %d, want %d: %s", tt.query, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
+
+func TestRestoreHandoffsWithoutRedis(t *testing.T) {
+	service := drainingService(t, false)
+	if _, err := service.restoreHandoffs(context.Background()); err == nil {
+		t.Error("restoreHandoffs() succeeded without Redis")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// dryRunWord is a word an ingest would store in the global index
+type dryRunWord struct {
+	Word       string  `json:"word"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+	Downranked bool    `json:"downranked,omitempty"` // Lowered by the profanity filter when suggested
+}
+
+// dryRunDrop is a token an ingest would not store, and why
+type dryRunDrop struct {
+	Token  string `json:"token"`
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+// Reasons a token is dropped at ingest
+const (
+	dropNoWord    = "no word characters"
+	dropProfanity = "profanity filter"
+)
+
+// dryRunReport is what /initialize?dry_run=true returns instead of ingesting
+type dryRunReport struct {
+	DryRun        bool                    `json:"dry_run"`
+	AudioID       string                  `json:"audio_id"`
+	Redaction     *models.RedactionReport `json:"redaction,omitempty"`
+	Words         []dryRunWord            `json:"words"`
+	Dropped       []dryRunDrop            `json:"dropped"`
+	Positions     int                     `json:"positions"`  // Word positions of the clip index
+	Candidates    int                     `json:"candidates"` // Candidates over all positions
+	EstimatedKeys map[string]int          `json:"estimated_keys"`
+	TotalKeys     int                     `json:"estimated_keys_total"`
+}
+
+// dryRunIngest runs an ingest's normalization, redaction, tokenization,
+// filtering, alignment and scoring without writing anything. The verified
+// prior is read, since it shapes the clip's ranking. Key counts are estimates:
+// keys other clips already created are counted as new.
+func (s *AutocompleteService) dryRunIngest(ctx context.Context, audioID string, data *models.AutocompleteData) *dryRunReport {
+	data, redaction := services.RedactAutocompleteData(services.NormalizeAutocompleteData(data))
+	report := &dryRunReport{
+		DryRun:  true,
+		AudioID: services.NormalizeAudioID(audioID),
+		Words:   []dryRunWord{},
+		Dropped: []dryRunDrop{},
+	}
+	if redaction.Mode != services.PIIOff {
+		report.Redaction = redaction
+	}
+
+	// Mirrors storeWords: the baseline with its first word boosted, then each
+	// alternative at 0.8, then detected particles at 0.9
+	var writes []wordWrite
+	plan := func(word, source string, confidence float64) {
+		filtered, keep := services.FilterIngestWord(word, confidence)
+		if !keep {
+			report.Dropped = append(report.Dropped, dryRunDrop{Token: word, Source: source, Reason: dropProfanity})
+			return
+		}
+		writes = append(writes, wordWrite{word: word, confidence: filtered})
+		downranked := services.ProfanityMode() == services.ProfanityDownrank && services.IsProfane(word)
+		report.Words = append(report.Words, dryRunWord{Word: word, Confidence: filtered, Source: source, Downranked: downranked})
+	}
+	planTranscript := func(transcription, source string, confidence float64) {
+		report.Dropped = append(report.Dropped, wordlessTokens(transcription, source)...)
+		for i, word := range splitIntoWords(transcription) {
+			if i == 0 {
+				plan(word, source, confidence+0.1)
+			} else {
+				plan(word, source, confidence)
+			}
+		}
+	}
+	planTranscript(data.FinalTranscription, "final_transcription", data.ConfidenceScore)
+	modelNames := make([]string, 0, len(data.ASRAlternatives))
+	for model := range data.ASRAlternatives {
+		modelNames = append(modelNames, model)
+	}
+	sort.Strings(modelNames)
+	for _, model := range modelNames {
+		planTranscript(data.ASRAlternatives[model], model, 0.8)
+	}
+	for _, particle := range data.DetectedParticles {
+		plan(particle, "detected_particles", 0.9)
+	}
+
+	prior, _ := s.verifiedPrior(ctx, data)
+	positionMap := services.ApplyVerifiedPrior(services.BuildPositionMap(data), prior)
+	report.Positions = len(positionMap)
+	for _, candidates := range positionMap {
+		report.Candidates += len(candidates)
+	}
+
+	report.EstimatedKeys = s.estimateIngestKeys(data, writes, positionMap)
+	for _, count := range report.EstimatedKeys {
+		report.TotalKeys += count
+	}
+	return report
+}
+
+// wordlessTokens returns the whitespace-separated tokens of a transcript that
+// hold no word, such as stray punctuation
+func wordlessTokens(transcription, source string) []dryRunDrop {
+	var dropped []dryRunDrop
+	for _, field := range strings.Fields(transcription) {
+		if len(services.TranscriptWords(field)) == 0 {
+			dropped = append(dropped, dryRunDrop{Token: field, Source: source, Reason: dropNoWord})
+		}
+	}
+	return dropped
+}
+
+// estimateIngestKeys counts the Redis keys an ingest would write, per family
+func (s *AutocompleteService) estimateIngestKeys(data *models.AutocompleteData, writes []wordWrite, positionMap models.PositionMap) map[string]int {
+	keys := make(map[string]int)
+	batch := planBatch(writes)
+	if len(batch.frequencies) > 0 {
+		keys["global"] = 1
+	}
+	keys["prefix"] = len(batch.prefixOrder)
+	if s.TopK > 0 && len(batch.prefixOrder) > 0 {
+		keys["topk"] = 1
+		if s.projector != nil {
+			keys["projection"] = 1
+		}
+	}
+	for _, family := range batch.groupFamilies {
+		keys[strings.TrimSuffix(strings.TrimPrefix(family, redisKeyPrefix), ":")] = len(batch.groupsByFamily[family])
+	}
+
+	words := services.TranscriptWords(data.FinalTranscription)
+	if len(words) > 0 {
+		keys["particle"] = 2 // Word counts and slot counts
+		if lexicon, err := services.ParticleLexicon(); err == nil {
+			after := make(map[string]bool)
+			for _, occurrence := range services.FindParticles(services.Tokenize(data.FinalTranscription), lexicon) {
+				if occurrence.After != "" {
+					after[occurrence.After] = true
+				}
+			}
+			keys["particle"] += len(after)
+		}
+		keys["ngram"] = len(services.NgramCounts(words))
+		if len(data.Topics) > 0 {
+			keys["topic"] = len(data.Topics) + 1
+		}
+		if data.Accent != "" {
+			keys["accent"] = 2
+		}
+	}
+
+	if s.Stateless {
+		// Lex, words, positions, occurrences and transcripts, plus a lex per language
+		keys["clip"] = 5 + len(services.Languages)
+	} else {
+		keys["clip"] = 0 // The clip index stays in process memory
+	}
+	for family, count := range keys {
+		if count == 0 {
+			delete(keys, family)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// embeddingCacheKeyPrefix holds one packed embedding per embedded text, under
+// the model version that produced it
+const embeddingCacheKeyPrefix = redisKeyPrefix + "embedcache:"
+
+// Precomputation limits: sentences embedded ahead of time per clip, and
+// clips waiting for a worker
+const (
+	maxPrecomputedSentences  = 5000
+	embeddingPrecomputeQueue = 256
+)
+
+var (
+	// embeddingModel names the embedding model version, from EMBEDDING_MODEL.
+	// Stored vectors are keyed by it, so an upgrade never compares vectors of
+	// two models; the old model's keys are left to expire or be purged.
+	embeddingModel = "default"
+
+	// embeddingCacheTTL is how long a cached text embedding lives, from EMBEDDING_CACHE_TTL
+	embeddingCacheTTL = 7 * 24 * time.Hour
+
+	embeddingCacheHits   atomic.Int64
+	embeddingCacheMisses atomic.Int64
+)
+
+// embeddingCacheKey is the cache key of a text's embedding under the current model
+func embeddingCacheKey(text string) string {
+	sum := sha1.Sum([]byte(text))
+	return embeddingCacheKeyPrefix + embeddingModel + ":" + hex.EncodeToString(sum[:])
+}
+
+// cachedEmbeddings returns one embedding per text, asking the embedding
+// service only for the texts the cache doesn't have, in batches, and caching
+// what it returns
+func (s *AutocompleteService) cachedEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	if embeddingURL == "" {
+		return nil, errNoEmbeddings
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = embeddingCacheKey(text)
+	}
+	cached, err := s.readClient().MGet(ctx, keys...).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	vectors := make([][]float64, len(texts))
+	missing := make(map[string][]int) // Text → the indices it fills
+	var order []string
+	for i, value := range cached {
+		if packed, ok := value.(string); ok {
+			vectors[i] = unpackVector(packed)
+			continue
+		}
+		if _, seen := missing[texts[i]]; !seen {
+			order = append(order, texts[i])
+		}
+		missing[texts[i]] = append(missing[texts[i]], i)
+	}
+	embeddingCacheHits.Add(int64(len(texts) - len(order)))
+	embeddingCacheMisses.Add(int64(len(order)))
+
+	for start := 0; start < len(order); start += embedBatchSize {
+		batch := order[start:min(start+embedBatchSize, len(order))]
+		embedded, err := embedTexts(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		pipe := s.RedisClient.Pipeline()
+		for i, text := range batch {
+			for _, index := range missing[text] {
+				vectors[index] = embedded[i]
+			}
+			pipe.Set(ctx, embeddingCacheKey(text), packVector(embedded[i]), embeddingCacheTTL)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Printf("Error caching embeddings: %v", err)
+		}
+	}
+	return vectors, nil
+}
+
+// embeddingPrecompute embeds freshly indexed clips on a bounded pool of
+// workers. A clip is queued once: initializing it again while it waits only
+// replaces the data the worker will embed.
+type embeddingPrecompute struct {
+	clips   chan string
+	mutex   sync.Mutex
+	pending map[string]*models.AutocompleteData // Queued clip → its latest data
+	cancel  context.CancelFunc
+	dropped atomic.Int64
+}
+
+// startEmbeddingPrecompute launches the workers, which stop with ctx or on shutdown
+func (s *AutocompleteService) startEmbeddingPrecompute(ctx context.Context, workers int) {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &embeddingPrecompute{
+		clips:   make(chan string, embeddingPrecomputeQueue),
+		pending: make(map[string]*models.AutocompleteData),
+		cancel:  cancel,
+	}
+	s.embeddings = p
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case audioID := <-p.clips:
+					p.mutex.Lock()
+					data := p.pending[audioID]
+					delete(p.pending, audioID)
+					p.mutex.Unlock()
+					s.embedClipSentences(ctx, audioID, data)
+				}
+			}
+		}()
+	}
+}
+
+// stop cancels in-flight precomputation and leaves queued clips unembedded
+func (p *embeddingPrecompute) stop() {
+	p.cancel()
+}
+
+// precomputeClipEmbeddings queues a freshly indexed clip for embedding, so
+// keystrokes find its sentences in the cache instead of waiting on the
+// embedding service. A full queue skips the clip.
+func (s *AutocompleteService) precomputeClipEmbeddings(audioID string, data *models.AutocompleteData) {
+	p := s.embeddings
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, queued := p.pending[audioID]; queued {
+		p.pending[audioID] = data
+		return
+	}
+	select {
+	case p.clips <- audioID:
+		p.pending[audioID] = data
+	default:
+		p.dropped.Add(1)
+		log.Printf("Embedding precompute queue full, skipping clip %s", audioID)
+	}
+}
+
+// embedClipSentences embeds the sentences semantic homophone reranking
+// compares at each word position of a clip: the context alone, and with each
+// word the ASR models heard there or a homophone of the baseline word in the slot
+func (s *AutocompleteService) embedClipSentences(ctx context.Context, audioID string, data *models.AutocompleteData) {
+	positionMap, err := s.clipPositionMap(ctx, audioID)
+	if err != nil {
+		log.Printf("Error reading positions of clip %s for embedding: %v", audioID, err)
+		return
+	}
+	sentences := clipSentences(services.TranscriptWords(data.FinalTranscription), positionMap)
+	if _, err := s.cachedEmbeddings(ctx, sentences); err != nil && ctx.Err() == nil {
+		log.Printf("Error precomputing embeddings of clip %s: %v", audioID, err)
+	}
+}
+
+// clipSentences lists the distinct sentences to embed for a clip's positions,
+// up to maxPrecomputedSentences
+func clipSentences(words []string, positionMap models.PositionMap) []string {
+	seen := make(map[string]bool)
+	var sentences []string
+	add := func(sentence string) {
+		if sentence != "" && !seen[sentence] && len(sentences) < maxPrecomputedSentences {
+			seen[sentence] = true
+			sentences = append(sentences, sentence)
+		}
+	}
+
+	for pos, word := range words {
+		sc := sentenceAround(words, pos)
+		if sc.empty() {
+			continue
+		}
+		add(sc.sentence(""))
+		for _, candidate := range positionMap[pos] {
+			add(sc.sentence(candidate.Text))
+		}
+		for _, homophone := range services.Homophones(word) {
+			add(sc.sentence(homophone))
+		}
+	}
+	return sentences
+}
+
+// embeddingCacheStats reports how often cached embeddings spared a request
+func embeddingCacheStats() map[string]interface{} {
+	hits := embeddingCacheHits.Load()
+	misses := embeddingCacheMisses.Load()
+	return map[string]interface{}{
+		"model":     embeddingModel,
+		"hits":      hits,
+		"misses":    misses,
+		"hit_ratio": services.HitRatio(hits, misses),
+	}
+}
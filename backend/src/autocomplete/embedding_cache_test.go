@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestEmbeddingCacheKey(t *testing.T) {
+	defer func(model string) { embeddingModel = model }(embeddingModel)
+
+	embeddingModel = "v1"
+	key := embeddingCacheKey("saya makan nasi")
+	tests := []struct {
+		name     string
+		model    string
+		text     string
+		wantSame bool
+	}{
+		{"same text and model", "v1", "saya makan nasi", true},
+		{"other text", "v1", "saya makna nasi", false},
+		{"other model", "v2", "saya makan nasi", false},
+	}
+	for _, tt := range tests {
+		embeddingModel = tt.model
+		got := embeddingCacheKey(tt.text)
+		if (got == key) != tt.wantSame {
+			t.Errorf("%s: embeddingCacheKey(%q) = %q, same as %q = %v, want %v", tt.name, tt.text, got, key, got == key, tt.wantSame)
+		}
+		if !strings.HasPrefix(got, embeddingCacheKeyPrefix+tt.model+":") {
+			t.Errorf("%s: embeddingCacheKey(%q) = %q, want it under model %s", tt.name, tt.text, got, tt.model)
+		}
+	}
+}
+
+func TestCachedEmbeddingsFailures(t *testing.T) {
+	defer func(url string) { embeddingURL = url }(embeddingURL)
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+
+	tests := []struct {
+		name    string
+		url     string
+		texts   []string
+		wantErr bool
+	}{
+		{"no embedding service", "", []string{"saya makan"}, true},
+		{"no texts", "http://embedder", nil, false},
+		{"cache unreachable", "http://embedder", []string{"saya makan"}, true},
+	}
+	for _, tt := range tests {
+		embeddingURL = tt.url
+		vectors, err := s.cachedEmbeddings(context.Background(), tt.texts)
+		if (err != nil) != tt.wantErr || vectors != nil {
+			t.Errorf("%s: cachedEmbeddings() = %v, %v, want error %v", tt.name, vectors, err, tt.wantErr)
+		}
+	}
+	embeddingURL = ""
+	if _, err := s.cachedEmbeddings(context.Background(), []string{"saya makan"}); err != errNoEmbeddings {
+		t.Errorf("cachedEmbeddings() without a service error = %v, want %v", err, errNoEmbeddings)
+	}
+}
+
+func TestClipSentences(t *testing.T) {
+	if err := services.ConfigureHomophones(); err != nil {
+		t.Fatal(err)
+	}
+	heard := func(texts ...string) []models.WordSuggestion {
+		var candidates []models.WordSuggestion
+		for _, text := range texts {
+			candidates = append(candidates, models.WordSuggestion{Text: text})
+		}
+		return candidates
+	}
+
+	tests := []struct {
+		name        string
+		transcript  string
+		positionMap models.PositionMap
+		want        []string
+	}{
+		{"single word has no context", "makan", models.PositionMap{0: heard("makna")}, nil},
+		{"context alone per position", "jom makan", nil, []string{"makan", "jom"}},
+		{"heard candidates in the slot", "jom makan", models.PositionMap{1: heard("makan", "makna")}, []string{
+			"makan", "jom", "jom makan", "jom makna",
+		}},
+		{"homophones of the baseline word", "saya tau", nil, []string{"tau", "sayer tau", "saya", "saya tahu"}},
+		{"repeated sentences once", "jom jom", models.PositionMap{0: heard("jom"), 1: heard("jom")}, []string{"jom", "jom jom"}},
+	}
+	for _, tt := range tests {
+		if got := clipSentences(services.TranscriptWords(tt.transcript), tt.positionMap); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: clipSentences() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPrecomputeClipEmbeddingsQueue(t *testing.T) {
+	first := &models.AutocompleteData{FinalTranscription: "saya makan"}
+	second := &models.AutocompleteData{FinalTranscription: "saya makan nasi"}
+
+	// No workers drain the queue, so what it holds stays put
+	p := &embeddingPrecompute{clips: make(chan string, 2), pending: make(map[string]*models.AutocompleteData), cancel: func() {}}
+	s := &AutocompleteService{embeddings: p}
+
+	tests := []struct {
+		audioID     string
+		data        *models.AutocompleteData
+		wantQueued  int
+		wantDropped int64
+	}{
+		{"a", first, 1, 0},
+		{"a", second, 1, 0}, // Still queued, so only its data is replaced
+		{"b", first, 2, 0},
+		{"c", first, 2, 1},
+	}
+	for _, tt := range tests {
+		s.precomputeClipEmbeddings(tt.audioID, tt.data)
+		if len(p.clips) != tt.wantQueued || p.dropped.Load() != tt.wantDropped {
+			t.Errorf("precompute %s: %d queued, %d dropped, want %d, %d", tt.audioID, len(p.clips), p.dropped.Load(), tt.wantQueued, tt.wantDropped)
+		}
+	}
+	if p.pending["a"] != second || p.pending["b"] != first || p.pending["c"] != nil {
+		t.Errorf("pending = %v, want a's latest data and b's", p.pending)
+	}
+
+	// Without a pool, nothing is queued
+	(&AutocompleteService{}).precomputeClipEmbeddings("a", first)
+}
+
+func TestEmbeddingPrecomputeWorkers(t *testing.T) {
+	defer func(url string) { embeddingURL = url }(embeddingURL)
+	embeddingURL = ""
+	services.ResetCache()
+	defer services.ResetCache()
+	data := &models.AutocompleteData{FinalTranscription: "saya makan", ConfidenceScore: 0.9}
+	services.BuildAndCacheData("clip", data)
+
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	s.startEmbeddingPrecompute(context.Background(), 2)
+	defer s.embeddings.stop()
+
+	for _, audioID := range []string{"clip", "missing"} {
+		s.precomputeClipEmbeddings(audioID, data)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.embeddings.mutex.Lock()
+		pending := len(s.embeddings.pending)
+		s.embeddings.mutex.Unlock()
+		if pending == 0 && len(s.embeddings.clips) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("workers left %d clips pending", pending)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
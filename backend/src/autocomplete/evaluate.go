@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Defaults and bounds of /evaluate: k, the characters typed at each position
+// and the clips evaluated per request
+const (
+	defaultEvaluateK      = 5
+	defaultEvaluatePrefix = 1
+	maxEvaluateClips      = 100
+)
+
+// handleEvaluate measures suggestion quality against reference transcripts of
+// indexed clips. At every word position the first prefix_length characters of
+// the reference word are typed and the top k suggestions the editor would show
+// there are ranked (see getPositionedSuggestions). It reports hit rate at k and
+// smaller cutoffs, mean reciprocal rank, and coverage: the share of positions
+// where some ASR model heard the reference word. Metrics are given per clip,
+// with every position, and over all clips.
+func (s *AutocompleteService) handleEvaluate(c *gin.Context) {
+	var request struct {
+		Clips []struct {
+			AudioID    string `json:"audio_id"`
+			Transcript string `json:"transcript"`
+		} `json:"clips"`
+		K            int      `json:"k"`
+		PrefixLength int      `json:"prefix_length"`
+		BlendWeight  *float64 `json:"blend_weight"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(request.Clips) == 0 || len(request.Clips) > maxEvaluateClips {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "clips must hold between 1 and " + strconv.Itoa(maxEvaluateClips) + " clips"})
+		return
+	}
+	k := request.K
+	if k == 0 {
+		k = defaultEvaluateK
+	}
+	if k < 1 || k > maxSimulateResults {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "k must be between 1 and " + strconv.Itoa(maxSimulateResults)})
+		return
+	}
+	prefixLength := request.PrefixLength
+	if prefixLength == 0 {
+		prefixLength = defaultEvaluatePrefix
+	}
+	if prefixLength < 1 || prefixLength > prefixIndexDepth {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix_length must be between 1 and " + strconv.Itoa(prefixIndexDepth)})
+		return
+	}
+	weight := positionBlendWeight
+	if request.BlendWeight != nil {
+		if *request.BlendWeight < 0 || *request.BlendWeight > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "blend_weight must be between 0 and 1"})
+			return
+		}
+		weight = *request.BlendWeight
+	}
+
+	ctx := context.Background()
+	tenant := requestTenant(c)
+	cutoffs := services.HitRateCutoffs(k)
+	evaluation := &models.Evaluation{
+		K:            k,
+		PrefixLength: prefixLength,
+		Clips:        make([]models.ClipEvaluation, 0, len(request.Clips)),
+	}
+	var all []models.EvaluatedPosition
+	for _, clip := range request.Clips {
+		audioID := services.NormalizeAudioID(clip.AudioID)
+		words := referenceWords(clip.Transcript)
+		if len(words) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "transcript of clip " + audioID + " has no words"})
+			return
+		}
+		if len(words) > maxSimulateWords {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "transcript of clip " + audioID + " exceeds " + strconv.Itoa(maxSimulateWords) + " words"})
+			return
+		}
+		if _, err := s.clipTranscripts(ctx, audioID); err == redis.Nil || err != nil && !s.Stateless {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no index built for clip " + audioID})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		positions, err := s.evaluateClip(ctx, tenant, audioID, words, k, prefixLength, weight)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		evaluation.Clips = append(evaluation.Clips, models.ClipEvaluation{
+			AudioID:        audioID,
+			RankingMetrics: services.RankMetrics(positions, cutoffs),
+			Positions:      positions,
+		})
+		all = append(all, positions...)
+	}
+	evaluation.RankingMetrics = services.RankMetrics(all, cutoffs)
+
+	c.JSON(http.StatusOK, evaluation)
+}
+
+// evaluateClip ranks each reference word among the suggestions for its first
+// prefixLength characters at its word position, and checks whether the ASR
+// models heard it there
+func (s *AutocompleteService) evaluateClip(ctx context.Context, tenant, audioID string, words []string, k, prefixLength int, weight float64) ([]models.EvaluatedPosition, error) {
+	positions := make([]models.EvaluatedPosition, len(words))
+	for position, word := range words {
+		prefix := []rune(word)
+		if len(prefix) > prefixLength {
+			prefix = prefix[:prefixLength]
+		}
+		suggestions, err := s.positionedTexts(ctx, tenant, string(prefix), audioID, position, weight, k)
+		if err != nil {
+			return nil, err
+		}
+		candidates, err := s.positionCandidates(ctx, audioID, strconv.Itoa(position))
+		if err != nil {
+			return nil, err
+		}
+
+		positions[position] = models.EvaluatedPosition{
+			Position: position,
+			Word:     word,
+			Rank:     services.SuggestionRank(suggestions, word),
+		}
+		for _, candidate := range candidates {
+			if strings.EqualFold(candidate.Text, word) {
+				positions[position].Covered = true
+				break
+			}
+		}
+	}
+	return positions, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestReferenceWords(t *testing.T) {
+	tests := []struct {
+		transcript string
+		want       []string
+	}{
+		{"Saya makan nasi.", []string{"Saya", "makan", "nasi"}},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		if got := referenceWords(tt.transcript); !reflect.DeepEqual(got, tt.want) && len(got)+len(tt.want) > 0 {
+			t.Errorf("referenceWords(%q) = %q, want %q", tt.transcript, got, tt.want)
+		}
+	}
+}
+
+func TestHandleEvaluateRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan"})
+
+	router := gin.New()
+	router.POST("/evaluate", (&AutocompleteService{}).handleEvaluate)
+
+	clip := `{"audio_id": "clip", "transcript": "saya makan"}`
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"not json", `not json`, http.StatusBadRequest},
+		{"no clips", `{"clips": []}`, http.StatusBadRequest},
+		{"too many clips", `{"clips": [` + strings.TrimSuffix(strings.Repeat(clip+",", maxEvaluateClips+1), ",") + `]}`, http.StatusBadRequest},
+		{"k too high", `{"clips": [` + clip + `], "k": 21}`, http.StatusBadRequest},
+		{"prefix too long", `{"clips": [` + clip + `], "prefix_length": 11}`, http.StatusBadRequest},
+		{"blend weight", `{"clips": [` + clip + `], "blend_weight": -0.5}`, http.StatusBadRequest},
+		{"no words", `{"clips": [{"audio_id": "clip", "transcript": ""}]}`, http.StatusBadRequest},
+		{"too many words", `{"clips": [{"audio_id": "clip", "transcript": "` + strings.Repeat("kata ", maxSimulateWords+1) + `"}]}`, http.StatusRequestEntityTooLarge},
+		{"unknown clip", `{"clips": [{"audio_id": "other", "transcript": "saya"}]}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/evaluate", strings.NewReader(tt.body)))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.wantStatus, w.Body)
+		}
+	}
+}
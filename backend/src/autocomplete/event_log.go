@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+)
+
+// eventLogKey is the append-only stream of everything that changed the
+// indexes, which replay-events rebuilds them from. It is never trimmed.
+const eventLogKey = redisKeyPrefix + "events"
+
+// Event log entry types
+const (
+	eventIngest     = "ingest"     // A clip was indexed from Data
+	eventCorrection = "correction" // /replace changed the word at Position
+	eventFinalize   = "finalize"   // /finalize verified Transcript
+	eventDelete     = "delete"     // A clip was soft-deleted, or purged when Hard
+	eventRestore    = "restore"    // A soft-deleted clip was restored
+)
+
+// loggedEvent is one entry of the event log. Ingested payloads are stored
+// after normalization and PII redaction, so the log holds nothing the
+// indexes don't.
+type loggedEvent struct {
+	ID         string                   `json:"-"` // The stream entry ID
+	Type       string                   `json:"type"`
+	AudioID    string                   `json:"audio_id"`
+	Data       *models.AutocompleteData `json:"data,omitempty"`
+	Position   int                      `json:"position,omitempty"`
+	OldWord    string                   `json:"old_word,omitempty"`
+	NewWord    string                   `json:"new_word,omitempty"`
+	Transcript string                   `json:"transcript,omitempty"`
+	Hard       bool                     `json:"hard,omitempty"`
+}
+
+// recordEvent appends an applied change to the event log when EVENT_LOG is
+// on. The change has already happened, so a failed append is only logged.
+func (s *AutocompleteService) recordEvent(ctx context.Context, event *loggedEvent) {
+	if !s.EventLog {
+		return
+	}
+	encoded, err := json.Marshal(event)
+	if err == nil {
+		err = s.RedisClient.XAdd(ctx, &redis.XAddArgs{
+			Stream: eventLogKey,
+			Values: map[string]interface{}{"type": event.Type, "event": encoded},
+		}).Err()
+	}
+	if err != nil {
+		log.Printf("Error recording %s event for clip %s: %v", event.Type, event.AudioID, err)
+	}
+}
+
+// scanEvents calls fn with each logged event after the exclusive stream ID
+// after, up to and including until ("+" for the end), in order
+func (s *AutocompleteService) scanEvents(ctx context.Context, after, until string, fn func(*loggedEvent) error) error {
+	for {
+		entries, err := s.RedisClient.XRangeN(ctx, eventLogKey, "("+after, until, 1000).Result()
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			raw, _ := entry.Values["event"].(string)
+			event := &loggedEvent{}
+			if err := json.Unmarshal([]byte(raw), event); err != nil {
+				log.Printf("Skipping corrupt event %s: %v", entry.ID, err)
+				continue
+			}
+			event.ID = entry.ID
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+		if len(entries) < 1000 {
+			return nil
+		}
+		after = entries[len(entries)-1].ID
+	}
+}
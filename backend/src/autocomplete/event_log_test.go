@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestLoggedEventEncoding(t *testing.T) {
+	tests := []struct {
+		name  string
+		event loggedEvent
+		want  string
+	}{
+		{
+			name:  "ingest",
+			event: loggedEvent{ID: "1-0", Type: eventIngest, AudioID: "clip", Data: &models.AutocompleteData{FinalTranscription: "saya makan"}},
+			want:  `{"type":"ingest","audio_id":"clip","data":{"final_transcription":"saya makan","confidence_score":0,"detected_particles":null,"asr_alternatives":null}}`,
+		},
+		{
+			name:  "correction",
+			event: loggedEvent{ID: "2-0", Type: eventCorrection, AudioID: "clip", Position: 1, OldWord: "makan", NewWord: "minum"},
+			want:  `{"type":"correction","audio_id":"clip","position":1,"old_word":"makan","new_word":"minum"}`,
+		},
+		{
+			name:  "finalize",
+			event: loggedEvent{Type: eventFinalize, AudioID: "clip", Transcript: "saya minum"},
+			want:  `{"type":"finalize","audio_id":"clip","transcript":"saya minum"}`,
+		},
+		{
+			name:  "hard delete",
+			event: loggedEvent{Type: eventDelete, AudioID: "clip", Hard: true},
+			want:  `{"type":"delete","audio_id":"clip","hard":true}`,
+		},
+		{
+			name:  "restore",
+			event: loggedEvent{Type: eventRestore, AudioID: "clip"},
+			want:  `{"type":"restore","audio_id":"clip"}`,
+		},
+	}
+	for _, tt := range tests {
+		encoded, err := json.Marshal(&tt.event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(encoded) != tt.want {
+			t.Errorf("%s: encoded %s, want %s", tt.name, encoded, tt.want)
+		}
+
+		// The stream entry ID is not part of the payload; scanEvents sets it
+		decoded := loggedEvent{}
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		decoded.ID = tt.event.ID
+		if !reflect.DeepEqual(decoded, tt.event) {
+			t.Errorf("%s: decoded %+v, want %+v", tt.name, decoded, tt.event)
+		}
+	}
+}
+
+func TestEventReplayRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *loggedEvent
+		want  string
+	}{
+		{"ingest without data", &loggedEvent{Type: eventIngest, AudioID: "clip"}, "ingest event without data"},
+		{"restore never deleted", &loggedEvent{Type: eventRestore, AudioID: "clip"}, "restore of a clip the log never soft-deleted"},
+		{"restore of a hard delete", &loggedEvent{Type: eventRestore, AudioID: "purged"}, "restore of a clip the log never soft-deleted"},
+		{"unknown type", &loggedEvent{Type: "merge", AudioID: "clip"}, `unknown event type "merge"`},
+	}
+	for _, tt := range tests {
+		replay := &eventReplay{
+			service: &AutocompleteService{RedisClient: unreachableRedis(t)},
+			history: map[string][]*loggedEvent{},
+			trash:   map[string][]*loggedEvent{"purged": nil},
+		}
+		err := replay.apply(context.Background(), tt.event)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: apply() = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRecordEventDisabled(t *testing.T) {
+	// With EVENT_LOG off nothing is written, so an unreachable Redis is never touched
+	service := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	service.recordEvent(context.Background(), &loggedEvent{Type: eventIngest, AudioID: "clip"})
+	if stats := service.RedisClient.PoolStats(); stats.Misses != 0 {
+		t.Errorf("recordEvent dialed Redis %d times with the event log off", stats.Misses)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+
+	"autocomplete/services"
+)
+
+// suggestExclusions are the words a suggest request asked to leave out: exact
+// words, such as the one already in the slot, and word prefixes, such as
+// candidates the user rejected
+type suggestExclusions struct {
+	words    map[string]bool
+	prefixes []string
+}
+
+// parseSuggestExclusions reads the comma-separated exclude and exclude_prefix
+// lists, returning nil when both are empty
+func parseSuggestExclusions(exclude, excludePrefix string) *suggestExclusions {
+	exclusions := &suggestExclusions{words: make(map[string]bool)}
+	for _, word := range strings.Split(exclude, ",") {
+		if word = exclusionKey(word); word != "" {
+			exclusions.words[word] = true
+		}
+	}
+	for _, prefix := range strings.Split(excludePrefix, ",") {
+		if prefix = exclusionKey(prefix); prefix != "" {
+			exclusions.prefixes = append(exclusions.prefixes, prefix)
+		}
+	}
+	if len(exclusions.words) == 0 && len(exclusions.prefixes) == 0 {
+		return nil
+	}
+	return exclusions
+}
+
+// exclusionKey normalizes a word for comparison, ignoring case
+func exclusionKey(word string) string {
+	return strings.ToLower(services.NormalizeText(strings.TrimSpace(word)))
+}
+
+// fetchCount is how many suggestions to look up so that maxResults remain
+// after exclusion: one more per excluded word, and twice as many when whole
+// prefixes are excluded
+func (e *suggestExclusions) fetchCount(maxResults int) int {
+	if e == nil {
+		return maxResults
+	}
+	count := maxResults + len(e.words)
+	if len(e.prefixes) > 0 {
+		count *= 2
+	}
+	return count
+}
+
+// excludes reports whether a suggested word was excluded
+func (e *suggestExclusions) excludes(text string) bool {
+	key := exclusionKey(text)
+	if e.words[key] {
+		return true
+	}
+	for _, prefix := range e.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply drops excluded suggestions and keeps the best maxResults of the rest
+func (e *suggestExclusions) apply(suggestions []map[string]interface{}, maxResults int) []map[string]interface{} {
+	if e == nil {
+		return suggestions
+	}
+	kept := suggestions[:0]
+	for _, suggestion := range suggestions {
+		if text, _ := suggestion["text"].(string); !e.excludes(text) {
+			kept = append(kept, suggestion)
+		}
+	}
+	if len(kept) > maxResults {
+		kept = kept[:maxResults]
+	}
+	return kept
+}
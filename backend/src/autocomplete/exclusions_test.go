@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSuggestExclusions(t *testing.T) {
+	tests := []struct {
+		exclude       string
+		excludePrefix string
+		wantNil       bool
+		wantWords     map[string]bool
+		wantPrefixes  []string
+	}{
+		{"", "", true, nil, nil},
+		{" , ", ",", true, nil, nil},
+		{"Makan, makna", "", false, map[string]bool{"makan": true, "makna": true}, nil},
+		{"", "MIN,ma", false, map[string]bool{}, []string{"min", "ma"}},
+		{"makan", "min", false, map[string]bool{"makan": true}, []string{"min"}},
+	}
+	for _, tt := range tests {
+		got := parseSuggestExclusions(tt.exclude, tt.excludePrefix)
+		if (got == nil) != tt.wantNil {
+			t.Errorf("parseSuggestExclusions(%q, %q) = %v, want nil %v", tt.exclude, tt.excludePrefix, got, tt.wantNil)
+			continue
+		}
+		if got != nil && (!reflect.DeepEqual(got.words, tt.wantWords) || !reflect.DeepEqual(got.prefixes, tt.wantPrefixes)) {
+			t.Errorf("parseSuggestExclusions(%q, %q) = %v %v, want %v %v", tt.exclude, tt.excludePrefix, got.words, got.prefixes, tt.wantWords, tt.wantPrefixes)
+		}
+	}
+}
+
+func TestSuggestExclusionsFetchCount(t *testing.T) {
+	tests := []struct {
+		exclude       string
+		excludePrefix string
+		want          int
+	}{
+		{"", "", 10},
+		{"makan,makna", "", 12},
+		{"", "min", 20},
+		{"makan", "min,ma", 22},
+	}
+	for _, tt := range tests {
+		if got := parseSuggestExclusions(tt.exclude, tt.excludePrefix).fetchCount(10); got != tt.want {
+			t.Errorf("exclude %q prefix %q: fetchCount(10) = %d, want %d", tt.exclude, tt.excludePrefix, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestExclusionsApply(t *testing.T) {
+	suggestions := func() []map[string]interface{} {
+		var listed []map[string]interface{}
+		for _, word := range []string{"Makan", "makna", "minum", "mandi", "mimpi"} {
+			listed = append(listed, map[string]interface{}{"text": word})
+		}
+		return listed
+	}
+	tests := []struct {
+		name          string
+		exclude       string
+		excludePrefix string
+		maxResults    int
+		want          []string
+	}{
+		{"no exclusions", "", "", 2, []string{"Makan", "makna", "minum", "mandi", "mimpi"}},
+		{"word ignoring case", "makan", "", 10, []string{"makna", "minum", "mandi", "mimpi"}},
+		{"prefix", "", "mi", 10, []string{"Makan", "makna", "mandi"}},
+		{"both, cut to max", "makna", "mi", 2, []string{"Makan", "mandi"}},
+		{"everything", "", "m", 10, []string{}},
+	}
+	for _, tt := range tests {
+		kept := parseSuggestExclusions(tt.exclude, tt.excludePrefix).apply(suggestions(), tt.maxResults)
+		got := []string{}
+		for _, suggestion := range kept {
+			got = append(got, suggestion["text"].(string))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: apply() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// frequencyDecayFactor is the factor the frequency decay job applies per
+// interval, 1 when decay is disabled
+var frequencyDecayFactor = 1.0
+
+// scoreExplanation breaks a suggestion's ranking score into its components.
+// Score is BaseConfidence plus AgreementBonus, Personalization and Adjustment;
+// Agreement, Frequency and Decay are reported for context and don't add to it.
+type scoreExplanation struct {
+	BaseConfidence  float64 `json:"base_confidence"`     // Confidence stored in the prefix index
+	Agreement       int     `json:"agreement,omitempty"` // ASR sources that heard the word at the clip position
+	AgreementBonus  float64 `json:"agreement_bonus"`
+	Frequency       float64 `json:"frequency"` // Global ingest count after decay
+	Decay           float64 `json:"decay"`     // Frequency decay factor per interval
+	Personalization float64 `json:"personalization"`
+	Adjustment      float64 `json:"adjustment"` // Mode-specific change, e.g. the positional blend
+	Score           float64 `json:"score"`
+}
+
+// explainSuggestions attaches a scoring breakdown to each suggestion under
+// "explain". Base confidences are read from the typed prefix's index key;
+// agreement needs the clip and word position the suggestions were for.
+// Ranking applies no agreement bonus and there are no per-user profiles,
+// so those components are always 0.
+func (s *AutocompleteService) explainSuggestions(ctx context.Context, suggestions []map[string]interface{}, prefix, audioID, position string) error {
+	if len(suggestions) == 0 {
+		return nil
+	}
+
+	pipe := s.readClient().Pipeline()
+	bases := make([]*redis.FloatCmd, len(suggestions))
+	frequencies := make([]*redis.FloatCmd, len(suggestions))
+	for i, suggestion := range suggestions {
+		text := suggestion["text"].(string)
+		bases[i] = pipe.ZScore(ctx, indexedPrefixKey(prefix), text)
+		frequencies[i] = pipe.ZScore(ctx, globalFrequencyKey, text)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return err
+	}
+
+	agreement := make(map[string]int)
+	if audioID != "" && position != "" {
+		candidates, err := s.positionCandidates(ctx, audioID, position)
+		if err != nil {
+			return err
+		}
+		for _, candidate := range candidates {
+			if candidate.Agreement > agreement[candidate.Text] {
+				agreement[candidate.Text] = candidate.Agreement
+			}
+		}
+	}
+
+	for i, suggestion := range suggestions {
+		text := suggestion["text"].(string)
+		score, _ := suggestion["confidence"].(float64)
+		base, err := bases[i].Result()
+		explanation := splitScore(score, base, err == nil)
+		explanation.Agreement = agreement[text]
+		explanation.Frequency = frequencies[i].Val()
+		suggestion["explain"] = explanation
+	}
+	return nil
+}
+
+// splitScore splits a suggestion's score into its base confidence and the
+// adjustment on top of it. A word without a base in the typed prefix's index
+// isn't a spelling match, e.g. phoneme or fuzzy, so it is ranked as stored.
+func splitScore(score, base float64, indexed bool) scoreExplanation {
+	if !indexed {
+		base = score
+	}
+	return scoreExplanation{
+		BaseConfidence: base,
+		Decay:          frequencyDecayFactor,
+		Adjustment:     score - base,
+		Score:          score,
+	}
+}
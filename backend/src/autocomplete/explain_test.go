@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSplitScore(t *testing.T) {
+	defer func(factor float64) { frequencyDecayFactor = factor }(frequencyDecayFactor)
+	frequencyDecayFactor = 0.5
+
+	tests := []struct {
+		name           string
+		score, base    float64
+		indexed        bool
+		wantBase       float64
+		wantAdjustment float64
+	}{
+		{"ranked as indexed", 0.75, 0.75, true, 0.75, 0},
+		{"positional blend", 0.875, 0.75, true, 0.75, 0.125},
+		{"demoted", 0.25, 0.75, true, 0.75, -0.5},
+		{"not a spelling match", 0.5, 0, false, 0.5, 0},
+	}
+	for _, tt := range tests {
+		got := splitScore(tt.score, tt.base, tt.indexed)
+		if got.BaseConfidence != tt.wantBase || got.Adjustment != tt.wantAdjustment || got.Score != tt.score {
+			t.Errorf("%s: splitScore() = base %v adjustment %v score %v, want %v %v %v", tt.name, got.BaseConfidence, got.Adjustment, got.Score, tt.wantBase, tt.wantAdjustment, tt.score)
+		}
+		if got.BaseConfidence+got.AgreementBonus+got.Personalization+got.Adjustment != got.Score {
+			t.Errorf("%s: components of %+v don't add up to the score", tt.name, got)
+		}
+		if got.Decay != 0.5 {
+			t.Errorf("%s: decay = %v, want 0.5", tt.name, got.Decay)
+		}
+	}
+}
+
+func TestExplainSuggestionsWithoutRedis(t *testing.T) {
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	tests := []struct {
+		name        string
+		suggestions []map[string]interface{}
+		wantErr     bool
+	}{
+		{"no suggestions", nil, false},
+		{"suggestions", []map[string]interface{}{{"text": "makan", "confidence": 0.5}}, true},
+	}
+	for _, tt := range tests {
+		err := s.explainSuggestions(context.Background(), tt.suggestions, "mak", "", "")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: explainSuggestions() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		for _, suggestion := range tt.suggestions {
+			if _, ok := suggestion["explain"]; ok {
+				t.Errorf("%s: explain attached despite the error", tt.name)
+			}
+		}
+	}
+}
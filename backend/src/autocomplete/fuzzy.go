@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// matchModeFuzzy tolerates typos of up to max_edits insertions, deletions or
+// substitutions
+const matchModeFuzzy = "fuzzy"
+
+// maxFuzzyEdits is the largest edit budget max_edits accepts
+const maxFuzzyEdits = 2
+
+// fuzzyWordFanout over-reads trie matches, since a word indexed by several
+// sources matches once per source
+const fuzzyWordFanout = 3
+
+// Global fuzzy lookups walk a trie of the global vocabulary, rebuilt from the
+// frequency set at most once per globalFuzzyRefresh and holding up to
+// maxFuzzyScan words
+const (
+	globalFuzzyRefresh = time.Minute
+	maxFuzzyScan       = 200000
+)
+
+// globalVocabulary is the cached trie of every globally indexed word, with the
+// confidence its prefix keys rank it by
+type globalVocabulary struct {
+	mutex sync.Mutex
+	trie  *models.PrefixTrie
+	built time.Time
+}
+
+// getFuzzySuggestions walks the global vocabulary for words starting within
+// maxEdits insertions, deletions or substitutions of the typed text, including
+// at its first letter, ranked by distance then confidence
+func (s *AutocompleteService) getFuzzySuggestions(ctx context.Context, tenant, typed string, maxEdits, maxResults int) ([]map[string]interface{}, error) {
+	trie, err := s.globalFuzzyTrie(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fuzzySuggestions(tenant, trie, typed, maxEdits, maxResults), nil
+}
+
+// globalFuzzyTrie returns the global vocabulary trie, rebuilding it once it is
+// older than globalFuzzyRefresh. Lookups share one rebuild.
+func (s *AutocompleteService) globalFuzzyTrie(ctx context.Context) (*models.PrefixTrie, error) {
+	v := &s.vocabulary
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if v.trie != nil && time.Since(v.built) < globalFuzzyRefresh {
+		return v.trie, nil
+	}
+	trie, err := s.loadGlobalVocabulary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.trie, v.built = trie, time.Now()
+	return trie, nil
+}
+
+// loadGlobalVocabulary builds a trie of the global frequency set's words, up to
+// maxFuzzyScan of them. Each word takes its confidence from its deepest prefix
+// key; words whose prefix keys expired aren't suggested by prefix either, so
+// they are left out.
+func (s *AutocompleteService) loadGlobalVocabulary(ctx context.Context) (*models.PrefixTrie, error) {
+	client := s.readClient()
+	trie := models.NewPrefixTrie("global")
+
+	var cursor uint64
+	scanned := 0
+	for {
+		entries, next, err := client.ZScan(ctx, globalFrequencyKey, cursor, "", 1000).Result()
+		if err != nil {
+			return nil, err
+		}
+		pipe := client.Pipeline()
+		scores := make(map[string]*redis.FloatCmd, len(entries)/2)
+		for i := 0; i+1 < len(entries); i += 2 {
+			scores[entries[i]] = pipe.ZScore(ctx, indexedPrefixKey(entries[i]), entries[i])
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, err
+		}
+		for word, score := range scores {
+			if confidence, err := score.Result(); err == nil {
+				trie.Insert(word, models.WordSuggestion{Text: word, Confidence: confidence})
+			}
+		}
+		scanned += len(entries) / 2
+
+		cursor = next
+		if cursor == 0 || scanned >= maxFuzzyScan {
+			return trie, nil
+		}
+	}
+}
+
+// parseMaxEdits reads the edit budget of a fuzzy lookup, defaulting to
+// services.MaxFuzzyDistance. The budget stays below the typed length so a
+// lookup never matches every word by deleting all of it.
+func parseMaxEdits(param, typed string) (int, error) {
+	edits := int(services.MaxFuzzyDistance(typed))
+	if param != "" {
+		parsed, err := strconv.Atoi(param)
+		if err != nil || parsed < 1 || parsed > maxFuzzyEdits {
+			return 0, fmt.Errorf("max_edits must be 1 or %d", maxFuzzyEdits)
+		}
+		edits = parsed
+	}
+	if length := len([]rune(typed)); edits >= length {
+		edits = length - 1
+	}
+	return edits, nil
+}
+
+// getTrieFuzzySuggestions is getFuzzySuggestions over a clip's own trie
+func (s *AutocompleteService) getTrieFuzzySuggestions(ctx context.Context, tenant, typed, audioID string, maxEdits, maxResults int) ([]map[string]interface{}, error) {
+	// Stateless replicas rebuild the whole clip, since an edit may fall on any letter
+	trie, err := s.clipTrie(ctx, audioID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return fuzzySuggestions(tenant, trie, typed, maxEdits, maxResults), nil
+}
+
+// fuzzySuggestions walks a trie for words starting within maxEdits of the
+// typed text, ranked by distance then confidence. Each suggestion reports its
+// "distance".
+func fuzzySuggestions(tenant string, trie *models.PrefixTrie, typed string, maxEdits, maxResults int) []map[string]interface{} {
+	matches := trie.SearchFuzzy(typed, maxEdits, suggestFetchCount(tenant, maxResults)*fuzzyWordFanout)
+	distances := make(map[string]int, len(matches))
+	ranked := make([]models.WordSuggestion, 0, len(matches))
+	for _, match := range matches {
+		if _, seen := distances[match.Text]; seen {
+			continue // Matches are ranked, so the first one of a word is its best
+		}
+		distances[match.Text] = match.Distance
+		ranked = append(ranked, match.WordSuggestion)
+	}
+
+	ranked = services.ApplySuggestFilters(tenant, ranked)
+	if len(ranked) > maxResults {
+		ranked = ranked[:maxResults]
+	}
+	suggestions := make([]map[string]interface{}, len(ranked))
+	for i, suggestion := range ranked {
+		suggestions[i] = map[string]interface{}{
+			"text":       suggestion.Text,
+			"confidence": suggestion.Confidence,
+			"distance":   distances[suggestion.Text],
+		}
+	}
+	return suggestions
+}
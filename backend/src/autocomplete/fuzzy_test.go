@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestParseMaxEdits(t *testing.T) {
+	tests := []struct {
+		param, typed string
+		want         int
+		wantErr      bool
+	}{
+		{"", "mak", 1, false},
+		{"", "makan", 2, false},
+		{"2", "mak", 2, false},
+		{"2", "ma", 1, false}, // Never the whole typed text
+		{"", "m", 0, false},
+		{"0", "makan", 0, true},
+		{"3", "makan", 0, true},
+		{"two", "makan", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseMaxEdits(tt.param, tt.typed)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseMaxEdits(%q, %q) = %d, %v, want %d (error %v)", tt.param, tt.typed, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFuzzySuggestions(t *testing.T) {
+	trie := models.NewPrefixTrie("test")
+	for _, word := range []models.WordSuggestion{
+		{Text: "makan", Confidence: 0.9, Source: "gemini_final"},
+		{Text: "makan", Confidence: 0.7, Source: "whisper"},
+		{Text: "mana", Confidence: 0.8},
+		{Text: "minum", Confidence: 0.6},
+		{Text: "akan", Confidence: 0.5},
+	} {
+		trie.Insert(word.Text, word)
+	}
+
+	tests := []struct {
+		typed      string
+		maxEdits   int
+		maxResults int
+		want       []string
+		distances  []int
+	}{
+		{"mak", 0, 10, []string{"makan"}, []int{0}},
+		{"mak", 1, 10, []string{"makan", "mana", "akan"}, []int{0, 1, 1}},
+		{"mak", 1, 2, []string{"makan", "mana"}, []int{0, 1}},
+		{"xyz", 1, 10, []string{}, []int{}},
+	}
+	for _, tt := range tests {
+		suggestions := fuzzySuggestions("", trie, tt.typed, tt.maxEdits, tt.maxResults)
+		got, distances := []string{}, []int{}
+		for _, suggestion := range suggestions {
+			got = append(got, suggestion["text"].(string))
+			distances = append(distances, suggestion["distance"].(int))
+		}
+		if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(distances, tt.distances) {
+			t.Errorf("fuzzySuggestions(%q, %d) = %v at %v, want %v at %v", tt.typed, tt.maxEdits, got, distances, tt.want, tt.distances)
+		}
+	}
+}
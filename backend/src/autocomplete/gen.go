@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// genSyllables build the made-up words that pad the vocabulary past the
+// packaged word lists, so they read like Malay
+var genSyllables = []string{
+	"ba", "ka", "ma", "na", "pa", "ta", "sa", "la", "ra", "ja", "da", "ga",
+	"bi", "ki", "mi", "ni", "pi", "ti", "si", "li", "ri", "ju", "du", "gu",
+	"bu", "ku", "mu", "nu", "pu", "tu", "su", "lu", "ru", "an", "kan", "ng",
+}
+
+// genPayload is one fabricated /initialize request body
+type genPayload struct {
+	AudioID string `json:"audio_id"`
+	*models.AutocompleteData
+}
+
+// runGen writes fabricated /initialize payloads as JSON lines, for load tests
+// and for developing the frontend without real ASR output. Words are drawn from
+// a Zipf distribution over the vocabulary, each model mishears a share of
+// them and particles are sprinkled between words. The same seed gives the same
+// payloads.
+func runGen(args []string) {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	count := flags.Int("count", 10, "number of payloads")
+	words := flags.Int("words", 30, "baseline words per payload")
+	vocabSize := flags.Int("vocab", 500, "vocabulary size")
+	disagreement := flags.Float64("disagreement", 0.15, "share of words each model hears differently from the baseline")
+	particles := flags.Float64("particles", 0.1, "share of word gaps holding a discourse particle")
+	modelList := flags.String("models", "whisper,mesolitica,vosk", "comma-separated ASR models to fabricate alternatives for")
+	prefix := flags.String("audio-prefix", "synthetic", "audio_id prefix; payloads are numbered from 1")
+	seed := flags.Int64("seed", 1, "random seed")
+	out := flags.String("out", "", "output file (stdout when empty)")
+	flags.Parse(args)
+
+	if *count < 1 || *words < 1 || *vocabSize < 2 ||
+		*disagreement < 0 || *disagreement > 1 || *particles < 0 || *particles > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	var modelNames []string
+	for _, model := range strings.Split(*modelList, ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			modelNames = append(modelNames, model)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	vocabulary, err := genVocabulary(rng, *vocabSize)
+	if err != nil {
+		log.Fatalf("Failed to build vocabulary: %v", err)
+	}
+	lexicon, err := services.ParticleLexicon()
+	if err != nil {
+		log.Fatalf("Failed to load particles: %v", err)
+	}
+	particleList := make([]string, 0, len(lexicon))
+	for particle := range lexicon {
+		particleList = append(particleList, particle)
+	}
+	sort.Strings(particleList)
+
+	output := os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *out, err)
+		}
+		defer file.Close()
+		output = file
+	}
+	writer := bufio.NewWriter(output)
+	defer writer.Flush()
+
+	encoder := json.NewEncoder(writer)
+	zipf := rand.NewZipf(rng, 1.1, 1, uint64(len(vocabulary)-1))
+	for i := 1; i <= *count; i++ {
+		payload := genPayload{
+			AudioID:          fmt.Sprintf("%s-%d", *prefix, i),
+			AutocompleteData: genAutocompleteData(rng, zipf, vocabulary, particleList, modelNames, *words, *disagreement, *particles),
+		}
+		if err := encoder.Encode(payload); err != nil {
+			log.Fatalf("Failed to write payload: %v", err)
+		}
+	}
+}
+
+// genVocabulary returns size words: the packaged Malay and English word lists
+// first, then made-up words of two or three syllables
+func genVocabulary(rng *rand.Rand, size int) ([]string, error) {
+	var vocabulary []string
+	seen := make(map[string]bool)
+	for _, name := range []string{services.ResourceWordlistMalay, services.ResourceWordlistEnglish} {
+		lines, err := services.LoadResource(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || seen[fields[0]] {
+				continue
+			}
+			seen[fields[0]] = true
+			vocabulary = append(vocabulary, fields[0])
+		}
+	}
+	// Shuffle so the Zipf head mixes both languages
+	rng.Shuffle(len(vocabulary), func(i, j int) {
+		vocabulary[i], vocabulary[j] = vocabulary[j], vocabulary[i]
+	})
+
+	for attempts := 0; len(vocabulary) < size && attempts < size*100; attempts++ {
+		var word strings.Builder
+		for syllables := 2 + rng.Intn(2); syllables > 0; syllables-- {
+			word.WriteString(genSyllables[rng.Intn(len(genSyllables))])
+		}
+		if !seen[word.String()] {
+			seen[word.String()] = true
+			vocabulary = append(vocabulary, word.String())
+		}
+	}
+	if len(vocabulary) > size {
+		vocabulary = vocabulary[:size]
+	}
+	return vocabulary, nil
+}
+
+// genAutocompleteData fabricates one clip: a baseline with particles, each
+// model's alternative, the particles heard but not transcribed, and word
+// timestamps at a natural speaking rate
+func genAutocompleteData(rng *rand.Rand, zipf *rand.Zipf, vocabulary, particles, modelNames []string, wordCount int, disagreement, particleDensity float64) *models.AutocompleteData {
+	data := &models.AutocompleteData{
+		ConfidenceScore:   genRound(0.6 + 0.35*rng.Float64()),
+		DetectedParticles: []string{},
+		ASRAlternatives:   make(map[string]string, len(modelNames)),
+	}
+
+	var baseline []string
+	detected := make(map[string]bool)
+	characters := 0
+	for i := 0; i < wordCount; i++ {
+		word := vocabulary[zipf.Uint64()]
+		baseline = append(baseline, word)
+		characters += len(word) + 1
+		if len(particles) == 0 || rng.Float64() >= particleDensity {
+			continue
+		}
+		particle := particles[rng.Intn(len(particles))]
+		if rng.Intn(3) == 0 {
+			// Heard in the audio but missing from the transcript
+			data.PotentialParticles = append(data.PotentialParticles, models.PotentialParticle{
+				Particle:          particle,
+				Confidence:        genRound(0.5 + 0.4*rng.Float64()),
+				WordIndex:         len(baseline) - 1,
+				CharacterPosition: characters - 1,
+			})
+			continue
+		}
+		baseline = append(baseline, particle)
+		characters += len(particle) + 1
+		if !detected[particle] {
+			detected[particle] = true
+			data.DetectedParticles = append(data.DetectedParticles, particle)
+		}
+	}
+	data.FinalTranscription = strings.Join(baseline, " ")
+
+	for _, model := range modelNames {
+		heard := make([]string, len(baseline))
+		for i, word := range baseline {
+			heard[i] = word
+			if rng.Float64() < disagreement {
+				heard[i] = genMishear(rng, zipf, vocabulary, word)
+			}
+		}
+		data.ASRAlternatives[model] = strings.Join(heard, " ")
+	}
+
+	start := 0.2 + 0.5*rng.Float64()
+	for _, word := range baseline {
+		end := start + 0.08*float64(len(word)) + 0.1*rng.Float64()
+		data.WordTimestamps = append(data.WordTimestamps, models.WordTimestamp{StartTime: genRound(start), EndTime: genRound(end)})
+		start = end + 0.05 + 0.2*rng.Float64()
+	}
+	return data
+}
+
+// genMishear is a model's mistake on word: a near miss with one letter changed,
+// dropped or doubled, or another word of the vocabulary
+func genMishear(rng *rand.Rand, zipf *rand.Zipf, vocabulary []string, word string) string {
+	runes := []rune(word)
+	if len(runes) < 3 || rng.Intn(2) == 0 {
+		return vocabulary[zipf.Uint64()]
+	}
+	i := 1 + rng.Intn(len(runes)-1)
+	switch rng.Intn(3) {
+	case 0:
+		runes[i] = rune("aeiou"[rng.Intn(5)])
+	case 1:
+		runes = append(runes[:i], runes[i+1:]...)
+	default:
+		runes = append(runes[:i+1], runes[i:]...)
+	}
+	return string(runes)
+}
+
+// genRound rounds fabricated times and confidences to milliseconds, for readability
+func genRound(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestGenVocabulary(t *testing.T) {
+	tests := []struct {
+		size int
+	}{
+		{2},
+		{50},
+		{500},
+	}
+	for _, tt := range tests {
+		vocabulary, err := genVocabulary(rand.New(rand.NewSource(1)), tt.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(vocabulary) != tt.size {
+			t.Errorf("genVocabulary(%d) has %d words", tt.size, len(vocabulary))
+		}
+		seen := make(map[string]bool)
+		for _, word := range vocabulary {
+			if word == "" || seen[word] {
+				t.Errorf("genVocabulary(%d) repeats or holds an empty word %q", tt.size, word)
+			}
+			seen[word] = true
+		}
+
+		again, _ := genVocabulary(rand.New(rand.NewSource(1)), tt.size)
+		if !reflect.DeepEqual(vocabulary, again) {
+			t.Errorf("genVocabulary(%d) differs for the same seed", tt.size)
+		}
+	}
+}
+
+func genClip(seed int64, wordCount int, disagreement, particles float64) *models.AutocompleteData {
+	rng := rand.New(rand.NewSource(seed))
+	vocabulary, _ := genVocabulary(rng, 200)
+	zipf := rand.NewZipf(rng, 1.1, 1, uint64(len(vocabulary)-1))
+	return genAutocompleteData(rng, zipf, vocabulary, []string{"lah", "kan"}, []string{"whisper", "vosk"}, wordCount, disagreement, particles)
+}
+
+func TestGenAutocompleteData(t *testing.T) {
+	tests := []struct {
+		name         string
+		words        int
+		disagreement float64
+		particles    float64
+	}{
+		{"plain", 30, 0, 0},
+		{"disagreeing models", 30, 1, 0},
+		{"particles", 40, 0.2, 1},
+	}
+	for _, tt := range tests {
+		data := genClip(7, tt.words, tt.disagreement, tt.particles)
+		baseline := strings.Fields(data.FinalTranscription)
+
+		inserted := 0
+		for _, word := range baseline {
+			if word == "lah" || word == "kan" {
+				inserted++
+			}
+		}
+		if tt.particles == 0 && len(baseline) != tt.words || len(baseline) < tt.words {
+			t.Errorf("%s: baseline has %d words, want %d plus particles", tt.name, len(baseline), tt.words)
+		}
+		if tt.particles == 1 && inserted+len(data.PotentialParticles) != tt.words {
+			t.Errorf("%s: %d particles written and %d heard, want one after each of %d words", tt.name, inserted, len(data.PotentialParticles), tt.words)
+		}
+		for _, particle := range data.PotentialParticles {
+			if particle.WordIndex < 0 || particle.WordIndex >= len(baseline) {
+				t.Errorf("%s: potential particle at word %d of %d", tt.name, particle.WordIndex, len(baseline))
+			}
+		}
+
+		for model, transcription := range data.ASRAlternatives {
+			heard := strings.Fields(transcription)
+			if len(heard) != len(baseline) {
+				t.Errorf("%s: %s heard %d words, want %d", tt.name, model, len(heard), len(baseline))
+			}
+			if tt.disagreement == 0 && transcription != data.FinalTranscription {
+				t.Errorf("%s: %s disagrees with the baseline", tt.name, model)
+			}
+		}
+
+		if len(data.WordTimestamps) != len(baseline) {
+			t.Errorf("%s: %d timestamps for %d words", tt.name, len(data.WordTimestamps), len(baseline))
+		}
+		if normalized := services.NormalizeWordTimestamps(data.WordTimestamps, len(baseline)); !reflect.DeepEqual(normalized, data.WordTimestamps) {
+			t.Errorf("%s: timestamps need repairs", tt.name)
+		}
+		for i := 1; i < len(data.WordTimestamps); i++ {
+			if data.WordTimestamps[i].StartTime <= data.WordTimestamps[i-1].EndTime {
+				t.Errorf("%s: word %d starts at %v, before word %d ends at %v", tt.name, i, data.WordTimestamps[i].StartTime, i-1, data.WordTimestamps[i-1].EndTime)
+			}
+		}
+
+		if again := genClip(7, tt.words, tt.disagreement, tt.particles); !reflect.DeepEqual(again, data) {
+			t.Errorf("%s: payload differs for the same seed", tt.name)
+		}
+	}
+}
+
+func TestGenMishear(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	vocabulary := []string{"saya", "makan", "nasi"}
+	inVocabulary := map[string]bool{"saya": true, "makan": true, "nasi": true}
+	zipf := rand.NewZipf(rng, 1.1, 1, uint64(len(vocabulary)-1))
+
+	// A near miss keeps the first letter and changes the length by at most one
+	for i := 0; i < 200; i++ {
+		heard := genMishear(rng, zipf, vocabulary, "makan")
+		length := len([]rune(heard))
+		if !inVocabulary[heard] && (!strings.HasPrefix(heard, "m") || length < 4 || length > 6) {
+			t.Fatalf("genMishear(makan) = %q, neither a near miss nor a vocabulary word", heard)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		if heard := genMishear(rng, zipf, vocabulary, "ya"); !inVocabulary[heard] {
+			t.Fatalf("genMishear(ya) = %q, want a vocabulary word for a short word", heard)
+		}
+	}
+}
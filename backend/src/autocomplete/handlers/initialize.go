@@ -23,8 +23,16 @@ func InitializeWithData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Build and cache the data structures globally (no audio_id needed)
-	services.BuildAndCacheData(&data)
+	data.Topics = services.NormalizeTopics(data.Topics)
+	data.Accent = services.NormalizeTag(data.Accent)
+	wordCount := len(services.TranscriptWords(data.FinalTranscription))
+	data.SpeakerSegments = services.NormalizeSpeakerSegments(data.SpeakerSegments, wordCount)
+	data.Segments = services.NormalizeAudioSegments(data.Segments, wordCount)
+	data.WordTimestamps = services.NormalizeWordTimestamps(data.WordTimestamps, wordCount)
+
+	// Normalize and redact PII, then build and cache the data structures for the clip (global when audio_id is omitted)
+	redacted, _ := services.RedactAutocompleteData(services.NormalizeAutocompleteData(&data))
+	services.BuildAndCacheData(r.URL.Query().Get("audio_id"), redacted)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Autocomplete data initialized successfully"))
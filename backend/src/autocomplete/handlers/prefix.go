@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 
 	"autocomplete/services"
@@ -10,31 +9,42 @@ import (
 
 // GetPrefixSuggestions handles requests for prefix-based autocomplete suggestions.
 func GetPrefixSuggestions(w http.ResponseWriter, r *http.Request) {
-	// Extract prefix from query parameters (no audio_id needed)
-	prefix := r.URL.Query().Get("prefix")
-	maxResults := 10 // Default max results
-
-	fmt.Println("DEBUG: GetPrefixSuggestions called for prefix:", prefix) // ADDED
+	// Extract prefix and optional clip from query parameters
+	audioID := r.URL.Query().Get("audio_id")
+	tenant := r.Header.Get("X-Tenant-ID")
+	if tenant == "" {
+		tenant = r.URL.Query().Get("tenant")
+	}
+	prefix := services.NormalizeQuery(r.URL.Query().Get("prefix"))
 
 	if prefix == "" {
 		http.Error(w, "Missing prefix parameter", http.StatusBadRequest)
 		return
 	}
+	maxResults, err := services.ParseMaxResults(r.URL.Query().Get("max_results"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Retrieve the global prefix trie
-	trie, err := services.GetPrefixTrie()
+	// Retrieve the clip's prefix trie
+	trie, err := services.GetPrefixTrie(audioID)
 	if err != nil {
-		fmt.Println("ERROR: GetPrefixTrie failed:", err) // ADDED
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	// Get suggestions from the trie
-	suggestions := trie.Search(prefix, maxResults)
-	fmt.Println("DEBUG: Suggestions found for prefix '" + prefix + "':", suggestions) // ADDED
+	// Get suggestions from the trie, over-fetching so filtered words don't shrink the list
+	suggestions := []string{}
+	for _, suggestion := range services.ApplySuggestFilters(tenant, trie.SearchSuggestions(prefix, maxResults*2)) {
+		if len(suggestions) == maxResults {
+			break
+		}
+		suggestions = append(suggestions, suggestion.Text)
+	}
 
 	// Prepare response
 	response := map[string][]string{"suggestions": suggestions}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// phoneticKeyPrefix groups indexed words by services.PhoneticKey
+const phoneticKeyPrefix = redisKeyPrefix + "phonetic:"
+
+// Homophone ranking: a homophone's own confidence is discounted, and one the
+// ASR models actually heard at the user's position gains contextFitBoost
+const (
+	homophoneDiscount     = 0.8
+	contextFitBoost       = 0.3
+	unseenHomophoneWeight = 0.3 // Table entries never ingested still get offered
+	phoneticFanout        = 20
+)
+
+// homophoneSuggestions returns words that sound like word: entries of the
+// homophone table plus indexed words sharing its phonetic key. When audioID and
+// position are given, candidates heard at that position rank first.
+func (s *AutocompleteService) homophoneSuggestions(ctx context.Context, tenant, word, audioID, position string, maxResults int) ([]map[string]interface{}, error) {
+	word = strings.ToLower(word)
+	client := s.readClient()
+	candidates := make(map[string]float64)
+
+	// Table entries, scored by their confidence in the prefix index
+	table := services.Homophones(word)
+	if len(table) > 0 {
+		pipe := client.Pipeline()
+		scores := make([]*redis.FloatCmd, len(table))
+		for i, variant := range table {
+			scores[i] = pipe.ZScore(ctx, indexedPrefixKey(variant), variant)
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, err
+		}
+		for i, variant := range table {
+			confidence := unseenHomophoneWeight
+			if scores[i].Err() == nil {
+				confidence = scores[i].Val()
+			}
+			candidates[variant] = confidence
+		}
+	}
+
+	// Indexed words that share the phonetic key
+	if key := services.PhoneticKey(word); key != "" {
+		results, err := client.ZRevRangeWithScores(ctx, phoneticKeyPrefix+key, 0, phoneticFanout-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			variant := result.Member.(string)
+			if result.Score > candidates[variant] {
+				candidates[variant] = result.Score
+			}
+		}
+	}
+	delete(candidates, word)
+
+	heard := make(map[string]bool)
+	if audioID != "" && position != "" {
+		atPosition, err := s.positionCandidates(ctx, audioID, position)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range atPosition {
+			heard[strings.ToLower(candidate.Text)] = true
+		}
+	}
+
+	ranked := services.ApplySuggestFilters(tenant, rankHomophones(candidates, heard))
+	if len(ranked) > maxResults {
+		ranked = ranked[:maxResults]
+	}
+
+	suggestions := make([]map[string]interface{}, len(ranked))
+	for i, suggestion := range ranked {
+		suggestions[i] = map[string]interface{}{
+			"text":         suggestion.Text,
+			"confidence":   suggestion.Confidence,
+			"homophone_of": word,
+			"context_fit":  heard[suggestion.Text],
+		}
+	}
+	return suggestions, nil
+}
+
+// rankHomophones discounts each candidate's confidence, boosts those heard at
+// the user's position and orders them best first, ties by text
+func rankHomophones(candidates map[string]float64, heard map[string]bool) []models.WordSuggestion {
+	ranked := make([]models.WordSuggestion, 0, len(candidates))
+	for variant, confidence := range candidates {
+		score := confidence * homophoneDiscount
+		if heard[variant] {
+			score += contextFitBoost
+		}
+		ranked = append(ranked, models.WordSuggestion{Text: variant, Confidence: score})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Confidence != ranked[j].Confidence {
+			return ranked[i].Confidence > ranked[j].Confidence
+		}
+		return ranked[i].Text < ranked[j].Text
+	})
+	return ranked
+}
+
+// positionCandidates returns the words the ASR models produced at a position of a clip
+func (s *AutocompleteService) positionCandidates(ctx context.Context, audioID, position string) ([]models.WordSuggestion, error) {
+	pos, err := strconv.Atoi(position)
+	if err != nil {
+		return nil, nil // Not a word position, so there is no context to fit
+	}
+
+	if !s.Stateless {
+		positionMap, err := services.GetPositionMap(audioID)
+		if err != nil {
+			return nil, nil // Clip not initialized on this replica
+		}
+		return positionMap[pos], nil
+	}
+
+	audioID = services.NormalizeAudioID(audioID)
+	s.touchClip(audioID)
+	packed, err := s.clipReadClient(audioID).HGet(ctx, clipPositionsKey(audioID), position).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSuggestions([]byte(packed))
+}
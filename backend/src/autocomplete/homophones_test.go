@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestRankHomophones(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates map[string]float64
+		heard      map[string]bool
+		want       []string
+	}{
+		{"none", map[string]float64{}, nil, []string{}},
+		{"by confidence", map[string]float64{"tahu": 0.4, "tau": 0.9}, nil, []string{"tau", "tahu"}},
+		{"ties by text", map[string]float64{"tau": 0.5, "tahu": 0.5}, nil, []string{"tahu", "tau"}},
+		{"heard outranks confidence", map[string]float64{"tahu": 0.5, "tau": 0.7}, map[string]bool{"tahu": true}, []string{"tahu", "tau"}},
+		{"unseen entry still offered", map[string]float64{"duda": unseenHomophoneWeight}, nil, []string{"duda"}},
+	}
+	for _, tt := range tests {
+		ranked := rankHomophones(tt.candidates, tt.heard)
+		got := []string{}
+		for _, suggestion := range ranked {
+			got = append(got, suggestion.Text)
+			want := tt.candidates[suggestion.Text] * homophoneDiscount
+			if tt.heard[suggestion.Text] {
+				want += contextFitBoost
+			}
+			if suggestion.Confidence != want {
+				t.Errorf("%s: %s confidence = %v, want %v", tt.name, suggestion.Text, suggestion.Confidence, want)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: rankHomophones() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPositionCandidates(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{
+		FinalTranscription: "saya tahu",
+		ConfidenceScore:    0.9,
+		ASRAlternatives:    map[string]string{"whisper": "saya tau"},
+	})
+
+	s := &AutocompleteService{}
+	tests := []struct {
+		audioID  string
+		position string
+		want     []string
+	}{
+		{"clip", "1", []string{"tahu", "tau"}},
+		{"clip", "0", []string{"saya"}},
+		{"clip", "7", nil},
+		{"clip", "last", nil},
+		{"other", "1", nil},
+	}
+	for _, tt := range tests {
+		candidates, err := s.positionCandidates(context.Background(), tt.audioID, tt.position)
+		if err != nil {
+			t.Errorf("positionCandidates(%s, %s) error: %v", tt.audioID, tt.position, err)
+		}
+		var got []string
+		for _, candidate := range candidates {
+			got = append(got, candidate.Text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("positionCandidates(%s, %s) = %v, want %v", tt.audioID, tt.position, got, tt.want)
+		}
+	}
+}
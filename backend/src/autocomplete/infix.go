@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// matchModeInfix matches the typed text anywhere inside a word
+const matchModeInfix = "infix"
+
+// trigramKeyPrefix indexes words by every three-letter run of their
+// lower-cased spelling
+const trigramKeyPrefix = redisKeyPrefix + "trigram:"
+
+// wordTrigrams returns the distinct three-letter runs of text, lower-cased.
+// Text shorter than three letters has none.
+func wordTrigrams(text string) []string {
+	runes := []rune(strings.ToLower(text))
+	seen := make(map[string]bool)
+	var trigrams []string
+	for i := 0; i+3 <= len(runes); i++ {
+		trigram := string(runes[i : i+3])
+		if !seen[trigram] {
+			seen[trigram] = true
+			trigrams = append(trigrams, trigram)
+		}
+	}
+	return trigrams
+}
+
+// getInfixSuggestions returns indexed words containing the typed text, so
+// "selamat" finds "keselamatan". Candidates are the words holding every
+// trigram of the typed text; those that don't contain it as one run are
+// dropped. Typed text shorter than a trigram matches nothing.
+func (s *AutocompleteService) getInfixSuggestions(ctx context.Context, tenant, typed string, maxResults int) ([]map[string]interface{}, error) {
+	trigrams := wordTrigrams(typed)
+	if len(trigrams) == 0 {
+		return []map[string]interface{}{}, nil
+	}
+
+	keys := make([]string, len(trigrams))
+	for i, trigram := range trigrams {
+		keys[i] = trigramKeyPrefix + trigram
+	}
+	candidates, err := s.readClient().ZInterWithScores(ctx, &redis.ZStore{Keys: keys, Aggregate: "MAX"}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	needle := strings.ToLower(typed)
+	matches := candidates[:0]
+	for _, candidate := range candidates {
+		if strings.Contains(strings.ToLower(candidate.Member.(string)), needle) {
+			matches = append(matches, candidate)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+
+	return formatSuggestions(tenant, matches, maxResults), nil
+}
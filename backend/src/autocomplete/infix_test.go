@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestWordTrigrams(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"ma", nil},
+		{"mak", []string{"mak"}},
+		{"Makan", []string{"mak", "aka", "kan"}},
+		{"kakaka", []string{"kak", "aka"}},
+		{"café", []string{"caf", "afé"}},
+	}
+	for _, tt := range tests {
+		if got := wordTrigrams(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wordTrigrams(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPlanBatchIndexesTrigrams(t *testing.T) {
+	plan := planBatch([]wordWrite{
+		{word: "selamat", confidence: 0.6},
+		{word: "keselamatan", confidence: 0.9},
+	})
+	groups := plan.groupsByFamily[trigramKeyPrefix]
+
+	tests := []struct {
+		trigram string
+		want    map[string]float64
+	}{
+		{"sel", map[string]float64{"selamat": 0.6, "keselamatan": 0.9}},
+		{"mat", map[string]float64{"selamat": 0.6, "keselamatan": 0.9}},
+		{"kes", map[string]float64{"keselamatan": 0.9}},
+		{"xyz", nil},
+	}
+	for _, tt := range tests {
+		if got := groups[tt.trigram]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("trigram %q members = %v, want %v", tt.trigram, got, tt.want)
+		}
+	}
+}
+
+func TestGetInfixSuggestionsWithoutRedis(t *testing.T) {
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	tests := []struct {
+		typed   string
+		wantErr bool
+	}{
+		{"", false},
+		{"se", false}, // Shorter than a trigram, so Redis isn't asked
+		{"selamat", true},
+	}
+	for _, tt := range tests {
+		suggestions, err := s.getInfixSuggestions(context.Background(), "", tt.typed, 10)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("getInfixSuggestions(%q) error = %v, want error %v", tt.typed, err, tt.wantErr)
+		}
+		if !tt.wantErr && len(suggestions) != 0 {
+			t.Errorf("getInfixSuggestions(%q) = %v, want none", tt.typed, suggestions)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ingestLatencySamples is how many recent ingest latencies are kept for percentiles
+const ingestLatencySamples = 128
+
+// ingestHealth tracks when the orchestrator last ingested a clip successfully
+// and how long recent ingests took, so stale suggestions are visible in
+// /readyz and /admin/stats. A nil tracker records nothing.
+type ingestHealth struct {
+	mu          sync.Mutex
+	started     time.Time
+	staleAfter  time.Duration // Age past which /readyz fails; 0 disables the check
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+	successes   int64
+	failures    int64
+	latencies   []time.Duration
+	next        int
+}
+
+func newIngestHealth(staleAfter time.Duration) *ingestHealth {
+	return &ingestHealth{started: time.Now(), staleAfter: staleAfter}
+}
+
+// record notes an ingest that took duration and failed with err, if not nil
+func (h *ingestHealth) record(duration time.Duration, err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if err != nil {
+		h.failures++
+		h.lastFailure = now
+		h.lastError = err.Error()
+	} else {
+		h.successes++
+		h.lastSuccess = now
+	}
+
+	if len(h.latencies) < ingestLatencySamples {
+		h.latencies = append(h.latencies, duration)
+		return
+	}
+	h.latencies[h.next] = duration
+	h.next = (h.next + 1) % ingestLatencySamples
+}
+
+// stale reports whether no ingest has succeeded within staleAfter, counting
+// from startup until the first one
+func (h *ingestHealth) stale(now time.Time) bool {
+	if h == nil || h.staleAfter <= 0 {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	since := h.lastSuccess
+	if since.IsZero() {
+		since = h.started
+	}
+	return now.Sub(since) > h.staleAfter
+}
+
+// stats reports ingest freshness, outcomes and latency percentiles
+func (h *ingestHealth) stats(now time.Time) gin.H {
+	if h == nil {
+		return gin.H{}
+	}
+	stale := h.stale(now)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := gin.H{
+		"successes":  h.successes,
+		"failures":   h.failures,
+		"stale":      stale,
+		"latency_ms": latencyPercentiles(h.latencies),
+	}
+	if h.staleAfter > 0 {
+		stats["stale_after_seconds"] = h.staleAfter.Seconds()
+	}
+	if !h.lastSuccess.IsZero() {
+		stats["last_success"] = h.lastSuccess
+		stats["last_success_age_seconds"] = now.Sub(h.lastSuccess).Seconds()
+	}
+	if !h.lastFailure.IsZero() {
+		stats["last_failure"] = h.lastFailure
+		stats["last_error"] = h.lastError
+	}
+	return stats
+}
+
+// latencyPercentiles summarises latency samples in milliseconds
+func latencyPercentiles(samples []time.Duration) gin.H {
+	if len(samples) == 0 {
+		return gin.H{"samples": 0}
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	milliseconds := func(p int) float64 {
+		return float64(percentile(sorted, p).Microseconds()) / 1000
+	}
+	return gin.H{
+		"samples": len(sorted),
+		"p50":     milliseconds(50),
+		"p95":     milliseconds(95),
+		"max":     milliseconds(100),
+	}
+}
+
+// handleReadyz reports whether the replica should receive traffic: Redis must
+// answer and, when INGEST_STALE_AFTER is set, an ingest must have succeeded
+// within it. The ingest freshness is included either way.
+func (s *AutocompleteService) handleReadyz(c *gin.Context) {
+	now := time.Now()
+	status := http.StatusOK
+	response := gin.H{
+		"status": "ready",
+		"ingest": s.ingests.stats(now),
+	}
+
+	if s.drain.draining.Load() {
+		status = http.StatusServiceUnavailable
+		response["status"] = "draining"
+		response["error"] = "replica is draining"
+	} else if err := s.RedisClient.Ping(context.Background()).Err(); err != nil {
+		status = http.StatusServiceUnavailable
+		response["status"] = "unavailable"
+		response["error"] = "Redis connection failed"
+	} else if s.ingests.stale(now) {
+		status = http.StatusServiceUnavailable
+		response["status"] = "stale"
+		response["error"] = "no successful ingest within " + s.ingests.staleAfter.String()
+	}
+	c.JSON(status, response)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIngestHealthStale(t *testing.T) {
+	start := time.Now()
+	tests := []struct {
+		name       string
+		staleAfter time.Duration
+		success    bool // Whether an ingest succeeded at start
+		age        time.Duration
+		want       bool
+	}{
+		{"check disabled", 0, false, time.Hour, false},
+		{"fresh after startup", time.Minute, false, 30 * time.Second, false},
+		{"nothing ingested since startup", time.Minute, false, 2 * time.Minute, true},
+		{"recent ingest", time.Minute, true, 30 * time.Second, false},
+		{"old ingest", time.Minute, true, 2 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		health := newIngestHealth(tt.staleAfter)
+		health.started = start
+		if tt.success {
+			health.record(time.Millisecond, nil)
+			health.lastSuccess = start
+		}
+		if got := health.stale(start.Add(tt.age)); got != tt.want {
+			t.Errorf("%s: stale() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	var none *ingestHealth
+	none.record(time.Second, errors.New("ignored"))
+	if none.stale(start.Add(time.Hour)) || len(none.stats(start)) != 0 {
+		t.Error("a nil tracker reported ingests")
+	}
+}
+
+func TestIngestHealthRecord(t *testing.T) {
+	health := newIngestHealth(time.Minute)
+	for i := 1; i <= ingestLatencySamples+2; i++ {
+		health.record(time.Duration(i)*time.Millisecond, nil)
+	}
+	health.record(time.Millisecond, errors.New("redis down"))
+
+	if len(health.latencies) != ingestLatencySamples {
+		t.Errorf("kept %d latencies, want %d", len(health.latencies), ingestLatencySamples)
+	}
+	// The oldest three samples were overwritten
+	want := []time.Duration{129 * time.Millisecond, 130 * time.Millisecond, time.Millisecond, 4 * time.Millisecond}
+	if got := health.latencies[:4]; !reflect.DeepEqual(got, want) {
+		t.Errorf("latencies start %v, want %v", got, want)
+	}
+
+	stats := health.stats(time.Now())
+	if stats["successes"] != int64(ingestLatencySamples+2) || stats["failures"] != int64(1) || stats["last_error"] != "redis down" || stats["stale"] != false {
+		t.Errorf("stats() = %v", stats)
+	}
+	if _, ok := stats["last_success_age_seconds"]; !ok {
+		t.Errorf("stats() = %v, want the age of the last success", stats)
+	}
+}
+
+func TestLatencyPercentiles(t *testing.T) {
+	tests := []struct {
+		samples []time.Duration
+		want    gin.H
+	}{
+		{nil, gin.H{"samples": 0}},
+		{[]time.Duration{1500 * time.Microsecond}, gin.H{"samples": 1, "p50": 1.5, "p95": 1.5, "max": 1.5}},
+		{[]time.Duration{4 * time.Millisecond, time.Millisecond, 3 * time.Millisecond, 2 * time.Millisecond}, gin.H{"samples": 4, "p50": 2.0, "p95": 4.0, "max": 4.0}},
+	}
+	for _, tt := range tests {
+		if got := latencyPercentiles(tt.samples); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("latencyPercentiles(%v) = %v, want %v", tt.samples, got, tt.want)
+		}
+	}
+}
+
+func TestHandleReadyzWithoutRedis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		draining bool
+		want     string
+	}{
+		{"Redis down", false, "unavailable"},
+		{"draining", true, "draining"},
+	}
+	for _, tt := range tests {
+		s := &AutocompleteService{RedisClient: unreachableRedis(t), drain: &drainState{}, ingests: newIngestHealth(time.Minute)}
+		s.drain.draining.Store(tt.draining)
+		router := gin.New()
+		router.GET("/readyz", s.handleReadyz)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var response struct {
+			Status string                 `json:"status"`
+			Ingest map[string]interface{} `json:"ingest"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != http.StatusServiceUnavailable || response.Status != tt.want || response.Ingest["stale_after_seconds"] != 60.0 {
+			t.Errorf("%s: /readyz = %d %s, want %d %s with the ingest freshness", tt.name, w.Code, w.Body, http.StatusServiceUnavailable, tt.want)
+		}
+	}
+}
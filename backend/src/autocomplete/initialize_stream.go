@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// streamProgressEvery is how many records pass between progress lines
+const streamProgressEvery = 100
+
+// streamRecordTimeout replaces the server's whole-request timeouts for streaming
+// uploads: each record only has to arrive within this long of the previous one
+const streamRecordTimeout = 30 * time.Second
+
+// streamRecord is one NDJSON line of a streaming initialize body
+type streamRecord struct {
+	Type            string   `json:"type"` // meta, transcript, alternative or particles
+	AudioID         string   `json:"audio_id"`
+	ConfidenceScore float64  `json:"confidence_score"`
+	Model           string   `json:"model"`
+	Text            string   `json:"text"`
+	Particles       []string `json:"particles"`
+}
+
+// streamProgress is one NDJSON line of the streaming initialize response
+type streamProgress struct {
+	Type      string                  `json:"type"` // progress, done or error
+	Records   int                     `json:"records"`
+	Words     int                     `json:"words"`
+	AudioID   string                  `json:"audio_id,omitempty"`
+	Error     string                  `json:"error,omitempty"`
+	Redaction *models.RedactionReport `json:"redaction,omitempty"`
+	Version   int64                   `json:"version,omitempty"` // The clip's ETag once done
+}
+
+// handleInitializeStream ingests an NDJSON body record by record. Each record is
+// redacted and checked against the /initialize size limits as it arrives, and
+// progress lines are streamed back while the upload continues. Nothing is
+// written until the body ends: a bad record anywhere fails the whole stream
+// without leaving earlier records' words in the global index.
+func (s *AutocompleteService) handleInitializeStream(c *gin.Context) {
+	start := time.Now()
+
+	// Let progress lines go out before the request body has been fully read
+	controller := http.NewResponseController(c.Writer)
+	if err := controller.EnableFullDuplex(); err != nil && err != http.ErrNotSupported {
+		log.Printf("Error enabling full duplex for streaming initialize: %v", err)
+	}
+	extendDeadlines := func() {
+		controller.SetReadDeadline(time.Now().Add(streamRecordTimeout))
+		controller.SetWriteDeadline(time.Now().Add(streamRecordTimeout))
+	}
+	extendDeadlines()
+
+	limitRequestBody(c)
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 64*1024), int(maxInitializeBytes))
+
+	// An optional leading meta record names the clip and the transcript confidence
+	meta := streamRecord{AudioID: c.Query("audio_id"), ConfidenceScore: 1}
+	var first *streamRecord
+	if scanner.Scan() {
+		var record streamRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "record 1: " + err.Error()})
+			return
+		}
+		if record.Type == "meta" {
+			if record.AudioID != "" {
+				meta.AudioID = record.AudioID
+			}
+			if record.ConfidenceScore > 0 {
+				meta.ConfidenceScore = record.ConfidenceScore
+			}
+		} else {
+			first = &record
+		}
+	}
+
+	ctx := context.Background()
+	var wait time.Duration
+	if c.Query("wait") == "true" {
+		wait = initLockWait
+	}
+	lock, err := s.acquireClipLock(c.Request.Context(), services.NormalizeAudioID(meta.AudioID), wait)
+	if err == errClipLocked {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := lock.release(ctx); err != nil {
+			log.Printf("Error releasing clip lock: %v", err)
+		}
+	}()
+
+	if !s.checkIfMatch(ctx, c, services.NormalizeAudioID(meta.AudioID)) {
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	send := func(progress streamProgress) {
+		encoder.Encode(progress)
+		c.Writer.Flush()
+	}
+
+	clip := &streamedClip{
+		data: &models.AutocompleteData{
+			ConfidenceScore:   meta.ConfidenceScore,
+			DetectedParticles: []string{},
+			ASRAlternatives:   map[string]string{},
+		},
+		alternatives:     map[string]*strings.Builder{},
+		alternativeWords: map[string]int{},
+		report:           &models.RedactionReport{Entities: map[string]int{}, Fields: map[string]int{}},
+	}
+
+	records := 0
+	if first != nil {
+		records++
+		if err := clip.add(first); err != nil {
+			send(streamProgress{Type: "error", Records: records, Words: clip.words, Error: fmt.Sprintf("record %d: %v", records, err)})
+			return
+		}
+	}
+	for extendDeadlines(); scanner.Scan(); extendDeadlines() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		records++
+		var record streamRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			send(streamProgress{Type: "error", Records: records, Words: clip.words, Error: fmt.Sprintf("record %d: %v", records, err)})
+			return
+		}
+		if err := clip.add(&record); err != nil {
+			send(streamProgress{Type: "error", Records: records, Words: clip.words, Error: fmt.Sprintf("record %d: %v", records, err)})
+			return
+		}
+		if records%streamProgressEvery == 0 {
+			send(streamProgress{Type: "progress", Records: records, Words: clip.words})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		send(streamProgress{Type: "error", Records: records, Words: clip.words, Error: err.Error()})
+		return
+	}
+
+	clip.data.FinalTranscription = clip.transcript.String()
+	for model, alternative := range clip.alternatives {
+		clip.data.ASRAlternatives[model] = alternative.String()
+	}
+	s.storeWords(ctx, clip.data)
+	err = s.indexClip(ctx, meta.AudioID, clip.data)
+	s.ingests.record(time.Since(start), err)
+	if err != nil {
+		send(streamProgress{Type: "error", Records: records, Words: clip.words, Error: err.Error()})
+		return
+	}
+
+	done := streamProgress{Type: "done", Records: records, Words: clip.words, AudioID: services.NormalizeAudioID(meta.AudioID)}
+	if clip.report.Mode != services.PIIOff && clip.report.Mode != "" {
+		done.Redaction = clip.report
+	}
+	if version, err := s.clipVersion(ctx, done.AudioID); err == nil {
+		done.Version = version
+	}
+	send(done)
+}
+
+// streamedClip accumulates the redacted text of a streaming initialize until
+// the body ends
+type streamedClip struct {
+	data             *models.AutocompleteData
+	transcript       strings.Builder
+	alternatives     map[string]*strings.Builder
+	alternativeWords map[string]int
+	report           *models.RedactionReport
+	words            int
+}
+
+// add redacts one record and appends it to the clip being assembled, failing
+// once the clip so far is past the /initialize size limits
+func (clip *streamedClip) add(record *streamRecord) error {
+	part := &models.AutocompleteData{ConfidenceScore: clip.data.ConfidenceScore}
+	switch record.Type {
+	case "transcript":
+		part.FinalTranscription = record.Text
+	case "alternative":
+		if record.Model == "" {
+			return fmt.Errorf("alternative record needs a model")
+		}
+		part.ASRAlternatives = map[string]string{record.Model: record.Text}
+	case "particles":
+		part.DetectedParticles = record.Particles
+	case "meta":
+		return fmt.Errorf("meta record must come first")
+	default:
+		return fmt.Errorf("unknown record type %q", record.Type)
+	}
+
+	part, report := services.RedactAutocompleteData(services.NormalizeAutocompleteData(part))
+	mergeRedactionReport(clip.report, report)
+
+	switch record.Type {
+	case "transcript":
+		appendText(&clip.transcript, part.FinalTranscription)
+		clip.words += len(strings.Fields(part.FinalTranscription))
+	case "alternative":
+		builder, exists := clip.alternatives[record.Model]
+		if !exists {
+			builder = &strings.Builder{}
+			clip.alternatives[record.Model] = builder
+		}
+		appendText(builder, part.ASRAlternatives[record.Model])
+		clip.alternativeWords[record.Model] += len(strings.Fields(part.ASRAlternatives[record.Model]))
+	case "particles":
+		clip.data.DetectedParticles = append(clip.data.DetectedParticles, part.DetectedParticles...)
+	}
+	return checkWordCounts(clip.words, clip.alternativeWords, len(clip.data.DetectedParticles))
+}
+
+// appendText joins transcript segments with a single space
+func appendText(builder *strings.Builder, text string) {
+	if text == "" {
+		return
+	}
+	if builder.Len() > 0 {
+		builder.WriteByte(' ')
+	}
+	builder.WriteString(text)
+}
+
+// mergeRedactionReport adds one record's PII counts to the running report
+func mergeRedactionReport(into, from *models.RedactionReport) {
+	into.Mode = from.Mode
+	into.TotalMatches += from.TotalMatches
+	into.Redacted = into.Redacted || from.Redacted
+	for entity, count := range from.Entities {
+		into.Entities[entity] += count
+	}
+	for field, count := range from.Fields {
+		into.Fields[field] += count
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestStreamedClipAdd(t *testing.T) {
+	defer func(limit int) { maxInitializeWords = limit }(maxInitializeWords)
+	maxInitializeWords = 4
+
+	transcript := func(text string) streamRecord { return streamRecord{Type: "transcript", Text: text} }
+	alternative := func(model, text string) streamRecord {
+		return streamRecord{Type: "alternative", Model: model, Text: text}
+	}
+	tests := []struct {
+		name    string
+		records []streamRecord
+		wantErr string // Error of the last record; earlier ones must succeed
+	}{
+		{"within limits", []streamRecord{transcript("saya nak"), transcript("pergi"), alternative("whisper", "saya nak pegi")}, ""},
+		{"transcript over the total", []streamRecord{transcript("saya nak"), transcript("pergi ke"), transcript("pasar")}, "final_transcription has 5 words"},
+		{"alternative over the total", []streamRecord{alternative("vosk", "saya nak"), alternative("whisper", "saya nak"), alternative("vosk", "pergi ke pasar")}, "vosk alternative has 5 words"},
+		{"particles over the total", []streamRecord{{Type: "particles", Particles: []string{"lah", "kan", "pun"}}, {Type: "particles", Particles: []string{"lah", "kan"}}}, "detected_particles has 5 entries"},
+		{"alternative without model", []streamRecord{transcript("saya"), alternative("", "saya")}, "needs a model"},
+		{"late meta", []streamRecord{transcript("saya"), {Type: "meta"}}, "meta record must come first"},
+		{"unknown type", []streamRecord{{Type: "audio"}}, "unknown record type"},
+	}
+	for _, tt := range tests {
+		clip := &streamedClip{
+			data:             &models.AutocompleteData{ConfidenceScore: 1},
+			alternatives:     map[string]*strings.Builder{},
+			alternativeWords: map[string]int{},
+			report:           &models.RedactionReport{Entities: map[string]int{}, Fields: map[string]int{}},
+		}
+		var err error
+		for i := range tt.records {
+			if err = clip.add(&tt.records[i]); err != nil && i < len(tt.records)-1 {
+				t.Fatalf("%s: record %d: %v", tt.name, i+1, err)
+			}
+		}
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if (tt.wantErr == "") != (got == "") || !strings.Contains(got, tt.wantErr) {
+			t.Errorf("%s: last record error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
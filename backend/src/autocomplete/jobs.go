@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// globalFrequencyKey accumulates how often each word has been ingested
+const globalFrequencyKey = redisKeyPrefix + "global:frequency"
+
+// registerBackgroundJobs builds the leader-only jobs enabled by configuration
+func (s *AutocompleteService) registerBackgroundJobs() {
+	// Frequency decay: multiplying scores on every replica would compound the
+	// decay, which is why it runs on the leader only
+	if factor, err := strconv.ParseFloat(os.Getenv("FREQUENCY_DECAY_FACTOR"), 64); err == nil && factor > 0 && factor < 1 {
+		interval := time.Hour
+		if parsed, err := time.ParseDuration(os.Getenv("FREQUENCY_DECAY_INTERVAL")); err == nil && parsed > 0 {
+			interval = parsed
+		}
+
+		s.jobs = append(s.jobs, &backgroundJob{
+			name:     "frequency_decay",
+			interval: interval,
+			run: func(ctx context.Context) error {
+				return s.decayGlobalFrequency(ctx, factor)
+			},
+		})
+		frequencyDecayFactor = factor
+		log.Printf("Frequency decay enabled: x%.3f every %s", factor, interval)
+	}
+
+	s.registerMemoryWatchdog()
+}
+
+// decayGlobalFrequency scales every global word frequency by factor and drops
+// words whose frequency has decayed to nothing
+func (s *AutocompleteService) decayGlobalFrequency(ctx context.Context, factor float64) error {
+	pipe := s.RedisClient.TxPipeline()
+	pipe.ZUnionStore(ctx, globalFrequencyKey, &redis.ZStore{
+		Keys:    []string{globalFrequencyKey},
+		Weights: []float64{factor},
+	})
+	pipe.ZRemRangeByScore(ctx, globalFrequencyKey, "-inf", "0.01")
+	_, err := pipe.Exec(ctx)
+	return err
+}
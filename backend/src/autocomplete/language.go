@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Language routing modes: restrict offers only words of the requested
+// language, prefer ranks them ahead of the clip's other words
+const (
+	langModeRestrict = "restrict"
+	langModePrefer   = "prefer"
+)
+
+// languageFetch is the minimum number of trie matches read per sub-index, so
+// words with several candidates don't crowd out the rest once deduplicated
+const languageFetch = 50
+
+// getLanguageSuggestions completes the prefix from the clip's sub-index for
+// lang, best candidate per word. With prefer, the clip's words of other
+// languages follow. Each suggestion reports its "lang".
+func (s *AutocompleteService) getLanguageSuggestions(ctx context.Context, tenant, prefix, audioID, lang string, prefer bool, maxResults int) ([]map[string]interface{}, error) {
+	fetch := suggestFetchCount(tenant, maxResults)
+	if fetch < languageFetch {
+		fetch = languageFetch
+	}
+
+	trie, err := s.clipLanguageTrie(ctx, audioID, lang, prefix)
+	if err != nil {
+		return nil, err
+	}
+	ranked := bestPerWord(trie.SearchSuggestions(prefix, fetch))
+	languages := make(map[string]string, len(ranked))
+	for _, suggestion := range ranked {
+		languages[suggestion.Text] = lang
+	}
+
+	if prefer {
+		trie, err := s.clipTrie(ctx, audioID, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, suggestion := range bestPerWord(trie.SearchSuggestions(prefix, fetch)) {
+			if _, seen := languages[suggestion.Text]; !seen {
+				languages[suggestion.Text] = services.DetectLanguage(suggestion.Text)
+				ranked = append(ranked, suggestion)
+			}
+		}
+	}
+
+	ranked = services.ApplySuggestFilters(tenant, ranked)
+	if len(ranked) > maxResults {
+		ranked = ranked[:maxResults]
+	}
+	suggestions := make([]map[string]interface{}, len(ranked))
+	for i, suggestion := range ranked {
+		suggestions[i] = map[string]interface{}{
+			"text":       suggestion.Text,
+			"confidence": suggestion.Confidence,
+			"lang":       languages[suggestion.Text],
+		}
+	}
+	return suggestions, nil
+}
+
+// bestPerWord keeps the most confident suggestion of each word, in confidence order
+func bestPerWord(suggestions []models.WordSuggestion) []models.WordSuggestion {
+	best := make(map[string]models.WordSuggestion, len(suggestions))
+	for _, suggestion := range suggestions {
+		if previous, seen := best[suggestion.Text]; !seen || suggestion.Confidence > previous.Confidence {
+			best[suggestion.Text] = suggestion
+		}
+	}
+
+	words := make([]models.WordSuggestion, 0, len(best))
+	for _, suggestion := range best {
+		words = append(words, suggestion)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].Confidence != words[j].Confidence {
+			return words[i].Confidence > words[j].Confidence
+		}
+		return words[i].Text < words[j].Text
+	})
+	return words
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestBestPerWord(t *testing.T) {
+	suggestions := []models.WordSuggestion{
+		{Text: "makan", Confidence: 0.4, Source: "whisper"},
+		{Text: "makna", Confidence: 0.6},
+		{Text: "makan", Confidence: 0.9, Source: "gemini_final"},
+		{Text: "maka", Confidence: 0.6},
+	}
+	want := []models.WordSuggestion{
+		{Text: "makan", Confidence: 0.9, Source: "gemini_final"},
+		{Text: "maka", Confidence: 0.6},
+		{Text: "makna", Confidence: 0.6},
+	}
+	if got := bestPerWord(suggestions); !reflect.DeepEqual(got, want) {
+		t.Errorf("bestPerWord() = %v, want %v", got, want)
+	}
+}
+
+func TestGetLanguageSuggestions(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{
+		FinalTranscription: "they makan these",
+		ConfidenceScore:    0.9,
+	})
+
+	s := &AutocompleteService{}
+	type suggestion struct{ text, lang string }
+	tests := []struct {
+		name    string
+		prefix  string
+		lang    string
+		prefer  bool
+		audioID string
+		want    []suggestion
+		wantErr bool
+	}{
+		{"restrict", "the", services.LangEnglish, false, "clip", []suggestion{{"these", "en"}, {"they", "en"}}, false},
+		{"restrict to another language", "the", services.LangMalay, false, "clip", []suggestion{}, false},
+		{"prefer", "", services.LangMalay, true, "clip", []suggestion{{"makan", "ms"}, {"these", "en"}, {"they", "en"}}, false},
+		{"unknown clip", "the", services.LangEnglish, false, "other", nil, true},
+	}
+	for _, tt := range tests {
+		suggestions, err := s.getLanguageSuggestions(context.Background(), "", tt.prefix, tt.audioID, tt.lang, tt.prefer, 10)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		got := []suggestion{}
+		for _, result := range suggestions {
+			got = append(got, suggestion{result["text"].(string), result["lang"].(string)})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: getLanguageSuggestions(%q, %s) = %v, want %v", tt.name, tt.prefix, tt.lang, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// leaderKey holds the id of the replica currently running background jobs
+	leaderKey = redisKeyPrefix + "leader"
+
+	// leaderTTL is how long leadership survives without renewal; a dead
+	// leader is replaced within this window
+	leaderTTL = 15 * time.Second
+)
+
+// leaderElector campaigns for leadership and keeps it renewed while held
+type leaderElector struct {
+	client   *redis.Client
+	id       string
+	isLeader atomic.Bool
+}
+
+// newLeaderElector identifies this replica by hostname plus a random suffix
+func newLeaderElector(client *redis.Client) *leaderElector {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+
+	return &leaderElector{
+		client: client,
+		id:     hostname + "-" + hex.EncodeToString(suffix),
+	}
+}
+
+// run campaigns every third of the TTL until ctx ends, then steps down
+func (e *leaderElector) run(ctx context.Context) {
+	ticker := time.NewTicker(leaderTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		e.campaign(ctx)
+
+		select {
+		case <-ctx.Done():
+			if e.isLeader.Load() {
+				releaseLockScript.Run(context.Background(), e.client, []string{leaderKey}, e.id)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign renews leadership if held, otherwise tries to take the vacant seat
+func (e *leaderElector) campaign(ctx context.Context) {
+	if e.isLeader.Load() {
+		renewed, err := renewLockScript.Run(ctx, e.client, []string{leaderKey}, e.id, leaderTTL.Milliseconds()).Int()
+		if err == nil && renewed == 1 {
+			return
+		}
+		e.isLeader.Store(false)
+		log.Printf("Lost leadership (%s)", e.id)
+	}
+
+	acquired, err := e.client.SetNX(ctx, leaderKey, e.id, leaderTTL).Result()
+	if err != nil {
+		log.Printf("Error campaigning for leadership: %v", err)
+		return
+	}
+	if acquired {
+		e.isLeader.Store(true)
+		log.Printf("Became leader (%s)", e.id)
+	}
+}
+
+// backgroundJob is periodic work that must run on exactly one replica
+type backgroundJob struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+
+	mu      sync.Mutex
+	lastRun time.Time
+	lastErr error
+	runs    int64
+}
+
+// startBackgroundJobs ticks every job, running it only while this replica leads
+func (s *AutocompleteService) startBackgroundJobs(ctx context.Context) {
+	for _, job := range s.jobs {
+		go func(job *backgroundJob) {
+			ticker := time.NewTicker(job.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				if !s.leader.isLeader.Load() {
+					continue
+				}
+
+				err := job.run(ctx)
+				if err != nil {
+					log.Printf("Background job %s failed: %v", job.name, err)
+				}
+
+				job.mu.Lock()
+				job.lastRun = time.Now()
+				job.lastErr = err
+				job.runs++
+				job.mu.Unlock()
+			}
+		}(job)
+	}
+}
+
+func (s *AutocompleteService) handleLeaderStatus(c *gin.Context) {
+	current, err := s.RedisClient.Get(context.Background(), leaderKey).Result()
+	if err != nil && err != redis.Nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	jobs := make([]gin.H, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		job.mu.Lock()
+		status := gin.H{
+			"name":     job.name,
+			"interval": job.interval.String(),
+			"runs":     job.runs,
+		}
+		if !job.lastRun.IsZero() {
+			status["last_run"] = job.lastRun
+		}
+		if job.lastErr != nil {
+			status["last_error"] = job.lastErr.Error()
+		}
+		job.mu.Unlock()
+		jobs = append(jobs, status)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"instance_id": s.leader.id,
+		"is_leader":   s.leader.isLeader.Load(),
+		"leader":      current,
+		"jobs":        jobs,
+	})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// unreachableRedis returns a client whose every command fails fast
+func unreachableRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestLeaderElectorCampaignWithoutRedis(t *testing.T) {
+	tests := []struct {
+		name     string
+		isLeader bool
+	}{
+		{"follower stays a follower", false},
+		{"leader that cannot renew steps down", true},
+	}
+	for _, tt := range tests {
+		elector := newLeaderElector(unreachableRedis(t))
+		elector.isLeader.Store(tt.isLeader)
+		elector.campaign(context.Background())
+		if elector.isLeader.Load() {
+			t.Errorf("%s: still leader after a failed campaign", tt.name)
+		}
+	}
+}
+
+func TestNewLeaderElectorIDsDiffer(t *testing.T) {
+	client := unreachableRedis(t)
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		id := newLeaderElector(client).id
+		if seen[id] {
+			t.Fatalf("replica id %s handed out twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestBackgroundJobsRunOnlyWhileLeading(t *testing.T) {
+	runs := make(chan struct{}, 100)
+	job := &backgroundJob{
+		name:     "test",
+		interval: 5 * time.Millisecond,
+		run: func(ctx context.Context) error {
+			runs <- struct{}{}
+			return nil
+		},
+	}
+	service := &AutocompleteService{leader: &leaderElector{}, jobs: []*backgroundJob{job}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.startBackgroundJobs(ctx)
+
+	select {
+	case <-runs:
+		t.Fatal("job ran on a follower")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	service.leader.isLeader.Store(true)
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("job did not run on the leader")
+	}
+}
+
+func TestRegisterBackgroundJobs(t *testing.T) {
+	defer func(factor float64) { frequencyDecayFactor = factor }(frequencyDecayFactor)
+
+	tests := []struct {
+		factor       string
+		interval     string
+		wantJob      bool
+		wantInterval time.Duration
+	}{
+		{"", "", false, 0},
+		{"0.9", "", true, time.Hour},
+		{"0.9", "10m", true, 10 * time.Minute},
+		{"0.9", "soon", true, time.Hour},
+		{"1", "", false, 0},
+		{"0", "", false, 0},
+		{"half", "", false, 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("FREQUENCY_DECAY_FACTOR", tt.factor)
+		t.Setenv("FREQUENCY_DECAY_INTERVAL", tt.interval)
+		t.Setenv("MEMORY_WATCHDOG_MAX_BYTES", "")
+		t.Setenv("MEMORY_WATCHDOG_MAX_KEYS", "")
+
+		service := &AutocompleteService{}
+		service.registerBackgroundJobs()
+		if got := len(service.jobs) == 1; got != tt.wantJob {
+			t.Errorf("factor %q: registered %d jobs", tt.factor, len(service.jobs))
+			continue
+		}
+		if tt.wantJob && service.jobs[0].interval != tt.wantInterval {
+			t.Errorf("factor %q interval %q: every %s, want %s", tt.factor, tt.interval, service.jobs[0].interval, tt.wantInterval)
+		}
+	}
+}
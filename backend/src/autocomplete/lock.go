@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// clipLockTTL is how long a lock survives without renewal, e.g. after a crash
+	clipLockTTL = 30 * time.Second
+
+	// clipLockRetryInterval is how often a waiting caller retries the lock
+	clipLockRetryInterval = 100 * time.Millisecond
+)
+
+// errClipLocked is returned when another caller holds the clip's lock
+var errClipLocked = errors.New("clip is being initialized by another request")
+
+// Only the holder's token may extend or release a lock
+var (
+	renewLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// clipLock is a held per-clip lock, renewed in the background until released
+type clipLock struct {
+	client *redis.Client
+	key    string
+	token  string
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// clipLockKey is the Redis key serializing rebuilds of a clip
+func clipLockKey(audioID string) string {
+	return clipKeyPrefix(audioID) + "lock"
+}
+
+// acquireClipLock takes the clip's lock with SET NX. With a zero wait it fails
+// immediately when the lock is held; otherwise it retries until wait elapses
+// or ctx is cancelled.
+func (s *AutocompleteService) acquireClipLock(ctx context.Context, audioID string, wait time.Duration) (*clipLock, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, err
+	}
+
+	lock := &clipLock{
+		client: s.clipClient(audioID),
+		key:    clipLockKey(audioID),
+		token:  hex.EncodeToString(tokenBytes),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		acquired, err := lock.client.SetNX(ctx, lock.key, lock.token, clipLockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			go lock.renew()
+			return lock, nil
+		}
+		if time.Now().Add(clipLockRetryInterval).After(deadline) {
+			return nil, errClipLocked
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(clipLockRetryInterval):
+		}
+	}
+}
+
+// renew extends the lock every third of its TTL until released
+func (l *clipLock) renew() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(clipLockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			renewed, err := renewLockScript.Run(context.Background(), l.client, []string{l.key}, l.token, clipLockTTL.Milliseconds()).Int()
+			if err != nil || renewed == 0 {
+				return // Lost the lock; the holder finishes but no longer excludes others
+			}
+		}
+	}
+}
+
+// release stops renewal and deletes the lock if this holder still owns it
+func (l *clipLock) release(ctx context.Context) error {
+	close(l.stop)
+	<-l.done
+	return releaseLockScript.Run(ctx, l.client, []string{l.key}, l.token).Err()
+}
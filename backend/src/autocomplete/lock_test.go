@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClipLockKey(t *testing.T) {
+	tests := []struct {
+		audioID string
+		want    string
+	}{
+		{"clip", clipKeyPrefix("clip") + "lock"},
+		{"global", clipKeyPrefix("global") + "lock"},
+		{"clip:2", clipKeyPrefix("clip:2") + "lock"},
+	}
+	for _, tt := range tests {
+		if got := clipLockKey(tt.audioID); got != tt.want || !strings.HasPrefix(got, redisKeyPrefix) {
+			t.Errorf("clipLockKey(%q) = %q, want %q", tt.audioID, got, tt.want)
+		}
+	}
+	if clipLockKey("a") == clipLockKey("b") {
+		t.Error("two clips share a lock")
+	}
+}
+
+func TestAcquireClipLockWithoutRedis(t *testing.T) {
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+
+	tests := []struct {
+		name string
+		wait time.Duration
+	}{
+		{"fail fast", 0},
+		{"wait for the lock", time.Second},
+	}
+	for _, tt := range tests {
+		start := time.Now()
+		lock, err := s.acquireClipLock(context.Background(), "clip", tt.wait)
+		if err == nil || err == errClipLocked || lock != nil {
+			t.Errorf("%s: acquireClipLock() = %v, %v, want the connection error", tt.name, lock, err)
+		}
+		if elapsed := time.Since(start); elapsed >= tt.wait && tt.wait > 0 {
+			t.Errorf("%s: acquireClipLock() retried for %v instead of failing on the error", tt.name, elapsed)
+		}
+	}
+}
+
+func TestClipLockReleaseStopsRenewal(t *testing.T) {
+	lock := &clipLock{
+		client: unreachableRedis(t),
+		key:    clipLockKey("clip"),
+		token:  "token",
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go lock.renew()
+
+	if err := lock.release(context.Background()); err == nil {
+		t.Error("release() without Redis succeeded")
+	}
+	select {
+	case <-lock.done:
+	default:
+		t.Error("renewal still running after release")
+	}
+}
@@ -2,43 +2,347 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
 )
 
 type AutocompleteService struct {
 	RedisClient *redis.Client
+
+	// ReadClients are read replicas serving suggest lookups; empty means reads go to RedisClient
+	ReadClients []*redis.Client
+	nextRead    atomic.Uint64
+
+	// Stateless keeps no clip state in process memory: clip indexes live in Redis only
+	Stateless bool
+
+	// ReplayLogEnabled records suggest queries and acceptances for offline evaluation
+	ReplayLogEnabled bool
+
+	// ShardConfigPath enables routing clip keys to Redis shards by consistent hash of audio_id
+	ShardConfigPath string
+	shards          *shardSet
+	shardMutex      sync.RWMutex
+
+	// TopK is how many results per prefix are materialized at ingest; 0 disables it
+	TopK int
+
+	// ReadModel holds the materialized top-k when READ_MODEL_URL names a
+	// separate Redis; nil keeps it on RedisClient
+	ReadModel *redis.Client
+
+	// Projects prefix writes into the top-k read model in the background, nil when inline
+	projector *topKProjector
+
+	// WebSocket keystroke sessions on /suggest/stream
+	streams *suggestStreams
+
+	// Per-class request pools keeping interactive traffic ahead of bulk work
+	pools map[string]*requestPool
+
+	// Per-route adaptive concurrency limits, nil when disabled
+	adaptive *adaptiveLimits
+
+	// Global vocabulary trie walked by fuzzy lookups
+	vocabulary globalVocabulary
+
+	// Bounded queue drained by workers so /initialize doesn't wait on Redis
+	queue *writeQueue
+
+	// Embeds initialized clips' sentences in the background, nil without EMBEDDING_URL
+	embeddings *embeddingPrecompute
+
+	// Leader election gating background jobs to a single replica
+	leader *leaderElector
+	jobs   []*backgroundJob
+
+	// Redis memory watchdog job, nil when no threshold is configured
+	watchdog *memoryWatchdog
+
+	// Redis suggest lookups that returned at least one / no suggestion
+	suggestHits   atomic.Int64
+	suggestMisses atomic.Int64
+
+	// Suggest lookups answered partially because their budget ran out
+	suggestPartial atomic.Int64
+
+	// Freshness and latency of orchestrator ingests, nil in offline tools
+	ingests *ingestHealth
+
+	// Reject mutations of existing clips that don't send If-Match
+	RequireIfMatch bool
+
+	// Append every index change to the event log for replay-events
+	EventLog bool
+
+	// How long soft-deleted clips can be restored
+	ClipRetention time.Duration
+
+	// Sliding expiration of queried clips' Redis keys, nil when disabled
+	touches *clipTouches
+
+	// Moves idle stateless clips to cold storage, nil unless configured
+	archive *clipArchiver
+
+	// Deduplicates redelivered ingest calls by nonce
+	replay *replayGuard
+
+	// Takes the replica out of rotation and hands off its state before shutdown
+	drain *drainState
+
+	// Sends signed completion and feedback callbacks, nil unless configured
+	webhooks *webhookSender
+
+	// Forwards corrections to the orchestrator, nil unless configured
+	outbox *feedbackOutbox
 }
 
 func main() {
-	// Initialize Redis connection
-	redisURL := os.Getenv("REDIS_URL")
+	// Subcommands run offline tools instead of the HTTP server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "build-index":
+			runBuildIndex(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		case "rebalance":
+			runRebalance(os.Args[2:])
+			return
+		case "selftest":
+			runSelftest(os.Args[2:])
+			return
+		case "gen":
+			runGen(os.Args[2:])
+			return
+		case "replay-events":
+			runReplayEvents(os.Args[2:])
+			return
+		}
+	}
+
+	runServer()
+}
+
+// connectRedis opens the primary Redis connection from REDIS_URL (or
+// REDIS_WRITE_URL) and verifies it with a ping
+func connectRedis(ctx context.Context) *redis.Client {
+	return connectRedisURL(ctx, redisURLFromEnv())
+}
+
+// redisURLFromEnv is the primary Redis URL: REDIS_WRITE_URL, else REDIS_URL
+func redisURLFromEnv() string {
+	redisURL := os.Getenv("REDIS_WRITE_URL")
+	if redisURL == "" {
+		redisURL = os.Getenv("REDIS_URL")
+	}
 	if redisURL == "" {
 		redisURL = "redis://redis:6379"
 	}
+	return redisURL
+}
 
-	opt, err := redis.ParseURL(redisURL)
+// connectRedisURL opens a Redis connection and verifies it with a ping
+func connectRedisURL(ctx context.Context, redisURL string) *redis.Client {
+	opt, err := redisOptions(redisURL)
 	if err != nil {
 		log.Fatalf("Failed to parse Redis URL: %v", err)
 	}
 
 	redisClient := redis.NewClient(opt)
+	instrumentRedis(redisClient)
 	
 	// Test Redis connection
-	ctx := context.Background()
 	_, err = redisClient.Ping(ctx).Result()
 	if err != nil {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
-	log.Println("Successfully connected to Redis")
+	log.Printf("Successfully connected to Redis at %s", opt.Addr)
+
+	return redisClient
+}
+
+func runServer() {
+	slowQueries.threshold = envDuration("SLOW_QUERY_THRESHOLD", slowQueries.threshold)
+	maxInitializeWords = envInt("MAX_INITIALIZE_WORDS", maxInitializeWords)
+	services.SetBuildWorkers(envInt("BUILD_WORKERS", services.BuildWorkers()))
+	maxInitializeBytes = int64(envInt("MAX_INITIALIZE_BYTES", int(maxInitializeBytes)))
+	positionBlendWeight = envFraction("POSITION_BLEND_WEIGHT", positionBlendWeight)
+	suggestBudget = envDuration("SUGGEST_BUDGET", suggestBudget)
+	services.SetDefaultSuggestResults(envInt("DEFAULT_MAX_RESULTS", services.DefaultSuggestResults()))
+	configureEmbeddings()
+	if err := configureSuggestBackends(os.Getenv("SUGGEST_BACKENDS")); err != nil {
+		log.Fatalf("Failed to configure suggest backends: %v", err)
+	}
+
+	// Initialize Redis connection
+	ctx := context.Background()
+	redisClient := connectRedis(ctx)
 
 	service := &AutocompleteService{
-		RedisClient: redisClient,
+		RedisClient:      redisClient,
+		Stateless:        os.Getenv("STATELESS_MODE") == "true",
+		ReplayLogEnabled: os.Getenv("QUERY_REPLAY_LOG") == "true",
+		ShardConfigPath:  os.Getenv("SHARD_CONFIG"),
+		TopK:             topKSetting(),
+		projector:        newTopKProjector(),
+		pools:            newPriorityPools(),
+		streams:          newSuggestStreams(),
+		ingests:          newIngestHealth(envDuration("INGEST_STALE_AFTER", 0)),
+		RequireIfMatch:   os.Getenv("REQUIRE_IF_MATCH") == "true",
+		EventLog:         os.Getenv("EVENT_LOG") == "true",
+		ClipRetention:    envDuration("CLIP_RETENTION", 7*24*time.Hour),
+		drain:            newDrainState(),
+		replay:           newReplayGuard(envDuration("REPLAY_WINDOW", 5*time.Minute), os.Getenv("REQUIRE_REPLAY_HEADERS") == "true"),
+		touches:          newClipTouches(envDuration("CLIP_TTL_CAP", 24*time.Hour), envDuration("CLIP_TTL_REFRESH_INTERVAL", time.Minute), false),
+	}
+
+	// Drain word writes in the background so /initialize returns quickly
+	service.startWriteQueue(ctx, envInt("INGEST_QUEUE_SIZE", 10000), envInt("INGEST_WORKERS", 4), envDuration("WRITE_BATCH_WINDOW", 50*time.Millisecond))
+	if embeddingURL != "" {
+		service.startEmbeddingPrecompute(ctx, envInt("EMBEDDING_PRECOMPUTE_WORKERS", 2))
+	}
+
+	// Keep the suggest read model apart from the write model, projected in the background
+	if readModelURL := os.Getenv("READ_MODEL_URL"); readModelURL != "" {
+		service.ReadModel = connectRedisURL(ctx, readModelURL)
+	}
+	if service.projector != nil && service.TopK > 0 {
+		go service.runProjector(ctx)
+	}
+
+	// Send suggest reads to replicas so initialize bursts on the primary don't slow typing
+	for _, readURL := range strings.Split(os.Getenv("REDIS_READ_URLS"), ",") {
+		if readURL = strings.TrimSpace(readURL); readURL != "" {
+			service.ReadClients = append(service.ReadClients, connectRedisURL(ctx, readURL))
+		}
+	}
+
+	// Route clip data across Redis shards when configured; SIGHUP reloads the config
+	if service.ShardConfigPath != "" {
+		if err := service.reloadShards(ctx); err != nil {
+			log.Fatalf("Failed to configure Redis shards: %v", err)
+		}
+		go service.watchShardReloads(ctx)
+	}
+
+	if err := services.ConfigureResources(os.Getenv("RESOURCE_DIR")); err != nil {
+		log.Fatalf("Failed to configure language resources: %v", err)
+	}
+	if err := services.ConfigureTextNormalization(os.Getenv("TEXT_NORMALIZATION")); err != nil {
+		log.Fatalf("Failed to configure text normalization: %v", err)
+	}
+	if err := services.ConfigureHomophones(); err != nil {
+		log.Fatalf("Failed to load homophone table: %v", err)
+	}
+	if err := services.ConfigureStemmer(); err != nil {
+		log.Fatalf("Failed to load stemmer overrides: %v", err)
+	}
+	if err := services.ConfigureKeyboardLayout(os.Getenv("KEYBOARD_LAYOUT")); err != nil {
+		log.Fatalf("Failed to configure keyboard layout: %v", err)
+	}
+	if err := services.ConfigureProfanityFilter(os.Getenv("PROFANITY_MODE"), os.Getenv("PROFANITY_LIST")); err != nil {
+		log.Fatalf("Failed to configure profanity filter: %v", err)
+	}
+
+	if err := services.ConfigurePIIRedaction(os.Getenv("PII_REDACTION"), os.Getenv("PII_RULES")); err != nil {
+		log.Fatalf("Failed to configure PII redaction: %v", err)
+	}
+
+	// Mirror tenant blocklists and follow updates published by other replicas
+	if err := service.loadBlocklists(ctx); err != nil {
+		log.Fatalf("Failed to load blocklists: %v", err)
+	}
+	go service.watchBlocklistUpdates(ctx)
+
+	// Mirror clip aliases the same way
+	if err := service.loadClipAliases(ctx); err != nil {
+		log.Fatalf("Failed to load clip aliases: %v", err)
+	}
+	go service.watchAliasUpdates(ctx)
+
+	// Background jobs run on whichever replica holds leadership
+	service.leader = newLeaderElector(redisClient)
+	// Notify receivers of completed ingests and feedback with signed callbacks
+	if err := service.startWebhooks(ctx); err != nil {
+		log.Fatalf("Failed to configure webhooks: %v", err)
+	}
+
+	// Move idle clips to cold storage instead of letting them expire
+	if err := service.configureArchive(); err != nil {
+		log.Fatalf("Failed to configure clip archiving: %v", err)
+	}
+	service.registerBackgroundJobs()
+	go service.leader.run(ctx)
+	service.startBackgroundJobs(ctx)
+
+	// Delta sync stamps local index changes with this instance's ID
+	syncOrigin := os.Getenv("SYNC_INSTANCE_ID")
+	if syncOrigin == "" {
+		syncOrigin, _ = os.Hostname()
+	}
+	services.ConfigureSyncOrigin(syncOrigin)
+
+	// Every replica delivers its share of the correction outbox to the orchestrator
+	if service.outbox = newFeedbackOutbox(service.leader.id); service.outbox != nil {
+		go service.runOutbox(ctx)
+	}
+
+	// Replicas merge their review sessions so feedback re-ranks a clip everywhere
+	if interval := envDuration("SESSION_MERGE_INTERVAL", 5*time.Second); interval > 0 && !service.Stateless {
+		services.SetSessionReplica(service.leader.id)
+		go service.runSessionMerge(ctx, service.leader.id, interval, envDuration("SESSION_MERGE_TTL", 24*time.Hour))
+	}
+
+	// Hydrate from the instance being replaced so traffic can switch over
+	// without a window of "autocomplete not initialized" errors
+	if restoreFrom := os.Getenv("RESTORE_FROM"); restoreFrom == "redis" && !service.Stateless {
+		// A drained replica handed its clips off through Redis
+		restored, err := service.restoreHandoffs(ctx)
+		if err != nil {
+			log.Fatalf("Failed to restore handoffs: %v", err)
+		}
+		log.Printf("Restored %d clips from drained replicas", restored)
+	} else if restoreFrom != "" && !service.Stateless {
+		snapshot, err := fetchSnapshot(restoreFrom)
+		if err != nil {
+			log.Fatalf("Failed to restore from %s: %v", restoreFrom, err)
+		}
+		log.Printf("Restored %d clips from %s", services.RestoreSnapshot(snapshot), restoreFrom)
+	}
+
+	// Warm-start the global index so a fresh deployment has suggestions
+	if seedPath := os.Getenv("SEED_CORPUS"); seedPath != "" {
+		count, err := service.loadSeedCorpus(ctx, seedPath)
+		if err != nil {
+			log.Fatalf("Failed to load seed corpus: %v", err)
+		}
+		log.Printf("Loaded %d seed words from %s", count, seedPath)
+	}
+
+	// A public demo serves a few sample clips, read-only and rate limited
+	demo := os.Getenv("DEMO_MODE") == "true"
+	if demo {
+		count, err := service.seedDemoClips(ctx)
+		if err != nil {
+			log.Fatalf("Failed to seed demo clips: %v", err)
+		}
+		log.Printf("Demo mode: seeded %d sample clips", count)
 	}
 
 	// Setup Gin router
@@ -56,11 +360,87 @@ func main() {
 		}
 		c.Next()
 	})
+	router.Use(service.drainMiddleware())
+	if demo {
+		router.Use(newDemoLimiter(envInt("DEMO_RATE_LIMIT", 5), envInt("DEMO_RATE_BURST", 20)).middleware())
+		router.Use(demoMiddleware())
+	}
+	router.Use(service.priorityMiddleware())
+	if os.Getenv("ADAPTIVE_CONCURRENCY") == "true" {
+		service.adaptive = &adaptiveLimits{routes: make(map[string]*adaptiveLimiter)}
+		router.Use(service.adaptiveLimitMiddleware())
+	}
+	if service.archive != nil {
+		router.Use(service.archiveMiddleware())
+	}
 
 	// Register routes
 	router.GET("/health", service.handleHealth)
-	router.POST("/initialize", service.handleInitialize)
+	router.GET("/readyz", service.handleReadyz)
+	router.POST("/initialize", service.replayMiddleware(), service.handleInitialize)
+	router.POST("/initialize/chunks", service.replayMiddleware(), service.handleInitializeChunk)
+	router.POST("/initialize/stream", service.replayMiddleware(), service.handleInitializeStream)
 	router.GET("/suggest/prefix", service.handlePrefixSuggest)
+	router.GET("/suggest/suffix", service.handleSuffixSuggest)
+	router.GET("/suggest/pattern", service.handlePatternSuggest)
+	router.GET("/suggest/insertions", service.handleInsertionSuggestions)
+	router.GET("/suggest/positions", service.handlePositionRange)
+	router.GET("/suggest/next", service.handleNextWordSuggest)
+	router.GET("/suggest/semantic", service.handleSemanticSuggest)
+	router.GET(suggestStreamPath, service.handleSuggestStream)
+	router.POST("/suggest/accept", service.handleSuggestAccept)
+	router.POST("/replace", service.handleReplace)
+	router.GET("/alternatives/sentences", service.handleSentenceAlternatives)
+	router.POST("/validate/particles", service.handleValidateParticles)
+	router.POST("/finalize", service.replayMiddleware(), service.handleFinalize)
+	router.GET("/align", service.handleAlign)
+	router.GET("/search", service.handleSearch)
+	router.POST("/clips/merge", service.handleMergeClips)
+	router.GET("/clips/:audio_id/occurrences", service.handleClipOccurrences)
+	router.GET("/clips/:audio_id/vocabulary", service.handleClipVocabulary)
+	router.GET("/clips/:audio_id/words/:word", service.handleWordDetail)
+	router.GET("/clips/:audio_id/stats", service.handleClipStats)
+	router.POST("/simulate", service.handleSimulate)
+	router.POST("/evaluate", service.handleEvaluate)
+
+	// Manual testing page; it calls the admin routes, so keep it off in production
+	if os.Getenv("DEBUG_UI") == "true" {
+		router.GET("/debug/ui", handleDebugUI)
+	}
+
+	// Admin routes
+	admin := router.Group("/admin")
+	admin.GET("/versions", service.handleListVersions)
+	admin.GET("/versions/diff", service.handleVersionsDiff)
+	admin.GET("/trie", service.handleTrieDump)
+	admin.GET("/stats", service.handleStats)
+	admin.GET("/clips/deleted", service.handleListDeletedClips)
+	admin.DELETE("/clips/:audio_id", service.handleDeleteClip)
+	admin.POST("/clips/:audio_id/restore", service.handleRestoreClip)
+	admin.POST("/clips/:audio_id/archive", service.handleArchiveClip)
+	admin.POST("/clips/:audio_id/clone", service.handleCloneClip)
+	admin.GET("/aliases", service.handleListAliases)
+	admin.POST("/aliases", service.handleAddAlias)
+	admin.DELETE("/aliases/:alias", service.handleDeleteAlias)
+	admin.GET("/archive", service.handleArchiveStatus)
+	admin.GET("/outbox", service.handleOutboxStatus)
+	admin.POST("/outbox/redrive", service.handleOutboxRedrive)
+	admin.POST("/drain", service.handleDrain)
+	admin.POST("/reset", service.handleReset)
+	admin.POST("/embeddings/backfill", service.handleEmbeddingBackfill)
+	admin.GET("/replay/export", service.handleReplayExport)
+	admin.GET("/slowlog", service.handleSlowLog)
+	admin.GET("/snapshot", service.handleSnapshot)
+	admin.POST("/restore", service.handleRestore)
+	admin.GET("/sync/delta", service.handleSyncDelta)
+	admin.POST("/sync/delta", service.handleApplySyncDelta)
+	admin.GET("/leader", service.handleLeaderStatus)
+	admin.GET("/shards", service.handleShardStatus)
+	admin.POST("/shards/reload", service.handleReloadShards)
+	admin.GET("/blocklists/:tenant", service.handleGetBlocklist)
+	admin.POST("/blocklists/:tenant", service.handleAddBlocklistWords)
+	admin.DELETE("/blocklists/:tenant", service.handleClearBlocklist)
+	admin.DELETE("/blocklists/:tenant/:word", service.handleDeleteBlocklistWord)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -68,9 +448,12 @@ func main() {
 	}
 
 	log.Printf("Starting autocomplete service on port %s", port)
-	if err := router.Run(":" + port); err != nil {
+	server := newHTTPServer(router, ":"+port)
+	stopped := service.shutdownOnSignal(server)
+	if err := serveHTTP(server); err != http.ErrServerClosed {
 		log.Fatalf("Failed to start server: %v", err)
 	}
+	<-stopped
 }
 
 func (s *AutocompleteService) handleHealth(c *gin.Context) {
@@ -91,31 +474,131 @@ func (s *AutocompleteService) handleHealth(c *gin.Context) {
 	})
 }
 
+// initLockWait bounds how long /initialize?wait=true queues for a clip's lock
+const initLockWait = 30 * time.Second
+
 func (s *AutocompleteService) handleInitialize(c *gin.Context) {
 	var request struct {
+		AudioID           string            `json:"audio_id"`
 		FinalTranscription string            `json:"final_transcription"`
 		ConfidenceScore   float64           `json:"confidence_score"`
 		DetectedParticles []string          `json:"detected_particles"`
 		AsrAlternatives   map[string]string `json:"asr_alternatives"`
+		PotentialParticles []models.PotentialParticle `json:"potential_particles"`
+		Topics            []string          `json:"topics"`
+		Accent            string            `json:"accent"`
+		SpeakerSegments   []models.SpeakerSegment `json:"speaker_segments"`
+		Segments          []models.AudioSegment `json:"segments"`
+		WordTimestamps    []models.WordTimestamp `json:"word_timestamps"`
 	}
 
+	limitRequestBody(c)
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(bindErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	// Positions refer to the words as sent; ingest moves them onto the redacted baseline
+	wordCount := len(services.TranscriptWords(request.FinalTranscription))
+	data := &models.AutocompleteData{
+		FinalTranscription: request.FinalTranscription,
+		ConfidenceScore:   request.ConfidenceScore,
+		DetectedParticles: request.DetectedParticles,
+		ASRAlternatives:   request.AsrAlternatives,
+		PotentialParticles: request.PotentialParticles,
+		Topics:            services.NormalizeTopics(request.Topics),
+		Accent:            services.NormalizeTag(request.Accent),
+		SpeakerSegments:   services.NormalizeSpeakerSegments(request.SpeakerSegments, wordCount),
+		Segments:          services.NormalizeAudioSegments(request.Segments, wordCount),
+		WordTimestamps:    services.NormalizeWordTimestamps(request.WordTimestamps, wordCount),
+	}
+	if err := checkTranscriptSize(data); err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
 		return
 	}
 
+	s.initializeClip(c, request.AudioID, data)
+}
+
+// initializeClip ingests data for a clip under the clip's rebuild lock and writes the response
+func (s *AutocompleteService) initializeClip(c *gin.Context, audioID string, data *models.AutocompleteData) {
 	ctx := context.Background()
-	
+
+	// dry_run=true reports what would be stored without writing anything
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, s.dryRunIngest(ctx, audioID, data))
+		return
+	}
+
+	// Serialize rebuilds of the same clip across replicas; wait=true queues
+	// behind the current holder instead of failing with 409
+	var wait time.Duration
+	if c.Query("wait") == "true" {
+		wait = initLockWait
+	}
+	lock, err := s.acquireClipLock(c.Request.Context(), services.NormalizeAudioID(audioID), wait)
+	if err == errClipLocked {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := lock.release(ctx); err != nil {
+			log.Printf("Error releasing clip lock: %v", err)
+		}
+	}()
+
+	// Re-initializing a clip someone else changed since it was read would clobber their work
+	if !s.checkIfMatch(ctx, c, services.NormalizeAudioID(audioID)) {
+		return
+	}
+
+	start := time.Now()
+	report, err := s.ingest(ctx, audioID, data)
+	s.ingests.record(time.Since(start), err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"status": "success",
+		"message": "Autocomplete data initialized",
+	}
+	if report.Mode != services.PIIOff {
+		response["redaction"] = report
+	}
+	if version, err := s.clipVersion(ctx, services.NormalizeAudioID(audioID)); err == nil {
+		setClipETag(c, version)
+		response["version"] = version
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ingest normalizes and redacts PII from the payload, stores the clip's words in Redis and
+// rebuilds its in-memory trie
+func (s *AutocompleteService) ingest(ctx context.Context, audioID string, data *models.AutocompleteData) (*models.RedactionReport, error) {
+	data, report := services.RedactAutocompleteData(services.NormalizeAutocompleteData(data))
+	s.storeWords(ctx, data)
+	return report, s.indexClip(ctx, audioID, data)
+}
+
+// storeWords adds an already-normalized and redacted payload's words to the global prefix index
+func (s *AutocompleteService) storeWords(ctx context.Context, data *models.AutocompleteData) {
 	// Store final transcription with confidence
-	if request.FinalTranscription != "" {
-		err := s.storeTranscriptionWords(ctx, request.FinalTranscription, request.ConfidenceScore)
+	if data.FinalTranscription != "" {
+		err := s.storeTranscriptionWords(ctx, data.FinalTranscription, data.ConfidenceScore)
 		if err != nil {
 			log.Printf("Error storing transcription: %v", err)
 		}
 	}
 
 	// Store ASR alternatives
-	for model, transcription := range request.AsrAlternatives {
+	for model, transcription := range data.ASRAlternatives {
 		if transcription != "" {
 			err := s.storeTranscriptionWords(ctx, transcription, 0.8) // Lower confidence for alternatives
 			if err != nil {
@@ -125,42 +608,411 @@ func (s *AutocompleteService) handleInitialize(c *gin.Context) {
 	}
 
 	// Store detected particles
-	for _, particle := range request.DetectedParticles {
+	for _, particle := range data.DetectedParticles {
 		err := s.storeWord(ctx, particle, 0.9)
 		if err != nil {
 			log.Printf("Error storing particle %s: %v", particle, err)
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"message": "Autocomplete data initialized",
-	})
+	// Learn which words particles follow, for insertion suggestions
+	if err := s.learnParticlePatterns(ctx, data.FinalTranscription); err != nil {
+		log.Printf("Error learning particle patterns: %v", err)
+	}
+	if err := s.learnTopicVocabulary(ctx, data); err != nil {
+		log.Printf("Error learning topic vocabulary: %v", err)
+	}
+	if err := s.learnAccentVocabulary(ctx, data); err != nil {
+		log.Printf("Error learning accent vocabulary: %v", err)
+	}
+	if err := s.learnContextNgrams(ctx, data); err != nil {
+		log.Printf("Error learning context n-grams: %v", err)
+	}
+	s.embedIngestedWords(data)
+}
+
+// indexClip builds the clip index so it can be inspected and diffed: in Redis
+// when stateless, otherwise as an in-memory trie. Candidates are ranked with
+// the verified corpus's prior.
+func (s *AutocompleteService) indexClip(ctx context.Context, audioID string, data *models.AutocompleteData) error {
+	start := time.Now()
+
+	// Rank the clip's candidates with what finalized transcripts have taught
+	prior, err := s.verifiedPrior(ctx, data)
+	if err != nil {
+		log.Printf("Error loading verified prior: %v", err)
+	}
+
+	if s.Stateless {
+		if err := s.storeClipIndex(ctx, audioID, data, prior); err != nil {
+			log.Printf("Error storing clip index: %v", err)
+			return err
+		}
+	} else {
+		services.BuildAndCacheDataWithPrior(audioID, data, prior)
+		s.dropPublishedSession(ctx, services.NormalizeAudioID(audioID))
+	}
+	if err := s.recordClipBuild(ctx, services.NormalizeAudioID(audioID), time.Since(start)); err != nil {
+		log.Printf("Error recording clip build time: %v", err)
+	}
+	if err := s.startClipTTLCap(ctx, services.NormalizeAudioID(audioID)); err != nil {
+		log.Printf("Error starting clip TTL cap: %v", err)
+	}
+	version, err := s.bumpClipVersion(ctx, services.NormalizeAudioID(audioID))
+	if err != nil {
+		log.Printf("Error bumping clip version: %v", err)
+	}
+	if err := s.dropArchivedClip(ctx, services.NormalizeAudioID(audioID)); err != nil {
+		log.Printf("Error dropping archived clip: %v", err)
+	}
+	s.precomputeClipEmbeddings(services.NormalizeAudioID(audioID), data)
+	s.recordEvent(ctx, &loggedEvent{Type: eventIngest, AudioID: services.NormalizeAudioID(audioID), Data: data})
+	s.emitWebhook(webhookIngestCompleted, gin.H{"audio_id": services.NormalizeAudioID(audioID), "version": version})
+	return nil
 }
 
 func (s *AutocompleteService) handlePrefixSuggest(c *gin.Context) {
-	prefix := c.Query("prefix")
-	if prefix == "" {
+	// Editing inside a word sends the whole token and the caret instead of a prefix
+	edit, err := parseCaretEdit(c.Query("token"), c.Query("caret"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefix := services.NormalizeQuery(c.Query("prefix"))
+	if edit != nil {
+		prefix = edit.left
+	} else if prefix == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix parameter required"})
 		return
 	}
 
-	maxResults := 5
-	if maxParam := c.Query("max_results"); maxParam != "" {
-		// Parse maxResults if provided
+	maxResults, err := services.ParseMaxResults(c.Query("max_results"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	ctx := context.Background()
-	suggestions, err := s.getPrefixSuggestions(ctx, prefix, maxResults)
+	matchMode := c.DefaultQuery("match_mode", matchModePrefix)
+	// fuzzy=true is shorthand for match_mode=fuzzy
+	if c.Query("fuzzy") == "true" {
+		if mode := c.Query("match_mode"); mode != "" && mode != matchModeFuzzy {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "fuzzy=true means match_mode=fuzzy"})
+			return
+		}
+		matchMode = matchModeFuzzy
+	}
+	if matchMode != matchModePrefix && matchMode != matchModePhoneme && matchMode != matchModeFuzzy && matchMode != matchModeInfix {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "match_mode must be prefix, phoneme, fuzzy or infix"})
+		return
+	}
+
+	stemmed := c.Query("stem") == "true"
+	// Capitalization in the prefix only counts when asked for, e.g. to find proper nouns
+	caseSensitive := c.Query("case_sensitive") == "true"
+
+	// A word index conditions prefix ranking on what the ASR heard at that slot
+	wordIndex := -1
+	if indexParam := c.Query("word_index"); indexParam != "" {
+		wordIndex, err = strconv.Atoi(indexParam)
+		if err != nil || wordIndex < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "word_index must be a non-negative integer"})
+			return
+		}
+	}
+	blendWeight, ok := parseBlendWeight(c.Query("position_weight"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "position_weight must be between 0 and 1"})
+		return
+	}
+
+	// Plain prefix lookups go through the merge layer when other backends are enabled
+	backends := suggestBackends
+	if backendsParam := c.Query("backends"); backendsParam != "" {
+		backends, err = parseBackends(backendsParam, suggestBackends)
+		if err == nil && len(backends) == 0 {
+			err = fmt.Errorf("backends must name at least one backend")
+		}
+		if _, llm := backends[backendLLM]; err == nil && llm && llmSuggestURL == "" {
+			err = fmt.Errorf("the llm backend is not configured")
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	// Correcting one stretch of audio draws only on the words heard in it
+	scope, err := parseSegmentScope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Accepted words around the target rerank by n-gram fit
+	window := services.ParseContextWindow(c.Query("left_context"), c.Query("right_context"))
+	sentence := semanticContext{left: c.Query("left_context"), right: c.Query("right_context")}
+	semantic := semanticEnabled(c.Query("semantic"))
+
+	// Mixed-language clips can be completed from one language's sub-index
+	lang := c.Query("lang")
+	langMode := c.DefaultQuery("lang_mode", langModeRestrict)
+	if lang != "" && !services.IsLanguage(lang) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lang must be one of " + strings.Join(services.Languages, ", ")})
+		return
+	}
+	if langMode != langModeRestrict && langMode != langModePrefer {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lang_mode must be restrict or prefer"})
+		return
+	}
+
+	// max_edits sets the typo budget of match_mode=fuzzy
+	maxEdits, err := parseMaxEdits(c.Query("max_edits"), prefix)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	_, redisOnly := backends[backendRedis]
+	redisOnly = redisOnly && len(backends) == 1
+
+	// Fuzzy matching weights typos by the keyboard the client reports
+	layout, err := services.KeyboardLayoutFor(c.Query("keyboard"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The UI leaves out the word already in the slot and rejected candidates
+	exclusions := parseSuggestExclusions(c.Query("exclude"), c.Query("exclude_prefix"))
+	lookupResults := exclusions.fetchCount(maxResults)
+
+	// budget_ms keeps keystroke latency predictable: the lookup answers with what
+	// it has when the budget runs out instead of waiting on slow backends
+	budget, ok := parseSuggestBudget(c.Query("budget_ms"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "budget_ms must be a positive integer"})
+		return
+	}
+
+	// sort=alpha lists the top results in the reader's alphabetical order
+	collator, err := parseListingCollator(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// scope=clip answers from the clip's own words, and fallback=global tops up
+	// plain prefix lookups from the global pool
+	suggestionScope, err := parseSuggestScope(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Options that each pick their own lookup can't be combined
+	lookup := suggestLookup{
+		matchMode:     matchMode,
+		edit:          edit != nil,
+		stemmed:       stemmed,
+		caseSensitive: caseSensitive,
+		wordIndex:     wordIndex >= 0,
+		segment:       scope != nil,
+		context:       !window.Empty(),
+		lang:          lang != "",
+		homophones:    c.Query("homophones") == "true",
+		backends:      c.Query("backends") != "" && !redisOnly,
+		clip:          suggestionScope.clip,
+		fallback:      suggestionScope.fallback,
+	}
+	// Clip scope by default covers only the lookups the clip's index can answer;
+	// the others, e.g. word_index, keep conditioning the global pool on the clip
+	if suggestionScope.clip && !suggestionScope.requested && !suggestionScope.fallback && lookup.globalOption() != "" {
+		suggestionScope.clip = false
+		lookup.clip = false
+	}
+	if err := lookup.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Typing in a clip keeps its Redis keys alive; the ETag lets the editor
+	// make conditional writes against the version it is suggesting from
+	if audioID := c.Query("audio_id"); audioID != "" {
+		s.touchClip(audioID)
+		if version, err := s.clipVersion(context.Background(), services.NormalizeAudioID(audioID)); err == nil {
+			setClipETag(c, version)
+		}
+	}
+
+	start := time.Now()
+	ctx, timing := withRequestTiming(context.Background())
+	defer func() {
+		total := time.Since(start)
+		slowQueries.record(SlowEntry{
+			Time:      start,
+			Operation: "suggest",
+			Backend:   "redis_" + matchMode,
+			AudioID:   c.Query("audio_id"),
+			Prefix:    prefix,
+			Breakdown: timing.breakdown(total),
+		}, total)
+	}()
+
+	var firstWord, lastWord int
+	if scope != nil {
+		firstWord, lastWord, err = s.segmentWordRange(ctx, c.Query("audio_id"), scope)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	lookupCtx := ctx
+	if budget > 0 {
+		var cancel context.CancelFunc
+		lookupCtx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	var suggestions []map[string]interface{}
+	var backendErrors map[string]string
+	var pendingBackends []string
+	var activeSpeaker string
+	switch {
+	case edit != nil:
+		suggestions, err = s.getCaretSuggestions(lookupCtx, requestTenant(c), edit, layout, lookupResults)
+	case stemmed:
+		suggestions, err = s.getStemSuggestions(lookupCtx, requestTenant(c), prefix, lookupResults)
+	case matchMode == matchModePhoneme:
+		suggestions, err = s.getPhonemeSuggestions(lookupCtx, requestTenant(c), prefix, lookupResults)
+	case matchMode == matchModeInfix:
+		suggestions, err = s.getInfixSuggestions(lookupCtx, requestTenant(c), prefix, lookupResults)
+	case matchMode == matchModeFuzzy && suggestionScope.clip:
+		suggestions, err = s.getTrieFuzzySuggestions(lookupCtx, requestTenant(c), prefix, c.Query("audio_id"), maxEdits, lookupResults)
+	case matchMode == matchModeFuzzy:
+		suggestions, err = s.getFuzzySuggestions(lookupCtx, requestTenant(c), prefix, maxEdits, lookupResults)
+	case caseSensitive:
+		suggestions, err = s.getCaseSensitiveSuggestions(lookupCtx, requestTenant(c), prefix, lookupResults)
+	case scope != nil:
+		suggestions, err = s.getSegmentSuggestions(lookupCtx, requestTenant(c), prefix, c.Query("audio_id"), firstWord, lastWord, lookupResults)
+	case wordIndex >= 0:
+		suggestions, err = s.getPositionedSuggestions(lookupCtx, requestTenant(c), prefix, c.Query("audio_id"), wordIndex, blendWeight, lookupResults)
+	case lang != "":
+		suggestions, err = s.getLanguageSuggestions(lookupCtx, requestTenant(c), prefix, c.Query("audio_id"), lang, langMode == langModePrefer, lookupResults)
+	case suggestionScope.clip:
+		suggestions, err = s.getClipScopedSuggestions(lookupCtx, requestTenant(c), prefix, c.Query("audio_id"), suggestionScope.fallback, lookupResults)
+	case !window.Empty():
+		suggestions, err = s.getContextSuggestions(lookupCtx, requestTenant(c), prefix, window, lookupResults)
+		if err == nil && semantic {
+			suggestions = s.semanticRerank(lookupCtx, suggestions, sentence)
+		}
+	case !redisOnly:
+		suggestions, backendErrors, pendingBackends, err = s.getMergedSuggestions(lookupCtx, requestTenant(c), prefix, c.Query("audio_id"), backends, lookupResults)
+	default:
+		// Clips tagged with topics, an accent or speakers favour words typical of them
+		if profile := s.clipProfile(lookupCtx, c.Query("audio_id"), c.Query("speaker"), c.Query("position")); !profile.empty() {
+			suggestions, err = s.getProfileSuggestions(lookupCtx, requestTenant(c), prefix, profile, lookupResults)
+			if profile.speaker != "" {
+				activeSpeaker = profile.speaker
+			}
+		} else {
+			suggestions, err = s.getPrefixSuggestions(lookupCtx, requestTenant(c), prefix, lookupResults)
+		}
+	}
+	// A lookup cut off by the budget answers empty rather than failing
+	partial := len(pendingBackends) > 0
+	if err != nil && budget > 0 && errors.Is(err, context.DeadlineExceeded) {
+		suggestions, err, partial = nil, nil, true
+	}
+	if errors.Is(err, services.ErrClipNotInitialized) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	suggestions = exclusions.apply(suggestions, maxResults)
+	if collator != nil {
+		collateSuggestions(suggestions, collator)
+	}
 
-	c.JSON(http.StatusOK, gin.H{
+	// explain=true shows researchers why each word ranked where it did
+	if c.Query("explain") == "true" {
+		position := c.Query("position")
+		if wordIndex >= 0 {
+			position = strconv.Itoa(wordIndex)
+		}
+		if err := s.explainSuggestions(ctx, suggestions, prefix, c.Query("audio_id"), position); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if len(suggestions) > 0 {
+		s.suggestHits.Add(1)
+	} else {
+		s.suggestMisses.Add(1)
+	}
+
+	response := gin.H{
 		"suggestions": suggestions,
 		"prefix": prefix,
-	})
+	}
+	if matchMode == matchModePhoneme {
+		response["phonemes"] = services.Phonemes(prefix)
+	}
+	if len(backendErrors) > 0 {
+		response["backend_errors"] = backendErrors
+	}
+	if partial {
+		s.suggestPartial.Add(1)
+		response["partial"] = true
+		if len(pendingBackends) > 0 {
+			response["pending_backends"] = pendingBackends
+		}
+	}
+	if activeSpeaker != "" {
+		response["speaker"] = activeSpeaker
+	}
+	if scope != nil {
+		response["word_range"] = []int{firstWord, lastWord}
+	}
+	if edit != nil {
+		response["token"] = edit.token
+		response["caret"] = edit.caret
+	}
+
+	if c.Query("homophones") == "true" {
+		homophones, err := s.homophoneSuggestions(ctx, requestTenant(c), prefix, c.Query("audio_id"), c.Query("position"), lookupResults)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Homophones that make no sense in the sentence drop below ones that do
+		if semantic {
+			if sentence.empty() {
+				sentence = s.clipSentenceContext(ctx, c.Query("audio_id"), c.Query("position"))
+			}
+			homophones = s.semanticRerank(ctx, homophones, sentence)
+		}
+		homophones = exclusions.apply(homophones, maxResults)
+		if collator != nil {
+			collateSuggestions(homophones, collator)
+		}
+		response["homophones"] = homophones
+	}
+
+	if s.ReplayLogEnabled {
+		queryID, err := s.logReplayQuery(ctx, c.Query("audio_id"), prefix, c.Query("position"), suggestions)
+		if err != nil {
+			log.Printf("Error logging replay query: %v", err)
+		} else {
+			response["query_id"] = queryID
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 func (s *AutocompleteService) storeTranscriptionWords(ctx context.Context, transcription string, baseConfidence float64) error {
@@ -186,63 +1038,90 @@ func (s *AutocompleteService) storeTranscriptionWords(ctx context.Context, trans
 }
 
 func (s *AutocompleteService) storeWord(ctx context.Context, word string, confidence float64) error {
-	// Store in global word frequency
-	s.RedisClient.ZIncrBy(ctx, "autocomplete:global:frequency", 1, word)
-	
-	// Store for prefix matching - add to all relevant prefix keys
-	for i := 1; i <= len(word) && i <= 10; i++ {
-		prefix := word[:i]
-		key := "autocomplete:prefix:" + prefix
-		s.RedisClient.ZAdd(ctx, key, &redis.Z{
-			Score:  confidence,
-			Member: word,
-		})
-		// Set expiration to 1 hour for prefix keys
-		s.RedisClient.Expire(ctx, key, time.Hour)
+	confidence, keep := services.FilterIngestWord(word, confidence)
+	if !keep {
+		return nil
 	}
-	
-	return nil
+
+	if s.queue != nil {
+		return s.enqueueWord(ctx, word, confidence)
+	}
+	return s.writeBatch(ctx, []wordWrite{{word: word, confidence: confidence}})
 }
 
-func (s *AutocompleteService) getPrefixSuggestions(ctx context.Context, prefix string, maxResults int) ([]map[string]interface{}, error) {
-	key := "autocomplete:prefix:" + prefix
-	
-	// Get top suggestions from Redis sorted set
-	results, err := s.RedisClient.ZRevRangeWithScores(ctx, key, 0, int64(maxResults-1)).Result()
+// readClient picks the client for a suggest read, rotating across read replicas
+func (s *AutocompleteService) readClient() *redis.Client {
+	if len(s.ReadClients) == 0 {
+		return s.RedisClient
+	}
+	return s.ReadClients[s.nextRead.Add(1)%uint64(len(s.ReadClients))]
+}
+
+// loadSeedCorpus loads weighted words into the global trie and Redis prefix index
+func (s *AutocompleteService) loadSeedCorpus(ctx context.Context, path string) (int, error) {
+	suggestions, err := services.LoadSeedCorpus(path)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	
-	suggestions := make([]map[string]interface{}, len(results))
-	for i, result := range results {
-		suggestions[i] = map[string]interface{}{
-			"text":       result.Member.(string),
-			"confidence": result.Score,
+
+	if !s.Stateless {
+		services.SeedGlobalIndex(suggestions)
+	}
+	for _, suggestion := range suggestions {
+		if err := s.storeWord(ctx, suggestion.Text, suggestion.Confidence); err != nil {
+			return 0, err
 		}
 	}
-	
-	return suggestions, nil
+
+	return len(suggestions), nil
 }
 
-func splitIntoWords(text string) []string {
-	// Simple word splitting - can be enhanced with better tokenization
-	words := []string{}
-	current := ""
+func (s *AutocompleteService) getPrefixSuggestions(ctx context.Context, tenant, prefix string, maxResults int) ([]map[string]interface{}, error) {
+	// Get top suggestions from the materialized top-k, or the Redis sorted set
+	results, err := s.rankedPrefix(ctx, prefix, suggestFetchCount(tenant, maxResults))
+	if err != nil {
+		return nil, err
+	}
 	
-	for _, char := range text {
-		if char == ' ' || char == '\t' || char == '\n' {
-			if current != "" {
-				words = append(words, current)
-				current = ""
-			}
-		} else {
-			current += string(char)
+	return formatSuggestions(tenant, results, maxResults), nil
+}
+
+// suggestFetchCount is how many ranked words to read for maxResults suggestions
+func suggestFetchCount(tenant string, maxResults int) int {
+	// Over-fetch when filtering so dropped words don't shrink the result list
+	if services.HasSuggestFilters(tenant) {
+		return maxResults * 2
+	}
+	return maxResults
+}
+
+// formatSuggestions applies the tenant's filters to ranked words and converts
+// the best maxResults into response entries
+func formatSuggestions(tenant string, results []redis.Z, maxResults int) []map[string]interface{} {
+	ranked := make([]models.WordSuggestion, len(results))
+	for i, result := range results {
+		ranked[i] = models.WordSuggestion{
+			Text:       result.Member.(string),
+			Confidence: result.Score,
 		}
 	}
+	ranked = services.ApplySuggestFilters(tenant, ranked)
+	if len(ranked) > maxResults {
+		ranked = ranked[:maxResults]
+	}
 	
-	if current != "" {
-		words = append(words, current)
+	suggestions := make([]map[string]interface{}, len(ranked))
+	for i, suggestion := range ranked {
+		suggestions[i] = map[string]interface{}{
+			"text":       suggestion.Text,
+			"confidence": suggestion.Confidence,
+		}
 	}
 	
-	return words
+	return suggestions
+}
+
+func splitIntoWords(text string) []string {
+	// Punctuation stays with the transcript's tokens, not the indexed words
+	return services.TranscriptWords(text)
 }
\ No newline at end of file
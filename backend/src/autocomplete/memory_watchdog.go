@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// Actions the memory watchdog takes once a threshold is crossed
+const (
+	watchdogWarn       = "warn"
+	watchdogShortenTTL = "shorten_ttl"
+	watchdogEvict      = "evict"
+)
+
+// memoryWatchdog checks Redis memory and autocomplete key counts against limits
+type memoryWatchdog struct {
+	maxBytes   int64
+	maxKeys    int64
+	action     string
+	shortTTL   time.Duration
+	evictClips int
+
+	mu           sync.Mutex
+	lastCheck    time.Time
+	usedMemory   int64
+	keyCount     int64
+	breached     bool
+	breaches     int64
+	ttlsReduced  int64
+	clipsEvicted int64
+}
+
+// registerMemoryWatchdog adds the watchdog job when MEMORY_WATCHDOG_MAX_BYTES or
+// MEMORY_WATCHDOG_MAX_KEYS is set
+func (s *AutocompleteService) registerMemoryWatchdog() {
+	maxBytes, _ := strconv.ParseInt(os.Getenv("MEMORY_WATCHDOG_MAX_BYTES"), 10, 64)
+	maxKeys, _ := strconv.ParseInt(os.Getenv("MEMORY_WATCHDOG_MAX_KEYS"), 10, 64)
+	if maxBytes <= 0 && maxKeys <= 0 {
+		return
+	}
+
+	action := os.Getenv("MEMORY_WATCHDOG_ACTION")
+	switch action {
+	case "":
+		action = watchdogWarn
+	case watchdogWarn, watchdogShortenTTL, watchdogEvict:
+	default:
+		log.Printf("Unknown MEMORY_WATCHDOG_ACTION %q, only warning", action)
+		action = watchdogWarn
+	}
+
+	s.watchdog = &memoryWatchdog{
+		maxBytes:   maxBytes,
+		maxKeys:    maxKeys,
+		action:     action,
+		shortTTL:   envDuration("MEMORY_WATCHDOG_SHORT_TTL", 10*time.Minute),
+		evictClips: envInt("MEMORY_WATCHDOG_EVICT_CLIPS", 10),
+	}
+	interval := envDuration("MEMORY_WATCHDOG_INTERVAL", time.Minute)
+
+	s.jobs = append(s.jobs, &backgroundJob{
+		name:     "memory_watchdog",
+		interval: interval,
+		run:      s.checkRedisMemory,
+	})
+	log.Printf("Memory watchdog enabled: max_bytes=%d max_keys=%d action=%s every %s", maxBytes, maxKeys, action, interval)
+}
+
+// checkRedisMemory compares the largest instance's used memory and the total
+// autocomplete key count with the limits and reacts to a breach
+func (s *AutocompleteService) checkRedisMemory(ctx context.Context) error {
+	w := s.watchdog
+
+	var usedMemory int64
+	for _, client := range s.redisClients() {
+		info, err := client.Info(ctx, "memory").Result()
+		if err != nil {
+			return err
+		}
+		if used := parseRedisInfo(info)["used_memory"]; used > usedMemory {
+			usedMemory = used
+		}
+	}
+
+	namespaces, err := s.redisNamespaceStats(ctx)
+	if err != nil {
+		return err
+	}
+	var keyCount int64
+	for _, stats := range namespaces {
+		keyCount += stats.KeyCount
+	}
+
+	breached := (w.maxBytes > 0 && usedMemory > w.maxBytes) || (w.maxKeys > 0 && keyCount > w.maxKeys)
+
+	w.mu.Lock()
+	w.lastCheck = time.Now()
+	w.usedMemory = usedMemory
+	w.keyCount = keyCount
+	w.breached = breached
+	if breached {
+		w.breaches++
+	}
+	w.mu.Unlock()
+
+	if !breached {
+		return nil
+	}
+	log.Printf("WARNING: Redis memory watchdog threshold crossed: used_memory=%d (max %d) autocomplete_keys=%d (max %d)",
+		usedMemory, w.maxBytes, keyCount, w.maxKeys)
+
+	switch w.action {
+	case watchdogShortenTTL:
+		reduced, err := s.shortenTTLs(ctx, w.shortTTL)
+		w.mu.Lock()
+		w.ttlsReduced += reduced
+		w.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		log.Printf("Memory watchdog shortened the TTL of %d keys to %s", reduced, w.shortTTL)
+	case watchdogEvict:
+		evicted, err := s.evictIdleClips(ctx, w.evictClips)
+		w.mu.Lock()
+		w.clipsEvicted += int64(len(evicted))
+		w.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		log.Printf("Memory watchdog evicted %d least recently used clips: %v", len(evicted), evicted)
+	}
+	return nil
+}
+
+// shortenTTLs caps the TTL of every prefix, top-k and clip key at ttl
+func (s *AutocompleteService) shortenTTLs(ctx context.Context, ttl time.Duration) (int64, error) {
+	var reduced int64
+	for _, client := range s.redisClients() {
+		for _, pattern := range []string{prefixKeyPrefix + "*", topKKey, clipKeyPrefix("*") + "*"} {
+			iter := client.Scan(ctx, 0, pattern, 1000).Iterator()
+			for iter.Next(ctx) {
+				key := iter.Val()
+				current, err := client.TTL(ctx, key).Result()
+				if err != nil {
+					return reduced, err
+				}
+				if current == -2 {
+					continue // Expired since the scan
+				}
+				if current == -1 || current > ttl { // -1 means the key has no expiry
+					if err := client.Expire(ctx, key, ttl).Err(); err != nil {
+						return reduced, err
+					}
+					reduced++
+				}
+			}
+			if err := iter.Err(); err != nil {
+				return reduced, err
+			}
+		}
+	}
+	return reduced, nil
+}
+
+// idleClip is a clip index with the time since it was last read or written
+type idleClip struct {
+	client  *redis.Client
+	audioID string
+	idle    time.Duration
+}
+
+// evictIdleClips purges up to limit clips whose Redis index has gone unused the
+// longest, judged by OBJECT IDLETIME of the clip's words hash
+func (s *AutocompleteService) evictIdleClips(ctx context.Context, limit int) ([]string, error) {
+	var clips []idleClip
+	for _, client := range s.redisClients() {
+		iter := client.Scan(ctx, 0, clipKeyPrefix("*")+"words", 1000).Iterator()
+		for iter.Next(ctx) {
+			key := iter.Val()
+			idle, err := client.ObjectIdleTime(ctx, key).Result()
+			if err == redis.Nil {
+				continue // Expired since the scan
+			}
+			if err != nil {
+				return nil, err
+			}
+			audioID := clipIDFromKey(key)
+			if audioID == services.GlobalAudioID {
+				continue // The global clip backs every unscoped suggestion
+			}
+			clips = append(clips, idleClip{client: client, audioID: audioID, idle: idle})
+		}
+		if err := iter.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(clips, func(i, j int) bool { return clips[i].idle > clips[j].idle })
+	if len(clips) > limit {
+		clips = clips[:limit]
+	}
+
+	evicted := make([]string, 0, len(clips))
+	for _, clip := range clips {
+		if _, err := s.deleteKeys(ctx, clip.client, clipKeyPrefix(clip.audioID)+"*"); err != nil {
+			return evicted, fmt.Errorf("failed to evict clip %s: %w", clip.audioID, err)
+		}
+		services.PurgeClip(clip.audioID)
+		evicted = append(evicted, clip.audioID)
+	}
+	return evicted, nil
+}
+
+// watchdogStats reports the last memory check and the actions taken so far
+func (s *AutocompleteService) watchdogStats() map[string]interface{} {
+	if s.watchdog == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+	w := s.watchdog
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return map[string]interface{}{
+		"enabled":       true,
+		"action":        w.action,
+		"max_bytes":     w.maxBytes,
+		"max_keys":      w.maxKeys,
+		"last_check":    w.lastCheck,
+		"used_memory":   w.usedMemory,
+		"key_count":     w.keyCount,
+		"breached":      w.breached,
+		"breaches":      w.breaches,
+		"ttls_reduced":  w.ttlsReduced,
+		"clips_evicted": w.clipsEvicted,
+	}
+}
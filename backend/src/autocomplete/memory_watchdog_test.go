@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRegisterMemoryWatchdog(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxBytes   string
+		maxKeys    string
+		action     string
+		wantAction string // Empty when the watchdog stays off
+	}{
+		{"no limits", "", "", watchdogEvict, ""},
+		{"invalid limits", "-5", "many", watchdogEvict, ""},
+		{"bytes, default action", "1000000", "", "", watchdogWarn},
+		{"keys, shorten", "", "5000", watchdogShortenTTL, watchdogShortenTTL},
+		{"both, evict", "1000000", "5000", watchdogEvict, watchdogEvict},
+		{"unknown action warns", "1000000", "", "flush", watchdogWarn},
+	}
+	for _, tt := range tests {
+		t.Setenv("MEMORY_WATCHDOG_MAX_BYTES", tt.maxBytes)
+		t.Setenv("MEMORY_WATCHDOG_MAX_KEYS", tt.maxKeys)
+		t.Setenv("MEMORY_WATCHDOG_ACTION", tt.action)
+
+		s := &AutocompleteService{}
+		s.registerMemoryWatchdog()
+		if tt.wantAction == "" {
+			if s.watchdog != nil || len(s.jobs) != 0 || s.watchdogStats()["enabled"] != false {
+				t.Errorf("%s: watchdog registered", tt.name)
+			}
+			continue
+		}
+		if s.watchdog == nil || len(s.jobs) != 1 || s.jobs[0].name != "memory_watchdog" {
+			t.Errorf("%s: watchdog not registered as a job", tt.name)
+			continue
+		}
+		if s.watchdog.action != tt.wantAction || s.watchdog.shortTTL != 10*time.Minute || s.watchdog.evictClips != 10 {
+			t.Errorf("%s: action %s, short TTL %v, evict %d", tt.name, s.watchdog.action, s.watchdog.shortTTL, s.watchdog.evictClips)
+		}
+	}
+}
+
+func TestCheckRedisMemoryWithoutRedis(t *testing.T) {
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	s.watchdog = &memoryWatchdog{maxBytes: 1, action: watchdogEvict}
+
+	// A failed check leaves the last good reading in place
+	if err := s.checkRedisMemory(context.Background()); err == nil {
+		t.Error("checkRedisMemory() without Redis succeeded")
+	}
+	stats := s.watchdogStats()
+	if stats["breaches"] != int64(0) || stats["clips_evicted"] != int64(0) || !stats["last_check"].(time.Time).IsZero() {
+		t.Errorf("watchdogStats() after a failed check = %v", stats)
+	}
+}
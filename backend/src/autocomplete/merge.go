@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// maxMergeParts bounds the clips one /clips/merge call combines
+const maxMergeParts = 100
+
+// handleMergeClips indexes the concatenation of several clips' transcripts,
+// e.g. the parts of one long lecture the orchestrator split up, under one
+// audio_id so corrections happen against the full recording. Parts are
+// merged in the order given, with their word positions and timings shifted
+// past the earlier parts. The parts stay indexed, and their words are not
+// counted again in the global index.
+func (s *AutocompleteService) handleMergeClips(c *gin.Context) {
+	var request struct {
+		AudioID string   `json:"audio_id" binding:"required"`
+		Parts   []string `json:"parts" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(request.Parts) < 2 || len(request.Parts) > maxMergeParts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parts must list between 2 and 100 clips"})
+		return
+	}
+
+	ctx := context.Background()
+	target := services.NormalizeAudioID(request.AudioID)
+	partIDs := make([]string, len(request.Parts))
+	parts := make([]*models.AutocompleteData, len(request.Parts))
+	seen := make(map[string]bool)
+	for i, part := range request.Parts {
+		partIDs[i] = services.NormalizeAudioID(part)
+		if seen[partIDs[i]] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "clip " + partIDs[i] + " is listed twice"})
+			return
+		}
+		seen[partIDs[i]] = true
+
+		data, err := s.clipTranscripts(ctx, partIDs[i])
+		if err == redis.Nil || err != nil && !s.Stateless {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no transcripts stored for clip " + partIDs[i]})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		parts[i] = data
+	}
+	merged, placed := services.MergeAutocompleteData(partIDs, parts)
+
+	lock, err := s.acquireClipLock(ctx, target, initLockWait)
+	if err == errClipLocked {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := lock.release(ctx); err != nil {
+			log.Printf("Error releasing clip lock: %v", err)
+		}
+	}()
+	if !s.checkIfMatch(ctx, c, target) {
+		return
+	}
+
+	// The parts' payloads were normalized and redacted when they were ingested
+	start := time.Now()
+	err = s.indexClip(ctx, target, merged)
+	s.ingests.record(time.Since(start), err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"status":   "merged",
+		"audio_id": target,
+		"words":    len(services.TranscriptWords(merged.FinalTranscription)),
+		"parts":    placed,
+	}
+	if version, err := s.clipVersion(ctx, target); err == nil {
+		setClipETag(c, version)
+		response["version"] = version
+	}
+	c.JSON(http.StatusOK, response)
+}
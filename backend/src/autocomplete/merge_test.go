@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleMergeClipsRejects(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("part-1", &models.AutocompleteData{FinalTranscription: "saya makan", ConfidenceScore: 0.9})
+	services.BuildAndCacheData("part-2", &models.AutocompleteData{FinalTranscription: "nasi goreng", ConfidenceScore: 0.9})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/clips/merge", (&AutocompleteService{RedisClient: unreachableRedis(t)}).handleMergeClips)
+	stateless := gin.New()
+	stateless.POST("/clips/merge", (&AutocompleteService{Stateless: true, RedisClient: unreachableRedis(t)}).handleMergeClips)
+
+	tooMany := `{"audio_id": "lecture", "parts": ["p"` + strings.Repeat(`, "p"`, maxMergeParts) + `]}`
+	tests := []struct {
+		name   string
+		router *gin.Engine
+		body   string
+		want   int
+	}{
+		{"no body", router, "", http.StatusBadRequest},
+		{"no target", router, `{"parts": ["part-1", "part-2"]}`, http.StatusBadRequest},
+		{"one part", router, `{"audio_id": "lecture", "parts": ["part-1"]}`, http.StatusBadRequest},
+		{"too many parts", router, tooMany, http.StatusBadRequest},
+		{"part listed twice", router, `{"audio_id": "lecture", "parts": ["part-1", "part-2", "part-1"]}`, http.StatusBadRequest},
+		{"unknown part", router, `{"audio_id": "lecture", "parts": ["part-1", "part-3"]}`, http.StatusNotFound},
+		// Both parts are cached, but the target's lock lives in Redis
+		{"cached parts", router, `{"audio_id": "lecture", "parts": ["part-1", "part-2"]}`, http.StatusInternalServerError},
+		{"stateless", stateless, `{"audio_id": "lecture", "parts": ["part-1", "part-2"]}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		tt.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/clips/merge", strings.NewReader(tt.body)))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
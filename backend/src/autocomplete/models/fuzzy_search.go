@@ -0,0 +1,84 @@
+package models
+
+import "sort"
+
+// FuzzyMatch is a suggestion whose word starts within Distance edits of the
+// searched prefix
+type FuzzyMatch struct {
+	WordSuggestion
+	Distance int `json:"distance"`
+}
+
+// SearchFuzzy finds the words starting with a string at most maxEdits
+// insertions, deletions or substitutions away from prefix, ranked by distance
+// then confidence. The trie is walked once with a Levenshtein row per node, so
+// branches already more than maxEdits away are never visited.
+func (pt *PrefixTrie) SearchFuzzy(prefix string, maxEdits, maxResults int) []FuzzyMatch {
+	target := []rune(prefix)
+	row := make([]int, len(target)+1)
+	for i := range row {
+		row[i] = i
+	}
+
+	// The empty path already matches when the whole prefix may be deleted
+	best := maxEdits + 1
+	if row[len(target)] <= maxEdits {
+		best = row[len(target)]
+	}
+
+	var matches []FuzzyMatch
+	pt.Root.eachChild(func(char rune, child *TrieNode) {
+		matches = pt.searchFuzzy(child, char, target, row, best, maxEdits, matches)
+	})
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Confidence > matches[j].Confidence
+	})
+	if len(matches) > maxResults {
+		matches = matches[:maxResults]
+	}
+	return matches
+}
+
+// searchFuzzy extends the parent's Levenshtein row by char and collects the
+// node's words. best is the smallest distance between the prefix and any
+// prefix of the path so far, which is the distance of every word below it.
+func (pt *PrefixTrie) searchFuzzy(node *TrieNode, char rune, target []rune, parent []int, best, maxEdits int, matches []FuzzyMatch) []FuzzyMatch {
+	row := make([]int, len(parent))
+	row[0] = parent[0] + 1
+	lowest := row[0]
+	for i := 1; i < len(row); i++ {
+		cost := 1
+		if target[i-1] == char {
+			cost = 0
+		}
+		row[i] = min(parent[i-1]+cost, parent[i]+1, row[i-1]+1)
+		lowest = min(lowest, row[i])
+	}
+	// A row's smallest distance never shrinks as the path grows, so past the
+	// budget the path either already matched or never will
+	if lowest > maxEdits {
+		if best > maxEdits {
+			return matches
+		}
+		for _, suggestion := range pt.collectAllSuggestions(node, nil) {
+			matches = append(matches, FuzzyMatch{WordSuggestion: suggestion, Distance: best})
+		}
+		return matches
+	}
+
+	best = min(best, row[len(target)])
+	if node.IsEndOfWord && best <= maxEdits {
+		for _, suggestion := range node.Suggestions {
+			matches = append(matches, FuzzyMatch{WordSuggestion: suggestion, Distance: best})
+		}
+	}
+
+	node.eachChild(func(char rune, child *TrieNode) {
+		matches = pt.searchFuzzy(child, char, target, row, best, maxEdits, matches)
+	})
+	return matches
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchFuzzy(t *testing.T) {
+	trie := NewPrefixTrie("test")
+	for word, confidence := range map[string]float64{
+		"makan": 0.9,
+		"akan":  0.8,
+		"mandi": 0.7,
+		"tamak": 0.6,
+		"lama":  0.95,
+	} {
+		trie.Insert(word, WordSuggestion{Text: word, Confidence: confidence})
+	}
+
+	type match struct {
+		text     string
+		distance int
+	}
+	tests := []struct {
+		name       string
+		prefix     string
+		maxEdits   int
+		maxResults int
+		want       []match
+	}{
+		{"exact prefix", "mak", 0, 10, []match{{"makan", 0}}},
+		{"substitution", "mbk", 1, 10, []match{{"makan", 1}}},
+		{"missing and extra letters", "mkan", 1, 10, []match{{"makan", 1}, {"akan", 1}, {"mandi", 1}}},
+		{"extra first letter", "xmakan", 1, 10, []match{{"makan", 1}}},
+		{"distance before confidence", "mak", 1, 10, []match{{"makan", 0}, {"akan", 1}, {"mandi", 1}}},
+		{"max results", "mak", 1, 2, []match{{"makan", 0}, {"akan", 1}}},
+		{"over budget", "xyz", 1, 10, []match{}},
+		{"two edits", "mxkxn", 2, 10, []match{{"makan", 2}}},
+	}
+	for _, tt := range tests {
+		got := []match{}
+		for _, m := range trie.SearchFuzzy(tt.prefix, tt.maxEdits, tt.maxResults) {
+			got = append(got, match{m.Text, m.Distance})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: SearchFuzzy(%q, %d) = %v, want %v", tt.name, tt.prefix, tt.maxEdits, got, tt.want)
+		}
+	}
+}
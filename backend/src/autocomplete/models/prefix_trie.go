@@ -2,92 +2,366 @@ package models
 
 import (
 	"sort"
+	"sync"
+	"sync/atomic"
 )
 
-// TrieNode represents a single node in the prefix trie
+// smallChildLimit is the fan-out kept in sorted arrays before a node switches to
+// a map. Most nodes have one or two children, where a short scan beats hashing.
+const smallChildLimit = 8
+
+// nodeSlabSize is how many nodes a nodeArena allocates at once
+const nodeSlabSize = 128
+
+// trieGenerations issues write generations for copy-on-write. A node may only be
+// changed in place by the trie whose current generation created it.
+var trieGenerations atomic.Uint64
+
+// TrieNode represents a single node in the prefix trie. Children live in the
+// parallel sorted keys/children arrays until the fan-out passes smallChildLimit,
+// then in childMap.
 type TrieNode struct {
-	Children    map[rune]*TrieNode
+	gen         uint64
+	keys        []rune
+	children    []*TrieNode
+	childMap    map[rune]*TrieNode
 	IsEndOfWord bool
 	Suggestions []WordSuggestion
 }
 
-// PrefixTrie represents the complete trie structure
+// PrefixTrie represents the complete trie structure. Snapshot returns an
+// immutable view sharing every node; later inserts copy the nodes on their path
+// instead of changing shared ones, so readers of a snapshot need no lock.
 type PrefixTrie struct {
 	Root        *TrieNode
 	AudioClipID string
+
+	gen   atomic.Uint64
+	arena nodeArena
+}
+
+// nodeArena hands out nodes from slabs, so inserts allocate once per
+// nodeSlabSize new nodes instead of once per node. Not safe for concurrent use.
+type nodeArena struct {
+	slab []TrieNode
+}
+
+func (a *nodeArena) newNode() *TrieNode {
+	if len(a.slab) == 0 {
+		a.slab = make([]TrieNode, nodeSlabSize)
+	}
+	node := &a.slab[0]
+	a.slab = a.slab[1:]
+	return node
 }
 
 // NewPrefixTrie creates a new prefix trie
 func NewPrefixTrie(audioClipID string) *PrefixTrie {
-	return &PrefixTrie{
-		Root: &TrieNode{
-			Children: make(map[rune]*TrieNode),
-		},
+	gen := trieGenerations.Add(1)
+	pt := &PrefixTrie{
+		Root:        &TrieNode{gen: gen},
 		AudioClipID: audioClipID,
 	}
+	pt.gen.Store(gen)
+	return pt
+}
+
+// Snapshot returns a consistent read-only view of the trie in O(1). It may be
+// called concurrently with other Snapshot calls and reads, but not with inserts.
+func (pt *PrefixTrie) Snapshot() *PrefixTrie {
+	snapshot := &PrefixTrie{
+		Root:        pt.Root,
+		AudioClipID: pt.AudioClipID,
+	}
+	// Both tries move to fresh generations, so neither writes to the shared nodes
+	snapshot.gen.Store(trieGenerations.Add(1))
+	pt.gen.Store(trieGenerations.Add(1))
+	return snapshot
+}
+
+// clone copies a node into generation gen, sharing its children
+func (n *TrieNode) clone(gen uint64, arena *nodeArena) *TrieNode {
+	copied := arena.newNode()
+	copied.gen = gen
+	copied.IsEndOfWord = n.IsEndOfWord
+	copied.Suggestions = append([]WordSuggestion(nil), n.Suggestions...)
+	if n.childMap != nil {
+		copied.childMap = make(map[rune]*TrieNode, len(n.childMap))
+		for key, child := range n.childMap {
+			copied.childMap[key] = child
+		}
+	} else {
+		copied.keys = append([]rune(nil), n.keys...)
+		copied.children = append([]*TrieNode(nil), n.children...)
+	}
+	return copied
+}
+
+// child returns the node's child for char, or nil
+func (n *TrieNode) child(char rune) *TrieNode {
+	if n.childMap != nil {
+		return n.childMap[char]
+	}
+	for i, key := range n.keys {
+		if key == char {
+			return n.children[i]
+		}
+		if key > char {
+			break
+		}
+	}
+	return nil
+}
+
+// mutableChild returns the node's child for char in generation gen: a child of
+// an older generation is replaced by a copy, and a missing one is created. The
+// node itself must already belong to gen.
+func (n *TrieNode) mutableChild(char rune, gen uint64, arena *nodeArena) *TrieNode {
+	if existing := n.child(char); existing != nil {
+		if existing.gen == gen {
+			return existing
+		}
+		copied := existing.clone(gen, arena)
+		n.replaceChild(char, copied)
+		return copied
+	}
+	created := arena.newNode()
+	created.gen = gen
+
+	if n.childMap != nil {
+		n.childMap[char] = created
+		return created
+	}
+	if len(n.keys) == smallChildLimit {
+		n.childMap = make(map[rune]*TrieNode, smallChildLimit*2)
+		for i, key := range n.keys {
+			n.childMap[key] = n.children[i]
+		}
+		n.childMap[char] = created
+		n.keys, n.children = nil, nil
+		return created
+	}
+
+	// Insert in rune order so lookups can stop early and walks need no sort
+	i := sort.Search(len(n.keys), func(i int) bool { return n.keys[i] > char })
+	n.keys = append(n.keys, 0)
+	copy(n.keys[i+1:], n.keys[i:])
+	n.keys[i] = char
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = created
+	return created
+}
+
+// replaceChild swaps the existing child for char
+func (n *TrieNode) replaceChild(char rune, child *TrieNode) {
+	if n.childMap != nil {
+		n.childMap[char] = child
+		return
+	}
+	for i, key := range n.keys {
+		if key == char {
+			n.children[i] = child
+			return
+		}
+	}
+}
+
+// eachChild calls fn for every child in rune order
+func (n *TrieNode) eachChild(fn func(char rune, child *TrieNode)) {
+	if n.childMap == nil {
+		for i, key := range n.keys {
+			fn(key, n.children[i])
+		}
+		return
+	}
+
+	keys := make([]rune, 0, len(n.childMap))
+	for key := range n.childMap {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, key := range keys {
+		fn(key, n.childMap[key])
+	}
 }
 
 // Insert adds a word and its suggestion to the trie
 func (pt *PrefixTrie) Insert(word string, suggestion WordSuggestion) {
+	pt.insert(word, suggestion, pt.mutableRoot(), &pt.arena)
+}
+
+// mutableRoot makes the root writable in the trie's current generation, copying
+// it first if a snapshot shares it, and returns that generation
+func (pt *PrefixTrie) mutableRoot() uint64 {
+	gen := pt.gen.Load()
+	if pt.Root.gen != gen {
+		pt.Root = pt.Root.clone(gen, &pt.arena)
+	}
+	return gen
+}
+
+func (pt *PrefixTrie) insert(word string, suggestion WordSuggestion, gen uint64, arena *nodeArena) {
 	node := pt.Root
 	for _, char := range word {
-		if node.Children[char] == nil {
-			node.Children[char] = &TrieNode{
-				Children: make(map[rune]*TrieNode),
-			}
-		}
-		node = node.Children[char]
+		node = node.mutableChild(char, gen, arena)
 	}
 	node.IsEndOfWord = true
-	node.Suggestions = append(node.Suggestions, suggestion)
-	
-	// Sort suggestions by confidence (descending)
-	sort.Slice(node.Suggestions, func(i, j int) bool {
-		return node.Suggestions[i].Confidence > node.Suggestions[j].Confidence
+
+	// Keep suggestions sorted by confidence (descending) by inserting in place
+	// after any equal confidences, rather than re-sorting on every insert
+	i := sort.Search(len(node.Suggestions), func(i int) bool {
+		return node.Suggestions[i].Confidence < suggestion.Confidence
 	})
+	node.Suggestions = append(node.Suggestions, WordSuggestion{})
+	copy(node.Suggestions[i+1:], node.Suggestions[i:])
+	node.Suggestions[i] = suggestion
+}
+
+// InsertAll adds suggestions keyed by their text using up to workers goroutines.
+// Words are sharded by first rune: each shard is a separate subtree of the root,
+// so shards fill concurrently without locking and each shard keeps input order.
+func (pt *PrefixTrie) InsertAll(suggestions []WordSuggestion, workers int) {
+	shards := make(map[rune][]WordSuggestion)
+	var order []rune
+	for _, suggestion := range suggestions {
+		if suggestion.Text == "" {
+			continue
+		}
+		first := []rune(suggestion.Text)[0]
+		if _, exists := shards[first]; !exists {
+			order = append(order, first)
+		}
+		shards[first] = append(shards[first], suggestion)
+	}
+
+	// Make the root and shard roots writable up front; after this the root's
+	// children are only read
+	gen := pt.mutableRoot()
+	for _, first := range order {
+		pt.Root.mutableChild(first, gen, &pt.arena)
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	queue := make(chan rune, len(order))
+	for _, first := range order {
+		queue <- first
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(order); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var arena nodeArena // Arenas aren't shared, so each worker has its own
+			for first := range queue {
+				for _, suggestion := range shards[first] {
+					pt.insert(suggestion.Text, suggestion, gen, &arena)
+				}
+			}
+		}()
+	}
+	wg.Wait()
 }
 
 // Search finds all words that start with the given prefix and returns their text.
 func (pt *PrefixTrie) Search(prefix string, maxResults int) []string {
+	var result []string
+	for _, s := range pt.SearchSuggestions(prefix, maxResults) {
+		result = append(result, s.Text)
+	}
+
+	return result
+}
+
+// SearchSuggestions finds the highest-confidence suggestions for words starting with the prefix.
+func (pt *PrefixTrie) SearchSuggestions(prefix string, maxResults int) []WordSuggestion {
 	node := pt.Root
 	for _, char := range prefix {
-		if node.Children[char] == nil {
-			return []string{}
+		node = node.child(char)
+		if node == nil {
+			return []WordSuggestion{}
 		}
-		node = node.Children[char]
 	}
-	
-	// Collect all WordSuggestions from the subtree
-	allSuggestions := pt.collectAllSuggestions(node)
-	
-	// Extract only the text and limit results
-	var result []string
-	for i, s := range allSuggestions {
-		if i >= maxResults {
-			break
-		}
-		result = append(result, s.Text)
+
+	// Collect all WordSuggestions from the subtree, then rank them once
+	allSuggestions := pt.collectAllSuggestions(node, nil)
+	sort.SliceStable(allSuggestions, func(i, j int) bool {
+		return allSuggestions[i].Confidence > allSuggestions[j].Confidence
+	})
+
+	if len(allSuggestions) > maxResults {
+		allSuggestions = allSuggestions[:maxResults]
 	}
-	
-	return result
+
+	return allSuggestions
 }
 
-// collectAllSuggestions recursively collects all suggestions from a node
-func (pt *PrefixTrie) collectAllSuggestions(node *TrieNode) []WordSuggestion {
-	var suggestions []WordSuggestion
-	
+// collectAllSuggestions appends the suggestions of a node and its descendants in trie order
+func (pt *PrefixTrie) collectAllSuggestions(node *TrieNode, suggestions []WordSuggestion) []WordSuggestion {
 	if node.IsEndOfWord {
 		suggestions = append(suggestions, node.Suggestions...)
 	}
-	
-	for _, child := range node.Children {
-		suggestions = append(suggestions, pt.collectAllSuggestions(child)...)
-	}
-	
-	// Sort by confidence (descending)
-	sort.Slice(suggestions, func(i, j int) bool {
-		return suggestions[i].Confidence > suggestions[j].Confidence
+
+	node.eachChild(func(_ rune, child *TrieNode) {
+		suggestions = pt.collectAllSuggestions(child, suggestions)
 	})
-	
+
 	return suggestions
-}
\ No newline at end of file
+}
+
+// Words returns every indexed word with the suggestions stored for it.
+func (pt *PrefixTrie) Words() map[string][]WordSuggestion {
+	words := make(map[string][]WordSuggestion)
+	pt.collectWords(pt.Root, []rune{}, words)
+	return words
+}
+
+// collectWords walks the trie depth-first, recording the path to every end node
+func (pt *PrefixTrie) collectWords(node *TrieNode, path []rune, words map[string][]WordSuggestion) {
+	if node.IsEndOfWord {
+		words[string(path)] = node.Suggestions
+	}
+
+	node.eachChild(func(char rune, child *TrieNode) {
+		pt.collectWords(child, append(path, char), words)
+	})
+}
+
+// Subtree returns a dump of the trie below the given prefix, or false if no
+// indexed word starts with it. An empty prefix dumps the whole trie.
+func (pt *PrefixTrie) Subtree(prefix string) (*TrieNodeDump, bool) {
+	node := pt.Root
+	for _, char := range prefix {
+		node = node.child(char)
+		if node == nil {
+			return nil, false
+		}
+	}
+	return dumpNode(node, prefix), true
+}
+
+// dumpNode converts a node and its descendants into their JSON representation
+func dumpNode(node *TrieNode, path string) *TrieNodeDump {
+	dump := &TrieNodeDump{
+		Path:        path,
+		IsEndOfWord: node.IsEndOfWord,
+		Suggestions: node.Suggestions,
+		Children:    []*TrieNodeDump{},
+	}
+	if node.IsEndOfWord {
+		dump.WordCount = 1
+	}
+
+	// Children are visited in rune order, which keeps the dump stable
+	node.eachChild(func(char rune, child *TrieNode) {
+		childDump := dumpNode(child, path+string(char))
+		dump.WordCount += childDump.WordCount
+		dump.Children = append(dump.Children, childDump)
+	})
+
+	return dump
+}
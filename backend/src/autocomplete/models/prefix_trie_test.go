@@ -0,0 +1,305 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestChildArraySorted(t *testing.T) {
+	tests := []struct {
+		words []string
+		want  []rune
+	}{
+		{[]string{"d", "b", "a", "c"}, []rune("abcd")},
+		{[]string{"z", "a", "m"}, []rune("amz")},
+		{[]string{"é", "e", "a"}, []rune("aeé")},
+		{[]string{"b", "b", "a"}, []rune("ab")},
+	}
+	for _, tt := range tests {
+		trie := NewPrefixTrie("test")
+		for _, word := range tt.words {
+			trie.Insert(word, WordSuggestion{Text: word})
+		}
+		if !reflect.DeepEqual(trie.Root.keys, tt.want) || trie.Root.childMap != nil {
+			t.Errorf("insert %v: keys = %q (map %v), want %q", tt.words, trie.Root.keys, trie.Root.childMap != nil, tt.want)
+		}
+		for i, key := range trie.Root.keys {
+			if trie.Root.child(key) != trie.Root.children[i] {
+				t.Errorf("insert %v: child(%q) is not children[%d]", tt.words, key, i)
+			}
+		}
+	}
+}
+
+func TestChildMapSwitch(t *testing.T) {
+	tests := []struct {
+		children int
+		wantMap  bool
+	}{
+		{1, false},
+		{smallChildLimit - 1, false},
+		{smallChildLimit, false},
+		{smallChildLimit + 1, true},
+		{3 * smallChildLimit, true},
+	}
+	for _, tt := range tests {
+		trie := NewPrefixTrie("test")
+		for i := tt.children - 1; i >= 0; i-- {
+			word := string(rune('a' + i))
+			trie.Insert(word, WordSuggestion{Text: word})
+		}
+		root := trie.Root
+		if (root.childMap != nil) != tt.wantMap {
+			t.Errorf("%d children: map = %v, want %v", tt.children, root.childMap != nil, tt.wantMap)
+		}
+		if tt.wantMap && (root.keys != nil || root.children != nil || len(root.childMap) != tt.children) {
+			t.Errorf("%d children: %d keys, %d children, %d in map after the switch", tt.children, len(root.keys), len(root.children), len(root.childMap))
+		}
+		for i := 0; i < tt.children; i++ {
+			if root.child(rune('a'+i)) == nil {
+				t.Errorf("%d children: child %q lost", tt.children, rune('a'+i))
+			}
+		}
+	}
+}
+
+func TestSearchOrderAcrossMapSwitch(t *testing.T) {
+	// Equal confidences list in rune order, whether a node's children are in
+	// the array or, past smallChildLimit, in the map
+	tests := []struct {
+		name     string
+		prefix   string
+		children int
+	}{
+		{"root array", "", smallChildLimit},
+		{"root map", "", smallChildLimit + 1},
+		{"inner array", "x", smallChildLimit},
+		{"inner map", "x", 2 * smallChildLimit},
+	}
+	for _, tt := range tests {
+		trie := NewPrefixTrie("test")
+		var want []string
+		for i := 0; i < tt.children; i++ {
+			want = append(want, tt.prefix+string(rune('a'+i)))
+		}
+		for i := len(want) - 1; i >= 0; i-- {
+			trie.Insert(want[i], WordSuggestion{Text: want[i], Confidence: 0.5})
+		}
+		if got := trie.Search(tt.prefix, len(want)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Search(%q) = %v, want %v", tt.name, tt.prefix, got, want)
+		}
+	}
+}
+
+// mapTrieNode is the map-per-node shape nodes had before their children moved
+// into sorted arrays, kept as the baseline of the insert benchmarks
+type mapTrieNode struct {
+	children    map[rune]*mapTrieNode
+	IsEndOfWord bool
+	Suggestions []WordSuggestion
+}
+
+func (n *mapTrieNode) insert(word string, suggestion WordSuggestion) {
+	node := n
+	for _, char := range word {
+		if node.children[char] == nil {
+			node.children[char] = &mapTrieNode{children: make(map[rune]*mapTrieNode)}
+		}
+		node = node.children[char]
+	}
+	node.IsEndOfWord = true
+	node.Suggestions = append(node.Suggestions, suggestion)
+}
+
+// benchmarkVocabulary generates n distinct Malay-like words with confidences
+func benchmarkVocabulary(n int) []WordSuggestion {
+	syllables := []string{"ma", "ka", "na", "ta", "sa", "la", "ber", "me", "di", "ke", "an", "kan", "lah", "pun", "nya", "ng", "ri", "ju"}
+	random := rand.New(rand.NewSource(1))
+	seen := make(map[string]bool, n)
+	words := make([]WordSuggestion, 0, n)
+	for len(words) < n {
+		word := ""
+		for i := 0; i < 2+random.Intn(4); i++ {
+			word += syllables[random.Intn(len(syllables))]
+		}
+		if seen[word] {
+			word = fmt.Sprintf("%s%d", word, len(words))
+		}
+		seen[word] = true
+		words = append(words, WordSuggestion{Text: word, Confidence: random.Float64()})
+	}
+	return words
+}
+
+func BenchmarkInsert(b *testing.B) {
+	words := benchmarkVocabulary(10000)
+
+	b.Run("array", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			trie := NewPrefixTrie("bench")
+			for _, word := range words {
+				trie.Insert(word.Text, word)
+			}
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			root := &mapTrieNode{children: make(map[rune]*mapTrieNode)}
+			for _, word := range words {
+				root.insert(word.Text, word)
+			}
+		}
+	})
+}
+
+func BenchmarkInsertAll(b *testing.B) {
+	words := benchmarkVocabulary(10000)
+
+	for _, workers := range []int{1, 4} {
+		b.Run(fmt.Sprintf("array/workers=%d", workers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				NewPrefixTrie("bench").InsertAll(words, workers)
+			}
+		})
+	}
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			root := &mapTrieNode{children: make(map[rune]*mapTrieNode)}
+			for _, word := range words {
+				root.insert(word.Text, word)
+			}
+		}
+	})
+}
+
+// snapshotState serializes what a trie's readers see, so a later change to a
+// shared node shows up even through slices the dump aliases
+func snapshotState(t *testing.T, trie *PrefixTrie, prefix string) string {
+	t.Helper()
+	subtree, _ := trie.Subtree(prefix)
+	state, err := json.Marshal(map[string]interface{}{
+		"words":   trie.Words(),
+		"subtree": subtree,
+		"search":  trie.SearchSuggestions(prefix, 100),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(state)
+}
+
+func TestSnapshotIsolation(t *testing.T) {
+	base := []string{"makan", "makna", "minum", "a", "b", "c", "d", "e", "f", "g"}
+	tests := []struct {
+		name   string
+		prefix string
+		insert []string
+	}{
+		{"new word under a shared node", "mak", []string{"makanan"}},
+		{"new suggestion of a shared word", "mak", []string{"makan"}},
+		{"new branch in a small array", "m", []string{"mandi", "mula"}},
+		{"small array switching to a map", "", []string{"h", "i", "j"}},
+		{"many inserts", "m", []string{"makanan", "makan", "mandi", "h", "i", "minuman", "x"}},
+	}
+	for _, tt := range tests {
+		trie := NewPrefixTrie("test")
+		for _, word := range base {
+			trie.Insert(word, WordSuggestion{Text: word, Confidence: 0.5})
+		}
+		snapshot := trie.Snapshot()
+		before := snapshotState(t, snapshot, tt.prefix)
+
+		for _, word := range tt.insert {
+			trie.Insert(word, WordSuggestion{Text: word, Confidence: 0.9, Source: "live"})
+		}
+
+		if after := snapshotState(t, snapshot, tt.prefix); after != before {
+			t.Errorf("%s: snapshot changed\nbefore %s\nafter  %s", tt.name, before, after)
+		}
+		live := trie.Words()
+		for _, word := range tt.insert {
+			if suggestions := live[word]; len(suggestions) == 0 || suggestions[0].Source != "live" {
+				t.Errorf("%s: live trie is missing %q: %v", tt.name, word, suggestions)
+			}
+		}
+	}
+}
+
+func TestSnapshotReadDuringInsert(t *testing.T) {
+	trie := NewPrefixTrie("test")
+	for _, word := range benchmarkVocabulary(2000) {
+		trie.Insert(word.Text, word)
+	}
+	snapshot := trie.Snapshot()
+	want := snapshotState(t, snapshot, "ma")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if got := snapshotState(t, snapshot, "ma"); got != want {
+					t.Error("snapshot changed while the live trie was written")
+					return
+				}
+			}
+		}()
+	}
+	for _, word := range benchmarkVocabulary(4000) {
+		trie.Insert(word.Text, WordSuggestion{Text: word.Text, Confidence: word.Confidence, Source: "live"})
+	}
+	wg.Wait()
+}
+
+func TestSubtree(t *testing.T) {
+	trie := NewPrefixTrie("test")
+	for _, word := range []string{"makan", "makanan", "makna", "minum", "tidur"} {
+		trie.Insert(word, WordSuggestion{Text: word, Confidence: 0.5})
+	}
+
+	tests := []struct {
+		prefix       string
+		found        bool
+		wordCount    int
+		children     []string
+		isEndOfWord  bool
+		wantWordText string
+	}{
+		{"", true, 5, []string{"m", "t"}, false, ""},
+		{"m", true, 4, []string{"ma", "mi"}, false, ""},
+		{"mak", true, 3, []string{"maka", "makn"}, false, ""},
+		{"makan", true, 2, []string{"makana"}, true, "makan"},
+		{"tidur", true, 1, []string{}, true, "tidur"},
+		{"x", false, 0, nil, false, ""},
+		{"makanan!", false, 0, nil, false, ""},
+	}
+	for _, tt := range tests {
+		dump, found := trie.Subtree(tt.prefix)
+		if found != tt.found {
+			t.Errorf("Subtree(%q) found = %v, want %v", tt.prefix, found, tt.found)
+			continue
+		}
+		if !found {
+			continue
+		}
+		children := []string{}
+		for _, child := range dump.Children {
+			children = append(children, child.Path)
+		}
+		if dump.Path != tt.prefix || dump.WordCount != tt.wordCount || dump.IsEndOfWord != tt.isEndOfWord || !reflect.DeepEqual(children, tt.children) {
+			t.Errorf("Subtree(%q) = path %q, %d words, end %v, children %v", tt.prefix, dump.Path, dump.WordCount, dump.IsEndOfWord, children)
+		}
+		if tt.isEndOfWord && (len(dump.Suggestions) != 1 || dump.Suggestions[0].Text != tt.wantWordText) {
+			t.Errorf("Subtree(%q) suggestions = %v", tt.prefix, dump.Suggestions)
+		}
+	}
+}
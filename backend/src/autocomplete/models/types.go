@@ -10,6 +10,7 @@ type WordSuggestion struct {
 	Confidence float64 `json:"confidence"`
 	Source     string  `json:"source"`
 	Rank       int     `json:"rank"`
+	Agreement  int     `json:"agreement,omitempty"` // ASR sources that produced this word at its position
 }
 
 
@@ -28,4 +29,298 @@ type AutocompleteData struct {
 	ConfidenceScore   float64           `json:"confidence_score"`
 	DetectedParticles []string          `json:"detected_particles"`
 	ASRAlternatives   map[string]string `json:"asr_alternatives"`
-}
\ No newline at end of file
+	PotentialParticles []PotentialParticle `json:"potential_particles,omitempty"`
+	Topics            []string          `json:"topics,omitempty"` // Subject or domain tags, e.g. "biology"
+	Accent            string            `json:"accent,omitempty"` // Accent or dialect hint, e.g. "kelantanese"
+	SpeakerSegments   []SpeakerSegment  `json:"speaker_segments,omitempty"`
+	Segments          []AudioSegment    `json:"segments,omitempty"`
+	WordTimestamps    []WordTimestamp   `json:"word_timestamps,omitempty"` // One per baseline word, in order
+}
+
+// WordTimestamp is when a baseline word is spoken, in seconds
+type WordTimestamp struct {
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// AudioSegment maps a window of the audio, in seconds, to the baseline words
+// heard in it. StartWord and EndWord are inclusive word positions.
+type AudioSegment struct {
+	ID        string  `json:"id"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+	StartWord int     `json:"start_word"`
+	EndWord   int     `json:"end_word"`
+}
+
+// SpeakerSegment labels a run of baseline words with the speaker diarization
+// attributed them to. StartWord and EndWord are inclusive word positions.
+type SpeakerSegment struct {
+	Speaker   string `json:"speaker"`
+	StartWord int    `json:"start_word"`
+	EndWord   int    `json:"end_word"`
+}
+
+// PotentialParticle is a discourse particle the orchestrator heard in the audio
+// but which may be missing from the transcript. WordIndex is the word it follows.
+type PotentialParticle struct {
+	Particle          string  `json:"particle"`
+	Confidence        float64 `json:"confidence"`
+	WordIndex         int     `json:"word_index"`
+	CharacterPosition int     `json:"character_position"`
+	IPA               string  `json:"ipa,omitempty"`
+	Region            string  `json:"region,omitempty"`
+}
+
+// IndexVersionInfo summarises one stored version of a clip's index
+type IndexVersionInfo struct {
+	Version   int       `json:"version"`
+	Reason    string    `json:"reason"`
+	WordCount int       `json:"word_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ConfidenceShift describes a word present in both versions whose best suggestion changed
+type ConfidenceShift struct {
+	Text          string  `json:"text"`
+	OldConfidence float64 `json:"old_confidence"`
+	NewConfidence float64 `json:"new_confidence"`
+	Delta         float64 `json:"delta"`
+	OldSource     string  `json:"old_source"`
+	NewSource     string  `json:"new_source"`
+}
+
+// VersionDiff represents the difference between two index versions of a clip
+type VersionDiff struct {
+	AudioID string            `json:"audio_id"`
+	From    int               `json:"from"`
+	To      int               `json:"to"`
+	Added   []WordSuggestion  `json:"added"`
+	Removed []WordSuggestion  `json:"removed"`
+	Changed []ConfidenceShift `json:"changed"`
+}
+
+// TrieNodeDump is the JSON representation of a trie node and its subtree
+type TrieNodeDump struct {
+	Path        string           `json:"path"`
+	IsEndOfWord bool             `json:"is_end_of_word"`
+	WordCount   int              `json:"word_count"`
+	Suggestions []WordSuggestion `json:"suggestions,omitempty"`
+	Children    []*TrieNodeDump  `json:"children"`
+}
+
+// CacheStats summarises the in-memory clip cache
+type CacheStats struct {
+	ClipCount      int            `json:"clip_count"`
+	ClipWordCounts map[string]int `json:"clip_word_counts"`
+	Hits           int64          `json:"hits"`
+	Misses         int64          `json:"misses"`
+	HitRatio       float64        `json:"hit_ratio"`
+	Evictions      int64          `json:"evictions"`
+	Rebuilds       int64          `json:"rebuilds"` // Cached tries replaced by a re-initialize
+}
+
+// RedisNamespaceStats summarises the Redis keys under one key namespace
+type RedisNamespaceStats struct {
+	KeyCount       int64 `json:"key_count"`
+	SampledKeys    int64 `json:"sampled_keys"`
+	EstimatedBytes int64 `json:"estimated_bytes"`
+}
+
+// ClipSnapshot is the serialised form of a clip's index, written by the offline builder
+type ClipSnapshot struct {
+	AudioID   string                      `json:"audio_id"`
+	CreatedAt time.Time                   `json:"created_at"`
+	Words     map[string][]WordSuggestion `json:"words"`
+	Positions PositionMap                 `json:"positions,omitempty"`
+}
+
+// ServiceSnapshot is the complete in-memory state of an instance, used to
+// hydrate a replacement instance during a deploy
+type ServiceSnapshot struct {
+	CreatedAt time.Time        `json:"created_at"`
+	Clips     []ClipSnapshot   `json:"clips"`
+	Seed      []WordSuggestion `json:"seed,omitempty"`
+}
+
+// RedactionReport summarises the PII found in one initialize payload
+type RedactionReport struct {
+	Mode         string         `json:"mode"`
+	TotalMatches int            `json:"total_matches"`
+	Redacted     bool           `json:"redacted"`
+	Entities     map[string]int `json:"entities"`
+	Fields       map[string]int `json:"fields"`
+}
+
+// Token is one word of a transcript with its surrounding text kept separately,
+// so the transcript can be rebuilt exactly after words are replaced
+type Token struct {
+	Leading   string `json:"leading,omitempty"`   // Punctuation before the word, e.g. an opening quote
+	Text      string `json:"text"`
+	Trailing  string `json:"trailing,omitempty"`  // Punctuation after the word, e.g. a comma
+	Separator string `json:"separator,omitempty"` // Whitespace (and any word-less text) up to the next token
+}
+
+// WordOccurrence is a word some ASR model heard at a position of an indexed clip
+type WordOccurrence struct {
+	AudioID    string  `json:"audio_id"`
+	Position   int     `json:"position"`
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+	Baseline   bool    `json:"baseline"` // The word is what the position currently reads as
+}
+
+// VocabularyEntry aggregates one word over every position of a clip it was
+// heard at
+type VocabularyEntry struct {
+	Text           string   `json:"text"`
+	Occurrences    int      `json:"occurrences"`
+	MaxConfidence  float64  `json:"max_confidence"`
+	MeanConfidence float64  `json:"mean_confidence"`
+	Sources        []string `json:"sources"`
+	Positions      []int    `json:"positions"`
+	Baseline       int      `json:"baseline"` // Positions that currently read as the word
+}
+
+// ClipStats characterizes an indexed clip for the dashboard
+type ClipStats struct {
+	Words            int                `json:"words"`          // Word positions
+	Vocabulary       int                `json:"vocabulary"`     // Distinct candidate words, ignoring case
+	ModelCoverage    map[string]float64 `json:"model_coverage"` // Share of positions each ASR source produced a candidate at
+	AverageAgreement float64            `json:"average_agreement"`
+	Particles        int                `json:"particles"` // Positions whose baseline word is a discourse particle
+}
+
+// PositionMap holds the candidate words heard at each word position of a clip
+type PositionMap map[int][]WordSuggestion
+
+// Word diff markers of a model transcript aligned to the baseline
+const (
+	DiffSame    = "same"
+	DiffChanged = "changed"
+	DiffMissing = "missing" // The model has no word at a baseline position
+	DiffExtra   = "extra"   // The model has words past the end of the baseline
+)
+
+// AlignedWord is one word of a model transcript at a baseline position
+type AlignedWord struct {
+	Position int    `json:"position"`
+	Word     string `json:"word"`
+	Baseline string `json:"baseline"`
+	Diff     string `json:"diff"`
+}
+
+// SentenceAlternative is a model's complete transcript aligned word by word to the baseline
+type SentenceAlternative struct {
+	Model      string        `json:"model"`
+	Transcript string        `json:"transcript"`
+	Changed    int           `json:"changed"`
+	Words      []AlignedWord `json:"words"`
+}
+
+// SentenceAlternatives lists every model's transcript of a clip against its baseline
+type SentenceAlternatives struct {
+	AudioID  string                `json:"audio_id"`
+	Baseline string                `json:"baseline"`
+	Tokens   []Token               `json:"tokens"`
+	Models   []SentenceAlternative `json:"models"`
+}
+
+// WordSimulation is how one ground-truth word of a clip is typed by a user who
+// accepts the word as soon as it is suggested
+type WordSimulation struct {
+	Position   int    `json:"position"`
+	Word       string `json:"word"`
+	Keystrokes int    `json:"keystrokes"`  // Typing the word in full
+	Typed      int    `json:"typed"`       // Characters typed plus the acceptance, if any
+	Saved      int    `json:"saved"`
+	AcceptedAt int    `json:"accepted_at"` // Characters typed before the word was suggested, 0 if never
+	Rank       int    `json:"rank,omitempty"`
+}
+
+// KeystrokeSimulation totals the keystrokes saved over a clip's ground-truth transcript
+type KeystrokeSimulation struct {
+	AudioID     string           `json:"audio_id"`
+	MaxResults  int              `json:"max_results"`
+	Keystrokes  int              `json:"keystrokes"`
+	Typed       int              `json:"typed"`
+	Saved       int              `json:"saved"`
+	SavingsRate float64          `json:"savings_rate"` // Saved over keystrokes
+	Accepted    int              `json:"accepted"`     // Words completed from a suggestion
+	Words       []WordSimulation `json:"words"`
+}
+
+// EvaluatedPosition is how the suggestions at one word position of a clip
+// ranked the reference word
+type EvaluatedPosition struct {
+	Position int    `json:"position"`
+	Word     string `json:"word"`
+	Rank     int    `json:"rank"`    // 1-based within the top k, 0 when not suggested
+	Covered  bool   `json:"covered"` // Some ASR model heard the word at the position
+}
+
+// RankingMetrics summarises evaluated positions. HitRate is keyed by cutoff,
+// e.g. "@5"; MRR counts positions missing from the top k as 0.
+type RankingMetrics struct {
+	Words    int                `json:"words"`
+	HitRate  map[string]float64 `json:"hit_rate"`
+	MRR      float64            `json:"mrr"`
+	Coverage float64            `json:"coverage"`
+}
+
+// ClipEvaluation is the ranking quality of one clip against its reference transcript
+type ClipEvaluation struct {
+	AudioID string `json:"audio_id"`
+	RankingMetrics
+	Positions []EvaluatedPosition `json:"positions"`
+}
+
+// Evaluation is the ranking quality over every evaluated clip
+type Evaluation struct {
+	K            int `json:"k"`
+	PrefixLength int `json:"prefix_length"`
+	RankingMetrics
+	Clips []ClipEvaluation `json:"clips"`
+}
+
+// SessionAccept is a word accepted at a clip position during review: a
+// last-writer-wins register ordered by At (Unix nanoseconds), then Replica
+type SessionAccept struct {
+	Word    string `json:"word"`
+	At      int64  `json:"at"`
+	Replica string `json:"replica"`
+}
+
+// SessionState is the mergeable review state of a clip. Confusions count, per
+// heard baseline word and accepted word, the acceptances each replica made, so
+// replicas merge by taking the larger count per replica and rank by the sum.
+type SessionState struct {
+	Accepted   map[int]SessionAccept                `json:"accepted"`
+	Confusions map[string]map[string]map[string]int `json:"confusions"`
+}
+
+// SyncStamp identifies the change that produced a clip's cached index: the
+// instance it was made on, that instance's change sequence number and when
+type SyncStamp struct {
+	Origin string    `json:"origin"`
+	Seq    int       `json:"seq"`
+	At     time.Time `json:"at"`
+}
+
+// ClipDelta is one clip's index as exported for delta sync
+type ClipDelta struct {
+	ClipSnapshot
+	Stamp SyncStamp `json:"stamp"`
+}
+
+// SyncDelta carries the clip indexes an instance changed since a version
+// vector (the highest change sequence number seen per origin instance).
+// Vector is what the receiver has seen once it applies the delta; More means
+// the export was cut short and another round is needed.
+type SyncDelta struct {
+	Origin string         `json:"origin"`
+	Vector map[string]int `json:"vector"`
+	Clips  []ClipDelta    `json:"clips"`
+	More   bool           `json:"more"`
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Next-word ranking: the n-gram probability of a follower, with trigrams
+// interpolated over bigrams when the two-word context was seen, blended with
+// the word's ASR confidence in the prefix index relative to the most
+// confident follower
+const (
+	nextTrigramWeight = 0.7
+	nextNgramWeight   = 0.7
+	nextFollowerFetch = 100
+)
+
+// handleNextWordSuggest predicts the word after the previous one or two words
+// from the bigrams and trigrams counted over ingested baselines. prefix keeps
+// the followers starting with what has been typed of the next word.
+func (s *AutocompleteService) handleNextWordSuggest(c *gin.Context) {
+	previous := services.TranscriptWords(c.Query("previous"))
+	if len(previous) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "previous parameter required"})
+		return
+	}
+	if len(previous) > 2 {
+		previous = previous[len(previous)-2:]
+	}
+	for i, word := range previous {
+		previous[i] = strings.ToLower(word)
+	}
+	maxResults, err := services.ParseMaxResults(c.Query("max_results"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prefix := strings.ToLower(services.NormalizeQuery(c.Query("prefix")))
+
+	suggestions, err := s.getNextWordSuggestions(context.Background(), requestTenant(c), previous, prefix, maxResults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(suggestions) > 0 {
+		s.suggestHits.Add(1)
+	} else {
+		s.suggestMisses.Add(1)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"previous":    previous,
+		"prefix":      prefix,
+		"suggestions": suggestions,
+	})
+}
+
+// getNextWordSuggestions ranks the followers of the previous words. Each
+// reports its n-gram "probability" next to the blended confidence.
+func (s *AutocompleteService) getNextWordSuggestions(ctx context.Context, tenant string, previous []string, prefix string, maxResults int) ([]map[string]interface{}, error) {
+	client := s.readClient()
+	pipe := client.Pipeline()
+	bigrams := pipe.ZRevRangeWithScores(ctx, ngramKeyPrefix+previous[len(previous)-1], 0, nextFollowerFetch-1)
+	var trigrams *redis.ZSliceCmd
+	if len(previous) == 2 {
+		trigrams = pipe.ZRevRangeWithScores(ctx, ngramKeyPrefix+strings.Join(previous, " "), 0, nextFollowerFetch-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	var trigramFollowers []redis.Z
+	if trigrams != nil {
+		trigramFollowers = trigrams.Val()
+	}
+	probabilities := interpolateNgrams(bigrams.Val(), trigramFollowers, prefix)
+	if len(probabilities) == 0 {
+		return []map[string]interface{}{}, nil
+	}
+
+	// The followers' ASR confidence, 0 for words no longer indexed. Prefix
+	// scores aren't bounded by 1, so they are scaled by the highest.
+	words := make([]string, 0, len(probabilities))
+	pipe = client.Pipeline()
+	confidences := make([]*redis.FloatCmd, 0, len(probabilities))
+	for word := range probabilities {
+		words = append(words, word)
+		confidences = append(confidences, pipe.ZScore(ctx, indexedPrefixKey(word), word))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64, len(words))
+	for i, word := range words {
+		scores[word] = confidences[i].Val()
+	}
+	ranked := rankNextWords(probabilities, scores)
+	ranked = services.ApplySuggestFilters(tenant, ranked)
+	if len(ranked) > maxResults {
+		ranked = ranked[:maxResults]
+	}
+
+	suggestions := make([]map[string]interface{}, len(ranked))
+	for i, suggestion := range ranked {
+		suggestions[i] = map[string]interface{}{
+			"text":        suggestion.Text,
+			"confidence":  suggestion.Confidence,
+			"probability": probabilities[suggestion.Text],
+		}
+	}
+	return suggestions, nil
+}
+
+// interpolateNgrams gives each follower starting with prefix its probability
+// after the last previous word, interpolated with its probability after both
+// when trigrams were seen
+func interpolateNgrams(bigrams, trigrams []redis.Z, prefix string) map[string]float64 {
+	probabilities := ngramProbabilities(bigrams)
+	if len(trigrams) > 0 {
+		fromTrigrams := ngramProbabilities(trigrams)
+		for word := range fromTrigrams {
+			if _, exists := probabilities[word]; !exists {
+				probabilities[word] = 0
+			}
+		}
+		for word, probability := range probabilities {
+			probabilities[word] = nextTrigramWeight*fromTrigrams[word] + (1-nextTrigramWeight)*probability
+		}
+	}
+	for word := range probabilities {
+		if !strings.HasPrefix(word, prefix) {
+			delete(probabilities, word)
+		}
+	}
+	return probabilities
+}
+
+// rankNextWords blends each follower's probability with its prefix score
+// scaled by the highest, best first and ties in word order
+func rankNextWords(probabilities, scores map[string]float64) []models.WordSuggestion {
+	highest := 0.0
+	for _, score := range scores {
+		highest = math.Max(highest, score)
+	}
+	ranked := make([]models.WordSuggestion, 0, len(probabilities))
+	for word, probability := range probabilities {
+		confidence := 0.0
+		if highest > 0 {
+			confidence = scores[word] / highest
+		}
+		ranked = append(ranked, models.WordSuggestion{
+			Text:       word,
+			Confidence: nextNgramWeight*probability + (1-nextNgramWeight)*confidence,
+		})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Confidence != ranked[j].Confidence {
+			return ranked[i].Confidence > ranked[j].Confidence
+		}
+		return ranked[i].Text < ranked[j].Text
+	})
+	return ranked
+}
+
+// ngramProbabilities turns the follower counts of one context into shares of
+// the counts read
+func ngramProbabilities(followers []redis.Z) map[string]float64 {
+	total := 0.0
+	for _, follower := range followers {
+		total += follower.Score
+	}
+	probabilities := make(map[string]float64, len(followers))
+	for _, follower := range followers {
+		if total > 0 {
+			probabilities[follower.Member.(string)] = follower.Score / total
+		}
+	}
+	return probabilities
+}
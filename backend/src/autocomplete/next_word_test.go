@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestInterpolateNgrams(t *testing.T) {
+	bigrams := []redis.Z{{Member: "makan", Score: 3}, {Member: "minum", Score: 1}}
+	trigrams := []redis.Z{{Member: "minum", Score: 2}, {Member: "mandi", Score: 2}}
+	tests := []struct {
+		name     string
+		bigrams  []redis.Z
+		trigrams []redis.Z
+		prefix   string
+		want     map[string]float64
+	}{
+		{"bigrams only", bigrams, nil, "", map[string]float64{"makan": 0.75, "minum": 0.25}},
+		{"trigrams interpolated", bigrams, trigrams, "", map[string]float64{"makan": 0.225, "minum": 0.425, "mandi": 0.35}},
+		{"prefix", bigrams, trigrams, "mi", map[string]float64{"minum": 0.425}},
+		{"no bigrams", nil, trigrams, "", map[string]float64{"minum": 0.35, "mandi": 0.35}},
+		{"no followers", nil, nil, "", map[string]float64{}},
+		{"no follower matches", bigrams, nil, "x", map[string]float64{}},
+	}
+	for _, tt := range tests {
+		got := interpolateNgrams(tt.bigrams, tt.trigrams, tt.prefix)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: interpolateNgrams() = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for word, want := range tt.want {
+			if math.Abs(got[word]-want) > 1e-9 {
+				t.Errorf("%s: %s = %v, want %v", tt.name, word, got[word], want)
+			}
+		}
+	}
+}
+
+func TestRankNextWords(t *testing.T) {
+	tests := []struct {
+		name          string
+		probabilities map[string]float64
+		scores        map[string]float64
+		want          []string
+		wantTop       float64
+	}{
+		{"probability first", map[string]float64{"makan": 0.6, "minum": 0.4}, map[string]float64{"makan": 1, "minum": 1}, []string{"makan", "minum"}, 0.72},
+		{"scores scaled by the highest", map[string]float64{"makan": 0.5, "minum": 0.5}, map[string]float64{"makan": 20, "minum": 40}, []string{"minum", "makan"}, 0.65},
+		{"unindexed follower", map[string]float64{"makan": 0.5, "minum": 0.5}, map[string]float64{"makan": 2}, []string{"makan", "minum"}, 0.65},
+		{"no scores", map[string]float64{"makan": 0.5, "minum": 0.5}, map[string]float64{}, []string{"makan", "minum"}, 0.35},
+		{"confidence outweighs a small lead", map[string]float64{"makan": 0.55, "minum": 0.45}, map[string]float64{"minum": 5}, []string{"minum", "makan"}, 0.615},
+	}
+	for _, tt := range tests {
+		ranked := rankNextWords(tt.probabilities, tt.scores)
+		got := make([]string, len(ranked))
+		for i, suggestion := range ranked {
+			got[i] = suggestion.Text
+			if suggestion.Confidence < 0 || suggestion.Confidence > 1 {
+				t.Errorf("%s: %s confidence %v out of [0, 1]", tt.name, suggestion.Text, suggestion.Confidence)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ranked %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		if math.Abs(ranked[0].Confidence-tt.wantTop) > 1e-9 {
+			t.Errorf("%s: top confidence %v, want %v", tt.name, ranked[0].Confidence, tt.wantTop)
+		}
+	}
+}
+
+func TestHandleNextWordSuggestRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	service := &AutocompleteService{}
+	router := gin.New()
+	router.GET("/suggest/next", service.handleNextWordSuggest)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"no previous words", ""},
+		{"blank previous words", "?previous=%20%20"},
+		{"bad max_results", "?previous=saya&max_results=many"},
+		{"zero max_results", "?previous=saya&max_results=0"},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/suggest/next"+tt.query, nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400: %s", tt.name, recorder.Code, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// errClipNotIndexed is returned when a stateless clip has no index in Redis
+var errClipNotIndexed = errors.New("clip is not indexed")
+
+// handleClipOccurrences returns the positions a clip's transcript reads a word
+// at, from the word → positions index built at initialize, so the editor can
+// replace every occurrence of a word without scanning the transcript
+func (s *AutocompleteService) handleClipOccurrences(c *gin.Context) {
+	audioID := services.NormalizeAudioID(c.Param("audio_id"))
+	word := services.OccurrenceKey(c.Query("word"))
+	if word == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "word parameter required"})
+		return
+	}
+
+	positions, err := s.clipOccurrences(context.Background(), audioID, word)
+	if err == errClipNotIndexed || err != nil && !s.Stateless {
+		c.JSON(http.StatusNotFound, gin.H{"error": "autocomplete not initialized for clip " + audioID + ", please initialize first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if positions == nil {
+		positions = []int{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"audio_id":  audioID,
+		"word":      word,
+		"count":     len(positions),
+		"positions": positions,
+	})
+}
+
+// clipOccurrences reads a word's positions from the clip's occurrence index:
+// the cached one normally, or the Redis hash in stateless mode
+func (s *AutocompleteService) clipOccurrences(ctx context.Context, audioID, word string) ([]int, error) {
+	if !s.Stateless {
+		return services.ClipOccurrences(audioID, word)
+	}
+
+	s.touchClip(audioID)
+	client := s.clipReadClient(audioID)
+	encoded, err := client.HGet(ctx, clipOccurrencesKey(audioID), word).Result()
+	if err == redis.Nil {
+		// An unknown word or an unknown clip; only the latter is an error
+		exists, err := client.Exists(ctx, clipWordsKey(audioID)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			return nil, errClipNotIndexed
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []int
+	if err := json.Unmarshal([]byte(encoded), &positions); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleClipOccurrences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan saya"})
+
+	router := gin.New()
+	router.GET("/clips/:audio_id/occurrences", (&AutocompleteService{}).handleClipOccurrences)
+	stateless := gin.New()
+	stateless.GET("/clips/:audio_id/occurrences", (&AutocompleteService{Stateless: true, RedisClient: unreachableRedis(t)}).handleClipOccurrences)
+
+	tests := []struct {
+		router     *gin.Engine
+		path       string
+		wantStatus int
+		want       []int
+	}{
+		{router, "/clips/clip/occurrences?word=Saya", http.StatusOK, []int{0, 2}},
+		{router, "/clips/clip/occurrences?word=tidur", http.StatusOK, []int{}},
+		{router, "/clips/clip/occurrences?word=%20", http.StatusBadRequest, nil},
+		{router, "/clips/other/occurrences?word=saya", http.StatusNotFound, nil},
+		{stateless, "/clips/clip/occurrences?word=saya", http.StatusInternalServerError, nil},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.path, w.Code, tt.wantStatus, w.Body)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var response struct {
+			Count     int   `json:"count"`
+			Positions []int `json:"positions"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(response.Positions, tt.want) || response.Count != len(tt.want) {
+			t.Errorf("%s: %d positions %v, want %v", tt.path, response.Count, response.Positions, tt.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// Feedback for the orchestrator's model-improvement loop is written to an
+// outbox stream in the same Redis transaction as the correction it reports,
+// then delivered by a consumer group spread over the replicas. Entries that
+// keep failing move to the dead-letter stream.
+const (
+	outboxStreamKey = redisKeyPrefix + "outbox"
+	outboxDeadKey   = redisKeyPrefix + "outbox:dead"
+	outboxGroup     = "forwarder"
+)
+
+// Outbox event types
+const (
+	outboxCorrection = "correction"
+	outboxVerified   = "verified"
+)
+
+// outboxBatch is how many entries a delivery round reads or reclaims
+const outboxBatch = 50
+
+// feedbackOutbox forwards outbox entries to the orchestrator
+type feedbackOutbox struct {
+	url         string
+	secret      []byte
+	client      *http.Client
+	consumer    string
+	maxAttempts int64
+	retryAfter  time.Duration
+
+	delivered    atomic.Int64
+	failures     atomic.Int64
+	deadLettered atomic.Int64
+}
+
+// newFeedbackOutbox forwards feedback to FEEDBACK_FORWARD_URL, signed with
+// WEBHOOK_SECRET when set, or returns nil to keep feedback local
+func newFeedbackOutbox(consumer string) *feedbackOutbox {
+	url := os.Getenv("FEEDBACK_FORWARD_URL")
+	if url == "" {
+		return nil
+	}
+	return &feedbackOutbox{
+		url:         url,
+		secret:      []byte(os.Getenv("WEBHOOK_SECRET")),
+		client:      &http.Client{Timeout: envDuration("WEBHOOK_TIMEOUT", 5*time.Second)},
+		consumer:    consumer,
+		maxAttempts: int64(envInt("FEEDBACK_MAX_ATTEMPTS", 10)),
+		retryAfter:  envDuration("FEEDBACK_RETRY_INTERVAL", 30*time.Second),
+	}
+}
+
+// addOutboxEntry queues an outbox write on pipe, so the entry commits or fails
+// together with the write it reports. It is a no-op when forwarding is off.
+func (s *AutocompleteService) addOutboxEntry(ctx context.Context, pipe redis.Pipeliner, eventType string, data interface{}) error {
+	if s.outbox == nil {
+		return nil
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: outboxStreamKey,
+		Values: map[string]interface{}{
+			"type":       eventType,
+			"payload":    payload,
+			"created_at": time.Now().UnixMilli(),
+		},
+	})
+	return nil
+}
+
+// runOutbox delivers outbox entries until ctx ends: first reclaiming entries
+// whose delivery failed at least retryAfter ago, on any replica, then reading
+// new ones
+func (s *AutocompleteService) runOutbox(ctx context.Context) {
+	err := s.RedisClient.XGroupCreateMkStream(ctx, outboxStreamKey, outboxGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		log.Printf("Error creating feedback outbox group: %v", err)
+	}
+
+	for ctx.Err() == nil {
+		if err := s.retryOutbox(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error retrying feedback outbox: %v", err)
+		}
+
+		streams, err := s.RedisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    outboxGroup,
+			Consumer: s.outbox.consumer,
+			Streams:  []string{outboxStreamKey, ">"},
+			Count:    outboxBatch,
+			Block:    s.outbox.retryAfter,
+		}).Result()
+		if err == redis.Nil {
+			continue // Nothing new within the block
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error reading feedback outbox: %v", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		for _, stream := range streams {
+			for _, message := range stream.Messages {
+				s.deliverOutboxEntry(ctx, message)
+			}
+		}
+	}
+}
+
+// retryOutbox redelivers pending entries idle for retryAfter, dead-lettering
+// those that have used up their attempts
+func (s *AutocompleteService) retryOutbox(ctx context.Context) error {
+	pending, err := s.RedisClient.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: outboxStreamKey,
+		Group:  outboxGroup,
+		Idle:   s.outbox.retryAfter,
+		Start:  "-",
+		End:    "+",
+		Count:  outboxBatch,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range pending {
+		claimed, err := s.RedisClient.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   outboxStreamKey,
+			Group:    outboxGroup,
+			Consumer: s.outbox.consumer,
+			MinIdle:  s.outbox.retryAfter,
+			Messages: []string{entry.ID},
+		}).Result()
+		if err != nil {
+			return err
+		}
+		for _, message := range claimed { // Empty when another replica claimed it first
+			if entry.RetryCount >= s.outbox.maxAttempts {
+				if err := s.deadLetterOutboxEntry(ctx, message, entry.RetryCount); err != nil {
+					return err
+				}
+				continue
+			}
+			s.deliverOutboxEntry(ctx, message)
+		}
+	}
+	return nil
+}
+
+// deliverOutboxEntry posts one entry to the orchestrator and acknowledges it
+// on success; a failed entry stays pending until it is retried
+func (s *AutocompleteService) deliverOutboxEntry(ctx context.Context, message redis.XMessage) {
+	event := outboxEvent(message)
+	body, err := json.Marshal(event)
+	if err == nil {
+		err = postEvent(ctx, s.outbox.client, s.outbox.secret, s.outbox.url, event, body)
+	}
+	if err != nil {
+		s.outbox.failures.Add(1)
+		log.Printf("Error forwarding %s feedback %s: %v", event.Type, message.ID, err)
+		return
+	}
+
+	pipe := s.RedisClient.TxPipeline()
+	pipe.XAck(ctx, outboxStreamKey, outboxGroup, message.ID)
+	pipe.XDel(ctx, outboxStreamKey, message.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Error acknowledging feedback %s: %v", message.ID, err)
+		return
+	}
+	s.outbox.delivered.Add(1)
+}
+
+// deadLetterOutboxEntry moves an entry out of the outbox after its last attempt
+func (s *AutocompleteService) deadLetterOutboxEntry(ctx context.Context, message redis.XMessage, attempts int64) error {
+	values := map[string]interface{}{"outbox_id": message.ID, "attempts": attempts}
+	for field, value := range message.Values {
+		values[field] = value
+	}
+
+	pipe := s.RedisClient.TxPipeline()
+	pipe.XAdd(ctx, &redis.XAddArgs{Stream: outboxDeadKey, Values: values})
+	pipe.XAck(ctx, outboxStreamKey, outboxGroup, message.ID)
+	pipe.XDel(ctx, outboxStreamKey, message.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	s.outbox.deadLettered.Add(1)
+	log.Printf("Dead-lettered feedback %s after %d attempts", message.ID, attempts)
+	return nil
+}
+
+// outboxEvent turns an outbox entry into the event posted for it. The entry
+// id is the event id, so the orchestrator can deduplicate redeliveries.
+func outboxEvent(message redis.XMessage) webhookEvent {
+	eventType, _ := message.Values["type"].(string)
+	payload, _ := message.Values["payload"].(string)
+	createdAt, _ := strconv.ParseInt(fmt.Sprint(message.Values["created_at"]), 10, 64)
+	return webhookEvent{
+		ID:        message.ID,
+		Type:      "feedback." + eventType,
+		CreatedAt: time.UnixMilli(createdAt),
+		Data:      json.RawMessage(payload),
+	}
+}
+
+// handleOutboxStatus reports the outbox backlog, delivery counts and the
+// most recent dead letters
+func (s *AutocompleteService) handleOutboxStatus(c *gin.Context) {
+	if s.outbox == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	ctx := context.Background()
+	pipe := s.RedisClient.Pipeline()
+	backlog := pipe.XLen(ctx, outboxStreamKey)
+	deadCount := pipe.XLen(ctx, outboxDeadKey)
+	dead := pipe.XRevRangeN(ctx, outboxDeadKey, "+", "-", 20)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":       true,
+		"backlog":       backlog.Val(),
+		"dead_letters":  deadCount.Val(),
+		"recent_dead":   dead.Val(),
+		"delivered":     s.outbox.delivered.Load(),
+		"failures":      s.outbox.failures.Load(),
+		"dead_lettered": s.outbox.deadLettered.Load(),
+	})
+}
+
+// handleOutboxRedrive moves every dead letter back into the outbox with
+// fresh attempts, e.g. once the orchestrator is fixed
+func (s *AutocompleteService) handleOutboxRedrive(c *gin.Context) {
+	if s.outbox == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "feedback forwarding is disabled; set FEEDBACK_FORWARD_URL"})
+		return
+	}
+	ctx := context.Background()
+	dead, err := s.RedisClient.XRange(ctx, outboxDeadKey, "-", "+").Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	for i, message := range dead {
+		pipe := s.RedisClient.TxPipeline()
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: outboxStreamKey,
+			Values: map[string]interface{}{
+				"type":       message.Values["type"],
+				"payload":    message.Values["payload"],
+				"created_at": message.Values["created_at"],
+			},
+		})
+		pipe.XDel(ctx, outboxDeadKey, message.ID)
+		if _, err := pipe.Exec(ctx); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "redriven": i})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "redriven", "redriven": len(dead)})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestOutboxEvent(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      map[string]interface{}
+		wantType    string
+		wantCreated time.Time
+		wantPayload string
+	}{
+		{"correction", map[string]interface{}{"type": outboxCorrection, "payload": `{"word":"makan"}`, "created_at": "1700000000000"}, "feedback.correction", time.UnixMilli(1700000000000), `{"word":"makan"}`},
+		{"verified", map[string]interface{}{"type": outboxVerified, "payload": `{}`, "created_at": "5"}, "feedback.verified", time.UnixMilli(5), `{}`},
+		{"missing created_at", map[string]interface{}{"type": outboxCorrection, "payload": `{}`}, "feedback.correction", time.UnixMilli(0), `{}`},
+	}
+	for _, tt := range tests {
+		event := outboxEvent(redis.XMessage{ID: "1-0", Values: tt.values})
+		payload, _ := event.Data.(json.RawMessage)
+		if event.ID != "1-0" || event.Type != tt.wantType || !event.CreatedAt.Equal(tt.wantCreated) || string(payload) != tt.wantPayload {
+			t.Errorf("%s: outboxEvent() = %+v (payload %s)", tt.name, event, payload)
+		}
+	}
+}
+
+func TestNewFeedbackOutbox(t *testing.T) {
+	t.Setenv("FEEDBACK_FORWARD_URL", "")
+	if outbox := newFeedbackOutbox("replica"); outbox != nil {
+		t.Errorf("newFeedbackOutbox() without a URL = %+v, want nil", outbox)
+	}
+
+	t.Setenv("FEEDBACK_FORWARD_URL", "http://orchestrator/feedback")
+	t.Setenv("FEEDBACK_MAX_ATTEMPTS", "3")
+	t.Setenv("FEEDBACK_RETRY_INTERVAL", "1m")
+	outbox := newFeedbackOutbox("replica")
+	if outbox == nil || outbox.url != "http://orchestrator/feedback" || outbox.consumer != "replica" || outbox.maxAttempts != 3 || outbox.retryAfter != time.Minute {
+		t.Errorf("newFeedbackOutbox() = %+v", outbox)
+	}
+}
+
+func TestAddOutboxEntryWithoutForwarding(t *testing.T) {
+	service := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	pipe := service.RedisClient.TxPipeline()
+	if err := service.addOutboxEntry(context.Background(), pipe, outboxCorrection, map[string]string{"word": "makan"}); err != nil {
+		t.Fatal(err)
+	}
+	if queued := pipe.Len(); queued != 0 {
+		t.Errorf("queued %d commands with forwarding off", queued)
+	}
+}
+
+func TestDeliverOutboxEntry(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantFailures int64
+	}{
+		{"accepted", http.StatusOK, 0},
+		{"rejected", http.StatusInternalServerError, 1},
+		{"redirected", http.StatusMultipleChoices, 1},
+	}
+	for _, tt := range tests {
+		var gotType, gotNonce, gotSignature, gotBody string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			gotType, gotNonce, gotSignature, gotBody = r.Header.Get("X-Autocomplete-Event"), r.Header.Get(replayNonceHeader), r.Header.Get(webhookSignatureHeader), string(body)
+			w.WriteHeader(tt.status)
+		}))
+
+		service := &AutocompleteService{
+			RedisClient: unreachableRedis(t),
+			outbox:      &feedbackOutbox{url: server.URL, secret: []byte("secret"), client: server.Client()},
+		}
+		service.deliverOutboxEntry(context.Background(), redis.XMessage{ID: "7-0", Values: map[string]interface{}{
+			"type":       outboxCorrection,
+			"payload":    `{"word":"makan"}`,
+			"created_at": "1700000000000",
+		}})
+		server.Close()
+
+		if gotType != "feedback.correction" || gotNonce != "7-0" || gotSignature == "" {
+			t.Errorf("%s: posted type %q, nonce %q, signature %q", tt.name, gotType, gotNonce, gotSignature)
+		}
+		var event struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(gotBody), &event); err != nil || event.Data["word"] != "makan" {
+			t.Errorf("%s: posted body %s", tt.name, gotBody)
+		}
+		if failures := service.outbox.failures.Load(); failures != tt.wantFailures {
+			t.Errorf("%s: %d failures, want %d", tt.name, failures, tt.wantFailures)
+		}
+		// Acknowledging needs Redis, so even an accepted entry is not counted
+		// as delivered here and stays pending for a retry
+		if delivered := service.outbox.delivered.Load(); delivered != 0 {
+			t.Errorf("%s: %d delivered without an acknowledgement", tt.name, delivered)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Placement checks: a particle is implausible in a sentence slot that held under
+// implausibleSlotShare of its learned sightings, once it has minSlotSightings
+const (
+	minSlotSightings     = 10
+	implausibleSlotShare = 0.05
+)
+
+// particleWarning flags one particle placement of a sentence
+type particleWarning struct {
+	Position int      `json:"position"`
+	Particle string   `json:"particle"`
+	Slot     string   `json:"slot"`
+	Message  string   `json:"message"`
+	Share    *float64 `json:"share,omitempty"` // Share of sightings in the slot, when learned
+}
+
+// handleValidateParticles checks a corrected sentence for implausible particle
+// placement before it is finalized. Each particle's sentence slot is compared
+// with how often the particle was seen there in ingested baselines; particles
+// without enough sightings fall back to the lexicon rule that a particle
+// follows the word it modifies, so it can't open a sentence. Repeated
+// particles are flagged as well. Warnings never block the sentence.
+func (s *AutocompleteService) handleValidateParticles(c *gin.Context) {
+	var request struct {
+		Sentence string `json:"sentence" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lexicon, err := services.ParticleLexicon()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens := services.Tokenize(request.Sentence)
+	occurrences := services.FindParticles(tokens, lexicon)
+	warnings, err := s.particleWarnings(context.Background(), tokens, occurrences)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sentence":  request.Sentence,
+		"particles": len(occurrences),
+		"valid":     len(warnings) == 0,
+		"warnings":  warnings,
+	})
+}
+
+// particleWarnings checks each particle occurrence against the learned slot statistics
+func (s *AutocompleteService) particleWarnings(ctx context.Context, tokens []models.Token, occurrences []services.ParticleOccurrence) ([]particleWarning, error) {
+	warnings := []particleWarning{}
+	if len(occurrences) == 0 {
+		return warnings, nil
+	}
+
+	slots := []string{services.SlotInitial, services.SlotMedial, services.SlotFinal}
+	pipe := s.readClient().Pipeline()
+	reads := make([]*redis.SliceCmd, len(occurrences))
+	for i, occurrence := range occurrences {
+		fields := make([]string, len(slots))
+		for j, slot := range slots {
+			fields[j] = occurrence.Particle + ":" + slot
+		}
+		reads[i] = pipe.HMGet(ctx, particleSlotsKey, fields...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	for i, occurrence := range occurrences {
+		counts := make(map[string]float64, len(slots))
+		for j, value := range reads[i].Val() {
+			if value != nil {
+				counts[slots[j]], _ = strconv.ParseFloat(value.(string), 64)
+			}
+		}
+		if warning := placementWarning(tokens, occurrence, counts); warning != nil {
+			warnings = append(warnings, *warning)
+		}
+	}
+	return warnings, nil
+}
+
+// placementWarning checks one particle occurrence against its learned sightings
+// per sentence slot, or returns nil when its placement is plausible
+func placementWarning(tokens []models.Token, occurrence services.ParticleOccurrence, counts map[string]float64) *particleWarning {
+	warning := &particleWarning{
+		Position: occurrence.Position,
+		Particle: occurrence.Particle,
+		Slot:     occurrence.Slot,
+	}
+	if occurrence.Position > 0 && strings.EqualFold(tokens[occurrence.Position-1].Text, occurrence.Particle) {
+		warning.Message = fmt.Sprintf("%q is repeated", occurrence.Particle)
+		return warning
+	}
+
+	var total float64
+	for _, count := range counts {
+		total += count
+	}
+
+	switch {
+	case total >= minSlotSightings:
+		share := counts[occurrence.Slot] / total
+		if share >= implausibleSlotShare {
+			return nil
+		}
+		warning.Message = fmt.Sprintf("%q is rarely %s in a sentence (%.0f%% of %.0f sightings)", occurrence.Particle, occurrence.Slot, share*100, total)
+		warning.Share = &share
+		return warning
+	case occurrence.Slot == services.SlotInitial:
+		warning.Message = fmt.Sprintf("%q follows the word it modifies and shouldn't open a sentence", occurrence.Particle)
+		return warning
+	}
+	return nil
+}
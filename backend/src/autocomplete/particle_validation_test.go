@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/services"
+)
+
+func TestPlacementWarning(t *testing.T) {
+	lexicon := map[string]bool{"lah": true, "kan": true}
+	tests := []struct {
+		name      string
+		sentence  string
+		particle  int // Index of the occurrence checked
+		counts    map[string]float64
+		want      string // Start of the message, empty for no warning
+		wantShare float64
+	}{
+		{"final, unlearned", "makan lah", 0, nil, "", 0},
+		{"initial, unlearned", "lah makan", 0, nil, `"lah" follows the word`, 0},
+		{"initial after a full stop", "Dah. Lah makan", 0, nil, `"lah" follows the word`, 0},
+		{"too few sightings", "lah makan", 0, map[string]float64{"final": 9}, `"lah" follows the word`, 0},
+		{"learned initial", "lah makan", 0, map[string]float64{"initial": 5, "final": 5}, "", 0},
+		{"rare slot", "makan lah nasi", 0, map[string]float64{"medial": 1, "final": 39}, `"lah" is rarely medial in a sentence (2% of 40 sightings)`, 0.025},
+		{"share at the limit", "makan lah nasi", 0, map[string]float64{"medial": 1, "final": 19}, "", 0},
+		{"repeated", "makan lah lah", 1, map[string]float64{"final": 40}, `"lah" is repeated`, 0},
+		{"repeated across case", "makan Kan kan", 1, nil, `"kan" is repeated`, 0},
+	}
+	for _, tt := range tests {
+		tokens := services.Tokenize(tt.sentence)
+		occurrence := services.FindParticles(tokens, lexicon)[tt.particle]
+		got := placementWarning(tokens, occurrence, tt.counts)
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("%s: placementWarning() = %q, want none", tt.name, got.Message)
+			}
+			continue
+		}
+		if got == nil || !strings.HasPrefix(got.Message, tt.want) {
+			t.Errorf("%s: placementWarning() = %+v, want %q", tt.name, got, tt.want)
+			continue
+		}
+		if got.Position != occurrence.Position || got.Slot != occurrence.Slot {
+			t.Errorf("%s: warning at %d (%s), want %d (%s)", tt.name, got.Position, got.Slot, occurrence.Position, occurrence.Slot)
+		}
+		if (got.Share == nil) != (tt.wantShare == 0) || (got.Share != nil && *got.Share != tt.wantShare) {
+			t.Errorf("%s: share = %v, want %v", tt.name, got.Share, tt.wantShare)
+		}
+	}
+}
+
+func TestHandleValidateParticles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &AutocompleteService{}
+	router := gin.New()
+	router.POST("/validate/particles", s.handleValidateParticles)
+
+	// Sentences without particles are valid before the learned slots are read
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`{"sentence": "saya makan nasi"}`, http.StatusOK},
+		{`{}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/validate/particles", strings.NewReader(tt.body)))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.body, w.Code, tt.wantStatus, w.Body)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var response struct {
+			Particles int               `json:"particles"`
+			Valid     bool              `json:"valid"`
+			Warnings  []particleWarning `json:"warnings"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Particles != 0 || !response.Valid || len(response.Warnings) != 0 {
+			t.Errorf("%s: response = %+v, want valid with no particles", tt.body, response)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// Learned particle patterns: which particles follow each word, how often each
+// word was seen in a baseline transcript at all, and how often each particle
+// opened, closed or sat inside a sentence (fields "{particle}:{slot}")
+const (
+	particleAfterKeyPrefix = redisKeyPrefix + "particle:after:"
+	particleWordCountKey   = redisKeyPrefix + "particle:words"
+	particleSlotsKey       = redisKeyPrefix + "particle:slots"
+)
+
+// Insertion scoring: learned patterns need a few sightings and rank below the
+// orchestrator's own particle detections at the same likelihood
+const (
+	minParticleSightings  = 2
+	learnedParticleWeight = 0.8
+	defaultInsertions     = 10
+)
+
+// Sources of insertion suggestions
+const (
+	insertionSourceDetected = "potential_particles"
+	insertionSourceLearned  = "learned_pattern"
+)
+
+// particleInsertion suggests inserting a particle into the gap before word Gap
+// of a clip's baseline; gap 0 is before the first word
+type particleInsertion struct {
+	Gap        int     `json:"gap"`
+	After      string  `json:"after"`
+	Before     string  `json:"before"`
+	Particle   string  `json:"particle"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+}
+
+// learnParticlePatterns counts the words of a baseline transcript, the
+// particles following them and the sentence slots the particles sit in
+func (s *AutocompleteService) learnParticlePatterns(ctx context.Context, transcription string) error {
+	tokens := services.Tokenize(transcription)
+	if len(tokens) == 0 || tokens[0].Text == "" {
+		return nil
+	}
+	lexicon, err := services.ParticleLexicon()
+	if err != nil {
+		return err
+	}
+
+	pipe := s.RedisClient.Pipeline()
+	for _, token := range tokens {
+		pipe.HIncrBy(ctx, particleWordCountKey, strings.ToLower(token.Text), 1)
+	}
+	for _, occurrence := range services.FindParticles(tokens, lexicon) {
+		pipe.HIncrBy(ctx, particleSlotsKey, occurrence.Particle+":"+occurrence.Slot, 1)
+		if occurrence.After != "" {
+			pipe.ZIncrBy(ctx, particleAfterKeyPrefix+occurrence.After, 1, occurrence.Particle)
+		}
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// handleInsertionSuggestions suggests discourse particles to insert between the
+// words of a clip's baseline, for the editor to render as ghost chips. Particles
+// the orchestrator heard at a word are suggested after it with their detection
+// confidence; learned patterns suggest particles that often follow a word, with
+// the share of its sightings they followed. gap=N restricts suggestions to one gap.
+func (s *AutocompleteService) handleInsertionSuggestions(c *gin.Context) {
+	audioID := c.Query("audio_id")
+
+	gap := -1
+	if gapParam := c.Query("gap"); gapParam != "" {
+		var err error
+		if gap, err = strconv.Atoi(gapParam); err != nil || gap < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "gap must be a non-negative integer"})
+			return
+		}
+	}
+	maxResults := defaultInsertions
+	if maxParam := c.Query("max_results"); maxParam != "" {
+		n, err := strconv.Atoi(maxParam)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_results must be a positive integer"})
+			return
+		}
+		maxResults = n
+	}
+
+	ctx := context.Background()
+	data, err := s.clipTranscripts(ctx, audioID)
+	if err == redis.Nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no transcripts stored for clip " + services.NormalizeAudioID(audioID)})
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if !s.Stateless {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	words := services.TranscriptWords(data.FinalTranscription)
+	if gap > len(words) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "gap is past the end of the transcript"})
+		return
+	}
+	lexicon, err := services.ParticleLexicon()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	best := make(map[string]particleInsertion)
+	suggest := func(insertion particleInsertion) {
+		if gap >= 0 && insertion.Gap != gap {
+			return
+		}
+		// A particle already written on either side of the gap needs no insertion
+		if insertion.Gap > 0 && strings.EqualFold(words[insertion.Gap-1], insertion.Particle) ||
+			insertion.Gap < len(words) && strings.EqualFold(words[insertion.Gap], insertion.Particle) {
+			return
+		}
+		insertion.After, insertion.Before = gapNeighbours(words, insertion.Gap)
+		key := strconv.Itoa(insertion.Gap) + ":" + insertion.Particle
+		if existing, exists := best[key]; !exists || insertion.Confidence > existing.Confidence {
+			best[key] = insertion
+		}
+	}
+
+	for _, detected := range data.PotentialParticles {
+		particle := strings.ToLower(strings.TrimSpace(detected.Particle))
+		if particle == "" || detected.WordIndex < 0 || detected.WordIndex >= len(words) {
+			continue
+		}
+		suggest(particleInsertion{
+			Gap:        detected.WordIndex + 1,
+			Particle:   particle,
+			Confidence: detected.Confidence,
+			Source:     insertionSourceDetected,
+		})
+	}
+
+	learned, err := s.learnedParticles(ctx, words, gap, lexicon)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, insertion := range learned {
+		suggest(insertion)
+	}
+
+	insertions := make([]particleInsertion, 0, len(best))
+	for _, insertion := range best {
+		insertions = append(insertions, insertion)
+	}
+	sort.Slice(insertions, func(i, j int) bool {
+		if insertions[i].Confidence != insertions[j].Confidence {
+			return insertions[i].Confidence > insertions[j].Confidence
+		}
+		if insertions[i].Gap != insertions[j].Gap {
+			return insertions[i].Gap < insertions[j].Gap
+		}
+		return insertions[i].Particle < insertions[j].Particle
+	})
+	if len(insertions) > maxResults {
+		insertions = insertions[:maxResults]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"audio_id":   services.NormalizeAudioID(audioID),
+		"insertions": insertions,
+	})
+}
+
+// learnedParticles reads the learned followers of each word of the transcript
+// (or only the word before gap, when one is given) as insertion candidates
+func (s *AutocompleteService) learnedParticles(ctx context.Context, words []string, gap int, lexicon map[string]bool) ([]particleInsertion, error) {
+	var positions []int
+	for pos, word := range words {
+		if (gap < 0 || pos == gap-1) && !lexicon[strings.ToLower(word)] {
+			positions = append(positions, pos)
+		}
+	}
+	if len(positions) == 0 {
+		return nil, nil
+	}
+
+	client := s.readClient()
+	pipe := client.Pipeline()
+	followers := make([]*redis.ZSliceCmd, len(positions))
+	counts := make([]*redis.StringCmd, len(positions))
+	for i, pos := range positions {
+		word := strings.ToLower(words[pos])
+		followers[i] = pipe.ZRevRangeWithScores(ctx, particleAfterKeyPrefix+word, 0, -1)
+		counts[i] = pipe.HGet(ctx, particleWordCountKey, word)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	var insertions []particleInsertion
+	for i, pos := range positions {
+		sightings, err := counts[i].Float64()
+		if err != nil || sightings < minParticleSightings {
+			continue
+		}
+		for _, follower := range followers[i].Val() {
+			insertions = append(insertions, particleInsertion{
+				Gap:        pos + 1,
+				Particle:   follower.Member.(string),
+				Confidence: learnedParticleWeight * follower.Score / sightings,
+				Source:     insertionSourceLearned,
+			})
+		}
+	}
+	return insertions, nil
+}
+
+// gapNeighbours returns the words either side of a gap, empty at the ends
+func gapNeighbours(words []string, gap int) (after, before string) {
+	if gap > 0 {
+		after = words[gap-1]
+	}
+	if gap < len(words) {
+		before = words[gap]
+	}
+	return after, before
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestGapNeighbours(t *testing.T) {
+	words := []string{"saya", "makan", "nasi"}
+	tests := []struct {
+		gap           int
+		after, before string
+	}{
+		{0, "", "saya"},
+		{1, "saya", "makan"},
+		{3, "nasi", ""},
+	}
+	for _, tt := range tests {
+		if after, before := gapNeighbours(words, tt.gap); after != tt.after || before != tt.before {
+			t.Errorf("gapNeighbours(%d) = %q, %q, want %q, %q", tt.gap, after, before, tt.after, tt.before)
+		}
+	}
+}
+
+func TestHandleInsertionSuggestions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{
+		FinalTranscription: "jom lah makan",
+		PotentialParticles: []models.PotentialParticle{
+			{Particle: "Kan ", Confidence: 0.6, WordIndex: 1},
+			{Particle: "pun", Confidence: 0.9, WordIndex: 1},
+			{Particle: "kan", Confidence: 0.8, WordIndex: 1}, // The best detection of a particle wins
+			{Particle: "lah", Confidence: 0.9, WordIndex: 1}, // Already written before the gap
+			{Particle: "eh", Confidence: 0.9, WordIndex: 0},  // Another gap
+			{Particle: "weh", Confidence: 0.9, WordIndex: 7}, // Past the transcript
+		},
+	})
+
+	s := &AutocompleteService{}
+	router := gin.New()
+	router.GET("/suggest/insertions", s.handleInsertionSuggestions)
+
+	// The word before gap 2 is itself a particle, so no learned patterns are read
+	tests := []struct {
+		query      string
+		wantStatus int
+		want       []string
+	}{
+		{"?audio_id=clip&gap=2", http.StatusOK, []string{"pun", "kan"}},
+		{"?audio_id=clip&gap=2&max_results=1", http.StatusOK, []string{"pun"}},
+		{"?audio_id=clip&gap=4", http.StatusBadRequest, nil},
+		{"?audio_id=clip&gap=-1", http.StatusBadRequest, nil},
+		{"?audio_id=clip&gap=2&max_results=0", http.StatusBadRequest, nil},
+		{"?audio_id=other&gap=2", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/suggest/insertions"+tt.query, nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.query, w.Code, tt.wantStatus, w.Body)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var response struct {
+			Insertions []particleInsertion `json:"insertions"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, insertion := range response.Insertions {
+			got = append(got, insertion.Particle)
+			if insertion.Gap != 2 || insertion.After != "lah" || insertion.Before != "makan" || insertion.Source != insertionSourceDetected {
+				t.Errorf("%s: insertion = %+v", tt.query, insertion)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: insertions = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
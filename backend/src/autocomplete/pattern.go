@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Bounds of a /suggest/pattern lookup: results returned by default and at
+// most, matches collected before ranking, and vocabulary entries scanned
+const (
+	defaultPatternResults = 20
+	maxPatternResults     = 200
+	maxPatternMatches     = 5000
+	maxPatternScan        = 200000
+)
+
+// handlePatternSuggest lists vocabulary words matching a simple pattern such
+// as "ma?an" or "ber*kan", most frequent first. The lookup scans the global
+// frequency set with a bounded budget; truncated reports when it stopped early.
+func (s *AutocompleteService) handlePatternSuggest(c *gin.Context) {
+	pattern := services.NormalizeText(strings.TrimSpace(c.Query("pattern")))
+	if strings.Trim(pattern, "*?") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "pattern must contain at least one letter"})
+		return
+	}
+	maxResults := defaultPatternResults
+	if maxParam := c.Query("max_results"); maxParam != "" {
+		n, err := strconv.Atoi(maxParam)
+		if err != nil || n < 1 || n > maxPatternResults {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_results must be between 1 and " + strconv.Itoa(maxPatternResults)})
+			return
+		}
+		maxResults = n
+	}
+
+	matches, truncated, err := s.matchVocabulary(context.Background(), pattern)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	words := make([]models.WordSuggestion, len(matches))
+	for i, match := range matches {
+		words[i] = models.WordSuggestion{Text: match.Member.(string), Confidence: match.Score}
+	}
+	words = services.ApplySuggestFilters(requestTenant(c), words)
+	total := len(words)
+	if len(words) > maxResults {
+		words = words[:maxResults]
+	}
+
+	results := make([]gin.H, len(words))
+	for i, word := range words {
+		results[i] = gin.H{"text": word.Text, "frequency": word.Confidence}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"pattern":   pattern,
+		"matches":   results,
+		"total":     total,
+		"truncated": truncated,
+	})
+}
+
+// matchVocabulary returns the words of the global frequency set matching the
+// pattern case-insensitively, most frequent first. It stops after
+// maxPatternMatches matches or maxPatternScan scanned entries, reporting
+// whether it did.
+func (s *AutocompleteService) matchVocabulary(ctx context.Context, pattern string) ([]redis.Z, bool, error) {
+	glob := strings.ToLower(pattern)
+	client := s.readClient()
+
+	var matches []redis.Z
+	var cursor uint64
+	scanned := 0
+	for {
+		// ZSCAN pairs each member with its score
+		entries, next, err := client.ZScan(ctx, globalFrequencyKey, cursor, "", 1000).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		for i := 0; i+1 < len(entries); i += 2 {
+			if !globMatch(glob, strings.ToLower(entries[i])) {
+				continue
+			}
+			score, err := strconv.ParseFloat(entries[i+1], 64)
+			if err != nil {
+				continue
+			}
+			matches = append(matches, redis.Z{Member: entries[i], Score: score})
+		}
+		scanned += len(entries) / 2
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+		if len(matches) >= maxPatternMatches || scanned >= maxPatternScan {
+			sortByScore(matches)
+			return matches, true, nil
+		}
+	}
+	sortByScore(matches)
+	return matches, false, nil
+}
+
+// globMatch reports whether text matches a pattern in which ? stands for one
+// letter and * for any run of letters
+func globMatch(pattern, text string) bool {
+	p, t := []rune(pattern), []rune(text)
+	pi, ti := 0, 0
+	star, resume := -1, 0
+	for ti < len(t) {
+		switch {
+		case pi < len(p) && p[pi] == '*':
+			star, resume = pi, ti
+			pi++
+		case pi < len(p) && (p[pi] == '?' || p[pi] == t[ti]):
+			pi++
+			ti++
+		case star >= 0:
+			pi = star + 1
+			resume++
+			ti = resume
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// sortByScore orders entries highest score first, ties by text
+func sortByScore(entries []redis.Z) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].Member.(string) < entries[j].Member.(string)
+	})
+}
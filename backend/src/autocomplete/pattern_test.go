@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    bool
+	}{
+		{"makan", "makan", true},
+		{"makan", "makanan", false},
+		{"ma?an", "makan", true},
+		{"ma?an", "maan", false},
+		{"ber*kan", "berkan", true},
+		{"ber*kan", "bersihkan", true},
+		{"ber*kan", "bersihkannya", false},
+		{"*lah", "pergilah", true},
+		{"*lah*", "lahir", true},
+		{"ke*an*", "kebersihannya", true},
+		{"*", "", true},
+		{"?", "", false},
+		{"?é", "té", true},
+		{"a*b*c", "aXbYbZc", true},
+		{"a*b*c", "aXbYc Z", false},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.text); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSortByScore(t *testing.T) {
+	entries := []redis.Z{
+		{Member: "minum", Score: 2},
+		{Member: "makan", Score: 5},
+		{Member: "mandi", Score: 2},
+		{Member: "makna", Score: 1},
+	}
+	sortByScore(entries)
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Member.(string))
+	}
+	if want := []string{"makan", "mandi", "minum", "makna"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sortByScore() = %v, want %v", got, want)
+	}
+}
+
+func TestHandlePatternSuggest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/suggest/pattern", (&AutocompleteService{RedisClient: unreachableRedis(t)}).handlePatternSuggest)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?pattern=*", http.StatusBadRequest},
+		{"?pattern=?*?", http.StatusBadRequest},
+		{"?pattern=ma?an&max_results=0", http.StatusBadRequest},
+		{"?pattern=ma?an&max_results=x", http.StatusBadRequest},
+		{"?pattern=ber*kan", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/suggest/pattern"+tt.query, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%q: status %d, want %d: %s", tt.query, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+
+	"autocomplete/services"
+)
+
+// Suggest match modes: prefix matches spelling, phoneme matches how the typed
+// text sounds according to services.Phonemes
+const (
+	matchModePrefix  = "prefix"
+	matchModePhoneme = "phoneme"
+)
+
+// phonemeKeyPrefix indexes words by the prefixes of their phoneme strings
+const phonemeKeyPrefix = redisKeyPrefix + "phoneme:"
+
+// getPhonemeSuggestions returns indexed words whose pronunciation starts with
+// the pronunciation of the typed text, so "fon" finds "phone" and "cinta"
+// finds "chinta"
+func (s *AutocompleteService) getPhonemeSuggestions(ctx context.Context, tenant, typed string, maxResults int) ([]map[string]interface{}, error) {
+	phonemes := services.Phonemes(typed)
+	if phonemes == "" {
+		return []map[string]interface{}{}, nil
+	}
+
+	key := phonemeKeyPrefix + indexedRunes(phonemes)
+	results, err := s.readClient().ZRevRangeWithScores(ctx, key, 0, int64(suggestFetchCount(tenant, maxResults)-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return formatSuggestions(tenant, results, maxResults), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// positionBlendWeight is the default share of a position-conditioned score taken
+// by the positional prior, set from POSITION_BLEND_WEIGHT
+var positionBlendWeight = 0.5
+
+// positionFetch is the minimum number of prefix matches read before blending, so
+// words the ASR heard at the slot can climb from below the top few
+const positionFetch = 20
+
+// parseBlendWeight reads the per-request blend weight, defaulting to positionBlendWeight
+func parseBlendWeight(param string) (float64, bool) {
+	if param == "" {
+		return positionBlendWeight, true
+	}
+	weight, err := strconv.ParseFloat(param, 64)
+	if err != nil || weight < 0 || weight > 1 {
+		return 0, false
+	}
+	return weight, true
+}
+
+// getPositionedSuggestions ranks prefix matches by blending their prefix score
+// with the positional prior: the confidence of the word among the candidates the
+// ASR models heard at wordIndex of the clip (0 when it wasn't heard there).
+// Heard candidates matching the prefix are included even when they fall outside
+// the prefix index's top results.
+func (s *AutocompleteService) getPositionedSuggestions(ctx context.Context, tenant, prefix, audioID string, wordIndex int, weight float64, maxResults int) ([]map[string]interface{}, error) {
+	fetch := suggestFetchCount(tenant, maxResults)
+	if fetch < positionFetch {
+		fetch = positionFetch
+	}
+	results, err := s.rankedPrefix(ctx, prefix, fetch)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.positionCandidates(ctx, audioID, strconv.Itoa(wordIndex))
+	if err != nil {
+		return nil, err
+	}
+	prior := make(map[string]float64)
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate.Text, prefix) && candidate.Confidence > prior[candidate.Text] {
+			prior[candidate.Text] = candidate.Confidence
+		}
+	}
+
+	scores := make(map[string]float64, len(results)+len(prior))
+	for _, result := range results {
+		scores[result.Member.(string)] = result.Score
+	}
+
+	// Look up the prefix scores of heard words the top results missed
+	var missing []string
+	for word := range prior {
+		if _, exists := scores[word]; !exists {
+			missing = append(missing, word)
+		}
+	}
+	if len(missing) > 0 {
+		pipe := s.readClient().Pipeline()
+		lookups := make([]*redis.FloatCmd, len(missing))
+		for i, word := range missing {
+			lookups[i] = pipe.ZScore(ctx, indexedPrefixKey(prefix), word)
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, err
+		}
+		for i, word := range missing {
+			scores[word] = lookups[i].Val() // 0 when the word isn't indexed
+		}
+	}
+
+	return formatSuggestions(tenant, blendPositionPrior(scores, prior, weight), maxResults), nil
+}
+
+// blendPositionPrior ranks words by their prefix score blended with their
+// positional prior, ties by text
+func blendPositionPrior(scores, prior map[string]float64, weight float64) []redis.Z {
+	blended := make([]redis.Z, 0, len(scores))
+	for word, score := range scores {
+		blended = append(blended, redis.Z{Member: word, Score: (1-weight)*score + weight*prior[word]})
+	}
+	sort.Slice(blended, func(i, j int) bool {
+		if blended[i].Score != blended[j].Score {
+			return blended[i].Score > blended[j].Score
+		}
+		return blended[i].Member.(string) < blended[j].Member.(string)
+	})
+	return blended
+}
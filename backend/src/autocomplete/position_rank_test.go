@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBlendWeight(t *testing.T) {
+	tests := []struct {
+		param  string
+		want   float64
+		wantOK bool
+	}{
+		{"", positionBlendWeight, true},
+		{"0", 0, true},
+		{"0.8", 0.8, true},
+		{"1", 1, true},
+		{"1.5", 0, false},
+		{"-0.1", 0, false},
+		{"half", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseBlendWeight(tt.param)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseBlendWeight(%q) = %v, %v, want %v, %v", tt.param, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestBlendPositionPrior(t *testing.T) {
+	scores := map[string]float64{"makan": 0.9, "makna": 0.5, "makam": 0.2}
+	prior := map[string]float64{"makna": 0.9, "makam": 0.8}
+
+	tests := []struct {
+		weight float64
+		want   []string
+	}{
+		{0, []string{"makan", "makna", "makam"}},   // Prefix score only
+		{0.5, []string{"makna", "makam", "makan"}}, // 0.70, 0.50, 0.45
+		{1, []string{"makna", "makam", "makan"}},   // Prior only
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, result := range blendPositionPrior(scores, prior, tt.weight) {
+			got = append(got, result.Member.(string))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("weight %v: blendPositionPrior() = %v, want %v", tt.weight, got, tt.want)
+		}
+	}
+}
+
+func TestBlendPositionPriorTies(t *testing.T) {
+	blended := blendPositionPrior(map[string]float64{"makna": 0.5, "makan": 0.5}, nil, 0.5)
+	if blended[0].Member != "makan" || blended[1].Member != "makna" {
+		t.Errorf("blendPositionPrior() ties = %v, want makan before makna", blended)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Limits of /suggest/positions: suggestions per word by default and at most,
+// and the widest range of word indices one request may cover
+const (
+	defaultPositionResults = 5
+	maxPositionResults     = 50
+	maxPositionRange       = 500
+	maxPositionWindow      = 3
+)
+
+// Kinds and reasons of the edit hints /suggest/positions returns with a window
+const (
+	hintInsert          = "insert"
+	hintDelete          = "delete"
+	deleteReasonRepeat  = "repeated"
+	deleteReasonUnheard = "unheard_particle"
+)
+
+// positionHint suggests inserting a word into a gap between two returned
+// positions (gap N is before word N), or deleting the word at an index
+type positionHint struct {
+	Type       string  `json:"type"`
+	Gap        *int    `json:"gap,omitempty"`
+	WordIndex  *int    `json:"word_index,omitempty"`
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// handlePositionRange answers the candidates heard at every word index from
+// from to to (inclusive) in one response, so the editor renders a sentence
+// with one request instead of one per word. Indices without candidates get an
+// empty list. window=N widens the range by N words on each side, marks the
+// requested indices as the focus and adds insertion and deletion hints for
+// the words and gaps between the returned indices.
+func (s *AutocompleteService) handlePositionRange(c *gin.Context) {
+	audioID := c.Query("audio_id")
+	if audioID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "audio_id parameter required"})
+		return
+	}
+	audioID = services.NormalizeAudioID(audioID)
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a non-negative integer"})
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil || to < from {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an integer no less than from"})
+		return
+	}
+	window := 0
+	if windowParam := c.Query("window"); windowParam != "" {
+		window, err = strconv.Atoi(windowParam)
+		if err != nil || window < 0 || window > maxPositionWindow {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "window must be between 0 and " + strconv.Itoa(maxPositionWindow)})
+			return
+		}
+	}
+	first, last := from-window, to+window
+	if first < 0 {
+		first = 0
+	}
+	if last-first+1 > maxPositionRange {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a range covers at most " + strconv.Itoa(maxPositionRange) + " word indices"})
+		return
+	}
+	maxResults := defaultPositionResults
+	if maxParam := c.Query("max_results"); maxParam != "" {
+		n, err := strconv.Atoi(maxParam)
+		if err != nil || n < 1 || n > maxPositionResults {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_results must be between 1 and " + strconv.Itoa(maxPositionResults)})
+			return
+		}
+		maxResults = n
+	}
+
+	ctx := context.Background()
+	candidates, err := s.positionRangeCandidates(ctx, audioID, first, last)
+	if err == errClipNotIndexed || err != nil && !s.Stateless {
+		c.JSON(http.StatusNotFound, gin.H{"error": "autocomplete not initialized for clip " + audioID + ", please initialize first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The window's hints need the baseline words, which also end it at the transcript's end
+	var hints []positionHint
+	if window > 0 {
+		data, err := s.clipTranscripts(ctx, audioID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		words := services.TranscriptWords(data.FinalTranscription)
+		if last >= len(words) && len(words) > to {
+			last = len(words) - 1
+		}
+		if hints, err = positionHints(data, words, candidates, first, last); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	tenant := requestTenant(c)
+	positions := make([]gin.H, 0, last-first+1)
+	for index := first; index <= last; index++ {
+		ranked := services.ApplySuggestFilters(tenant, bestPerWord(candidates[index]))
+		if len(ranked) > maxResults {
+			ranked = ranked[:maxResults]
+		}
+		suggestions := make([]map[string]interface{}, len(ranked))
+		for i, suggestion := range ranked {
+			suggestions[i] = map[string]interface{}{
+				"text":       suggestion.Text,
+				"confidence": suggestion.Confidence,
+			}
+		}
+		position := gin.H{"word_index": index, "suggestions": suggestions}
+		if window > 0 {
+			position["focus"] = index >= from && index <= to
+		}
+		positions = append(positions, position)
+	}
+
+	response := gin.H{
+		"audio_id":  audioID,
+		"from":      from,
+		"to":        to,
+		"positions": positions,
+	}
+	if window > 0 {
+		response["window"] = window
+		response["hints"] = hints
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// positionHints suggests edits around the words first to last: particles the
+// orchestrator heard after a word are inserted into the gap following it, and
+// a word is deleted when it repeats the word before it or is a particle none of
+// the ASR models heard at its position
+func positionHints(data *models.AutocompleteData, words []string, candidates models.PositionMap, first, last int) ([]positionHint, error) {
+	lexicon, err := services.ParticleLexicon()
+	if err != nil {
+		return nil, err
+	}
+
+	hints := []positionHint{}
+	for _, detected := range data.PotentialParticles {
+		particle := strings.ToLower(strings.TrimSpace(detected.Particle))
+		gap := detected.WordIndex + 1
+		if particle == "" || gap <= first || gap > last || gap > len(words) {
+			continue
+		}
+		// A particle already written on either side of the gap needs no insertion
+		if strings.EqualFold(words[gap-1], particle) || gap < len(words) && strings.EqualFold(words[gap], particle) {
+			continue
+		}
+		hints = append(hints, positionHint{Type: hintInsert, Gap: intPointer(gap), Text: particle, Confidence: detected.Confidence})
+	}
+
+	for index := first; index <= last && index < len(words); index++ {
+		word := words[index]
+		switch {
+		case index > first && strings.EqualFold(word, words[index-1]):
+			hints = append(hints, positionHint{Type: hintDelete, WordIndex: intPointer(index), Text: word, Reason: deleteReasonRepeat})
+		case len(data.ASRAlternatives) > 0 && lexicon[strings.ToLower(word)] && !heardByModels(candidates[index], word):
+			hints = append(hints, positionHint{Type: hintDelete, WordIndex: intPointer(index), Text: word, Reason: deleteReasonUnheard})
+		}
+	}
+	return hints, nil
+}
+
+// heardByModels reports whether any ASR model agreed with the baseline word at
+// a position. The baseline's own vote counts towards its agreement.
+func heardByModels(candidates []models.WordSuggestion, word string) bool {
+	for _, candidate := range candidates {
+		if candidate.Text == word && candidate.Agreement > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// intPointer returns a pointer to a copy of n, for optional JSON fields
+func intPointer(n int) *int {
+	return &n
+}
+
+// positionRangeCandidates reads the candidates of word indices from to to: from
+// the cached position map normally, or with one HMGET of the clip's positions
+// hash in stateless mode
+func (s *AutocompleteService) positionRangeCandidates(ctx context.Context, audioID string, from, to int) (models.PositionMap, error) {
+	if !s.Stateless {
+		return services.GetPositionMap(audioID)
+	}
+
+	s.touchClip(audioID)
+	fields := make([]string, 0, to-from+1)
+	for index := from; index <= to; index++ {
+		fields = append(fields, strconv.Itoa(index))
+	}
+	pipe := s.clipReadClient(audioID).Pipeline()
+	exists := pipe.Exists(ctx, clipWordsKey(audioID))
+	packed := pipe.HMGet(ctx, clipPositionsKey(audioID), fields...)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if exists.Val() == 0 {
+		return nil, errClipNotIndexed
+	}
+
+	positionMap := make(models.PositionMap, len(fields))
+	for i, value := range packed.Val() {
+		encoded, ok := value.(string)
+		if !ok {
+			continue // Nothing heard at this index
+		}
+		candidates, err := decodeSuggestions([]byte(encoded))
+		if err != nil {
+			return nil, err
+		}
+		positionMap[from+i] = candidates
+	}
+	return positionMap, nil
+}
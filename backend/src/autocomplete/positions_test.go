@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// positionRangeResponse is the part of a /suggest/positions response the tests check
+type positionRangeResponse struct {
+	Positions []struct {
+		WordIndex   int  `json:"word_index"`
+		Focus       bool `json:"focus"`
+		Suggestions []struct {
+			Text string `json:"text"`
+		} `json:"suggestions"`
+	} `json:"positions"`
+	Hints []positionHint `json:"hints"`
+}
+
+// positionWordsOf lists the suggested words of every returned position, by word index
+func positionWordsOf(t *testing.T, body []byte) map[int][]string {
+	t.Helper()
+	var response positionRangeResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[int][]string)
+	for _, position := range response.Positions {
+		words := []string{}
+		for _, suggestion := range position.Suggestions {
+			words = append(words, suggestion.Text)
+		}
+		got[position.WordIndex] = words
+	}
+	return got
+}
+
+func TestHandlePositionRange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{
+		FinalTranscription: "saya makan nasi",
+		ConfidenceScore:    0.9,
+		ASRAlternatives:    map[string]string{"whisper": "saya makna nasi"},
+	})
+
+	router := gin.New()
+	router.GET("/suggest/positions", (&AutocompleteService{}).handlePositionRange)
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		want       map[int][]string
+	}{
+		{"?audio_id=clip&from=1&to=2", http.StatusOK, map[int][]string{1: {"makan", "makna"}, 2: {"nasi"}}},
+		{"?audio_id=clip&from=1&to=1&max_results=1", http.StatusOK, map[int][]string{1: {"makan"}}},
+		{"?audio_id=clip&from=2&to=4", http.StatusOK, map[int][]string{2: {"nasi"}, 3: {}, 4: {}}},
+		{"?audio_id=other&from=0&to=1", http.StatusNotFound, nil},
+		{"?from=0&to=1", http.StatusBadRequest, nil},
+		{"?audio_id=clip&to=1", http.StatusBadRequest, nil},
+		{"?audio_id=clip&from=-1&to=1", http.StatusBadRequest, nil},
+		{"?audio_id=clip&from=2&to=1", http.StatusBadRequest, nil},
+		{"?audio_id=clip&from=0&to=500", http.StatusBadRequest, nil},
+		{"?audio_id=clip&from=0&to=1&max_results=0", http.StatusBadRequest, nil},
+		{"?audio_id=clip&from=0&to=1&max_results=51", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/suggest/positions"+tt.query, nil))
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d: %s", tt.query, recorder.Code, tt.wantStatus, recorder.Body)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		if got := positionWordsOf(t, recorder.Body.Bytes()); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: positions %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+// hintString renders a hint compactly, e.g. "insert 2 lah" or "delete 1 lah unheard_particle"
+func hintString(hint positionHint) string {
+	switch {
+	case hint.Gap != nil:
+		return fmt.Sprintf("%s %d %s", hint.Type, *hint.Gap, hint.Text)
+	case hint.WordIndex != nil:
+		return fmt.Sprintf("%s %d %s %s", hint.Type, *hint.WordIndex, hint.Text, hint.Reason)
+	}
+	return hint.Type + " " + hint.Text
+}
+
+func TestPositionHints(t *testing.T) {
+	heard := func(text string, agreement int) []models.WordSuggestion {
+		return []models.WordSuggestion{{Text: text, Agreement: agreement}}
+	}
+	particle := func(text string, wordIndex int) models.PotentialParticle {
+		return models.PotentialParticle{Particle: text, WordIndex: wordIndex, Confidence: 0.7}
+	}
+	alternatives := map[string]string{"whisper": "-"}
+
+	tests := []struct {
+		name         string
+		transcript   string
+		particles    []models.PotentialParticle
+		alternatives map[string]string
+		candidates   models.PositionMap
+		first, last  int
+		want         []string
+	}{
+		{"nothing to suggest", "saya makan nasi", nil, nil, nil, 0, 2, []string{}},
+		{"particle heard after a word", "jom makan", []models.PotentialParticle{particle(" LAH ", 1)}, nil, nil, 0, 2, []string{"insert 2 lah"}},
+		{"particle already written after the word", "jom lah makan", []models.PotentialParticle{particle("lah", 0)}, nil, nil, 0, 2, []string{}},
+		{"particle already written before the word", "jom lah makan", []models.PotentialParticle{particle("lah", 1)}, nil, nil, 0, 2, []string{}},
+		{"gap outside the window", "saya makan nasi goreng", []models.PotentialParticle{particle("lah", 0), particle("pun", 3)}, nil, nil, 1, 2, []string{}},
+		{"blank particle", "saya makan", []models.PotentialParticle{particle(" ", 0)}, nil, nil, 0, 1, []string{}},
+		{"repeated word", "saya makan Makan nasi", nil, nil, nil, 0, 3, []string{"delete 2 Makan repeated"}},
+		{"repeat of a word before the window", "saya makan makan nasi", nil, nil, nil, 2, 3, []string{}},
+		{"particle no model heard", "makan lah nasi", nil, alternatives, models.PositionMap{1: heard("lah", 1)}, 0, 2, []string{"delete 1 lah unheard_particle"}},
+		{"particle another model heard", "makan lah nasi", nil, alternatives, models.PositionMap{1: heard("lah", 2)}, 0, 2, []string{}},
+		{"particle without alternatives to compare", "makan lah nasi", nil, nil, nil, 0, 2, []string{}},
+	}
+	for _, tt := range tests {
+		data := &models.AutocompleteData{FinalTranscription: tt.transcript, PotentialParticles: tt.particles, ASRAlternatives: tt.alternatives}
+		hints, err := positionHints(data, services.TranscriptWords(tt.transcript), tt.candidates, tt.first, tt.last)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, hint := range hints {
+			got = append(got, hintString(hint))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: positionHints() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHandlePositionRangeWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{
+		FinalTranscription: "jom makan makan nasi",
+		ConfidenceScore:    0.9,
+		PotentialParticles: []models.PotentialParticle{{Particle: "lah", WordIndex: 0, Confidence: 0.7}},
+	})
+
+	router := gin.New()
+	router.GET("/suggest/positions", (&AutocompleteService{}).handlePositionRange)
+
+	tests := []struct {
+		query      string
+		wantStatus int
+		wantFocus  map[int]bool
+		wantHints  []string
+	}{
+		{"?audio_id=clip&from=1&to=1&window=1", http.StatusOK, map[int]bool{0: false, 1: true, 2: false}, []string{"insert 1 lah", "delete 2 makan repeated"}},
+		{"?audio_id=clip&from=0&to=0&window=2", http.StatusOK, map[int]bool{0: true, 1: false, 2: false}, []string{"insert 1 lah", "delete 2 makan repeated"}},
+		{"?audio_id=clip&from=3&to=3&window=3", http.StatusOK, map[int]bool{0: false, 1: false, 2: false, 3: true}, []string{"insert 1 lah", "delete 2 makan repeated"}},
+		{"?audio_id=clip&from=2&to=3", http.StatusOK, map[int]bool{2: false, 3: false}, nil},
+		{"?audio_id=clip&from=0&to=1&window=4", http.StatusBadRequest, nil, nil},
+		{"?audio_id=clip&from=0&to=1&window=-1", http.StatusBadRequest, nil, nil},
+		{"?audio_id=clip&from=3&to=499&window=3", http.StatusBadRequest, nil, nil},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/suggest/positions"+tt.query, nil))
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d: %s", tt.query, recorder.Code, tt.wantStatus, recorder.Body)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var response positionRangeResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		focus := make(map[int]bool)
+		for _, position := range response.Positions {
+			focus[position.WordIndex] = position.Focus
+		}
+		var hints []string
+		for _, hint := range response.Hints {
+			hints = append(hints, hintString(hint))
+		}
+		if !reflect.DeepEqual(focus, tt.wantFocus) || !reflect.DeepEqual(hints, tt.wantHints) {
+			t.Errorf("%s: focus %v hints %q, want %v %q", tt.query, focus, hints, tt.wantFocus, tt.wantHints)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Request classes, each with its own concurrency pool
+const (
+	classInteractive = "interactive"
+	classBulk        = "bulk"
+	classDefault     = "default"
+)
+
+// priorityQueueTimeout is how long a request waits for a slot before 503
+const priorityQueueTimeout = 10 * time.Second
+
+// requestPool bounds the concurrent requests of one class
+type requestPool struct {
+	slots    chan struct{}
+	inFlight atomic.Int64
+	queued   atomic.Int64
+	served   atomic.Int64
+	rejected atomic.Int64
+}
+
+func newRequestPool(limit int) *requestPool {
+	return &requestPool{slots: make(chan struct{}, limit)}
+}
+
+// requestClass maps a route onto its priority class: keystroke-driven suggest
+// lookups and user corrections are interactive, ingestion is bulk
+func requestClass(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/suggest"), path == "/replace":
+		return classInteractive
+	case strings.HasPrefix(path, "/initialize"), strings.HasPrefix(path, "/import"):
+		return classBulk
+	default:
+		return classDefault
+	}
+}
+
+// newPriorityPools sizes the pools from INTERACTIVE_CONCURRENCY, BULK_CONCURRENCY and DEFAULT_CONCURRENCY
+func newPriorityPools() map[string]*requestPool {
+	return map[string]*requestPool{
+		classInteractive: newRequestPool(envInt("INTERACTIVE_CONCURRENCY", 256)),
+		classBulk:        newRequestPool(envInt("BULK_CONCURRENCY", 4)),
+		classDefault:     newRequestPool(envInt("DEFAULT_CONCURRENCY", 64)),
+	}
+}
+
+// priorityMiddleware admits each request through its class's pool, so /suggest
+// requests never wait behind a burst of /initialize processing
+func (s *AutocompleteService) priorityMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.Path == suggestStreamPath {
+			c.Next() // Sessions are long-lived and run one lookup at a time, so they don't hold a slot
+			return
+		}
+		pool := s.pools[requestClass(c.Request.URL.Path)]
+
+		select {
+		case pool.slots <- struct{}{}:
+		default:
+			// Pool is full: queue for a slot, bounded by the timeout
+			pool.queued.Add(1)
+			timer := time.NewTimer(priorityQueueTimeout)
+			select {
+			case pool.slots <- struct{}{}:
+				timer.Stop()
+				pool.queued.Add(-1)
+			case <-timer.C:
+				pool.queued.Add(-1)
+				pool.rejected.Add(1)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "server busy, retry later"})
+				return
+			case <-c.Request.Context().Done():
+				timer.Stop()
+				pool.queued.Add(-1)
+				c.Abort()
+				return
+			}
+		}
+
+		pool.inFlight.Add(1)
+		defer func() {
+			pool.inFlight.Add(-1)
+			pool.served.Add(1)
+			<-pool.slots
+		}()
+
+		c.Next()
+	}
+}
+
+// priorityStats reports each class's limit and current load
+func (s *AutocompleteService) priorityStats() map[string]interface{} {
+	stats := make(map[string]interface{}, len(s.pools))
+	for class, pool := range s.pools {
+		stats[class] = map[string]interface{}{
+			"limit":     cap(pool.slots),
+			"in_flight": pool.inFlight.Load(),
+			"queued":    pool.queued.Load(),
+			"served":    pool.served.Load(),
+			"rejected":  pool.rejected.Load(),
+		}
+	}
+	return stats
+}
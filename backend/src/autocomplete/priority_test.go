@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestClass(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/suggest/prefix", classInteractive},
+		{"/suggest/stream", classInteractive},
+		{"/replace", classInteractive},
+		{"/replacements", classDefault},
+		{"/initialize", classBulk},
+		{"/initialize/stream", classBulk},
+		{"/import/csv", classBulk},
+		{"/health", classDefault},
+	}
+	for _, tt := range tests {
+		if got := requestClass(tt.path); got != tt.want {
+			t.Errorf("requestClass(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPriorityMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		path       string
+		fullBulk   bool
+		wantServed bool
+	}{
+		{"free slot", "/initialize", false, true},
+		{"other class is unaffected", "/suggest/prefix", true, true},
+		{"streams skip the pools", suggestStreamPath, true, true},
+		{"full pool, caller gone", "/initialize", true, false},
+	}
+	for _, tt := range tests {
+		s := &AutocompleteService{pools: map[string]*requestPool{
+			classInteractive: newRequestPool(1),
+			classBulk:        newRequestPool(1),
+			classDefault:     newRequestPool(1),
+		}}
+		if tt.fullBulk {
+			s.pools[classBulk].slots <- struct{}{}
+		}
+		served := false
+		router := gin.New()
+		router.Use(s.priorityMiddleware())
+		router.Any("/*path", func(c *gin.Context) { served = true })
+
+		// A cancelled request gives up its place in the queue instead of
+		// waiting out priorityQueueTimeout
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil).WithContext(ctx)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if served != tt.wantServed {
+			t.Errorf("%s: served = %v, want %v", tt.name, served, tt.wantServed)
+		}
+		for class, pool := range s.pools {
+			held := 0
+			if class == classBulk && tt.fullBulk {
+				held = 1
+			}
+			if len(pool.slots) != held || pool.inFlight.Load() != 0 || pool.queued.Load() != 0 {
+				t.Errorf("%s: %s pool left %d slots, %d in flight, %d queued", tt.name, class, len(pool.slots), pool.inFlight.Load(), pool.queued.Load())
+			}
+		}
+	}
+}
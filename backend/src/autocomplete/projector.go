@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// projectionDirtyKey queues the prefixes whose top-k must be re-projected,
+// scored by when each was first written since its last projection
+const projectionDirtyKey = redisKeyPrefix + "projection:dirty"
+
+// topKProjector materializes the suggest read model (the top-k hash) from
+// the write model (the prefix sorted sets) in the background, at most batch
+// prefixes per interval, so a re-ingestion burst queues up projection work
+// instead of competing with suggest for Redis
+type topKProjector struct {
+	interval time.Duration
+	batch    int
+
+	projected atomic.Int64
+	failed    atomic.Int64
+}
+
+// newTopKProjector projects asynchronously unless TOPK_PROJECTION=inline,
+// which materializes each ingest flush's prefixes before it returns
+func newTopKProjector() *topKProjector {
+	if os.Getenv("TOPK_PROJECTION") == "inline" {
+		return nil
+	}
+	return &topKProjector{
+		interval: envDuration("PROJECTOR_INTERVAL", 100*time.Millisecond),
+		batch:    envInt("PROJECTOR_BATCH", 500),
+	}
+}
+
+// markProjectionDirty queues prefixes for projection on pipe, keeping the
+// time a prefix was first queued so lag covers its whole wait
+func (s *AutocompleteService) markProjectionDirty(ctx context.Context, pipe redis.Pipeliner, prefixes []string) {
+	if len(prefixes) == 0 {
+		return
+	}
+	now := float64(time.Now().UnixMilli())
+	members := make([]*redis.Z, len(prefixes))
+	for i, prefix := range prefixes {
+		members[i] = &redis.Z{Score: now, Member: prefix}
+	}
+	pipe.ZAddNX(ctx, projectionDirtyKey, members...)
+}
+
+// runProjector drains the dirty prefixes until ctx ends. Replicas share the
+// queue, since each ZPOPMIN hands a prefix to one of them.
+func (s *AutocompleteService) runProjector(ctx context.Context) {
+	ticker := time.NewTicker(s.projector.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.projectDirty(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error projecting top-k: %v", err)
+		}
+	}
+}
+
+// projectDirty materializes one batch of dirty prefixes, queueing them again
+// when that fails
+func (s *AutocompleteService) projectDirty(ctx context.Context) error {
+	popped, err := s.RedisClient.ZPopMin(ctx, projectionDirtyKey, int64(s.projector.batch)).Result()
+	if err != nil || len(popped) == 0 {
+		return err
+	}
+
+	prefixes := make([]string, len(popped))
+	for i, entry := range popped {
+		prefixes[i] = entry.Member.(string)
+	}
+	if err := s.materializeTopK(ctx, prefixes); err != nil {
+		s.projector.failed.Add(int64(len(popped)))
+		requeue := make([]*redis.Z, len(popped))
+		for i := range popped {
+			requeue[i] = &popped[i]
+		}
+		if requeueErr := s.RedisClient.ZAddNX(ctx, projectionDirtyKey, requeue...).Err(); requeueErr != nil {
+			log.Printf("Error re-queueing %d prefixes for projection: %v", len(popped), requeueErr)
+		}
+		return err
+	}
+	s.projector.projected.Add(int64(len(popped)))
+	return nil
+}
+
+// projectorStats reports the projection backlog and how far the read model
+// lags the write model
+func (s *AutocompleteService) projectorStats(ctx context.Context) map[string]interface{} {
+	if s.projector == nil {
+		return map[string]interface{}{"mode": "inline", "separate_read_model": s.ReadModel != nil}
+	}
+	stats := map[string]interface{}{
+		"mode":                "async",
+		"separate_read_model": s.ReadModel != nil,
+		"projected":           s.projector.projected.Load(),
+		"failed":              s.projector.failed.Load(),
+	}
+
+	pipe := s.RedisClient.Pipeline()
+	pending := pipe.ZCard(ctx, projectionDirtyKey)
+	oldest := pipe.ZRangeWithScores(ctx, projectionDirtyKey, 0, 0)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		stats["error"] = err.Error()
+		return stats
+	}
+	stats["pending"] = pending.Val()
+	stats["lag_ms"] = int64(0)
+	if entries := oldest.Val(); len(entries) > 0 {
+		stats["lag_ms"] = time.Now().UnixMilli() - int64(entries[0].Score)
+	}
+	return stats
+}
+
+// readModelClient is where the top-k read model lives: READ_MODEL_URL's
+// Redis when set, so suggest reads stay off the instance absorbing ingest
+func (s *AutocompleteService) readModelClient() *redis.Client {
+	if s.ReadModel != nil {
+		return s.ReadModel
+	}
+	return s.RedisClient
+}
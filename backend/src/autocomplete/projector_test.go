@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewTopKProjector(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		interval     string
+		batch        string
+		wantNil      bool
+		wantInterval time.Duration
+		wantBatch    int
+	}{
+		{"default", "", "", "", false, 100 * time.Millisecond, 500},
+		{"async", "async", "1s", "50", false, time.Second, 50},
+		{"inline", "inline", "1s", "50", true, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("TOPK_PROJECTION", tt.mode)
+		t.Setenv("PROJECTOR_INTERVAL", tt.interval)
+		t.Setenv("PROJECTOR_BATCH", tt.batch)
+		projector := newTopKProjector()
+		if (projector == nil) != tt.wantNil {
+			t.Errorf("%s: newTopKProjector() = %v, want nil %v", tt.name, projector, tt.wantNil)
+			continue
+		}
+		if projector != nil && (projector.interval != tt.wantInterval || projector.batch != tt.wantBatch) {
+			t.Errorf("%s: interval %v batch %d, want %v and %d", tt.name, projector.interval, projector.batch, tt.wantInterval, tt.wantBatch)
+		}
+	}
+}
+
+func TestReadModelClient(t *testing.T) {
+	write, read := unreachableRedis(t), unreachableRedis(t)
+	tests := []struct {
+		name    string
+		service *AutocompleteService
+		want    interface{}
+	}{
+		{"shared", &AutocompleteService{RedisClient: write}, write},
+		{"separate", &AutocompleteService{RedisClient: write, ReadModel: read}, read},
+	}
+	for _, tt := range tests {
+		if got := tt.service.readModelClient(); got != tt.want {
+			t.Errorf("%s: readModelClient() is not the expected client", tt.name)
+		}
+	}
+}
+
+func TestProjectorStats(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   *AutocompleteService
+		wantMode  string
+		wantError bool
+	}{
+		{"inline", &AutocompleteService{RedisClient: unreachableRedis(t)}, "inline", false},
+		{"async without Redis", &AutocompleteService{RedisClient: unreachableRedis(t), projector: &topKProjector{batch: 1}}, "async", true},
+	}
+	for _, tt := range tests {
+		stats := tt.service.projectorStats(context.Background())
+		if stats["mode"] != tt.wantMode {
+			t.Errorf("%s: mode = %v, want %s", tt.name, stats["mode"], tt.wantMode)
+		}
+		if _, hasError := stats["error"]; hasError != tt.wantError {
+			t.Errorf("%s: projectorStats() = %v, want error %v", tt.name, stats, tt.wantError)
+		}
+	}
+}
+
+func TestProjectDirtyWithoutRedis(t *testing.T) {
+	service := &AutocompleteService{RedisClient: unreachableRedis(t), projector: &topKProjector{batch: 10}}
+	if err := service.projectDirty(context.Background()); err == nil {
+		t.Error("projectDirty() succeeded without Redis")
+	}
+	if projected, failed := service.projector.projected.Load(), service.projector.failed.Load(); projected != 0 || failed != 0 {
+		t.Errorf("%d projected, %d failed without popping any prefix", projected, failed)
+	}
+}
+
+func TestMarkProjectionDirty(t *testing.T) {
+	service := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	tests := []struct {
+		prefixes []string
+		want     int
+	}{
+		{nil, 0},
+		{[]string{"m", "ma", "mak"}, 1},
+	}
+	for _, tt := range tests {
+		pipe := service.RedisClient.Pipeline()
+		service.markProjectionDirty(context.Background(), pipe, tt.prefixes)
+		if got := pipe.Len(); got != tt.want {
+			t.Errorf("markProjectionDirty(%v) queued %d commands, want %d", tt.prefixes, got, tt.want)
+		}
+		pipe.Discard()
+	}
+}
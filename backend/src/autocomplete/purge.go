@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// defaultResetToken is the confirmation required by /admin/reset when ADMIN_RESET_TOKEN is unset
+const defaultResetToken = "RESET"
+
+// purgeIDMetachars are the SCAN glob characters an audio_id to purge must not
+// contain, so the clip's key pattern can't match other clips
+const purgeIDMetachars = "*?[]\\"
+
+// adminConfirmToken is the confirmation destructive admin operations require
+func adminConfirmToken() string {
+	if token := os.Getenv("ADMIN_RESET_TOKEN"); token != "" {
+		return token
+	}
+	return defaultResetToken
+}
+
+// clipKeyPrefix is the Redis namespace holding a single clip's keys
+func clipKeyPrefix(audioID string) string {
+	return redisKeyPrefix + "clip:" + audioID + ":"
+}
+
+// sharedIndexPatterns match the namespaces the global clip owns: the shared
+// frequency, prefix, top-k and phonetic indexes
+var sharedIndexPatterns = []string{
+	redisKeyPrefix + "global:*", prefixKeyPrefix + "*", topKKey,
+	phoneticKeyPrefix + "*", phonemeKeyPrefix + "*", stemPrefixKeyPrefix + "*", stemFormsKeyPrefix + "*",
+	caseFoldKeyPrefix + "*", redisKeyPrefix + "particle:*",
+	redisKeyPrefix + "topic:*", redisKeyPrefix + "accent:vocab:*", accentAllVocabKey,
+	ngramKeyPrefix + "*", trigramKeyPrefix + "*", suffixKeyPrefix + "*",
+	projectionDirtyKey, embeddingIndexKeyPrefix + "*",
+}
+
+// handlePurgeClip removes a clip for good. Purging the global clip clears
+// every shared index, so it needs the same confirmation as /admin/reset in
+// ?confirm=.
+func (s *AutocompleteService) handlePurgeClip(c *gin.Context) {
+	if strings.ContainsAny(c.Param("audio_id"), purgeIDMetachars) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "audio_id must not contain any of " + purgeIDMetachars})
+		return
+	}
+	audioID := services.NormalizeAudioID(c.Param("audio_id"))
+	if audioID == services.GlobalAudioID && c.Query("confirm") != adminConfirmToken() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "purging the global clip requires confirm set to the reset token"})
+		return
+	}
+	ctx := context.Background()
+
+	deleted, cached, err := s.purgeClipData(ctx, audioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !cached && deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no data stored for clip " + audioID})
+		return
+	}
+	s.recordEvent(ctx, &loggedEvent{Type: eventDelete, AudioID: audioID, Hard: true})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":             "purged",
+		"audio_id":           audioID,
+		"trie_removed":       cached,
+		"redis_keys_deleted": deleted,
+	})
+}
+
+// purgeClipData deletes the clip's Redis keys and cached trie, reporting how
+// many keys were deleted and whether the trie was cached. Purging the global
+// clip also clears the shared namespaces on every instance.
+func (s *AutocompleteService) purgeClipData(ctx context.Context, audioID string) (int64, bool, error) {
+	deleted, err := s.deleteClipKeys(ctx, s.clipClient(audioID), audioID)
+	if err != nil {
+		return deleted, false, err
+	}
+
+	if audioID == services.GlobalAudioID {
+		for _, client := range s.redisClients() {
+			for _, pattern := range sharedIndexPatterns {
+				n, err := s.deleteKeys(ctx, client, pattern)
+				if err != nil {
+					return deleted, false, err
+				}
+				deleted += n
+			}
+		}
+		if s.ReadModel != nil {
+			n, err := s.ReadModel.Del(ctx, topKKey).Result()
+			if err != nil {
+				return deleted, false, err
+			}
+			deleted += n
+		}
+	}
+
+	return deleted, services.PurgeClip(audioID), nil
+}
+
+func (s *AutocompleteService) handleReset(c *gin.Context) {
+	var request struct {
+		Confirm string `json:"confirm"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.Confirm != adminConfirmToken() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "confirmation token does not match"})
+		return
+	}
+
+	ctx := context.Background()
+	var deleted int64
+	clients := s.redisClients()
+	if s.ReadModel != nil {
+		clients = append(clients, s.ReadModel)
+	}
+	for _, client := range clients {
+		n, err := s.deleteKeys(ctx, client, redisKeyPrefix+"*")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		deleted += n
+	}
+
+	services.ResetCache()
+	for _, tenant := range services.BlocklistTenants() {
+		if err := s.publishBlocklistChange(ctx, tenant); err != nil {
+			log.Printf("Error propagating blocklist reset for tenant %s: %v", tenant, err)
+		}
+	}
+	if err := s.publishAliasChange(ctx); err != nil {
+		log.Printf("Error propagating clip alias reset: %v", err)
+	}
+	s.suggestHits.Store(0)
+	s.suggestMisses.Store(0)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":             "reset",
+		"redis_keys_deleted": deleted,
+	})
+}
+
+// deleteKeys removes every key matching the pattern, scanning in batches so
+// large namespaces don't block Redis
+func (s *AutocompleteService) deleteKeys(ctx context.Context, client *redis.Client, pattern string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+
+	for {
+		keys, next, err := client.Scan(ctx, cursor, pattern, 1000).Result()
+		if err != nil {
+			return deleted, err
+		}
+
+		if len(keys) > 0 {
+			n, err := client.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// deleteClipKeys removes the keys of one clip. The clip's pattern also matches
+// the keys of clips whose ID extends it past a colon, so only keys whose
+// suffix is the clip's own are deleted.
+func (s *AutocompleteService) deleteClipKeys(ctx context.Context, client *redis.Client, audioID string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+
+	for {
+		keys, next, err := client.Scan(ctx, cursor, clipKeyPrefix(audioID)+"*", 1000).Result()
+		if err != nil {
+			return deleted, err
+		}
+
+		keys = ownClipKeys(keys, audioID)
+		if len(keys) > 0 {
+			n, err := client.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// ownClipKeys keeps the keys that belong to the clip itself
+func ownClipKeys(keys []string, audioID string) []string {
+	prefix := clipKeyPrefix(audioID)
+	own := keys[:0]
+	for _, key := range keys {
+		if suffix := strings.TrimPrefix(key, prefix); suffix != key && !strings.Contains(suffix, ":") {
+			own = append(own, key)
+		}
+	}
+	return own
+}
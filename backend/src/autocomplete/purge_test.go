@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/services"
+)
+
+func TestOwnClipKeys(t *testing.T) {
+	keys := []string{
+		"autocomplete:clip:a:words",
+		"autocomplete:clip:a:lex_en",
+		"autocomplete:clip:a:trash_words",
+		"autocomplete:clip:a:b:words",
+		"autocomplete:clip:a:b:c:lex",
+		"autocomplete:clip:ab:words",
+	}
+	tests := []struct {
+		audioID string
+		want    []string
+	}{
+		{"a", []string{"autocomplete:clip:a:words", "autocomplete:clip:a:lex_en", "autocomplete:clip:a:trash_words"}},
+		{"a:b", []string{"autocomplete:clip:a:b:words"}},
+		{"a:b:c", []string{"autocomplete:clip:a:b:c:lex"}},
+		{"ab", []string{"autocomplete:clip:ab:words"}},
+		{"b", []string{}},
+	}
+	for _, tt := range tests {
+		got := ownClipKeys(append([]string(nil), keys...), tt.audioID)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ownClipKeys(%q) = %v, want %v", tt.audioID, got, tt.want)
+		}
+	}
+}
+
+func TestPurgeAndResetRejections(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ADMIN_RESET_TOKEN", "")
+	service := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	router := gin.New()
+	router.DELETE("/admin/clips/:audio_id", service.handlePurgeClip)
+	router.POST("/admin/reset", service.handleReset)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"glob in audio_id", http.MethodDelete, "/admin/clips/clip*", "", http.StatusBadRequest},
+		{"bracket in audio_id", http.MethodDelete, "/admin/clips/clip%5B1%5D", "", http.StatusBadRequest},
+		{"global without confirm", http.MethodDelete, "/admin/clips/" + services.GlobalAudioID, "", http.StatusForbidden},
+		{"global with a wrong confirm", http.MethodDelete, "/admin/clips/" + services.GlobalAudioID + "?confirm=yes", "", http.StatusForbidden},
+		{"reset without a body", http.MethodPost, "/admin/reset", "", http.StatusBadRequest},
+		{"reset with a wrong token", http.MethodPost, "/admin/reset", `{"confirm": "yes"}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
+
+func TestAdminConfirmToken(t *testing.T) {
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{"", defaultResetToken},
+		{"s3cret", "s3cret"},
+	}
+	for _, tt := range tests {
+		t.Setenv("ADMIN_RESET_TOKEN", tt.env)
+		if got := adminConfirmToken(); got != tt.want {
+			t.Errorf("ADMIN_RESET_TOKEN=%q: adminConfirmToken() = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
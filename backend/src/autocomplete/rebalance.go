@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// runRebalance moves clip keys to the shard that owns them under the current
+// shard config. Run it after adding or removing shards; keys already on their
+// owner are left alone, so it is safe to repeat.
+func runRebalance(args []string) {
+	flags := flag.NewFlagSet("rebalance", flag.ExitOnError)
+	configPath := flags.String("config", os.Getenv("SHARD_CONFIG"), "shard config file")
+	dryRun := flags.Bool("dry-run", false, "report moves without performing them")
+	flags.Parse(args)
+
+	if *configPath == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	config, err := readShardConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load shard config: %v", err)
+	}
+	set, err := openShardSet(ctx, config, nil)
+	if err != nil {
+		log.Fatalf("Failed to connect to shards: %v", err)
+	}
+	defer set.closeExcept(nil)
+
+	moved, failed := 0, 0
+	for _, shard := range config.Shards {
+		source := set.clients[shard.Name]
+
+		iter := source.Scan(ctx, 0, clipKeyPrefix("*")+"*", 1000).Iterator()
+		for iter.Next(ctx) {
+			key := iter.Val()
+			audioID := clipIDFromKey(key)
+			owner, target := set.owner(audioID)
+			if owner == shard.Name {
+				continue
+			}
+
+			if *dryRun {
+				log.Printf("Would move %s: %s -> %s", key, shard.Name, owner)
+				moved++
+				continue
+			}
+			if err := moveKey(ctx, source, target, key); err != nil {
+				log.Printf("Failed to move %s: %v", key, err)
+				failed++
+				continue
+			}
+			moved++
+		}
+		if err := iter.Err(); err != nil {
+			log.Fatalf("Failed to scan shard %s: %v", shard.Name, err)
+		}
+	}
+
+	log.Printf("Rebalance finished: %d keys moved, %d failed", moved, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// clipIDFromKey extracts the audio_id from an autocomplete:clip:{id}:... key
+func clipIDFromKey(key string) string {
+	rest := strings.TrimPrefix(key, redisKeyPrefix+"clip:")
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		return rest[:i]
+	}
+	return rest
+}
+
+// moveKey copies a key with its TTL to the target instance, then removes it from the source
+func moveKey(ctx context.Context, source, target *redis.Client, key string) error {
+	dump, err := source.Dump(ctx, key).Result()
+	if err == redis.Nil {
+		return nil // Expired between scan and move
+	}
+	if err != nil {
+		return err
+	}
+
+	ttl, err := source.PTTL(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if ttl < 0 {
+		ttl = 0 // No expiry
+	}
+
+	if err := target.RestoreReplace(ctx, key, ttl, dump).Err(); err != nil {
+		return err
+	}
+	return source.Del(ctx, key).Err()
+}
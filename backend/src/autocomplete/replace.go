@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// clipCorrectionsKey lists a clip's replacements, oldest first
+func clipCorrectionsKey(audioID string) string { return clipKeyPrefix(audioID) + "corrections" }
+
+// correctionRecord is one replacement as stored in the clip's correction log
+type correctionRecord struct {
+	Position  int    `json:"position"`
+	OldWord   string `json:"old_word"`
+	NewWord   string `json:"new_word"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// handleReplace replaces the word at a position of a clip. The correction is
+// logged, the position's baseline and confidences are updated, the clip's
+// trie, bigrams (or Redis index when stateless) are rebuilt from the new
+// position map, and the new word is added to the global prefix index. The
+// response carries the corrected baseline transcript, punctuation intact.
+func (s *AutocompleteService) handleReplace(c *gin.Context) {
+	var request struct {
+		AudioID  string `json:"audio_id"`
+		Position *int   `json:"position" binding:"required"`
+		OldWord  string `json:"old_word" binding:"required"`
+		NewWord  string `json:"new_word" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	oldWord := services.NormalizeText(strings.TrimSpace(request.OldWord))
+	newWord := services.NormalizeText(strings.TrimSpace(request.NewWord))
+	if len(strings.Fields(newWord)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new_word must be a single word"})
+		return
+	}
+	if newWord == oldWord {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "new_word must differ from old_word"})
+		return
+	}
+
+	ctx := context.Background()
+	audioID := services.NormalizeAudioID(request.AudioID)
+
+	// Queue behind an initialize of the same clip rather than racing its rebuild
+	lock, err := s.acquireClipLock(c.Request.Context(), audioID, initLockWait)
+	if err == errClipLocked {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer func() {
+		if err := lock.release(ctx); err != nil {
+			log.Printf("Error releasing clip lock: %v", err)
+		}
+	}()
+
+	if !s.checkIfMatch(ctx, c, audioID) {
+		return
+	}
+
+	candidates, err := s.applyReplace(ctx, audioID, *request.Position, oldWord, newWord)
+	switch {
+	case errors.Is(err, services.ErrCorrectionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrPositionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.logCorrection(ctx, audioID, *request.Position, oldWord, newWord); err != nil {
+		log.Printf("Error logging correction: %v", err)
+	}
+	version, err := s.bumpClipVersion(ctx, audioID)
+	if err != nil {
+		log.Printf("Error bumping clip version: %v", err)
+	}
+	if err := s.storeWord(ctx, newWord, candidates[0].Confidence); err != nil {
+		log.Printf("Error storing corrected word: %v", err)
+	}
+	s.recordEvent(ctx, &loggedEvent{Type: eventCorrection, AudioID: audioID, Position: *request.Position, OldWord: oldWord, NewWord: newWord})
+
+	response := gin.H{
+		"status":     "replaced",
+		"audio_id":   audioID,
+		"position":   *request.Position,
+		"old_word":   oldWord,
+		"new_word":   newWord,
+		"candidates": candidates,
+	}
+	if err == nil {
+		setClipETag(c, version)
+		response["version"] = version
+	}
+	// Snapshot-restored clips have no stored transcript to rebuild
+	if data, err := s.clipTranscripts(ctx, audioID); err == nil {
+		response["transcript"] = data.FinalTranscription
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// applyReplace corrects the word at a position of the clip's cached trie, or
+// of its Redis index when stateless, returning the position's new candidates
+func (s *AutocompleteService) applyReplace(ctx context.Context, audioID string, position int, oldWord, newWord string) ([]models.WordSuggestion, error) {
+	if s.Stateless {
+		return s.replaceInClipIndex(ctx, audioID, position, oldWord, newWord)
+	}
+	return services.ApplyCorrection(audioID, position, oldWord, newWord)
+}
+
+// replaceInClipIndex applies a correction to a stateless clip's Redis index
+func (s *AutocompleteService) replaceInClipIndex(ctx context.Context, audioID string, position int, oldWord, newWord string) ([]models.WordSuggestion, error) {
+	packed, err := s.clipClient(audioID).HGetAll(ctx, clipPositionsKey(audioID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(packed) == 0 {
+		return nil, fmt.Errorf("%w: clip %s is not initialized", services.ErrPositionNotFound, audioID)
+	}
+
+	positionMap := make(models.PositionMap, len(packed))
+	for field, value := range packed {
+		pos, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		candidates, err := decodeSuggestions([]byte(value))
+		if err != nil {
+			return nil, fmt.Errorf("corrupt position %d: %w", pos, err)
+		}
+		positionMap[pos] = candidates
+	}
+
+	updated, err := services.CorrectPositionMap(positionMap, position, oldWord, newWord)
+	if err != nil {
+		return nil, fmt.Errorf("clip %s: %w", audioID, err)
+	}
+	if err := s.writeClipIndex(ctx, audioID, updated); err != nil {
+		return nil, err
+	}
+	if err := s.correctClipTranscript(ctx, audioID, position, newWord); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	return updated[position], nil
+}
+
+// correctClipTranscript rewrites a stateless clip's stored baseline transcript
+// with the corrected word
+func (s *AutocompleteService) correctClipTranscript(ctx context.Context, audioID string, position int, newWord string) error {
+	data, err := s.clipTranscripts(ctx, audioID)
+	if err != nil {
+		return err
+	}
+	corrected, err := services.CorrectTranscript(data, position, newWord)
+	if err != nil {
+		return err
+	}
+	transcripts, err := json.Marshal(corrected)
+	if err != nil {
+		return err
+	}
+	return s.clipClient(audioID).Set(ctx, clipTranscriptsKey(audioID), transcripts, clipIndexTTL).Err()
+}
+
+// logCorrection appends a replacement to the clip's correction log
+func (s *AutocompleteService) logCorrection(ctx context.Context, audioID string, position int, oldWord, newWord string) error {
+	encoded, err := json.Marshal(correctionRecord{
+		Position:  position,
+		OldWord:   oldWord,
+		NewWord:   newWord,
+		Timestamp: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return err
+	}
+
+	client := s.clipClient(audioID)
+	pipe := client.TxPipeline()
+	pipe.RPush(ctx, clipCorrectionsKey(audioID), encoded)
+	pipe.Expire(ctx, clipCorrectionsKey(audioID), clipIndexTTL)
+
+	// Forward the correction to the orchestrator through the outbox, in the same
+	// transaction unless the clip lives on another shard
+	correction := gin.H{"audio_id": audioID, "position": position, "old_word": oldWord, "new_word": newWord}
+	if client == s.RedisClient {
+		if err := s.addOutboxEntry(ctx, pipe, outboxCorrection, correction); err != nil {
+			return err
+		}
+		_, err = pipe.Exec(ctx)
+		return err
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	outbox := s.RedisClient.Pipeline()
+	if err := s.addOutboxEntry(ctx, outbox, outboxCorrection, correction); err != nil {
+		return err
+	}
+	_, err = outbox.Exec(ctx)
+	return err
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleReplaceRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &AutocompleteService{}
+	router := gin.New()
+	router.POST("/replace", s.handleReplace)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"not JSON", "{"},
+		{"no position", `{"audio_id": "clip", "old_word": "makna", "new_word": "makan"}`},
+		{"no old word", `{"audio_id": "clip", "position": 1, "new_word": "makan"}`},
+		{"no new word", `{"audio_id": "clip", "position": 1, "old_word": "makna"}`},
+		{"two new words", `{"audio_id": "clip", "position": 1, "old_word": "makna", "new_word": "makan nasi"}`},
+		{"blank new word", `{"audio_id": "clip", "position": 1, "old_word": "makna", "new_word": "  "}`},
+		{"unchanged word", `{"audio_id": "clip", "position": 1, "old_word": "makan", "new_word": " makan "}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/replace", strings.NewReader(tt.body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400 (%s)", tt.name, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers webhook senders and stream consumers set on mutating calls so a
+// redelivered event is applied once
+const (
+	replayNonceHeader     = "X-Request-Nonce"
+	replayTimestampHeader = "X-Request-Timestamp" // Unix seconds
+)
+
+// replayNonceKeyPrefix namespaces the nonces seen within the replay window
+const replayNonceKeyPrefix = redisKeyPrefix + "nonce:"
+
+// maxNonceLength bounds a nonce, which becomes part of a Redis key
+const maxNonceLength = 128
+
+// replayGuard rejects stale requests and drops duplicate deliveries of
+// mutating calls that carry a nonce
+type replayGuard struct {
+	window   time.Duration
+	required bool
+
+	accepted   atomic.Int64
+	duplicates atomic.Int64
+	rejected   atomic.Int64
+}
+
+// newReplayGuard accepts timestamps within window of now, requiring the
+// nonce and timestamp headers on every guarded call when required is set
+func newReplayGuard(window time.Duration, required bool) *replayGuard {
+	return &replayGuard{window: window, required: required}
+}
+
+// replayMiddleware guards a mutating route. A request's nonce is claimed in
+// Redis for the replay window, so a redelivery answers 200 with status
+// "duplicate" without being applied again. When the first delivery fails the
+// nonce is released, so an at-least-once sender's retry still goes through.
+func (s *AutocompleteService) replayMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		g := s.replay
+		nonce := c.GetHeader(replayNonceHeader)
+		timestamp := c.GetHeader(replayTimestampHeader)
+		if nonce == "" && timestamp == "" && !g.required {
+			c.Next()
+			return
+		}
+		if nonce == "" || timestamp == "" {
+			g.rejected.Add(1)
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": replayNonceHeader + " and " + replayTimestampHeader + " headers required"})
+			return
+		}
+		if len(nonce) > maxNonceLength {
+			g.rejected.Add(1)
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "nonce longer than " + strconv.Itoa(maxNonceLength) + " characters"})
+			return
+		}
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			g.rejected.Add(1)
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid " + replayTimestampHeader + ": " + err.Error()})
+			return
+		}
+		if skew := time.Since(time.Unix(unix, 0)); skew > g.window || skew < -g.window {
+			// Older than the window the nonce is remembered for, so it can't be deduplicated
+			g.rejected.Add(1)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "request timestamp outside the " + g.window.String() + " replay window"})
+			return
+		}
+
+		ctx := context.Background()
+		key := replayNonceKeyPrefix + nonce
+		claimed, err := s.RedisClient.SetNX(ctx, key, c.Request.URL.Path, 2*g.window).Result()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !claimed {
+			g.duplicates.Add(1)
+			c.AbortWithStatusJSON(http.StatusOK, gin.H{"status": "duplicate", "nonce": nonce})
+			return
+		}
+		g.accepted.Add(1)
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusMultipleChoices {
+			if err := s.RedisClient.Del(ctx, key).Err(); err != nil {
+				log.Printf("Error releasing nonce %s: %v", nonce, err)
+			}
+		}
+	}
+}
+
+// replayStats reports how many guarded calls were applied, deduplicated or rejected
+func (s *AutocompleteService) replayStats() map[string]interface{} {
+	g := s.replay
+	return map[string]interface{}{
+		"window":     g.window.String(),
+		"required":   g.required,
+		"accepted":   g.accepted.Load(),
+		"duplicates": g.duplicates.Load(),
+		"rejected":   g.rejected.Load(),
+	}
+}
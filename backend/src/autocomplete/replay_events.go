@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// runReplayEvents rebuilds every index from the event log: the global word
+// index, clip indexes and the verified index, replayed in order through the
+// current ingest and scoring code. That recovers from a corrupted index, and
+// applies new scoring logic to everything ingested before it.
+func runReplayEvents(args []string) {
+	flags := flag.NewFlagSet("replay-events", flag.ExitOnError)
+	reset := flags.Bool("reset", false, "delete the indexes before replaying (required unless -dry-run)")
+	dryRun := flags.Bool("dry-run", false, "count the logged events by type without applying them")
+	until := flags.String("until", "+", "last event ID to apply, to rebuild the indexes as of that event")
+	stateless := flags.Bool("stateless", os.Getenv("STATELESS_MODE") == "true", "rebuild clip indexes in Redis rather than in memory")
+	output := flags.String("output", "snapshot.json", "file the rebuilt in-memory clips are written to, for POST /admin/restore (unused with -stateless)")
+	flags.Parse(args)
+
+	ctx := context.Background()
+	service := &AutocompleteService{
+		RedisClient:     connectRedis(ctx),
+		Stateless:       *stateless,
+		TopK:            topKSetting(),
+		ShardConfigPath: os.Getenv("SHARD_CONFIG"),
+	}
+	if readModelURL := os.Getenv("READ_MODEL_URL"); readModelURL != "" {
+		service.ReadModel = connectRedisURL(ctx, readModelURL)
+	}
+
+	if *dryRun {
+		counts := map[string]int{}
+		err := service.scanEvents(ctx, "0", *until, func(event *loggedEvent) error {
+			counts[event.Type]++
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("Failed to read event log: %v", err)
+		}
+		types := make([]string, 0, len(counts))
+		for eventType := range counts {
+			types = append(types, eventType)
+		}
+		sort.Strings(types)
+		for _, eventType := range types {
+			fmt.Printf("%-12s %d\n", eventType, counts[eventType])
+		}
+		return
+	}
+	if !*reset {
+		log.Fatalf("replay-events rewrites the indexes from scratch; pass -reset to delete them first")
+	}
+
+	if service.ShardConfigPath != "" {
+		if err := service.reloadShards(ctx); err != nil {
+			log.Fatalf("Failed to configure Redis shards: %v", err)
+		}
+	}
+	if err := services.ConfigureResources(os.Getenv("RESOURCE_DIR")); err != nil {
+		log.Fatalf("Failed to configure language resources: %v", err)
+	}
+	if err := services.ConfigureHomophones(); err != nil {
+		log.Fatalf("Failed to load homophone table: %v", err)
+	}
+	if err := services.ConfigureStemmer(); err != nil {
+		log.Fatalf("Failed to load stemmer overrides: %v", err)
+	}
+	if err := services.ConfigureProfanityFilter(os.Getenv("PROFANITY_MODE"), os.Getenv("PROFANITY_LIST")); err != nil {
+		log.Fatalf("Failed to configure profanity filter: %v", err)
+	}
+
+	deleted, err := service.resetIndexes(ctx)
+	if err != nil {
+		log.Fatalf("Failed to delete indexes: %v", err)
+	}
+	log.Printf("Deleted %d index keys", deleted)
+
+	replay := &eventReplay{service: service, history: map[string][]*loggedEvent{}, trash: map[string][]*loggedEvent{}}
+	if err := service.scanEvents(ctx, "0", *until, func(event *loggedEvent) error {
+		if err := replay.apply(ctx, event); err != nil {
+			replay.failed++
+			log.Printf("Error replaying %s event %s for clip %s: %v", event.Type, event.ID, event.AudioID, err)
+		} else {
+			replay.applied++
+		}
+		return nil
+	}); err != nil {
+		log.Fatalf("Failed to read event log: %v", err)
+	}
+
+	if !service.Stateless {
+		encoded, err := json.Marshal(services.SnapshotAll())
+		if err != nil {
+			log.Fatalf("Failed to encode snapshot: %v", err)
+		}
+		if err := os.WriteFile(*output, encoded, 0o644); err != nil {
+			log.Fatalf("Failed to write snapshot: %v", err)
+		}
+		log.Printf("Wrote rebuilt clips to %s", *output)
+	}
+	log.Printf("Replayed %d events (%d failed)", replay.applied, replay.failed)
+	if replay.failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// resetIndexes deletes everything the event log rebuilds: the shared and
+// verified indexes and every clip's keys, including archived and
+// soft-deleted clips. Blocklists, the outbox and the log itself stay.
+func (s *AutocompleteService) resetIndexes(ctx context.Context) (int64, error) {
+	patterns := append([]string{
+		redisKeyPrefix + "verified:*", accentConfusionKeyPrefix + "*",
+		archivedClipsKey, trashIndexKey,
+	}, sharedIndexPatterns...)
+
+	var deleted int64
+	for _, pattern := range patterns {
+		n, err := s.deleteKeys(ctx, s.RedisClient, pattern)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+	if s.ReadModel != nil {
+		n, err := s.ReadModel.Del(ctx, topKKey).Result()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+	for _, client := range s.redisClients() {
+		n, err := s.deleteKeys(ctx, client, clipKeyPrefix("*")+"*")
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// eventReplay applies logged events in order. It keeps each clip's ingest
+// and corrections since it was last indexed, so restoring a soft-deleted clip
+// can replay them.
+type eventReplay struct {
+	service *AutocompleteService
+	history map[string][]*loggedEvent
+	trash   map[string][]*loggedEvent
+	applied int
+	failed  int
+}
+
+func (r *eventReplay) apply(ctx context.Context, event *loggedEvent) error {
+	s := r.service
+	audioID := services.NormalizeAudioID(event.AudioID)
+
+	switch event.Type {
+	case eventIngest:
+		if event.Data == nil {
+			return fmt.Errorf("ingest event without data")
+		}
+		s.storeWords(ctx, event.Data)
+		r.history[audioID] = []*loggedEvent{event}
+		return s.indexClip(ctx, audioID, event.Data)
+
+	case eventCorrection:
+		candidates, err := r.correct(ctx, audioID, event)
+		if err != nil {
+			return err
+		}
+		r.history[audioID] = append(r.history[audioID], event)
+		return s.storeWord(ctx, event.NewWord, candidates[0].Confidence)
+
+	case eventFinalize:
+		data, err := s.clipTranscripts(ctx, audioID)
+		if err != nil {
+			return err
+		}
+		if err := s.RedisClient.SAdd(ctx, verifiedClipsKey, audioID).Err(); err != nil {
+			return err
+		}
+		baseline := services.TranscriptWords(data.FinalTranscription)
+		_, err = s.storeVerified(ctx, audioID, data, baseline, services.TranscriptWords(event.Transcript))
+		return err
+
+	case eventDelete:
+		if _, _, err := s.purgeClipData(ctx, audioID); err != nil {
+			return err
+		}
+		if event.Hard {
+			delete(r.trash, audioID)
+		} else {
+			r.trash[audioID] = r.history[audioID]
+		}
+		delete(r.history, audioID)
+		return nil
+
+	case eventRestore:
+		history := r.trash[audioID]
+		if len(history) == 0 {
+			return fmt.Errorf("restore of a clip the log never soft-deleted")
+		}
+		delete(r.trash, audioID)
+		// The clip's words never left the global index, so only its own index is rebuilt
+		if err := s.indexClip(ctx, audioID, history[0].Data); err != nil {
+			return err
+		}
+		for _, correction := range history[1:] {
+			if _, err := r.correct(ctx, audioID, correction); err != nil {
+				return err
+			}
+		}
+		r.history[audioID] = history
+		return nil
+	}
+	return fmt.Errorf("unknown event type %q", event.Type)
+}
+
+// correct re-applies a logged correction to the clip and its correction log
+func (r *eventReplay) correct(ctx context.Context, audioID string, event *loggedEvent) ([]models.WordSuggestion, error) {
+	candidates, err := r.service.applyReplace(ctx, audioID, event.Position, event.OldWord, event.NewWord)
+	if err != nil {
+		return nil, err
+	}
+	return candidates, r.service.logCorrection(ctx, audioID, event.Position, event.OldWord, event.NewWord)
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+const (
+	// replayLogKey is the append-only stream of suggest queries and acceptances
+	replayLogKey = redisKeyPrefix + "replay:log"
+
+	// replayLogMaxLen caps the stream so the log cannot grow without bound
+	replayLogMaxLen = 1000000
+)
+
+// ReplayRecord is one (prefix, position, candidates, accepted) tuple of the export
+type ReplayRecord struct {
+	QueryID    string   `json:"query_id"`
+	AudioID    string   `json:"audio_id"`
+	Prefix     string   `json:"prefix"`
+	Position   string   `json:"position,omitempty"`
+	Candidates []string `json:"candidates"`
+	Accepted   string   `json:"accepted,omitempty"`
+	Timestamp  int64    `json:"timestamp"`
+}
+
+// logReplayQuery appends a suggest query to the replay log. The stream entry id
+// doubles as the query id clients send back when a candidate is accepted.
+func (s *AutocompleteService) logReplayQuery(ctx context.Context, audioID, prefix, position string, suggestions []map[string]interface{}) (string, error) {
+	candidates := make([]string, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		if text, ok := suggestion["text"].(string); ok {
+			candidates = append(candidates, text)
+		}
+	}
+	encoded, err := json.Marshal(candidates)
+	if err != nil {
+		return "", err
+	}
+
+	return s.RedisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: replayLogKey,
+		MaxLen: replayLogMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":       "query",
+			"audio_id":   audioID,
+			"prefix":     prefix,
+			"position":   position,
+			"candidates": string(encoded),
+		},
+	}).Result()
+}
+
+// handleSuggestAccept records an accepted candidate. With a query_id it joins
+// the replay log; with a position it re-ranks the clip's remaining positions.
+func (s *AutocompleteService) handleSuggestAccept(c *gin.Context) {
+	var request struct {
+		QueryID  string `json:"query_id"`
+		AudioID  string `json:"audio_id"`
+		Position *int   `json:"position"`
+		Accepted string `json:"accepted" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.QueryID == "" && request.Position == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query_id or position required"})
+		return
+	}
+
+	response := gin.H{"status": "recorded"}
+	feedback := gin.H{"audio_id": services.NormalizeAudioID(request.AudioID), "accepted": request.Accepted}
+	if request.Position != nil {
+		feedback["position"] = *request.Position
+	}
+	if request.QueryID != "" {
+		feedback["query_id"] = request.QueryID
+	}
+
+	// Re-rank the rest of the clip so later positions reflect the user's choices
+	if request.Position != nil {
+		if s.Stateless {
+			response["reranked"] = 0
+			response["message"] = "Session re-ranking needs in-memory clips"
+		} else {
+			changed, err := services.AcceptWord(request.AudioID, *request.Position, request.Accepted)
+			if errors.Is(err, services.ErrPositionNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			response["reranked"] = changed
+		}
+	}
+
+	if request.QueryID == "" {
+		s.emitWebhook(webhookFeedbackAccepted, feedback)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	if !s.ReplayLogEnabled {
+		if request.Position == nil {
+			response = gin.H{"status": "ignored", "message": "Query replay log is disabled"}
+		} else {
+			s.emitWebhook(webhookFeedbackAccepted, feedback)
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	ctx := context.Background()
+	err := s.RedisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: replayLogKey,
+		MaxLen: replayLogMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":     "accept",
+			"query_id": request.QueryID,
+			"accepted": request.Accepted,
+		},
+	}).Err()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.emitWebhook(webhookFeedbackAccepted, feedback)
+	c.JSON(http.StatusOK, response)
+}
+
+// handleReplayExport streams the replay log as NDJSON, joining each query with
+// its acceptance. Use since (an exclusive stream id) and count to page through.
+// A query accepted after its own page ended is exported again, with accepted
+// set, at the start of the page holding the acceptance.
+func (s *AutocompleteService) handleReplayExport(c *gin.Context) {
+	start := "-"
+	if since := c.Query("since"); since != "" {
+		start = "(" + since
+	}
+	count := int64(10000)
+	if countParam := c.Query("count"); countParam != "" {
+		parsed, err := strconv.ParseInt(countParam, 10, 64)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "count must be a positive integer"})
+			return
+		}
+		count = parsed
+	}
+
+	ctx := context.Background()
+	entries, err := s.RedisClient.XRangeN(ctx, replayLogKey, start, "+", count).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	records, late := joinReplayPage(entries)
+	if len(late) > 0 {
+		earlier, err := s.fetchReplayQueries(ctx, late)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		records = append(earlier, records...)
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	if len(entries) > 0 {
+		c.Header("X-Replay-Last-ID", entries[len(entries)-1].ID)
+	}
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	for _, record := range records {
+		encoder.Encode(record)
+	}
+}
+
+// joinReplayPage joins the queries of one page of the replay log with the
+// accepts on the same page. Accepts whose query precedes the page come back in
+// late, holding only the query id and accepted candidate, in the order seen.
+func joinReplayPage(entries []redis.XMessage) (records, late []*ReplayRecord) {
+	byQueryID := make(map[string]*ReplayRecord)
+	lateByQueryID := make(map[string]*ReplayRecord)
+	for _, entry := range entries {
+		switch entry.Values["type"] {
+		case "query":
+			record := replayQueryRecord(entry)
+			records = append(records, record)
+			byQueryID[entry.ID] = record
+		case "accept":
+			queryID := stringValue(entry.Values["query_id"])
+			accepted := stringValue(entry.Values["accepted"])
+			if record, exists := byQueryID[queryID]; exists {
+				record.Accepted = accepted
+			} else if record, exists := lateByQueryID[queryID]; exists {
+				record.Accepted = accepted
+			} else {
+				record := &ReplayRecord{QueryID: queryID, Accepted: accepted}
+				late = append(late, record)
+				lateByQueryID[queryID] = record
+			}
+		}
+	}
+	return records, late
+}
+
+// fetchReplayQueries looks up the queries of late accepts by stream id and
+// joins them. Queries the stream has already trimmed are dropped.
+func (s *AutocompleteService) fetchReplayQueries(ctx context.Context, late []*ReplayRecord) ([]*ReplayRecord, error) {
+	pipe := s.RedisClient.Pipeline()
+	lookups := make([]*redis.XMessageSliceCmd, len(late))
+	for i, record := range late {
+		lookups[i] = pipe.XRange(ctx, replayLogKey, record.QueryID, record.QueryID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	records := make([]*ReplayRecord, 0, len(late))
+	for i, lookup := range lookups {
+		entries, err := lookup.Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 || entries[0].Values["type"] != "query" {
+			continue
+		}
+		record := replayQueryRecord(entries[0])
+		record.Accepted = late[i].Accepted
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// replayQueryRecord decodes a query entry of the replay log
+func replayQueryRecord(entry redis.XMessage) *ReplayRecord {
+	record := &ReplayRecord{
+		QueryID:    entry.ID,
+		AudioID:    stringValue(entry.Values["audio_id"]),
+		Prefix:     stringValue(entry.Values["prefix"]),
+		Position:   stringValue(entry.Values["position"]),
+		Candidates: []string{},
+		Timestamp:  streamIDMillis(entry.ID),
+	}
+	json.Unmarshal([]byte(stringValue(entry.Values["candidates"])), &record.Candidates)
+	return record
+}
+
+// stringValue reads a stream field, which go-redis returns as interface{}
+func stringValue(value interface{}) string {
+	str, _ := value.(string)
+	return str
+}
+
+// streamIDMillis extracts the millisecond timestamp of a stream entry id
+func streamIDMillis(id string) int64 {
+	for i := 0; i < len(id); i++ {
+		if id[i] == '-' {
+			millis, _ := strconv.ParseInt(id[:i], 10, 64)
+			return millis
+		}
+	}
+	return 0
+}
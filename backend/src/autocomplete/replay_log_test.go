@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func replayQuery(id, prefix string) redis.XMessage {
+	return redis.XMessage{ID: id, Values: map[string]interface{}{
+		"type":       "query",
+		"audio_id":   "clip",
+		"prefix":     prefix,
+		"candidates": `["` + prefix + `an"]`,
+	}}
+}
+
+func replayAccept(id, queryID, accepted string) redis.XMessage {
+	return redis.XMessage{ID: id, Values: map[string]interface{}{
+		"type":     "accept",
+		"query_id": queryID,
+		"accepted": accepted,
+	}}
+}
+
+func TestJoinReplayPage(t *testing.T) {
+	type joined struct {
+		queryID  string
+		accepted string
+	}
+	tests := []struct {
+		name     string
+		entries  []redis.XMessage
+		wantJoin []joined
+		wantLate []joined
+	}{
+		{"empty page", nil, nil, nil},
+		{"query without accept", []redis.XMessage{replayQuery("1-0", "mak")}, []joined{{"1-0", ""}}, nil},
+		{"accept on the same page", []redis.XMessage{
+			replayQuery("1-0", "mak"), replayAccept("2-0", "1-0", "makan"),
+		}, []joined{{"1-0", "makan"}}, nil},
+		{"last accept wins", []redis.XMessage{
+			replayQuery("1-0", "mak"), replayAccept("2-0", "1-0", "makan"), replayAccept("3-0", "1-0", "makna"),
+		}, []joined{{"1-0", "makna"}}, nil},
+		{"accept of an earlier page", []redis.XMessage{
+			replayAccept("5-0", "1-0", "makan"), replayQuery("6-0", "min"),
+		}, []joined{{"6-0", ""}}, []joined{{"1-0", "makan"}}},
+		{"late accepts in order, last wins", []redis.XMessage{
+			replayAccept("5-0", "2-0", "minum"), replayAccept("6-0", "1-0", "makan"), replayAccept("7-0", "2-0", "minuman"),
+		}, nil, []joined{{"2-0", "minuman"}, {"1-0", "makan"}}},
+	}
+	for _, tt := range tests {
+		records, late := joinReplayPage(tt.entries)
+		var gotJoin, gotLate []joined
+		for _, record := range records {
+			gotJoin = append(gotJoin, joined{record.QueryID, record.Accepted})
+		}
+		for _, record := range late {
+			gotLate = append(gotLate, joined{record.QueryID, record.Accepted})
+		}
+		if !reflect.DeepEqual(gotJoin, tt.wantJoin) || !reflect.DeepEqual(gotLate, tt.wantLate) {
+			t.Errorf("%s: joined %v late %v, want %v late %v", tt.name, gotJoin, gotLate, tt.wantJoin, tt.wantLate)
+		}
+	}
+}
+
+func TestJoinReplayPagesAcrossPages(t *testing.T) {
+	log := []redis.XMessage{
+		replayQuery("1-0", "mak"),
+		replayQuery("2-0", "min"),
+		replayAccept("3-0", "2-0", "minum"),
+		replayQuery("4-0", "ma"),
+		replayAccept("5-0", "1-0", "makan"),
+		replayAccept("6-0", "4-0", "mandi"),
+	}
+	byID := make(map[string]redis.XMessage)
+	for _, entry := range log {
+		byID[entry.ID] = entry
+	}
+	want := map[string]string{"1-0": "makan", "2-0": "minum", "4-0": "mandi"}
+
+	for count := 1; count <= len(log); count++ {
+		// Keep the last record of each query, as consumers of the export do
+		got := make(map[string]string)
+		for start := 0; start < len(log); start += count {
+			end := start + count
+			if end > len(log) {
+				end = len(log)
+			}
+			records, late := joinReplayPage(log[start:end])
+			for _, record := range late {
+				if query := replayQueryRecord(byID[record.QueryID]); query.Prefix == "" {
+					t.Errorf("count %d: late accept of %s resolved to no query", count, record.QueryID)
+				}
+			}
+			records = append(late, records...)
+			for _, record := range records {
+				got[record.QueryID] = record.Accepted
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("count %d: accepted = %v, want %v", count, got, want)
+		}
+	}
+}
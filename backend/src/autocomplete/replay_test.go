@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReplayMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now().Unix()
+	stamp := func(offset time.Duration) string { return strconv.FormatInt(now+int64(offset.Seconds()), 10) }
+
+	tests := []struct {
+		name         string
+		required     bool
+		nonce        string
+		timestamp    string
+		want         int
+		wantRejected int64
+	}{
+		{"no headers", false, "", "", http.StatusOK, 0},
+		{"no headers when required", true, "", "", http.StatusBadRequest, 1},
+		{"nonce without timestamp", false, "abc", "", http.StatusBadRequest, 1},
+		{"timestamp without nonce", false, "", stamp(0), http.StatusBadRequest, 1},
+		{"long nonce", false, strings.Repeat("n", maxNonceLength+1), stamp(0), http.StatusBadRequest, 1},
+		{"malformed timestamp", false, "abc", "yesterday", http.StatusBadRequest, 1},
+		{"stale timestamp", false, "abc", stamp(-10 * time.Minute), http.StatusUnauthorized, 1},
+		{"future timestamp", false, "abc", stamp(10 * time.Minute), http.StatusUnauthorized, 1},
+		// Passes the checks, then needs Redis to claim the nonce
+		{"fresh", false, "abc", stamp(-time.Minute), http.StatusInternalServerError, 0},
+	}
+	for _, tt := range tests {
+		service := &AutocompleteService{RedisClient: unreachableRedis(t), replay: newReplayGuard(5*time.Minute, tt.required)}
+		router := gin.New()
+		router.POST("/finalize", service.replayMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		req := httptest.NewRequest(http.MethodPost, "/finalize", nil)
+		if tt.nonce != "" {
+			req.Header.Set(replayNonceHeader, tt.nonce)
+		}
+		if tt.timestamp != "" {
+			req.Header.Set(replayTimestampHeader, tt.timestamp)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+		}
+		if got := service.replay.rejected.Load(); got != tt.wantRejected {
+			t.Errorf("%s: %d rejected, want %d", tt.name, got, tt.wantRejected)
+		}
+	}
+}
+
+func TestReplayStats(t *testing.T) {
+	service := &AutocompleteService{replay: newReplayGuard(5*time.Minute, true)}
+	service.replay.accepted.Add(3)
+	service.replay.duplicates.Add(1)
+
+	stats := service.replayStats()
+	if stats["window"] != "5m0s" || stats["required"] != true || stats["accepted"] != int64(3) || stats["duplicates"] != int64(1) || stats["rejected"] != int64(0) {
+		t.Errorf("replayStats() = %v", stats)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Bounds on /search results: occurrences returned by default and at most
+const (
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+// handleSearch finds every occurrence of a word across the indexed clips, with
+// the confidence and source of each, so researchers can spot-check a rare term
+// throughout the corpus. Stateless replicas scan every clip index in Redis;
+// otherwise the clips cached on this replica are searched.
+func (s *AutocompleteService) handleSearch(c *gin.Context) {
+	word := strings.TrimSpace(services.NormalizeText(c.Query("word")))
+	if word == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "word parameter required"})
+		return
+	}
+	limit := defaultSearchLimit
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed <= 0 || parsed > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxSearchLimit)})
+			return
+		}
+		limit = parsed
+	}
+
+	var occurrences []models.WordOccurrence
+	if s.Stateless {
+		var err error
+		occurrences, err = s.searchClipIndexes(context.Background(), word)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		occurrences = services.SearchCachedClips(word)
+	}
+
+	clips := make(map[string]bool)
+	for _, occurrence := range occurrences {
+		clips[occurrence.AudioID] = true
+	}
+	total := len(occurrences)
+	if len(occurrences) > limit {
+		occurrences = occurrences[:limit]
+	}
+	if occurrences == nil {
+		occurrences = []models.WordOccurrence{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"word":        word,
+		"clips":       len(clips),
+		"total":       total,
+		"occurrences": occurrences,
+	})
+}
+
+// searchClipIndexes scans the clip indexes of every Redis instance for a word.
+// A clip's positions are only read when its words hash holds the word in some
+// capitalization.
+func (s *AutocompleteService) searchClipIndexes(ctx context.Context, word string) ([]models.WordOccurrence, error) {
+	var occurrences []models.WordOccurrence
+	for _, client := range s.redisClients() {
+		iter := client.Scan(ctx, 0, clipKeyPrefix("*")+"words", 1000).Iterator()
+		for iter.Next(ctx) {
+			audioID := clipIDFromKey(iter.Val())
+			found, err := clipHasWord(ctx, client, audioID, word)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+
+			positionMap, err := readClipPositions(ctx, client, audioID)
+			if err != nil {
+				return nil, err
+			}
+			occurrences = append(occurrences, services.FindWordOccurrences(audioID, positionMap, word)...)
+		}
+		if err := iter.Err(); err != nil {
+			return nil, err
+		}
+	}
+	services.SortOccurrences(occurrences)
+	return occurrences, nil
+}
+
+// readClipPositions decodes a stateless clip's positions hash into a position map
+func readClipPositions(ctx context.Context, client *redis.Client, audioID string) (models.PositionMap, error) {
+	positions, err := client.HGetAll(ctx, clipPositionsKey(audioID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	positionMap := make(models.PositionMap, len(positions))
+	for field, packed := range positions {
+		pos, err := strconv.Atoi(field)
+		if err != nil {
+			continue
+		}
+		candidates, err := decodeSuggestions([]byte(packed))
+		if err != nil {
+			return nil, err
+		}
+		positionMap[pos] = candidates
+	}
+	return positionMap, nil
+}
+
+// clipHasWord reports whether a clip's words hash has the word, in any capitalization
+func clipHasWord(ctx context.Context, client *redis.Client, audioID, word string) (bool, error) {
+	fields, err := client.HKeys(ctx, clipWordsKey(audioID)).Result()
+	if err != nil {
+		return false, err
+	}
+	word = strings.ToLower(word)
+	for _, field := range fields {
+		if strings.ToLower(field) == word {
+			return true, nil
+		}
+	}
+	return false, nil
+}
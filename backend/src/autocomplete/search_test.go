@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleSearch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("a", &models.AutocompleteData{FinalTranscription: "makan nasi makan"})
+	services.BuildAndCacheData("b", &models.AutocompleteData{FinalTranscription: "saya makan"})
+
+	router := gin.New()
+	router.GET("/search", (&AutocompleteService{}).handleSearch)
+	stateless := gin.New()
+	stateless.GET("/search", (&AutocompleteService{Stateless: true, RedisClient: unreachableRedis(t)}).handleSearch)
+
+	tests := []struct {
+		router     *gin.Engine
+		query      string
+		wantStatus int
+		wantClips  int
+		wantTotal  int
+		wantListed int
+	}{
+		{router, "?word=makan", http.StatusOK, 2, 3, 3},
+		{router, "?word=MAKAN&limit=2", http.StatusOK, 2, 3, 2},
+		{router, "?word=tidur", http.StatusOK, 0, 0, 0},
+		{router, "?word=%20", http.StatusBadRequest, 0, 0, 0},
+		{router, "?word=makan&limit=0", http.StatusBadRequest, 0, 0, 0},
+		{router, "?word=makan&limit=1001", http.StatusBadRequest, 0, 0, 0},
+		{stateless, "?word=makan", http.StatusInternalServerError, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		tt.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/search"+tt.query, nil))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.query, w.Code, tt.wantStatus, w.Body)
+			continue
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		var response struct {
+			Clips       int                     `json:"clips"`
+			Total       int                     `json:"total"`
+			Occurrences []models.WordOccurrence `json:"occurrences"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Clips != tt.wantClips || response.Total != tt.wantTotal || len(response.Occurrences) != tt.wantListed || response.Occurrences == nil {
+			t.Errorf("%s: %d clips, %d total, %v listed, want %d, %d, %d", tt.query, response.Clips, response.Total, response.Occurrences, tt.wantClips, tt.wantTotal, tt.wantListed)
+		}
+	}
+}
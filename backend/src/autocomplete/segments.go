@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// segmentScope restricts suggestions to the words heard in part of a clip's
+// audio: the segment with an id, or every segment overlapping a time window
+type segmentScope struct {
+	segmentID string
+	start     float64
+	end       float64
+}
+
+// parseSegmentScope reads the segment_id or start_time/end_time parameters,
+// nil when neither is set. A window missing one end is open on that side.
+func parseSegmentScope(c *gin.Context) (*segmentScope, error) {
+	segmentID := strings.TrimSpace(c.Query("segment_id"))
+	startParam, endParam := c.Query("start_time"), c.Query("end_time")
+	if segmentID == "" && startParam == "" && endParam == "" {
+		return nil, nil
+	}
+	if segmentID != "" && (startParam != "" || endParam != "") {
+		return nil, fmt.Errorf("segment_id cannot be combined with start_time or end_time")
+	}
+	if c.Query("audio_id") == "" {
+		return nil, fmt.Errorf("segment filters require audio_id")
+	}
+
+	scope := &segmentScope{segmentID: segmentID, end: math.Inf(1)}
+	if startParam != "" {
+		start, err := strconv.ParseFloat(startParam, 64)
+		if err != nil || start < 0 {
+			return nil, fmt.Errorf("start_time must be a non-negative number of seconds")
+		}
+		scope.start = start
+	}
+	if endParam != "" {
+		end, err := strconv.ParseFloat(endParam, 64)
+		if err != nil || end < scope.start {
+			return nil, fmt.Errorf("end_time must be a number of seconds no earlier than start_time")
+		}
+		scope.end = end
+	}
+	return scope, nil
+}
+
+// segmentWordRange resolves a scope to the clip's word positions it covers.
+// It returns services.ErrSegmentNotFound when the clip has no matching segment.
+func (s *AutocompleteService) segmentWordRange(ctx context.Context, audioID string, scope *segmentScope) (int, int, error) {
+	data, err := s.clipTranscripts(ctx, audioID)
+	if err != nil {
+		return 0, 0, services.ErrSegmentNotFound
+	}
+	return services.SegmentWordRange(data.Segments, scope.segmentID, scope.start, scope.end)
+}
+
+// getSegmentSuggestions offers only words some ASR model heard at the word
+// positions first..last of the clip, ranked by their best confidence there
+func (s *AutocompleteService) getSegmentSuggestions(ctx context.Context, tenant, prefix, audioID string, first, last, maxResults int) ([]map[string]interface{}, error) {
+	prefix = strings.ToLower(prefix)
+	best := make(map[string]models.WordSuggestion)
+	for pos := first; pos <= last; pos++ {
+		candidates, err := s.positionCandidates(ctx, audioID, strconv.Itoa(pos))
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range candidates {
+			if !strings.HasPrefix(strings.ToLower(candidate.Text), prefix) {
+				continue
+			}
+			if previous, seen := best[candidate.Text]; !seen || candidate.Confidence > previous.Confidence {
+				best[candidate.Text] = candidate
+			}
+		}
+	}
+
+	ranked := make([]models.WordSuggestion, 0, len(best))
+	for _, candidate := range best {
+		ranked = append(ranked, candidate)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Confidence != ranked[j].Confidence {
+			return ranked[i].Confidence > ranked[j].Confidence
+		}
+		return ranked[i].Text < ranked[j].Text
+	})
+	ranked = services.ApplySuggestFilters(tenant, ranked)
+	if len(ranked) > maxResults {
+		ranked = ranked[:maxResults]
+	}
+
+	suggestions := make([]map[string]interface{}, len(ranked))
+	for i, candidate := range ranked {
+		suggestions[i] = map[string]interface{}{
+			"text":       candidate.Text,
+			"confidence": candidate.Confidence,
+			"source":     candidate.Source,
+		}
+	}
+	return suggestions, nil
+}
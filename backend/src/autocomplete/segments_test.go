@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestParseSegmentScope(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    *segmentScope
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"?audio_id=clip", nil, false},
+		{"?audio_id=clip&segment_id=%20intro%20", &segmentScope{segmentID: "intro", end: math.Inf(1)}, false},
+		{"?audio_id=clip&start_time=1.5", &segmentScope{start: 1.5, end: math.Inf(1)}, false},
+		{"?audio_id=clip&end_time=3", &segmentScope{end: 3}, false},
+		{"?audio_id=clip&start_time=1&end_time=1", &segmentScope{start: 1, end: 1}, false},
+		{"?segment_id=intro", nil, true},
+		{"?audio_id=clip&segment_id=intro&start_time=1", nil, true},
+		{"?audio_id=clip&start_time=-1", nil, true},
+		{"?audio_id=clip&start_time=soon", nil, true},
+		{"?audio_id=clip&start_time=2&end_time=1", nil, true},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/suggest/prefix"+tt.query, nil)
+		got, err := parseSegmentScope(c)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseSegmentScope() error = %v, want error %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseSegmentScope() = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestGetSegmentSuggestions(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{
+		FinalTranscription: "mana makan nasi",
+		ASRAlternatives:    map[string]string{"whisper": "mana makna nasi"},
+		ConfidenceScore:    0.9,
+	})
+
+	s := &AutocompleteService{}
+	tests := []struct {
+		name        string
+		audioID     string
+		prefix      string
+		first, last int
+		max         int
+		want        []string
+	}{
+		{"one word", "clip", "ma", 0, 0, 10, []string{"mana"}},
+		{"segment range", "clip", "MA", 1, 2, 10, []string{"makan", "makna"}},
+		{"limited", "clip", "ma", 0, 2, 1, []string{"makan"}},
+		{"no match", "clip", "ti", 0, 2, 10, []string{}},
+		{"unknown clip", "other", "ma", 0, 2, 10, []string{}},
+	}
+	for _, tt := range tests {
+		suggestions, err := s.getSegmentSuggestions(context.Background(), "", tt.prefix, tt.audioID, tt.first, tt.last, tt.max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, suggestion := range suggestions {
+			got = append(got, suggestion["text"].(string))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: getSegmentSuggestions(%q, %d..%d) = %v, want %v", tt.name, tt.prefix, tt.first, tt.last, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// selftestWord is the fixture clip's only word, unlikely to collide with real ones
+const selftestWord = "selftestprobe"
+
+// selftestCheck is one step of the self-test and how it went
+type selftestCheck struct {
+	name     string
+	err      error
+	duration time.Duration
+}
+
+// runSelftest checks the service can work before it takes traffic: it
+// connects to Redis, writes and reads a probe key, optionally pings the
+// orchestrator, indexes a fixture clip in memory and in Redis, and completes a
+// prefix against each. It prints a report and exits non-zero if any check
+// failed. Nothing is written to the global index, and the fixture is removed.
+func runSelftest(args []string) {
+	flags := flag.NewFlagSet("selftest", flag.ExitOnError)
+	orchestrator := flags.String("orchestrator", os.Getenv("ORCHESTRATOR_URL"), "orchestrator base URL whose /health is pinged (skipped when empty)")
+	timeout := flags.Duration("timeout", 5*time.Second, "timeout for each check")
+	flags.Parse(args)
+
+	var checks []selftestCheck
+	run := func(name string, check func(ctx context.Context) error) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		start := time.Now()
+		err := check(ctx)
+		checks = append(checks, selftestCheck{name: name, err: err, duration: time.Since(start)})
+		return err == nil
+	}
+
+	var client *redis.Client
+	redisOK := run("redis connect", func(ctx context.Context) error {
+		opt, err := redisOptions(redisURLFromEnv())
+		if err != nil {
+			return err
+		}
+		client = redis.NewClient(opt)
+		return client.Ping(ctx).Err()
+	})
+	if redisOK {
+		run("redis probe key", func(ctx context.Context) error {
+			key := fmt.Sprintf("%sselftest:%d", redisKeyPrefix, time.Now().UnixNano())
+			defer client.Del(context.Background(), key)
+			if err := client.Set(ctx, key, selftestWord, time.Minute).Err(); err != nil {
+				return err
+			}
+			value, err := client.Get(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+			if value != selftestWord {
+				return fmt.Errorf("read back %q, wrote %q", value, selftestWord)
+			}
+			return nil
+		})
+	}
+	if *orchestrator != "" {
+		run("orchestrator ping", func(ctx context.Context) error {
+			return pingOrchestrator(ctx, *orchestrator)
+		})
+	}
+
+	audioID := fmt.Sprintf("selftest-%d", time.Now().UnixNano())
+	fixture := &models.AutocompleteData{
+		FinalTranscription: selftestWord,
+		ConfidenceScore:    0.9,
+		ASRAlternatives:    map[string]string{},
+	}
+	run("in-memory index", func(ctx context.Context) error {
+		defer services.PurgeClip(audioID)
+		services.BuildAndCacheData(audioID, fixture)
+		trie, err := services.GetPrefixTrie(audioID)
+		if err != nil {
+			return err
+		}
+		return expectSelftestWord(trie)
+	})
+	if redisOK {
+		run("redis index", func(ctx context.Context) error {
+			service := &AutocompleteService{RedisClient: client, Stateless: true}
+			defer service.deleteKeys(context.Background(), client, clipKeyPrefix(audioID)+"*")
+			if err := service.storeClipIndex(ctx, audioID, fixture, nil); err != nil {
+				return err
+			}
+			trie, err := service.clipTrie(ctx, audioID, selftestWord[:3])
+			if err != nil {
+				return err
+			}
+			return expectSelftestWord(trie)
+		})
+	}
+
+	failed := 0
+	for _, check := range checks {
+		status := "ok"
+		if check.err != nil {
+			status = "FAIL: " + check.err.Error()
+			failed++
+		}
+		fmt.Printf("%-18s %8.1fms  %s\n", check.name, float64(check.duration.Microseconds())/1000, status)
+	}
+	if failed > 0 {
+		fmt.Printf("selftest failed: %d of %d checks\n", failed, len(checks))
+		os.Exit(1)
+	}
+	fmt.Printf("selftest passed: %d checks\n", len(checks))
+}
+
+// expectSelftestWord completes the fixture word's first letters against a trie
+func expectSelftestWord(trie *models.PrefixTrie) error {
+	for _, suggestion := range trie.SearchSuggestions(selftestWord[:3], 5) {
+		if suggestion.Text == selftestWord {
+			return nil
+		}
+	}
+	return fmt.Errorf("prefix %q did not complete to %q", selftestWord[:3], selftestWord)
+}
+
+// pingOrchestrator expects a 2xx from the orchestrator's /health
+func pingOrchestrator(ctx context.Context, baseURL string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/health", nil)
+	if err != nil {
+		return err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("orchestrator returned status %d", response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestExpectSelftestWord(t *testing.T) {
+	tests := []struct {
+		name    string
+		words   []string
+		wantErr bool
+	}{
+		{"fixture indexed", []string{selftestWord}, false},
+		{"fixture among others", []string{"selfish", selftestWord, "selection"}, false},
+		{"fixture missing", []string{"selfish"}, true},
+		{"empty", nil, true},
+	}
+	for _, tt := range tests {
+		trie := models.NewPrefixTrie("selftest")
+		for _, word := range tt.words {
+			trie.Insert(word, models.WordSuggestion{Text: word, Confidence: 0.5})
+		}
+		if err := expectSelftestWord(trie); (err != nil) != tt.wantErr {
+			t.Errorf("%s: expectSelftestWord() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPingOrchestrator(t *testing.T) {
+	tests := []struct {
+		status  int
+		wantErr bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusNoContent, false},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusNotFound, true},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/health" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(tt.status)
+		}))
+		// A trailing slash on the base URL is tolerated
+		err := pingOrchestrator(context.Background(), server.URL+"/")
+		server.Close()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("status %d: pingOrchestrator() error = %v, want error %v", tt.status, err, tt.wantErr)
+		}
+	}
+
+	if err := pingOrchestrator(context.Background(), "http://127.0.0.1:1"); err == nil {
+		t.Error("pingOrchestrator() reached an orchestrator that isn't running")
+	}
+}
+
+func TestRedisURLFromEnv(t *testing.T) {
+	tests := []struct {
+		writeURL, url string
+		want          string
+	}{
+		{"", "", "redis://redis:6379"},
+		{"", "redis://cache:6379", "redis://cache:6379"},
+		{"redis://primary:6379", "redis://cache:6379", "redis://primary:6379"},
+	}
+	for _, tt := range tests {
+		t.Setenv("REDIS_WRITE_URL", tt.writeURL)
+		t.Setenv("REDIS_URL", tt.url)
+		if got := redisURLFromEnv(); got != tt.want {
+			t.Errorf("REDIS_WRITE_URL %q REDIS_URL %q: redisURLFromEnv() = %q, want %q", tt.writeURL, tt.url, got, tt.want)
+		}
+	}
+}
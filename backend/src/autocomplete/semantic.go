@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"autocomplete/services"
+)
+
+// Semantic reranking asks the sentence-embedding service at EMBEDDING_URL how
+// well each candidate fits the sentence around it. It is off without a URL.
+var (
+	embeddingURL    string
+	embeddingClient = &http.Client{Timeout: 250 * time.Millisecond}
+
+	// semanticWeight is the share of a reranked score taken by the semantic fit,
+	// set from SEMANTIC_WEIGHT
+	semanticWeight = 0.3
+
+	semanticReranked atomic.Int64
+	semanticFailed   atomic.Int64
+)
+
+// semanticContextWords is how many baseline words on each side of a clip
+// position form the sentence homophones are reranked against
+const semanticContextWords = 8
+
+// configureEmbeddings reads EMBEDDING_URL, EMBEDDING_MODEL, EMBEDDING_TIMEOUT,
+// EMBEDDING_CACHE_TTL and SEMANTIC_WEIGHT
+func configureEmbeddings() {
+	embeddingURL = os.Getenv("EMBEDDING_URL")
+	if model := os.Getenv("EMBEDDING_MODEL"); model != "" {
+		embeddingModel = model
+	}
+	embeddingClient.Timeout = envDuration("EMBEDDING_TIMEOUT", embeddingClient.Timeout)
+	embeddingCacheTTL = envDuration("EMBEDDING_CACHE_TTL", embeddingCacheTTL)
+	semanticWeight = envFraction("SEMANTIC_WEIGHT", semanticWeight)
+}
+
+// semanticContext is the text on each side of the word being ranked
+type semanticContext struct {
+	left  string
+	right string
+}
+
+func (sc semanticContext) empty() bool {
+	return sc.left == "" && sc.right == ""
+}
+
+// sentence is the context with word in the slot, or without it for an empty word
+func (sc semanticContext) sentence(word string) string {
+	return strings.Join(strings.Fields(sc.left+" "+word+" "+sc.right), " ")
+}
+
+// semanticEnabled reports whether a request's candidates are reranked by
+// semantic fit: an embedding service is configured and semantic=false wasn't sent
+func semanticEnabled(param string) bool {
+	return embeddingURL != "" && param != "false"
+}
+
+// clipSentenceContext is the baseline around a word position of a clip, for
+// homophone lookups that name a position instead of sending context
+func (s *AutocompleteService) clipSentenceContext(ctx context.Context, audioID, position string) semanticContext {
+	pos, err := strconv.Atoi(position)
+	if err != nil || audioID == "" {
+		return semanticContext{}
+	}
+	data, err := s.clipTranscripts(ctx, audioID)
+	if err != nil {
+		return semanticContext{}
+	}
+	return sentenceAround(services.TranscriptWords(data.FinalTranscription), pos)
+}
+
+// sentenceAround is the context of up to semanticContextWords words on each
+// side of a position of the baseline
+func sentenceAround(words []string, pos int) semanticContext {
+	if pos < 0 || pos >= len(words) {
+		return semanticContext{}
+	}
+	start := pos - semanticContextWords
+	if start < 0 {
+		start = 0
+	}
+	end := pos + 1 + semanticContextWords
+	if end > len(words) {
+		end = len(words)
+	}
+	return semanticContext{
+		left:  strings.Join(words[start:pos], " "),
+		right: strings.Join(words[pos+1:end], " "),
+	}
+}
+
+// semanticRerank blends each suggestion's confidence with how well it fits the
+// context, so a homophone that makes no sense in the sentence drops below one
+// that does. The fit is the similarity between the embeddings of the context
+// and of the sentence with the suggestion in its slot, rescaled across the
+// candidates to 0..1, and is reported as "semantic_fit". Embeddings come from
+// the cache when they can. Without context, or when the embedding service
+// fails, suggestions keep their order.
+func (s *AutocompleteService) semanticRerank(ctx context.Context, suggestions []map[string]interface{}, sc semanticContext) []map[string]interface{} {
+	if sc.empty() || len(suggestions) < 2 {
+		return suggestions
+	}
+
+	texts := make([]string, 0, len(suggestions)+1)
+	texts = append(texts, sc.sentence(""))
+	for _, suggestion := range suggestions {
+		text, _ := suggestion["text"].(string)
+		texts = append(texts, sc.sentence(text))
+	}
+	embeddings, err := s.cachedEmbeddings(ctx, texts)
+	if err != nil {
+		semanticFailed.Add(1)
+		log.Printf("Error embedding suggestion context: %v", err)
+		return suggestions
+	}
+
+	similarities := make([]float64, len(suggestions))
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for i := range suggestions {
+		similarities[i] = cosineSimilarity(embeddings[0], embeddings[i+1])
+		lowest = math.Min(lowest, similarities[i])
+		highest = math.Max(highest, similarities[i])
+	}
+
+	for i, suggestion := range suggestions {
+		fit := 0.0
+		if highest > lowest {
+			fit = (similarities[i] - lowest) / (highest - lowest)
+		}
+		confidence, _ := suggestion["confidence"].(float64)
+		suggestion["semantic_fit"] = fit
+		suggestion["confidence"] = (1-semanticWeight)*confidence + semanticWeight*fit
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i]["confidence"].(float64) > suggestions[j]["confidence"].(float64)
+	})
+	semanticReranked.Add(1)
+	return suggestions
+}
+
+// embedTexts asks the embedding service for one vector per text. It is sent
+// {"texts": [...], "model"} and must answer with {"embeddings": [[...], ...]}
+// in the same order.
+func embedTexts(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(map[string]interface{}{"texts": texts, "model": embeddingModel})
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, embeddingURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := embeddingClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding service returned status %d", response.StatusCode)
+	}
+
+	var decoded struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode embeddings: %w", err)
+	}
+	if len(decoded.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedding service returned %d vectors for %d texts", len(decoded.Embeddings), len(texts))
+	}
+	return decoded.Embeddings, nil
+}
+
+// cosineSimilarity compares two vectors; mismatched or zero vectors score 0
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// semanticStats reports whether semantic reranking is on and how it has fared
+func semanticStats() map[string]interface{} {
+	return map[string]interface{}{
+		"enabled":  embeddingURL != "",
+		"weight":   semanticWeight,
+		"reranked": semanticReranked.Load(),
+		"failed":   semanticFailed.Load(),
+		"cache":    embeddingCacheStats(),
+	}
+}
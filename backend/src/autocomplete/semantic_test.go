@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigureEmbeddings(t *testing.T) {
+	defer func(url, model string, timeout, ttl time.Duration, weight float64) {
+		embeddingURL, embeddingModel, embeddingClient.Timeout, embeddingCacheTTL, semanticWeight = url, model, timeout, ttl, weight
+	}(embeddingURL, embeddingModel, embeddingClient.Timeout, embeddingCacheTTL, semanticWeight)
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantURL     string
+		wantModel   string
+		wantTimeout time.Duration
+		wantWeight  float64
+	}{
+		{"unset", nil, "", "default", 250 * time.Millisecond, 0.3},
+		{"all set", map[string]string{
+			"EMBEDDING_URL": "http://embedder", "EMBEDDING_MODEL": "v2", "EMBEDDING_TIMEOUT": "1s", "SEMANTIC_WEIGHT": "0.6",
+		}, "http://embedder", "v2", time.Second, 0.6},
+		{"invalid settings keep the defaults", map[string]string{
+			"EMBEDDING_URL": "http://embedder", "EMBEDDING_TIMEOUT": "soon", "SEMANTIC_WEIGHT": "1.5",
+		}, "http://embedder", "default", 250 * time.Millisecond, 0.3},
+	}
+	for _, tt := range tests {
+		embeddingModel, embeddingClient.Timeout, semanticWeight = "default", 250*time.Millisecond, 0.3
+		for _, name := range []string{"EMBEDDING_URL", "EMBEDDING_MODEL", "EMBEDDING_TIMEOUT", "EMBEDDING_CACHE_TTL", "SEMANTIC_WEIGHT"} {
+			t.Setenv(name, tt.env[name])
+		}
+		configureEmbeddings()
+		if embeddingURL != tt.wantURL || embeddingModel != tt.wantModel || embeddingClient.Timeout != tt.wantTimeout || semanticWeight != tt.wantWeight {
+			t.Errorf("%s: url %q model %q timeout %v weight %v, want %q %q %v %v", tt.name,
+				embeddingURL, embeddingModel, embeddingClient.Timeout, semanticWeight, tt.wantURL, tt.wantModel, tt.wantTimeout, tt.wantWeight)
+		}
+	}
+}
+
+func TestSemanticEnabled(t *testing.T) {
+	defer func(url string) { embeddingURL = url }(embeddingURL)
+
+	tests := []struct {
+		url   string
+		param string
+		want  bool
+	}{
+		{"", "", false},
+		{"", "true", false},
+		{"http://embedder", "", true},
+		{"http://embedder", "true", true},
+		{"http://embedder", "false", false},
+	}
+	for _, tt := range tests {
+		embeddingURL = tt.url
+		if got := semanticEnabled(tt.param); got != tt.want {
+			t.Errorf("url %q: semanticEnabled(%q) = %v, want %v", tt.url, tt.param, got, tt.want)
+		}
+	}
+}
+
+func TestSentenceAround(t *testing.T) {
+	long := strings.Fields("a b c d e f g h i j k l m n o p q r s t")
+
+	tests := []struct {
+		words     []string
+		pos       int
+		wantLeft  string
+		wantRight string
+	}{
+		{[]string{"saya", "makan", "nasi"}, 1, "saya", "nasi"},
+		{[]string{"saya", "makan", "nasi"}, 0, "", "makan nasi"},
+		{[]string{"saya", "makan", "nasi"}, 2, "saya makan", ""},
+		{[]string{"makan"}, 0, "", ""},
+		{[]string{"saya", "makan"}, 2, "", ""},
+		{[]string{"saya", "makan"}, -1, "", ""},
+		{long, 10, "c d e f g h i j", "l m n o p q r s"},
+	}
+	for _, tt := range tests {
+		got := sentenceAround(tt.words, tt.pos)
+		if got.left != tt.wantLeft || got.right != tt.wantRight {
+			t.Errorf("sentenceAround(%v, %d) = %q | %q, want %q | %q", tt.words, tt.pos, got.left, got.right, tt.wantLeft, tt.wantRight)
+		}
+	}
+}
+
+func TestSemanticContextSentence(t *testing.T) {
+	tests := []struct {
+		sc   semanticContext
+		word string
+		want string
+	}{
+		{semanticContext{"saya", "nasi"}, "makan", "saya makan nasi"},
+		{semanticContext{"saya", "nasi"}, "", "saya nasi"},
+		{semanticContext{"", "nasi"}, "makan", "makan nasi"},
+		{semanticContext{"saya  ", " nasi"}, "makan", "saya makan nasi"},
+	}
+	for _, tt := range tests {
+		if got := tt.sc.sentence(tt.word); got != tt.want {
+			t.Errorf("%+v.sentence(%q) = %q, want %q", tt.sc, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []float64
+		want float64
+	}{
+		{[]float64{1, 0}, []float64{1, 0}, 1},
+		{[]float64{1, 0}, []float64{0, 1}, 0},
+		{[]float64{1, 0}, []float64{-2, 0}, -1},
+		{[]float64{1, 1}, []float64{1, 0}, 1 / math.Sqrt2},
+		{[]float64{1, 0}, []float64{1, 0, 0}, 0},
+		{[]float64{0, 0}, []float64{1, 0}, 0},
+	}
+	for _, tt := range tests {
+		if got := cosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("cosineSimilarity(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEmbedTexts(t *testing.T) {
+	defer func(url string) { embeddingURL = url }(embeddingURL)
+
+	tests := []struct {
+		name       string
+		status     int
+		embeddings [][]float64
+		want       [][]float64
+		wantErr    bool
+	}{
+		{"one vector per text", http.StatusOK, [][]float64{{1, 0}, {0, 1}}, [][]float64{{1, 0}, {0, 1}}, false},
+		{"service failure", http.StatusBadGateway, nil, nil, true},
+		{"too few vectors", http.StatusOK, [][]float64{{1, 0}}, nil, true},
+	}
+	for _, tt := range tests {
+		var received struct {
+			Texts []string `json:"texts"`
+			Model string   `json:"model"`
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(tt.status)
+			json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": tt.embeddings})
+		}))
+		embeddingURL = server.URL
+
+		got, err := embedTexts(context.Background(), []string{"saya makan", "saya makna"})
+		server.Close()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: embedTexts() error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: embedTexts() = %v, want %v", tt.name, got, tt.want)
+		}
+		if !reflect.DeepEqual(received.Texts, []string{"saya makan", "saya makna"}) || received.Model != embeddingModel {
+			t.Errorf("%s: service received %+v", tt.name, received)
+		}
+	}
+}
+
+func TestSemanticRerankKeepsOrder(t *testing.T) {
+	defer func(url string) { embeddingURL = url }(embeddingURL)
+	embeddingURL = "http://embedder"
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+
+	suggestion := func(text string, confidence float64) map[string]interface{} {
+		return map[string]interface{}{"text": text, "confidence": confidence}
+	}
+	tests := []struct {
+		name        string
+		suggestions []map[string]interface{}
+		sc          semanticContext
+		wantFailed  int64
+	}{
+		{"no context", []map[string]interface{}{suggestion("makna", 0.4), suggestion("makan", 0.9)}, semanticContext{}, 0},
+		{"one suggestion", []map[string]interface{}{suggestion("makna", 0.4)}, semanticContext{"saya", "nasi"}, 0},
+		{"embeddings unavailable", []map[string]interface{}{suggestion("makna", 0.4), suggestion("makan", 0.9)}, semanticContext{"saya", "nasi"}, 1},
+	}
+	for _, tt := range tests {
+		want := make([]string, len(tt.suggestions))
+		for i, suggestion := range tt.suggestions {
+			want[i] = suggestion["text"].(string)
+		}
+		failed := semanticFailed.Load()
+
+		var got []string
+		for _, suggestion := range s.semanticRerank(context.Background(), tt.suggestions, tt.sc) {
+			if _, scored := suggestion["semantic_fit"]; scored {
+				t.Errorf("%s: %v was scored", tt.name, suggestion["text"])
+			}
+			got = append(got, suggestion["text"].(string))
+		}
+		if !reflect.DeepEqual(got, want) || semanticFailed.Load()-failed != tt.wantFailed {
+			t.Errorf("%s: semanticRerank() = %v with %d failures, want %v with %d", tt.name, got, semanticFailed.Load()-failed, want, tt.wantFailed)
+		}
+	}
+}
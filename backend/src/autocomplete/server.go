@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newHTTPServer wraps the router in a server with explicit timeouts. gin's Run()
+// uses a bare http.Server, so a slow client could hold a connection open forever.
+//
+//	HTTP_READ_HEADER_TIMEOUT, HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT,
+//	HTTP_IDLE_TIMEOUT, HTTP_MAX_HEADER_BYTES, HTTP_H2C
+func newHTTPServer(router *gin.Engine, addr string) *http.Server {
+	// h2c lets in-cluster callers speak HTTP/2 without TLS; HTTP/1.1 clients are unaffected
+	router.UseH2C = os.Getenv("HTTP_H2C") != "false"
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           router.Handler(),
+		ReadHeaderTimeout: envDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:       envDuration("HTTP_READ_TIMEOUT", 30*time.Second),
+		WriteTimeout:      envDuration("HTTP_WRITE_TIMEOUT", 60*time.Second),
+		IdleTimeout:       envDuration("HTTP_IDLE_TIMEOUT", 120*time.Second),
+		MaxHeaderBytes:    envInt("HTTP_MAX_HEADER_BYTES", 64<<10),
+	}
+}
+
+// serveHTTP serves TLS (with HTTP/2 negotiated over ALPN) when TLS_CERT_FILE and
+// TLS_KEY_FILE are set, and plain HTTP/1.1 plus h2c otherwise
+func serveHTTP(server *http.Server) error {
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if certFile != "" && keyFile != "" {
+		log.Printf("Serving HTTPS with HTTP/2 on %s", server.Addr)
+		return server.ListenAndServeTLS(certFile, keyFile)
+	}
+	return server.ListenAndServe()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewHTTPServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name       string
+		h2c        string
+		readHeader string
+		maxHeader  string
+		wantH2C    bool
+		wantRead   time.Duration
+		wantHeader int
+	}{
+		{"defaults", "", "", "", true, 5 * time.Second, 64 << 10},
+		{"h2c off", "false", "", "", false, 5 * time.Second, 64 << 10},
+		{"overrides", "true", "2s", "4096", true, 2 * time.Second, 4096},
+		{"invalid overrides", "", "quick", "-1", true, 5 * time.Second, 64 << 10},
+	}
+	for _, tt := range tests {
+		t.Setenv("HTTP_H2C", tt.h2c)
+		t.Setenv("HTTP_READ_HEADER_TIMEOUT", tt.readHeader)
+		t.Setenv("HTTP_MAX_HEADER_BYTES", tt.maxHeader)
+
+		router := gin.New()
+		server := newHTTPServer(router, ":8080")
+		if router.UseH2C != tt.wantH2C || server.ReadHeaderTimeout != tt.wantRead || server.MaxHeaderBytes != tt.wantHeader {
+			t.Errorf("%s: h2c %v, read header timeout %v, max header bytes %d", tt.name, router.UseH2C, server.ReadHeaderTimeout, server.MaxHeaderBytes)
+		}
+		if server.Addr != ":8080" || server.WriteTimeout != 60*time.Second || server.IdleTimeout != 120*time.Second {
+			t.Errorf("%s: addr %s, write timeout %v, idle timeout %v", tt.name, server.Addr, server.WriteTimeout, server.IdleTimeout)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package services
+
+import "sync"
+
+var (
+	// Alternative clip ids mapped onto the clip ids they resolve to, mirrored
+	// from Redis so NormalizeAudioID can resolve them without a round trip
+	clipAliases    = make(map[string]string)
+	clipAliasMutex sync.RWMutex
+)
+
+// SetClipAliases replaces the in-memory alias table
+func SetClipAliases(aliases map[string]string) {
+	table := make(map[string]string, len(aliases))
+	for alias, audioID := range aliases {
+		table[alias] = audioID
+	}
+
+	clipAliasMutex.Lock()
+	defer clipAliasMutex.Unlock()
+	clipAliases = table
+}
+
+// resolveClipAlias returns the clip id an alias stands for, or the id itself
+func resolveClipAlias(audioID string) string {
+	clipAliasMutex.RLock()
+	defer clipAliasMutex.RUnlock()
+
+	if resolved, exists := clipAliases[audioID]; exists {
+		return resolved
+	}
+	return audioID
+}
@@ -0,0 +1,31 @@
+package services
+
+import "testing"
+
+func TestNormalizeAudioIDResolvesAliases(t *testing.T) {
+	defer SetClipAliases(nil)
+	aliases := map[string]string{"job-7": "clip-uuid"}
+	SetClipAliases(aliases)
+	aliases["late"] = "clip-uuid" // The table is copied, so later edits don't leak in
+
+	tests := []struct {
+		audioID string
+		want    string
+	}{
+		{"", GlobalAudioID},
+		{"job-7", "clip-uuid"},
+		{"clip-uuid", "clip-uuid"},
+		{"late", "late"},
+		{"JOB-7", "JOB-7"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeAudioID(tt.audioID); got != tt.want {
+			t.Errorf("NormalizeAudioID(%q) = %q, want %q", tt.audioID, got, tt.want)
+		}
+	}
+
+	SetClipAliases(nil)
+	if got := NormalizeAudioID("job-7"); got != "job-7" {
+		t.Errorf("after clearing the aliases, NormalizeAudioID(%q) = %q, want it unchanged", "job-7", got)
+	}
+}
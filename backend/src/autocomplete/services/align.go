@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"math"
+
+	"autocomplete/models"
+)
+
+// How a playback time was mapped to a word position
+const (
+	AlignTimestamp = "timestamp" // From the word's own timestamps
+	AlignSegment   = "segment"   // Interpolated within the audio segment holding the time
+)
+
+// ErrNoTiming is returned when a clip has neither word timestamps nor audio segments
+var ErrNoTiming = errors.New("clip has no word timestamps or audio segments")
+
+// ErrTimeNotCovered is returned when a playback time falls outside every audio segment
+var ErrTimeNotCovered = errors.New("no audio segment covers the requested time")
+
+// NormalizeWordTimestamps truncates timestamps to the baseline's word count and
+// repairs entries that end before they start
+func NormalizeWordTimestamps(timestamps []models.WordTimestamp, wordCount int) []models.WordTimestamp {
+	if len(timestamps) > wordCount {
+		timestamps = timestamps[:wordCount]
+	}
+	normalized := make([]models.WordTimestamp, len(timestamps))
+	for i, timestamp := range timestamps {
+		timestamp.StartTime = math.Max(0, timestamp.StartTime)
+		if timestamp.EndTime < timestamp.StartTime {
+			timestamp.EndTime = timestamp.StartTime
+		}
+		normalized[i] = timestamp
+	}
+	return normalized
+}
+
+// WordAtTime returns the word position spoken at playback time t and how it
+// was found. Word timestamps are preferred: the word whose span holds t, else
+// the last word to start before it (a pause belongs to the word before).
+// Without them, t is interpolated linearly across the words of the audio
+// segment covering it, whose id is returned too.
+func WordAtTime(data *models.AutocompleteData, t float64) (position int, method, segmentID string, err error) {
+	if len(data.WordTimestamps) > 0 {
+		position = 0
+		for i, timestamp := range data.WordTimestamps {
+			if timestamp.StartTime > t {
+				break
+			}
+			position = i
+			if t <= timestamp.EndTime {
+				break
+			}
+		}
+		return position, AlignTimestamp, "", nil
+	}
+	if len(data.Segments) == 0 {
+		return 0, "", "", ErrNoTiming
+	}
+
+	for _, segment := range data.Segments {
+		if t < segment.StartTime || t > segment.EndTime {
+			continue
+		}
+		words := segment.EndWord - segment.StartWord + 1
+		offset := 0
+		if duration := segment.EndTime - segment.StartTime; duration > 0 {
+			offset = int((t - segment.StartTime) / duration * float64(words))
+		}
+		if offset >= words {
+			offset = words - 1
+		}
+		return segment.StartWord + offset, AlignSegment, segment.ID, nil
+	}
+	return 0, "", "", ErrTimeNotCovered
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestNormalizeWordTimestamps(t *testing.T) {
+	tests := []struct {
+		name       string
+		timestamps []models.WordTimestamp
+		wordCount  int
+		want       []models.WordTimestamp
+	}{
+		{"none", nil, 2, []models.WordTimestamp{}},
+		{"kept", []models.WordTimestamp{{StartTime: 0, EndTime: 0.4}, {StartTime: 0.5, EndTime: 0.9}}, 2, []models.WordTimestamp{{StartTime: 0, EndTime: 0.4}, {StartTime: 0.5, EndTime: 0.9}}},
+		{"truncated", []models.WordTimestamp{{StartTime: 0, EndTime: 0.4}, {StartTime: 0.5, EndTime: 0.9}}, 1, []models.WordTimestamp{{StartTime: 0, EndTime: 0.4}}},
+		{"repaired", []models.WordTimestamp{{StartTime: -0.2, EndTime: -0.1}, {StartTime: 0.5, EndTime: 0.3}}, 2, []models.WordTimestamp{{StartTime: 0, EndTime: 0}, {StartTime: 0.5, EndTime: 0.5}}},
+	}
+	for _, tt := range tests {
+		if got := NormalizeWordTimestamps(tt.timestamps, tt.wordCount); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: NormalizeWordTimestamps() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWordAtTime(t *testing.T) {
+	timed := &models.AutocompleteData{WordTimestamps: []models.WordTimestamp{
+		{StartTime: 0.2, EndTime: 0.5},
+		{StartTime: 0.6, EndTime: 1.0},
+		{StartTime: 1.5, EndTime: 2.0},
+	}}
+	segmented := &models.AutocompleteData{Segments: []models.AudioSegment{
+		{ID: "intro", StartTime: 0, EndTime: 2, StartWord: 0, EndWord: 3},
+		{ID: "instant", StartTime: 3, EndTime: 3, StartWord: 4, EndWord: 5},
+	}}
+	tests := []struct {
+		name      string
+		data      *models.AutocompleteData
+		t         float64
+		want      int
+		method    string
+		segmentID string
+		wantErr   error
+	}{
+		{"before the first word", timed, 0, 0, AlignTimestamp, "", nil},
+		{"inside a word", timed, 0.7, 1, AlignTimestamp, "", nil},
+		{"at a word's end", timed, 1.0, 1, AlignTimestamp, "", nil},
+		{"in a pause", timed, 1.2, 1, AlignTimestamp, "", nil},
+		{"after the last word", timed, 9, 2, AlignTimestamp, "", nil},
+		{"segment start", segmented, 0, 0, AlignSegment, "intro", nil},
+		{"interpolated", segmented, 1.1, 2, AlignSegment, "intro", nil},
+		{"segment end", segmented, 2, 3, AlignSegment, "intro", nil},
+		{"zero-length segment", segmented, 3, 4, AlignSegment, "instant", nil},
+		{"between segments", segmented, 2.5, 0, "", "", ErrTimeNotCovered},
+		{"no timing", &models.AutocompleteData{}, 1, 0, "", "", ErrNoTiming},
+	}
+	for _, tt := range tests {
+		position, method, segmentID, err := WordAtTime(tt.data, tt.t)
+		if err != tt.wantErr || position != tt.want || method != tt.method || segmentID != tt.segmentID {
+			t.Errorf("%s: WordAtTime(%v) = %d, %q, %q, %v, want %d, %q, %q, %v", tt.name, tt.t, position, method, segmentID, err, tt.want, tt.method, tt.segmentID, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"autocomplete/models"
+)
+
+// Transcripts each cached clip was built from, guarded by cacheMutex
+var clipTranscripts = make(map[string]*models.AutocompleteData)
+
+// ClipTranscripts returns the (normalized and redacted) payload a cached clip was built from
+func ClipTranscripts(audioID string) (*models.AutocompleteData, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	if data, exists := clipTranscripts[audioID]; exists {
+		return data, nil
+	}
+	return nil, fmt.Errorf("no transcripts stored for clip %s, please initialize first", audioID)
+}
+
+// AlignSentences aligns every model's full transcript to the baseline using the
+// same positional alignment as the position map, marking each word as the
+// same as the baseline, changed, missing or extra. Models are listed by name.
+func AlignSentences(audioID string, data *models.AutocompleteData) *models.SentenceAlternatives {
+	tokens := Tokenize(data.FinalTranscription)
+	baselineWords := TranscriptWords(data.FinalTranscription)
+
+	modelNames := make([]string, 0, len(data.ASRAlternatives))
+	for model := range data.ASRAlternatives {
+		modelNames = append(modelNames, model)
+	}
+	sort.Strings(modelNames)
+
+	alternatives := &models.SentenceAlternatives{
+		AudioID:  NormalizeAudioID(audioID),
+		Baseline: data.FinalTranscription,
+		Tokens:   tokens,
+		Models:   make([]models.SentenceAlternative, 0, len(modelNames)),
+	}
+	for _, model := range modelNames {
+		transcript := data.ASRAlternatives[model]
+		modelWords := TranscriptWords(transcript)
+		alternative := models.SentenceAlternative{
+			Model:      model,
+			Transcript: transcript,
+			Words:      make([]models.AlignedWord, 0, len(baselineWords)),
+		}
+
+		aligned := alignToBaseline(baselineWords, modelWords)
+		for pos, baseWord := range baselineWords {
+			word, exists := aligned[pos]
+			diff := models.DiffSame
+			switch {
+			case !exists:
+				diff = models.DiffMissing
+			case word != baseWord:
+				diff = models.DiffChanged
+			}
+			if diff != models.DiffSame {
+				alternative.Changed++
+			}
+			alternative.Words = append(alternative.Words, models.AlignedWord{
+				Position: pos,
+				Word:     word,
+				Baseline: baseWord,
+				Diff:     diff,
+			})
+		}
+		for pos := len(baselineWords); pos < len(modelWords); pos++ {
+			alternative.Changed++
+			alternative.Words = append(alternative.Words, models.AlignedWord{
+				Position: pos,
+				Word:     modelWords[pos],
+				Diff:     models.DiffExtra,
+			})
+		}
+
+		alternatives.Models = append(alternatives.Models, alternative)
+	}
+
+	return alternatives
+}
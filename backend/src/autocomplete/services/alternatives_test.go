@@ -0,0 +1,50 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestAlignSentences(t *testing.T) {
+	data := &models.AutocompleteData{
+		FinalTranscription: "saya makan nasi.",
+		ASRAlternatives: map[string]string{
+			"whisper": "saya makan nasi",
+			"vosk":    "saya makna",
+			"wav2vec": "saya makan nasi lemak",
+		},
+	}
+	alternatives := AlignSentences("", data)
+
+	if alternatives.AudioID != GlobalAudioID || alternatives.Baseline != data.FinalTranscription {
+		t.Errorf("AlignSentences() = clip %q, baseline %q", alternatives.AudioID, alternatives.Baseline)
+	}
+
+	tests := []struct {
+		model   string
+		changed int
+		diffs   []string
+	}{
+		{"vosk", 2, []string{models.DiffSame, models.DiffChanged, models.DiffMissing}},
+		{"wav2vec", 1, []string{models.DiffSame, models.DiffSame, models.DiffSame, models.DiffExtra}},
+		{"whisper", 0, []string{models.DiffSame, models.DiffSame, models.DiffSame}},
+	}
+	if len(alternatives.Models) != len(tests) {
+		t.Fatalf("AlignSentences() listed %d models, want %d", len(alternatives.Models), len(tests))
+	}
+	for i, tt := range tests {
+		alternative := alternatives.Models[i]
+		var diffs []string
+		for pos, word := range alternative.Words {
+			diffs = append(diffs, word.Diff)
+			if word.Position != pos {
+				t.Errorf("%s: word %d has position %d", tt.model, pos, word.Position)
+			}
+		}
+		if alternative.Model != tt.model || alternative.Changed != tt.changed || !reflect.DeepEqual(diffs, tt.diffs) {
+			t.Errorf("model %d = %s, %d changed, %v, want %s, %d changed, %v", i, alternative.Model, alternative.Changed, diffs, tt.model, tt.changed, tt.diffs)
+		}
+	}
+}
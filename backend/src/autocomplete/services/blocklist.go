@@ -0,0 +1,83 @@
+package services
+
+import (
+	"strings"
+	"sync"
+
+	"autocomplete/models"
+)
+
+// DefaultTenant is the tenant used when a request does not name one
+const DefaultTenant = "default"
+
+// Per-tenant blocklists mirrored from Redis, replaced per tenant on every update
+var (
+	blocklists     = make(map[string]map[string]bool)
+	blocklistMutex sync.RWMutex
+)
+
+// NormalizeTenant maps an empty tenant onto the default tenant
+func NormalizeTenant(tenant string) string {
+	if tenant == "" {
+		return DefaultTenant
+	}
+	return tenant
+}
+
+// SetBlocklist replaces the in-memory blocklist of a tenant
+func SetBlocklist(tenant string, words []string) {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[strings.ToLower(word)] = true
+	}
+
+	blocklistMutex.Lock()
+	defer blocklistMutex.Unlock()
+
+	if len(set) == 0 {
+		delete(blocklists, NormalizeTenant(tenant))
+		return
+	}
+	blocklists[NormalizeTenant(tenant)] = set
+}
+
+// IsBlocked reports whether the tenant never wants the word suggested
+func IsBlocked(tenant, word string) bool {
+	blocklistMutex.RLock()
+	defer blocklistMutex.RUnlock()
+	return blocklists[NormalizeTenant(tenant)][strings.ToLower(word)]
+}
+
+// HasSuggestFilters reports whether suggest results may be filtered for the
+// tenant, so callers know to over-fetch candidates
+func HasSuggestFilters(tenant string) bool {
+	blocklistMutex.RLock()
+	_, hasBlocklist := blocklists[NormalizeTenant(tenant)]
+	blocklistMutex.RUnlock()
+
+	return hasBlocklist || ProfanityMode() != ProfanityOff
+}
+
+// ApplySuggestFilters removes the tenant's blocked words and applies the
+// profanity filter. Every suggestion backend must pass its results through here.
+func ApplySuggestFilters(tenant string, suggestions []models.WordSuggestion) []models.WordSuggestion {
+	filtered := make([]models.WordSuggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		if !IsBlocked(tenant, suggestion.Text) {
+			filtered = append(filtered, suggestion)
+		}
+	}
+	return FilterSuggestions(filtered)
+}
+
+// BlocklistTenants returns the tenants that currently have a blocklist
+func BlocklistTenants() []string {
+	blocklistMutex.RLock()
+	defer blocklistMutex.RUnlock()
+
+	tenants := make([]string, 0, len(blocklists))
+	for tenant := range blocklists {
+		tenants = append(tenants, tenant)
+	}
+	return tenants
+}
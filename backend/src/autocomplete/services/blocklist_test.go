@@ -0,0 +1,81 @@
+package services
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestBlocklists(t *testing.T) {
+	defer func() {
+		for _, tenant := range BlocklistTenants() {
+			SetBlocklist(tenant, nil)
+		}
+	}()
+
+	SetBlocklist("", []string{"Bodoh"})
+	SetBlocklist("school", []string{"babi", "celaka"})
+	SetBlocklist("cleared", []string{"makan"})
+	SetBlocklist("cleared", nil)
+
+	tests := []struct {
+		tenant string
+		word   string
+		want   bool
+	}{
+		{"", "bodoh", true},
+		{DefaultTenant, "BODOH", true},
+		{"", "babi", false},
+		{"school", "Babi", true},
+		{"school", "bodoh", false},
+		{"cleared", "makan", false},
+		{"unknown", "bodoh", false},
+	}
+	for _, tt := range tests {
+		if got := IsBlocked(tt.tenant, tt.word); got != tt.want {
+			t.Errorf("IsBlocked(%q, %q) = %v, want %v", tt.tenant, tt.word, got, tt.want)
+		}
+	}
+
+	tenants := BlocklistTenants()
+	sort.Strings(tenants)
+	if want := []string{DefaultTenant, "school"}; !reflect.DeepEqual(tenants, want) {
+		t.Errorf("BlocklistTenants() = %v, want %v", tenants, want)
+	}
+}
+
+func TestApplySuggestFilters(t *testing.T) {
+	defer SetBlocklist("school", nil)
+	defer ConfigureProfanityFilter(ProfanityOff, "")
+	SetBlocklist("school", []string{"babi"})
+
+	suggestions := []models.WordSuggestion{{Text: "Babi"}, {Text: "bahasa"}, {Text: "fuck"}}
+	tests := []struct {
+		tenant      string
+		profanity   string
+		want        []string
+		wantFilters bool
+	}{
+		{"school", ProfanityOff, []string{"bahasa", "fuck"}, true},
+		{"other", ProfanityOff, []string{"Babi", "bahasa", "fuck"}, false},
+		{"school", ProfanityDrop, []string{"bahasa"}, true},
+		{"other", ProfanityDrop, []string{"Babi", "bahasa"}, true},
+	}
+	for _, tt := range tests {
+		if err := ConfigureProfanityFilter(tt.profanity, ""); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, suggestion := range ApplySuggestFilters(tt.tenant, suggestions) {
+			got = append(got, suggestion.Text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s with profanity %s: ApplySuggestFilters() = %v, want %v", tt.tenant, tt.profanity, got, tt.want)
+		}
+		if filters := HasSuggestFilters(tt.tenant); filters != tt.wantFilters {
+			t.Errorf("%s with profanity %s: HasSuggestFilters() = %v, want %v", tt.tenant, tt.profanity, filters, tt.wantFilters)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"strings"
+
+	"autocomplete/models"
+)
+
+// ClipStats characterizes a clip's position map: its length and vocabulary,
+// the share of positions each ASR model produced a candidate at, the mean
+// agreement of the baseline words and how many of them are particles
+func ClipStats(positionMap models.PositionMap, particles map[string]bool) *models.ClipStats {
+	stats := &models.ClipStats{
+		Words:         len(positionMap),
+		ModelCoverage: make(map[string]float64),
+	}
+	if len(positionMap) == 0 {
+		return stats
+	}
+
+	vocabulary := make(map[string]bool)
+	var agreement int
+	for _, candidates := range positionMap {
+		sources := make(map[string]bool)
+		for _, candidate := range candidates {
+			vocabulary[strings.ToLower(candidate.Text)] = true
+			if candidate.Source != "" {
+				sources[candidate.Source] = true
+			}
+		}
+		for source := range sources {
+			stats.ModelCoverage[source]++
+		}
+
+		baseline, _ := BaselineWord(candidates)
+		for _, candidate := range candidates {
+			if candidate.Text == baseline {
+				agreement += candidate.Agreement
+				break
+			}
+		}
+		if particles[strings.ToLower(baseline)] {
+			stats.Particles++
+		}
+	}
+
+	stats.Vocabulary = len(vocabulary)
+	for source, positions := range stats.ModelCoverage {
+		stats.ModelCoverage[source] = positions / float64(len(positionMap))
+	}
+	stats.AverageAgreement = float64(agreement) / float64(len(positionMap))
+	return stats
+}
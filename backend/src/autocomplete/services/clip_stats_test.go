@@ -0,0 +1,53 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+// agreed is a candidate the given number of models agreed on
+func agreed(text, source string, rank, agreement int) models.WordSuggestion {
+	suggestion := candidate(text, source, 0.5, rank)
+	suggestion.Agreement = agreement
+	return suggestion
+}
+
+func TestClipStats(t *testing.T) {
+	particles := map[string]bool{"lah": true, "kan": true}
+	tests := []struct {
+		name          string
+		positionMap   models.PositionMap
+		wantWords     int
+		wantVocab     int
+		wantCoverage  map[string]float64
+		wantAgreement float64
+		wantParticles int
+	}{
+		{"empty", nil, 0, 0, map[string]float64{}, 0, 0},
+		{"one model", models.PositionMap{
+			0: {agreed("Pergi", "whisper", 1, 1)},
+			1: {agreed("lah", "whisper", 1, 1)},
+		}, 2, 2, map[string]float64{"whisper": 1}, 1, 1},
+		{"models disagree", models.PositionMap{
+			0: {agreed("makan", "whisper", 1, 2), agreed("makan", "wav2vec", 1, 2)},
+			1: {agreed("Lah", "whisper", 1, 1), agreed("la", "wav2vec", 2, 1)},
+			2: {agreed("nasi", "whisper", 1, 1)},
+			3: {agreed("makan", "whisper", 1, 3), agreed("Makan", "wav2vec", 2, 1)},
+		}, 4, 4, map[string]float64{"whisper": 1, "wav2vec": 0.75}, 7.0 / 4, 1},
+	}
+	for _, tt := range tests {
+		stats := ClipStats(tt.positionMap, particles)
+		if stats.Words != tt.wantWords || stats.Vocabulary != tt.wantVocab || stats.Particles != tt.wantParticles {
+			t.Errorf("%s: %d words, %d vocabulary, %d particles, want %d, %d, %d", tt.name, stats.Words, stats.Vocabulary, stats.Particles, tt.wantWords, tt.wantVocab, tt.wantParticles)
+		}
+		if !reflect.DeepEqual(stats.ModelCoverage, tt.wantCoverage) {
+			t.Errorf("%s: coverage %v, want %v", tt.name, stats.ModelCoverage, tt.wantCoverage)
+		}
+		if math.Abs(stats.AverageAgreement-tt.wantAgreement) > 1e-9 {
+			t.Errorf("%s: agreement %v, want %v", tt.name, stats.AverageAgreement, tt.wantAgreement)
+		}
+	}
+}
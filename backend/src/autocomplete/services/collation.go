@@ -0,0 +1,46 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Collations alphabetical listings can be ordered by. Chinese words order by
+// their pinyin readings or by stroke count.
+const (
+	CollationMalay   = "ms"
+	CollationEnglish = "en"
+	CollationPinyin  = "zh-pinyin"
+	CollationStroke  = "zh-stroke"
+)
+
+// Collations lists the supported collation names
+var Collations = []string{CollationMalay, CollationEnglish, CollationPinyin, CollationStroke}
+
+var collationTags = map[string]language.Tag{
+	CollationMalay:   language.Malay,
+	CollationEnglish: language.English,
+	CollationPinyin:  language.MustParse("zh-u-co-pinyin"),
+	CollationStroke:  language.MustParse("zh-u-co-stroke"),
+}
+
+// NewCollator returns a collator for a collation name. Collators are not safe
+// for concurrent use, so callers make one per request.
+func NewCollator(name string) (*collate.Collator, error) {
+	tag, exists := collationTags[name]
+	if !exists {
+		return nil, fmt.Errorf("collation must be one of %s", strings.Join(Collations, ", "))
+	}
+	return collate.New(tag, collate.IgnoreWidth), nil
+}
+
+// LanguageCollation picks the collation for a language code, pinyin for Chinese
+func LanguageCollation(lang string) string {
+	if lang == LangChinese {
+		return CollationPinyin
+	}
+	return lang
+}
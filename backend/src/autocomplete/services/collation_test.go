@@ -0,0 +1,55 @@
+package services
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNewCollator(t *testing.T) {
+	tests := []struct {
+		name    string
+		words   []string
+		want    []string
+		wantErr bool
+	}{
+		{CollationMalay, []string{"Zaman", "baju", "Apa", "abang"}, []string{"abang", "Apa", "baju", "Zaman"}, false},
+		{CollationEnglish, []string{"zebra", "éclair", "apple"}, []string{"apple", "éclair", "zebra"}, false},
+		{CollationPinyin, []string{"中", "北", "爱"}, []string{"爱", "北", "中"}, false},
+		{CollationStroke, []string{"爱", "北", "中"}, []string{"中", "北", "爱"}, false},
+		{"fr", nil, nil, true},
+		{"", nil, nil, true},
+	}
+	for _, tt := range tests {
+		collator, err := NewCollator(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewCollator(%q) error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		got := append([]string(nil), tt.words...)
+		sort.Slice(got, func(i, j int) bool { return collator.CompareString(got[i], got[j]) < 0 })
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: sorted = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageCollation(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{LangMalay, CollationMalay},
+		{LangEnglish, CollationEnglish},
+		{LangChinese, CollationPinyin},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := LanguageCollation(tt.lang); got != tt.want {
+			t.Errorf("LanguageCollation(%q) = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
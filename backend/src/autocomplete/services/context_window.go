@@ -0,0 +1,113 @@
+package services
+
+import (
+	"math"
+	"strings"
+)
+
+// Context-window fit: a candidate gains, for every n-gram it completes with
+// the accepted words around it, the n-gram's weight scaled down until the
+// n-gram has minContextSightings. Trigrams weigh more than bigrams.
+const (
+	bigramFitWeight     = 0.1
+	trigramFitWeight    = 0.15
+	minContextSightings = 3
+)
+
+// contextWindowWords is how many accepted words on each side of the target count
+const contextWindowWords = 2
+
+// ContextWindow holds the accepted words around the word being typed,
+// lower-cased. Left ends with the word just before it, Right starts with the
+// word just after it.
+type ContextWindow struct {
+	Left  []string
+	Right []string
+}
+
+// ParseContextWindow reads the accepted words on each side of the target,
+// keeping the two nearest on each side
+func ParseContextWindow(left, right string) ContextWindow {
+	window := ContextWindow{
+		Left:  lowerWords(TranscriptWords(left)),
+		Right: lowerWords(TranscriptWords(right)),
+	}
+	if len(window.Left) > contextWindowWords {
+		window.Left = window.Left[len(window.Left)-contextWindowWords:]
+	}
+	if len(window.Right) > contextWindowWords {
+		window.Right = window.Right[:contextWindowWords]
+	}
+	return window
+}
+
+func lowerWords(words []string) []string {
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return words
+}
+
+// Empty reports whether the window holds no words
+func (w ContextWindow) Empty() bool {
+	return len(w.Left) == 0 && len(w.Right) == 0
+}
+
+// ContextNgram is an n-gram of the window a candidate would complete: Next
+// following the words of Context
+type ContextNgram struct {
+	Context string // Space-separated words, one for a bigram and two for a trigram
+	Next    string
+}
+
+// Ngrams returns the bigrams and trigrams that place candidate within the window
+func (w ContextWindow) Ngrams(candidate string) []ContextNgram {
+	candidate = strings.ToLower(candidate)
+	words := append(append(append([]string{}, w.Left...), candidate), w.Right...)
+	target := len(w.Left)
+
+	var ngrams []ContextNgram
+	for n := 2; n <= 3; n++ {
+		for start := 0; start+n <= len(words); start++ {
+			if target < start || target >= start+n {
+				continue // Only n-grams containing the candidate tell how well it fits
+			}
+			ngrams = append(ngrams, ContextNgram{
+				Context: strings.Join(words[start:start+n-1], " "),
+				Next:    words[start+n-1],
+			})
+		}
+	}
+	return ngrams
+}
+
+// ContextFit scores how well a candidate fits its window from the counts of
+// the n-grams Ngrams returned for it, in the same order
+func ContextFit(ngrams []ContextNgram, counts []float64) float64 {
+	fit := 0.0
+	for i, ngram := range ngrams {
+		weight := bigramFitWeight
+		if strings.Contains(ngram.Context, " ") {
+			weight = trigramFitWeight
+		}
+		fit += weight * math.Min(1, counts[i]/minContextSightings)
+	}
+	return fit
+}
+
+// NgramCounts counts the bigrams and trigrams of a transcript's words,
+// lower-cased, as context → next word → count
+func NgramCounts(words []string) map[string]map[string]float64 {
+	words = lowerWords(append([]string(nil), words...))
+	counts := make(map[string]map[string]float64)
+	for n := 2; n <= 3; n++ {
+		for start := 0; start+n <= len(words); start++ {
+			preceding := strings.Join(words[start:start+n-1], " ")
+			if counts[preceding] == nil {
+				counts[preceding] = make(map[string]float64)
+			}
+			counts[preceding][words[start+n-1]]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseContextWindow(t *testing.T) {
+	tests := []struct {
+		left, right string
+		wantLeft    []string
+		wantRight   []string
+	}{
+		{"", "", nil, nil},
+		{"Saya", "", []string{"saya"}, nil},
+		{"", "nasi.", nil, []string{"nasi"}},
+		{"kelmarin saya pergi", "Nasi lemak sedap", []string{"saya", "pergi"}, []string{"nasi", "lemak"}},
+	}
+	for _, tt := range tests {
+		got := ParseContextWindow(tt.left, tt.right)
+		// An empty side may be nil or empty
+		if fmt.Sprint(got.Left) != fmt.Sprint(tt.wantLeft) || fmt.Sprint(got.Right) != fmt.Sprint(tt.wantRight) {
+			t.Errorf("ParseContextWindow(%q, %q) = %v %v, want %v %v", tt.left, tt.right, got.Left, got.Right, tt.wantLeft, tt.wantRight)
+		}
+		if wantEmpty := len(tt.wantLeft)+len(tt.wantRight) == 0; got.Empty() != wantEmpty {
+			t.Errorf("ParseContextWindow(%q, %q).Empty() = %v, want %v", tt.left, tt.right, got.Empty(), wantEmpty)
+		}
+	}
+}
+
+func TestContextWindowNgrams(t *testing.T) {
+	tests := []struct {
+		window ContextWindow
+		want   []ContextNgram
+	}{
+		{ContextWindow{}, nil},
+		{ContextWindow{Left: []string{"saya"}}, []ContextNgram{{"saya", "makan"}}},
+		{ContextWindow{Right: []string{"nasi"}}, []ContextNgram{{"makan", "nasi"}}},
+		{ContextWindow{Left: []string{"saya", "mahu"}, Right: []string{"nasi", "lemak"}}, []ContextNgram{
+			{"mahu", "makan"},
+			{"makan", "nasi"},
+			{"saya mahu", "makan"},
+			{"mahu makan", "nasi"},
+			{"makan nasi", "lemak"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := tt.window.Ngrams("Makan"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.Ngrams(Makan) = %v, want %v", tt.window, got, tt.want)
+		}
+	}
+}
+
+func TestContextFit(t *testing.T) {
+	ngrams := []ContextNgram{{"mahu", "makan"}, {"saya mahu", "makan"}}
+	tests := []struct {
+		counts []float64
+		want   float64
+	}{
+		{[]float64{0, 0}, 0},
+		{[]float64{minContextSightings, 0}, bigramFitWeight},
+		{[]float64{0, 10}, trigramFitWeight},
+		{[]float64{1, 1}, (bigramFitWeight + trigramFitWeight) / minContextSightings},
+	}
+	for _, tt := range tests {
+		if got := ContextFit(ngrams, tt.counts); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("ContextFit(%v) = %v, want %v", tt.counts, got, tt.want)
+		}
+	}
+}
+
+func TestNgramCounts(t *testing.T) {
+	words := []string{"Saya", "makan", "saya", "makan"}
+	want := map[string]map[string]float64{
+		"saya":       {"makan": 2},
+		"makan":      {"saya": 1},
+		"saya makan": {"saya": 1},
+		"makan saya": {"makan": 1},
+	}
+	if got := NgramCounts(words); !reflect.DeepEqual(got, want) {
+		t.Errorf("NgramCounts(%v) = %v, want %v", words, got, want)
+	}
+	if words[0] != "Saya" {
+		t.Errorf("NgramCounts changed its input to %v", words)
+	}
+}
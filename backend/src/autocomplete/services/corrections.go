@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"autocomplete/models"
+)
+
+// Confidence shifts applied by a correction: the user's word becomes the
+// position's baseline with full confidence, and the word it replaced is demoted
+const (
+	CorrectionSource     = "user_correction"
+	correctionConfidence = 1.0
+	replacedWordFactor   = 0.5
+)
+
+// Correction failures callers map onto response statuses
+var (
+	ErrCorrectionConflict = errors.New("old word does not match the current baseline")
+	ErrPositionNotFound   = errors.New("clip position not found")
+)
+
+// CorrectPosition returns a position's candidates with newWord as the baseline.
+// The previous baseline, which must be oldWord (case-insensitively), drops to
+// rank 2 at half its confidence; a candidate already spelling newWord is merged
+// into the new baseline, keeping its agreement.
+func CorrectPosition(candidates []models.WordSuggestion, oldWord, newWord string) ([]models.WordSuggestion, error) {
+	if baseline, ok := BaselineWord(candidates); ok && !strings.EqualFold(baseline, oldWord) {
+		return nil, fmt.Errorf("%w: position reads %q", ErrCorrectionConflict, baseline)
+	}
+
+	corrected := models.WordSuggestion{
+		Text:       newWord,
+		Confidence: correctionConfidence,
+		Source:     CorrectionSource,
+		Rank:       1,
+	}
+	rest := make([]models.WordSuggestion, 0, len(candidates))
+	for _, candidate := range candidates {
+		switch {
+		case candidate.Text == newWord:
+			corrected.Agreement = candidate.Agreement
+			continue
+		case candidate.Rank == 1 || strings.EqualFold(candidate.Text, oldWord):
+			candidate.Rank = 2
+			candidate.Confidence *= replacedWordFactor
+		}
+		rest = append(rest, candidate)
+	}
+
+	return append([]models.WordSuggestion{corrected}, rest...), nil
+}
+
+// ApplyCorrection replaces the baseline word at a position of a cached clip,
+// then rebuilds the clip's trie and bigrams from the updated position map and
+// records a "replace" version. The stored baseline transcript gets the new word
+// with its punctuation and spacing kept. It returns the position's new candidates.
+func ApplyCorrection(audioID string, position int, oldWord, newWord string) ([]models.WordSuggestion, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	positionMap, exists := clipPositions[audioID]
+	if !exists {
+		return nil, fmt.Errorf("%w: clip %s is not initialized", ErrPositionNotFound, audioID)
+	}
+
+	// During a review session the correction applies to the unboosted positions
+	session := clipSessions[audioID]
+	if session != nil {
+		positionMap = session.base
+	}
+
+	// Readers may hold the old map, so the update goes into a copy
+	updated, err := CorrectPositionMap(positionMap, position, oldWord, newWord)
+	if err != nil {
+		return nil, fmt.Errorf("clip %s: %w", audioID, err)
+	}
+
+	if session != nil {
+		session.base = updated
+		updated, _ = session.rerank()
+	}
+	refreshClip(audioID, updated)
+	if data, exists := clipTranscripts[audioID]; exists {
+		if corrected, err := CorrectTranscript(data, position, newWord); err == nil {
+			clipTranscripts[audioID] = corrected
+		}
+	}
+	recordVersion(audioID, "replace", clipTries[audioID])
+
+	return updated[position], nil
+}
+
+// CorrectPositionMap returns a copy of the position map with the correction
+// applied at position, leaving the original untouched
+func CorrectPositionMap(positionMap models.PositionMap, position int, oldWord, newWord string) (models.PositionMap, error) {
+	candidates, exists := positionMap[position]
+	if !exists {
+		return nil, fmt.Errorf("%w: no word at position %d", ErrPositionNotFound, position)
+	}
+
+	corrected, err := CorrectPosition(candidates, oldWord, newWord)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make(models.PositionMap, len(positionMap))
+	for pos, existing := range positionMap {
+		updated[pos] = existing
+	}
+	updated[position] = corrected
+	return updated, nil
+}
+
+// CorrectTranscript returns a copy of the payload with the baseline word at
+// position replaced, leaving punctuation, spacing and the model transcripts as
+// they were
+func CorrectTranscript(data *models.AutocompleteData, position int, newWord string) (*models.AutocompleteData, error) {
+	transcription, err := ReplaceTranscriptWord(data.FinalTranscription, position, newWord)
+	if err != nil {
+		return nil, err
+	}
+	corrected := *data
+	corrected.FinalTranscription = transcription
+	return &corrected, nil
+}
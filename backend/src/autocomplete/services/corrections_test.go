@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestCorrectPosition(t *testing.T) {
+	candidates := []models.WordSuggestion{
+		{Text: "makna", Confidence: 0.9, Source: "gemini_final", Rank: 1, Agreement: 1},
+		{Text: "makan", Confidence: 0.7, Source: "whisper", Rank: 2, Agreement: 3},
+		{Text: "makam", Confidence: 0.6, Source: "vosk", Rank: 2, Agreement: 1},
+	}
+
+	tests := []struct {
+		name    string
+		oldWord string
+		newWord string
+		want    []models.WordSuggestion
+		wantErr error
+	}{
+		{"alternative promoted", "makna", "makan", []models.WordSuggestion{
+			{Text: "makan", Confidence: correctionConfidence, Source: CorrectionSource, Rank: 1, Agreement: 3},
+			{Text: "makna", Confidence: 0.45, Source: "gemini_final", Rank: 2, Agreement: 1},
+			{Text: "makam", Confidence: 0.6, Source: "vosk", Rank: 2, Agreement: 1},
+		}, nil},
+		{"new word, old word in another case", "MAKNA", "makanan", []models.WordSuggestion{
+			{Text: "makanan", Confidence: correctionConfidence, Source: CorrectionSource, Rank: 1},
+			{Text: "makna", Confidence: 0.45, Source: "gemini_final", Rank: 2, Agreement: 1},
+			{Text: "makan", Confidence: 0.7, Source: "whisper", Rank: 2, Agreement: 3},
+			{Text: "makam", Confidence: 0.6, Source: "vosk", Rank: 2, Agreement: 1},
+		}, nil},
+		{"stale old word", "makan", "makam", nil, ErrCorrectionConflict},
+	}
+	for _, tt := range tests {
+		got, err := CorrectPosition(candidates, tt.oldWord, tt.newWord)
+		if !errors.Is(err, tt.wantErr) || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: CorrectPosition() = %+v, %v, want %+v, %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+	if candidates[0].Rank != 1 || candidates[0].Confidence != 0.9 {
+		t.Errorf("CorrectPosition changed its input: %+v", candidates[0])
+	}
+}
+
+func TestCorrectPositionMap(t *testing.T) {
+	positionMap := models.PositionMap{
+		0: {{Text: "saya", Confidence: 0.9, Rank: 1}},
+		1: {{Text: "makna", Confidence: 0.9, Rank: 1}},
+	}
+
+	updated, err := CorrectPositionMap(positionMap, 1, "makna", "makan")
+	if err != nil {
+		t.Fatalf("CorrectPositionMap() error: %v", err)
+	}
+	if word, _ := BaselineWord(updated[1]); word != "makan" || &updated[0][0] != &positionMap[0][0] {
+		t.Errorf("CorrectPositionMap() = %+v, want makan at 1 and position 0 shared", updated)
+	}
+	if word, _ := BaselineWord(positionMap[1]); word != "makna" {
+		t.Errorf("CorrectPositionMap changed the original map: %+v", positionMap)
+	}
+	if _, err := CorrectPositionMap(positionMap, 5, "x", "y"); !errors.Is(err, ErrPositionNotFound) {
+		t.Errorf("CorrectPositionMap() past the end = %v, want ErrPositionNotFound", err)
+	}
+}
+
+func TestApplyCorrection(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "Saya makna nasi.", ConfidenceScore: 0.9})
+
+	candidates, err := ApplyCorrection("clip", 1, "makna", "makan")
+	if err != nil {
+		t.Fatalf("ApplyCorrection() error: %v", err)
+	}
+	if word, _ := BaselineWord(candidates); word != "makan" {
+		t.Errorf("ApplyCorrection() candidates = %+v", candidates)
+	}
+
+	cacheMutex.RLock()
+	transcript := clipTranscripts["clip"].FinalTranscription
+	cacheMutex.RUnlock()
+	if transcript != "Saya makan nasi." {
+		t.Errorf("transcript after the correction = %q", transcript)
+	}
+
+	trie, _ := GetPrefixTrie("clip")
+	if got := trie.Search("maka", 5); !reflect.DeepEqual(got, []string{"makan"}) {
+		t.Errorf("trie after the correction: Search(maka) = %v", got)
+	}
+	bigrams, _ := ClipBigrams("clip")
+	if got := bigrams.Followers("makan"); !reflect.DeepEqual(got, []string{"nasi"}) {
+		t.Errorf("bigrams after the correction: Followers(makan) = %v", got)
+	}
+
+	tests := []struct {
+		audioID  string
+		position int
+		oldWord  string
+		want     error
+	}{
+		{"clip", 1, "makna", ErrCorrectionConflict}, // Already corrected
+		{"clip", 9, "nasi", ErrPositionNotFound},
+		{"other", 0, "saya", ErrPositionNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := ApplyCorrection(tt.audioID, tt.position, tt.oldWord, "baru"); !errors.Is(err, tt.want) {
+			t.Errorf("ApplyCorrection(%s, %d, %s) = %v, want %v", tt.audioID, tt.position, tt.oldWord, err, tt.want)
+		}
+	}
+}
+
+func TestBigrams(t *testing.T) {
+	positionMap := models.PositionMap{
+		0: {{Text: "saya", Rank: 1}},
+		1: {{Text: "makan", Rank: 1}},
+		2: {{Text: "saya", Rank: 1}},
+		3: {{Text: "minum", Rank: 2}, {Text: "makan", Rank: 1}},
+		4: {},
+		5: {{Text: "saya", Rank: 1}},
+	}
+	bigrams := BuildBigrams(positionMap)
+
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"saya", []string{"makan"}},
+		{"makan", []string{"saya"}},
+		{"nasi", []string{}},
+	}
+	for _, tt := range tests {
+		if got := bigrams.Followers(tt.word); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Followers(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+	if bigrams["saya"]["makan"] != 2 {
+		t.Errorf("saya makan counted %d times, want 2", bigrams["saya"]["makan"])
+	}
+}
@@ -1,52 +1,146 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"autocomplete/models"
 )
 
-// In-memory cache for single global trie (replace with Redis in production)
+// GlobalAudioID is the clip id used when a request does not name a clip
+const GlobalAudioID = "global"
+
+// ErrClipNotInitialized is returned when a clip's index is missing, e.g.
+// before its first initialize or after it expired
+var ErrClipNotInitialized = errors.New("autocomplete not initialized")
+
+// In-memory cache of per-clip tries and position maps (replace with Redis in production)
 var (
-	globalPrefixTrie *models.PrefixTrie
-	cacheMutex       sync.RWMutex
+	clipTries     = make(map[string]*models.PrefixTrie)
+	clipPositions = make(map[string]models.PositionMap)
+	cacheMutex    sync.RWMutex
 )
 
-// BuildAndCacheData builds the PrefixTrie from the provided data and caches it globally.
+// NormalizeAudioID maps an empty clip id onto the global clip, and an alias
+// onto the clip it was registered for
+func NormalizeAudioID(audioID string) string {
+	if audioID == "" {
+		return GlobalAudioID
+	}
+	return resolveClipAlias(audioID)
+}
+
+// BuildAndCacheData builds the PrefixTrie from the provided data and caches it for the clip.
 // This is called by the /initialize endpoint.
-func BuildAndCacheData(data *models.AutocompleteData) {
-	fmt.Println("DEBUG: BuildAndCacheData called") // ADDED
-	// Build the data structure
-	trie := BuildDataStructures(data)
+func BuildAndCacheData(audioID string, data *models.AutocompleteData) {
+	BuildAndCacheDataWithPrior(audioID, data, nil)
+}
+
+// BuildAndCacheDataWithPrior is BuildAndCacheData with the clip's candidates
+// first ranked by what the verified corpus knows about them
+func BuildAndCacheDataWithPrior(audioID string, data *models.AutocompleteData, prior *VerifiedPrior) {
+	audioID = NormalizeAudioID(audioID)
 
-	// Cache the result globally
+	// Build the data structures
+	positionMap := ApplyVerifiedPrior(BuildPositionMap(data), prior)
+	trie := buildTrie(positionMap)
+	trie.AudioClipID = audioID
+
+	// Cache the result for the clip
 	cacheMutex.Lock()
-	globalPrefixTrie = trie
+	if audioID == GlobalAudioID {
+		applySeed(trie)
+	}
+	if _, exists := clipTries[audioID]; exists {
+		atomic.AddInt64(&cacheRebuilds, 1) // Rebuild replaces the previous trie
+	}
+	clipTries[audioID] = trie
+	setClipPositions(audioID, positionMap)
+	clipTranscripts[audioID] = data
+	delete(clipSessions, audioID)
+	recordVersion(audioID, "initialize", trie)
 	cacheMutex.Unlock()
-	fmt.Println("DEBUG: Global PrefixTrie cached") // ADDED
 }
 
-// GetPrefixTrie retrieves the global prefix trie from the cache.
+// GetPrefixTrie retrieves a snapshot of the clip's prefix trie from the cache.
+// The snapshot stays consistent while seeds or restores insert into the cached
+// trie, so callers can traverse it without holding any lock.
 // This is called by the /suggest/prefix endpoint.
-func GetPrefixTrie() (*models.PrefixTrie, error) {
-	fmt.Println("DEBUG: GetPrefixTrie called") // ADDED
+func GetPrefixTrie(audioID string) (*models.PrefixTrie, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	if trie, exists := clipTries[audioID]; exists {
+		atomic.AddInt64(&cacheHits, 1)
+		return trie.Snapshot(), nil
+	}
+
+	atomic.AddInt64(&cacheMisses, 1)
+	return nil, fmt.Errorf("%w for clip %s, please initialize first", ErrClipNotInitialized, audioID)
+}
+
+// GetPositionMap retrieves the clip's position map from the cache
+func GetPositionMap(audioID string) (models.PositionMap, error) {
+	audioID = NormalizeAudioID(audioID)
+
 	cacheMutex.RLock()
 	defer cacheMutex.RUnlock()
 
-	if globalPrefixTrie != nil {
-		fmt.Println("DEBUG: Global PrefixTrie found in cache") // ADDED
-		return globalPrefixTrie, nil
+	if positionMap, exists := clipPositions[audioID]; exists {
+		return positionMap, nil
+	}
+	return nil, fmt.Errorf("%w for clip %s, please initialize first", ErrClipNotInitialized, audioID)
+}
+
+// PurgeClip removes the clip's trie, position map and version history, live or
+// soft-deleted, reporting whether the clip was cached
+func PurgeClip(audioID string) bool {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	_, existed := clipTries[audioID]
+	if existed {
+		atomic.AddInt64(&cacheEvictions, 1)
+	}
+	delete(clipTries, audioID)
+	setClipPositions(audioID, nil)
+	delete(clipTranscripts, audioID)
+	delete(clipSessions, audioID)
+	delete(clipVersions, audioID)
+	delete(clipStamps, audioID)
+	if _, deleted := deletedClips[audioID]; deleted {
+		delete(deletedClips, audioID)
+		existed = true
 	}
 
-	fmt.Println("DEBUG: Global PrefixTrie NOT found in cache") // ADDED
-	return nil, fmt.Errorf("autocomplete not initialized, please initialize first")
+	return existed
 }
 
-// ClearCache clears all cached data (useful for testing)
-func ClearCache() {
+// ResetCache clears all cached clips, version history and cache counters
+func ResetCache() {
 	cacheMutex.Lock()
 	defer cacheMutex.Unlock()
 
-	globalPrefixTrie = nil
-}
\ No newline at end of file
+	clipTries = make(map[string]*models.PrefixTrie)
+	clipPositions = make(map[string]models.PositionMap)
+	clipBigrams = make(map[string]Bigrams)
+	clipOccurrences = make(map[string]Occurrences)
+	clipLanguageTries = make(map[string]map[string]*models.PrefixTrie)
+	clipSessions = make(map[string]*clipSession)
+	clipTranscripts = make(map[string]*models.AutocompleteData)
+	clipVersions = make(map[string][]*indexVersion)
+	clipStamps = make(map[string]models.SyncStamp)
+	deletedClips = make(map[string]*deletedClip)
+	syncVector = map[string]int{syncOrigin: syncSeq} // Peers must resend everything
+	seedSuggestions = nil
+	atomic.StoreInt64(&cacheHits, 0)
+	atomic.StoreInt64(&cacheMisses, 0)
+	atomic.StoreInt64(&cacheEvictions, 0)
+	atomic.StoreInt64(&cacheRebuilds, 0)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"autocomplete/models"
+)
+
+// DictionarySource marks suggestions drawn from the packaged word lists
+const DictionarySource = "dictionary"
+
+// The packaged word lists with their seed weights, loaded on first use
+var (
+	dictionaryOnce  sync.Once
+	dictionaryWords []models.WordSuggestion
+	dictionaryErr   error
+)
+
+// DictionaryMatches returns up to limit words of the packaged Malay and English
+// word lists starting with prefix (case-insensitively), by seed weight. Unlike
+// the seed corpus these are looked up on demand, so the dictionary can serve
+// words the index has never ingested.
+func DictionaryMatches(prefix string, limit int) ([]models.WordSuggestion, error) {
+	dictionaryOnce.Do(func() {
+		dictionaryWords, dictionaryErr = builtinSeedCorpus()
+	})
+	if dictionaryErr != nil {
+		return nil, dictionaryErr
+	}
+
+	prefix = strings.ToLower(prefix)
+	var matches []models.WordSuggestion
+	for _, word := range dictionaryWords {
+		if strings.HasPrefix(strings.ToLower(word.Text), prefix) {
+			word.Source = DictionarySource
+			matches = append(matches, word)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Confidence > matches[j].Confidence
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
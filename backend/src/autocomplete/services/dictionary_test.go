@@ -0,0 +1,36 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDictionaryMatches(t *testing.T) {
+	tests := []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"mak", 10, []string{"makan"}},
+		{"MAK", 10, []string{"makan"}},
+		{"the", 10, []string{"they", "the"}}, // Stopwords weigh less than listed words
+		{"the", 1, []string{"they"}},
+		{"zzq", 10, nil},
+	}
+	for _, tt := range tests {
+		matches, err := DictionaryMatches(tt.prefix, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, match := range matches {
+			got = append(got, match.Text)
+			if match.Source != DictionarySource {
+				t.Errorf("DictionaryMatches(%q): %s has source %q, want %q", tt.prefix, match.Text, match.Source, DictionarySource)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DictionaryMatches(%q, %d) = %v, want %v", tt.prefix, tt.limit, got, tt.want)
+		}
+	}
+}
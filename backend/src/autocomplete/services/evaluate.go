@@ -0,0 +1,62 @@
+package services
+
+import (
+	"strconv"
+
+	"autocomplete/models"
+)
+
+// hitRateCutoffs are the ranks hit rate is reported at, besides k itself
+var hitRateCutoffs = []int{1, 3, 5, 10}
+
+// HitRateCutoffs returns the cutoffs up to k that hit rate is reported at
+func HitRateCutoffs(k int) []int {
+	var cutoffs []int
+	for _, cutoff := range hitRateCutoffs {
+		if cutoff < k {
+			cutoffs = append(cutoffs, cutoff)
+		}
+	}
+	return append(cutoffs, k)
+}
+
+// RankMetrics computes hit rate at each cutoff, mean reciprocal rank and ASR
+// coverage over evaluated positions
+func RankMetrics(positions []models.EvaluatedPosition, cutoffs []int) models.RankingMetrics {
+	metrics := models.RankingMetrics{
+		Words:   len(positions),
+		HitRate: make(map[string]float64, len(cutoffs)),
+	}
+	for _, cutoff := range cutoffs {
+		metrics.HitRate["@"+strconv.Itoa(cutoff)] = 0
+	}
+	if len(positions) == 0 {
+		return metrics
+	}
+
+	var reciprocal float64
+	var covered int
+	hits := make([]int, len(cutoffs))
+	for _, position := range positions {
+		if position.Covered {
+			covered++
+		}
+		if position.Rank == 0 {
+			continue
+		}
+		reciprocal += 1 / float64(position.Rank)
+		for i, cutoff := range cutoffs {
+			if position.Rank <= cutoff {
+				hits[i]++
+			}
+		}
+	}
+
+	total := float64(len(positions))
+	for i, cutoff := range cutoffs {
+		metrics.HitRate["@"+strconv.Itoa(cutoff)] = float64(hits[i]) / total
+	}
+	metrics.MRR = reciprocal / total
+	metrics.Coverage = float64(covered) / total
+	return metrics
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestHitRateCutoffs(t *testing.T) {
+	tests := []struct {
+		k    int
+		want []int
+	}{
+		{1, []int{1}},
+		{3, []int{1, 3}},
+		{5, []int{1, 3, 5}},
+		{7, []int{1, 3, 5, 7}},
+		{20, []int{1, 3, 5, 10, 20}},
+	}
+	for _, tt := range tests {
+		if got := HitRateCutoffs(tt.k); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("HitRateCutoffs(%d) = %v, want %v", tt.k, got, tt.want)
+		}
+	}
+}
+
+func TestRankMetrics(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions []models.EvaluatedPosition
+		want      models.RankingMetrics
+	}{
+		{"no positions", nil, models.RankingMetrics{HitRate: map[string]float64{"@1": 0, "@3": 0}}},
+		{"mixed ranks", []models.EvaluatedPosition{
+			{Rank: 1, Covered: true},
+			{Rank: 2, Covered: true},
+			{Rank: 0, Covered: true},
+			{Rank: 0},
+		}, models.RankingMetrics{Words: 4, HitRate: map[string]float64{"@1": 0.25, "@3": 0.5}, MRR: 1.5 / 4, Coverage: 0.75}},
+		{"past the smaller cutoff", []models.EvaluatedPosition{{Rank: 3}}, models.RankingMetrics{Words: 1, HitRate: map[string]float64{"@1": 0, "@3": 1}, MRR: 1.0 / 3}},
+	}
+	for _, tt := range tests {
+		if got := RankMetrics(tt.positions, []int{1, 3}); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: RankMetrics() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+// EditDistance returns the weighted edit distance between typed and the whole word
+func EditDistance(typed, word string, layout *KeyboardLayout) float64 {
+	row := editDistanceRow(typed, word, layout)
+	return row[len(row)-1]
+}
+
+// editDistanceRow returns the distances between typed and every prefix of word
+func editDistanceRow(typed, word string, layout *KeyboardLayout) []float64 {
+	a, b := []rune(typed), []rune(word)
+
+	// rows[i][j] is the distance between a[:i] and b[:j]; three rows are kept
+	// for the transposition lookback
+	prev2 := make([]float64, len(b)+1)
+	prev := make([]float64, len(b)+1)
+	curr := make([]float64, len(b)+1)
+	for j := range prev {
+		prev[j] = float64(j)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = float64(i)
+		for j := 1; j <= len(b); j++ {
+			best := prev[j-1] + layout.SubstitutionCost(b[j-1], a[i-1])
+			if deletion := prev[j] + 1; deletion < best {
+				best = deletion
+			}
+			if insertion := curr[j-1] + 1; insertion < best {
+				best = insertion
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				if swap := prev2[j-2] + 1; swap < best {
+					best = swap
+				}
+			}
+			curr[j] = best
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev
+}
+
+// MaxFuzzyDistance is the edit budget for typed text of the given length:
+// short prefixes allow one edit, longer ones two
+func MaxFuzzyDistance(typed string) float64 {
+	if len([]rune(typed)) <= 4 {
+		return 1
+	}
+	return 2
+}
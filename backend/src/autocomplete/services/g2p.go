@@ -0,0 +1,76 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Phoneme symbols are single ASCII characters so phoneme strings can be sliced
+// into prefixes like words: N = ng, J = ny, S = sh/sy, C = ch, x = kh, G = gh,
+// T = th, ? = glottal stop, Y = ai, W = au, O = oi, e = e/schwa
+var graphemeRules = []struct {
+	graphemes string
+	phonemes  string
+}{
+	// Longest graphemes first; the first rule matching at a position wins
+	{"tion", "Sen"}, {"sion", "Sen"}, {"ough", "of"},
+	{"ngg", "Ng"}, {"sch", "sk"}, {"tch", "C"},
+	{"ng", "N"}, {"ny", "J"}, {"sy", "S"}, {"sh", "S"}, {"ch", "C"}, {"kh", "x"},
+	{"gh", "G"}, {"ph", "f"}, {"th", "T"}, {"ck", "k"}, {"qu", "kw"}, {"wh", "w"},
+	{"ee", "i"}, {"ea", "i"}, {"ie", "i"}, {"oo", "u"}, {"ou", "W"},
+	{"ai", "Y"}, {"ay", "Y"}, {"au", "W"}, {"aw", "W"}, {"oi", "O"}, {"oy", "O"},
+	// No soft c/g before e/i: Malay c is always ch ("cinta") and g always hard ("gigi")
+	{"c", "C"}, {"q", "k"}, {"x", "ks"}, {"v", "f"},
+}
+
+// Phonemes converts a Malay or English word into a rough phoneme string using
+// spelling rules, so words can be matched by how they sound rather than how
+// they are spelled. Silent h after a vowel, a final k after a vowel (a glottal
+// stop in Malay) and repeated phonemes are normalized.
+func Phonemes(word string) string {
+	var letters []rune
+	for _, r := range strings.ToLower(word) {
+		if unicode.IsLetter(r) {
+			letters = append(letters, r)
+		}
+	}
+	text := string(letters)
+
+	var phonemes []rune
+	for i := 0; i < len(text); {
+		matched := false
+		for _, rule := range graphemeRules {
+			if strings.HasPrefix(text[i:], rule.graphemes) {
+				phonemes = append(phonemes, []rune(rule.phonemes)...)
+				i += len(rule.graphemes)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			r := []rune(text[i:])[0]
+			phonemes = append(phonemes, r)
+			i += len(string(r))
+		}
+	}
+
+	isVowel := func(r rune) bool { return strings.ContainsRune("aeiouYWO", r) }
+
+	var normalized []rune
+	for i, r := range phonemes {
+		last := i == len(phonemes)-1
+		switch {
+		case r == 'h' && i > 0 && isVowel(phonemes[i-1]) && (last || !isVowel(phonemes[i+1])):
+			continue // Silent h: "boleh", "sudah"
+		case r == 'k' && last && i > 0 && isVowel(phonemes[i-1]):
+			r = '?'
+		case r == 'y' && last:
+			r = 'i' // English final y: "happy"
+		}
+		if len(normalized) > 0 && normalized[len(normalized)-1] == r {
+			continue
+		}
+		normalized = append(normalized, r)
+	}
+	return string(normalized)
+}
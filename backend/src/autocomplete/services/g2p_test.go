@@ -0,0 +1,51 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPhonemes(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"", ""},
+		{"123", ""},
+		{"boleh", "bole"},
+		{"sudah", "suda"},
+		{"phone", "fone"},
+		{"nyanyi", "JaJi"},
+		{"happy", "hapi"},
+		{"nasik", "nasi?"},
+		{"cinta", "Cinta"},
+		{"station", "staSen"},
+		{"mangga", "maNga"},
+		{"manga", "maNa"},
+		{"Khabar!", "xabar"},
+		{"pakai", "pakY"},
+	}
+	for _, tt := range tests {
+		if got := Phonemes(tt.word); got != tt.want {
+			t.Errorf("Phonemes(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestPhonemePrefixesMatchSoundAlikes(t *testing.T) {
+	tests := []struct {
+		typed, word string
+	}{
+		{"fon", "phone"},
+		{"cinta", "chinta"},
+		{"bole", "boleh"},
+		{"syarikat", "sharikat"},
+		{"nasi", "nasik"},
+		{"kwe", "question"},
+	}
+	for _, tt := range tests {
+		if typed, word := Phonemes(tt.typed), Phonemes(tt.word); !strings.HasPrefix(word, typed) {
+			t.Errorf("Phonemes(%q) = %q is not a prefix of Phonemes(%q) = %q", tt.typed, typed, tt.word, word)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// defaultVirtualNodes is how many points each shard gets on the ring when unset
+const defaultVirtualNodes = 100
+
+// HashRing maps keys onto named shards by consistent hashing, so adding or
+// removing a shard only moves the keys that hashed next to it
+type HashRing struct {
+	points []uint32
+	owners map[uint32]string
+}
+
+// NewHashRing places every shard on the ring virtualNodes times
+func NewHashRing(shards []string, virtualNodes int) *HashRing {
+	if virtualNodes <= 0 {
+		virtualNodes = defaultVirtualNodes
+	}
+
+	ring := &HashRing{owners: make(map[uint32]string)}
+	for _, shard := range shards {
+		for i := 0; i < virtualNodes; i++ {
+			point := crc32.ChecksumIEEE([]byte(shard + "#" + strconv.Itoa(i)))
+			if _, taken := ring.owners[point]; taken {
+				continue // Keep the first owner of a colliding point
+			}
+			ring.owners[point] = shard
+			ring.points = append(ring.points, point)
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+
+	return ring
+}
+
+// Locate returns the shard owning the key, or "" for an empty ring
+func (r *HashRing) Locate(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(key))
+	index := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if index == len(r.points) {
+		index = 0 // Wrap around to the first point
+	}
+	return r.owners[r.points[index]]
+}
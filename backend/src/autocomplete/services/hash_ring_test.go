@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashRingLocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		shards []string
+	}{
+		{"one shard", []string{"a"}},
+		{"three shards", []string{"a", "b", "c"}},
+		{"order does not matter", []string{"c", "a", "b"}},
+	}
+	for _, tt := range tests {
+		ring := NewHashRing(tt.shards, 0)
+		again := NewHashRing(tt.shards, 0)
+		owned := make(map[string]int)
+		for i := 0; i < 3000; i++ {
+			key := fmt.Sprintf("clip-%d", i)
+			shard := ring.Locate(key)
+			if shard != again.Locate(key) {
+				t.Errorf("%s: %s located on %s, then %s", tt.name, key, shard, again.Locate(key))
+			}
+			owned[shard]++
+		}
+		for _, shard := range tt.shards {
+			// Every shard gets a fair share, within a wide margin
+			if share := owned[shard]; share < 3000/len(tt.shards)/2 {
+				t.Errorf("%s: shard %s owns %d of 3000 keys", tt.name, shard, share)
+			}
+		}
+		if len(owned) != len(tt.shards) {
+			t.Errorf("%s: keys located on %v", tt.name, owned)
+		}
+	}
+
+	if shard := NewHashRing(nil, 0).Locate("clip"); shard != "" {
+		t.Errorf("empty ring located clip on %q", shard)
+	}
+}
+
+func TestHashRingAddShardMovesOnlyItsKeys(t *testing.T) {
+	tests := []struct {
+		before []string
+		added  string
+	}{
+		{[]string{"a"}, "b"},
+		{[]string{"a", "b"}, "c"},
+		{[]string{"a", "b", "c", "d"}, "e"},
+	}
+	for _, tt := range tests {
+		before := NewHashRing(tt.before, 50)
+		after := NewHashRing(append(append([]string(nil), tt.before...), tt.added), 50)
+		moved := 0
+		for i := 0; i < 2000; i++ {
+			key := fmt.Sprintf("clip-%d", i)
+			was, is := before.Locate(key), after.Locate(key)
+			if was != is {
+				if is != tt.added {
+					t.Errorf("adding %s moved %s from %s to %s", tt.added, key, was, is)
+				}
+				moved++
+			}
+		}
+		if moved == 0 {
+			t.Errorf("adding %s to %v moved no keys", tt.added, tt.before)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Homophone groups keyed by member word, loaded by ConfigureHomophones
+var (
+	homophoneGroups = map[string][]string{}
+	homophoneMutex  sync.RWMutex
+)
+
+// ConfigureHomophones loads the homophone table from the packaged resources
+func ConfigureHomophones() error {
+	lines, err := LoadResource(ResourceHomophones)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string][]string)
+	for _, line := range lines {
+		words := strings.Fields(strings.ToLower(line))
+		for _, word := range words {
+			for _, other := range words {
+				if other != word {
+					groups[word] = append(groups[word], other)
+				}
+			}
+		}
+	}
+
+	homophoneMutex.Lock()
+	homophoneGroups = groups
+	homophoneMutex.Unlock()
+
+	return nil
+}
+
+// Homophones returns the words listed with word in the homophone table
+func Homophones(word string) []string {
+	homophoneMutex.RLock()
+	defer homophoneMutex.RUnlock()
+	return homophoneGroups[strings.ToLower(word)]
+}
+
+// phoneticRewrites fold spellings that sound alike in Malay and English onto one
+// form, applied in order before doubled letters are collapsed
+var phoneticRewrites = strings.NewReplacer(
+	"sy", "s", "kh", "k", "gh", "g", "ph", "f", "ck", "k", "ch", "c",
+	"ny", "N", "ng", "G", "q", "k", "v", "f", "z", "s", "x", "ks",
+)
+
+// PhoneticKey reduces a word to a rough sound-alike key, so spellings such as
+// "tahu"/"tau", "sahaja"/"saja" or "nasi"/"nasik" share a key. Silent h between
+// or after vowels, a final glottal k after a vowel and doubled letters are dropped.
+func PhoneticKey(word string) string {
+	var letters strings.Builder
+	for _, r := range strings.ToLower(word) {
+		if unicode.IsLetter(r) {
+			letters.WriteRune(r)
+		}
+	}
+	folded := []rune(phoneticRewrites.Replace(letters.String()))
+
+	isVowel := func(r rune) bool { return strings.ContainsRune("aeiou", r) }
+
+	var key []rune
+	for i, r := range folded {
+		if r == 'h' && i > 0 && isVowel(folded[i-1]) && (i == len(folded)-1 || isVowel(folded[i+1])) {
+			continue
+		}
+		if r == 'k' && i == len(folded)-1 && i > 0 && isVowel(folded[i-1]) {
+			continue
+		}
+		if len(key) > 0 && key[len(key)-1] == r {
+			continue
+		}
+		key = append(key, r)
+	}
+	return string(key)
+}
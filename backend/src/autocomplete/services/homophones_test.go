@@ -0,0 +1,54 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPhoneticKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"tahu", "tau", true},
+		{"sahaja", "saja", true},
+		{"nasi", "nasik", true},
+		{"syarikat", "sarikat", true},
+		{"khabar", "kabar", true},
+		{"photo", "foto", true},
+		{"Makan", "makan!", true},
+		{"nyanyi", "nanyi", false},
+		{"makan", "makna", false},
+		{"hari", "ari", false}, // A leading h is pronounced
+	}
+	for _, tt := range tests {
+		a, b := PhoneticKey(tt.a), PhoneticKey(tt.b)
+		if (a == b) != tt.same {
+			t.Errorf("PhoneticKey(%q) = %q, PhoneticKey(%q) = %q, want same %v", tt.a, a, tt.b, b, tt.same)
+		}
+	}
+	if got := PhoneticKey("123 !"); got != "" {
+		t.Errorf("PhoneticKey of no letters = %q, want empty", got)
+	}
+}
+
+func TestHomophones(t *testing.T) {
+	if err := ConfigureHomophones(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"tau", []string{"tahu"}},
+		{"TAHU", []string{"tau"}},
+		{"tak", []string{"tidak"}},
+		{"makan", nil},
+	}
+	for _, tt := range tests {
+		if got := Homophones(tt.word); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Homophones(%q) = %v, want %v", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Keyboard layout names accepted by ConfigureKeyboardLayout and KeyboardLayoutFor
+const (
+	KeyboardQWERTY = "qwerty"
+	KeyboardT9     = "t9"
+)
+
+// Substitution costs for fuzzy matching: a slip onto a neighbouring key is
+// cheaper than an arbitrary wrong letter, and letters sharing a key cost least
+const (
+	sameKeyCost  = 0.25
+	neighborCost = 0.5
+	typoCost     = 1.0
+)
+
+// KeyboardLayout weights substitutions by how easily one key is hit for another
+// on an input device
+type KeyboardLayout struct {
+	Name string
+
+	keys      map[rune]string            // Letter to the key it is typed on
+	neighbors map[string]map[string]bool // Keys physically next to each key
+}
+
+// SubstitutionCost returns the cost of typing got where want was meant
+func (l *KeyboardLayout) SubstitutionCost(want, got rune) float64 {
+	want, got = unicode.ToLower(want), unicode.ToLower(got)
+	if want == got {
+		return 0
+	}
+	wantKey, wantKnown := l.keys[want]
+	gotKey, gotKnown := l.keys[got]
+	switch {
+	case !wantKnown || !gotKnown:
+		return typoCost
+	case wantKey == gotKey:
+		return sameKeyCost
+	case l.neighbors[wantKey][gotKey]:
+		return neighborCost
+	}
+	return typoCost
+}
+
+// Neighbors returns the letters typed on the same key as r or a neighbouring one
+func (l *KeyboardLayout) Neighbors(r rune) []rune {
+	key, known := l.keys[unicode.ToLower(r)]
+	if !known {
+		return nil
+	}
+	var letters []rune
+	for letter, letterKey := range l.keys {
+		if letter != unicode.ToLower(r) && (letterKey == key || l.neighbors[key][letterKey]) {
+			letters = append(letters, letter)
+		}
+	}
+	return letters
+}
+
+// gridLayout builds a layout from rows of keys, each row shifted right by its
+// offset in key widths. Keys within 1.5 key widths of each other are neighbours.
+func gridLayout(name string, rows []string, offsets []float64) *KeyboardLayout {
+	layout := &KeyboardLayout{
+		Name:      name,
+		keys:      make(map[rune]string),
+		neighbors: make(map[string]map[string]bool),
+	}
+
+	type position struct{ x, y float64 }
+	positions := make(map[string]position)
+	for row, keys := range rows {
+		for col, key := range strings.Fields(keys) {
+			positions[key] = position{x: float64(col) + offsets[row], y: float64(row)}
+			for _, letter := range key {
+				layout.keys[letter] = key
+			}
+		}
+	}
+
+	for key, a := range positions {
+		layout.neighbors[key] = make(map[string]bool)
+		for other, b := range positions {
+			if other != key && math.Hypot(a.x-b.x, a.y-b.y) < 1.5 {
+				layout.neighbors[key][other] = true
+			}
+		}
+	}
+	return layout
+}
+
+// keyboardLayouts are the supported input devices
+var keyboardLayouts = map[string]*KeyboardLayout{
+	// Full keyboard: one letter per key, staggered rows
+	KeyboardQWERTY: gridLayout(KeyboardQWERTY, []string{
+		"q w e r t y u i o p",
+		"a s d f g h j k l",
+		"z x c v b n m",
+	}, []float64{0, 0.25, 0.75}),
+	// Mobile 9-key pad: letters share keys 2-9, laid out on a 3x3 grid
+	KeyboardT9: gridLayout(KeyboardT9, []string{
+		"1 abc def",
+		"ghi jkl mno",
+		"pqrs tuv wxyz",
+	}, []float64{0, 0, 0}),
+}
+
+var defaultKeyboardLayout = keyboardLayouts[KeyboardQWERTY]
+
+// ConfigureKeyboardLayout sets the layout used when the client reports none.
+// An empty name keeps QWERTY.
+func ConfigureKeyboardLayout(name string) error {
+	if name == "" {
+		return nil
+	}
+	layout, err := KeyboardLayoutFor(name)
+	if err != nil {
+		return err
+	}
+	defaultKeyboardLayout = layout
+	return nil
+}
+
+// KeyboardLayoutFor returns the named layout, or the configured default for an empty name
+func KeyboardLayoutFor(name string) (*KeyboardLayout, error) {
+	if name == "" {
+		return defaultKeyboardLayout, nil
+	}
+	layout, exists := keyboardLayouts[strings.ToLower(name)]
+	if !exists {
+		return nil, fmt.Errorf("unknown keyboard layout %q (expected %s or %s)", name, KeyboardQWERTY, KeyboardT9)
+	}
+	return layout, nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestSubstitutionCost(t *testing.T) {
+	qwerty, _ := KeyboardLayoutFor(KeyboardQWERTY)
+	t9, _ := KeyboardLayoutFor(KeyboardT9)
+	tests := []struct {
+		layout    *KeyboardLayout
+		want, got rune
+		cost      float64
+	}{
+		{qwerty, 'a', 'a', 0},
+		{qwerty, 'a', 'A', 0},
+		{qwerty, 'a', 's', neighborCost},
+		{qwerty, 'a', 'q', neighborCost},
+		{qwerty, 'a', 'p', typoCost},
+		{qwerty, 'a', 'é', typoCost},
+		{t9, 'a', 'c', sameKeyCost},
+		{t9, 'a', 'd', neighborCost},
+		{t9, 'a', 'w', typoCost},
+	}
+	for _, tt := range tests {
+		if got := tt.layout.SubstitutionCost(tt.want, tt.got); got != tt.cost {
+			t.Errorf("%s: SubstitutionCost(%q, %q) = %v, want %v", tt.layout.Name, tt.want, tt.got, got, tt.cost)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	qwerty, _ := KeyboardLayoutFor(KeyboardQWERTY)
+	tests := []struct {
+		typed, word string
+		want        float64
+	}{
+		{"makan", "makan", 0},
+		{"mskan", "makan", neighborCost},
+		{"mpkan", "makan", typoCost},
+		{"mkaan", "makan", 1}, // Transposition
+		{"makn", "makan", 1},
+		{"makaan", "makan", 1},
+		{"", "makan", 5},
+		{"maka", "", 4},
+	}
+	for _, tt := range tests {
+		if got := EditDistance(tt.typed, tt.word, qwerty); got != tt.want {
+			t.Errorf("EditDistance(%q, %q) = %v, want %v", tt.typed, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestMaxFuzzyDistance(t *testing.T) {
+	tests := []struct {
+		typed string
+		want  float64
+	}{
+		{"mak", 1},
+		{"maka", 1},
+		{"makan", 2},
+		{"kuéh", 1},
+	}
+	for _, tt := range tests {
+		if got := MaxFuzzyDistance(tt.typed); got != tt.want {
+			t.Errorf("MaxFuzzyDistance(%q) = %v, want %v", tt.typed, got, tt.want)
+		}
+	}
+}
+
+func TestKeyboardLayoutFor(t *testing.T) {
+	defer func(layout *KeyboardLayout) { defaultKeyboardLayout = layout }(defaultKeyboardLayout)
+
+	tests := []struct {
+		configure string
+		name      string
+		want      string
+		wantErr   bool
+	}{
+		{"", "", KeyboardQWERTY, false},
+		{"", "T9", KeyboardT9, false},
+		{"", "dvorak", "", true},
+		{KeyboardT9, "", KeyboardT9, false},
+		{KeyboardT9, KeyboardQWERTY, KeyboardQWERTY, false},
+	}
+	for _, tt := range tests {
+		defaultKeyboardLayout = keyboardLayouts[KeyboardQWERTY]
+		if err := ConfigureKeyboardLayout(tt.configure); err != nil {
+			t.Fatalf("ConfigureKeyboardLayout(%q) error: %v", tt.configure, err)
+		}
+		layout, err := KeyboardLayoutFor(tt.name)
+		if (err != nil) != tt.wantErr || (err == nil && layout.Name != tt.want) {
+			t.Errorf("default %q: KeyboardLayoutFor(%q) = %v, %v, want %s", tt.configure, tt.name, layout, err, tt.want)
+		}
+	}
+	if err := ConfigureKeyboardLayout("dvorak"); err == nil {
+		t.Error("ConfigureKeyboardLayout accepted an unknown layout")
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	qwerty, _ := KeyboardLayoutFor(KeyboardQWERTY)
+	t9, _ := KeyboardLayoutFor(KeyboardT9)
+	tests := []struct {
+		layout *KeyboardLayout
+		r      rune
+		want   string
+	}{
+		{qwerty, 'g', "bfhtvy"},
+		{qwerty, 'Q', "aw"},
+		{t9, 'a', "1bcdefghijklmno"},
+		{t9, '1', "abcghijkl"},
+		{t9, '!', ""},
+	}
+	for _, tt := range tests {
+		letters := tt.layout.Neighbors(tt.r)
+		sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+		if got := string(letters); got != tt.want {
+			t.Errorf("%s: Neighbors(%q) = %q, want %q", tt.layout.Name, tt.r, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"autocomplete/models"
+)
+
+// Languages a clip's words are split into for per-language sub-indexes
+const (
+	LangMalay   = "ms"
+	LangEnglish = "en"
+	LangChinese = "zh"
+)
+
+// Languages lists the supported language codes
+var Languages = []string{LangMalay, LangEnglish, LangChinese}
+
+// englishClusters are spellings Malay orthography doesn't use, so a word
+// holding one is taken as English
+var englishClusters = []string{"th", "wh", "ph", "ck", "ee", "oo", "ght", "tion", "q", "x"}
+
+// The packaged word lists and particle lexicon keyed by word, loaded on first use
+var (
+	languageOnce    sync.Once
+	languageLexicon map[string]string
+)
+
+// Per-language tries per clip, derived from the position map and guarded by cacheMutex
+var clipLanguageTries = make(map[string]map[string]*models.PrefixTrie)
+
+// IsLanguage reports whether lang is a supported language code
+func IsLanguage(lang string) bool {
+	for _, supported := range Languages {
+		if lang == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectLanguage guesses the language of a word. Words with Han characters are
+// Chinese; words in the packaged Malay or English word lists (or the particle
+// lexicon, as Malay) take that list's language; otherwise English spellings
+// mark English and anything else is Malay, the clips' main language.
+func DetectLanguage(word string) string {
+	word = strings.ToLower(word)
+	if strings.IndexFunc(word, func(r rune) bool { return unicode.Is(unicode.Han, r) }) >= 0 {
+		return LangChinese
+	}
+
+	languageOnce.Do(loadLanguageLexicon)
+	if lang, known := languageLexicon[word]; known {
+		return lang
+	}
+	for _, cluster := range englishClusters {
+		if strings.Contains(word, cluster) {
+			return LangEnglish
+		}
+	}
+	return LangMalay
+}
+
+// loadLanguageLexicon indexes the packaged word lists. A resource that fails
+// to load only weakens detection, so errors leave its words out.
+func loadLanguageLexicon() {
+	languageLexicon = make(map[string]string)
+	for _, list := range []struct{ name, lang string }{
+		{ResourceWordlistEnglish, LangEnglish},
+		{ResourceWordlistMalay, LangMalay},
+		{ResourceParticles, LangMalay},
+	} {
+		entries, err := LoadResource(list.name)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if fields := strings.Fields(entry); len(fields) > 0 {
+				languageLexicon[strings.ToLower(fields[0])] = list.lang
+			}
+		}
+	}
+}
+
+// BuildLanguageTries splits a clip's candidates by DetectLanguage into one
+// prefix trie per language
+func BuildLanguageTries(audioID string, positionMap models.PositionMap) map[string]*models.PrefixTrie {
+	byLanguage := make(map[string][]models.WordSuggestion)
+	for pos := 0; pos < len(positionMap); pos++ {
+		for _, candidate := range positionMap[pos] {
+			lang := DetectLanguage(candidate.Text)
+			byLanguage[lang] = append(byLanguage[lang], candidate)
+		}
+	}
+
+	tries := make(map[string]*models.PrefixTrie, len(Languages))
+	for _, lang := range Languages {
+		trie := models.NewPrefixTrie(audioID)
+		trie.InsertAll(byLanguage[lang], BuildWorkers())
+		tries[lang] = trie
+	}
+	return tries
+}
+
+// GetLanguageTrie retrieves a snapshot of one language's sub-index of a cached clip
+func GetLanguageTrie(audioID, lang string) (*models.PrefixTrie, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	tries, exists := clipLanguageTries[audioID]
+	if !exists {
+		return nil, fmt.Errorf("%w for clip %s, please initialize first", ErrClipNotInitialized, audioID)
+	}
+	return tries[lang].Snapshot(), nil
+}
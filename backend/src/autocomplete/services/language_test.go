@@ -0,0 +1,84 @@
+package services
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"makan", LangMalay},
+		{"They", LangEnglish},
+		{"lah", LangMalay},     // Particle lexicon
+		{"phone", LangEnglish}, // English spelling
+		{"sekolah", LangMalay},
+		{"吃饭", LangChinese},
+		{"makan饭", LangChinese},
+		{"", LangMalay},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.word); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestIsLanguage(t *testing.T) {
+	tests := []struct {
+		lang string
+		want bool
+	}{
+		{"ms", true},
+		{"en", true},
+		{"zh", true},
+		{"EN", false},
+		{"ta", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsLanguage(tt.lang); got != tt.want {
+			t.Errorf("IsLanguage(%q) = %v, want %v", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageTries(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	BuildAndCacheData("clip", &models.AutocompleteData{
+		FinalTranscription: "we makan nasi",
+		ASRAlternatives:    map[string]string{"whisper": "we makan the"},
+	})
+
+	tests := []struct {
+		lang string
+		want []string
+	}{
+		{LangMalay, []string{"makan", "nasi"}},
+		{LangEnglish, []string{"the", "we"}},
+		{LangChinese, nil},
+	}
+	for _, tt := range tests {
+		trie, err := GetLanguageTrie("clip", tt.lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for word := range trie.Words() {
+			got = append(got, word)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetLanguageTrie(%s) words = %v, want %v", tt.lang, got, tt.want)
+		}
+	}
+	if _, err := GetLanguageTrie("other", LangMalay); err == nil {
+		t.Error("GetLanguageTrie(other) found a clip that was never built")
+	}
+}
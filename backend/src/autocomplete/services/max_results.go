@@ -0,0 +1,38 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MaxSuggestResults is the most suggestions one prefix lookup may ask for
+const MaxSuggestResults = 50
+
+// defaultSuggestResults is how many suggestions a lookup without max_results gets
+var defaultSuggestResults = 5
+
+// SetDefaultSuggestResults sets the max_results default; values outside
+// 1..MaxSuggestResults are ignored
+func SetDefaultSuggestResults(n int) {
+	if n > 0 && n <= MaxSuggestResults {
+		defaultSuggestResults = n
+	}
+}
+
+// DefaultSuggestResults returns the max_results default
+func DefaultSuggestResults() int {
+	return defaultSuggestResults
+}
+
+// ParseMaxResults reads a max_results parameter, returning the default when
+// it is empty and an error when it isn't an integer in 1..MaxSuggestResults
+func ParseMaxResults(param string) (int, error) {
+	if param == "" {
+		return defaultSuggestResults, nil
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil || n < 1 || n > MaxSuggestResults {
+		return 0, fmt.Errorf("max_results must be an integer between 1 and %d", MaxSuggestResults)
+	}
+	return n, nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import "testing"
+
+func TestParseMaxResults(t *testing.T) {
+	tests := []struct {
+		param   string
+		want    int
+		wantErr bool
+	}{
+		{"", DefaultSuggestResults(), false},
+		{"1", 1, false},
+		{"50", MaxSuggestResults, false},
+		{"0", 0, true},
+		{"-3", 0, true},
+		{"51", 0, true},
+		{"ten", 0, true},
+		{"2.5", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMaxResults(tt.param)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMaxResults(%q) = (%d, %v), want %d (error %v)", tt.param, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestSetDefaultSuggestResults(t *testing.T) {
+	defer SetDefaultSuggestResults(DefaultSuggestResults())
+
+	tests := []struct {
+		set  int
+		want int
+	}{
+		{8, 8},
+		{0, 8},
+		{MaxSuggestResults + 1, 8},
+		{MaxSuggestResults, MaxSuggestResults},
+	}
+	for _, tt := range tests {
+		SetDefaultSuggestResults(tt.set)
+		if got, _ := ParseMaxResults(""); got != tt.want {
+			t.Errorf("after SetDefaultSuggestResults(%d), default = %d, want %d", tt.set, got, tt.want)
+		}
+	}
+}
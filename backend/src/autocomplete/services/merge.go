@@ -0,0 +1,120 @@
+package services
+
+import (
+	"strings"
+
+	"autocomplete/models"
+)
+
+// MergedPart is where one clip landed in a merged payload: its first word
+// position and the seconds added to its timings
+type MergedPart struct {
+	AudioID    string  `json:"audio_id"`
+	WordOffset int     `json:"word_offset"`
+	Words      int     `json:"words"`
+	TimeOffset float64 `json:"time_offset"`
+}
+
+// MergeAutocompleteData concatenates the payloads of consecutive clips, e.g.
+// the parts of one long recording, into one payload. Word positions of
+// particles, segments and speakers are shifted past the earlier parts, and
+// timings by the end of the last timing of the earlier parts. Segment IDs are
+// prefixed with their clip's ID to stay unique. The confidence score is the
+// mean of the parts' weighted by their word counts. Word timestamps are kept
+// only when every part has them, since they must cover every baseline word.
+func MergeAutocompleteData(audioIDs []string, parts []*models.AutocompleteData) (*models.AutocompleteData, []MergedPart) {
+	merged := &models.AutocompleteData{ASRAlternatives: make(map[string]string)}
+	placed := make([]MergedPart, len(parts))
+
+	var finals []string
+	alternatives := make(map[string][]string)
+	particles := make(map[string]bool)
+	topics := make(map[string]bool)
+	timestamps := true
+	var words, characters int
+	var timeOffset, confidence float64
+	for i, part := range parts {
+		partWords := len(TranscriptWords(part.FinalTranscription))
+		placed[i] = MergedPart{AudioID: audioIDs[i], WordOffset: words, Words: partWords, TimeOffset: timeOffset}
+
+		if part.FinalTranscription != "" {
+			finals = append(finals, part.FinalTranscription)
+		}
+		for model, transcription := range part.ASRAlternatives {
+			if transcription != "" {
+				alternatives[model] = append(alternatives[model], transcription)
+			}
+		}
+		for _, particle := range part.DetectedParticles {
+			if !particles[particle] {
+				particles[particle] = true
+				merged.DetectedParticles = append(merged.DetectedParticles, particle)
+			}
+		}
+		for _, topic := range part.Topics {
+			if !topics[topic] {
+				topics[topic] = true
+				merged.Topics = append(merged.Topics, topic)
+			}
+		}
+		if i == 0 {
+			merged.Accent = part.Accent
+		} else if part.Accent != merged.Accent {
+			merged.Accent = "" // Parts disagree, so the hint no longer applies
+		}
+
+		for _, potential := range part.PotentialParticles {
+			potential.WordIndex += words
+			potential.CharacterPosition += characters
+			merged.PotentialParticles = append(merged.PotentialParticles, potential)
+		}
+		for _, speaker := range part.SpeakerSegments {
+			speaker.StartWord += words
+			speaker.EndWord += words
+			merged.SpeakerSegments = append(merged.SpeakerSegments, speaker)
+		}
+
+		end := 0.0
+		for _, segment := range part.Segments {
+			if segment.EndTime > end {
+				end = segment.EndTime
+			}
+			segment.ID = audioIDs[i] + "/" + segment.ID
+			segment.StartWord += words
+			segment.EndWord += words
+			segment.StartTime += timeOffset
+			segment.EndTime += timeOffset
+			merged.Segments = append(merged.Segments, segment)
+		}
+		if len(part.WordTimestamps) == 0 && partWords > 0 {
+			timestamps = false
+		}
+		for _, timestamp := range part.WordTimestamps {
+			if timestamp.EndTime > end {
+				end = timestamp.EndTime
+			}
+			timestamp.StartTime += timeOffset
+			timestamp.EndTime += timeOffset
+			merged.WordTimestamps = append(merged.WordTimestamps, timestamp)
+		}
+
+		confidence += part.ConfidenceScore * float64(partWords)
+		words += partWords
+		if part.FinalTranscription != "" {
+			characters += len([]rune(part.FinalTranscription)) + 1 // The joining space
+		}
+		timeOffset += end
+	}
+
+	merged.FinalTranscription = strings.Join(finals, " ")
+	for model, transcriptions := range alternatives {
+		merged.ASRAlternatives[model] = strings.Join(transcriptions, " ")
+	}
+	if words > 0 {
+		merged.ConfidenceScore = confidence / float64(words)
+	}
+	if !timestamps {
+		merged.WordTimestamps = nil
+	}
+	return merged, placed
+}
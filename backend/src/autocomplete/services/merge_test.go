@@ -0,0 +1,101 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestMergeAutocompleteData(t *testing.T) {
+	first := &models.AutocompleteData{
+		FinalTranscription: "saya makan",
+		ConfidenceScore:    0.9,
+		ASRAlternatives:    map[string]string{"whisper": "saya makna", "wav2vec": "saya makan"},
+		DetectedParticles:  []string{"lah"},
+		Topics:             []string{"food"},
+		Accent:             "kelantan",
+		PotentialParticles: []models.PotentialParticle{{Particle: "lah", WordIndex: 1, CharacterPosition: 10}},
+		SpeakerSegments:    []models.SpeakerSegment{segment("A", 0, 1)},
+		Segments:           []models.AudioSegment{audioSegment("s1", 0, 1.5, 0, 1)},
+		WordTimestamps:     []models.WordTimestamp{{StartTime: 0, EndTime: 0.5}, {StartTime: 0.5, EndTime: 2}},
+	}
+	second := &models.AutocompleteData{
+		FinalTranscription: "nasi goreng sedap",
+		ConfidenceScore:    0.4,
+		ASRAlternatives:    map[string]string{"whisper": "nasi goreng sedap"},
+		DetectedParticles:  []string{"lah", "kan"},
+		Topics:             []string{"food", "market"},
+		Accent:             "kelantan",
+		PotentialParticles: []models.PotentialParticle{{Particle: "kan", WordIndex: 2, CharacterPosition: 17}},
+		SpeakerSegments:    []models.SpeakerSegment{segment("B", 0, 2)},
+		Segments:           []models.AudioSegment{audioSegment("s1", 0, 3, 0, 2)},
+		WordTimestamps:     []models.WordTimestamp{{StartTime: 0, EndTime: 1}, {StartTime: 1, EndTime: 2}, {StartTime: 2, EndTime: 3}},
+	}
+
+	merged, placed := MergeAutocompleteData([]string{"part-1", "part-2"}, []*models.AutocompleteData{first, second})
+
+	if merged.FinalTranscription != "saya makan nasi goreng sedap" {
+		t.Errorf("transcription %q", merged.FinalTranscription)
+	}
+	if want := map[string]string{"whisper": "saya makna nasi goreng sedap", "wav2vec": "saya makan"}; !reflect.DeepEqual(merged.ASRAlternatives, want) {
+		t.Errorf("alternatives %v, want %v", merged.ASRAlternatives, want)
+	}
+	if want := (0.9*2 + 0.4*3) / 5; math.Abs(merged.ConfidenceScore-want) > 1e-9 {
+		t.Errorf("confidence %v, want %v", merged.ConfidenceScore, want)
+	}
+	if !reflect.DeepEqual(merged.DetectedParticles, []string{"lah", "kan"}) || !reflect.DeepEqual(merged.Topics, []string{"food", "market"}) || merged.Accent != "kelantan" {
+		t.Errorf("particles %v, topics %v, accent %q", merged.DetectedParticles, merged.Topics, merged.Accent)
+	}
+	if want := []MergedPart{{"part-1", 0, 2, 0}, {"part-2", 2, 3, 2}}; !reflect.DeepEqual(placed, want) {
+		t.Errorf("placed %+v, want %+v", placed, want)
+	}
+
+	// Everything from the second part is shifted past the first
+	if got := merged.PotentialParticles[1]; got.WordIndex != 4 || got.CharacterPosition != 17+len("saya makan ") {
+		t.Errorf("second particle at word %d, character %d", got.WordIndex, got.CharacterPosition)
+	}
+	if want := []models.SpeakerSegment{segment("A", 0, 1), segment("B", 2, 4)}; !reflect.DeepEqual(merged.SpeakerSegments, want) {
+		t.Errorf("speakers %v, want %v", merged.SpeakerSegments, want)
+	}
+	if want := []models.AudioSegment{audioSegment("part-1/s1", 0, 1.5, 0, 1), audioSegment("part-2/s1", 2, 5, 2, 4)}; !reflect.DeepEqual(merged.Segments, want) {
+		t.Errorf("segments %v, want %v", merged.Segments, want)
+	}
+	if len(merged.WordTimestamps) != 5 || merged.WordTimestamps[2].StartTime != 2 || merged.WordTimestamps[4].EndTime != 5 {
+		t.Errorf("timestamps %v", merged.WordTimestamps)
+	}
+}
+
+func TestMergeAutocompleteDataPartialHints(t *testing.T) {
+	tests := []struct {
+		name           string
+		parts          []*models.AutocompleteData
+		wantAccent     string
+		wantTimestamps int
+	}{
+		{"accents differ", []*models.AutocompleteData{
+			{FinalTranscription: "saya", Accent: "kelantan"},
+			{FinalTranscription: "makan", Accent: "johor"},
+		}, "", 0},
+		{"timestamps missing from a part", []*models.AutocompleteData{
+			{FinalTranscription: "saya", WordTimestamps: []models.WordTimestamp{{StartTime: 0, EndTime: 1}}},
+			{FinalTranscription: "makan"},
+		}, "", 0},
+		{"empty part keeps timestamps", []*models.AutocompleteData{
+			{FinalTranscription: "saya", WordTimestamps: []models.WordTimestamp{{StartTime: 0, EndTime: 1}}},
+			{},
+			{FinalTranscription: "makan", WordTimestamps: []models.WordTimestamp{{StartTime: 0, EndTime: 1}}},
+		}, "", 2},
+	}
+	for _, tt := range tests {
+		ids := make([]string, len(tt.parts))
+		for i := range ids {
+			ids[i] = string(rune('a' + i))
+		}
+		merged, _ := MergeAutocompleteData(ids, tt.parts)
+		if merged.Accent != tt.wantAccent || len(merged.WordTimestamps) != tt.wantTimestamps {
+			t.Errorf("%s: accent %q, %d timestamps, want %q and %d", tt.name, merged.Accent, len(merged.WordTimestamps), tt.wantAccent, tt.wantTimestamps)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"autocomplete/models"
+)
+
+// Bigrams counts, for each word of a clip's baseline, the words that follow it
+type Bigrams map[string]map[string]int
+
+// Baseline bigrams per clip, derived from the position map and guarded by cacheMutex
+var clipBigrams = make(map[string]Bigrams)
+
+// BaselineWord returns the word a position currently reads as: its rank 1
+// candidate, or the best candidate when the baseline word was filtered out
+func BaselineWord(candidates []models.WordSuggestion) (string, bool) {
+	for _, candidate := range candidates {
+		if candidate.Rank == 1 {
+			return candidate.Text, true
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0].Text, true
+	}
+	return "", false
+}
+
+// BuildBigrams counts the word pairs of adjacent baseline positions
+func BuildBigrams(positionMap models.PositionMap) Bigrams {
+	bigrams := make(Bigrams)
+	for pos := 0; pos+1 < len(positionMap); pos++ {
+		word, ok := BaselineWord(positionMap[pos])
+		next, nextOK := BaselineWord(positionMap[pos+1])
+		if !ok || !nextOK {
+			continue
+		}
+		if bigrams[word] == nil {
+			bigrams[word] = make(map[string]int)
+		}
+		bigrams[word][next]++
+	}
+	return bigrams
+}
+
+// Followers returns the words seen after word, most frequent first
+func (b Bigrams) Followers(word string) []string {
+	followers := make([]string, 0, len(b[word]))
+	for next := range b[word] {
+		followers = append(followers, next)
+	}
+	sort.Slice(followers, func(i, j int) bool {
+		if b[word][followers[i]] != b[word][followers[j]] {
+			return b[word][followers[i]] > b[word][followers[j]]
+		}
+		return followers[i] < followers[j]
+	})
+	return followers
+}
+
+// ClipBigrams returns the baseline bigrams of a cached clip
+func ClipBigrams(audioID string) (Bigrams, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	if bigrams, exists := clipBigrams[audioID]; exists {
+		return bigrams, nil
+	}
+	if _, exists := clipPositions[audioID]; !exists {
+		return nil, fmt.Errorf("autocomplete not initialized for clip %s, please initialize first", audioID)
+	}
+	return Bigrams{}, nil
+}
+
+// setClipPositions caches a clip's position map and the bigrams, word
+// occurrences and language sub-indexes derived from it, or drops them all when
+// positionMap is nil. Callers must hold cacheMutex.
+func setClipPositions(audioID string, positionMap models.PositionMap) {
+	if positionMap == nil {
+		delete(clipPositions, audioID)
+		delete(clipBigrams, audioID)
+		delete(clipOccurrences, audioID)
+		delete(clipLanguageTries, audioID)
+		return
+	}
+	clipPositions[audioID] = positionMap
+	clipBigrams[audioID] = BuildBigrams(positionMap)
+	clipOccurrences[audioID] = BuildOccurrences(positionMap)
+	clipLanguageTries[audioID] = BuildLanguageTries(audioID, positionMap)
+}
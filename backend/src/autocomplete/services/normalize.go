@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"autocomplete/models"
+)
+
+// Text normalization modes
+const (
+	NormalizeOff   = "off"
+	NormalizeStrip = "strip"
+)
+
+// textNormalization is the active mode, set by ConfigureTextNormalization
+var textNormalization = NormalizeOff
+
+// ConfigureTextNormalization sets how transcripts and queries are cleaned
+// before they reach the index
+func ConfigureTextNormalization(mode string) error {
+	switch mode {
+	case "":
+		mode = NormalizeOff
+	case NormalizeOff, NormalizeStrip:
+	default:
+		return fmt.Errorf("unknown text normalization mode %q", mode)
+	}
+	textNormalization = mode
+	return nil
+}
+
+// TextNormalization returns the active normalization mode
+func TextNormalization() string {
+	return textNormalization
+}
+
+// NormalizeText applies the active mode to text. In strip mode zero-width and
+// other invisible format characters are removed, and emoji, symbols and
+// punctuation become spaces unless the punctuation joins two letters or digits,
+// so "kanak-kanak" and "don't" survive while "makan,😀" becomes "makan".
+func NormalizeText(text string) string {
+	if textNormalization != NormalizeStrip {
+		return text
+	}
+
+	runes := []rune(text)
+	var normalized strings.Builder
+	normalized.Grow(len(text))
+	for i, r := range runes {
+		switch {
+		case unicode.Is(unicode.Cf, r) || isEmojiModifier(r):
+			// Zero-width spaces/joiners, BOMs, soft hyphens, variation selectors
+			continue
+		case unicode.IsPunct(r) && isWordChar(runes, i-1) && isWordChar(runes, i+1):
+			normalized.WriteRune(r)
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			normalized.WriteRune(' ')
+		default:
+			normalized.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(normalized.String()), " ")
+}
+
+// NormalizeQuery applies the active mode to a typed prefix. A trailing hyphen
+// or apostrophe after a letter is kept, since the user may be mid-way through
+// typing "kanak-kanak" or "don't".
+func NormalizeQuery(prefix string) string {
+	normalized := NormalizeText(prefix)
+	if textNormalization != NormalizeStrip || normalized == "" {
+		return normalized
+	}
+
+	runes := []rune(strings.TrimRightFunc(prefix, func(r rune) bool {
+		return unicode.Is(unicode.Cf, r) || isEmojiModifier(r)
+	}))
+	if n := len(runes); n >= 2 && (runes[n-1] == '-' || runes[n-1] == '\'') && isWordChar(runes, n-2) {
+		normalized += string(runes[n-1])
+	}
+	return normalized
+}
+
+// NormalizeAutocompleteData returns a copy of the payload with every transcript
+// and particle normalized, or the payload itself when normalization is off
+func NormalizeAutocompleteData(data *models.AutocompleteData) *models.AutocompleteData {
+	if textNormalization != NormalizeStrip {
+		return data
+	}
+
+	normalized := *data
+	normalized.FinalTranscription = NormalizeText(data.FinalTranscription)
+
+	normalized.ASRAlternatives = make(map[string]string, len(data.ASRAlternatives))
+	for model, transcription := range data.ASRAlternatives {
+		normalized.ASRAlternatives[model] = NormalizeText(transcription)
+	}
+
+	normalized.DetectedParticles = make([]string, 0, len(data.DetectedParticles))
+	for _, particle := range data.DetectedParticles {
+		if particle = NormalizeText(particle); particle != "" {
+			normalized.DetectedParticles = append(normalized.DetectedParticles, particle)
+		}
+	}
+	return &normalized
+}
+
+// isWordChar reports whether runes[i] exists and is a letter or digit
+func isWordChar(runes []rune, i int) bool {
+	return i >= 0 && i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]))
+}
+
+// isEmojiModifier reports whether r only decorates an emoji: variation
+// selectors, skin tone modifiers and the keycap combining mark
+func isEmojiModifier(r rune) bool {
+	return r >= 0xFE00 && r <= 0xFE0F || r >= 0x1F3FB && r <= 0x1F3FF || r == 0x20E3
+}
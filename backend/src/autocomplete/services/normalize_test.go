@@ -0,0 +1,110 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestConfigureTextNormalization(t *testing.T) {
+	defer ConfigureTextNormalization(NormalizeOff)
+
+	tests := []struct {
+		mode    string
+		want    string
+		wantErr bool
+	}{
+		{"", NormalizeOff, false},
+		{NormalizeStrip, NormalizeStrip, false},
+		{NormalizeOff, NormalizeOff, false},
+		{"lowercase", NormalizeOff, true}, // An unknown mode keeps the previous one
+	}
+	for _, tt := range tests {
+		err := ConfigureTextNormalization(tt.mode)
+		if (err != nil) != tt.wantErr || TextNormalization() != tt.want {
+			t.Errorf("ConfigureTextNormalization(%q) = %v, mode %q, want %q", tt.mode, err, TextNormalization(), tt.want)
+		}
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	defer ConfigureTextNormalization(NormalizeOff)
+
+	tests := []struct {
+		text      string
+		wantOff   string
+		wantStrip string
+	}{
+		{"saya makan", "saya makan", "saya makan"},
+		{"makan,😀", "makan,😀", "makan"},
+		{"kanak-kanak don't", "kanak-kanak don't", "kanak-kanak don't"},
+		{"ma\u200bkan", "ma\u200bkan", "makan"},
+		{"👍🏽 ok ❤️", "👍🏽 ok ❤️", "ok"},
+		{"  (lah)  ", "  (lah)  ", "lah"},
+		{"harga $5.50", "harga $5.50", "harga 5.50"},
+	}
+	for _, tt := range tests {
+		ConfigureTextNormalization(NormalizeOff)
+		if got := NormalizeText(tt.text); got != tt.wantOff {
+			t.Errorf("off: NormalizeText(%q) = %q, want %q", tt.text, got, tt.wantOff)
+		}
+		ConfigureTextNormalization(NormalizeStrip)
+		if got := NormalizeText(tt.text); got != tt.wantStrip {
+			t.Errorf("strip: NormalizeText(%q) = %q, want %q", tt.text, got, tt.wantStrip)
+		}
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	defer ConfigureTextNormalization(NormalizeOff)
+	ConfigureTextNormalization(NormalizeStrip)
+
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"makan", "makan"},
+		{"kanak-", "kanak-"},
+		{"don'", "don'"},
+		{"don'\u200b", "don'"},
+		{"a-", "a-"},
+		{"-", ""},
+		{"makan!", "makan"},
+		{"makan😀", "makan"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeQuery(tt.prefix); got != tt.want {
+			t.Errorf("NormalizeQuery(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeAutocompleteData(t *testing.T) {
+	defer ConfigureTextNormalization(NormalizeOff)
+	data := &models.AutocompleteData{
+		FinalTranscription: "saya makan 😀",
+		ConfidenceScore:    0.9,
+		DetectedParticles:  []string{"lah!", "🙂"},
+		ASRAlternatives:    map[string]string{"whisper": "saya, makan"},
+	}
+
+	ConfigureTextNormalization(NormalizeOff)
+	if got := NormalizeAutocompleteData(data); got != data {
+		t.Error("NormalizeAutocompleteData copied the payload with normalization off")
+	}
+
+	ConfigureTextNormalization(NormalizeStrip)
+	want := &models.AutocompleteData{
+		FinalTranscription: "saya makan",
+		ConfidenceScore:    0.9,
+		DetectedParticles:  []string{"lah"},
+		ASRAlternatives:    map[string]string{"whisper": "saya makan"},
+	}
+	if got := NormalizeAutocompleteData(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeAutocompleteData() = %+v, want %+v", got, want)
+	}
+	if data.FinalTranscription != "saya makan 😀" || data.DetectedParticles[0] != "lah!" {
+		t.Errorf("NormalizeAutocompleteData changed its input: %+v", data)
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"autocomplete/models"
+)
+
+// Occurrences maps each baseline word of a clip, lower-cased, to the positions
+// it is read at, in order
+type Occurrences map[string][]int
+
+// Word → positions index per clip, derived from the position map and guarded by cacheMutex
+var clipOccurrences = make(map[string]Occurrences)
+
+// OccurrenceKey is the form words are indexed under: normalized, trimmed and lower-cased
+func OccurrenceKey(word string) string {
+	return strings.ToLower(strings.TrimSpace(NormalizeText(word)))
+}
+
+// BuildOccurrences indexes the positions of each baseline word
+func BuildOccurrences(positionMap models.PositionMap) Occurrences {
+	occurrences := make(Occurrences)
+	for pos, candidates := range positionMap {
+		if word, ok := BaselineWord(candidates); ok {
+			key := OccurrenceKey(word)
+			occurrences[key] = append(occurrences[key], pos)
+		}
+	}
+	for _, positions := range occurrences {
+		sort.Ints(positions)
+	}
+	return occurrences
+}
+
+// ClipOccurrences returns the positions a cached clip's baseline reads word at
+func ClipOccurrences(audioID, word string) ([]int, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	occurrences, exists := clipOccurrences[audioID]
+	if !exists {
+		return nil, fmt.Errorf("autocomplete not initialized for clip %s, please initialize first", audioID)
+	}
+	return occurrences[OccurrenceKey(word)], nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestBuildOccurrences(t *testing.T) {
+	positionMap := models.PositionMap{
+		3: {{Text: "makan", Rank: 1}},
+		0: {{Text: "Makan", Rank: 1}},
+		1: {{Text: "nasi", Rank: 2}, {Text: "makan", Rank: 1}}, // The baseline isn't always first
+		2: {},
+	}
+	want := Occurrences{"makan": {0, 1, 3}}
+	if got := BuildOccurrences(positionMap); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildOccurrences() = %v, want %v", got, want)
+	}
+}
+
+func TestClipOccurrences(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "Saya makan, saya minum"})
+
+	tests := []struct {
+		audioID, word string
+		want          []int
+		wantErr       bool
+	}{
+		{"clip", "saya", []int{0, 2}, false},
+		{"clip", " MINUM ", []int{3}, false},
+		{"clip", "tidur", nil, false},
+		{"other", "saya", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ClipOccurrences(tt.audioID, tt.word)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ClipOccurrences(%q, %q) = %v, %v, want %v (error %v)", tt.audioID, tt.word, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -5,19 +5,21 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"runtime"
 	"strings"
+	"sync"
 
 	"autocomplete/models"
 )
 
 // OrchestratorResponse represents the response from the orchestrator API
 type OrchestratorResponse struct {
-	Status    string `json:"status"`
-	Primary   string `json:"primary"`
-	Alternatives map[string]string `json:"alternatives"`
-	AutocompleteData *models.AutocompleteData `json:"autocomplete_data"`
-	PotentialParticles []interface{} `json:"potential_particles"`
-	Metadata struct {
+	Status             string                     `json:"status"`
+	Primary            string                     `json:"primary"`
+	Alternatives       map[string]string          `json:"alternatives"`
+	AutocompleteData   *models.AutocompleteData   `json:"autocomplete_data"`
+	PotentialParticles []models.PotentialParticle `json:"potential_particles"`
+	Metadata           struct {
 		Confidence     float64 `json:"confidence"`
 		ProcessingTime float64 `json:"processing_time"`
 		ModelsUsed     int     `json:"models_used"`
@@ -30,98 +32,245 @@ func LoadAutocompleteData(audioID string) (*models.AutocompleteData, error) {
 	if orchestratorURL == "" {
 		orchestratorURL = "http://localhost:8000"
 	}
-	
+
 	url := fmt.Sprintf("%s/transcribe-consensus", orchestratorURL)
-	
+
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call orchestrator: %w", err)
 	}
 	defer resp.Body.Close()
-	
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("orchestrator returned status %d", resp.StatusCode)
 	}
-	
+
 	var orchestratorResp OrchestratorResponse
 	if err := json.NewDecoder(resp.Body).Decode(&orchestratorResp); err != nil {
 		return nil, fmt.Errorf("failed to decode orchestrator response: %w", err)
 	}
-	
+
+	return extractAutocompleteData(&orchestratorResp), nil
+}
+
+// ParseOrchestratorOutput decodes a saved orchestrator response. Files holding a
+// bare AutocompleteData payload are accepted as well.
+func ParseOrchestratorOutput(raw []byte) (*models.AutocompleteData, error) {
+	var orchestratorResp OrchestratorResponse
+	if err := json.Unmarshal(raw, &orchestratorResp); err != nil {
+		return nil, fmt.Errorf("failed to decode orchestrator output: %w", err)
+	}
+
+	if orchestratorResp.AutocompleteData != nil || orchestratorResp.Primary != "" {
+		return extractAutocompleteData(&orchestratorResp), nil
+	}
+
+	var autocompleteData models.AutocompleteData
+	if err := json.Unmarshal(raw, &autocompleteData); err != nil {
+		return nil, fmt.Errorf("failed to decode autocomplete data: %w", err)
+	}
+	if autocompleteData.FinalTranscription == "" {
+		return nil, fmt.Errorf("orchestrator output has no transcription")
+	}
+
+	return &autocompleteData, nil
+}
+
+// extractAutocompleteData picks the autocomplete payload out of an orchestrator response
+func extractAutocompleteData(orchestratorResp *OrchestratorResponse) *models.AutocompleteData {
 	// Use the pre-extracted autocomplete data if available
 	if orchestratorResp.AutocompleteData != nil {
-		return orchestratorResp.AutocompleteData, nil
+		return orchestratorResp.AutocompleteData
 	}
-	
+
 	// Fallback to manual extraction (for backward compatibility)
-	autocompleteData := &models.AutocompleteData{
+	return &models.AutocompleteData{
 		FinalTranscription: orchestratorResp.Primary,
-		ConfidenceScore:   orchestratorResp.Metadata.Confidence,
-		DetectedParticles: []string{}, // TODO: Extract from PotentialParticles
-		ASRAlternatives:   orchestratorResp.Alternatives,
+		ConfidenceScore:    orchestratorResp.Metadata.Confidence,
+		DetectedParticles:  detectParticles(orchestratorResp.Primary),
+		ASRAlternatives:    orchestratorResp.Alternatives,
+		PotentialParticles: orchestratorResp.PotentialParticles,
 	}
-	
-	return autocompleteData, nil
 }
 
-// BuildDataStructures transforms orchestrator results into autocomplete data structures
-func BuildDataStructures(autocompleteData *models.AutocompleteData) *models.PrefixTrie {
-	fmt.Println("DEBUG: BuildDataStructures called") // ADDED
-	fmt.Println("DEBUG: FinalTranscription received:", autocompleteData.FinalTranscription) // ADDED
+// detectParticles picks the discourse particles from the packaged lexicon out of a transcription
+func detectParticles(transcription string) []string {
+	particles := []string{}
+	known, err := ParticleLexicon()
+	if err != nil {
+		return particles
+	}
 
+	seen := make(map[string]bool)
+	for _, token := range strings.Fields(transcription) {
+		word := normalizeProfanityToken(token)
+		if known[word] && !seen[word] {
+			seen[word] = true
+			particles = append(particles, word)
+		}
+	}
+	return particles
+}
+
+// buildWorkers bounds the goroutines aligning models and filling tries during a build
+var buildWorkers = runtime.NumCPU()
+
+// SetBuildWorkers sets how many goroutines a build may use; values below 1 are ignored
+func SetBuildWorkers(workers int) {
+	if workers > 0 {
+		buildWorkers = workers
+	}
+}
+
+// BuildWorkers returns the build concurrency limit
+func BuildWorkers() int {
+	return buildWorkers
+}
+
+// BuildDataStructures transforms orchestrator results into autocomplete data structures:
+// the per-position candidate map and the prefix trie built from it
+func BuildDataStructures(autocompleteData *models.AutocompleteData) (models.PositionMap, *models.PrefixTrie) {
+
+	positionMap := BuildPositionMap(autocompleteData)
+	return positionMap, buildTrie(positionMap)
+}
+
+// buildTrie indexes every candidate of the position map, in position order
+func buildTrie(positionMap models.PositionMap) *models.PrefixTrie {
 	prefixTrie := models.NewPrefixTrie("global")
 
+	var suggestions []models.WordSuggestion
+	for pos := 0; pos < len(positionMap); pos++ {
+		suggestions = append(suggestions, positionMap[pos]...)
+	}
+	prefixTrie.InsertAll(suggestions, BuildWorkers())
+
+	return prefixTrie
+}
+
+// BuildPositionMap aligns every model's words to the baseline and collects the
+// candidates heard at each word position
+func BuildPositionMap(autocompleteData *models.AutocompleteData) models.PositionMap {
+	positionMap := make(models.PositionMap)
+
+	// Sources agreeing on each word per position, including alternatives that match the baseline
+	votes := make(map[int]map[string]int)
+	vote := func(pos int, word string) {
+		if votes[pos] == nil {
+			votes[pos] = make(map[string]int)
+		}
+		votes[pos][word]++
+	}
+
 	// STEP 1: Use final transcription as baseline
-	baselineWords := strings.Fields(autocompleteData.FinalTranscription)
-	fmt.Println("DEBUG: Baseline words:", baselineWords) // ADDED
+	baselineWords := TranscriptWords(autocompleteData.FinalTranscription)
 
-	for _, baseWord := range baselineWords {
-		suggestion := models.WordSuggestion{
+	for pos, baseWord := range baselineWords {
+		positionMap[pos] = []models.WordSuggestion{}
+		vote(pos, baseWord)
+
+		confidence, keep := FilterIngestWord(baseWord, autocompleteData.ConfidenceScore)
+		if !keep {
+			continue
+		}
+
+		positionMap[pos] = append(positionMap[pos], models.WordSuggestion{
 			Text:       baseWord,
-			Confidence: autocompleteData.ConfidenceScore,
+			Confidence: confidence,
 			Source:     "gemini_final",
 			Rank:       1,
-		}
-
-		prefixTrie.Insert(baseWord, suggestion)
-		fmt.Println("DEBUG: Inserted word:", baseWord) // ADDED
+		})
 	}
 
-	// STEP 2: Add ASR alternatives
+	// STEP 2: Add ASR alternatives. Models are aligned concurrently, bounded by
+	// the build worker count, then merged in model order so builds are deterministic.
 	wordBasedModels := []string{"whisper", "mesolitica", "vosk", "wav2vec", "moonshine"}
 
-	for _, modelName := range wordBasedModels {
-		if transcription, exists := autocompleteData.ASRAlternatives[modelName]; exists {
-			modelWords := strings.Fields(transcription)
-			alignedAlternatives := alignToBaseline(baselineWords, modelWords)
-
-			for pos, altWord := range alignedAlternatives {
-				if pos >= len(baselineWords) {
-					continue // Skip if model has extra words
-				}
-
-				if altWord != baselineWords[pos] { // Only add if different from baseline
-					suggestion := models.WordSuggestion{
-						Text:       altWord,
-						Confidence: 0.7, // Raw ASR = lower confidence
-						Source:     modelName,
-						Rank:       2,
-					}
-
-					prefixTrie.Insert(altWord, suggestion)
-				}
+	aligned := make([]modelAlignment, len(wordBasedModels))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, BuildWorkers())
+	for i, modelName := range wordBasedModels {
+		transcription, exists := autocompleteData.ASRAlternatives[modelName]
+		if !exists {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, modelName, transcription string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			aligned[i] = alignModel(modelName, transcription, baselineWords)
+		}(i, modelName, transcription)
+	}
+	wg.Wait()
+
+	for _, alignment := range aligned {
+		for pos, word := range alignment.words {
+			if word != "" {
+				vote(pos, word)
 			}
 		}
+		for _, candidate := range alignment.candidates {
+			positionMap[candidate.pos] = append(positionMap[candidate.pos], candidate.suggestion)
+		}
 	}
 
-	return prefixTrie
+	for pos, candidates := range positionMap {
+		for i := range candidates {
+			candidates[i].Agreement = votes[pos][candidates[i].Text]
+		}
+	}
+
+	return positionMap
+}
+
+// modelAlignment is one model's words aligned to the baseline positions and the
+// candidates it contributes where it disagrees with the baseline
+type modelAlignment struct {
+	words      []string
+	candidates []positionedSuggestion
 }
 
+type positionedSuggestion struct {
+	pos        int
+	suggestion models.WordSuggestion
+}
+
+// alignModel aligns one model's transcription to the baseline, position by position
+func alignModel(modelName, transcription string, baselineWords []string) modelAlignment {
+	alignedAlternatives := alignToBaseline(baselineWords, TranscriptWords(transcription))
+
+	alignment := modelAlignment{words: make([]string, len(baselineWords))}
+	for pos := range baselineWords {
+		altWord, exists := alignedAlternatives[pos]
+		if !exists {
+			continue // Model has fewer words than the baseline
+		}
+		alignment.words[pos] = altWord
+
+		if altWord != baselineWords[pos] { // Only add if different from baseline
+			confidence, keep := FilterIngestWord(altWord, 0.7) // Raw ASR = lower confidence
+			if !keep {
+				continue
+			}
+
+			alignment.candidates = append(alignment.candidates, positionedSuggestion{
+				pos: pos,
+				suggestion: models.WordSuggestion{
+					Text:       altWord,
+					Confidence: confidence,
+					Source:     modelName,
+					Rank:       2,
+				},
+			})
+		}
+	}
+	return alignment
+}
 
 func alignToBaseline(baseline []string, modelWords []string) map[int]string {
 	aligned := make(map[int]string)
 
-
 	minLen := len(baseline)
 	if len(modelWords) < minLen {
 		minLen = len(modelWords)
@@ -132,4 +281,4 @@ func alignToBaseline(baseline []string, modelWords []string) map[int]string {
 	}
 
 	return aligned
-}
\ No newline at end of file
+}
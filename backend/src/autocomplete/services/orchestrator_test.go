@@ -0,0 +1,109 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestParseOrchestratorOutput(t *testing.T) {
+	tests := []struct {
+		name             string
+		raw              string
+		wantTranscript   string
+		wantAlternatives map[string]string
+		wantConfidence   float64
+		wantErr          string
+	}{
+		{
+			name:           "pre-extracted autocomplete data",
+			raw:            `{"status": "ok", "primary": "ignored", "autocomplete_data": {"final_transcription": "saya makan", "confidence_score": 0.9}}`,
+			wantTranscript: "saya makan",
+			wantConfidence: 0.9,
+		},
+		{
+			name:             "consensus response",
+			raw:              `{"status": "ok", "primary": "saya makan", "alternatives": {"whisper": "saya makna"}, "metadata": {"confidence": 0.7}}`,
+			wantTranscript:   "saya makan",
+			wantAlternatives: map[string]string{"whisper": "saya makna"},
+			wantConfidence:   0.7,
+		},
+		{
+			name:           "bare autocomplete payload",
+			raw:            `{"final_transcription": "saya minum", "confidence_score": 0.5}`,
+			wantTranscript: "saya minum",
+			wantConfidence: 0.5,
+		},
+		{name: "no transcription", raw: `{"status": "ok"}`, wantErr: "orchestrator output has no transcription"},
+		{name: "not json", raw: `saya makan`, wantErr: "failed to decode orchestrator output: invalid character 's' looking for beginning of value"},
+		{name: "wrong field type", raw: `{"primary": 1}`, wantErr: "failed to decode orchestrator output: json: cannot unmarshal number into Go struct field OrchestratorResponse.primary of type string"},
+	}
+	for _, tt := range tests {
+		data, err := ParseOrchestratorOutput([]byte(tt.raw))
+		gotErr := ""
+		if err != nil {
+			gotErr = err.Error()
+		}
+		if gotErr != tt.wantErr {
+			t.Errorf("%s: ParseOrchestratorOutput() error = %q, want %q", tt.name, gotErr, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if data.FinalTranscription != tt.wantTranscript || data.ConfidenceScore != tt.wantConfidence || !reflect.DeepEqual(data.ASRAlternatives, tt.wantAlternatives) {
+			t.Errorf("%s: ParseOrchestratorOutput() = %+v", tt.name, data)
+		}
+	}
+}
+
+func TestSetBuildWorkers(t *testing.T) {
+	defer SetBuildWorkers(BuildWorkers())
+
+	tests := []struct {
+		workers int
+		want    int
+	}{
+		{4, 4},
+		{0, 4},
+		{-2, 4},
+		{1, 1},
+	}
+	for _, tt := range tests {
+		SetBuildWorkers(tt.workers)
+		if got := BuildWorkers(); got != tt.want {
+			t.Errorf("SetBuildWorkers(%d): BuildWorkers() = %d, want %d", tt.workers, got, tt.want)
+		}
+	}
+}
+
+func TestBuildIsDeterministicAcrossWorkers(t *testing.T) {
+	defer SetBuildWorkers(BuildWorkers())
+	data := &models.AutocompleteData{
+		FinalTranscription: "saya nak makan nasi lemak pagi ini",
+		ConfidenceScore:    0.9,
+		ASRAlternatives: map[string]string{
+			"whisper":    "saya nak makan nasi lemak pagi ni",
+			"mesolitica": "saya na makan nasi lema pagi ini",
+			"vosk":       "sayang nak makan nasi",
+			"wav2vec":    "saya nak makna nasi lemak bagi ini",
+			"moonshine":  "saya nak makan nasik lemak pagi ini tadi",
+		},
+	}
+
+	SetBuildWorkers(1)
+	wantMap, wantTrie := BuildDataStructures(data)
+	for _, workers := range []int{2, 3, 8} {
+		SetBuildWorkers(workers)
+		for run := 0; run < 5; run++ {
+			positionMap, trie := BuildDataStructures(data)
+			if !reflect.DeepEqual(positionMap, wantMap) {
+				t.Errorf("%d workers, run %d: position map differs from a single worker build", workers, run)
+			}
+			if !reflect.DeepEqual(trie.Words(), wantTrie.Words()) {
+				t.Errorf("%d workers, run %d: trie differs from a single worker build", workers, run)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"strings"
+
+	"autocomplete/models"
+)
+
+// Sentence slots a particle can occupy
+const (
+	SlotInitial = "initial"
+	SlotMedial  = "medial"
+	SlotFinal   = "final"
+)
+
+// ParticleLexicon returns the packaged discourse particles, lower-cased
+func ParticleLexicon() (map[string]bool, error) {
+	lexicon, err := LoadResource(ResourceParticles)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(lexicon))
+	for _, particle := range lexicon {
+		known[strings.ToLower(particle)] = true
+	}
+	return known, nil
+}
+
+// ParticleOccurrence is a particle found in a transcript: its word position, its
+// sentence slot and the word before it, empty when the particle opens the transcript
+type ParticleOccurrence struct {
+	Particle string
+	Position int
+	Slot     string
+	After    string
+}
+
+// FindParticles returns the particles among a transcript's tokens, in order
+func FindParticles(tokens []models.Token, lexicon map[string]bool) []ParticleOccurrence {
+	var occurrences []ParticleOccurrence
+	for pos, token := range tokens {
+		particle := strings.ToLower(token.Text)
+		if !lexicon[particle] {
+			continue
+		}
+		occurrence := ParticleOccurrence{Particle: particle, Position: pos, Slot: SentenceSlot(tokens, pos)}
+		if pos > 0 {
+			occurrence.After = strings.ToLower(tokens[pos-1].Text)
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences
+}
+
+// SentenceSlot reports whether the token at pos opens a sentence, ends one or
+// sits inside it. Sentences end at the transcript's end or at a token whose
+// trailing punctuation holds '.', '!' or '?'.
+func SentenceSlot(tokens []models.Token, pos int) string {
+	switch {
+	case pos == 0 || endsSentence(tokens[pos-1]):
+		return SlotInitial
+	case pos == len(tokens)-1 || endsSentence(tokens[pos]):
+		return SlotFinal
+	default:
+		return SlotMedial
+	}
+}
+
+func endsSentence(token models.Token) bool {
+	return strings.ContainsAny(token.Trailing, ".!?")
+}
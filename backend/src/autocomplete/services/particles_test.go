@@ -0,0 +1,42 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindParticles(t *testing.T) {
+	lexicon, err := ParticleLexicon()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text string
+		want []ParticleOccurrence
+	}{
+		{"saya makan", nil},
+		{"Lah, saya makan", []ParticleOccurrence{{Particle: "lah", Position: 0, Slot: SlotInitial}}},
+		{"saya pun makan", []ParticleOccurrence{{Particle: "pun", Position: 1, Slot: SlotMedial, After: "saya"}}},
+		{"makan LAH", []ParticleOccurrence{{Particle: "lah", Position: 1, Slot: SlotFinal, After: "makan"}}},
+		{"jom lah! kan best", []ParticleOccurrence{
+			{Particle: "lah", Position: 1, Slot: SlotFinal, After: "jom"},
+			{Particle: "kan", Position: 2, Slot: SlotInitial, After: "lah"},
+		}},
+	}
+	for _, tt := range tests {
+		if got := FindParticles(Tokenize(tt.text), lexicon); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FindParticles(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSentenceSlot(t *testing.T) {
+	tokens := Tokenize("Saya makan. Dia minum? Ok")
+	want := []string{SlotInitial, SlotFinal, SlotInitial, SlotFinal, SlotInitial}
+	for pos := range tokens {
+		if got := SentenceSlot(tokens, pos); got != want[pos] {
+			t.Errorf("SentenceSlot(%d) = %s, want %s", pos, got, want[pos])
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sync"
+
+	"autocomplete/models"
+)
+
+// PII redaction modes
+const (
+	PIIOff    = "off"
+	PIIDetect = "detect"
+	PIIRedact = "redact"
+)
+
+// PIIRule is one entity pattern checked at ingest
+type PIIRule struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+
+	re *regexp.Regexp
+}
+
+// defaultPIIRules cover Malaysian IC numbers, phone numbers and emails. IC
+// numbers are matched first so their digits are not half-matched as phones.
+var defaultPIIRules = []PIIRule{
+	{Name: "ic_number", Pattern: `\b\d{6}-?\d{2}-?\d{4}\b`, Replacement: "[IC]"},
+	{Name: "email", Pattern: `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`, Replacement: "[EMAIL]"},
+	{Name: "phone", Pattern: `(?:\+?60[-\s]?|\b0)1\d[-\s]?\d{3,4}[-\s]?\d{4}\b`, Replacement: "[PHONE]"},
+	{Name: "phone", Pattern: `(?:\+?60[-\s]?|\b0)[3-9][-\s]?\d{3,4}[-\s]?\d{4}\b`, Replacement: "[PHONE]"},
+}
+
+// Active redaction configuration, replaced wholesale by ConfigurePIIRedaction
+var (
+	piiMode  = PIIOff
+	piiRules []PIIRule
+	piiMutex sync.RWMutex
+)
+
+// ConfigurePIIRedaction sets the redaction mode and compiles the rules, read
+// from a JSON array at rulesPath or the built-in defaults when empty.
+func ConfigurePIIRedaction(mode, rulesPath string) error {
+	switch mode {
+	case "":
+		mode = PIIOff
+	case PIIOff, PIIDetect, PIIRedact:
+	default:
+		return fmt.Errorf("unknown PII redaction mode %q", mode)
+	}
+
+	rules := defaultPIIRules
+	if rulesPath != "" {
+		raw, err := os.ReadFile(rulesPath)
+		if err != nil {
+			return fmt.Errorf("failed to read PII rules: %w", err)
+		}
+		rules = nil
+		if err := json.Unmarshal(raw, &rules); err != nil {
+			return fmt.Errorf("failed to decode PII rules: %w", err)
+		}
+	}
+
+	compiled := make([]PIIRule, len(rules))
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern for PII rule %s: %w", rule.Name, err)
+		}
+		rule.re = re
+		if rule.Replacement == "" {
+			rule.Replacement = "[REDACTED]"
+		}
+		compiled[i] = rule
+	}
+
+	piiMutex.Lock()
+	piiMode = mode
+	piiRules = compiled
+	piiMutex.Unlock()
+
+	return nil
+}
+
+// RedactAutocompleteData scans every transcription in the payload for PII.
+// In redact mode a redacted copy is returned, its word positions moved onto
+// the redacted baseline; otherwise the payload is returned unchanged. The
+// report never contains the matched values themselves.
+func RedactAutocompleteData(data *models.AutocompleteData) (*models.AutocompleteData, *models.RedactionReport) {
+	piiMutex.RLock()
+	mode, rules := piiMode, piiRules
+	piiMutex.RUnlock()
+
+	report := &models.RedactionReport{
+		Mode:     mode,
+		Entities: map[string]int{},
+		Fields:   map[string]int{},
+	}
+	if mode == PIIOff {
+		return data, report
+	}
+
+	redacted := *data
+	var positions []int
+	redacted.FinalTranscription, positions = redactBaseline(data.FinalTranscription, rules, report)
+
+	redacted.ASRAlternatives = make(map[string]string, len(data.ASRAlternatives))
+	for model, transcription := range data.ASRAlternatives {
+		redacted.ASRAlternatives[model] = scanPII(transcription, "asr_alternatives."+model, rules, report)
+	}
+
+	redacted.DetectedParticles = make([]string, len(data.DetectedParticles))
+	for i, particle := range data.DetectedParticles {
+		redacted.DetectedParticles[i] = scanPII(particle, "detected_particles", rules, report)
+	}
+
+	report.Redacted = mode == PIIRedact && report.TotalMatches > 0
+	if mode == PIIDetect {
+		return data, report
+	}
+	if report.Fields["final_transcription"] > 0 {
+		remapWordPositions(&redacted, positions)
+	}
+	return &redacted, report
+}
+
+// redactBaseline is scanPII for the baseline, also returning where each of
+// its words ends up: the position of the same word in the redacted baseline,
+// or of the placeholder that replaced the span holding it
+func redactBaseline(text string, rules []PIIRule, report *models.RedactionReport) (string, []int) {
+	spans := wordSpans(text)
+	for _, rule := range rules {
+		matches := rule.re.FindAllStringIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		for i, span := range spans {
+			spans[i] = [2]int{
+				shiftOffset(span[0], false, matches, len(rule.Replacement)),
+				shiftOffset(span[1], true, matches, len(rule.Replacement)),
+			}
+		}
+		text = scanPII(text, "final_transcription", []PIIRule{rule}, report)
+	}
+
+	// A word belongs to the last redacted word starting before it ends
+	redactedSpans := wordSpans(text)
+	positions := make([]int, len(spans))
+	j := 0
+	for i, span := range spans {
+		for j+1 < len(redactedSpans) && redactedSpans[j+1][0] < span[1] {
+			j++
+		}
+		positions[i] = j
+	}
+	return text, positions
+}
+
+// wordSpans returns the byte range of each word of a transcript, one per
+// clip position
+func wordSpans(text string) [][2]int {
+	spans := [][2]int{}
+	offset := 0
+	for _, token := range Tokenize(text) {
+		offset += len(token.Leading)
+		if token.Text != "" {
+			spans = append(spans, [2]int{offset, offset + len(token.Text)})
+		}
+		offset += len(token.Text) + len(token.Trailing) + len(token.Separator)
+	}
+	return spans
+}
+
+// shiftOffset moves a byte offset of text to where it lands once every match
+// is replaced by a replacement of the given length. Offsets inside a match
+// land on the replacement's start, or its end for the end of a range.
+func shiftOffset(offset int, end bool, matches [][]int, replacement int) int {
+	delta := 0
+	for _, match := range matches {
+		if offset < match[0] || end && offset == match[0] {
+			break
+		}
+		if offset < match[1] || end && offset == match[1] {
+			if end {
+				return match[0] + delta + replacement
+			}
+			return match[0] + delta
+		}
+		delta += replacement - (match[1] - match[0])
+	}
+	return offset + delta
+}
+
+// remapWordPositions moves the baseline positions a payload refers to onto
+// the redacted baseline. A placeholder takes over the segments of the words it
+// replaced, and their timestamps merge into one spanning them all. Positions
+// past the baseline keep their distance from its end, for normalization to clamp.
+func remapWordPositions(data *models.AutocompleteData, positions []int) {
+	if len(positions) == 0 {
+		return
+	}
+	at := func(position int) int {
+		if position < 0 {
+			return position
+		}
+		if position >= len(positions) {
+			return positions[len(positions)-1] + 1 + position - len(positions)
+		}
+		return positions[position]
+	}
+
+	if len(data.SpeakerSegments) > 0 {
+		speakers := make([]models.SpeakerSegment, len(data.SpeakerSegments))
+		for i, segment := range data.SpeakerSegments {
+			segment.StartWord, segment.EndWord = at(segment.StartWord), at(segment.EndWord)
+			speakers[i] = segment
+		}
+		data.SpeakerSegments = speakers
+	}
+
+	if len(data.Segments) > 0 {
+		segments := make([]models.AudioSegment, len(data.Segments))
+		for i, segment := range data.Segments {
+			segment.StartWord, segment.EndWord = at(segment.StartWord), at(segment.EndWord)
+			segments[i] = segment
+		}
+		data.Segments = segments
+	}
+
+	if len(data.PotentialParticles) > 0 {
+		particles := make([]models.PotentialParticle, len(data.PotentialParticles))
+		for i, particle := range data.PotentialParticles {
+			particle.WordIndex = at(particle.WordIndex)
+			particles[i] = particle
+		}
+		data.PotentialParticles = particles
+	}
+
+	if len(data.WordTimestamps) > 0 {
+		timestamps := make([]models.WordTimestamp, 0, len(data.WordTimestamps))
+		for i, timestamp := range data.WordTimestamps {
+			if len(timestamps) > 0 && at(i) < len(timestamps) {
+				merged := &timestamps[len(timestamps)-1]
+				merged.EndTime = math.Max(merged.EndTime, timestamp.EndTime)
+				continue
+			}
+			timestamps = append(timestamps, timestamp)
+		}
+		data.WordTimestamps = timestamps
+	}
+}
+
+// scanPII counts rule matches in text and returns it with matches replaced
+func scanPII(text, field string, rules []PIIRule, report *models.RedactionReport) string {
+	for _, rule := range rules {
+		matches := len(rule.re.FindAllStringIndex(text, -1))
+		if matches == 0 {
+			continue
+		}
+		report.TotalMatches += matches
+		report.Entities[rule.Name] += matches
+		report.Fields[field] += matches
+		text = rule.re.ReplaceAllLiteralString(text, rule.Replacement)
+	}
+	return text
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestRedactRemapsWordPositions(t *testing.T) {
+	if err := ConfigurePIIRedaction(PIIRedact, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer ConfigurePIIRedaction(PIIOff, "")
+
+	tests := []struct {
+		name          string
+		transcript    string
+		want          string
+		wantPositions []int
+	}{
+		{
+			name:          "nothing to redact",
+			transcript:    "saya nak makan",
+			want:          "saya nak makan",
+			wantPositions: []int{0, 1, 2},
+		},
+		{
+			name:          "multi-word phone collapses",
+			transcript:    "call me at 012 345 6789 tomorrow ok",
+			want:          "call me at [PHONE] tomorrow ok",
+			wantPositions: []int{0, 1, 2, 3, 3, 3, 4, 5},
+		},
+		{
+			name:          "single-word email with punctuation",
+			transcript:    "email ali@example.com, then phone 03-1234 5678.",
+			want:          "email [EMAIL], then phone [PHONE].",
+			wantPositions: []int{0, 1, 2, 3, 4, 4},
+		},
+		{
+			name:          "two spans",
+			transcript:    "ic 900101-14-5678 and 012 345 6789",
+			want:          "ic [IC] and [PHONE]",
+			wantPositions: []int{0, 1, 2, 3, 3, 3},
+		},
+	}
+	for _, tt := range tests {
+		report := &models.RedactionReport{Entities: map[string]int{}, Fields: map[string]int{}}
+		got, positions := redactBaseline(tt.transcript, piiRules, report)
+		if got != tt.want {
+			t.Errorf("%s: redacted %q, want %q", tt.name, got, tt.want)
+		}
+		if !reflect.DeepEqual(positions, tt.wantPositions) {
+			t.Errorf("%s: positions %v, want %v", tt.name, positions, tt.wantPositions)
+		}
+	}
+}
+
+func TestRedactAutocompleteDataRemapsSegments(t *testing.T) {
+	if err := ConfigurePIIRedaction(PIIRedact, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer ConfigurePIIRedaction(PIIOff, "")
+
+	data := &models.AutocompleteData{
+		FinalTranscription: "call me at 012 345 6789 tomorrow ok",
+		SpeakerSegments: []models.SpeakerSegment{
+			{Speaker: "A", StartWord: 0, EndWord: 2},
+			{Speaker: "B", StartWord: 3, EndWord: 7},
+		},
+		Segments: []models.AudioSegment{{ID: "s1", StartWord: 4, EndWord: 6}},
+		WordTimestamps: []models.WordTimestamp{
+			{StartTime: 0, EndTime: 1}, {StartTime: 1, EndTime: 2}, {StartTime: 2, EndTime: 3},
+			{StartTime: 3, EndTime: 4}, {StartTime: 4, EndTime: 5}, {StartTime: 5, EndTime: 6},
+			{StartTime: 6, EndTime: 7}, {StartTime: 7, EndTime: 8},
+		},
+		PotentialParticles: []models.PotentialParticle{{Particle: "lah", WordIndex: 7}},
+	}
+	redacted, _ := RedactAutocompleteData(data)
+
+	wantSpeakers := []models.SpeakerSegment{
+		{Speaker: "A", StartWord: 0, EndWord: 2},
+		{Speaker: "B", StartWord: 3, EndWord: 5},
+	}
+	if !reflect.DeepEqual(redacted.SpeakerSegments, wantSpeakers) {
+		t.Errorf("speaker segments %v, want %v", redacted.SpeakerSegments, wantSpeakers)
+	}
+	if got := redacted.Segments[0]; got.StartWord != 3 || got.EndWord != 4 {
+		t.Errorf("audio segment words %d-%d, want 3-4", got.StartWord, got.EndWord)
+	}
+	wantTimestamps := []models.WordTimestamp{
+		{StartTime: 0, EndTime: 1}, {StartTime: 1, EndTime: 2}, {StartTime: 2, EndTime: 3},
+		{StartTime: 3, EndTime: 6}, {StartTime: 6, EndTime: 7}, {StartTime: 7, EndTime: 8},
+	}
+	if !reflect.DeepEqual(redacted.WordTimestamps, wantTimestamps) {
+		t.Errorf("word timestamps %v, want %v", redacted.WordTimestamps, wantTimestamps)
+	}
+	if got := redacted.PotentialParticles[0].WordIndex; got != 5 {
+		t.Errorf("particle word index %d, want 5", got)
+	}
+	if data.SpeakerSegments[1].EndWord != 7 {
+		t.Errorf("input payload was modified")
+	}
+}
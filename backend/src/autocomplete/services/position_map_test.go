@@ -0,0 +1,85 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestBuildPositionMap(t *testing.T) {
+	type candidate struct {
+		text      string
+		source    string
+		agreement int
+	}
+	tests := []struct {
+		name string
+		data *models.AutocompleteData
+		want map[int][]candidate
+	}{
+		{
+			name: "baseline only",
+			data: &models.AutocompleteData{FinalTranscription: "saya makan", ConfidenceScore: 0.9},
+			want: map[int][]candidate{
+				0: {{"saya", "gemini_final", 1}},
+				1: {{"makan", "gemini_final", 1}},
+			},
+		},
+		{
+			name: "alternatives that differ are candidates, those that agree are votes",
+			data: &models.AutocompleteData{
+				FinalTranscription: "saya makan nasi",
+				ASRAlternatives: map[string]string{
+					"whisper":    "saya makna nasi",
+					"mesolitica": "saya makan nasi",
+					"vosk":       "saya makna nasi",
+				},
+			},
+			want: map[int][]candidate{
+				0: {{"saya", "gemini_final", 4}},
+				1: {{"makan", "gemini_final", 2}, {"makna", "whisper", 2}, {"makna", "vosk", 2}},
+				2: {{"nasi", "gemini_final", 4}},
+			},
+		},
+		{
+			name: "shorter and longer alternatives",
+			data: &models.AutocompleteData{
+				FinalTranscription: "saya nak makan",
+				ASRAlternatives:    map[string]string{"whisper": "saya", "vosk": "sayang nak makan lah"},
+			},
+			want: map[int][]candidate{
+				0: {{"saya", "gemini_final", 2}, {"sayang", "vosk", 1}},
+				1: {{"nak", "gemini_final", 2}},
+				2: {{"makan", "gemini_final", 2}},
+			},
+		},
+		{
+			name: "models the build does not align are ignored",
+			data: &models.AutocompleteData{
+				FinalTranscription: "saya",
+				ASRAlternatives:    map[string]string{"llm": "sayang"},
+			},
+			want: map[int][]candidate{
+				0: {{"saya", "gemini_final", 1}},
+			},
+		},
+		{
+			name: "empty transcript",
+			data: &models.AutocompleteData{},
+			want: map[int][]candidate{},
+		},
+	}
+	for _, tt := range tests {
+		got := make(map[int][]candidate)
+		for pos, suggestions := range BuildPositionMap(tt.data) {
+			got[pos] = []candidate{}
+			for _, suggestion := range suggestions {
+				got[pos] = append(got[pos], candidate{suggestion.Text, suggestion.Source, suggestion.Agreement})
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: BuildPositionMap() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
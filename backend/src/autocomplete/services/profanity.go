@@ -0,0 +1,148 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"autocomplete/models"
+)
+
+// Profanity filter modes
+const (
+	ProfanityOff      = "off"
+	ProfanityDrop     = "drop"
+	ProfanityMask     = "mask"
+	ProfanityDownrank = "downrank"
+)
+
+// profanityDownrankFactor scales the confidence of profane words in downrank mode
+const profanityDownrankFactor = 0.1
+
+// Active filter configuration, replaced wholesale by ConfigureProfanityFilter
+var (
+	profanityMode  = ProfanityOff
+	profanityWords = map[string]bool{}
+	profanityMutex sync.RWMutex
+)
+
+// ConfigureProfanityFilter sets the filter mode and loads the word list from
+// listPath (one word per line), falling back to the packaged list when empty.
+func ConfigureProfanityFilter(mode, listPath string) error {
+	switch mode {
+	case "":
+		mode = ProfanityOff
+	case ProfanityOff, ProfanityDrop, ProfanityMask, ProfanityDownrank:
+	default:
+		return fmt.Errorf("unknown profanity mode %q", mode)
+	}
+
+	var list []string
+	var err error
+	if listPath != "" {
+		list, err = readWordList(listPath)
+	} else {
+		list, err = LoadResource(ResourceProfanity)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load profanity list: %w", err)
+	}
+
+	words := make(map[string]bool, len(list))
+	for _, word := range list {
+		words[normalizeProfanityToken(word)] = true
+	}
+
+	profanityMutex.Lock()
+	profanityMode = mode
+	profanityWords = words
+	profanityMutex.Unlock()
+
+	return nil
+}
+
+// ProfanityMode returns the active filter mode
+func ProfanityMode() string {
+	profanityMutex.RLock()
+	defer profanityMutex.RUnlock()
+	return profanityMode
+}
+
+// IsProfane reports whether the word is on the profanity list
+func IsProfane(word string) bool {
+	profanityMutex.RLock()
+	defer profanityMutex.RUnlock()
+	return profanityWords[normalizeProfanityToken(word)]
+}
+
+// FilterIngestWord applies the filter before a word is stored. Only drop mode
+// acts here, rejecting the word. Masking and downranking happen at suggest
+// time, so the word stays matchable by its real prefix, is stored at its real
+// confidence and follows list changes without a reindex.
+func FilterIngestWord(word string, confidence float64) (float64, bool) {
+	if ProfanityMode() == ProfanityDrop && IsProfane(word) {
+		return 0, false
+	}
+	return confidence, true
+}
+
+// FilterSuggestions applies the filter to ranked suggestions at suggest time
+func FilterSuggestions(suggestions []models.WordSuggestion) []models.WordSuggestion {
+	mode := ProfanityMode()
+	if mode == ProfanityOff {
+		return suggestions
+	}
+
+	filtered := make([]models.WordSuggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		if IsProfane(suggestion.Text) {
+			switch mode {
+			case ProfanityDrop:
+				continue
+			case ProfanityMask:
+				suggestion.Text = MaskWord(suggestion.Text)
+			case ProfanityDownrank:
+				suggestion.Confidence *= profanityDownrankFactor
+			}
+		}
+		filtered = append(filtered, suggestion)
+	}
+
+	if mode == ProfanityDownrank {
+		sort.SliceStable(filtered, func(i, j int) bool {
+			return filtered[i].Confidence > filtered[j].Confidence
+		})
+	}
+
+	return filtered
+}
+
+// MaskWord keeps the first letter and replaces the rest with asterisks
+func MaskWord(word string) string {
+	runes := []rune(word)
+	if len(runes) <= 1 {
+		return word
+	}
+	return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+}
+
+// normalizeProfanityToken lowercases and strips surrounding punctuation
+func normalizeProfanityToken(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	}))
+}
+
+// readWordList reads one entry per line, skipping blanks and # comments
+func readWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseWordList(file)
+}
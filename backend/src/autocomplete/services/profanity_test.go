@@ -0,0 +1,59 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestProfanityModes(t *testing.T) {
+	defer ConfigureProfanityFilter(ProfanityOff, "")
+
+	downranked := 0.9
+	downranked *= profanityDownrankFactor
+	suggestions := []models.WordSuggestion{
+		{Text: "fuck", Confidence: 0.9},
+		{Text: "fokus", Confidence: 0.5},
+		{Text: "bodoh", Confidence: 0.4},
+	}
+	tests := []struct {
+		mode           string
+		keepIngest     bool
+		ingestScore    float64
+		wantSuggestion []models.WordSuggestion
+	}{
+		{ProfanityOff, true, 0.9, suggestions},
+		{ProfanityDrop, false, 0, []models.WordSuggestion{
+			{Text: "fokus", Confidence: 0.5},
+			{Text: "bodoh", Confidence: 0.4},
+		}},
+		{ProfanityMask, true, 0.9, []models.WordSuggestion{
+			{Text: "f***", Confidence: 0.9},
+			{Text: "fokus", Confidence: 0.5},
+			{Text: "bodoh", Confidence: 0.4},
+		}},
+		// Downranked once, at suggest time only
+		{ProfanityDownrank, true, 0.9, []models.WordSuggestion{
+			{Text: "fokus", Confidence: 0.5},
+			{Text: "bodoh", Confidence: 0.4},
+			{Text: "fuck", Confidence: downranked},
+		}},
+	}
+	for _, tt := range tests {
+		if err := ConfigureProfanityFilter(tt.mode, ""); err != nil {
+			t.Fatalf("ConfigureProfanityFilter(%q): %v", tt.mode, err)
+		}
+		score, keep := FilterIngestWord("fuck", 0.9)
+		if keep != tt.keepIngest || score != tt.ingestScore {
+			t.Errorf("%s: FilterIngestWord = (%v, %v), want (%v, %v)", tt.mode, score, keep, tt.ingestScore, tt.keepIngest)
+		}
+		if score, keep := FilterIngestWord("bodoh", 0.4); !keep || score != 0.4 {
+			t.Errorf("%s: everyday word bodoh filtered at ingest: (%v, %v)", tt.mode, score, keep)
+		}
+		got := FilterSuggestions(append([]models.WordSuggestion(nil), suggestions...))
+		if !reflect.DeepEqual(got, tt.wantSuggestion) {
+			t.Errorf("%s: FilterSuggestions = %v, want %v", tt.mode, got, tt.wantSuggestion)
+		}
+	}
+}
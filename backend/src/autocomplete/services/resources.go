@@ -0,0 +1,95 @@
+package services
+
+import (
+	"bufio"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Packaged language resources, so the container image needs no volume mounts
+const (
+	ResourceWordlistMalay   = "wordlist_ms.txt"
+	ResourceWordlistEnglish = "wordlist_en.txt"
+	ResourceStopwords       = "stopwords.txt"
+	ResourceParticles       = "particles.txt"
+	ResourceProfanity       = "profanity.txt"
+	ResourceHomophones      = "homophones.txt"
+	ResourceStems           = "stems.txt"
+)
+
+//go:embed resources/*.txt
+var embeddedResources embed.FS
+
+// Directory whose files take precedence over the embedded copies
+var (
+	resourceOverrideDir string
+	resourceMutex       sync.RWMutex
+)
+
+// ConfigureResources sets a directory whose files override the embedded resources
+// of the same name. Resources missing from the directory still come from the binary.
+func ConfigureResources(dir string) error {
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("failed to open resource directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("resource path %s is not a directory", dir)
+		}
+	}
+
+	resourceMutex.Lock()
+	resourceOverrideDir = dir
+	resourceMutex.Unlock()
+
+	return nil
+}
+
+// LoadResource returns the entries of a packaged word list, preferring the
+// override directory when it holds a file of that name
+func LoadResource(name string) ([]string, error) {
+	resourceMutex.RLock()
+	dir := resourceOverrideDir
+	resourceMutex.RUnlock()
+
+	if dir != "" {
+		words, err := readWordList(filepath.Join(dir, name))
+		if err == nil {
+			return words, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read resource %s: %w", name, err)
+		}
+	}
+
+	file, err := embeddedResources.Open("resources/" + name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown resource %s: %w", name, err)
+	}
+	defer file.Close()
+
+	return parseWordList(file)
+}
+
+// parseWordList reads one entry per line, skipping blanks and # comments
+func parseWordList(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+
+	return words, scanner.Err()
+}
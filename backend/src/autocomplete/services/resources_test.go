@@ -0,0 +1,79 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWordList(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"lah\nkan\n", []string{"lah", "kan"}},
+		{"# particles\n\n  lah  \n\t\nkan", []string{"lah", "kan"}},
+		{"#only a comment", nil},
+		{"tak apa\n", []string{"tak apa"}},
+	}
+	for _, tt := range tests {
+		got, err := parseWordList(strings.NewReader(tt.input))
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseWordList(%q) = %v, %v, want %v", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestLoadResource(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ResourceParticles), []byte("# local\nweh\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer ConfigureResources("")
+
+	tests := []struct {
+		name      string
+		dir       string
+		resource  string
+		wantFirst string
+		wantErr   bool
+	}{
+		{"embedded", "", ResourceParticles, "lah", false},
+		{"overridden", dir, ResourceParticles, "weh", false},
+		{"missing from the override", dir, ResourceStopwords, "", false},
+		{"unknown", "", "klingon.txt", "", true},
+	}
+	for _, tt := range tests {
+		if err := ConfigureResources(tt.dir); err != nil {
+			t.Fatalf("%s: ConfigureResources() error: %v", tt.name, err)
+		}
+		words, err := LoadResource(tt.resource)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: LoadResource(%s) error = %v, want error %v", tt.name, tt.resource, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && len(words) == 0 {
+			t.Errorf("%s: LoadResource(%s) is empty", tt.name, tt.resource)
+		}
+		if tt.wantFirst != "" && words[0] != tt.wantFirst {
+			t.Errorf("%s: LoadResource(%s)[0] = %q, want %q", tt.name, tt.resource, words[0], tt.wantFirst)
+		}
+	}
+}
+
+func TestConfigureResourcesRejects(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "particles.txt")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer ConfigureResources("")
+
+	for _, dir := range []string{file, filepath.Join(file, "missing")} {
+		if err := ConfigureResources(dir); err == nil {
+			t.Errorf("ConfigureResources(%s) accepted a path that is not a directory", dir)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"autocomplete/models"
+)
+
+// FindWordOccurrences returns the positions of a clip where some candidate
+// matches word, compared case-insensitively after normalization
+func FindWordOccurrences(audioID string, positionMap models.PositionMap, word string) []models.WordOccurrence {
+	word = strings.ToLower(NormalizeText(word))
+	var occurrences []models.WordOccurrence
+	for pos, candidates := range positionMap {
+		baseline, _ := BaselineWord(candidates)
+		for _, candidate := range candidates {
+			if strings.ToLower(NormalizeText(candidate.Text)) != word {
+				continue
+			}
+			occurrences = append(occurrences, models.WordOccurrence{
+				AudioID:    audioID,
+				Position:   pos,
+				Text:       candidate.Text,
+				Confidence: candidate.Confidence,
+				Source:     candidate.Source,
+				Baseline:   candidate.Text == baseline,
+			})
+		}
+	}
+	SortOccurrences(occurrences)
+	return occurrences
+}
+
+// SearchCachedClips finds a word in every clip cached on this replica
+func SearchCachedClips(word string) []models.WordOccurrence {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	var occurrences []models.WordOccurrence
+	for audioID, positionMap := range clipPositions {
+		occurrences = append(occurrences, FindWordOccurrences(audioID, positionMap, word)...)
+	}
+	SortOccurrences(occurrences)
+	return occurrences
+}
+
+// SortOccurrences orders occurrences by clip, then position, then confidence
+func SortOccurrences(occurrences []models.WordOccurrence) {
+	sort.Slice(occurrences, func(i, j int) bool {
+		a, b := occurrences[i], occurrences[j]
+		if a.AudioID != b.AudioID {
+			return a.AudioID < b.AudioID
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.Confidence > b.Confidence
+	})
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestFindWordOccurrences(t *testing.T) {
+	positionMap := models.PositionMap{
+		0: {{Text: "Makan", Confidence: 0.9, Source: "gemini_final", Rank: 1}},
+		1: {{Text: "nasi", Confidence: 0.9, Source: "gemini_final", Rank: 1}, {Text: "makan", Confidence: 0.4, Source: "whisper", Rank: 2}},
+		2: {{Text: "minum", Confidence: 0.8, Source: "gemini_final", Rank: 1}},
+	}
+	tests := []struct {
+		word string
+		want []models.WordOccurrence
+	}{
+		{"makan", []models.WordOccurrence{
+			{AudioID: "clip", Position: 0, Text: "Makan", Confidence: 0.9, Source: "gemini_final", Baseline: true},
+			{AudioID: "clip", Position: 1, Text: "makan", Confidence: 0.4, Source: "whisper", Baseline: false},
+		}},
+		{"MINUM", []models.WordOccurrence{{AudioID: "clip", Position: 2, Text: "minum", Confidence: 0.8, Source: "gemini_final", Baseline: true}}},
+		{"tidur", nil},
+	}
+	for _, tt := range tests {
+		if got := FindWordOccurrences("clip", positionMap, tt.word); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FindWordOccurrences(%q) = %+v, want %+v", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestSortOccurrences(t *testing.T) {
+	occurrences := []models.WordOccurrence{
+		{AudioID: "b", Position: 0, Confidence: 0.9},
+		{AudioID: "a", Position: 2, Confidence: 0.9},
+		{AudioID: "a", Position: 1, Confidence: 0.4},
+		{AudioID: "a", Position: 1, Confidence: 0.8},
+	}
+	SortOccurrences(occurrences)
+	want := []models.WordOccurrence{
+		{AudioID: "a", Position: 1, Confidence: 0.8},
+		{AudioID: "a", Position: 1, Confidence: 0.4},
+		{AudioID: "a", Position: 2, Confidence: 0.9},
+		{AudioID: "b", Position: 0, Confidence: 0.9},
+	}
+	if !reflect.DeepEqual(occurrences, want) {
+		t.Errorf("SortOccurrences() = %+v, want %+v", occurrences, want)
+	}
+}
+
+func TestSearchCachedClips(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	BuildAndCacheData("b", &models.AutocompleteData{FinalTranscription: "saya makan"})
+	BuildAndCacheData("a", &models.AutocompleteData{FinalTranscription: "makan nasi", ASRAlternatives: map[string]string{"whisper": "makan makan"}})
+
+	var got []string
+	for _, occurrence := range SearchCachedClips("makan") {
+		got = append(got, occurrence.AudioID+":"+occurrence.Text)
+	}
+	if want := []string{"a:makan", "a:makan", "b:makan"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SearchCachedClips(makan) = %v, want %v", got, want)
+	}
+}
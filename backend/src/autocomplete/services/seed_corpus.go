@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"autocomplete/models"
+)
+
+// defaultSeedWeight is the confidence given to seed words listed without a weight
+const defaultSeedWeight = 0.5
+
+// stopwordSeedWeight keeps function words from crowding out content words in the builtin seed
+const stopwordSeedWeight = 0.2
+
+// BuiltinSeedCorpus selects the packaged Malay and English word lists instead of a file
+const BuiltinSeedCorpus = "builtin"
+
+// Seed suggestions re-applied whenever the global trie is rebuilt, guarded by cacheMutex
+var seedSuggestions []models.WordSuggestion
+
+// seedEntry is one line of a JSONL seed corpus
+type seedEntry struct {
+	Word   string   `json:"word"`
+	Weight *float64 `json:"weight"`
+}
+
+// LoadSeedCorpus reads weighted words from a seed corpus file. Files ending in
+// .jsonl hold one {"word": ..., "weight": ...} object per line; any other file
+// is plain text with one word per line, optionally followed by a weight. The
+// path "builtin" loads the packaged word lists.
+func LoadSeedCorpus(path string) ([]models.WordSuggestion, error) {
+	if path == BuiltinSeedCorpus {
+		return builtinSeedCorpus()
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open seed corpus: %w", err)
+	}
+	defer file.Close()
+
+	isJSONL := strings.HasSuffix(strings.ToLower(path), ".jsonl")
+	var suggestions []models.WordSuggestion
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		word, weight := "", defaultSeedWeight
+		if isJSONL {
+			var entry seedEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return nil, fmt.Errorf("seed corpus line %d: %w", lineNumber, err)
+			}
+			word = strings.TrimSpace(entry.Word)
+			if entry.Weight != nil {
+				weight = *entry.Weight
+			}
+		} else {
+			fields := strings.Fields(line)
+			word = fields[0]
+			if len(fields) > 1 {
+				parsed, err := strconv.ParseFloat(fields[1], 64)
+				if err != nil {
+					return nil, fmt.Errorf("seed corpus line %d: invalid weight %q", lineNumber, fields[1])
+				}
+				weight = parsed
+			}
+		}
+
+		if word == "" {
+			continue
+		}
+		suggestions = append(suggestions, models.WordSuggestion{
+			Text:       word,
+			Confidence: weight,
+			Source:     "seed_corpus",
+			Rank:       3,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read seed corpus: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// SeedGlobalIndex inserts seed suggestions into the global trie and keeps them
+// so later rebuilds of the global clip start from the same seed.
+func SeedGlobalIndex(suggestions []models.WordSuggestion) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	seedSuggestions = append(seedSuggestions, suggestions...)
+
+	trie, exists := clipTries[GlobalAudioID]
+	if !exists {
+		trie = models.NewPrefixTrie(GlobalAudioID)
+		clipTries[GlobalAudioID] = trie
+	}
+	for _, suggestion := range suggestions {
+		trie.Insert(suggestion.Text, suggestion)
+	}
+	recordVersion(GlobalAudioID, "seed_corpus", trie)
+}
+
+// applySeed inserts the retained seed suggestions into a freshly built global trie.
+// Callers must hold cacheMutex.
+func applySeed(trie *models.PrefixTrie) {
+	for _, suggestion := range seedSuggestions {
+		trie.Insert(suggestion.Text, suggestion)
+	}
+}
+
+// builtinSeedCorpus seeds from the packaged word lists, weighting stopwords down
+func builtinSeedCorpus() ([]models.WordSuggestion, error) {
+	stopwords, err := LoadResource(ResourceStopwords)
+	if err != nil {
+		return nil, err
+	}
+
+	weights := make(map[string]float64)
+	var order []string
+	for _, name := range []string{ResourceWordlistMalay, ResourceWordlistEnglish, ResourceStopwords} {
+		entries, err := LoadResource(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			fields := strings.Fields(entry)
+			weight := defaultSeedWeight
+			if len(fields) > 1 {
+				parsed, err := strconv.ParseFloat(fields[1], 64)
+				if err != nil {
+					return nil, fmt.Errorf("resource %s: invalid weight %q", name, fields[1])
+				}
+				weight = parsed
+			}
+			if _, seen := weights[fields[0]]; !seen {
+				order = append(order, fields[0])
+			}
+			weights[fields[0]] = weight
+		}
+	}
+	for _, word := range stopwords {
+		weights[strings.Fields(word)[0]] = stopwordSeedWeight
+	}
+
+	suggestions := make([]models.WordSuggestion, 0, len(order))
+	for _, word := range order {
+		suggestions = append(suggestions, models.WordSuggestion{
+			Text:       word,
+			Confidence: weights[word],
+			Source:     "seed_corpus",
+			Rank:       3,
+		})
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestLoadSeedCorpus(t *testing.T) {
+	type seed struct {
+		word   string
+		weight float64
+	}
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []seed
+		wantErr string
+	}{
+		{"plain words", "seed.txt", "makan\nminum 0.9\n\n# comment\n  tidur   0.1  \n", []seed{{"makan", defaultSeedWeight}, {"minum", 0.9}, {"tidur", 0.1}}, ""},
+		{"bad plain weight", "seed.txt", "makan\nminum heavy\n", nil, `seed corpus line 2: invalid weight "heavy"`},
+		{"jsonl", "seed.JSONL", "{\"word\": \"makan\", \"weight\": 0.8}\n{\"word\": \" minum \"}\n{\"word\": \"\"}\n{\"word\": \"kosong\", \"weight\": 0}\n", []seed{{"makan", 0.8}, {"minum", defaultSeedWeight}, {"kosong", 0}}, ""},
+		{"bad jsonl", "seed.jsonl", "{\"word\": \"makan\"}\nmakan 0.8\n", nil, "seed corpus line 2: invalid character 'm' looking for beginning of value"},
+		{"empty", "seed.txt", "", nil, ""},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.file)
+		if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		suggestions, err := LoadSeedCorpus(path)
+		gotErr := ""
+		if err != nil {
+			gotErr = err.Error()
+		}
+		if gotErr != tt.wantErr {
+			t.Errorf("%s: LoadSeedCorpus() error = %q, want %q", tt.name, gotErr, tt.wantErr)
+			continue
+		}
+		var got []seed
+		for _, suggestion := range suggestions {
+			if suggestion.Source != "seed_corpus" {
+				t.Errorf("%s: %s has source %q", tt.name, suggestion.Text, suggestion.Source)
+			}
+			got = append(got, seed{suggestion.Text, suggestion.Confidence})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: LoadSeedCorpus() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := LoadSeedCorpus(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadSeedCorpus of a missing file returned no error")
+	}
+}
+
+func TestSeedSurvivesGlobalRebuild(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	defer func(seed []models.WordSuggestion) { seedSuggestions = seed }(seedSuggestions)
+	seedSuggestions = nil
+
+	SeedGlobalIndex([]models.WordSuggestion{{Text: "benih", Confidence: 0.5, Source: "seed_corpus"}})
+	tests := []struct {
+		name     string
+		audioID  string
+		rebuild  string
+		wantSeed bool
+	}{
+		{"seeded global clip", GlobalAudioID, "", true},
+		{"global clip rebuilt", GlobalAudioID, "saya makan", true},
+		{"other clip", "clip", "saya makan", false},
+	}
+	for _, tt := range tests {
+		if tt.rebuild != "" {
+			BuildAndCacheData(tt.audioID, &models.AutocompleteData{FinalTranscription: tt.rebuild})
+		}
+		trie, err := GetPrefixTrie(tt.audioID)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		words := trie.Words()
+		if _, seeded := words["benih"]; seeded != tt.wantSeed {
+			t.Errorf("%s: seed word present = %v, want %v", tt.name, seeded, tt.wantSeed)
+		}
+		if _, built := words["makan"]; tt.rebuild != "" && !built {
+			t.Errorf("%s: rebuilt clip is missing its own words: %v", tt.name, words)
+		}
+	}
+}
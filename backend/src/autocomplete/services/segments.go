@@ -0,0 +1,67 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"autocomplete/models"
+)
+
+// ErrSegmentNotFound is returned when a segment id or time window matches none of a clip's segments
+var ErrSegmentNotFound = errors.New("no audio segment matches the requested window")
+
+// maxSegmentIDLength bounds an audio segment id
+const maxSegmentIDLength = 128
+
+// NormalizeAudioSegments trims segment ids and clamps segments to the
+// baseline's words, dropping segments whose word range is empty or whose end
+// time precedes their start. The result is sorted by StartTime.
+func NormalizeAudioSegments(segments []models.AudioSegment, wordCount int) []models.AudioSegment {
+	normalized := []models.AudioSegment{}
+	for _, segment := range segments {
+		segment.ID = strings.TrimSpace(segment.ID)
+		if len(segment.ID) > maxSegmentIDLength || segment.StartTime < 0 || segment.EndTime < segment.StartTime {
+			continue
+		}
+		if segment.StartWord < 0 {
+			segment.StartWord = 0
+		}
+		if segment.EndWord >= wordCount {
+			segment.EndWord = wordCount - 1
+		}
+		if segment.StartWord > segment.EndWord {
+			continue
+		}
+		normalized = append(normalized, segment)
+	}
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return normalized[i].StartTime < normalized[j].StartTime
+	})
+	return normalized
+}
+
+// SegmentWordRange returns the inclusive range of word positions heard in the
+// segment with the given id or, when segmentID is empty, in every segment
+// overlapping the [start, end] time window
+func SegmentWordRange(segments []models.AudioSegment, segmentID string, start, end float64) (first, last int, err error) {
+	first, last = -1, -1
+	for _, segment := range segments {
+		if segmentID != "" && segment.ID != segmentID {
+			continue
+		}
+		if segmentID == "" && (segment.EndTime < start || segment.StartTime > end) {
+			continue
+		}
+		if first < 0 || segment.StartWord < first {
+			first = segment.StartWord
+		}
+		if segment.EndWord > last {
+			last = segment.EndWord
+		}
+	}
+	if first < 0 {
+		return 0, 0, ErrSegmentNotFound
+	}
+	return first, last, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func audioSegment(id string, start, end float64, startWord, endWord int) models.AudioSegment {
+	return models.AudioSegment{ID: id, StartTime: start, EndTime: end, StartWord: startWord, EndWord: endWord}
+}
+
+func TestNormalizeAudioSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []models.AudioSegment
+		want     []models.AudioSegment
+	}{
+		{"none", nil, []models.AudioSegment{}},
+		{"sorted by start time", []models.AudioSegment{audioSegment(" b ", 2, 4, 2, 3), audioSegment("a", 0, 2, 0, 1)}, []models.AudioSegment{audioSegment("a", 0, 2, 0, 1), audioSegment("b", 2, 4, 2, 3)}},
+		{"words clamped", []models.AudioSegment{audioSegment("a", 0, 2, -1, 9)}, []models.AudioSegment{audioSegment("a", 0, 2, 0, 3)}},
+		{"unlabelled kept", []models.AudioSegment{audioSegment("", 0, 2, 0, 1)}, []models.AudioSegment{audioSegment("", 0, 2, 0, 1)}},
+		{"bad times", []models.AudioSegment{audioSegment("a", -1, 2, 0, 1), audioSegment("b", 3, 2, 2, 3)}, []models.AudioSegment{}},
+		{"no words in range", []models.AudioSegment{audioSegment("a", 0, 2, 4, 6), audioSegment("b", 2, 4, 3, 2)}, []models.AudioSegment{}},
+		{"id too long", []models.AudioSegment{audioSegment(strings.Repeat("x", maxSegmentIDLength+1), 0, 2, 0, 1)}, []models.AudioSegment{}},
+	}
+	for _, tt := range tests {
+		if got := NormalizeAudioSegments(tt.segments, 4); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: NormalizeAudioSegments() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSegmentWordRange(t *testing.T) {
+	segments := []models.AudioSegment{
+		audioSegment("intro", 0, 2.5, 0, 3),
+		audioSegment("question", 2.5, 5, 4, 7),
+		audioSegment("answer", 6, 9, 8, 12),
+	}
+	tests := []struct {
+		segmentID   string
+		start, end  float64
+		first, last int
+		wantErr     bool
+	}{
+		{"question", 0, 0, 4, 7, false},
+		{"missing", 0, 100, 0, 0, true},
+		{"", 1, 2, 0, 3, false},
+		{"", 2.5, 2.5, 0, 7, false}, // Touching both segments
+		{"", 3, 7, 4, 12, false},
+		{"", 5.5, 5.8, 0, 0, true}, // Between segments
+		{"", 10, 20, 0, 0, true},
+	}
+	for _, tt := range tests {
+		first, last, err := SegmentWordRange(segments, tt.segmentID, tt.start, tt.end)
+		if (err != nil) != tt.wantErr || first != tt.first || last != tt.last {
+			t.Errorf("SegmentWordRange(%q, %v, %v) = %d, %d, %v, want %d, %d (error %v)", tt.segmentID, tt.start, tt.end, first, last, err, tt.first, tt.last, tt.wantErr)
+		}
+	}
+}
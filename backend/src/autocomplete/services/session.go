@@ -0,0 +1,345 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"autocomplete/models"
+)
+
+// Within-session re-ranking: each earlier acceptance of a different word for
+// the same heard word adds confusionBoost (up to maxConfusionBoost), and a
+// candidate following the accepted previous word in the baseline bigrams gains
+// contextBoost. Confidences are capped at 1.
+const (
+	SessionConfusionSource = "session_confusion"
+	confusionBoost         = 0.25
+	maxConfusionBoost      = 0.5
+	contextBoost           = 0.2
+)
+
+// clipSession is the review state of a clip whose words are being accepted.
+// Acceptances and confusion counts are kept per replica so the sessions other
+// replicas hold for the clip can be merged in (see MergeSessionState).
+type clipSession struct {
+	base       models.PositionMap                   // Positions without session boosts, corrections applied
+	accepted   map[int]models.SessionAccept         // Latest word accepted at each reviewed position
+	confusions map[string]map[string]map[string]int // Heard baseline word → word accepted instead → replica → count
+	dirty      bool                                 // Accepted on this replica since the state was last exported
+}
+
+// Review sessions per clip, guarded by cacheMutex and dropped when the clip is rebuilt
+var clipSessions = make(map[string]*clipSession)
+
+// sessionReplica attributes this process's acceptances in merged session state
+var sessionReplica = "local"
+
+// SetSessionReplica sets the replica ID this process's acceptances are recorded under
+func SetSessionReplica(replica string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	sessionReplica = replica
+}
+
+// openSession returns the clip's review session, starting one from its
+// positions if needed. Callers must hold cacheMutex.
+func openSession(audioID string, positionMap models.PositionMap) *clipSession {
+	session, exists := clipSessions[audioID]
+	if !exists {
+		session = &clipSession{
+			base:       positionMap,
+			accepted:   make(map[int]models.SessionAccept),
+			confusions: make(map[string]map[string]map[string]int),
+		}
+		clipSessions[audioID] = session
+	}
+	return session
+}
+
+// AcceptWord records that word was accepted at a position of a cached clip.
+// Accepting a word other than the baseline corrects the position and records
+// the confusion pair; every position not yet accepted is then re-ranked from
+// the session state, and the clip's trie and bigrams are rebuilt. It returns
+// how many positions changed order.
+func AcceptWord(audioID string, position int, word string) (int, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	positionMap, exists := clipPositions[audioID]
+	if !exists {
+		return 0, fmt.Errorf("%w: clip %s is not initialized", ErrPositionNotFound, audioID)
+	}
+
+	session := openSession(audioID, positionMap)
+	if _, exists := session.base[position]; !exists {
+		return 0, fmt.Errorf("%w: clip %s has no word at position %d", ErrPositionNotFound, audioID, position)
+	}
+	heard, err := session.accept(position, models.SessionAccept{Word: word, At: time.Now().UnixNano(), Replica: sessionReplica})
+	if err != nil {
+		return 0, err
+	}
+	if heard != "" {
+		session.count(heard, word, sessionReplica, session.confusionsBy(heard, word, sessionReplica)+1)
+	}
+	session.dirty = true
+
+	ranked, changed := session.rerank()
+	refreshClip(audioID, ranked)
+	return changed, nil
+}
+
+// SessionAccepts returns a copy of the word accepted at each reviewed position
+// of a cached clip, empty when nothing has been accepted
+func SessionAccepts(audioID string) map[int]models.SessionAccept {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	accepts := make(map[int]models.SessionAccept)
+	if session, exists := clipSessions[NormalizeAudioID(audioID)]; exists {
+		for position, accepted := range session.accepted {
+			accepts[position] = accepted
+		}
+	}
+	return accepts
+}
+
+// rerank applies the session's boosts to every position not yet accepted
+func (s *clipSession) rerank() (models.PositionMap, int) {
+	bigrams := BuildBigrams(s.base)
+	ranked := make(models.PositionMap, len(s.base))
+	changed := 0
+
+	for pos, candidates := range s.base {
+		if _, done := s.accepted[pos]; done {
+			ranked[pos] = candidates
+			continue
+		}
+
+		boosted := append([]models.WordSuggestion(nil), candidates...)
+		baseline, _ := BaselineWord(candidates)
+		confusions := s.confusionTotals(strings.ToLower(baseline))
+
+		// Offer words the user chose for this heard word before, even when no model produced them
+		for word := range confusions {
+			if !hasCandidate(boosted, word) {
+				boosted = append(boosted, models.WordSuggestion{Text: word, Source: SessionConfusionSource, Rank: 2})
+			}
+		}
+
+		previous, hasContext := s.accepted[pos-1]
+		for i := range boosted {
+			boost := math.Min(float64(confusions[boosted[i].Text])*confusionBoost, maxConfusionBoost)
+			if hasContext && bigrams[previous.Word][boosted[i].Text] > 0 {
+				boost += contextBoost
+			}
+			boosted[i].Confidence = math.Min(1, boosted[i].Confidence+boost)
+		}
+		sort.SliceStable(boosted, func(i, j int) bool {
+			return boosted[i].Confidence > boosted[j].Confidence
+		})
+
+		if !sameOrder(candidates, boosted) {
+			changed++
+		}
+		ranked[pos] = boosted
+	}
+
+	return ranked, changed
+}
+
+// accept records an acceptance at a position, correcting the position when the
+// word differs from its baseline. It returns the lower-cased baseline word the
+// acceptance replaced, or "" when the baseline was accepted as heard.
+func (s *clipSession) accept(position int, accepted models.SessionAccept) (string, error) {
+	s.accepted[position] = accepted
+	baseline, ok := BaselineWord(s.base[position])
+	if !ok || baseline == accepted.Word {
+		return "", nil
+	}
+	updated, err := CorrectPositionMap(s.base, position, baseline, accepted.Word)
+	if err != nil {
+		return "", err
+	}
+	s.base = updated
+	return strings.ToLower(baseline), nil
+}
+
+// confusionsBy is how many times one replica accepted word in place of heard
+func (s *clipSession) confusionsBy(heard, word, replica string) int {
+	return s.confusions[heard][word][replica]
+}
+
+// count sets one replica's count of word accepted in place of heard
+func (s *clipSession) count(heard, word, replica string, count int) {
+	if s.confusions[heard] == nil {
+		s.confusions[heard] = make(map[string]map[string]int)
+	}
+	if s.confusions[heard][word] == nil {
+		s.confusions[heard][word] = make(map[string]int)
+	}
+	s.confusions[heard][word][replica] = count
+}
+
+// confusionTotals sums the replicas' counts of each word accepted in place of heard
+func (s *clipSession) confusionTotals(heard string) map[string]int {
+	totals := make(map[string]int, len(s.confusions[heard]))
+	for word, replicas := range s.confusions[heard] {
+		for _, count := range replicas {
+			totals[word] += count
+		}
+	}
+	return totals
+}
+
+// DirtySessionStates exports the review state of every clip accepted on since
+// the last export, and marks it exported
+func DirtySessionStates() map[string]*models.SessionState {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	states := make(map[string]*models.SessionState)
+	for audioID, session := range clipSessions {
+		if !session.dirty {
+			continue
+		}
+		states[audioID] = session.state()
+		session.dirty = false
+	}
+	return states
+}
+
+// MarkSessionsDirty queues clips' review state for export again, e.g. after a
+// failed publish
+func MarkSessionsDirty(audioIDs []string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	for _, audioID := range audioIDs {
+		if session, exists := clipSessions[audioID]; exists {
+			session.dirty = true
+		}
+	}
+}
+
+// state copies the session's mergeable state
+func (s *clipSession) state() *models.SessionState {
+	state := &models.SessionState{
+		Accepted:   make(map[int]models.SessionAccept, len(s.accepted)),
+		Confusions: make(map[string]map[string]map[string]int, len(s.confusions)),
+	}
+	for position, accepted := range s.accepted {
+		state.Accepted[position] = accepted
+	}
+	for heard, words := range s.confusions {
+		state.Confusions[heard] = make(map[string]map[string]int, len(words))
+		for word, replicas := range words {
+			state.Confusions[heard][word] = make(map[string]int, len(replicas))
+			for replica, count := range replicas {
+				state.Confusions[heard][word][replica] = count
+			}
+		}
+	}
+	return state
+}
+
+// MergeSessionState joins review state another replica exported into the
+// clip's session: each position keeps the latest acceptance and each
+// replica's confusion count the larger value, so merging is idempotent and
+// order-independent and every replica converges on the same ranking. Clips not
+// cached here are skipped. It reports whether the session changed, in which
+// case the clip is re-ranked.
+func MergeSessionState(audioID string, state *models.SessionState) (bool, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	positionMap, exists := clipPositions[audioID]
+	if !exists {
+		return false, nil
+	}
+	session := openSession(audioID, positionMap)
+
+	changed := false
+	positions := make([]int, 0, len(state.Accepted))
+	for position := range state.Accepted {
+		positions = append(positions, position)
+	}
+	sort.Ints(positions)
+	for _, position := range positions {
+		remote := state.Accepted[position]
+		if _, exists := session.base[position]; !exists {
+			continue
+		}
+		if local, exists := session.accepted[position]; exists && !newerAccept(remote, local) {
+			continue
+		}
+		if _, err := session.accept(position, remote); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	for heard, words := range state.Confusions {
+		for word, replicas := range words {
+			for replica, count := range replicas {
+				if count > session.confusionsBy(heard, word, replica) {
+					session.count(heard, word, replica, count)
+					changed = true
+				}
+			}
+		}
+	}
+
+	if changed {
+		ranked, _ := session.rerank()
+		refreshClip(audioID, ranked)
+	}
+	return changed, nil
+}
+
+// newerAccept orders acceptances by time, breaking ties by replica ID
+func newerAccept(a, b models.SessionAccept) bool {
+	if a.At != b.At {
+		return a.At > b.At
+	}
+	return a.Replica > b.Replica
+}
+
+// refreshClip caches a clip's updated positions and rebuilds its trie and
+// bigrams from them. Callers must hold cacheMutex.
+func refreshClip(audioID string, positionMap models.PositionMap) {
+	trie := buildTrie(positionMap)
+	trie.AudioClipID = audioID
+	if audioID == GlobalAudioID {
+		applySeed(trie)
+	}
+	clipTries[audioID] = trie
+	setClipPositions(audioID, positionMap)
+}
+
+func hasCandidate(candidates []models.WordSuggestion, word string) bool {
+	for _, candidate := range candidates {
+		if candidate.Text == word {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOrder reports whether two candidate lists list the same words in the same order
+func sameOrder(a, b []models.WordSuggestion) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Text != b[i].Text {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+// positionWords lists a position's candidates in ranked order
+func positionWords(t *testing.T, audioID string, position int) []string {
+	t.Helper()
+	positionMap, err := GetPositionMap(audioID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var words []string
+	for _, candidate := range positionMap[position] {
+		words = append(words, candidate.Text)
+	}
+	return words
+}
+
+func TestAcceptWord(t *testing.T) {
+	tests := []struct {
+		name         string
+		alternatives map[string]string
+		accept       string
+		wantChanged  int
+		wantLater    []string // Position 3 after the acceptance at position 1
+	}{
+		{"baseline accepted", map[string]string{"whisper": "saya tau dia tau"}, "tahu", 0, []string{"tahu", "tau"}},
+		{"confusion promotes the alternative", map[string]string{"whisper": "saya tau dia tau"}, "tau", 1, []string{"tau", "tahu"}},
+		{"confusion offered without a model", nil, "tau", 1, []string{"tahu", "tau"}},
+	}
+	for _, tt := range tests {
+		ResetCache()
+		BuildAndCacheData("clip", &models.AutocompleteData{
+			FinalTranscription: "saya tahu dia tahu",
+			ConfidenceScore:    0.9,
+			ASRAlternatives:    tt.alternatives,
+		})
+
+		changed, err := AcceptWord("clip", 1, tt.accept)
+		if err != nil {
+			t.Fatalf("%s: AcceptWord() error: %v", tt.name, err)
+		}
+		if changed != tt.wantChanged {
+			t.Errorf("%s: AcceptWord() changed %d positions, want %d", tt.name, changed, tt.wantChanged)
+		}
+		if got := positionWords(t, "clip", 1); got[0] != tt.accept {
+			t.Errorf("%s: accepted position reads %v", tt.name, got)
+		}
+		if got := positionWords(t, "clip", 3); !reflect.DeepEqual(got, tt.wantLater) {
+			t.Errorf("%s: later position = %v, want %v", tt.name, got, tt.wantLater)
+		}
+		if accepts := SessionAccepts("clip"); accepts[1].Word != tt.accept || len(accepts) != 1 {
+			t.Errorf("%s: SessionAccepts() = %v", tt.name, accepts)
+		}
+	}
+
+	if _, err := AcceptWord("clip", 9, "x"); !errors.Is(err, ErrPositionNotFound) {
+		t.Errorf("AcceptWord() past the end = %v, want ErrPositionNotFound", err)
+	}
+	if _, err := AcceptWord("other", 0, "x"); !errors.Is(err, ErrPositionNotFound) {
+		t.Errorf("AcceptWord() on an unknown clip = %v, want ErrPositionNotFound", err)
+	}
+	ResetCache()
+}
+
+func TestMergeSessionState(t *testing.T) {
+	defer ResetCache()
+	defer SetSessionReplica("local")
+	data := &models.AutocompleteData{
+		FinalTranscription: "saya tahu dia tahu",
+		ConfidenceScore:    0.9,
+		ASRAlternatives:    map[string]string{"whisper": "saya tau dia tau"},
+	}
+
+	// Replica a accepts a correction and exports its state
+	ResetCache()
+	SetSessionReplica("a")
+	BuildAndCacheData("clip", data)
+	if _, err := AcceptWord("clip", 1, "tau"); err != nil {
+		t.Fatal(err)
+	}
+	states := DirtySessionStates()
+	if len(states) != 1 || len(DirtySessionStates()) != 0 {
+		t.Fatalf("DirtySessionStates() exported %d clips, then more on the second call", len(states))
+	}
+	want := positionWords(t, "clip", 3)
+
+	// Replica b merges it, twice
+	ResetCache()
+	SetSessionReplica("b")
+	BuildAndCacheData("clip", data)
+	for i, wantChanged := range []bool{true, false} {
+		changed, err := MergeSessionState("clip", states["clip"])
+		if err != nil || changed != wantChanged {
+			t.Errorf("merge %d: MergeSessionState() = %v, %v, want %v", i, changed, err, wantChanged)
+		}
+	}
+	if got := positionWords(t, "clip", 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("merged ranking = %v, want %v as on the exporting replica", got, want)
+	}
+	if changed, _ := MergeSessionState("other", states["clip"]); changed {
+		t.Error("MergeSessionState() changed a clip that isn't cached")
+	}
+}
+
+func TestNewerAccept(t *testing.T) {
+	tests := []struct {
+		a, b models.SessionAccept
+		want bool
+	}{
+		{models.SessionAccept{At: 2, Replica: "a"}, models.SessionAccept{At: 1, Replica: "b"}, true},
+		{models.SessionAccept{At: 1, Replica: "b"}, models.SessionAccept{At: 2, Replica: "a"}, false},
+		{models.SessionAccept{At: 1, Replica: "b"}, models.SessionAccept{At: 1, Replica: "a"}, true},
+		{models.SessionAccept{At: 1, Replica: "a"}, models.SessionAccept{At: 1, Replica: "a"}, false},
+	}
+	for _, tt := range tests {
+		if got := newerAccept(tt.a, tt.b); got != tt.want {
+			t.Errorf("newerAccept(%+v, %+v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+
+	"autocomplete/models"
+)
+
+// SimulateWord types word one character at a time, asking suggest for the
+// suggestions after each prefix, and accepts the word with one keystroke as
+// soon as it is among them. A suggestion is only accepted when that saves a
+// keystroke, so a short word may be typed in full even when it was suggested.
+func SimulateWord(position int, word string, suggest func(prefix string) ([]string, error)) (models.WordSimulation, error) {
+	runes := []rune(word)
+	simulation := models.WordSimulation{
+		Position:   position,
+		Word:       word,
+		Keystrokes: len(runes),
+		Typed:      len(runes),
+	}
+	// Accepting after typed characters costs typed+1, so stop before that stops saving
+	for typed := 1; typed+1 < len(runes); typed++ {
+		suggestions, err := suggest(string(runes[:typed]))
+		if err != nil {
+			return simulation, err
+		}
+		if rank := SuggestionRank(suggestions, word); rank > 0 {
+			simulation.Typed = typed + 1
+			simulation.Saved = simulation.Keystrokes - simulation.Typed
+			simulation.AcceptedAt = typed
+			simulation.Rank = rank
+			return simulation, nil
+		}
+	}
+	return simulation, nil
+}
+
+// TotalKeystrokes sums the word simulations of a transcript into simulation
+func TotalKeystrokes(simulation *models.KeystrokeSimulation) {
+	simulation.Keystrokes, simulation.Typed, simulation.Saved, simulation.Accepted = 0, 0, 0, 0
+	for _, word := range simulation.Words {
+		simulation.Keystrokes += word.Keystrokes
+		simulation.Typed += word.Typed
+		simulation.Saved += word.Saved
+		if word.AcceptedAt > 0 {
+			simulation.Accepted++
+		}
+	}
+	simulation.SavingsRate = 0
+	if simulation.Keystrokes > 0 {
+		simulation.SavingsRate = float64(simulation.Saved) / float64(simulation.Keystrokes)
+	}
+}
+
+// SuggestionRank is the 1-based rank of word among suggestions, ignoring case,
+// or 0 when it isn't suggested
+func SuggestionRank(suggestions []string, word string) int {
+	for i, suggestion := range suggestions {
+		if strings.EqualFold(suggestion, word) {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestSimulateWord(t *testing.T) {
+	// suggestAfter offers the word once at least n characters are typed, at rank
+	suggestAfter := func(word string, n, rank int) func(string) ([]string, error) {
+		return func(prefix string) ([]string, error) {
+			suggestions := []string{"x", "y", "z"}[:rank-1]
+			if len([]rune(prefix)) >= n {
+				suggestions = append(suggestions, strings.ToUpper(word))
+			}
+			return suggestions, nil
+		}
+	}
+	tests := []struct {
+		name    string
+		word    string
+		suggest func(string) ([]string, error)
+		want    models.WordSimulation
+	}{
+		{"first letter", "makan", suggestAfter("makan", 1, 1), models.WordSimulation{Word: "makan", Keystrokes: 5, Typed: 2, Saved: 3, AcceptedAt: 1, Rank: 1}},
+		{"third letter", "makan", suggestAfter("makan", 3, 2), models.WordSimulation{Word: "makan", Keystrokes: 5, Typed: 4, Saved: 1, AcceptedAt: 3, Rank: 2}},
+		{"too late to save", "makan", suggestAfter("makan", 4, 1), models.WordSimulation{Word: "makan", Keystrokes: 5, Typed: 5}},
+		{"never suggested", "makan", suggestAfter("makan", 9, 1), models.WordSimulation{Word: "makan", Keystrokes: 5, Typed: 5}},
+		{"two letters", "ya", suggestAfter("ya", 1, 1), models.WordSimulation{Word: "ya", Keystrokes: 2, Typed: 2}},
+		{"runes, not bytes", "café", suggestAfter("café", 2, 1), models.WordSimulation{Word: "café", Keystrokes: 4, Typed: 3, Saved: 1, AcceptedAt: 2, Rank: 1}},
+	}
+	for _, tt := range tests {
+		got, err := SimulateWord(0, tt.word, tt.suggest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: SimulateWord(%q) = %+v, want %+v", tt.name, tt.word, got, tt.want)
+		}
+	}
+
+	failure := errors.New("redis down")
+	if _, err := SimulateWord(0, "makan", func(string) ([]string, error) { return nil, failure }); err != failure {
+		t.Errorf("SimulateWord() error = %v, want %v", err, failure)
+	}
+}
+
+func TestTotalKeystrokes(t *testing.T) {
+	simulation := &models.KeystrokeSimulation{
+		Keystrokes: 99, // Stale totals are replaced
+		Words: []models.WordSimulation{
+			{Keystrokes: 5, Typed: 2, Saved: 3, AcceptedAt: 1},
+			{Keystrokes: 3, Typed: 3},
+		},
+	}
+	TotalKeystrokes(simulation)
+	if simulation.Keystrokes != 8 || simulation.Typed != 5 || simulation.Saved != 3 || simulation.Accepted != 1 || simulation.SavingsRate != 3.0/8 {
+		t.Errorf("TotalKeystrokes() = %+v", simulation)
+	}
+
+	empty := &models.KeystrokeSimulation{SavingsRate: 0.5}
+	TotalKeystrokes(empty)
+	if empty.SavingsRate != 0 {
+		t.Errorf("TotalKeystrokes() of no words has savings rate %v", empty.SavingsRate)
+	}
+}
+
+func TestSuggestionRank(t *testing.T) {
+	tests := []struct {
+		suggestions []string
+		word        string
+		want        int
+	}{
+		{[]string{"makan", "makna"}, "makan", 1},
+		{[]string{"makan", "Makna"}, "makna", 2},
+		{[]string{"makan"}, "makna", 0},
+		{nil, "makan", 0},
+	}
+	for _, tt := range tests {
+		if got := SuggestionRank(tt.suggestions, tt.word); got != tt.want {
+			t.Errorf("SuggestionRank(%v, %q) = %d, want %d", tt.suggestions, tt.word, got, tt.want)
+		}
+	}
+}
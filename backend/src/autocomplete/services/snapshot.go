@@ -0,0 +1,115 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"autocomplete/models"
+)
+
+// SnapshotClip captures the clip's current trie and position map
+func SnapshotClip(audioID string) (*models.ClipSnapshot, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.RLock()
+	trie, exists := clipTries[audioID]
+	if !exists {
+		cacheMutex.RUnlock()
+		return nil, fmt.Errorf("autocomplete not initialized for clip %s, please initialize first", audioID)
+	}
+	view := trie.Snapshot()
+	positions := clipPositions[audioID]
+	cacheMutex.RUnlock()
+
+	// The walk runs on the trie snapshot, so inserts aren't blocked meanwhile
+	return snapshotClip(audioID, view, positions), nil
+}
+
+// SnapshotAll captures every cached clip and the seed corpus
+func SnapshotAll() *models.ServiceSnapshot {
+	cacheMutex.RLock()
+	snapshot := &models.ServiceSnapshot{
+		CreatedAt: time.Now(),
+		Clips:     make([]models.ClipSnapshot, 0, len(clipTries)),
+		Seed:      append([]models.WordSuggestion(nil), seedSuggestions...),
+	}
+	views := make(map[string]*models.PrefixTrie, len(clipTries))
+	positions := make(map[string]models.PositionMap, len(clipTries))
+	for audioID, trie := range clipTries {
+		views[audioID] = trie.Snapshot()
+		positions[audioID] = clipPositions[audioID]
+	}
+	cacheMutex.RUnlock()
+
+	// Every clip was frozen at the same instant; walk them without the lock
+	for audioID, view := range views {
+		snapshot.Clips = append(snapshot.Clips, *snapshotClip(audioID, view, positions[audioID]))
+	}
+
+	return snapshot
+}
+
+// snapshotClip copies one clip's state from a trie snapshot
+func snapshotClip(audioID string, view *models.PrefixTrie, positions models.PositionMap) *models.ClipSnapshot {
+	return &models.ClipSnapshot{
+		AudioID:   audioID,
+		CreatedAt: time.Now(),
+		Words:     view.Words(),
+		Positions: positions,
+	}
+}
+
+// RestoreSnapshot replaces the cached state of every clip in the snapshot and
+// the seed corpus, returning the number of clips restored. Clips not in the
+// snapshot are left untouched.
+func RestoreSnapshot(snapshot *models.ServiceSnapshot) int {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	if len(snapshot.Seed) > 0 {
+		seedSuggestions = append([]models.WordSuggestion(nil), snapshot.Seed...)
+	}
+
+	for _, clip := range snapshot.Clips {
+		restoreClip(NormalizeAudioID(clip.AudioID), clip, "restore")
+	}
+
+	return len(snapshot.Clips)
+}
+
+// restoreClip replaces a clip's cached state with a snapshot of it, recording
+// a version with reason. Callers must hold cacheMutex.
+func restoreClip(audioID string, clip models.ClipSnapshot, reason string) {
+	trie := models.NewPrefixTrie(audioID)
+	for word, suggestions := range clip.Words {
+		for _, suggestion := range suggestions {
+			trie.Insert(word, suggestion)
+		}
+	}
+
+	clipTries[audioID] = trie
+	setClipPositions(audioID, clip.Positions)
+	delete(clipSessions, audioID)
+	delete(clipTranscripts, audioID) // Snapshots carry no transcripts
+	recordVersion(audioID, reason, trie)
+}
+
+// WriteSnapshot saves a clip snapshot as JSON, replacing the file atomically
+func WriteSnapshot(path string, snapshot *models.ClipSnapshot) error {
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
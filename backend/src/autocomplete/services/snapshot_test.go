@@ -0,0 +1,159 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func snapshotWords(words ...string) map[string][]models.WordSuggestion {
+	suggestions := make(map[string][]models.WordSuggestion)
+	for i, word := range words {
+		suggestions[word] = []models.WordSuggestion{{Text: word, Confidence: 0.9 - 0.1*float64(i)}}
+	}
+	return suggestions
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	defer func(seed []models.WordSuggestion) { seedSuggestions = seed }(seedSuggestions)
+
+	BuildAndCacheData("kept", &models.AutocompleteData{FinalTranscription: "saya minum"})
+	seedSuggestions = []models.WordSuggestion{{Text: "seed"}}
+
+	tests := []struct {
+		name     string
+		snapshot *models.ServiceSnapshot
+		want     map[string]map[string][]models.WordSuggestion
+		wantSeed []string
+	}{
+		{
+			name: "new clips",
+			snapshot: &models.ServiceSnapshot{Clips: []models.ClipSnapshot{
+				{AudioID: "a", Words: snapshotWords("saya", "makan"), Positions: models.PositionMap{0: {{Text: "saya"}}}},
+				{AudioID: "b", Words: snapshotWords("nasi")},
+			}},
+			want: map[string]map[string][]models.WordSuggestion{
+				"a": snapshotWords("saya", "makan"),
+				"b": snapshotWords("nasi"),
+			},
+			wantSeed: []string{"seed"},
+		},
+		{
+			name: "replaces a restored clip and the seed",
+			snapshot: &models.ServiceSnapshot{
+				Clips: []models.ClipSnapshot{{AudioID: "a", Words: snapshotWords("minum")}},
+				Seed:  []models.WordSuggestion{{Text: "benih"}},
+			},
+			want: map[string]map[string][]models.WordSuggestion{
+				"a": snapshotWords("minum"),
+				"b": snapshotWords("nasi"),
+			},
+			wantSeed: []string{"benih"},
+		},
+	}
+	for _, tt := range tests {
+		if restored := RestoreSnapshot(tt.snapshot); restored != len(tt.snapshot.Clips) {
+			t.Errorf("%s: restored %d clips, want %d", tt.name, restored, len(tt.snapshot.Clips))
+		}
+		for audioID, want := range tt.want {
+			trie, err := GetPrefixTrie(audioID)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if got := trie.Words(); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: clip %s words = %v, want %v", tt.name, audioID, got, want)
+			}
+		}
+		if _, err := GetPrefixTrie("kept"); err != nil {
+			t.Errorf("%s: clip missing from the snapshot was dropped: %v", tt.name, err)
+		}
+		var seed []string
+		for _, suggestion := range seedSuggestions {
+			seed = append(seed, suggestion.Text)
+		}
+		if !reflect.DeepEqual(seed, tt.wantSeed) {
+			t.Errorf("%s: seed = %v, want %v", tt.name, seed, tt.wantSeed)
+		}
+	}
+
+	if positions, err := GetPositionMap("a"); err == nil {
+		t.Errorf("clip a positions = %v, want none after restoring a snapshot without them", positions)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+
+	BuildAndCacheData("a", &models.AutocompleteData{FinalTranscription: "saya nak makan nasi", ASRAlternatives: map[string]string{"makan": "makna"}})
+	BuildAndCacheData("b", &models.AutocompleteData{FinalTranscription: "dia minum teh"})
+
+	encoded, err := json.Marshal(SnapshotAll())
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := make(map[string]*models.ClipSnapshot)
+	for _, audioID := range []string{"a", "b"} {
+		if before[audioID], err = SnapshotClip(audioID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ResetCache()
+	snapshot := &models.ServiceSnapshot{}
+	if err := json.Unmarshal(encoded, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if restored := RestoreSnapshot(snapshot); restored != 2 {
+		t.Fatalf("restored %d clips, want 2", restored)
+	}
+
+	for audioID, want := range before {
+		got, err := SnapshotClip(audioID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Words, want.Words) || !reflect.DeepEqual(got.Positions, want.Positions) {
+			t.Errorf("clip %s after restore = %+v, want %+v", audioID, got, want)
+		}
+	}
+
+	if _, err := SnapshotClip("missing"); err == nil {
+		t.Error("SnapshotClip of a clip never initialized returned no error")
+	}
+}
+
+func TestWriteSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "clip.json")
+	tests := []*models.ClipSnapshot{
+		{AudioID: "a", Words: snapshotWords("saya")},
+		{AudioID: "a", Words: snapshotWords("makan", "minum")},
+	}
+	for _, snapshot := range tests {
+		if err := WriteSnapshot(path, snapshot); err != nil {
+			t.Fatal(err)
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got models.ClipSnapshot
+		if err := json.Unmarshal(raw, &got); err != nil || !reflect.DeepEqual(got.Words, snapshot.Words) {
+			t.Errorf("wrote %s, want %+v", raw, snapshot)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files left in the snapshot directory, want 1", len(entries))
+	}
+
+	if err := WriteSnapshot(filepath.Join(dir, "missing", "clip.json"), tests[0]); err == nil {
+		t.Error("WriteSnapshot into a missing directory returned no error")
+	}
+}
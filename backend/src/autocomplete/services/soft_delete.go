@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"autocomplete/models"
+)
+
+// ErrClipExists is returned when restoring a deleted clip whose ID has been
+// initialized again since
+var ErrClipExists = errors.New("clip has been initialized again since it was deleted")
+
+// deletedClip is the cached state of a soft-deleted clip, kept aside so it is
+// hidden from suggest but can be restored
+type deletedClip struct {
+	trie        *models.PrefixTrie
+	positions   models.PositionMap
+	transcripts *models.AutocompleteData
+	versions    []*indexVersion
+	deletedAt   time.Time
+}
+
+// Soft-deleted clips, guarded by cacheMutex
+var deletedClips = make(map[string]*deletedClip)
+
+// SoftDeleteClip moves a cached clip aside, reporting whether it was cached.
+// Its review session is dropped.
+func SoftDeleteClip(audioID string) bool {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	trie, exists := clipTries[audioID]
+	if !exists {
+		return false
+	}
+	deletedClips[audioID] = &deletedClip{
+		trie:        trie,
+		positions:   clipPositions[audioID],
+		transcripts: clipTranscripts[audioID],
+		versions:    clipVersions[audioID],
+		deletedAt:   time.Now(),
+	}
+	delete(clipTries, audioID)
+	setClipPositions(audioID, nil)
+	delete(clipTranscripts, audioID)
+	delete(clipSessions, audioID)
+	delete(clipVersions, audioID)
+	delete(clipStamps, audioID)
+	return true
+}
+
+// RestoreDeletedClip puts a soft-deleted clip back in the cache and records an
+// "undelete" version, reporting whether there was one to restore
+func RestoreDeletedClip(audioID string) (bool, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	deleted, exists := deletedClips[audioID]
+	if !exists {
+		return false, nil
+	}
+	if _, live := clipTries[audioID]; live {
+		return false, ErrClipExists
+	}
+	clipTries[audioID] = deleted.trie
+	setClipPositions(audioID, deleted.positions)
+	if deleted.transcripts != nil {
+		clipTranscripts[audioID] = deleted.transcripts
+	}
+	clipVersions[audioID] = deleted.versions
+	delete(deletedClips, audioID)
+	recordVersion(audioID, "undelete", deleted.trie)
+	return true, nil
+}
+
+// DeletedClips returns when each soft-deleted cached clip was deleted
+func DeletedClips() map[string]time.Time {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	deleted := make(map[string]time.Time, len(deletedClips))
+	for audioID, clip := range deletedClips {
+		deleted[audioID] = clip.deletedAt
+	}
+	return deleted
+}
+
+// ExpireDeletedClips drops the soft-deleted clips deleted before cutoff,
+// returning how many were dropped
+func ExpireDeletedClips(cutoff time.Time) int {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	expired := 0
+	for audioID, clip := range deletedClips {
+		if clip.deletedAt.Before(cutoff) {
+			delete(deletedClips, audioID)
+			expired++
+		}
+	}
+	return expired
+}
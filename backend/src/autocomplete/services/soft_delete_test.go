@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"autocomplete/models"
+)
+
+func TestSoftDeleteAndRestoreClip(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan nasi", ConfidenceScore: 0.9})
+
+	if SoftDeleteClip("other") {
+		t.Error("SoftDeleteClip() deleted a clip that isn't cached")
+	}
+	if !SoftDeleteClip("clip") {
+		t.Fatal("SoftDeleteClip() found no cached clip")
+	}
+	if _, err := GetPrefixTrie("clip"); err == nil {
+		t.Error("a soft-deleted clip is still served")
+	}
+	if _, deleted := DeletedClips()["clip"]; !deleted {
+		t.Errorf("DeletedClips() = %v, want the clip", DeletedClips())
+	}
+
+	tests := []struct {
+		name    string
+		audioID string
+		want    bool
+	}{
+		{"not deleted", "other", false},
+		{"deleted", "clip", true},
+		{"already restored", "clip", false},
+	}
+	for _, tt := range tests {
+		if got, err := RestoreDeletedClip(tt.audioID); got != tt.want || err != nil {
+			t.Errorf("%s: RestoreDeletedClip(%q) = %v, %v, want %v", tt.name, tt.audioID, got, err, tt.want)
+		}
+	}
+	trie, err := GetPrefixTrie("clip")
+	if err != nil || len(trie.Words()["makan"]) == 0 {
+		t.Errorf("restored clip is not served: %v", err)
+	}
+	if versions := ListVersions("clip"); len(versions) == 0 || versions[len(versions)-1].Reason != "undelete" {
+		t.Errorf("ListVersions() after restore = %v, want an undelete version last", versions)
+	}
+}
+
+func TestRestoreDeletedClipInitializedAgain(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan nasi", ConfidenceScore: 0.9})
+	SoftDeleteClip("clip")
+	BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "dia minum teh", ConfidenceScore: 0.9})
+
+	if restored, err := RestoreDeletedClip("clip"); restored || !errors.Is(err, ErrClipExists) {
+		t.Errorf("RestoreDeletedClip() = %v, %v, want %v", restored, err, ErrClipExists)
+	}
+}
+
+func TestExpireDeletedClips(t *testing.T) {
+	tests := []struct {
+		name        string
+		cutoff      time.Duration // From now
+		wantExpired int
+	}{
+		{"within retention", -time.Hour, 0},
+		{"retention passed", time.Hour, 1},
+	}
+	for _, tt := range tests {
+		ResetCache()
+		BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan nasi", ConfidenceScore: 0.9})
+		SoftDeleteClip("clip")
+		if got := ExpireDeletedClips(time.Now().Add(tt.cutoff)); got != tt.wantExpired {
+			t.Errorf("%s: ExpireDeletedClips() = %d, want %d", tt.name, got, tt.wantExpired)
+		}
+		if _, kept := DeletedClips()["clip"]; kept != (tt.wantExpired == 0) {
+			t.Errorf("%s: DeletedClips() = %v", tt.name, DeletedClips())
+		}
+	}
+	ResetCache()
+}
+
+func TestPurgeSoftDeletedClip(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+	BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan nasi", ConfidenceScore: 0.9})
+	SoftDeleteClip("clip")
+
+	if !PurgeClip("clip") {
+		t.Error("PurgeClip() did not report the soft-deleted clip")
+	}
+	if restored, _ := RestoreDeletedClip("clip"); restored {
+		t.Error("a purged clip was restored")
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"autocomplete/models"
+)
+
+// Speaker conditioning: a word gains up to maxSpeakerBoost in proportion to
+// the share of its uses in the clip that came from the active speaker, and
+// speakerContextBoost when that speaker said it after the previous word
+const (
+	maxSpeakerBoost     = 0.2
+	speakerContextBoost = 0.1
+)
+
+// maxSpeakerLabelLength bounds a diarization label
+const maxSpeakerLabelLength = 64
+
+// SpeakerStats is what one speaker said in a clip, lower-cased
+type SpeakerStats struct {
+	Words   map[string]float64            // Uses of each word
+	Bigrams map[string]map[string]float64 // Word → words the speaker said next, with counts
+}
+
+// NormalizeSpeakerSegments trims speaker labels and clamps segments to the
+// baseline's words, dropping segments without a label or any word in range.
+// The result is sorted by StartWord.
+func NormalizeSpeakerSegments(segments []models.SpeakerSegment, wordCount int) []models.SpeakerSegment {
+	normalized := []models.SpeakerSegment{}
+	for _, segment := range segments {
+		segment.Speaker = strings.TrimSpace(segment.Speaker)
+		if segment.Speaker == "" || len(segment.Speaker) > maxSpeakerLabelLength {
+			continue
+		}
+		if segment.StartWord < 0 {
+			segment.StartWord = 0
+		}
+		if segment.EndWord >= wordCount {
+			segment.EndWord = wordCount - 1
+		}
+		if segment.StartWord > segment.EndWord {
+			continue
+		}
+		normalized = append(normalized, segment)
+	}
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return normalized[i].StartWord < normalized[j].StartWord
+	})
+	return normalized
+}
+
+// SpeakerAt returns the speaker of a word position, "" when no segment covers it
+func SpeakerAt(segments []models.SpeakerSegment, position int) string {
+	for _, segment := range segments {
+		if position >= segment.StartWord && position <= segment.EndWord {
+			return segment.Speaker
+		}
+	}
+	return ""
+}
+
+// SpeakerStatistics counts each speaker's words and bigrams over a clip's
+// baseline words. Bigrams don't cross a change of speaker.
+func SpeakerStatistics(words []string, segments []models.SpeakerSegment) map[string]*SpeakerStats {
+	stats := make(map[string]*SpeakerStats)
+	for pos, word := range words {
+		speaker := SpeakerAt(segments, pos)
+		if speaker == "" {
+			continue
+		}
+		speakerStats, exists := stats[speaker]
+		if !exists {
+			speakerStats = &SpeakerStats{Words: make(map[string]float64), Bigrams: make(map[string]map[string]float64)}
+			stats[speaker] = speakerStats
+		}
+		word = strings.ToLower(word)
+		speakerStats.Words[word]++
+		if pos+1 < len(words) && SpeakerAt(segments, pos+1) == speaker {
+			if speakerStats.Bigrams[word] == nil {
+				speakerStats.Bigrams[word] = make(map[string]float64)
+			}
+			speakerStats.Bigrams[word][strings.ToLower(words[pos+1])]++
+		}
+	}
+	return stats
+}
+
+// SpeakerBoost is the boost for a word offered to the active speaker, after
+// previous when it is known
+func SpeakerBoost(stats map[string]*SpeakerStats, speaker, word, previous string) float64 {
+	active := stats[speaker]
+	if active == nil {
+		return 0
+	}
+	word = strings.ToLower(word)
+
+	var uses float64
+	for _, speakerStats := range stats {
+		uses += speakerStats.Words[word]
+	}
+	boost := 0.0
+	if uses > 0 {
+		boost = maxSpeakerBoost * math.Min(1, active.Words[word]/uses)
+	}
+	if previous != "" && active.Bigrams[strings.ToLower(previous)][word] > 0 {
+		boost += speakerContextBoost
+	}
+	return boost
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func segment(speaker string, start, end int) models.SpeakerSegment {
+	return models.SpeakerSegment{Speaker: speaker, StartWord: start, EndWord: end}
+}
+
+func TestNormalizeSpeakerSegments(t *testing.T) {
+	tests := []struct {
+		name      string
+		segments  []models.SpeakerSegment
+		wordCount int
+		want      []models.SpeakerSegment
+	}{
+		{"none", nil, 4, []models.SpeakerSegment{}},
+		{"sorted by start", []models.SpeakerSegment{segment("B", 2, 3), segment(" A ", 0, 1)}, 4, []models.SpeakerSegment{segment("A", 0, 1), segment("B", 2, 3)}},
+		{"clamped", []models.SpeakerSegment{segment("A", -2, 1), segment("B", 2, 9)}, 4, []models.SpeakerSegment{segment("A", 0, 1), segment("B", 2, 3)}},
+		{"out of range", []models.SpeakerSegment{segment("A", 4, 6), segment("B", 3, 1)}, 4, []models.SpeakerSegment{}},
+		{"bad labels", []models.SpeakerSegment{segment("  ", 0, 1), segment(strings.Repeat("s", maxSpeakerLabelLength+1), 2, 3)}, 4, []models.SpeakerSegment{}},
+	}
+	for _, tt := range tests {
+		if got := NormalizeSpeakerSegments(tt.segments, tt.wordCount); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: NormalizeSpeakerSegments() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpeakerAt(t *testing.T) {
+	segments := []models.SpeakerSegment{segment("A", 0, 1), segment("B", 3, 4)}
+	tests := []struct {
+		position int
+		want     string
+	}{
+		{0, "A"},
+		{1, "A"},
+		{2, ""},
+		{4, "B"},
+		{5, ""},
+	}
+	for _, tt := range tests {
+		if got := SpeakerAt(segments, tt.position); got != tt.want {
+			t.Errorf("SpeakerAt(%d) = %q, want %q", tt.position, got, tt.want)
+		}
+	}
+}
+
+func TestSpeakerStatistics(t *testing.T) {
+	words := []string{"Jom", "makan", "makan", "nasi", "ok"}
+	segments := []models.SpeakerSegment{segment("A", 0, 1), segment("B", 2, 3)}
+	stats := SpeakerStatistics(words, segments)
+
+	want := map[string]*SpeakerStats{
+		"A": {Words: map[string]float64{"jom": 1, "makan": 1}, Bigrams: map[string]map[string]float64{"jom": {"makan": 1}}},
+		"B": {Words: map[string]float64{"makan": 1, "nasi": 1}, Bigrams: map[string]map[string]float64{"makan": {"nasi": 1}}},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("SpeakerStatistics() = A %+v B %+v, want A %+v B %+v", stats["A"], stats["B"], want["A"], want["B"])
+	}
+}
+
+func TestSpeakerBoost(t *testing.T) {
+	words := []string{"jom", "makan", "makan", "nasi", "minum"}
+	stats := SpeakerStatistics(words, []models.SpeakerSegment{segment("A", 0, 1), segment("B", 2, 4)})
+	tests := []struct {
+		speaker, word, previous string
+		want                    float64
+	}{
+		{"A", "jom", "", maxSpeakerBoost},
+		{"A", "Makan", "", maxSpeakerBoost / 2},
+		{"A", "makan", "Jom", maxSpeakerBoost/2 + speakerContextBoost},
+		{"B", "makan", "jom", maxSpeakerBoost / 2}, // Only A said it after jom
+		{"B", "jom", "", 0},
+		{"A", "teh", "", 0},
+		{"C", "makan", "", 0},
+	}
+	for _, tt := range tests {
+		if got := SpeakerBoost(stats, tt.speaker, tt.word, tt.previous); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("SpeakerBoost(%s, %q after %q) = %v, want %v", tt.speaker, tt.word, tt.previous, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"sync/atomic"
+
+	"autocomplete/models"
+)
+
+// Cache counters, updated lock-free from the lookup and eviction paths
+var (
+	cacheHits      int64
+	cacheMisses    int64
+	cacheEvictions int64
+	cacheRebuilds  int64
+)
+
+// CacheStats reports the state of the in-memory clip cache
+func CacheStats() models.CacheStats {
+	cacheMutex.RLock()
+	views := make(map[string]*models.PrefixTrie, len(clipTries))
+	for audioID, trie := range clipTries {
+		views[audioID] = trie.Snapshot()
+	}
+	cacheMutex.RUnlock()
+
+	stats := models.CacheStats{
+		ClipCount:      len(views),
+		ClipWordCounts: make(map[string]int, len(views)),
+		Hits:           atomic.LoadInt64(&cacheHits),
+		Misses:         atomic.LoadInt64(&cacheMisses),
+		Evictions:      atomic.LoadInt64(&cacheEvictions),
+		Rebuilds:       atomic.LoadInt64(&cacheRebuilds),
+	}
+	for audioID, view := range views {
+		stats.ClipWordCounts[audioID] = len(view.Words())
+	}
+	stats.HitRatio = HitRatio(stats.Hits, stats.Misses)
+
+	return stats
+}
+
+// HitRatio returns hits / (hits + misses), or 0 before any lookup
+func HitRatio(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestHitRatio(t *testing.T) {
+	tests := []struct {
+		hits, misses int64
+		want         float64
+	}{
+		{0, 0, 0},
+		{1, 0, 1},
+		{0, 3, 0},
+		{3, 1, 0.75},
+	}
+	for _, tt := range tests {
+		if got := HitRatio(tt.hits, tt.misses); got != tt.want {
+			t.Errorf("HitRatio(%d, %d) = %v, want %v", tt.hits, tt.misses, got, tt.want)
+		}
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+
+	BuildAndCacheData("a", &models.AutocompleteData{FinalTranscription: "saya makan nasi"})
+	BuildAndCacheData("a", &models.AutocompleteData{FinalTranscription: "saya minum"})
+	BuildAndCacheData("b", &models.AutocompleteData{FinalTranscription: "tidur"})
+	BuildAndCacheData("c", &models.AutocompleteData{FinalTranscription: "mandi"})
+	GetPrefixTrie("a")
+	GetPrefixTrie("b")
+	GetPrefixTrie("missing")
+	PurgeClip("c")
+	PurgeClip("missing")
+
+	got := CacheStats()
+	want := models.CacheStats{
+		ClipCount:      2,
+		ClipWordCounts: map[string]int{"a": 2, "b": 1},
+		Hits:           2,
+		Misses:         1,
+		Evictions:      1,
+		Rebuilds:       1,
+		HitRatio:       2.0 / 3,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CacheStats() = %+v, want %+v", got, want)
+	}
+
+	ResetCache()
+	if got := CacheStats(); got.ClipCount != 0 || got.Hits != 0 || got.Misses != 0 || got.Evictions != 0 || got.Rebuilds != 0 {
+		t.Errorf("CacheStats() after ResetCache = %+v", got)
+	}
+}
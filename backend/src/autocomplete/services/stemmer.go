@@ -0,0 +1,178 @@
+package services
+
+import (
+	"strings"
+	"sync"
+)
+
+// Stemmer overrides from the packaged resource: a word mapped to itself is a
+// root that only looks affixed
+var (
+	stemOverrides = map[string]string{}
+	stemMutex     sync.RWMutex
+)
+
+// ConfigureStemmer loads the stemmer overrides from the packaged resources
+func ConfigureStemmer() error {
+	lines, err := LoadResource(ResourceStems)
+	if err != nil {
+		return err
+	}
+
+	overrides := make(map[string]string, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(strings.ToLower(line))
+		switch len(fields) {
+		case 1:
+			overrides[fields[0]] = fields[0]
+		case 2:
+			overrides[fields[0]] = fields[1]
+		}
+	}
+
+	stemMutex.Lock()
+	stemOverrides = overrides
+	stemMutex.Unlock()
+
+	return nil
+}
+
+// Minimum stem lengths in runes: suffixes need a longer remainder than
+// prefixes, since most short Malay roots end in -an or -i
+const (
+	minPrefixStem = 3
+	minSuffixStem = 4
+)
+
+// Malay suffixes: particles and possessives are stripped before prefixes,
+// derivational suffixes after. -i is left alone, since too many roots end in
+// it (cari, beli, lari).
+var (
+	malayParticles   = []string{"lah", "kah", "tah", "pun"}
+	malayPossessives = []string{"nya", "ku", "mu"}
+	malaySuffixes    = []string{"kan", "an"}
+)
+
+// Stem reduces a Malay or English word to its stem, so inflections such as
+// "makanan", "dimakan" and "memakan", or "playing" and "played", share one.
+// Malay affixes are stripped first; English suffixes only when no Malay affix
+// matched. Words on the override list are mapped as listed.
+func Stem(word string) string {
+	word = strings.ToLower(word)
+
+	stemMutex.RLock()
+	override, exists := stemOverrides[word]
+	stemMutex.RUnlock()
+	if exists {
+		return override
+	}
+
+	if stem := stemMalay(word); stem != word {
+		return stem
+	}
+	return stemEnglish(word)
+}
+
+// stemMalay strips Malay particles and possessives, then up to two prefixes
+// (as in "mem-per-") recoding meN-/peN- nasal assimilation, then a derivational
+// suffix. Prefixes go before suffixes so "dimakan" keeps the -an of "makan".
+func stemMalay(word string) string {
+	stem := trimSuffix(word, malayParticles, minSuffixStem)
+	stem = trimSuffix(stem, malayPossessives, minSuffixStem)
+
+	for i := 0; i < 2; i++ {
+		stripped := stripMalayPrefix(stem)
+		if stripped == stem || len([]rune(stripped)) < minPrefixStem {
+			break
+		}
+		stem = stripped
+	}
+
+	return trimSuffix(stem, malaySuffixes, minSuffixStem)
+}
+
+// stripMalayPrefix removes one prefix. meN- and peN- drop a root's initial
+// consonant (tulis → menulis, pukul → memukul, sapu → menyapu), which is
+// restored from the nasal left behind.
+func stripMalayPrefix(word string) string {
+	for _, nasal := range []string{"me", "pe"} {
+		if !strings.HasPrefix(word, nasal) {
+			continue
+		}
+		rest := word[len(nasal):]
+		switch {
+		case strings.HasPrefix(rest, "ng"):
+			return rest[2:] // mengajar → ajar, menggali → gali
+		case strings.HasPrefix(rest, "ny") && startsWithAny(rest[2:], vowels):
+			return "s" + rest[2:] // menyapu → sapu
+		case strings.HasPrefix(rest, "m") && startsWithAny(rest[1:], vowels):
+			return "p" + rest[1:] // memukul → pukul
+		case strings.HasPrefix(rest, "m") && startsWithAny(rest[1:], "bfpv"):
+			return rest[1:] // membaca → baca
+		case strings.HasPrefix(rest, "n") && startsWithAny(rest[1:], vowels):
+			return "t" + rest[1:] // menulis → tulis
+		case strings.HasPrefix(rest, "n") && startsWithAny(rest[1:], "cdjz"):
+			return rest[1:] // mencari → cari
+		case nasal == "me" && startsWithAny(rest, "lmnrwy"):
+			return rest // melihat → lihat
+		}
+	}
+
+	// Two-letter prefixes need a longer remainder: many roots start with di-, ke- or se-
+	for _, prefix := range []string{"ber", "ter", "per", "di", "ke", "se"} {
+		if strings.HasPrefix(word, prefix) && (len(prefix) == 3 || len([]rune(word))-len(prefix) >= minSuffixStem) {
+			return word[len(prefix):]
+		}
+	}
+	return word
+}
+
+// stemEnglish strips common English inflectional suffixes
+func stemEnglish(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y" // studies → study
+	case strings.HasSuffix(word, "ing"), strings.HasSuffix(word, "ed"):
+		stem := strings.TrimSuffix(strings.TrimSuffix(word, "ing"), "ed")
+		if len(stem) < minSuffixStem-1 || !strings.ContainsAny(stem, vowels+"y") {
+			return word
+		}
+		if n := len(stem); n >= 2 && stem[n-1] == stem[n-2] && !strings.ContainsAny(stem[n-1:], "lsz") {
+			stem = stem[:n-1] // running → run
+		}
+		return stem
+	case strings.HasSuffix(word, "ly") && len(word) > 5:
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "es") && hasAnySuffix(word[:len(word)-2], "s", "x", "z", "ch", "sh"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !hasAnySuffix(word, "ss", "us", "is", "as") && len(word) >= minSuffixStem:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// trimSuffix removes the first matching suffix that leaves at least minStem runes
+func trimSuffix(word string, suffixes []string, minStem int) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) && len([]rune(word))-len(suffix) >= minStem {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+func hasAnySuffix(word string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(word, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+const vowels = "aeiou"
+
+// startsWithAny reports whether text starts with one of the ASCII letters in chars
+func startsWithAny(text, chars string) bool {
+	return text != "" && strings.ContainsAny(text[:1], chars)
+}
@@ -0,0 +1,74 @@
+package services
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	if err := ConfigureStemmer(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		word string
+		want string
+	}{
+		// Malay suffixes, particles and possessives
+		{"makanan", "makan"},
+		{"rumahnya", "rumah"},
+		{"bukulah", "buku"},
+		{"makan", "makan"},
+		// meN- and peN- nasal assimilation
+		{"mengajar", "ajar"},
+		{"menulis", "tulis"},
+		{"memukul", "pukul"},
+		{"menyapu", "sapu"},
+		{"membaca", "baca"},
+		{"mencari", "cari"},
+		{"melihat", "lihat"},
+		// Other prefixes, stacked prefixes with a suffix
+		{"dimakan", "makan"},
+		{"bermain", "main"},
+		{"mempersoalkan", "soal"},
+		{"diri", "diri"},
+		// Overrides
+		{"memakan", "makan"},
+		{"keluarga", "keluarga"},
+		{"Sekolah", "sekolah"},
+		// English
+		{"playing", "play"},
+		{"played", "play"},
+		{"running", "run"},
+		{"studies", "study"},
+		{"quickly", "quick"},
+		{"boxes", "box"},
+		{"cats", "cat"},
+		{"bus", "bus"},
+	}
+	for _, tt := range tests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestStemOverridesOnlyListedWords(t *testing.T) {
+	defer ConfigureStemmer()
+
+	stemMutex.Lock()
+	stemOverrides = map[string]string{}
+	stemMutex.Unlock()
+
+	// Without the resource, roots that look affixed are stripped
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"keluarga", "luarga"},
+		{"memakan", "pakan"},
+		{"makanan", "makan"},
+	}
+	for _, tt := range tests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) without overrides = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
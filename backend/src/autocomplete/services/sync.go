@@ -0,0 +1,148 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"autocomplete/models"
+)
+
+// versionSync is the version reason of clips applied from a peer's delta
+const versionSync = "sync"
+
+// Delta sync state, guarded by cacheMutex: this instance's ID and change
+// counter, the highest sequence number seen per origin, and the change that
+// produced each cached clip
+var (
+	syncOrigin = "local"
+	syncSeq    int
+	syncVector = make(map[string]int)
+	clipStamps = make(map[string]models.SyncStamp)
+)
+
+// ConfigureSyncOrigin sets the ID this instance's changes are stamped with. It
+// must stay the same across restarts for peers' version vectors to hold.
+func ConfigureSyncOrigin(origin string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	syncOrigin = origin
+}
+
+// stampClip records a local change to a clip's index. Callers must hold cacheMutex.
+func stampClip(audioID string) {
+	syncSeq++
+	syncVector[syncOrigin] = syncSeq
+	clipStamps[audioID] = models.SyncStamp{Origin: syncOrigin, Seq: syncSeq, At: time.Now()}
+}
+
+// SyncVector returns the highest change sequence number seen per origin
+func SyncVector() map[string]int {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	return copyVector(syncVector)
+}
+
+// ExportDelta captures the cached clips changed since the version vector, in
+// change order. At most limit clips are exported (no limit when 0); the
+// delta's vector then only covers the exported changes and More is set.
+func ExportDelta(since map[string]int, limit int) *models.SyncDelta {
+	cacheMutex.RLock()
+	delta := &models.SyncDelta{Origin: syncOrigin, Clips: []models.ClipDelta{}}
+	var changed []string
+	for audioID, stamp := range clipStamps {
+		if stamp.Seq > since[stamp.Origin] {
+			changed = append(changed, audioID)
+		}
+	}
+	// Sequence order keeps each origin's exported changes a prefix of its
+	// history, so a cut-short export still advances a valid vector
+	sort.Slice(changed, func(i, j int) bool {
+		a, b := clipStamps[changed[i]], clipStamps[changed[j]]
+		if a.Seq != b.Seq {
+			return a.Seq < b.Seq
+		}
+		return a.Origin < b.Origin
+	})
+	if limit > 0 && len(changed) > limit {
+		changed = changed[:limit]
+		delta.More = true
+	}
+
+	stamps := make([]models.SyncStamp, len(changed))
+	views := make([]*models.PrefixTrie, len(changed))
+	positions := make([]models.PositionMap, len(changed))
+	for i, audioID := range changed {
+		stamps[i] = clipStamps[audioID]
+		views[i] = clipTries[audioID].Snapshot()
+		positions[i] = clipPositions[audioID]
+	}
+	if delta.More {
+		delta.Vector = copyVector(since)
+		for _, stamp := range stamps {
+			if stamp.Seq > delta.Vector[stamp.Origin] {
+				delta.Vector[stamp.Origin] = stamp.Seq
+			}
+		}
+	} else {
+		delta.Vector = copyVector(syncVector)
+	}
+	cacheMutex.RUnlock()
+
+	// Walk the frozen tries without the lock, as SnapshotAll does
+	for i, audioID := range changed {
+		delta.Clips = append(delta.Clips, models.ClipDelta{
+			ClipSnapshot: *snapshotClip(audioID, views[i], positions[i]),
+			Stamp:        stamps[i],
+		})
+	}
+	return delta
+}
+
+// ApplyDelta caches the clips of a peer's delta and raises the version vector
+// to the delta's. A clip is skipped when the cached one came from a later
+// change: a higher sequence number of the same origin, otherwise a later
+// change time (ties broken by origin). It returns the clips applied and skipped.
+func ApplyDelta(delta *models.SyncDelta) (int, int) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	applied, skipped := 0, 0
+	for _, clip := range delta.Clips {
+		audioID := NormalizeAudioID(clip.AudioID)
+		if local, exists := clipStamps[audioID]; exists && !newerStamp(clip.Stamp, local) {
+			skipped++
+			continue
+		}
+		restoreClip(audioID, clip.ClipSnapshot, versionSync)
+		clipStamps[audioID] = clip.Stamp
+		if clip.Stamp.Seq > syncVector[clip.Stamp.Origin] {
+			syncVector[clip.Stamp.Origin] = clip.Stamp.Seq
+		}
+		applied++
+	}
+	for origin, seq := range delta.Vector {
+		if origin != syncOrigin && seq > syncVector[origin] {
+			syncVector[origin] = seq
+		}
+	}
+	return applied, skipped
+}
+
+// newerStamp reports whether change a supersedes change b of the same clip
+func newerStamp(a, b models.SyncStamp) bool {
+	if a.Origin == b.Origin {
+		return a.Seq > b.Seq
+	}
+	if !a.At.Equal(b.At) {
+		return a.At.After(b.At)
+	}
+	return a.Origin > b.Origin
+}
+
+func copyVector(vector map[string]int) map[string]int {
+	copied := make(map[string]int, len(vector))
+	for origin, seq := range vector {
+		copied[origin] = seq
+	}
+	return copied
+}
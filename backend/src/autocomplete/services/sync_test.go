@@ -0,0 +1,126 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"autocomplete/models"
+)
+
+func TestNewerStamp(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		a, b models.SyncStamp
+		want bool
+	}{
+		{"same origin, later seq", models.SyncStamp{Origin: "edge", Seq: 2, At: now}, models.SyncStamp{Origin: "edge", Seq: 1, At: now.Add(time.Hour)}, true},
+		{"same origin, earlier seq", models.SyncStamp{Origin: "edge", Seq: 1, At: now.Add(time.Hour)}, models.SyncStamp{Origin: "edge", Seq: 2, At: now}, false},
+		{"other origin, later time", models.SyncStamp{Origin: "edge", Seq: 1, At: now.Add(time.Second)}, models.SyncStamp{Origin: "lab", Seq: 9, At: now}, true},
+		{"other origin, earlier time", models.SyncStamp{Origin: "lab", Seq: 9, At: now}, models.SyncStamp{Origin: "edge", Seq: 1, At: now.Add(time.Second)}, false},
+		{"same time, higher origin", models.SyncStamp{Origin: "lab", Seq: 1, At: now}, models.SyncStamp{Origin: "edge", Seq: 1, At: now}, true},
+		{"same time, lower origin", models.SyncStamp{Origin: "edge", Seq: 1, At: now}, models.SyncStamp{Origin: "lab", Seq: 1, At: now}, false},
+	}
+	for _, tt := range tests {
+		if got := newerStamp(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: newerStamp() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// deltaClips lists the audio IDs of a delta in order
+func deltaClips(delta *models.SyncDelta) []string {
+	ids := []string{}
+	for _, clip := range delta.Clips {
+		ids = append(ids, clip.AudioID)
+	}
+	return ids
+}
+
+func TestExportDelta(t *testing.T) {
+	ConfigureSyncOrigin("edge")
+	defer ConfigureSyncOrigin("local")
+	ResetCache()
+	defer ResetCache()
+
+	start := SyncVector()["edge"]
+	for _, audioID := range []string{"clip-a", "clip-b", "clip-c"} {
+		BuildAndCacheData(audioID, &models.AutocompleteData{FinalTranscription: "saya makan nasi", ConfidenceScore: 0.9})
+	}
+
+	tests := []struct {
+		name      string
+		since     map[string]int
+		limit     int
+		wantClips []string
+		wantMore  bool
+		wantSeq   int // The delta vector's edge entry, after start
+	}{
+		{"everything", nil, 0, []string{"clip-a", "clip-b", "clip-c"}, false, 3},
+		{"since the first change", map[string]int{"edge": start + 1}, 0, []string{"clip-b", "clip-c"}, false, 3},
+		{"up to date", map[string]int{"edge": start + 3}, 0, []string{}, false, 3},
+		{"cut short", nil, 2, []string{"clip-a", "clip-b"}, true, 2},
+		{"limit not reached", map[string]int{"edge": start + 2}, 2, []string{"clip-c"}, false, 3},
+	}
+	for _, tt := range tests {
+		delta := ExportDelta(tt.since, tt.limit)
+		if got := deltaClips(delta); !reflect.DeepEqual(got, tt.wantClips) || delta.More != tt.wantMore {
+			t.Errorf("%s: ExportDelta() = %v more %v, want %v more %v", tt.name, got, delta.More, tt.wantClips, tt.wantMore)
+		}
+		if delta.Vector["edge"] != start+tt.wantSeq || delta.Origin != "edge" {
+			t.Errorf("%s: delta from %s at edge:%d, want edge:%d", tt.name, delta.Origin, delta.Vector["edge"], start+tt.wantSeq)
+		}
+	}
+}
+
+// clipWords returns the words in a cached clip's trie
+func clipWords(t *testing.T, audioID string) map[string][]models.WordSuggestion {
+	t.Helper()
+	trie, err := GetPrefixTrie(audioID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trie.Words()
+}
+
+func TestApplyDelta(t *testing.T) {
+	ConfigureSyncOrigin("edge")
+	defer ConfigureSyncOrigin("local")
+	ResetCache()
+	defer ResetCache()
+
+	BuildAndCacheData("clip-a", &models.AutocompleteData{FinalTranscription: "saya makan nasi", ConfidenceScore: 0.9})
+	BuildAndCacheData("clip-b", &models.AutocompleteData{FinalTranscription: "dia minum teh", ConfidenceScore: 0.9})
+	delta := ExportDelta(nil, 0)
+
+	// Apply on another instance that changed clip-b after the edge did
+	ConfigureSyncOrigin("central")
+	ResetCache()
+	BuildAndCacheData("clip-b", &models.AutocompleteData{FinalTranscription: "dia minum kopi", ConfidenceScore: 0.9})
+
+	tests := []struct {
+		name        string
+		wantApplied int
+		wantSkipped int
+	}{
+		{"first apply", 1, 1},
+		{"applied again", 0, 2},
+	}
+	for _, tt := range tests {
+		applied, skipped := ApplyDelta(delta)
+		if applied != tt.wantApplied || skipped != tt.wantSkipped {
+			t.Errorf("%s: ApplyDelta() = %d applied, %d skipped, want %d, %d", tt.name, applied, skipped, tt.wantApplied, tt.wantSkipped)
+		}
+	}
+
+	if words := clipWords(t, "clip-a"); len(words["makan"]) == 0 {
+		t.Errorf("applied clip-a has words %v", words)
+	}
+	if words := clipWords(t, "clip-b"); len(words["kopi"]) == 0 || len(words["teh"]) != 0 {
+		t.Errorf("later local clip-b was overwritten: %v", words)
+	}
+	if vector := SyncVector(); vector["edge"] != delta.Vector["edge"] {
+		t.Errorf("SyncVector() = %v, want the edge raised to %d", vector, delta.Vector["edge"])
+	}
+}
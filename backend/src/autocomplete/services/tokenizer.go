@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"autocomplete/models"
+)
+
+// Tokenize splits a transcript into word tokens, keeping the punctuation around
+// each word and the text between words as token metadata, so JoinTokens gives
+// back the transcript byte for byte. Punctuation inside a word ("kanak-kanak",
+// "don't") and PII placeholders such as "[PHONE]" stay part of the word; text
+// with no word in it (a lone dash, leading whitespace) is folded into the
+// neighbouring token.
+func Tokenize(text string) []models.Token {
+	tokens := []models.Token{}
+	var pending strings.Builder // Word-less text before the first token
+
+	rest := text
+	for rest != "" {
+		// Split off the next whitespace-delimited chunk and the whitespace after it
+		start := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsSpace(r) })
+		if start < 0 {
+			appendSeparator(&tokens, &pending, rest)
+			break
+		}
+		appendSeparator(&tokens, &pending, rest[:start])
+		rest = rest[start:]
+
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		chunk := rest[:end]
+		rest = rest[end:]
+
+		token, ok := splitChunk(chunk)
+		if !ok {
+			appendSeparator(&tokens, &pending, chunk)
+			continue
+		}
+		if pending.Len() > 0 {
+			token.Leading = pending.String() + token.Leading
+			pending.Reset()
+		}
+		tokens = append(tokens, token)
+	}
+
+	if len(tokens) == 0 && pending.Len() > 0 {
+		// No words at all: keep the text so it still round-trips
+		tokens = append(tokens, models.Token{Separator: pending.String()})
+	}
+	return tokens
+}
+
+// appendSeparator attaches word-less text to the last token, or holds it for
+// the first one
+func appendSeparator(tokens *[]models.Token, pending *strings.Builder, text string) {
+	if len(*tokens) == 0 {
+		pending.WriteString(text)
+		return
+	}
+	(*tokens)[len(*tokens)-1].Separator += text
+}
+
+// splitChunk separates a chunk's leading and trailing punctuation from its word,
+// reporting false when the chunk has no word in it
+func splitChunk(chunk string) (models.Token, bool) {
+	core := strings.TrimFunc(chunk, isTokenPunct)
+	if core == "" {
+		return models.Token{}, false
+	}
+	start := strings.Index(chunk, core)
+	token := models.Token{
+		Leading:  chunk[:start],
+		Text:     core,
+		Trailing: chunk[start+len(core):],
+	}
+
+	// Keep redaction placeholders whole
+	if strings.HasSuffix(token.Leading, "[") && strings.HasPrefix(token.Trailing, "]") && isPlaceholderName(core) {
+		token.Leading = strings.TrimSuffix(token.Leading, "[")
+		token.Trailing = strings.TrimPrefix(token.Trailing, "]")
+		token.Text = "[" + core + "]"
+	}
+	return token, true
+}
+
+// isTokenPunct reports whether r is punctuation or a symbol kept outside a word
+func isTokenPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// isPlaceholderName reports whether name looks like the inside of a PII
+// placeholder: upper-case letters and underscores
+func isPlaceholderName(name string) bool {
+	for _, r := range name {
+		if !unicode.IsUpper(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// JoinTokens rebuilds the transcript the tokens came from
+func JoinTokens(tokens []models.Token) string {
+	var text strings.Builder
+	for _, token := range tokens {
+		text.WriteString(token.Leading)
+		text.WriteString(token.Text)
+		text.WriteString(token.Trailing)
+		text.WriteString(token.Separator)
+	}
+	return text.String()
+}
+
+// TranscriptWords returns the words of a transcript without their punctuation,
+// one per clip position
+func TranscriptWords(text string) []string {
+	tokens := Tokenize(text)
+	words := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if token.Text != "" {
+			words = append(words, token.Text)
+		}
+	}
+	return words
+}
+
+// ReplaceTranscriptWord swaps the word at a position of a transcript, keeping
+// the punctuation and spacing around it
+func ReplaceTranscriptWord(text string, position int, word string) (string, error) {
+	tokens := Tokenize(text)
+	if len(tokens) > 0 && tokens[0].Text == "" {
+		tokens = nil // Word-less transcript
+	}
+	if position < 0 || position >= len(tokens) {
+		return "", fmt.Errorf("%w: transcript has no word at position %d", ErrPositionNotFound, position)
+	}
+	tokens[position].Text = word
+	return JoinTokens(tokens), nil
+}
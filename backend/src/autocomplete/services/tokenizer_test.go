@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []models.Token
+	}{
+		{"", []models.Token{}},
+		{"saya makan", []models.Token{{Text: "saya", Separator: " "}, {Text: "makan"}}},
+		{`"Saya," katanya.`, []models.Token{{Leading: `"`, Text: "Saya", Trailing: `,"`, Separator: " "}, {Text: "katanya", Trailing: "."}}},
+		{"kanak-kanak don't", []models.Token{{Text: "kanak-kanak", Separator: " "}, {Text: "don't"}}},
+		{"call [PHONE] now", []models.Token{{Text: "call", Separator: " "}, {Text: "[PHONE]", Separator: " "}, {Text: "now"}}},
+		{"[nota] ok", []models.Token{{Leading: "[", Text: "nota", Trailing: "]", Separator: " "}, {Text: "ok"}}},
+		{"  saya - makan ", []models.Token{{Leading: "  ", Text: "saya", Separator: " - "}, {Text: "makan", Separator: " "}}},
+		{" ... ", []models.Token{{Separator: " ... "}}},
+	}
+	for _, tt := range tests {
+		got := Tokenize(tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+		if joined := JoinTokens(got); joined != tt.text {
+			t.Errorf("JoinTokens(Tokenize(%q)) = %q", tt.text, joined)
+		}
+	}
+}
+
+func TestTranscriptWords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{" ... ", []string{}},
+		{`"Saya," katanya - ok.`, []string{"Saya", "katanya", "ok"}},
+	}
+	for _, tt := range tests {
+		if got := TranscriptWords(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TranscriptWords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceTranscriptWord(t *testing.T) {
+	tests := []struct {
+		text     string
+		position int
+		want     string
+		wantErr  bool
+	}{
+		{"Saya makna nasi.", 1, "Saya makan nasi.", false},
+		{`"Makna," katanya.`, 0, `"makan," katanya.`, false},
+		{"saya  -  makna", 1, "saya  -  makan", false},
+		{"saya makna", 2, "", true},
+		{"saya makna", -1, "", true},
+		{" ... ", 0, "", true},
+	}
+	for _, tt := range tests {
+		got, err := ReplaceTranscriptWord(tt.text, tt.position, "makan")
+		if got != tt.want || (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrPositionNotFound)) {
+			t.Errorf("ReplaceTranscriptWord(%q, %d) = %q, %v, want %q", tt.text, tt.position, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Topic weighting: a word gains up to maxTopicBoost in proportion to the share
+// of its sightings in tagged clips that came from the clip's topic, scaled
+// down until it has minTopicSightings in that topic
+const (
+	maxTopicBoost     = 0.3
+	minTopicSightings = 3
+)
+
+// maxTopicLength bounds a topic or accent tag
+const maxTopicLength = 64
+
+// NormalizeTopics lower-cases and deduplicates topic tags, dropping the invalid
+// ones (see NormalizeTag)
+func NormalizeTopics(topics []string) []string {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, topic := range topics {
+		topic = NormalizeTag(topic)
+		if topic == "" || seen[topic] {
+			continue
+		}
+		seen[topic] = true
+		normalized = append(normalized, topic)
+	}
+	return normalized
+}
+
+// NormalizeTag lower-cases a topic or accent tag, returning "" for tags that
+// are empty, too long or hold characters other than letters, digits, '-' and '_'
+func NormalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if len(tag) > maxTopicLength || strings.IndexFunc(tag, invalidTopicRune) >= 0 {
+		return ""
+	}
+	return tag
+}
+
+func invalidTopicRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_'
+}
+
+// TopicBoost is the boost for a word seen inTopic times in clips of a topic (or
+// accent) and inAllTopics times in clips tagged with any
+func TopicBoost(inTopic, inAllTopics float64) float64 {
+	if inTopic <= 0 || inAllTopics <= 0 {
+		return 0
+	}
+	share := math.Min(1, inTopic/inAllTopics)
+	return maxTopicBoost * share * math.Min(1, inTopic/minTopicSightings)
+}
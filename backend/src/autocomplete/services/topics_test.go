@@ -0,0 +1,65 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTopics(t *testing.T) {
+	tests := []struct {
+		topics []string
+		want   []string
+	}{
+		{nil, []string{}},
+		{[]string{" Food ", "food", "sports_2024", "cooking-tips"}, []string{"food", "sports_2024", "cooking-tips"}},
+		{[]string{"", "food & drink", "makanan", "Makanan"}, []string{"makanan"}},
+		{[]string{strings.Repeat("a", maxTopicLength), strings.Repeat("b", maxTopicLength+1)}, []string{strings.Repeat("a", maxTopicLength)}},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTopics(tt.topics); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NormalizeTopics(%q) = %q, want %q", tt.topics, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{" Kelantan ", "kelantan"},
+		{"en-GB", "en-gb"},
+		{"east_coast", "east_coast"},
+		{"", ""},
+		{"east coast", ""},
+		{"kelantan!", ""},
+		{strings.Repeat("a", maxTopicLength+1), ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTag(tt.tag); got != tt.want {
+			t.Errorf("NormalizeTag(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestTopicBoost(t *testing.T) {
+	tests := []struct {
+		inTopic, inAllTopics float64
+		want                 float64
+	}{
+		{0, 10, 0},
+		{3, 0, 0},
+		{3, 3, maxTopicBoost},
+		{6, 12, maxTopicBoost / 2},
+		{1, 1, maxTopicBoost / minTopicSightings}, // Too few sightings to trust the share
+		{1, 4, maxTopicBoost / 4 / minTopicSightings},
+		{5, 4, maxTopicBoost}, // Counts read at different times can disagree
+	}
+	for _, tt := range tests {
+		if got := TopicBoost(tt.inTopic, tt.inAllTopics); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("TopicBoost(%v, %v) = %v, want %v", tt.inTopic, tt.inAllTopics, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"autocomplete/models"
+)
+
+// Verified-corpus boosts: a candidate gains verifiedWordBoost per verified
+// sighting (up to maxVerifiedWordBoost), verifiedContextBoost when the verified
+// corpus has it following the previous baseline word, and verifiedConfusionBoost
+// per time the heard word was corrected to it (up to maxVerifiedConfusionBoost),
+// counted separately over all clips and over clips of the same accent.
+// Confidences are capped at 1.
+const (
+	VerifiedConfusionSource   = "verified_confusion"
+	AccentConfusionSource     = "accent_confusion"
+	verifiedWordBoost         = 0.02
+	maxVerifiedWordBoost      = 0.2
+	verifiedContextBoost      = 0.1
+	verifiedConfusionBoost    = 0.15
+	maxVerifiedConfusionBoost = 0.45
+)
+
+// VerifiedPrior is what the corpus of finalized transcripts knows about the
+// words of a clip, overall and among clips of the clip's accent. Keys are lower-cased.
+type VerifiedPrior struct {
+	Frequency        map[string]float64            // Verified sightings of each word
+	Bigrams          map[string]map[string]float64 // Word → verified followers, with counts
+	Confusions       map[string]map[string]float64 // Heard word → words it was corrected to, with counts
+	AccentConfusions map[string]map[string]float64 // As Confusions, for clips of the same accent only
+}
+
+// ClipVocabulary returns the lower-cased words of every transcript of a payload,
+// and separately those of the baseline, for looking up a verified prior
+func ClipVocabulary(data *models.AutocompleteData) (words, baseline []string) {
+	seen := make(map[string]bool)
+	add := func(transcription string) {
+		for _, word := range TranscriptWords(transcription) {
+			word = strings.ToLower(word)
+			if !seen[word] {
+				seen[word] = true
+				words = append(words, word)
+			}
+		}
+	}
+
+	add(data.FinalTranscription)
+	baseline = append(baseline, words...)
+	for _, transcription := range data.ASRAlternatives {
+		add(transcription)
+	}
+	return words, baseline
+}
+
+// ApplyVerifiedPrior returns a copy of the position map with each position's
+// candidates boosted by the verified corpus and re-sorted by confidence. Words
+// the heard baseline was verified to be corrected to, overall or for the
+// clip's accent, are offered even when no model produced them.
+func ApplyVerifiedPrior(positionMap models.PositionMap, prior *VerifiedPrior) models.PositionMap {
+	if prior == nil {
+		return positionMap
+	}
+
+	ranked := make(models.PositionMap, len(positionMap))
+	for pos, candidates := range positionMap {
+		boosted := append([]models.WordSuggestion(nil), candidates...)
+		heard, _ := BaselineWord(candidates)
+		confusions := prior.Confusions[strings.ToLower(heard)]
+		accentConfusions := prior.AccentConfusions[strings.ToLower(heard)]
+
+		for word := range confusions {
+			if !hasCandidate(boosted, word) {
+				boosted = append(boosted, models.WordSuggestion{Text: word, Source: VerifiedConfusionSource, Rank: 2})
+			}
+		}
+		for word := range accentConfusions {
+			if !hasCandidate(boosted, word) {
+				boosted = append(boosted, models.WordSuggestion{Text: word, Source: AccentConfusionSource, Rank: 2})
+			}
+		}
+
+		var followers map[string]float64
+		if previous, ok := BaselineWord(positionMap[pos-1]); ok {
+			followers = prior.Bigrams[strings.ToLower(previous)]
+		}
+
+		for i := range boosted {
+			word := strings.ToLower(boosted[i].Text)
+			boost := math.Min(prior.Frequency[word]*verifiedWordBoost, maxVerifiedWordBoost)
+			if followers[word] > 0 {
+				boost += verifiedContextBoost
+			}
+			boost += math.Min(confusions[word]*verifiedConfusionBoost, maxVerifiedConfusionBoost)
+			boost += math.Min(accentConfusions[word]*verifiedConfusionBoost, maxVerifiedConfusionBoost)
+			boosted[i].Confidence = math.Min(1, boosted[i].Confidence+boost)
+		}
+		sort.SliceStable(boosted, func(i, j int) bool {
+			return boosted[i].Confidence > boosted[j].Confidence
+		})
+		ranked[pos] = boosted
+	}
+	return ranked
+}
+
+// VerifiedConfusions pairs the words of a clip's current baseline with those of
+// its finalized transcript, position by position, returning the heard → final
+// pairs that differ (lower-cased). Transcripts of different lengths can't be
+// aligned this way and yield no pairs.
+func VerifiedConfusions(baseline, final []string) [][2]string {
+	if len(baseline) != len(final) {
+		return nil
+	}
+	var pairs [][2]string
+	for pos := range baseline {
+		heard, verified := strings.ToLower(baseline[pos]), strings.ToLower(final[pos])
+		if heard != verified {
+			pairs = append(pairs, [2]string{heard, verified})
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestApplyVerifiedPrior(t *testing.T) {
+	positionMap := models.PositionMap{
+		0: {{Text: "saya", Confidence: 0.5, Rank: 1}},
+		1: {{Text: "Makan", Confidence: 0.5, Rank: 1}, {Text: "makna", Confidence: 0.45, Rank: 2}},
+	}
+	type candidate struct {
+		text       string
+		confidence float64
+	}
+	tests := []struct {
+		name  string
+		prior *VerifiedPrior
+		want  []candidate // Candidates of position 1
+	}{
+		{"no prior", nil, []candidate{{"Makan", 0.5}, {"makna", 0.45}}},
+		{"frequency", &VerifiedPrior{Frequency: map[string]float64{"makna": 4}}, []candidate{{"makna", 0.53}, {"Makan", 0.5}}},
+		{"frequency capped", &VerifiedPrior{Frequency: map[string]float64{"makan": 50}}, []candidate{{"Makan", 0.7}, {"makna", 0.45}}},
+		{"follows the previous word", &VerifiedPrior{Bigrams: map[string]map[string]float64{"saya": {"makna": 1}}}, []candidate{{"makna", 0.55}, {"Makan", 0.5}}},
+		{"confusion offered", &VerifiedPrior{Confusions: map[string]map[string]float64{"makan": {"makam": 2}}}, []candidate{{"Makan", 0.5}, {"makna", 0.45}, {"makam", 0.3}}},
+		{"confusion capped", &VerifiedPrior{Confusions: map[string]map[string]float64{"makan": {"makam": 9}}}, []candidate{{"Makan", 0.5}, {"makna", 0.45}, {"makam", 0.45}}},
+		{"accent confusion offered", &VerifiedPrior{AccentConfusions: map[string]map[string]float64{"makan": {"mekan": 1}}}, []candidate{{"Makan", 0.5}, {"makna", 0.45}, {"mekan", 0.15}}},
+		{"overall and accent confusions add up", &VerifiedPrior{
+			Confusions:       map[string]map[string]float64{"makan": {"makna": 1}},
+			AccentConfusions: map[string]map[string]float64{"makan": {"makna": 1}},
+		}, []candidate{{"makna", 0.75}, {"Makan", 0.5}}},
+		{"confidence capped", &VerifiedPrior{
+			Frequency:  map[string]float64{"makna": 50},
+			Bigrams:    map[string]map[string]float64{"saya": {"makna": 3}},
+			Confusions: map[string]map[string]float64{"makan": {"makna": 3}},
+		}, []candidate{{"makna", 1}, {"Makan", 0.5}}},
+	}
+	for _, tt := range tests {
+		ranked := ApplyVerifiedPrior(positionMap, tt.prior)
+		var got []candidate
+		for _, suggestion := range ranked[1] {
+			got = append(got, candidate{suggestion.Text, suggestion.Confidence})
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: position 1 = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].text != tt.want[i].text || math.Abs(got[i].confidence-tt.want[i].confidence) > 1e-9 {
+				t.Errorf("%s: position 1 = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+		if positionMap[1][0].Confidence != 0.5 || len(positionMap[1]) != 2 {
+			t.Errorf("%s: the input position map was changed: %v", tt.name, positionMap[1])
+		}
+	}
+}
+
+func TestApplyVerifiedPriorConfusionSource(t *testing.T) {
+	positionMap := models.PositionMap{0: {{Text: "makan", Confidence: 0.5, Rank: 1}}}
+	tests := []struct {
+		prior      *VerifiedPrior
+		wantSource string
+	}{
+		{&VerifiedPrior{Confusions: map[string]map[string]float64{"makan": {"makam": 1}}}, VerifiedConfusionSource},
+		{&VerifiedPrior{AccentConfusions: map[string]map[string]float64{"makan": {"makam": 1}}}, AccentConfusionSource},
+		{&VerifiedPrior{
+			Confusions:       map[string]map[string]float64{"makan": {"makam": 1}},
+			AccentConfusions: map[string]map[string]float64{"makan": {"makam": 1}},
+		}, VerifiedConfusionSource},
+	}
+	for _, tt := range tests {
+		ranked := ApplyVerifiedPrior(positionMap, tt.prior)
+		if len(ranked[0]) != 2 || ranked[0][1].Source != tt.wantSource || ranked[0][1].Rank != 2 {
+			t.Errorf("ApplyVerifiedPrior() = %+v, want makam offered once from %s", ranked[0], tt.wantSource)
+		}
+	}
+}
+
+func TestVerifiedConfusions(t *testing.T) {
+	tests := []struct {
+		baseline, final []string
+		want            [][2]string
+	}{
+		{[]string{"saya", "makam", "nasi"}, []string{"saya", "makan", "nasi"}, [][2]string{{"makam", "makan"}}},
+		{[]string{"Saya", "makan"}, []string{"saya", "Makan"}, nil},
+		{[]string{"saya", "makan"}, []string{"kami", "minum"}, [][2]string{{"saya", "kami"}, {"makan", "minum"}}},
+		{[]string{"saya", "makan"}, []string{"saya", "makan", "nasi"}, nil},
+	}
+	for _, tt := range tests {
+		if got := VerifiedConfusions(tt.baseline, tt.final); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("VerifiedConfusions(%v, %v) = %v, want %v", tt.baseline, tt.final, got, tt.want)
+		}
+	}
+}
+
+func TestClipVocabulary(t *testing.T) {
+	data := &models.AutocompleteData{
+		FinalTranscription: "Saya makan saya",
+		ASRAlternatives:    map[string]string{"whisper": "saya makna", "vosk": "SAYA minum"},
+	}
+	words, baseline := ClipVocabulary(data)
+	if !reflect.DeepEqual(baseline, []string{"saya", "makan"}) {
+		t.Errorf("ClipVocabulary() baseline = %v, want [saya makan]", baseline)
+	}
+	sort.Strings(words)
+	if want := []string{"makan", "makna", "minum", "saya"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("ClipVocabulary() words = %v, want %v", words, want)
+	}
+}
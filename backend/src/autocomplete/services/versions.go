@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"autocomplete/models"
+)
+
+// maxIndexVersions bounds how many past versions are kept per clip
+const maxIndexVersions = 10
+
+// indexVersion is a point-in-time copy of a clip's indexed words, keeping the
+// best suggestion per word so successive builds can be compared
+type indexVersion struct {
+	version   int
+	reason    string
+	createdAt time.Time
+	words     map[string]models.WordSuggestion
+}
+
+// Version history per clip, guarded by cacheMutex
+var clipVersions = make(map[string][]*indexVersion)
+
+// recordVersion snapshots the trie as the clip's next version, stamping it as
+// a local change for delta sync unless it came from a peer. Callers must hold
+// cacheMutex.
+func recordVersion(audioID, reason string, trie *models.PrefixTrie) {
+	if reason != versionSync {
+		stampClip(audioID)
+	}
+
+	words := make(map[string]models.WordSuggestion)
+	for word, suggestions := range trie.Words() {
+		if len(suggestions) > 0 {
+			words[word] = suggestions[0] // Suggestions are kept sorted by confidence
+		}
+	}
+
+	history := clipVersions[audioID]
+	next := 1
+	if len(history) > 0 {
+		next = history[len(history)-1].version + 1
+	}
+
+	history = append(history, &indexVersion{
+		version:   next,
+		reason:    reason,
+		createdAt: time.Now(),
+		words:     words,
+	})
+	if len(history) > maxIndexVersions {
+		history = history[len(history)-maxIndexVersions:]
+	}
+	clipVersions[audioID] = history
+}
+
+// ListVersions returns the stored index versions of a clip, oldest first
+func ListVersions(audioID string) []models.IndexVersionInfo {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	infos := []models.IndexVersionInfo{}
+	for _, v := range clipVersions[audioID] {
+		infos = append(infos, models.IndexVersionInfo{
+			Version:   v.version,
+			Reason:    v.reason,
+			WordCount: len(v.words),
+			CreatedAt: v.createdAt,
+		})
+	}
+	return infos
+}
+
+// DiffVersions compares two index versions of a clip. A version of 0 selects
+// the previous version for from and the latest version for to.
+func DiffVersions(audioID string, from, to int) (*models.VersionDiff, error) {
+	audioID = NormalizeAudioID(audioID)
+
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	history := clipVersions[audioID]
+	if len(history) == 0 {
+		return nil, fmt.Errorf("no index versions recorded for clip %s", audioID)
+	}
+
+	if to == 0 {
+		to = history[len(history)-1].version
+	}
+	if from == 0 {
+		from = to - 1
+	}
+
+	fromVersion := findVersion(history, from)
+	toVersion := findVersion(history, to)
+	if fromVersion == nil || toVersion == nil {
+		return nil, fmt.Errorf("versions %d and %d are not both available for clip %s", from, to, audioID)
+	}
+
+	diff := &models.VersionDiff{
+		AudioID: audioID,
+		From:    from,
+		To:      to,
+		Added:   []models.WordSuggestion{},
+		Removed: []models.WordSuggestion{},
+		Changed: []models.ConfidenceShift{},
+	}
+
+	for word, newSuggestion := range toVersion.words {
+		oldSuggestion, existed := fromVersion.words[word]
+		if !existed {
+			diff.Added = append(diff.Added, newSuggestion)
+			continue
+		}
+		if oldSuggestion.Confidence != newSuggestion.Confidence || oldSuggestion.Source != newSuggestion.Source {
+			diff.Changed = append(diff.Changed, models.ConfidenceShift{
+				Text:          word,
+				OldConfidence: oldSuggestion.Confidence,
+				NewConfidence: newSuggestion.Confidence,
+				Delta:         newSuggestion.Confidence - oldSuggestion.Confidence,
+				OldSource:     oldSuggestion.Source,
+				NewSource:     newSuggestion.Source,
+			})
+		}
+	}
+	for word, oldSuggestion := range fromVersion.words {
+		if _, exists := toVersion.words[word]; !exists {
+			diff.Removed = append(diff.Removed, oldSuggestion)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Text < diff.Added[j].Text })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Text < diff.Removed[j].Text })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Text < diff.Changed[j].Text })
+
+	return diff, nil
+}
+
+func findVersion(history []*indexVersion, version int) *indexVersion {
+	for _, v := range history {
+		if v.version == version {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+// recordWords records a version of the clip holding exactly the given words
+func recordWords(audioID, reason string, words map[string]float64) {
+	trie := models.NewPrefixTrie(audioID)
+	for word, confidence := range words {
+		trie.Insert(word, models.WordSuggestion{Text: word, Confidence: confidence, Source: reason})
+	}
+	cacheMutex.Lock()
+	recordVersion(audioID, reason, trie)
+	cacheMutex.Unlock()
+}
+
+func TestDiffVersions(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+
+	recordWords("clip", "initialize", map[string]float64{"saya": 0.9, "makan": 0.8, "nasi": 0.7})
+	recordWords("clip", "initialize", map[string]float64{"saya": 0.9, "makan": 0.6, "minum": 0.5})
+	recordWords("clip", "replace", map[string]float64{"saya": 0.9, "makan": 0.6, "minum": 0.5})
+
+	type diff struct {
+		added, removed, changed []string
+	}
+	tests := []struct {
+		name     string
+		from, to int
+		want     diff
+		wantErr  bool
+	}{
+		{"source change", 0, 0, diff{[]string{}, []string{}, []string{"makan", "minum", "saya"}}, false},
+		{"first to second", 1, 2, diff{[]string{"minum"}, []string{"nasi"}, []string{"makan"}}, false},
+		{"backwards", 2, 1, diff{[]string{"nasi"}, []string{"minum"}, []string{"makan"}}, false},
+		{"same version", 2, 2, diff{[]string{}, []string{}, []string{}}, false},
+		{"from defaults to one before to", 0, 2, diff{[]string{"minum"}, []string{"nasi"}, []string{"makan"}}, false},
+		{"unknown version", 1, 9, diff{}, true},
+	}
+	for _, tt := range tests {
+		got, err := DiffVersions("clip", tt.from, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: DiffVersions() error = %v", tt.name, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		texts := func(suggestions []models.WordSuggestion) []string {
+			out := []string{}
+			for _, suggestion := range suggestions {
+				out = append(out, suggestion.Text)
+			}
+			return out
+		}
+		changed := []string{}
+		for _, shift := range got.Changed {
+			changed = append(changed, shift.Text)
+		}
+		if gotDiff := (diff{texts(got.Added), texts(got.Removed), changed}); !reflect.DeepEqual(gotDiff, tt.want) {
+			t.Errorf("%s: DiffVersions(%d, %d) = %+v, want %+v", tt.name, tt.from, tt.to, gotDiff, tt.want)
+		}
+	}
+
+	if _, err := DiffVersions("missing", 0, 0); err == nil {
+		t.Error("DiffVersions of a clip without versions returned no error")
+	}
+
+	// The confidence shift of makan between the first two versions
+	got, _ := DiffVersions("clip", 1, 2)
+	oldConfidence, newConfidence := 0.8, 0.6
+	want := models.ConfidenceShift{Text: "makan", OldConfidence: oldConfidence, NewConfidence: newConfidence, Delta: newConfidence - oldConfidence, OldSource: "initialize", NewSource: "initialize"}
+	if len(got.Changed) != 1 || got.Changed[0] != want {
+		t.Errorf("changed = %+v, want %+v", got.Changed, want)
+	}
+}
+
+func TestListVersionsKeepsTheLatest(t *testing.T) {
+	ResetCache()
+	defer ResetCache()
+
+	for i := 0; i < maxIndexVersions+3; i++ {
+		recordWords("clip", "initialize", map[string]float64{"saya": 0.9})
+	}
+	versions := ListVersions("clip")
+	if len(versions) != maxIndexVersions {
+		t.Fatalf("kept %d versions, want %d", len(versions), maxIndexVersions)
+	}
+	if first, last := versions[0].Version, versions[len(versions)-1].Version; first != 4 || last != maxIndexVersions+3 {
+		t.Errorf("kept versions %d to %d, want 4 to %d", first, last, maxIndexVersions+3)
+	}
+	if len(ListVersions("missing")) != 0 {
+		t.Error("a clip without versions lists some")
+	}
+}
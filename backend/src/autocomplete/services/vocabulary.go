@@ -0,0 +1,69 @@
+package services
+
+import (
+	"sort"
+
+	"autocomplete/models"
+)
+
+// PositionVocabulary aggregates every word of a clip's position map: the positions
+// it was heard at, the ASR sources that produced it and its confidence there.
+// Entries are ordered by text, positions ascending.
+func PositionVocabulary(positionMap models.PositionMap) []models.VocabularyEntry {
+	entries := make(map[string]*models.VocabularyEntry)
+	sources := make(map[string]map[string]bool)
+	for pos, candidates := range positionMap {
+		baseline, _ := BaselineWord(candidates)
+		seen := make(map[string]bool)
+		for _, candidate := range candidates {
+			entry, exists := entries[candidate.Text]
+			if !exists {
+				entry = &models.VocabularyEntry{Text: candidate.Text}
+				entries[candidate.Text] = entry
+				sources[candidate.Text] = make(map[string]bool)
+			}
+			if candidate.Source != "" && !sources[candidate.Text][candidate.Source] {
+				sources[candidate.Text][candidate.Source] = true
+				entry.Sources = append(entry.Sources, candidate.Source)
+			}
+			if candidate.Confidence > entry.MaxConfidence {
+				entry.MaxConfidence = candidate.Confidence
+			}
+			// A word listed twice at a position counts once there, at its best
+			if seen[candidate.Text] {
+				continue
+			}
+			seen[candidate.Text] = true
+			entry.Occurrences++
+			entry.MeanConfidence += bestConfidence(candidates, candidate.Text)
+			entry.Positions = append(entry.Positions, pos)
+			if candidate.Text == baseline {
+				entry.Baseline++
+			}
+		}
+	}
+
+	vocabulary := make([]models.VocabularyEntry, 0, len(entries))
+	for _, entry := range entries {
+		entry.MeanConfidence /= float64(entry.Occurrences)
+		sort.Ints(entry.Positions)
+		sort.Strings(entry.Sources)
+		if entry.Sources == nil {
+			entry.Sources = []string{}
+		}
+		vocabulary = append(vocabulary, *entry)
+	}
+	sort.Slice(vocabulary, func(i, j int) bool { return vocabulary[i].Text < vocabulary[j].Text })
+	return vocabulary
+}
+
+// bestConfidence is the highest confidence of a word among a position's candidates
+func bestConfidence(candidates []models.WordSuggestion, text string) float64 {
+	var best float64
+	for _, candidate := range candidates {
+		if candidate.Text == text && candidate.Confidence > best {
+			best = candidate.Confidence
+		}
+	}
+	return best
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+// candidate is a ranked position candidate from one ASR source
+func candidate(text, source string, confidence float64, rank int) models.WordSuggestion {
+	return models.WordSuggestion{Text: text, Source: source, Confidence: confidence, Rank: rank}
+}
+
+func TestPositionVocabulary(t *testing.T) {
+	positionMap := models.PositionMap{
+		0: {candidate("saya", "whisper", 0.9, 1)},
+		1: {candidate("makan", "whisper", 0.8, 1), candidate("makna", "wav2vec", 0.4, 2), candidate("makan", "wav2vec", 0.6, 2)},
+		2: {candidate("nasi", "", 0.7, 1)},
+		3: {candidate("makna", "whisper", 0.6, 1), candidate("makan", "wav2vec", 0.2, 2)},
+	}
+	want := []models.VocabularyEntry{
+		{Text: "makan", Occurrences: 2, MaxConfidence: 0.8, MeanConfidence: 0.5, Sources: []string{"wav2vec", "whisper"}, Positions: []int{1, 3}, Baseline: 1},
+		{Text: "makna", Occurrences: 2, MaxConfidence: 0.6, MeanConfidence: 0.5, Sources: []string{"wav2vec", "whisper"}, Positions: []int{1, 3}, Baseline: 1},
+		{Text: "nasi", Occurrences: 1, MaxConfidence: 0.7, MeanConfidence: 0.7, Sources: []string{}, Positions: []int{2}, Baseline: 1},
+		{Text: "saya", Occurrences: 1, MaxConfidence: 0.9, MeanConfidence: 0.9, Sources: []string{"whisper"}, Positions: []int{0}, Baseline: 1},
+	}
+
+	got := PositionVocabulary(positionMap)
+	if len(got) != len(want) {
+		t.Fatalf("PositionVocabulary() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		// Means are compared with a tolerance, the rest exactly
+		mean := got[i].MeanConfidence
+		got[i].MeanConfidence = want[i].MeanConfidence
+		if math.Abs(mean-want[i].MeanConfidence) > 1e-9 || !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("entry %d = %+v (mean %v), want %+v", i, got[i], mean, want[i])
+		}
+	}
+	if empty := PositionVocabulary(nil); len(empty) != 0 || empty == nil {
+		t.Errorf("PositionVocabulary(nil) = %#v, want an empty list", empty)
+	}
+}
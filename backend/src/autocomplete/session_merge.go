@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// sessionClipsKey lists the clips with review state published for merging
+const sessionClipsKey = redisKeyPrefix + "session:clips"
+
+// clipSessionKey holds each replica's published review state of a clip, as
+// JSON keyed by replica ID
+func clipSessionKey(audioID string) string { return clipKeyPrefix(audioID) + "session" }
+
+// runSessionMerge makes replicas converge on the same session re-ranking.
+// Every interval this replica publishes the review state of clips accepted on
+// here, then merges what the other replicas published into the clips it has
+// cached (see services.MergeSessionState). Published state expires after ttl.
+func (s *AutocompleteService) runSessionMerge(ctx context.Context, replica string, interval, ttl time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.publishSessions(ctx, replica, ttl); err != nil {
+			log.Printf("Error publishing session state: %v", err)
+		}
+		if err := s.mergeSessions(ctx, replica); err != nil {
+			log.Printf("Error merging session state: %v", err)
+		}
+	}
+}
+
+// publishSessions writes the review state of clips accepted on since the last
+// publish under this replica's field. Clips that fail are published next time.
+func (s *AutocompleteService) publishSessions(ctx context.Context, replica string, ttl time.Duration) error {
+	var failed []string
+	var lastErr error
+	for audioID, state := range services.DirtySessionStates() {
+		encoded, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		pipe := s.clipClient(audioID).Pipeline()
+		pipe.HSet(ctx, clipSessionKey(audioID), replica, encoded)
+		pipe.Expire(ctx, clipSessionKey(audioID), ttl)
+		if _, err := pipe.Exec(ctx); err != nil {
+			failed = append(failed, audioID)
+			lastErr = err
+			continue
+		}
+		if err := s.RedisClient.SAdd(ctx, sessionClipsKey, audioID).Err(); err != nil {
+			failed = append(failed, audioID)
+			lastErr = err
+		}
+	}
+	services.MarkSessionsDirty(failed)
+	return lastErr
+}
+
+// mergeSessions merges the other replicas' published review state into the
+// clips cached here, and forgets clips whose published state has expired
+func (s *AutocompleteService) mergeSessions(ctx context.Context, replica string) error {
+	audioIDs, err := s.RedisClient.SMembers(ctx, sessionClipsKey).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, audioID := range audioIDs {
+		if _, err := services.GetPositionMap(audioID); err != nil {
+			continue // Not cached on this replica
+		}
+		published, err := s.clipClient(audioID).HGetAll(ctx, clipSessionKey(audioID)).Result()
+		if err != nil {
+			return err
+		}
+		if len(published) == 0 {
+			s.RedisClient.SRem(ctx, sessionClipsKey, audioID)
+			continue
+		}
+		for from, encoded := range published {
+			if from == replica {
+				continue
+			}
+			var state models.SessionState
+			if err := json.Unmarshal([]byte(encoded), &state); err != nil {
+				log.Printf("Skipping malformed session state of clip %s from %s: %v", audioID, from, err)
+				continue
+			}
+			if _, err := services.MergeSessionState(audioID, &state); err != nil {
+				log.Printf("Error merging session state of clip %s from %s: %v", audioID, from, err)
+			}
+		}
+	}
+	return nil
+}
+
+// dropPublishedSession forgets the review state published for a clip, so a
+// rebuilt clip starts a fresh session on every replica
+func (s *AutocompleteService) dropPublishedSession(ctx context.Context, audioID string) {
+	if err := s.clipClient(audioID).Del(ctx, clipSessionKey(audioID)).Err(); err != nil && err != redis.Nil {
+		log.Printf("Error dropping session state of clip %s: %v", audioID, err)
+	}
+}
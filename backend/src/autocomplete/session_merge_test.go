@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestClipSessionKey(t *testing.T) {
+	tests := []struct {
+		audioID string
+		want    string
+	}{
+		{"clip", "autocomplete:clip:clip:session"},
+		{"demo-kedai", "autocomplete:clip:demo-kedai:session"},
+	}
+	for _, tt := range tests {
+		if got := clipSessionKey(tt.audioID); got != tt.want {
+			t.Errorf("clipSessionKey(%q) = %q, want %q", tt.audioID, got, tt.want)
+		}
+	}
+}
+
+func TestPublishSessionsWithoutRedis(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya tahu dia tahu", ConfidenceScore: 0.9})
+	if _, err := services.AcceptWord("clip", 1, "tau"); err != nil {
+		t.Fatal(err)
+	}
+
+	service := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	if err := service.publishSessions(context.Background(), "replica-a", time.Minute); err == nil {
+		t.Error("publishSessions() succeeded without Redis")
+	}
+	// The failed clip is queued to be published next time
+	if states := services.DirtySessionStates(); states["clip"] == nil {
+		t.Errorf("DirtySessionStates() after a failed publish = %v, want the clip", states)
+	}
+	if err := service.publishSessions(context.Background(), "replica-a", time.Minute); err != nil {
+		t.Errorf("publishSessions() with nothing to publish: %v", err)
+	}
+}
+
+func TestMergeSessionsWithoutRedis(t *testing.T) {
+	service := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	if err := service.mergeSessions(context.Background(), "replica-a"); err == nil {
+		t.Error("mergeSessions() succeeded without Redis")
+	}
+}
+
+func TestRunSessionMergeStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		(&AutocompleteService{RedisClient: unreachableRedis(t)}).runSessionMerge(ctx, "replica-a", time.Millisecond, time.Minute)
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("runSessionMerge() kept running after its context was cancelled")
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// ShardConfig is the SHARD_CONFIG file listing the Redis instances clip data is spread over
+type ShardConfig struct {
+	VirtualNodes int           `json:"virtual_nodes"`
+	Shards       []ShardTarget `json:"shards"`
+}
+
+// ShardTarget names one Redis instance of the shard set
+type ShardTarget struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// shardSet routes clip data to Redis instances by consistent hash of audio_id
+type shardSet struct {
+	config  ShardConfig
+	ring    *services.HashRing
+	clients map[string]*redis.Client
+}
+
+// readShardConfig parses and validates a shard config file
+func readShardConfig(path string) (ShardConfig, error) {
+	var config ShardConfig
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read shard config: %w", err)
+	}
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return config, fmt.Errorf("failed to decode shard config: %w", err)
+	}
+	if len(config.Shards) == 0 {
+		return config, fmt.Errorf("shard config lists no shards")
+	}
+
+	seen := make(map[string]bool)
+	for _, shard := range config.Shards {
+		if shard.Name == "" || shard.URL == "" {
+			return config, fmt.Errorf("every shard needs a name and url")
+		}
+		if seen[shard.Name] {
+			return config, fmt.Errorf("duplicate shard name %s", shard.Name)
+		}
+		seen[shard.Name] = true
+	}
+
+	return config, nil
+}
+
+// openShardSet connects to every shard, reusing clients of a previous set whose URL is unchanged
+func openShardSet(ctx context.Context, config ShardConfig, previous *shardSet) (*shardSet, error) {
+	set := &shardSet{config: config, clients: make(map[string]*redis.Client)}
+
+	names := make([]string, 0, len(config.Shards))
+	for _, shard := range config.Shards {
+		names = append(names, shard.Name)
+
+		if client := previous.clientFor(shard); client != nil {
+			set.clients[shard.Name] = client
+			continue
+		}
+
+		opt, err := redisOptions(shard.URL)
+		if err != nil {
+			set.closeExcept(previous)
+			return nil, fmt.Errorf("failed to parse URL of shard %s: %w", shard.Name, err)
+		}
+		client := redis.NewClient(opt)
+		instrumentRedis(client)
+		if err := client.Ping(ctx).Err(); err != nil {
+			client.Close()
+			set.closeExcept(previous)
+			return nil, fmt.Errorf("failed to connect to shard %s: %w", shard.Name, err)
+		}
+		set.clients[shard.Name] = client
+	}
+	set.ring = services.NewHashRing(names, config.VirtualNodes)
+
+	return set, nil
+}
+
+// clientFor returns the set's client for the shard if it points at the same URL
+func (set *shardSet) clientFor(shard ShardTarget) *redis.Client {
+	if set == nil {
+		return nil
+	}
+	for _, existing := range set.config.Shards {
+		if existing.Name == shard.Name && existing.URL == shard.URL {
+			return set.clients[shard.Name]
+		}
+	}
+	return nil
+}
+
+// closeExcept closes the set's clients that are not shared with other
+func (set *shardSet) closeExcept(other *shardSet) {
+	for name, client := range set.clients {
+		if other != nil && other.clients[name] == client {
+			continue
+		}
+		client.Close()
+	}
+}
+
+// owner returns the shard name and client holding the clip's data
+func (set *shardSet) owner(audioID string) (string, *redis.Client) {
+	name := set.ring.Locate(services.NormalizeAudioID(audioID))
+	return name, set.clients[name]
+}
+
+// clipClient returns the Redis client holding a clip's keys: its shard when
+// sharding is configured, otherwise the primary client
+func (s *AutocompleteService) clipClient(audioID string) *redis.Client {
+	s.shardMutex.RLock()
+	defer s.shardMutex.RUnlock()
+
+	if s.shards == nil {
+		return s.RedisClient
+	}
+	_, client := s.shards.owner(audioID)
+	return client
+}
+
+// clipReadClient returns the client for reading a clip's keys: its shard when
+// sharding is configured, otherwise a read replica
+func (s *AutocompleteService) clipReadClient(audioID string) *redis.Client {
+	s.shardMutex.RLock()
+	sharded := s.shards != nil
+	s.shardMutex.RUnlock()
+
+	if sharded {
+		return s.clipClient(audioID)
+	}
+	return s.readClient()
+}
+
+// redisClients returns the primary client followed by every shard client
+func (s *AutocompleteService) redisClients() []*redis.Client {
+	s.shardMutex.RLock()
+	defer s.shardMutex.RUnlock()
+
+	clients := []*redis.Client{s.RedisClient}
+	if s.shards != nil {
+		for _, shard := range s.shards.config.Shards {
+			clients = append(clients, s.shards.clients[shard.Name])
+		}
+	}
+	return clients
+}
+
+// reloadShards re-reads SHARD_CONFIG and swaps in the new shard set
+func (s *AutocompleteService) reloadShards(ctx context.Context) error {
+	if s.ShardConfigPath == "" {
+		return fmt.Errorf("sharding is not configured (SHARD_CONFIG unset)")
+	}
+
+	config, err := readShardConfig(s.ShardConfigPath)
+	if err != nil {
+		return err
+	}
+
+	s.shardMutex.Lock()
+	defer s.shardMutex.Unlock()
+
+	set, err := openShardSet(ctx, config, s.shards)
+	if err != nil {
+		return err
+	}
+	if s.shards != nil {
+		s.shards.closeExcept(set)
+	}
+	s.shards = set
+
+	return nil
+}
+
+// watchShardReloads reloads the shard config whenever the process receives SIGHUP
+func (s *AutocompleteService) watchShardReloads(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	for range signals {
+		if err := s.reloadShards(ctx); err != nil {
+			log.Printf("Error reloading shard config: %v", err)
+			continue
+		}
+		log.Println("Reloaded shard config")
+	}
+}
+
+func (s *AutocompleteService) handleShardStatus(c *gin.Context) {
+	s.shardMutex.RLock()
+	defer s.shardMutex.RUnlock()
+
+	if s.shards == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	shards := make([]gin.H, 0, len(s.shards.config.Shards))
+	for _, shard := range s.shards.config.Shards {
+		shards = append(shards, gin.H{"name": shard.Name, "url": shard.URL})
+	}
+	response := gin.H{
+		"enabled":       true,
+		"virtual_nodes": s.shards.config.VirtualNodes,
+		"shards":        shards,
+	}
+	if audioID := c.Query("audio_id"); audioID != "" {
+		owner, _ := s.shards.owner(audioID)
+		response["audio_id"] = audioID
+		response["owner"] = owner
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *AutocompleteService) handleReloadShards(c *gin.Context) {
+	if err := s.reloadShards(context.Background()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "reloaded"})
+}
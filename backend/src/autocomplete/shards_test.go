@@ -0,0 +1,136 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+func TestReadShardConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{"valid", `{"virtual_nodes": 10, "shards": [{"name": "a", "url": "redis://a:6379"}, {"name": "b", "url": "redis://b:6379"}]}`, ""},
+		{"not json", `shards: a`, "failed to decode shard config: invalid character 's' looking for beginning of value"},
+		{"no shards", `{"shards": []}`, "shard config lists no shards"},
+		{"missing url", `{"shards": [{"name": "a"}]}`, "every shard needs a name and url"},
+		{"missing name", `{"shards": [{"url": "redis://a:6379"}]}`, "every shard needs a name and url"},
+		{"duplicate name", `{"shards": [{"name": "a", "url": "redis://a:6379"}, {"name": "a", "url": "redis://b:6379"}]}`, "duplicate shard name a"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "shards.json")
+		if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		config, err := readShardConfig(path)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.wantErr {
+			t.Errorf("%s: readShardConfig() error = %q, want %q", tt.name, got, tt.wantErr)
+		}
+		if tt.wantErr == "" && (config.VirtualNodes != 10 || len(config.Shards) != 2) {
+			t.Errorf("%s: readShardConfig() = %+v", tt.name, config)
+		}
+	}
+
+	if _, err := readShardConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("readShardConfig() of a missing file returned no error")
+	}
+}
+
+func TestShardSetClientFor(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "a:6379"})
+	defer client.Close()
+	previous := &shardSet{
+		config:  ShardConfig{Shards: []ShardTarget{{Name: "a", URL: "redis://a:6379"}}},
+		clients: map[string]*redis.Client{"a": client},
+	}
+
+	tests := []struct {
+		name  string
+		set   *shardSet
+		shard ShardTarget
+		want  *redis.Client
+	}{
+		{"no previous set", nil, ShardTarget{Name: "a", URL: "redis://a:6379"}, nil},
+		{"same name and url", previous, ShardTarget{Name: "a", URL: "redis://a:6379"}, client},
+		{"url changed", previous, ShardTarget{Name: "a", URL: "redis://a2:6379"}, nil},
+		{"new shard", previous, ShardTarget{Name: "b", URL: "redis://a:6379"}, nil},
+	}
+	for _, tt := range tests {
+		if got := tt.set.clientFor(tt.shard); got != tt.want {
+			t.Errorf("%s: clientFor(%+v) = %v, want %v", tt.name, tt.shard, got, tt.want)
+		}
+	}
+}
+
+func TestClipIDFromKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"autocomplete:clip:abc:words", "abc"},
+		{"autocomplete:clip:abc:topk", "abc"},
+		{"autocomplete:clip:a:b:words", "a:b"},
+		{"autocomplete:clip:abc", "abc"},
+	}
+	for _, tt := range tests {
+		if got := clipIDFromKey(tt.key); got != tt.want {
+			t.Errorf("clipIDFromKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestReadClientRouting(t *testing.T) {
+	newClient := func(addr string) *redis.Client {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+	primary, replicaA, replicaB := newClient("primary:6379"), newClient("a:6379"), newClient("b:6379")
+	shardA, shardB := newClient("shard-a:6379"), newClient("shard-b:6379")
+	shards := &shardSet{
+		config:  ShardConfig{Shards: []ShardTarget{{Name: "a", URL: "redis://shard-a"}, {Name: "b", URL: "redis://shard-b"}}},
+		ring:    services.NewHashRing([]string{"a", "b"}, 0),
+		clients: map[string]*redis.Client{"a": shardA, "b": shardB},
+	}
+
+	tests := []struct {
+		name        string
+		service     *AutocompleteService
+		wantReads   []*redis.Client // Successive readClient picks
+		wantClip    *redis.Client
+		wantClipAny bool // The clip read goes to whichever shard owns it
+	}{
+		{"primary only", &AutocompleteService{RedisClient: primary}, []*redis.Client{primary, primary}, primary, false},
+		{"replicas rotate", &AutocompleteService{RedisClient: primary, ReadClients: []*redis.Client{replicaA, replicaB}}, []*redis.Client{replicaB, replicaA, replicaB}, nil, false},
+		{"shards own their clips", &AutocompleteService{RedisClient: primary, ReadClients: []*redis.Client{replicaA}, shards: shards}, []*redis.Client{replicaA}, nil, true},
+	}
+	for _, tt := range tests {
+		for i, want := range tt.wantReads {
+			if got := tt.service.readClient(); got != want {
+				t.Errorf("%s: read %d went to %s, want %s", tt.name, i, got.Options().Addr, want.Options().Addr)
+			}
+		}
+		got := tt.service.clipReadClient("clip")
+		switch {
+		case tt.wantClipAny:
+			owner, client := shards.owner("clip")
+			if got != client || tt.service.clipClient("clip") != client {
+				t.Errorf("%s: clip read went to %s, want shard %s", tt.name, got.Options().Addr, owner)
+			}
+		case tt.wantClip != nil && got != tt.wantClip:
+			t.Errorf("%s: clip read went to %s, want %s", tt.name, got.Options().Addr, tt.wantClip.Options().Addr)
+		case tt.wantClip == nil && got != replicaA && got != replicaB:
+			t.Errorf("%s: clip read went to %s, want a replica", tt.name, got.Options().Addr)
+		}
+	}
+}
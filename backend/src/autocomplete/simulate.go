@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Bounds on /simulate: suggestions shown per keystroke by default and at most,
+// and ground-truth words simulated per request
+const (
+	defaultSimulateResults = 5
+	maxSimulateResults     = 20
+	maxSimulateWords       = 2000
+)
+
+// handleSimulate measures the keystrokes a perfect user would save on a clip:
+// each word of the ground-truth transcript is typed against the suggestions
+// the editor would show at its word position (see getPositionedSuggestions)
+// and accepted as soon as it appears among the top max_results.
+func (s *AutocompleteService) handleSimulate(c *gin.Context) {
+	var request struct {
+		AudioID     string   `json:"audio_id"`
+		Transcript  string   `json:"transcript"`
+		MaxResults  int      `json:"max_results"`
+		BlendWeight *float64 `json:"blend_weight"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	maxResults := request.MaxResults
+	if maxResults == 0 {
+		maxResults = defaultSimulateResults
+	}
+	if maxResults < 1 || maxResults > maxSimulateResults {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_results must be between 1 and " + strconv.Itoa(maxSimulateResults)})
+		return
+	}
+	weight := positionBlendWeight
+	if request.BlendWeight != nil {
+		if *request.BlendWeight < 0 || *request.BlendWeight > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "blend_weight must be between 0 and 1"})
+			return
+		}
+		weight = *request.BlendWeight
+	}
+
+	words := referenceWords(request.Transcript)
+	if len(words) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "transcript has no words"})
+		return
+	}
+	if len(words) > maxSimulateWords {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "transcript exceeds " + strconv.Itoa(maxSimulateWords) + " words"})
+		return
+	}
+
+	ctx := context.Background()
+	audioID := services.NormalizeAudioID(request.AudioID)
+	if _, err := s.clipTranscripts(ctx, audioID); err == redis.Nil || err != nil && !s.Stateless {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no index built for clip " + audioID})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tenant := requestTenant(c)
+	simulation := &models.KeystrokeSimulation{
+		AudioID:    audioID,
+		MaxResults: maxResults,
+		Words:      make([]models.WordSimulation, 0, len(words)),
+	}
+	for position, word := range words {
+		result, err := services.SimulateWord(position, word, func(prefix string) ([]string, error) {
+			return s.positionedTexts(ctx, tenant, prefix, audioID, position, weight, maxResults)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		simulation.Words = append(simulation.Words, result)
+	}
+	services.TotalKeystrokes(simulation)
+
+	c.JSON(http.StatusOK, simulation)
+}
+
+// referenceWords returns the words of a ground-truth transcript, normalized and
+// redacted like ingested text so they can match what the index stored
+func referenceWords(transcript string) []string {
+	truth, _ := services.RedactAutocompleteData(services.NormalizeAutocompleteData(&models.AutocompleteData{
+		FinalTranscription: transcript,
+	}))
+	return services.TranscriptWords(truth.FinalTranscription)
+}
+
+// positionedTexts returns the text of the suggestions the editor shows for a
+// prefix typed at a word position of a clip
+func (s *AutocompleteService) positionedTexts(ctx context.Context, tenant, prefix, audioID string, position int, weight float64, maxResults int) ([]string, error) {
+	suggestions, err := s.getPositionedSuggestions(ctx, tenant, services.NormalizeQuery(prefix), audioID, position, weight, maxResults)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		texts[i] = suggestion["text"].(string)
+	}
+	return texts, nil
+}
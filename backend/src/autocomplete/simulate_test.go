@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleSimulateRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan"})
+
+	router := gin.New()
+	router.POST("/simulate", (&AutocompleteService{}).handleSimulate)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"not json", `not json`, http.StatusBadRequest},
+		{"max_results too low", `{"audio_id": "clip", "transcript": "saya makan", "max_results": -1}`, http.StatusBadRequest},
+		{"max_results too high", `{"audio_id": "clip", "transcript": "saya makan", "max_results": 21}`, http.StatusBadRequest},
+		{"blend weight", `{"audio_id": "clip", "transcript": "saya makan", "blend_weight": 1.5}`, http.StatusBadRequest},
+		{"no words", `{"audio_id": "clip", "transcript": " "}`, http.StatusBadRequest},
+		{"too many words", `{"audio_id": "clip", "transcript": "` + strings.Repeat("kata ", maxSimulateWords+1) + `"}`, http.StatusRequestEntityTooLarge},
+		{"unknown clip", `{"audio_id": "other", "transcript": "saya makan"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(tt.body)))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, w.Code, tt.wantStatus, w.Body)
+		}
+	}
+}
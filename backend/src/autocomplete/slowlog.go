@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// slowLogCapacity is how many recent slow operations are kept for /admin/slowlog
+const slowLogCapacity = 128
+
+// SlowEntry is one operation that exceeded the slow query threshold
+type SlowEntry struct {
+	Time       time.Time          `json:"time"`
+	Operation  string             `json:"operation"`
+	Backend    string             `json:"backend"`
+	AudioID    string             `json:"audio_id,omitempty"`
+	Prefix     string             `json:"prefix,omitempty"`
+	Detail     string             `json:"detail,omitempty"`
+	DurationMs float64            `json:"duration_ms"`
+	Breakdown  map[string]float64 `json:"breakdown_ms,omitempty"`
+}
+
+// slowLog is a ring buffer of the most recent slow operations
+type slowLog struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	entries   []SlowEntry
+	next      int
+}
+
+// slowQueries is shared by the Redis hooks, which are installed before the service exists
+var slowQueries = &slowLog{threshold: 100 * time.Millisecond}
+
+// record logs the entry and keeps it in the ring buffer if it crossed the threshold
+func (l *slowLog) record(entry SlowEntry, duration time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if duration < l.threshold {
+		return
+	}
+	entry.DurationMs = float64(duration.Microseconds()) / 1000
+
+	log.Printf("SLOW %s backend=%s audio_id=%q prefix=%q detail=%q took=%.1fms breakdown=%v",
+		entry.Operation, entry.Backend, entry.AudioID, entry.Prefix, entry.Detail, entry.DurationMs, entry.Breakdown)
+
+	if len(l.entries) < slowLogCapacity {
+		l.entries = append(l.entries, entry)
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % slowLogCapacity
+}
+
+// slowest returns the buffered entries ordered from slowest to fastest
+func (l *slowLog) slowest() []SlowEntry {
+	l.mu.Lock()
+	entries := append([]SlowEntry(nil), l.entries...)
+	l.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].DurationMs > entries[j].DurationMs })
+	return entries
+}
+
+// requestTiming accumulates the Redis time spent on behalf of one request
+type requestTiming struct {
+	redisNanos    atomic.Int64
+	redisCommands atomic.Int64
+}
+
+type requestTimingKey struct{}
+type hookStartKey struct{}
+
+// withRequestTiming attaches a timing accumulator that the Redis hook fills in
+func withRequestTiming(ctx context.Context) (context.Context, *requestTiming) {
+	timing := &requestTiming{}
+	return context.WithValue(ctx, requestTimingKey{}, timing), timing
+}
+
+// breakdown splits a request's total time into Redis time and everything else
+func (t *requestTiming) breakdown(total time.Duration) map[string]float64 {
+	redisTime := time.Duration(t.redisNanos.Load())
+	return map[string]float64{
+		"redis":          float64(redisTime.Microseconds()) / 1000,
+		"other":          float64((total - redisTime).Microseconds()) / 1000,
+		"redis_commands": float64(t.redisCommands.Load()),
+	}
+}
+
+// slowLogHook times every command and pipeline sent through a Redis client
+type slowLogHook struct {
+	backend string
+}
+
+func (h slowLogHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, hookStartKey{}, time.Now()), nil
+}
+
+func (h slowLogHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.observe(ctx, cmd.Name(), 1)
+	return nil
+}
+
+func (h slowLogHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, hookStartKey{}, time.Now()), nil
+}
+
+func (h slowLogHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name())
+	}
+	h.observe(ctx, "pipeline["+strings.Join(names, ",")+"]", int64(len(cmds)))
+	return nil
+}
+
+// observe charges the elapsed time to the request and records it if slow
+func (h slowLogHook) observe(ctx context.Context, detail string, commands int64) {
+	start, ok := ctx.Value(hookStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start)
+
+	if timing, ok := ctx.Value(requestTimingKey{}).(*requestTiming); ok {
+		timing.redisNanos.Add(elapsed.Nanoseconds())
+		timing.redisCommands.Add(commands)
+	}
+
+	if len(detail) > 200 {
+		detail = detail[:200] + "..."
+	}
+	slowQueries.record(SlowEntry{
+		Time:      time.Now(),
+		Operation: "redis",
+		Backend:   h.backend,
+		Detail:    detail,
+	}, elapsed)
+}
+
+// instrumentRedis installs the slow query hook on a client
+func instrumentRedis(client *redis.Client) {
+	client.AddHook(slowLogHook{backend: "redis:" + client.Options().Addr})
+}
+
+func (s *AutocompleteService) handleSlowLog(c *gin.Context) {
+	slowQueries.mu.Lock()
+	threshold := slowQueries.threshold
+	slowQueries.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"threshold_ms": threshold.Milliseconds(),
+		"entries":      slowQueries.slowest(),
+	})
+}
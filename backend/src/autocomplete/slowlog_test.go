@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSlowLogRecord(t *testing.T) {
+	tests := []struct {
+		name        string
+		durations   []time.Duration
+		wantLen     int
+		wantSlowest float64
+	}{
+		{"under the threshold", []time.Duration{10 * time.Millisecond, 99 * time.Millisecond}, 0, 0},
+		{"at the threshold", []time.Duration{100 * time.Millisecond}, 1, 100},
+		{"slowest first", []time.Duration{150 * time.Millisecond, 300 * time.Millisecond, 200 * time.Millisecond}, 3, 300},
+		{"ring keeps the latest", append([]time.Duration{time.Second}, repeatDuration(slowLogCapacity, 120*time.Millisecond)...), slowLogCapacity, 120},
+	}
+	for _, tt := range tests {
+		l := &slowLog{threshold: 100 * time.Millisecond}
+		for _, duration := range tt.durations {
+			l.record(SlowEntry{Operation: "suggest"}, duration)
+		}
+		entries := l.slowest()
+		if len(entries) != tt.wantLen {
+			t.Errorf("%s: %d entries, want %d", tt.name, len(entries), tt.wantLen)
+			continue
+		}
+		for i := 1; i < len(entries); i++ {
+			if entries[i].DurationMs > entries[i-1].DurationMs {
+				t.Errorf("%s: entry %d (%.1fms) is slower than entry %d", tt.name, i, entries[i].DurationMs, i-1)
+			}
+		}
+		if len(entries) > 0 && entries[0].DurationMs != tt.wantSlowest {
+			t.Errorf("%s: slowest = %.1fms, want %.1fms", tt.name, entries[0].DurationMs, tt.wantSlowest)
+		}
+	}
+}
+
+func repeatDuration(n int, duration time.Duration) []time.Duration {
+	durations := make([]time.Duration, n)
+	for i := range durations {
+		durations[i] = duration
+	}
+	return durations
+}
+
+func TestSlowLogHookChargesRequest(t *testing.T) {
+	ctx, timing := withRequestTiming(context.Background())
+	hook := slowLogHook{backend: "redis:test"}
+
+	// Without a start time the hook has nothing to measure
+	hook.observe(ctx, "get", 1)
+	if timing.redisCommands.Load() != 0 {
+		t.Errorf("observe without a start charged %d commands", timing.redisCommands.Load())
+	}
+
+	started := context.WithValue(ctx, hookStartKey{}, time.Now().Add(-5*time.Millisecond))
+	hook.observe(started, "get", 1)
+	hook.observe(started, "pipeline[zadd,zadd]", 2)
+
+	breakdown := timing.breakdown(50 * time.Millisecond)
+	if breakdown["redis_commands"] != 3 || breakdown["redis"] < 10 || math.Abs(breakdown["redis"]+breakdown["other"]-50) > 0.01 {
+		t.Errorf("breakdown = %v, want 3 commands and at least 10ms of a 50ms request in Redis", breakdown)
+	}
+}
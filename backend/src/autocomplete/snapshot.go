@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// snapshotFetchTimeout bounds pulling a snapshot from another instance
+const snapshotFetchTimeout = 2 * time.Minute
+
+func (s *AutocompleteService) handleSnapshot(c *gin.Context) {
+	if s.Stateless {
+		c.JSON(http.StatusConflict, gin.H{"error": "stateless mode keeps no in-memory state to snapshot"})
+		return
+	}
+
+	c.JSON(http.StatusOK, services.SnapshotAll())
+}
+
+// handleRestore hydrates this instance from a snapshot, taken either from the
+// request body or pulled from another instance with ?from=<base url>
+func (s *AutocompleteService) handleRestore(c *gin.Context) {
+	if s.Stateless {
+		c.JSON(http.StatusConflict, gin.H{"error": "stateless mode keeps no in-memory state to restore"})
+		return
+	}
+
+	var snapshot *models.ServiceSnapshot
+	if from := c.Query("from"); from != "" {
+		fetched, err := fetchSnapshot(from)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		snapshot = fetched
+	} else {
+		snapshot = &models.ServiceSnapshot{}
+		if err := c.ShouldBindJSON(snapshot); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	restored := services.RestoreSnapshot(snapshot)
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "restored",
+		"clips_restored": restored,
+		"snapshot_time":  snapshot.CreatedAt,
+	})
+}
+
+// fetchSnapshot downloads the in-memory state of the instance at baseURL
+func fetchSnapshot(baseURL string) (*models.ServiceSnapshot, error) {
+	client := &http.Client{Timeout: snapshotFetchTimeout}
+
+	resp, err := client.Get(baseURL + "/admin/snapshot")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch snapshot: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("snapshot source returned status %d", resp.StatusCode)
+	}
+
+	var snapshot models.ServiceSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
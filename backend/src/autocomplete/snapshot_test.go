@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestFetchSnapshot(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+		want    int
+	}{
+		{"two clips", http.StatusOK, `{"clips": [{"audio_id": "a"}, {"audio_id": "b"}]}`, "", 2},
+		{"source in stateless mode", http.StatusConflict, `{"error": "stateless"}`, "snapshot source returned status 409", 0},
+		{"not a snapshot", http.StatusOK, `<html>`, "failed to decode snapshot: invalid character '<' looking for beginning of value", 0},
+	}
+	for _, tt := range tests {
+		var path string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		snapshot, err := fetchSnapshot(server.URL)
+		server.Close()
+
+		if path != "/admin/snapshot" {
+			t.Errorf("%s: fetched %s", tt.name, path)
+		}
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.wantErr {
+			t.Errorf("%s: fetchSnapshot() error = %q, want %q", tt.name, got, tt.wantErr)
+		}
+		if err == nil && len(snapshot.Clips) != tt.want {
+			t.Errorf("%s: fetched %d clips, want %d", tt.name, len(snapshot.Clips), tt.want)
+		}
+	}
+}
+
+func TestHandleRestore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&models.ServiceSnapshot{Clips: []models.ClipSnapshot{
+			{AudioID: "pulled", Words: map[string][]models.WordSuggestion{"nasi": {{Text: "nasi", Confidence: 0.9}}}},
+		}})
+	}))
+	defer source.Close()
+
+	tests := []struct {
+		name       string
+		stateless  bool
+		query      string
+		body       string
+		wantStatus int
+		wantClip   string
+	}{
+		{"body", false, "", `{"clips": [{"audio_id": "posted", "words": {"makan": [{"text": "makan", "confidence": 0.8}]}}]}`, http.StatusOK, "posted"},
+		{"pulled from another instance", false, "?from=" + source.URL, "", http.StatusOK, "pulled"},
+		{"unreachable instance", false, "?from=http://127.0.0.1:1", "", http.StatusBadGateway, ""},
+		{"bad body", false, "", `{"clips": "a"}`, http.StatusBadRequest, ""},
+		{"stateless", true, "", `{"clips": []}`, http.StatusConflict, ""},
+	}
+	for _, tt := range tests {
+		service := &AutocompleteService{Stateless: tt.stateless}
+		router := gin.New()
+		router.POST("/admin/restore", service.handleRestore)
+
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/admin/restore"+tt.query, strings.NewReader(tt.body))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.wantStatus, recorder.Body)
+		}
+		if tt.wantClip == "" {
+			continue
+		}
+		trie, err := services.GetPrefixTrie(tt.wantClip)
+		if err != nil || len(trie.Words()) != 1 {
+			t.Errorf("%s: clip %s not restored: %v", tt.name, tt.wantClip, err)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// Soft-deleted clips keep their Redis keys under the clip's namespace with
+// this suffix prefix (no colon, so clipIDFromKey still routes them), next to
+// a marker holding the deletion time. The trash index lists deleted clips by
+// deletion time.
+const (
+	trashSuffixPrefix = "trash_"
+	trashIndexKey     = redisKeyPrefix + "trash"
+)
+
+// clipDeletedKey marks a soft-deleted clip, expiring with its retention
+func clipDeletedKey(audioID string) string { return clipKeyPrefix(audioID) + "deleted" }
+
+// errClipExists is returned when a deleted clip's ID has live data again
+var errClipExists = errors.New("clip has been initialized again since it was deleted; purge it with hard=true first")
+
+// handleDeleteClip soft-deletes a clip unless hard=true asks for a purge: its
+// Redis keys are moved aside and its cached trie hidden from suggest, and
+// both are kept for the retention period so /admin/clips/{id}/restore can
+// bring them back. The global clip owns shared namespaces, so it can only be purged.
+func (s *AutocompleteService) handleDeleteClip(c *gin.Context) {
+	if c.Query("hard") == "true" {
+		s.handlePurgeClip(c)
+		return
+	}
+	audioID := c.Param("audio_id")
+	if audioID == services.GlobalAudioID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the global clip can only be purged, with hard=true"})
+		return
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	services.ExpireDeletedClips(now.Add(-s.ClipRetention))
+	client := s.clipClient(audioID)
+
+	// A clip deleted again replaces what its earlier deletion kept
+	if _, err := s.deleteKeys(ctx, client, clipKeyPrefix(audioID)+trashSuffixPrefix+"*"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	keys, err := s.liveClipKeys(ctx, client, audioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	cached := services.SoftDeleteClip(audioID)
+	if !cached && len(keys) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no data stored for clip " + audioID})
+		return
+	}
+
+	prefix := clipKeyPrefix(audioID)
+	pipe := client.TxPipeline()
+	for _, key := range keys {
+		trashKey := prefix + trashSuffixPrefix + strings.TrimPrefix(key, prefix)
+		pipe.Rename(ctx, key, trashKey)
+		pipe.Expire(ctx, trashKey, s.ClipRetention)
+	}
+	pipe.Set(ctx, clipDeletedKey(audioID), now.Unix(), s.ClipRetention)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.RedisClient.ZAdd(ctx, trashIndexKey, &redis.Z{Score: float64(now.Unix()), Member: audioID}).Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.recordEvent(ctx, &loggedEvent{Type: eventDelete, AudioID: audioID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":           "deleted",
+		"audio_id":         audioID,
+		"trie_hidden":      cached,
+		"redis_keys_moved": len(keys),
+		"restore_until":    now.Add(s.ClipRetention),
+	})
+}
+
+// handleRestoreClip brings back a soft-deleted clip within its retention
+func (s *AutocompleteService) handleRestoreClip(c *gin.Context) {
+	audioID := c.Param("audio_id")
+	ctx := context.Background()
+	services.ExpireDeletedClips(time.Now().Add(-s.ClipRetention))
+	client := s.clipClient(audioID)
+
+	live, err := s.liveClipKeys(ctx, client, audioID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, key := range live {
+		if key == clipWordsKey(audioID) || key == clipTranscriptsKey(audioID) {
+			c.JSON(http.StatusConflict, gin.H{"error": errClipExists.Error()})
+			return
+		}
+	}
+
+	cached, err := services.RestoreDeletedClip(audioID)
+	if errors.Is(err, services.ErrClipExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": errClipExists.Error()})
+		return
+	}
+
+	var trashed []string
+	iter := client.Scan(ctx, 0, clipKeyPrefix(audioID)+trashSuffixPrefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		trashed = append(trashed, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !cached && len(trashed) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no deleted data for clip " + audioID + ", or its retention has expired"})
+		return
+	}
+
+	prefix := clipKeyPrefix(audioID) + trashSuffixPrefix
+	pipe := client.TxPipeline()
+	for _, trashKey := range trashed {
+		key := clipKeyPrefix(audioID) + strings.TrimPrefix(trashKey, prefix)
+		pipe.Rename(ctx, trashKey, key)
+		pipe.Expire(ctx, key, clipIndexTTL)
+	}
+	pipe.Del(ctx, clipDeletedKey(audioID))
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.RedisClient.ZRem(ctx, trashIndexKey, audioID)
+	s.recordEvent(ctx, &loggedEvent{Type: eventRestore, AudioID: audioID})
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":              "restored",
+		"audio_id":            audioID,
+		"trie_restored":       cached,
+		"redis_keys_restored": len(trashed),
+	})
+}
+
+// handleListDeletedClips lists the soft-deleted clips that can still be
+// restored, most recently deleted first
+func (s *AutocompleteService) handleListDeletedClips(c *gin.Context) {
+	ctx := context.Background()
+	services.ExpireDeletedClips(time.Now().Add(-s.ClipRetention))
+
+	deletedAt := services.DeletedClips()
+	indexed, err := s.RedisClient.ZRangeWithScores(ctx, trashIndexKey, 0, -1).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, entry := range indexed {
+		audioID := entry.Member.(string)
+		exists, err := s.clipClient(audioID).Exists(ctx, clipDeletedKey(audioID)).Result()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if exists == 0 {
+			s.RedisClient.ZRem(ctx, trashIndexKey, audioID) // Retention expired
+			continue
+		}
+		if _, cached := deletedAt[audioID]; !cached {
+			deletedAt[audioID] = time.Unix(int64(entry.Score), 0)
+		}
+	}
+
+	clips := make([]gin.H, 0, len(deletedAt))
+	for audioID, at := range deletedAt {
+		clips = append(clips, gin.H{
+			"audio_id":      audioID,
+			"deleted_at":    at,
+			"restore_until": at.Add(s.ClipRetention),
+		})
+	}
+	sort.Slice(clips, func(i, j int) bool {
+		return clips[i]["deleted_at"].(time.Time).After(clips[j]["deleted_at"].(time.Time))
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":          len(clips),
+		"retention_days": strconv.FormatFloat(s.ClipRetention.Hours()/24, 'f', -1, 64),
+		"clips":          clips,
+	})
+}
+
+// liveClipKeys lists a clip's keys other than its trash, deletion marker and lock
+func (s *AutocompleteService) liveClipKeys(ctx context.Context, client *redis.Client, audioID string) ([]string, error) {
+	prefix := clipKeyPrefix(audioID)
+	var keys []string
+	iter := client.Scan(ctx, 0, prefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		suffix := strings.TrimPrefix(key, prefix)
+		if strings.HasPrefix(suffix, trashSuffixPrefix) || key == clipDeletedKey(audioID) || key == clipLockKey(audioID) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, iter.Err()
+}
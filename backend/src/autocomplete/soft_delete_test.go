@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClipIDFromTrashKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{clipDeletedKey("clip"), "clip"},
+		{clipKeyPrefix("clip") + trashSuffixPrefix + "words", "clip"},
+		{clipKeyPrefix("demo-kedai") + trashSuffixPrefix + "transcripts", "demo-kedai"},
+	}
+	for _, tt := range tests {
+		if got := clipIDFromKey(tt.key); got != tt.want {
+			t.Errorf("clipIDFromKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSoftDeleteHandlersWithoutRedis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	service := &AutocompleteService{RedisClient: unreachableRedis(t), ClipRetention: 24 * time.Hour}
+	router.DELETE("/admin/clips/:audio_id", service.handleDeleteClip)
+	router.POST("/admin/clips/:audio_id/restore", service.handleRestoreClip)
+	router.GET("/admin/clips/deleted", service.handleListDeletedClips)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"global clip", http.MethodDelete, "/admin/clips/global", http.StatusBadRequest},
+		{"delete", http.MethodDelete, "/admin/clips/clip", http.StatusInternalServerError},
+		{"restore", http.MethodPost, "/admin/clips/clip/restore", http.StatusInternalServerError},
+		{"list", http.MethodGet, "/admin/clips/deleted", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.path, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
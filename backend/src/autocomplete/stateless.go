@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// clipIndexTTL is how long a clip's Redis index lives after its last
+// initialize; archiving idle clips raises it past the archive threshold
+var clipIndexTTL = time.Hour
+
+// Redis layout of a clip's index in stateless mode, replacing the in-memory
+// trie (lexicographic set plus per-word metadata) and position map (hash).
+// Hash values are msgpack-packed suggestion lists, so one HMGET returns every
+// suggestion of a word with its source, rank and agreement.
+func clipLexKey(audioID string) string       { return clipKeyPrefix(audioID) + "lex" }
+func clipWordsKey(audioID string) string     { return clipKeyPrefix(audioID) + "words" }
+func clipPositionsKey(audioID string) string { return clipKeyPrefix(audioID) + "positions" }
+
+// clipLanguageLexKey is the lexicographic index of one language's words in a
+// stateless clip; their metadata stays in the clip's words hash. The suffix
+// has no colon, so clipIDFromKey still finds the clip.
+func clipLanguageLexKey(audioID, lang string) string {
+	return clipKeyPrefix(audioID) + "lex_" + lang
+}
+
+// clipOccurrencesKey maps each baseline word of a stateless clip to the JSON
+// list of positions it is read at
+func clipOccurrencesKey(audioID string) string { return clipKeyPrefix(audioID) + "occurrences" }
+
+// clipTranscriptsKey holds the JSON payload a stateless clip was built from
+func clipTranscriptsKey(audioID string) string { return clipKeyPrefix(audioID) + "transcripts" }
+
+// clipIndexKeys lists the keys of a stateless clip's index
+func clipIndexKeys(audioID string) []string {
+	keys := []string{clipLexKey(audioID), clipWordsKey(audioID), clipPositionsKey(audioID), clipOccurrencesKey(audioID), clipTopKKey(audioID)}
+	for _, lang := range services.Languages {
+		keys = append(keys, clipLanguageLexKey(audioID, lang))
+	}
+	return keys
+}
+
+// storeClipIndex writes the clip's index, ranked with the verified prior, and
+// its transcripts to Redis so any replica can serve it
+func (s *AutocompleteService) storeClipIndex(ctx context.Context, audioID string, data *models.AutocompleteData, prior *services.VerifiedPrior) error {
+	audioID = services.NormalizeAudioID(audioID)
+	positionMap := services.ApplyVerifiedPrior(services.BuildPositionMap(data), prior)
+	if err := s.writeClipIndex(ctx, audioID, positionMap); err != nil {
+		return err
+	}
+
+	transcripts, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.clipClient(audioID).Set(ctx, clipTranscriptsKey(audioID), transcripts, clipIndexTTL).Err()
+}
+
+// writeClipIndex replaces the clip's Redis index with one built from the position map
+func (s *AutocompleteService) writeClipIndex(ctx context.Context, audioID string, positionMap models.PositionMap) error {
+	words := make(map[string][]models.WordSuggestion)
+	positions := make(map[string]interface{}, len(positionMap))
+	for pos, candidates := range positionMap {
+		packed, err := encodeSuggestions(candidates)
+		if err != nil {
+			return err
+		}
+		positions[strconv.Itoa(pos)] = packed
+
+		for _, candidate := range candidates {
+			words[candidate.Text] = append(words[candidate.Text], candidate)
+		}
+	}
+
+	occurrences := make(map[string]interface{})
+	for word, wordPositions := range services.BuildOccurrences(positionMap) {
+		encoded, err := json.Marshal(wordPositions)
+		if err != nil {
+			return err
+		}
+		occurrences[word] = encoded
+	}
+
+	lexMembers := make([]*redis.Z, 0, len(words))
+	languageMembers := make(map[string][]*redis.Z)
+	wordFields := make(map[string]interface{}, len(words))
+	for word, suggestions := range words {
+		packed, err := encodeSuggestions(suggestions)
+		if err != nil {
+			return err
+		}
+		wordFields[word] = packed
+		lexMembers = append(lexMembers, &redis.Z{Score: 0, Member: word})
+		lang := services.DetectLanguage(word)
+		languageMembers[lang] = append(languageMembers[lang], &redis.Z{Score: 0, Member: word})
+	}
+	indexKeys := clipIndexKeys(audioID)
+
+	// Replace the previous index atomically so readers never see a half-built clip
+	pipe := s.clipClient(audioID).TxPipeline()
+	pipe.Del(ctx, indexKeys...)
+	if len(lexMembers) > 0 {
+		pipe.ZAdd(ctx, clipLexKey(audioID), lexMembers...)
+		pipe.HSet(ctx, clipWordsKey(audioID), wordFields)
+		pipe.HSet(ctx, clipTopKKey(audioID), clipTopKFields(words))
+	}
+	if len(positions) > 0 {
+		pipe.HSet(ctx, clipPositionsKey(audioID), positions)
+	}
+	if len(occurrences) > 0 {
+		pipe.HSet(ctx, clipOccurrencesKey(audioID), occurrences)
+	}
+	for lang, members := range languageMembers {
+		pipe.ZAdd(ctx, clipLanguageLexKey(audioID, lang), members...)
+	}
+	for _, key := range indexKeys {
+		pipe.Expire(ctx, key, clipIndexTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// clipTrie returns the clip's prefix trie: the cached one normally, or in
+// stateless mode one rebuilt from the words under prefix in the clip's Redis
+// lexicographic index (an empty prefix rebuilds the whole clip)
+func (s *AutocompleteService) clipTrie(ctx context.Context, audioID, prefix string) (*models.PrefixTrie, error) {
+	if !s.Stateless {
+		return services.GetPrefixTrie(audioID)
+	}
+	audioID = services.NormalizeAudioID(audioID)
+	return s.lexTrie(ctx, audioID, clipLexKey(audioID), prefix)
+}
+
+// clipLanguageTrie is clipTrie restricted to the clip's words of one language
+func (s *AutocompleteService) clipLanguageTrie(ctx context.Context, audioID, lang, prefix string) (*models.PrefixTrie, error) {
+	if !s.Stateless {
+		return services.GetLanguageTrie(audioID, lang)
+	}
+	audioID = services.NormalizeAudioID(audioID)
+	return s.lexTrie(ctx, audioID, clipLanguageLexKey(audioID, lang), prefix)
+}
+
+// lexTrie rebuilds a trie from the words under prefix in one of a stateless
+// clip's lexicographic indexes, with their metadata from the words hash
+func (s *AutocompleteService) lexTrie(ctx context.Context, audioID, lexKey, prefix string) (*models.PrefixTrie, error) {
+	s.touchClip(audioID)
+	client := s.clipReadClient(audioID)
+	exists, err := client.Exists(ctx, clipWordsKey(audioID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, fmt.Errorf("%w for clip %s, please initialize first", services.ErrClipNotInitialized, audioID)
+	}
+
+	words, err := client.ZRangeByLex(ctx, lexKey, &redis.ZRangeBy{
+		Min: "[" + prefix,
+		Max: "[" + prefix + "\xff",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	trie := models.NewPrefixTrie(audioID)
+	if len(words) == 0 {
+		return trie, nil
+	}
+
+	packed, err := client.HMGet(ctx, clipWordsKey(audioID), words...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range packed {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		suggestions, err := decodeSuggestions([]byte(str))
+		if err != nil {
+			return nil, fmt.Errorf("corrupt index entry for %q: %w", words[i], err)
+		}
+		for _, suggestion := range suggestions {
+			trie.Insert(words[i], suggestion)
+		}
+	}
+
+	return trie, nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClipIndexKeysRouteToTheirClip(t *testing.T) {
+	// Every key of a clip must be found by its clip's purge and shard routing,
+	// and by no clip whose ID extends it
+	for _, audioID := range []string{"abc", "a:b", "global"} {
+		keys := append(clipIndexKeys(audioID), clipTranscriptsKey(audioID))
+		for _, key := range keys {
+			if got := clipIDFromKey(key); got != audioID {
+				t.Errorf("clipIDFromKey(%q) = %q, want %q", key, got, audioID)
+			}
+		}
+		if got := ownClipKeys(append([]string(nil), keys...), audioID); !reflect.DeepEqual(got, keys) {
+			t.Errorf("ownClipKeys(%q) = %v, want %v", audioID, got, keys)
+		}
+		if got := ownClipKeys(append([]string(nil), keys...), audioID+":x"); len(got) != 0 {
+			t.Errorf("ownClipKeys(%q) claims keys of %q: %v", audioID+":x", audioID, got)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+const (
+	// redisKeyPrefix is the namespace every autocomplete key lives under
+	redisKeyPrefix = "autocomplete:"
+
+	// memorySamplesPerNamespace bounds the MEMORY USAGE calls made per namespace
+	memorySamplesPerNamespace = 50
+)
+
+func (s *AutocompleteService) handleStats(c *gin.Context) {
+	ctx := context.Background()
+
+	namespaces, err := s.redisNamespaceStats(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	info, err := s.RedisClient.Info(ctx, "stats", "memory").Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	redisInfo := parseRedisInfo(info)
+
+	hits := s.suggestHits.Load()
+	misses := s.suggestMisses.Load()
+
+	c.JSON(http.StatusOK, gin.H{
+		"cache":       services.CacheStats(),
+		"write_queue": s.queueStats(),
+		"priority":    s.priorityStats(),
+		"adaptive":    s.adaptiveStats(),
+		"watchdog":    s.watchdogStats(),
+		"replay":      s.replayStats(),
+		"webhooks":    s.webhookStats(),
+		"projector":   s.projectorStats(ctx),
+		"ingest":      s.ingests.stats(time.Now()),
+		"semantic":    semanticStats(),
+		"suggest": gin.H{
+			"hits":      hits,
+			"misses":    misses,
+			"hit_ratio": services.HitRatio(hits, misses),
+			"partial":   s.suggestPartial.Load(),
+			"streams":   s.streamStats(),
+		},
+		"redis": gin.H{
+			"namespaces":      namespaces,
+			"pools":           s.redisPoolStats(),
+			"used_memory":     redisInfo["used_memory"],
+			"keyspace_hits":   redisInfo["keyspace_hits"],
+			"keyspace_misses": redisInfo["keyspace_misses"],
+			"evicted_keys":    redisInfo["evicted_keys"],
+			"expired_keys":    redisInfo["expired_keys"],
+		},
+	})
+}
+
+// redisNamespaceStats counts autocomplete keys grouped by namespace (the segment
+// after "autocomplete:") and estimates their memory from a sample of each
+func (s *AutocompleteService) redisNamespaceStats(ctx context.Context) (map[string]*models.RedisNamespaceStats, error) {
+	namespaces := make(map[string]*models.RedisNamespaceStats)
+
+	for _, client := range s.redisClients() {
+		if err := scanNamespaceStats(ctx, client, namespaces); err != nil {
+			return nil, err
+		}
+	}
+
+	// Extrapolate the sampled usage to the whole namespace
+	for _, stats := range namespaces {
+		if stats.SampledKeys > 0 && stats.SampledKeys < stats.KeyCount {
+			stats.EstimatedBytes = stats.EstimatedBytes * stats.KeyCount / stats.SampledKeys
+		}
+	}
+
+	return namespaces, nil
+}
+
+// scanNamespaceStats adds one Redis instance's autocomplete keys to the namespace totals
+func scanNamespaceStats(ctx context.Context, client *redis.Client, namespaces map[string]*models.RedisNamespaceStats) error {
+	iter := client.Scan(ctx, 0, redisKeyPrefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		namespace := strings.SplitN(strings.TrimPrefix(key, redisKeyPrefix), ":", 2)[0]
+
+		stats, exists := namespaces[namespace]
+		if !exists {
+			stats = &models.RedisNamespaceStats{}
+			namespaces[namespace] = stats
+		}
+		stats.KeyCount++
+
+		if stats.SampledKeys < memorySamplesPerNamespace {
+			usage, err := client.MemoryUsage(ctx, key).Result()
+			if err == nil {
+				stats.SampledKeys++
+				stats.EstimatedBytes += usage
+			}
+		}
+	}
+	return iter.Err()
+}
+
+// parseRedisInfo extracts the numeric fields of an INFO reply
+func parseRedisInfo(info string) map[string]int64 {
+	fields := make(map[string]int64)
+
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		name, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found {
+			continue
+		}
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			fields[name] = parsed
+		}
+	}
+
+	return fields
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	index := (len(sorted)*p+99)/100 - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}
+
+// redisPoolStats reports connection pool usage for the primary, read replica and shard clients
+func (s *AutocompleteService) redisPoolStats() map[string]interface{} {
+	pools := map[string]interface{}{
+		"primary": poolStats(s.RedisClient),
+	}
+	for i, client := range s.ReadClients {
+		pools["read_"+strconv.Itoa(i)] = poolStats(client)
+	}
+
+	s.shardMutex.RLock()
+	if s.shards != nil {
+		for name, client := range s.shards.clients {
+			pools["shard_"+name] = poolStats(client)
+		}
+	}
+	s.shardMutex.RUnlock()
+
+	return pools
+}
+
+// poolStats converts a client's pool counters and settings into JSON fields
+func poolStats(client *redis.Client) map[string]interface{} {
+	stats := client.PoolStats()
+	opt := client.Options()
+	return map[string]interface{}{
+		"pool_size":      opt.PoolSize,
+		"min_idle_conns": opt.MinIdleConns,
+		"total_conns":    stats.TotalConns,
+		"idle_conns":     stats.IdleConns,
+		"stale_conns":    stats.StaleConns,
+		"hits":           stats.Hits,
+		"misses":         stats.Misses,
+		"timeouts":       stats.Timeouts,
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRedisInfo(t *testing.T) {
+	info := "# Memory\r\nused_memory:1048576\r\nused_memory_human:1.00M\r\nmem_fragmentation_ratio:1.25\r\n\r\n# Stats\r\nkeyspace_hits:42\r\nkeyspace_misses:0\r\nevicted_keys:-1\r\nnot a field\r\n"
+	want := map[string]int64{
+		"used_memory":     1048576,
+		"keyspace_hits":   42,
+		"keyspace_misses": 0,
+		"evicted_keys":    -1,
+	}
+	if got := parseRedisInfo(info); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRedisInfo() = %v, want %v", got, want)
+	}
+	if got := parseRedisInfo(""); len(got) != 0 {
+		t.Errorf("parseRedisInfo(\"\") = %v", got)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// Stem index: stems by the prefixes of the stem, and each stem's surface forms
+const (
+	stemPrefixKeyPrefix = redisKeyPrefix + "stemprefix:"
+	stemFormsKeyPrefix  = redisKeyPrefix + "stem:"
+)
+
+// stemFormsLimit bounds the surface forms nested under each stem
+const stemFormsLimit = 10
+
+// getStemSuggestions returns stems starting with the typed prefix, or with the
+// stem of the typed word, each with its indexed surface forms nested under
+// "forms". A stem ranks by its best form; tenant filters apply to the forms and
+// stems left without any are dropped.
+func (s *AutocompleteService) getStemSuggestions(ctx context.Context, tenant, prefix string, maxResults int) ([]map[string]interface{}, error) {
+	client := s.readClient()
+	fetch := int64(suggestFetchCount(tenant, maxResults))
+
+	prefix = strings.ToLower(prefix)
+	lookups := []string{prefix}
+	if stem := services.Stem(prefix); stem != prefix {
+		lookups = append(lookups, stem)
+	}
+
+	pipe := client.Pipeline()
+	reads := make([]*redis.ZSliceCmd, len(lookups))
+	for i, lookup := range lookups {
+		reads[i] = pipe.ZRevRangeWithScores(ctx, stemPrefixKeyPrefix+indexedRunes(lookup), 0, fetch-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	var stems []string
+	seen := make(map[string]bool)
+	for _, read := range reads {
+		for _, result := range read.Val() {
+			stem := result.Member.(string)
+			if !seen[stem] {
+				seen[stem] = true
+				stems = append(stems, stem)
+			}
+		}
+	}
+	if len(stems) == 0 {
+		return []map[string]interface{}{}, nil
+	}
+
+	pipe = client.Pipeline()
+	formReads := make([]*redis.ZSliceCmd, len(stems))
+	for i, stem := range stems {
+		formReads[i] = pipe.ZRevRangeWithScores(ctx, stemFormsKeyPrefix+stem, 0, stemFormsLimit-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	groups := make([]map[string]interface{}, 0, maxResults)
+	for i, stem := range stems {
+		forms := formatSuggestions(tenant, formReads[i].Val(), stemFormsLimit)
+		if len(forms) == 0 {
+			continue
+		}
+		groups = append(groups, map[string]interface{}{
+			"text":       stem,
+			"confidence": forms[0]["confidence"],
+			"forms":      forms,
+		})
+		if len(groups) == maxResults {
+			break
+		}
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/services"
+)
+
+// suffixKeyPrefix indexes words by the prefixes of their reversed,
+// lower-cased spelling, so ends-with lookups read one key
+const suffixKeyPrefix = redisKeyPrefix + "suffix:"
+
+// Results per /suggest/suffix lookup by default and at most
+const (
+	defaultSuffixResults = 20
+	maxSuffixResults     = 200
+)
+
+// reverseRunes reverses text by runes
+func reverseRunes(text string) string {
+	runes := []rune(text)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// handleSuffixSuggest lists indexed words ending with the suffix, e.g. every
+// "-kan" verb or "-lah" form, ranked by confidence. A leading hyphen is
+// optional.
+func (s *AutocompleteService) handleSuffixSuggest(c *gin.Context) {
+	suffix := services.NormalizeText(strings.TrimPrefix(strings.TrimSpace(c.Query("suffix")), "-"))
+	if suffix == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "suffix parameter required"})
+		return
+	}
+	maxResults := defaultSuffixResults
+	if maxParam := c.Query("max_results"); maxParam != "" {
+		n, err := strconv.Atoi(maxParam)
+		if err != nil || n < 1 || n > maxSuffixResults {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_results must be between 1 and " + strconv.Itoa(maxSuffixResults)})
+			return
+		}
+		maxResults = n
+	}
+
+	suggestions, err := s.getSuffixSuggestions(context.Background(), requestTenant(c), suffix, maxResults)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"suggestions": suggestions,
+		"suffix":      suffix,
+	})
+}
+
+// getSuffixSuggestions returns indexed words ending with the suffix. Only the
+// last prefixIndexDepth letters are indexed, so a longer suffix reads the key
+// of its last letters and checks the rest of each word.
+func (s *AutocompleteService) getSuffixSuggestions(ctx context.Context, tenant, suffix string, maxResults int) ([]map[string]interface{}, error) {
+	suffix = strings.ToLower(suffix)
+	key := suffixKeyPrefix + indexedRunes(reverseRunes(suffix))
+	candidates, err := s.readClient().ZRevRangeWithScores(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := candidates[:0]
+	for _, candidate := range candidates {
+		if strings.HasSuffix(strings.ToLower(candidate.Member.(string)), suffix) {
+			matches = append(matches, candidate)
+		}
+	}
+	return formatSuggestions(tenant, matches, maxResults), nil
+}
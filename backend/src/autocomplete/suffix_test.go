@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReverseRunes(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"a", "a"},
+		{"makan", "nakam"},
+		{"café", "éfac"},
+		{"中文", "文中"},
+	}
+	for _, tt := range tests {
+		if got := reverseRunes(tt.text); got != tt.want {
+			t.Errorf("reverseRunes(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestPlanBatchIndexesSuffixes(t *testing.T) {
+	tests := []struct {
+		word   string
+		suffix string // Looked up through the key getSuffixSuggestions reads
+	}{
+		{"makan", "kan"},
+		{"Makan", "makan"},
+		{"pergilah", "lah"},
+		{"kebersihannya", "bersihannya"}, // Longer than the indexed depth
+		{"café", "é"},
+	}
+	for _, tt := range tests {
+		plan := planBatch([]wordWrite{{word: tt.word, confidence: 0.5}})
+		key := indexedRunes(reverseRunes(tt.suffix))
+		if members := plan.groupsByFamily[suffixKeyPrefix][key]; members[tt.word] != 0.5 {
+			t.Errorf("%q: suffix key %q holds %v, want the word", tt.word, key, members)
+		}
+		if n := len(plan.groupsByFamily[suffixKeyPrefix]); n > prefixIndexDepth {
+			t.Errorf("%q: %d suffix keys, want at most %d", tt.word, n, prefixIndexDepth)
+		}
+	}
+}
+
+func TestHandleSuffixSuggest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/suggest/suffix", (&AutocompleteService{RedisClient: unreachableRedis(t)}).handleSuffixSuggest)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?suffix=-", http.StatusBadRequest},
+		{"?suffix=%20", http.StatusBadRequest},
+		{"?suffix=kan&max_results=0", http.StatusBadRequest},
+		{"?suffix=kan&max_results=201", http.StatusBadRequest},
+		{"?suffix=-kan&max_results=5", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/suggest/suffix"+tt.query, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%q: status %d, want %d: %s", tt.query, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import "fmt"
+
+// suggestLookup records which /suggest/prefix options a request set, so they
+// can be checked against each other in one place. Each of them picks its own
+// lookup, so at most one can be set at a time.
+type suggestLookup struct {
+	matchMode     string
+	edit          bool
+	stemmed       bool
+	caseSensitive bool
+	wordIndex     bool
+	segment       bool
+	context       bool
+	lang          bool
+	homophones    bool
+	backends      bool // Backends other than Redis were named explicitly
+
+	clip     bool // scope=clip
+	fallback bool // fallback=global
+}
+
+// suggestOption is one option of a lookup, and whether the clip's own index
+// can answer it
+type suggestOption struct {
+	name string
+	set  bool
+	clip bool
+}
+
+func (l suggestLookup) options() []suggestOption {
+	return []suggestOption{
+		{"match_mode=" + l.matchMode, l.matchMode != matchModePrefix, l.matchMode == matchModeFuzzy},
+		{"token", l.edit, false},
+		{"stem=true", l.stemmed, false},
+		{"case_sensitive=true", l.caseSensitive, false},
+		{"word_index", l.wordIndex, false},
+		{"segment filters", l.segment, true},
+		{"context words", l.context, false},
+		{"lang", l.lang, true},
+	}
+}
+
+// globalOption names the first option set that only the global pool can
+// answer, or is empty when the clip's own index can serve the lookup
+func (l suggestLookup) globalOption() string {
+	global := []suggestOption{{"homophones=true", l.homophones, false}, {"backends", l.backends, false}}
+	for _, option := range append(l.options(), global...) {
+		if option.set && !option.clip {
+			return option.name
+		}
+	}
+	return ""
+}
+
+// validate returns the first pair of options the lookup can't combine: two
+// options that each pick a lookup, scope=clip with one that reads the global
+// pool, or fallback=global with anything but a plain prefix lookup
+func (l suggestLookup) validate() error {
+	first := ""
+	for _, option := range l.options() {
+		if !option.set {
+			continue
+		}
+		if first != "" {
+			return fmt.Errorf("%s cannot be combined with %s", first, option.name)
+		}
+		first = option.name
+	}
+
+	if global := l.globalOption(); l.clip && global != "" {
+		return fmt.Errorf("scope=clip cannot be combined with %s", global)
+	}
+	if l.fallback && first != "" {
+		return fmt.Errorf("fallback=global cannot be combined with %s", first)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestSuggestLookupValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		lookup suggestLookup
+		want   string
+	}{
+		{"plain prefix", suggestLookup{matchMode: matchModePrefix}, ""},
+		{"fuzzy alone", suggestLookup{matchMode: matchModeFuzzy}, ""},
+		{"stem with phoneme", suggestLookup{matchMode: matchModePhoneme, stemmed: true}, "match_mode=phoneme cannot be combined with stem=true"},
+		{"token with stem", suggestLookup{matchMode: matchModePrefix, edit: true, stemmed: true}, "token cannot be combined with stem=true"},
+		{"case with word index", suggestLookup{matchMode: matchModePrefix, caseSensitive: true, wordIndex: true}, "case_sensitive=true cannot be combined with word_index"},
+		{"word index with fuzzy", suggestLookup{matchMode: matchModeFuzzy, wordIndex: true}, "match_mode=fuzzy cannot be combined with word_index"},
+		{"segment with context", suggestLookup{matchMode: matchModePrefix, segment: true, context: true}, "segment filters cannot be combined with context words"},
+		{"context with lang", suggestLookup{matchMode: matchModePrefix, context: true, lang: true}, "context words cannot be combined with lang"},
+		{"homophones alone", suggestLookup{matchMode: matchModePrefix, homophones: true}, ""},
+		{"clip prefix", suggestLookup{matchMode: matchModePrefix, clip: true}, ""},
+		{"clip fuzzy", suggestLookup{matchMode: matchModeFuzzy, clip: true}, ""},
+		{"clip segment", suggestLookup{matchMode: matchModePrefix, segment: true, clip: true}, ""},
+		{"clip lang", suggestLookup{matchMode: matchModePrefix, lang: true, clip: true}, ""},
+		{"clip infix", suggestLookup{matchMode: matchModeInfix, clip: true}, "scope=clip cannot be combined with match_mode=infix"},
+		{"clip stem", suggestLookup{matchMode: matchModePrefix, stemmed: true, clip: true}, "scope=clip cannot be combined with stem=true"},
+		{"clip context", suggestLookup{matchMode: matchModePrefix, context: true, clip: true}, "scope=clip cannot be combined with context words"},
+		{"clip homophones", suggestLookup{matchMode: matchModePrefix, homophones: true, clip: true}, "scope=clip cannot be combined with homophones=true"},
+		{"clip backends", suggestLookup{matchMode: matchModePrefix, backends: true, clip: true}, "scope=clip cannot be combined with backends"},
+		{"fallback prefix", suggestLookup{matchMode: matchModePrefix, clip: true, fallback: true}, ""},
+		{"fallback fuzzy", suggestLookup{matchMode: matchModeFuzzy, clip: true, fallback: true}, "fallback=global cannot be combined with match_mode=fuzzy"},
+		{"fallback lang", suggestLookup{matchMode: matchModePrefix, lang: true, clip: true, fallback: true}, "fallback=global cannot be combined with lang"},
+	}
+	for _, tt := range tests {
+		got := ""
+		if err := tt.lookup.validate(); err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("%s: validate() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestLookupGlobalOption(t *testing.T) {
+	tests := []struct {
+		lookup suggestLookup
+		want   string
+	}{
+		{suggestLookup{matchMode: matchModePrefix}, ""},
+		{suggestLookup{matchMode: matchModeFuzzy, lang: true}, ""},
+		{suggestLookup{matchMode: matchModePrefix, segment: true}, ""},
+		{suggestLookup{matchMode: matchModePhoneme}, "match_mode=phoneme"},
+		{suggestLookup{matchMode: matchModePrefix, wordIndex: true}, "word_index"},
+		{suggestLookup{matchMode: matchModePrefix, homophones: true}, "homophones=true"},
+		{suggestLookup{matchMode: matchModePrefix, backends: true}, "backends"},
+	}
+	for _, tt := range tests {
+		if got := tt.lookup.globalOption(); got != tt.want {
+			t.Errorf("%+v: globalOption() = %q, want %q", tt.lookup, got, tt.want)
+		}
+	}
+}
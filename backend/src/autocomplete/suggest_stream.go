@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	"autocomplete/services"
+)
+
+// suggestStreamPath serves keystroke sessions over a WebSocket
+const suggestStreamPath = "/suggest/stream"
+
+// streamRequest is one keystroke sent by a streaming client
+type streamRequest struct {
+	Seq        int64  `json:"seq"`
+	Prefix     string `json:"prefix"`
+	MaxResults int    `json:"max_results"`
+}
+
+// streamResponse answers the latest keystroke of a session
+type streamResponse struct {
+	Seq         int64                    `json:"seq"`
+	Prefix      string                   `json:"prefix"`
+	Suggestions []map[string]interface{} `json:"suggestions"`
+	Error       string                   `json:"error,omitempty"`
+}
+
+// suggestStreams tracks streaming sessions and the work coalescing saved
+type suggestStreams struct {
+	idleTimeout time.Duration
+	active      atomic.Int64
+	received    atomic.Int64
+	coalesced   atomic.Int64
+	cancelled   atomic.Int64
+	answered    atomic.Int64
+}
+
+func newSuggestStreams() *suggestStreams {
+	return &suggestStreams{idleTimeout: envDuration("SUGGEST_STREAM_IDLE_TIMEOUT", 5*time.Minute)}
+}
+
+func (s *AutocompleteService) handleSuggestStream(c *gin.Context) {
+	tenant := requestTenant(c)
+	server := websocket.Server{
+		// Origins are not restricted, matching the open CORS policy of the REST routes
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler:   func(ws *websocket.Conn) { s.serveSuggestSession(ws, tenant) },
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// serveSuggestSession answers only the newest prefix of a session. A keystroke
+// arriving while the previous one is still queued replaces it, and one arriving
+// while a lookup is running cancels that lookup, so fast typists cost one lookup
+// at a time instead of one per key.
+func (s *AutocompleteService) serveSuggestSession(ws *websocket.Conn, tenant string) {
+	defer ws.Close()
+	s.streams.active.Add(1)
+	defer s.streams.active.Add(-1)
+
+	// The hijacked connection keeps the HTTP server's deadlines; sessions use an idle timeout instead
+	ws.SetDeadline(time.Time{})
+
+	latest := make(chan streamRequest, 1)
+	var (
+		mu           sync.Mutex
+		cancelLookup context.CancelFunc
+	)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for request := range latest {
+			ctx, cancel := context.WithCancel(context.Background())
+			mu.Lock()
+			cancelLookup = cancel
+			mu.Unlock()
+
+			suggestions, err := s.getPrefixSuggestions(ctx, tenant, request.Prefix, request.MaxResults)
+
+			mu.Lock()
+			superseded := ctx.Err() != nil
+			cancelLookup = nil
+			mu.Unlock()
+			cancel()
+
+			if superseded {
+				s.streams.cancelled.Add(1)
+				continue
+			}
+
+			response := streamResponse{Seq: request.Seq, Prefix: request.Prefix, Suggestions: suggestions}
+			if err != nil {
+				response.Error = err.Error()
+			} else if len(suggestions) > 0 {
+				s.suggestHits.Add(1)
+			} else {
+				s.suggestMisses.Add(1)
+			}
+			if err := websocket.JSON.Send(ws, response); err != nil {
+				return
+			}
+			s.streams.answered.Add(1)
+		}
+	}()
+
+	for {
+		ws.SetReadDeadline(time.Now().Add(s.streams.idleTimeout))
+		var request streamRequest
+		if err := websocket.JSON.Receive(ws, &request); err != nil {
+			break
+		}
+		request.Prefix = services.NormalizeQuery(request.Prefix)
+		if request.Prefix == "" {
+			continue
+		}
+		if request.MaxResults <= 0 {
+			request.MaxResults = services.DefaultSuggestResults()
+		} else if request.MaxResults > services.MaxSuggestResults {
+			request.MaxResults = services.MaxSuggestResults
+		}
+		s.streams.received.Add(1)
+
+		// Drop a keystroke that hasn't started yet, and stop the one in flight
+		select {
+		case <-latest:
+			s.streams.coalesced.Add(1)
+		default:
+		}
+		mu.Lock()
+		if cancelLookup != nil {
+			cancelLookup()
+		}
+		mu.Unlock()
+
+		select {
+		case <-done:
+			return // The writer stopped after a failed send, so the session is over
+		default:
+			latest <- request
+		}
+	}
+
+	close(latest)
+	<-done
+}
+
+// streamStats reports streaming session activity
+func (s *AutocompleteService) streamStats() map[string]interface{} {
+	return map[string]interface{}{
+		"active":    s.streams.active.Load(),
+		"received":  s.streams.received.Load(),
+		"coalesced": s.streams.coalesced.Load(),
+		"cancelled": s.streams.cancelled.Load(),
+		"answered":  s.streams.answered.Load(),
+	}
+}
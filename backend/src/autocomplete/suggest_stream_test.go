@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+func TestSuggestStreamSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &AutocompleteService{RedisClient: unreachableRedis(t), streams: newSuggestStreams()}
+	router := gin.New()
+	router.GET(suggestStreamPath, s.handleSuggestStream)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+suggestStreamPath, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		request    streamRequest
+		wantAnswer bool
+	}{
+		{streamRequest{Seq: 1, Prefix: ""}, false},
+		{streamRequest{Seq: 2, Prefix: "mak"}, true},
+		{streamRequest{Seq: 3, Prefix: "min", MaxResults: 1000}, true},
+	}
+	for _, tt := range tests {
+		if err := websocket.JSON.Send(ws, tt.request); err != nil {
+			t.Fatal(err)
+		}
+		if !tt.wantAnswer {
+			continue
+		}
+		// Each keystroke is answered before the next is sent, so none is coalesced
+		var response streamResponse
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if err := websocket.JSON.Receive(ws, &response); err != nil {
+			t.Fatalf("seq %d: %v", tt.request.Seq, err)
+		}
+		if response.Seq != tt.request.Seq || response.Prefix != tt.request.Prefix || response.Error == "" {
+			t.Errorf("seq %d: response = %+v, want prefix %q with the Redis error", tt.request.Seq, response, tt.request.Prefix)
+		}
+	}
+	ws.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.streams.active.Load() != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	stats := s.streamStats()
+	if stats["active"] != int64(0) || stats["received"] != int64(2) || stats["answered"] != int64(2) || stats["coalesced"] != int64(0) {
+		t.Errorf("streamStats() = %v, want 2 received and answered, none active", stats)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/ugorji/go/codec"
+
+	"autocomplete/models"
+)
+
+// suggestionCodec packs suggestion metadata as msgpack, with structs encoded as
+// positional arrays so field names aren't repeated in every Redis value
+var suggestionCodec = func() *codec.MsgpackHandle {
+	handle := &codec.MsgpackHandle{}
+	handle.StructToArray = true
+	handle.WriteExt = true
+	return handle
+}()
+
+// encodeSuggestions packs a word's or position's suggestions into one value
+func encodeSuggestions(suggestions []models.WordSuggestion) ([]byte, error) {
+	var packed []byte
+	err := codec.NewEncoderBytes(&packed, suggestionCodec).Encode(suggestions)
+	return packed, err
+}
+
+// decodeSuggestions unpacks a value written by encodeSuggestions. Values written
+// as JSON before the switch to msgpack are still read until their TTL expires.
+func decodeSuggestions(packed []byte) ([]models.WordSuggestion, error) {
+	var suggestions []models.WordSuggestion
+	if len(packed) > 0 && packed[0] == '[' {
+		err := json.Unmarshal(packed, &suggestions)
+		return suggestions, err
+	}
+	err := codec.NewDecoderBytes(packed, suggestionCodec).Decode(&suggestions)
+	return suggestions, err
+}
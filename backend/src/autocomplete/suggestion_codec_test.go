@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+)
+
+func TestSuggestionCodec(t *testing.T) {
+	tests := []struct {
+		name        string
+		suggestions []models.WordSuggestion
+	}{
+		{"one", []models.WordSuggestion{{Text: "makan", Confidence: 0.9, Source: "whisper", Rank: 1, Agreement: 2}}},
+		{"several", []models.WordSuggestion{
+			{Text: "makan", Confidence: 0.9, Source: "whisper", Rank: 1},
+			{Text: "makna", Confidence: 0.4, Source: "wav2vec", Rank: 2, Agreement: 1},
+		}},
+		{"unicode", []models.WordSuggestion{{Text: "kuih-muih 🍰", Confidence: 1}}},
+	}
+	for _, tt := range tests {
+		packed, err := encodeSuggestions(tt.suggestions)
+		if err != nil {
+			t.Fatalf("%s: encodeSuggestions() error: %v", tt.name, err)
+		}
+		legacy, _ := json.Marshal(tt.suggestions)
+		if len(packed) >= len(legacy) {
+			t.Errorf("%s: msgpack is %d bytes, JSON %d", tt.name, len(packed), len(legacy))
+		}
+
+		// Values written before the switch to msgpack decode the same way
+		for _, value := range [][]byte{packed, legacy} {
+			got, err := decodeSuggestions(value)
+			if err != nil || !reflect.DeepEqual(got, tt.suggestions) {
+				t.Errorf("%s: decodeSuggestions(%q) = %v, %v, want %v", tt.name, value, got, err, tt.suggestions)
+			}
+		}
+	}
+}
+
+func TestDecodeSuggestionsRejectsDamagedValues(t *testing.T) {
+	for _, value := range []string{`[{"text":`, "\x92\xa5makan"} {
+		if _, err := decodeSuggestions([]byte(value)); err == nil {
+			t.Errorf("decodeSuggestions(%q) accepted a damaged value", value)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// syncFetchTimeout bounds pulling one delta from a peer over a slow link
+const syncFetchTimeout = 5 * time.Minute
+
+// handleSyncDelta exports the clip indexes changed since the version vector in
+// since ("origin:seq,..."; everything when empty), at most limit clips per delta
+func (s *AutocompleteService) handleSyncDelta(c *gin.Context) {
+	if s.Stateless {
+		c.JSON(http.StatusConflict, gin.H{"error": "stateless mode keeps no in-memory state to sync"})
+		return
+	}
+
+	since, err := parseVersionVector(c.Query("since"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit := 0
+	if limitParam := c.Query("limit"); limitParam != "" {
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, services.ExportDelta(since, limit))
+}
+
+// handleApplySyncDelta applies a delta taken either from the request body or
+// pulled from a peer with ?from=<base url>. Pulling sends this instance's
+// version vector and repeats until the peer has nothing more, fetching at
+// most limit clips per round.
+func (s *AutocompleteService) handleApplySyncDelta(c *gin.Context) {
+	if s.Stateless {
+		c.JSON(http.StatusConflict, gin.H{"error": "stateless mode keeps no in-memory state to sync"})
+		return
+	}
+
+	from := c.Query("from")
+	if from == "" {
+		var delta models.SyncDelta
+		if err := c.ShouldBindJSON(&delta); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		applied, skipped := services.ApplyDelta(&delta)
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "synced",
+			"applied": applied,
+			"skipped": skipped,
+			"vector":  services.SyncVector(),
+		})
+		return
+	}
+
+	limit := c.Query("limit")
+	applied, skipped, rounds := 0, 0, 0
+	for {
+		delta, err := fetchSyncDelta(from, services.SyncVector(), limit)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error":   err.Error(),
+				"applied": applied,
+				"skipped": skipped,
+			})
+			return
+		}
+		roundApplied, roundSkipped := services.ApplyDelta(delta)
+		applied += roundApplied
+		skipped += roundSkipped
+		rounds++
+		if !delta.More {
+			break
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "synced",
+		"from":    from,
+		"rounds":  rounds,
+		"applied": applied,
+		"skipped": skipped,
+		"vector":  services.SyncVector(),
+	})
+}
+
+// fetchSyncDelta downloads the changes the instance at baseURL made since vector
+func fetchSyncDelta(baseURL string, vector map[string]int, limit string) (*models.SyncDelta, error) {
+	client := &http.Client{Timeout: syncFetchTimeout}
+
+	query := url.Values{}
+	query.Set("since", formatVersionVector(vector))
+	if limit != "" {
+		query.Set("limit", limit)
+	}
+	resp, err := client.Get(baseURL + "/admin/sync/delta?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch delta: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("delta source returned status %d", resp.StatusCode)
+	}
+
+	var delta models.SyncDelta
+	if err := json.NewDecoder(resp.Body).Decode(&delta); err != nil {
+		return nil, fmt.Errorf("failed to decode delta: %w", err)
+	}
+	return &delta, nil
+}
+
+// parseVersionVector reads "origin:seq,..." into a version vector
+func parseVersionVector(param string) (map[string]int, error) {
+	vector := make(map[string]int)
+	for _, entry := range strings.Split(param, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		sep := strings.LastIndex(entry, ":")
+		if sep <= 0 {
+			return nil, fmt.Errorf("since entry %q must be origin:seq", entry)
+		}
+		seq, err := strconv.Atoi(entry[sep+1:])
+		if err != nil || seq < 0 {
+			return nil, fmt.Errorf("since entry %q must be origin:seq", entry)
+		}
+		vector[entry[:sep]] = seq
+	}
+	return vector, nil
+}
+
+// formatVersionVector writes a version vector as "origin:seq,...", in origin order
+func formatVersionVector(vector map[string]int) string {
+	entries := make([]string, 0, len(vector))
+	for origin, seq := range vector {
+		entries = append(entries, origin+":"+strconv.Itoa(seq))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestParseVersionVector(t *testing.T) {
+	tests := []struct {
+		param   string
+		want    map[string]int
+		wantErr bool
+	}{
+		{"", map[string]int{}, false},
+		{"edge:3", map[string]int{"edge": 3}, false},
+		{"edge:3, lab:0,", map[string]int{"edge": 3, "lab": 0}, false},
+		{"http://edge:3", map[string]int{"http://edge": 3}, false},
+		{"edge", nil, true},
+		{":3", nil, true},
+		{"edge:x", nil, true},
+		{"edge:-1", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseVersionVector(tt.param)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("parseVersionVector(%q) = %v, %v, want %v", tt.param, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormatVersionVector(t *testing.T) {
+	tests := []struct {
+		vector map[string]int
+		want   string
+	}{
+		{nil, ""},
+		{map[string]int{"edge": 3}, "edge:3"},
+		{map[string]int{"lab": 1, "central": 7, "edge": 3}, "central:7,edge:3,lab:1"},
+	}
+	for _, tt := range tests {
+		got := formatVersionVector(tt.vector)
+		if got != tt.want {
+			t.Errorf("formatVersionVector(%v) = %q, want %q", tt.vector, got, tt.want)
+		}
+		if parsed, err := parseVersionVector(got); err != nil || len(parsed) != len(tt.vector) {
+			t.Errorf("formatVersionVector(%v) = %q does not parse back: %v, %v", tt.vector, got, parsed, err)
+		}
+	}
+}
+
+func TestHandleSyncDelta(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan nasi", ConfidenceScore: 0.9})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin/sync/delta", (&AutocompleteService{}).handleSyncDelta)
+	stateless := gin.New()
+	stateless.GET("/admin/sync/delta", (&AutocompleteService{Stateless: true}).handleSyncDelta)
+
+	tests := []struct {
+		name      string
+		router    *gin.Engine
+		query     string
+		want      int
+		wantClips int
+	}{
+		{"everything", router, "", http.StatusOK, 1},
+		{"up to date", router, "?since=" + formatVersionVector(services.SyncVector()), http.StatusOK, 0},
+		{"limited", router, "?limit=1", http.StatusOK, 1},
+		{"bad since", router, "?since=local", http.StatusBadRequest, 0},
+		{"bad limit", router, "?limit=0", http.StatusBadRequest, 0},
+		{"stateless", stateless, "", http.StatusConflict, 0},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		tt.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/sync/delta"+tt.query, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var delta models.SyncDelta
+		if err := json.Unmarshal(recorder.Body.Bytes(), &delta); err != nil || len(delta.Clips) != tt.wantClips {
+			t.Errorf("%s: delta has %d clips, want %d (%v)", tt.name, len(delta.Clips), tt.wantClips, err)
+		}
+	}
+}
+
+func TestHandleApplySyncDelta(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+
+	// A peer that needs two rounds, then one that fails
+	var sinces []string
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sinces = append(sinces, r.URL.Query().Get("since"))
+		more := len(sinces) == 1
+		json.NewEncoder(w).Encode(models.SyncDelta{Origin: "peer", Vector: map[string]int{"peer": len(sinces)}, Clips: []models.ClipDelta{}, More: more})
+	}))
+	defer peer.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin/sync/delta", (&AutocompleteService{}).handleApplySyncDelta)
+
+	tests := []struct {
+		name       string
+		query      string
+		body       string
+		want       int
+		wantRounds float64
+	}{
+		{"body", "", `{"origin": "peer", "vector": {"peer": 1}, "clips": []}`, http.StatusOK, 0},
+		{"malformed body", "", `{"clips": 1}`, http.StatusBadRequest, 0},
+		{"pulled from a peer", "?from=" + peer.URL, "", http.StatusOK, 2},
+		{"failing peer", "?from=" + failing.URL, "", http.StatusBadGateway, 0},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/admin/sync/delta"+tt.query, strings.NewReader(tt.body)))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+			continue
+		}
+		var body map[string]interface{}
+		json.Unmarshal(recorder.Body.Bytes(), &body)
+		if rounds, _ := body["rounds"].(float64); rounds != tt.wantRounds {
+			t.Errorf("%s: %v rounds, want %v", tt.name, body["rounds"], tt.wantRounds)
+		}
+	}
+
+	// The second round asks for what the first one delivered
+	if len(sinces) != 2 || !strings.Contains(sinces[1], "peer:1") {
+		t.Errorf("peer was asked since %q", sinces)
+	}
+	if vector := services.SyncVector(); vector["peer"] != 2 {
+		t.Errorf("SyncVector() = %v, want peer:2", vector)
+	}
+}
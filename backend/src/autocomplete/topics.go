@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Per-topic vocabulary: word counts of the baselines of clips tagged with each
+// topic, and of all tagged clips together
+const (
+	topicVocabKeyPrefix = redisKeyPrefix + "topic:vocab:"
+	topicAllVocabKey    = redisKeyPrefix + "topic:all"
+)
+
+// topicFetch is the minimum number of prefix matches read before topic and
+// accent weighting, so on-profile words can climb from below the top few
+const topicFetch = 20
+
+// learnTopicVocabulary counts a tagged clip's baseline words under each of its topics
+func (s *AutocompleteService) learnTopicVocabulary(ctx context.Context, data *models.AutocompleteData) error {
+	if len(data.Topics) == 0 {
+		return nil
+	}
+	words := services.TranscriptWords(data.FinalTranscription)
+	if len(words) == 0 {
+		return nil
+	}
+
+	pipe := s.RedisClient.Pipeline()
+	for _, word := range words {
+		word = strings.ToLower(word)
+		pipe.ZIncrBy(ctx, topicAllVocabKey, 1, word)
+		for _, topic := range data.Topics {
+			pipe.ZIncrBy(ctx, topicVocabKeyPrefix+topic, 1, word)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// suggestProfile is what a clip tells prefix ranking about who is speaking
+// and about what: its topics and accent, and the active speaker with the
+// clip's per-speaker statistics and the word before the one being typed
+type suggestProfile struct {
+	topics   []string
+	accent   string
+	speaker  string
+	speakers map[string]*services.SpeakerStats
+	previous string
+}
+
+func (p suggestProfile) empty() bool {
+	return len(p.topics) == 0 && p.accent == "" && p.speaker == ""
+}
+
+// clipProfile reads the profile of a stored clip. The active speaker is the
+// speaker parameter, else the diarized speaker of the word position being
+// edited; the previous word only conditions suggestions when the same speaker
+// said it. The profile is empty when the clip isn't stored.
+func (s *AutocompleteService) clipProfile(ctx context.Context, audioID, speaker, positionParam string) suggestProfile {
+	if audioID == "" {
+		return suggestProfile{}
+	}
+	data, err := s.clipTranscripts(ctx, audioID)
+	if err != nil {
+		return suggestProfile{}
+	}
+	profile := suggestProfile{topics: data.Topics, accent: data.Accent}
+	if len(data.SpeakerSegments) == 0 {
+		return profile
+	}
+
+	words := services.TranscriptWords(data.FinalTranscription)
+	position, err := strconv.Atoi(positionParam)
+	if err != nil {
+		position = -1
+	}
+	if speaker = strings.TrimSpace(speaker); speaker == "" && position >= 0 {
+		speaker = services.SpeakerAt(data.SpeakerSegments, position)
+	}
+	profile.speakers = services.SpeakerStatistics(words, data.SpeakerSegments)
+	if profile.speakers[speaker] == nil {
+		return profile
+	}
+	profile.speaker = speaker
+	if position > 0 && position <= len(words) && services.SpeakerAt(data.SpeakerSegments, position-1) == speaker {
+		profile.previous = words[position-1]
+	}
+	return profile
+}
+
+// getProfileSuggestions ranks prefix matches with a boost for words typical of
+// the clip's topics (the best services.TopicBoost over them), of its accent
+// (the same boost computed over accent vocabularies) and of the active speaker
+// (services.SpeakerBoost). Each suggestion reports the boosts it got as
+// "topic_boost", "accent_boost" and "speaker_boost".
+func (s *AutocompleteService) getProfileSuggestions(ctx context.Context, tenant, prefix string, profile suggestProfile, maxResults int) ([]map[string]interface{}, error) {
+	topics, accent := profile.topics, profile.accent
+	fetch := suggestFetchCount(tenant, maxResults)
+	if fetch < topicFetch {
+		fetch = topicFetch
+	}
+	results, err := s.rankedPrefix(ctx, prefix, fetch)
+	if err != nil || len(results) == 0 {
+		return formatSuggestions(tenant, results, maxResults), err
+	}
+
+	pipe := s.readClient().Pipeline()
+	allTopics := make([]*redis.FloatCmd, len(results))
+	inTopic := make([][]*redis.FloatCmd, len(results))
+	allAccents := make([]*redis.FloatCmd, len(results))
+	inAccent := make([]*redis.FloatCmd, len(results))
+	for i, result := range results {
+		word := strings.ToLower(result.Member.(string))
+		if len(topics) > 0 {
+			allTopics[i] = pipe.ZScore(ctx, topicAllVocabKey, word)
+			for _, topic := range topics {
+				inTopic[i] = append(inTopic[i], pipe.ZScore(ctx, topicVocabKeyPrefix+topic, word))
+			}
+		}
+		if accent != "" {
+			allAccents[i] = pipe.ZScore(ctx, accentAllVocabKey, word)
+			inAccent[i] = pipe.ZScore(ctx, accentVocabKeyPrefix+accent, word)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	topicBoosts := make(map[string]float64, len(results))
+	accentBoosts := make(map[string]float64, len(results))
+	speakerBoosts := make(map[string]float64, len(results))
+	boosted := make([]redis.Z, len(results))
+	for i, result := range results {
+		word := result.Member.(string)
+		for _, count := range inTopic[i] {
+			if boost := services.TopicBoost(count.Val(), allTopics[i].Val()); boost > topicBoosts[word] {
+				topicBoosts[word] = boost
+			}
+		}
+		if accent != "" {
+			accentBoosts[word] = services.TopicBoost(inAccent[i].Val(), allAccents[i].Val())
+		}
+		if profile.speaker != "" {
+			speakerBoosts[word] = services.SpeakerBoost(profile.speakers, profile.speaker, word, profile.previous)
+		}
+		boosted[i] = redis.Z{Member: word, Score: result.Score + topicBoosts[word] + accentBoosts[word] + speakerBoosts[word]}
+	}
+	sort.SliceStable(boosted, func(i, j int) bool {
+		return boosted[i].Score > boosted[j].Score
+	})
+
+	suggestions := formatSuggestions(tenant, boosted, maxResults)
+	for _, suggestion := range suggestions {
+		text := suggestion["text"].(string)
+		if len(topics) > 0 {
+			suggestion["topic_boost"] = topicBoosts[text]
+		}
+		if accent != "" {
+			suggestion["accent_boost"] = accentBoosts[text]
+		}
+		if profile.speaker != "" {
+			suggestion["speaker_boost"] = speakerBoosts[text]
+		}
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestClipProfileTags(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("food", &models.AutocompleteData{FinalTranscription: "saya makan", Topics: []string{"food", "travel"}})
+	services.BuildAndCacheData("kelantan", &models.AutocompleteData{FinalTranscription: "saya makan", Accent: "kelantan"})
+	services.BuildAndCacheData("untagged", &models.AutocompleteData{FinalTranscription: "saya makan"})
+
+	s := &AutocompleteService{}
+	tests := []struct {
+		audioID    string
+		want       []string
+		wantAccent string
+		wantEmpty  bool
+	}{
+		{"food", []string{"food", "travel"}, "", false},
+		{"kelantan", nil, "kelantan", false},
+		{"untagged", nil, "", true},
+		{"other", nil, "", true},
+		{"", nil, "", true},
+	}
+	for _, tt := range tests {
+		profile := s.clipProfile(context.Background(), tt.audioID, "", "")
+		if !reflect.DeepEqual(profile.topics, tt.want) || profile.accent != tt.wantAccent || profile.empty() != tt.wantEmpty {
+			t.Errorf("clipProfile(%q) = topics %v accent %q (empty %v), want %v %q (empty %v)", tt.audioID, profile.topics, profile.accent, profile.empty(), tt.want, tt.wantAccent, tt.wantEmpty)
+		}
+	}
+}
+
+func TestLearnTopicVocabularyWithoutRedis(t *testing.T) {
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	tests := []struct {
+		name    string
+		data    *models.AutocompleteData
+		wantErr bool
+	}{
+		{"untagged", &models.AutocompleteData{FinalTranscription: "saya makan"}, false},
+		{"no words", &models.AutocompleteData{Topics: []string{"food"}}, false},
+		{"tagged", &models.AutocompleteData{FinalTranscription: "saya makan", Topics: []string{"food"}}, true},
+	}
+	for _, tt := range tests {
+		if err := s.learnTopicVocabulary(context.Background(), tt.data); (err != nil) != tt.wantErr {
+			t.Errorf("%s: learnTopicVocabulary() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestClipProfileSpeaker(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{
+		FinalTranscription: "jom makan nasi ok",
+		SpeakerSegments: []models.SpeakerSegment{
+			{Speaker: "A", StartWord: 0, EndWord: 1},
+			{Speaker: "B", StartWord: 2, EndWord: 3},
+		},
+	})
+
+	s := &AutocompleteService{}
+	tests := []struct {
+		speaker, position string
+		wantSpeaker       string
+		wantPrevious      string
+	}{
+		{"", "1", "A", "jom"},
+		{"", "2", "B", ""}, // The previous word is A's
+		{"", "3", "B", "nasi"},
+		{"", "0", "A", ""},
+		{" B ", "", "B", ""},
+		{"A", "3", "A", ""},
+		{"C", "1", "", ""},
+		{"", "", "", ""},
+		{"", "9", "", ""},
+	}
+	for _, tt := range tests {
+		profile := s.clipProfile(context.Background(), "clip", tt.speaker, tt.position)
+		if profile.speaker != tt.wantSpeaker || profile.previous != tt.wantPrevious {
+			t.Errorf("clipProfile(speaker %q, position %q) = speaker %q previous %q, want %q %q", tt.speaker, tt.position, profile.speaker, profile.previous, tt.wantSpeaker, tt.wantPrevious)
+		}
+		if len(profile.speakers) != 2 {
+			t.Errorf("clipProfile(speaker %q, position %q) has statistics of %d speakers, want 2", tt.speaker, tt.position, len(profile.speakers))
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// topKKey is the hash of materialized top-k results, one packed field per prefix
+const topKKey = "autocomplete:topk"
+
+// defaultTopK covers the largest max_results the frontend asks for
+const defaultTopK = 20
+
+// topKSetting reads TOPK_MATERIALIZE; 0 disables materialization
+func topKSetting() int {
+	value, err := strconv.Atoi(os.Getenv("TOPK_MATERIALIZE"))
+	if err != nil || value < 0 {
+		return defaultTopK
+	}
+	return value
+}
+
+// packTopK encodes ranked members as "word\tscore" lines. Words never contain
+// whitespace since they come from splitting transcriptions on it.
+func packTopK(results []redis.Z) string {
+	var packed strings.Builder
+	for i, result := range results {
+		if i > 0 {
+			packed.WriteByte('\n')
+		}
+		packed.WriteString(result.Member.(string))
+		packed.WriteByte('\t')
+		packed.WriteString(strconv.FormatFloat(result.Score, 'g', -1, 64))
+	}
+	return packed.String()
+}
+
+// unpackTopK decodes a packed top-k field
+func unpackTopK(packed string) []redis.Z {
+	if packed == "" {
+		return []redis.Z{}
+	}
+	lines := strings.Split(packed, "\n")
+	results := make([]redis.Z, 0, len(lines))
+	for _, line := range lines {
+		word, score, found := strings.Cut(line, "\t")
+		if !found {
+			continue
+		}
+		value, err := strconv.ParseFloat(score, 64)
+		if err != nil {
+			continue
+		}
+		results = append(results, redis.Z{Member: word, Score: value})
+	}
+	return results
+}
+
+// materializeTopK recomputes the packed top-k of each prefix from its sorted
+// set into the read model, so suggest can answer with one HGET instead of a
+// ZREVRANGE per keystroke
+func (s *AutocompleteService) materializeTopK(ctx context.Context, prefixes []string) error {
+	if s.TopK <= 0 || len(prefixes) == 0 {
+		return nil
+	}
+
+	pipe := s.RedisClient.Pipeline()
+	ranges := make([]*redis.ZSliceCmd, len(prefixes))
+	for i, prefix := range prefixes {
+		ranges[i] = pipe.ZRevRangeWithScores(ctx, prefixKey(prefix), 0, int64(s.TopK-1))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	fields := make(map[string]interface{}, len(prefixes))
+	for i, prefix := range prefixes {
+		fields[prefix] = packTopK(ranges[i].Val())
+	}
+
+	pipe = s.readModelClient().Pipeline()
+	pipe.HSet(ctx, topKKey, fields)
+	pipe.Expire(ctx, topKKey, time.Hour) // Matches the prefix keys it mirrors
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// rankedPrefix returns the top count members for a prefix, from the
+// materialized hash when it holds enough results and the sorted set otherwise,
+// e.g. for a new prefix the projector hasn't reached yet
+func (s *AutocompleteService) rankedPrefix(ctx context.Context, prefix string, count int) ([]redis.Z, error) {
+	client := s.readClient()
+
+	if count <= s.TopK {
+		readModel := client
+		if s.ReadModel != nil {
+			readModel = s.ReadModel
+		}
+		packed, err := readModel.HGet(ctx, topKKey, prefix).Result()
+		if err == nil {
+			results := unpackTopK(packed)
+			if len(results) > count {
+				results = results[:count]
+			}
+			return results, nil
+		}
+		if err != redis.Nil {
+			return nil, err
+		}
+	}
+
+	return client.ZRevRangeWithScores(ctx, prefixKey(prefix), 0, int64(count-1)).Result()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestPackTopK(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []redis.Z
+		packed  string
+	}{
+		{"empty", []redis.Z{}, ""},
+		{"one", []redis.Z{{Member: "makan", Score: 3}}, "makan\t3"},
+		{"ranked", []redis.Z{{Member: "makan", Score: 3.5}, {Member: "makna", Score: 1e-7}}, "makan\t3.5\nmakna\t1e-07"},
+	}
+	for _, tt := range tests {
+		if got := packTopK(tt.results); got != tt.packed {
+			t.Errorf("%s: packTopK() = %q, want %q", tt.name, got, tt.packed)
+		}
+		if got := unpackTopK(tt.packed); !reflect.DeepEqual(got, tt.results) {
+			t.Errorf("%s: unpackTopK(%q) = %v, want %v", tt.name, tt.packed, got, tt.results)
+		}
+	}
+}
+
+func TestUnpackTopKSkipsDamagedLines(t *testing.T) {
+	got := unpackTopK("makan\t3\nno score\nmakna\tlots\nminum\t1")
+	want := []redis.Z{{Member: "makan", Score: 3}, {Member: "minum", Score: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unpackTopK() = %v, want %v", got, want)
+	}
+}
+
+func TestTopKSetting(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", defaultTopK},
+		{"50", 50},
+		{"0", 0},
+		{"-1", defaultTopK},
+		{"many", defaultTopK},
+	}
+	for _, tt := range tests {
+		t.Setenv("TOPK_MATERIALIZE", tt.value)
+		if got := topKSetting(); got != tt.want {
+			t.Errorf("topKSetting(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// embeddingIndexKeyPrefix holds the flat vector index of each embedding model
+// version: one packed float32 embedding per indexed word, from the service at
+// EMBEDDING_URL
+const embeddingIndexKeyPrefix = redisKeyPrefix + "embedding:"
+
+// embeddingIndexKey is the vector index of the current model version
+func embeddingIndexKey() string {
+	return embeddingIndexKeyPrefix + embeddingModel
+}
+
+// Vector index limits: words embedded per request to the embedding service,
+// and how many stored vectors one /suggest/semantic lookup compares
+const (
+	embedBatchSize  = 64
+	maxSemanticScan = 100000
+)
+
+// errNoEmbeddings is returned by vector index operations when no embedding
+// service is configured
+var errNoEmbeddings = fmt.Errorf("semantic search requires EMBEDDING_URL")
+
+// packVector encodes an embedding as little-endian float32s
+func packVector(vector []float64) string {
+	packed := make([]byte, 4*len(vector))
+	for i, value := range vector {
+		binary.LittleEndian.PutUint32(packed[4*i:], math.Float32bits(float32(value)))
+	}
+	return string(packed)
+}
+
+// unpackVector decodes a packVector value
+func unpackVector(packed string) []float64 {
+	vector := make([]float64, len(packed)/4)
+	for i := range vector {
+		vector[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32([]byte(packed[4*i : 4*i+4]))))
+	}
+	return vector
+}
+
+// ingestVocabulary lists the distinct words a payload adds to the global index
+func ingestVocabulary(data *models.AutocompleteData) []string {
+	transcriptions := []string{data.FinalTranscription}
+	for _, transcription := range data.ASRAlternatives {
+		transcriptions = append(transcriptions, transcription)
+	}
+
+	seen := make(map[string]bool)
+	var words []string
+	add := func(word string) {
+		if _, keep := services.FilterIngestWord(word, 1); keep && word != "" && !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	for _, transcription := range transcriptions {
+		for _, word := range splitIntoWords(transcription) {
+			add(word)
+		}
+	}
+	for _, particle := range data.DetectedParticles {
+		add(particle)
+	}
+	return words
+}
+
+// embedVocabulary stores the embeddings of the words the index doesn't have
+// yet, returning how many it added
+func (s *AutocompleteService) embedVocabulary(ctx context.Context, words []string) (int, error) {
+	if embeddingURL == "" {
+		return 0, errNoEmbeddings
+	}
+	if len(words) == 0 {
+		return 0, nil
+	}
+
+	stored, err := s.RedisClient.HMGet(ctx, embeddingIndexKey(), words...).Result()
+	if err != nil {
+		return 0, err
+	}
+	var missing []string
+	for i, value := range stored {
+		if value == nil {
+			missing = append(missing, words[i])
+		}
+	}
+
+	added := 0
+	for start := 0; start < len(missing); start += embedBatchSize {
+		batch := missing[start:min(start+embedBatchSize, len(missing))]
+		vectors, err := embedTexts(ctx, batch)
+		if err != nil {
+			return added, err
+		}
+		fields := make(map[string]interface{}, len(batch))
+		for i, word := range batch {
+			fields[word] = packVector(vectors[i])
+		}
+		if err := s.RedisClient.HSet(ctx, embeddingIndexKey(), fields).Err(); err != nil {
+			return added, err
+		}
+		added += len(batch)
+	}
+	return added, nil
+}
+
+// embedIngestedWords adds a payload's new words to the vector index in the
+// background, so ingestion doesn't wait on the embedding service
+func (s *AutocompleteService) embedIngestedWords(data *models.AutocompleteData) {
+	if embeddingURL == "" {
+		return
+	}
+	words := ingestVocabulary(data)
+	go func() {
+		if _, err := s.embedVocabulary(context.Background(), words); err != nil {
+			log.Printf("Error embedding ingested words: %v", err)
+		}
+	}()
+}
+
+// handleEmbeddingBackfill embeds every word of the global frequency set that
+// the vector index is missing, for vocabulary ingested before EMBEDDING_URL
+// was set
+func (s *AutocompleteService) handleEmbeddingBackfill(c *gin.Context) {
+	if embeddingURL == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errNoEmbeddings.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	added := 0
+	var cursor uint64
+	for {
+		entries, next, err := s.RedisClient.ZScan(ctx, globalFrequencyKey, cursor, "", 1000).Result()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		words := make([]string, 0, len(entries)/2)
+		for i := 0; i < len(entries); i += 2 {
+			words = append(words, entries[i])
+		}
+		n, err := s.embedVocabulary(ctx, words)
+		added += n
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "embedded": added})
+			return
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	total, err := s.RedisClient.HLen(ctx, embeddingIndexKey()).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"embedded": added, "total": total})
+}
+
+// handleSemanticSuggest lists indexed words whose meaning is close to the
+// query term, for when the user knows what the speaker meant but not the word
+// they used. Words are ranked by the cosine similarity of their embeddings.
+func (s *AutocompleteService) handleSemanticSuggest(c *gin.Context) {
+	query := services.NormalizeQuery(c.Query("query"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter required"})
+		return
+	}
+	maxResults, err := services.ParseMaxResults(c.Query("max_results"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if embeddingURL == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": errNoEmbeddings.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	vectors, err := s.cachedEmbeddings(ctx, []string{query})
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	neighbours, truncated, err := s.nearestWords(ctx, vectors[0], query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	neighbours = services.ApplySuggestFilters(requestTenant(c), neighbours)
+	if len(neighbours) > maxResults {
+		neighbours = neighbours[:maxResults]
+	}
+	suggestions := make([]gin.H, len(neighbours))
+	for i, neighbour := range neighbours {
+		suggestions[i] = gin.H{"text": neighbour.Text, "similarity": neighbour.Confidence}
+	}
+	if len(suggestions) > 0 {
+		s.suggestHits.Add(1)
+	} else {
+		s.suggestMisses.Add(1)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"query":       query,
+		"suggestions": suggestions,
+		"truncated":   truncated,
+	})
+}
+
+// nearestWords compares the query vector with every stored embedding, up to
+// maxSemanticScan of them, and returns the words most similar first with the
+// similarity as their confidence. The query word itself is left out.
+func (s *AutocompleteService) nearestWords(ctx context.Context, query []float64, queryWord string) ([]models.WordSuggestion, bool, error) {
+	client := s.readClient()
+
+	var neighbours []models.WordSuggestion
+	var cursor uint64
+	scanned := 0
+	for {
+		entries, next, err := client.HScan(ctx, embeddingIndexKey(), cursor, "", 1000).Result()
+		if err != nil && err != redis.Nil {
+			return nil, false, err
+		}
+		neighbours = append(neighbours, scoreNeighbours(query, queryWord, entries)...)
+		scanned += len(entries) / 2
+
+		cursor = next
+		if cursor == 0 || scanned >= maxSemanticScan {
+			break
+		}
+	}
+
+	sortBySimilarity(neighbours)
+	return neighbours, cursor != 0, nil
+}
+
+// scoreNeighbours scores the words of HSCAN entries, which pair each word
+// with its vector, by their similarity to the query vector
+func scoreNeighbours(query []float64, queryWord string, entries []string) []models.WordSuggestion {
+	neighbours := make([]models.WordSuggestion, 0, len(entries)/2)
+	for i := 0; i+1 < len(entries); i += 2 {
+		if strings.EqualFold(entries[i], queryWord) {
+			continue
+		}
+		neighbours = append(neighbours, models.WordSuggestion{
+			Text:       entries[i],
+			Confidence: cosineSimilarity(query, unpackVector(entries[i+1])),
+		})
+	}
+	return neighbours
+}
+
+// sortBySimilarity puts the most similar words first, ties in word order
+func sortBySimilarity(neighbours []models.WordSuggestion) {
+	sort.Slice(neighbours, func(i, j int) bool {
+		if neighbours[i].Confidence != neighbours[j].Confidence {
+			return neighbours[i].Confidence > neighbours[j].Confidence
+		}
+		return neighbours[i].Text < neighbours[j].Text
+	})
+}
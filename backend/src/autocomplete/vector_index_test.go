@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestPackVector(t *testing.T) {
+	tests := [][]float64{
+		{},
+		{0},
+		{1, -1, 0.5, -0.25},
+		{0.1, 0.2, 0.3},
+		{math.MaxFloat32, -math.SmallestNonzeroFloat32},
+	}
+	for _, vector := range tests {
+		packed := packVector(vector)
+		if len(packed) != 4*len(vector) {
+			t.Errorf("packVector(%v) is %d bytes, want %d", vector, len(packed), 4*len(vector))
+		}
+		got := unpackVector(packed)
+		if len(got) != len(vector) {
+			t.Errorf("unpackVector(packVector(%v)) = %v", vector, got)
+			continue
+		}
+		for i := range vector {
+			// Vectors are stored as float32
+			if float32(got[i]) != float32(vector[i]) {
+				t.Errorf("unpackVector(packVector(%v))[%d] = %v", vector, i, got[i])
+			}
+		}
+	}
+
+	// A truncated value drops the partial float rather than panicking
+	if got := unpackVector(packVector([]float64{1, 2})[:6]); !reflect.DeepEqual(got, []float64{1}) {
+		t.Errorf("unpackVector of 6 bytes = %v, want [1]", got)
+	}
+}
+
+func TestIngestVocabulary(t *testing.T) {
+	defer services.ConfigureProfanityFilter(services.ProfanityOff, "")
+
+	tests := []struct {
+		name      string
+		profanity string
+		data      *models.AutocompleteData
+		want      []string
+	}{
+		{
+			name: "transcript words once",
+			data: &models.AutocompleteData{FinalTranscription: "saya makan saya makan"},
+			want: []string{"makan", "saya"},
+		},
+		{
+			name: "alternatives and particles",
+			data: &models.AutocompleteData{
+				FinalTranscription: "saya makan",
+				ASRAlternatives:    map[string]string{"whisper": "saya makna", "mms": "sayang makan"},
+				DetectedParticles:  []string{"lah", "saya"},
+			},
+			want: []string{"lah", "makan", "makna", "saya", "sayang"},
+		},
+		{
+			name: "empty payload",
+			data: &models.AutocompleteData{},
+			want: nil,
+		},
+		{
+			name:      "dropped profanity",
+			profanity: services.ProfanityDrop,
+			data:      &models.AutocompleteData{FinalTranscription: "fuck makan"},
+			want:      []string{"makan"},
+		},
+		{
+			name:      "masked profanity is still embedded",
+			profanity: services.ProfanityMask,
+			data:      &models.AutocompleteData{FinalTranscription: "fuck makan"},
+			want:      []string{"fuck", "makan"},
+		},
+	}
+	for _, tt := range tests {
+		if err := services.ConfigureProfanityFilter(tt.profanity, ""); err != nil {
+			t.Fatal(err)
+		}
+		got := ingestVocabulary(tt.data)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ingestVocabulary() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScoreNeighbours(t *testing.T) {
+	entries := []string{
+		"makan", packVector([]float64{1, 0}),
+		"santap", packVector([]float64{0.9, 0.1}),
+		"minum", packVector([]float64{0, 1}),
+		"tidur", packVector([]float64{-1, 0}),
+		"kosong", packVector([]float64{0, 0}),
+		"rosak", packVector([]float64{1, 0, 0}),
+	}
+	tests := []struct {
+		name      string
+		query     []float64
+		queryWord string
+		entries   []string
+		want      []string
+	}{
+		{"query word left out", []float64{1, 0}, "Makan", entries, []string{"santap", "kosong", "minum", "rosak", "tidur"}},
+		{"other query", []float64{0, 1}, "air", entries, []string{"minum", "santap", "kosong", "makan", "rosak", "tidur"}},
+		{"odd entry dropped", []float64{1, 0}, "", entries[:3], []string{"makan"}},
+		{"no entries", []float64{1, 0}, "", nil, []string{}},
+	}
+	for _, tt := range tests {
+		neighbours := scoreNeighbours(tt.query, tt.queryWord, tt.entries)
+		sortBySimilarity(neighbours)
+		got := make([]string, len(neighbours))
+		for i, neighbour := range neighbours {
+			got[i] = neighbour.Text
+			if neighbour.Confidence < -1-1e-9 || neighbour.Confidence > 1+1e-9 {
+				t.Errorf("%s: %s similarity %v out of [-1, 1]", tt.name, neighbour.Text, neighbour.Confidence)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: neighbours %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVectorIndexWithoutEmbeddings(t *testing.T) {
+	defer func(url string) { embeddingURL = url }(embeddingURL)
+	embeddingURL = ""
+
+	service := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	if _, err := service.embedVocabulary(context.Background(), []string{"makan"}); err != errNoEmbeddings {
+		t.Errorf("embedVocabulary() error = %v, want %v", err, errNoEmbeddings)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/suggest/semantic", service.handleSemanticSuggest)
+	router.POST("/admin/embeddings/backfill", service.handleEmbeddingBackfill)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{"no query", http.MethodGet, "/suggest/semantic", http.StatusBadRequest},
+		{"bad max_results", http.MethodGet, "/suggest/semantic?query=makan&max_results=x", http.StatusBadRequest},
+		{"semantic lookup", http.MethodGet, "/suggest/semantic?query=makan", http.StatusServiceUnavailable},
+		{"backfill", http.MethodPost, "/admin/embeddings/backfill", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.target, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
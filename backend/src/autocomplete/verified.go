@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// The verified index is built from finalized transcripts and never expires:
+// word frequencies, bigram followers, heard → corrected confusion pairs, and
+// the clips already counted. Finalized clips with an accent also count their
+// confusion pairs under that accent (see accentConfusionKey).
+const (
+	verifiedFrequencyKey       = redisKeyPrefix + "verified:frequency"
+	verifiedBigramKeyPrefix    = redisKeyPrefix + "verified:bigram:"
+	verifiedConfusionKeyPrefix = redisKeyPrefix + "verified:confusion:"
+	verifiedClipsKey           = redisKeyPrefix + "verified:clips"
+)
+
+// verifiedFollowersLimit bounds the followers and corrections read per word
+const verifiedFollowersLimit = 20
+
+// handleFinalize feeds a clip's finalized transcript into the verified index.
+// The transcript defaults to the clip's stored baseline with its corrections
+// applied; one sent in the request is normalized and redacted like ingested
+// text. Confusion pairs come from the clip's correction log, plus positional
+// differences between the stored baseline and a transcript sent with the same
+// word count. A clip is only counted once.
+func (s *AutocompleteService) handleFinalize(c *gin.Context) {
+	var request struct {
+		AudioID    string `json:"audio_id"`
+		Transcript string `json:"transcript"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.Background()
+	audioID := services.NormalizeAudioID(request.AudioID)
+
+	data, err := s.clipTranscripts(ctx, audioID)
+	if err == redis.Nil || err != nil && !s.Stateless {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no transcripts stored for clip " + audioID})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	baseline := services.TranscriptWords(data.FinalTranscription)
+	final := baseline
+	if request.Transcript != "" {
+		submitted, _ := services.RedactAutocompleteData(services.NormalizeAutocompleteData(&models.AutocompleteData{
+			FinalTranscription: request.Transcript,
+		}))
+		final = services.TranscriptWords(submitted.FinalTranscription)
+	}
+	if len(final) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "transcript has no words"})
+		return
+	}
+
+	added, err := s.RedisClient.SAdd(ctx, verifiedClipsKey, audioID).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if added == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "clip " + audioID + " is already finalized"})
+		return
+	}
+
+	confusions, err := s.storeVerified(ctx, audioID, data, baseline, final)
+	if err != nil {
+		// Let the clip be finalized again once Redis recovers
+		s.RedisClient.SRem(ctx, verifiedClipsKey, audioID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.recordEvent(ctx, &loggedEvent{Type: eventFinalize, AudioID: audioID, Transcript: strings.Join(final, " ")})
+
+	s.emitWebhook(webhookFeedbackFinalized, gin.H{"audio_id": audioID, "words": len(final), "confusions": confusions})
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "finalized",
+		"audio_id":   audioID,
+		"words":      len(final),
+		"confusions": confusions,
+	})
+}
+
+// storeVerified counts a finalized transcript's words, bigrams and confusion
+// pairs into the verified index and queues it for the orchestrator, returning
+// the number of confusion pairs
+func (s *AutocompleteService) storeVerified(ctx context.Context, audioID string, data *models.AutocompleteData, baseline, final []string) (int, error) {
+	confusions, err := s.correctionPairs(ctx, audioID)
+	if err != nil {
+		return 0, err
+	}
+	confusions = append(confusions, services.VerifiedConfusions(baseline, final)...)
+
+	pipe := s.RedisClient.TxPipeline()
+	for pos, word := range final {
+		word = strings.ToLower(word)
+		pipe.ZIncrBy(ctx, verifiedFrequencyKey, 1, word)
+		if pos+1 < len(final) {
+			pipe.ZIncrBy(ctx, verifiedBigramKeyPrefix+word, 1, strings.ToLower(final[pos+1]))
+		}
+	}
+	for _, pair := range confusions {
+		pipe.ZIncrBy(ctx, verifiedConfusionKeyPrefix+pair[0], 1, pair[1])
+		if data.Accent != "" {
+			pipe.ZIncrBy(ctx, accentConfusionKey(data.Accent, pair[0]), 1, pair[1])
+		}
+	}
+	verified := gin.H{"audio_id": audioID, "transcript": strings.Join(final, " "), "confusions": confusions}
+	if err := s.addOutboxEntry(ctx, pipe, outboxVerified, verified); err != nil {
+		return 0, err
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return len(confusions), nil
+}
+
+// correctionPairs returns the heard → corrected pairs of a clip's correction log, lower-cased
+func (s *AutocompleteService) correctionPairs(ctx context.Context, audioID string) ([][2]string, error) {
+	entries, err := s.clipClient(audioID).LRange(ctx, clipCorrectionsKey(audioID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs [][2]string
+	for _, entry := range entries {
+		var record correctionRecord
+		if err := json.Unmarshal([]byte(entry), &record); err != nil {
+			continue
+		}
+		pairs = append(pairs, [2]string{strings.ToLower(record.OldWord), strings.ToLower(record.NewWord)})
+	}
+	return pairs, nil
+}
+
+// verifiedPrior reads what the verified index knows about a clip's words: the
+// frequency of every word any model heard, the followers of each baseline word
+// and the corrections each baseline word received, overall and in clips of the
+// clip's accent. It is nil when the verified index is empty.
+func (s *AutocompleteService) verifiedPrior(ctx context.Context, data *models.AutocompleteData) (*services.VerifiedPrior, error) {
+	client := s.readClient()
+	if n, err := client.ZCard(ctx, verifiedFrequencyKey).Result(); err != nil || n == 0 {
+		return nil, err
+	}
+
+	words, baseline := services.ClipVocabulary(data)
+	pipe := client.Pipeline()
+	frequencies := make([]*redis.FloatCmd, len(words))
+	for i, word := range words {
+		frequencies[i] = pipe.ZScore(ctx, verifiedFrequencyKey, word)
+	}
+	followers := make([]*redis.ZSliceCmd, len(baseline))
+	corrections := make([]*redis.ZSliceCmd, len(baseline))
+	accentCorrections := make([]*redis.ZSliceCmd, len(baseline))
+	for i, word := range baseline {
+		followers[i] = pipe.ZRevRangeWithScores(ctx, verifiedBigramKeyPrefix+word, 0, verifiedFollowersLimit-1)
+		corrections[i] = pipe.ZRevRangeWithScores(ctx, verifiedConfusionKeyPrefix+word, 0, verifiedFollowersLimit-1)
+		if data.Accent != "" {
+			accentCorrections[i] = pipe.ZRevRangeWithScores(ctx, accentConfusionKey(data.Accent, word), 0, verifiedFollowersLimit-1)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	prior := &services.VerifiedPrior{
+		Frequency:  make(map[string]float64),
+		Bigrams:    make(map[string]map[string]float64),
+		Confusions: make(map[string]map[string]float64),
+
+		AccentConfusions: make(map[string]map[string]float64),
+	}
+	for i, word := range words {
+		if frequency := frequencies[i].Val(); frequency > 0 {
+			prior.Frequency[word] = frequency
+		}
+	}
+	for i, word := range baseline {
+		prior.Bigrams[word] = scoreMap(followers[i].Val())
+		prior.Confusions[word] = scoreMap(corrections[i].Val())
+		if data.Accent != "" {
+			prior.AccentConfusions[word] = scoreMap(accentCorrections[i].Val())
+		}
+	}
+	return prior, nil
+}
+
+// scoreMap converts ranked members into a member → score map
+func scoreMap(results []redis.Z) map[string]float64 {
+	scores := make(map[string]float64, len(results))
+	for _, result := range results {
+		scores[result.Member.(string)] = result.Score
+	}
+	return scores
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleFinalizeWithoutRedis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan"})
+
+	s := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	router := gin.New()
+	router.POST("/finalize", s.handleFinalize)
+
+	tests := []struct {
+		body       string
+		wantStatus int
+	}{
+		{`not json`, http.StatusBadRequest},
+		{`{"audio_id": "other"}`, http.StatusNotFound},
+		{`{"audio_id": "clip", "transcript": "  "}`, http.StatusBadRequest},
+		{`{"audio_id": "clip"}`, http.StatusInternalServerError},
+		{`{"audio_id": "clip", "transcript": "saya makam"}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/finalize", strings.NewReader(tt.body)))
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.body, w.Code, tt.wantStatus, w.Body)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// Page sizes of /clips/:audio_id/vocabulary by default and at most
+const (
+	defaultVocabularyLimit = 100
+	maxVocabularyLimit     = 1000
+)
+
+// handleClipVocabulary lists every word indexed for a clip with its positions,
+// sources and confidence, for the review screen's "words in this clip" panel
+// and index audits. Pages are selected with offset and limit; sort=confidence
+// lists the most confident words first instead of by text.
+func (s *AutocompleteService) handleClipVocabulary(c *gin.Context) {
+	audioID := services.NormalizeAudioID(c.Param("audio_id"))
+
+	limit := defaultVocabularyLimit
+	if limitParam := c.Query("limit"); limitParam != "" {
+		parsed, err := strconv.Atoi(limitParam)
+		if err != nil || parsed <= 0 || parsed > maxVocabularyLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxVocabularyLimit)})
+			return
+		}
+		limit = parsed
+	}
+	offset := 0
+	if offsetParam := c.Query("offset"); offsetParam != "" {
+		parsed, err := strconv.Atoi(offsetParam)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return
+		}
+		offset = parsed
+	}
+	order := c.DefaultQuery("sort", "text")
+	if order != "text" && order != "confidence" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be text or confidence"})
+		return
+	}
+
+	positionMap, err := s.clipPositionMap(context.Background(), audioID)
+	if err == errClipNotIndexed || err != nil && !s.Stateless {
+		c.JSON(http.StatusNotFound, gin.H{"error": "autocomplete not initialized for clip " + audioID + ", please initialize first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	vocabulary := services.PositionVocabulary(positionMap)
+	if order == "confidence" {
+		sort.SliceStable(vocabulary, func(i, j int) bool {
+			return vocabulary[i].MeanConfidence > vocabulary[j].MeanConfidence
+		})
+	}
+
+	total := len(vocabulary)
+	page := []models.VocabularyEntry{}
+	if offset < total {
+		end := offset + limit
+		if end > total {
+			end = total
+		}
+		page = vocabulary[offset:end]
+	}
+	response := gin.H{
+		"audio_id": audioID,
+		"total":    total,
+		"offset":   offset,
+		"words":    page,
+	}
+	if offset+len(page) < total {
+		response["next_offset"] = offset + len(page)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// clipPositionMap reads the clip's position map: the cached one normally, or
+// its Redis index in stateless mode
+func (s *AutocompleteService) clipPositionMap(ctx context.Context, audioID string) (models.PositionMap, error) {
+	if !s.Stateless {
+		return services.GetPositionMap(audioID)
+	}
+
+	s.touchClip(audioID)
+	positionMap, err := readClipPositions(ctx, s.clipReadClient(audioID), audioID)
+	if err != nil {
+		return nil, err
+	}
+	if len(positionMap) == 0 {
+		return nil, errClipNotIndexed
+	}
+	return positionMap, nil
+}
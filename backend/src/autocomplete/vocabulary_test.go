@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleClipVocabulary(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan nasi goreng", ConfidenceScore: 0.9})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/clips/:audio_id/vocabulary", (&AutocompleteService{}).handleClipVocabulary)
+	stateless := gin.New()
+	stateless.GET("/clips/:audio_id/vocabulary", (&AutocompleteService{Stateless: true, RedisClient: unreachableRedis(t)}).handleClipVocabulary)
+
+	tests := []struct {
+		name      string
+		router    *gin.Engine
+		path      string
+		want      int
+		wantWords []string
+		wantNext  interface{}
+		wantTotal float64
+	}{
+		{"everything", router, "/clips/clip/vocabulary", http.StatusOK, []string{"goreng", "makan", "nasi", "saya"}, nil, 4},
+		{"first page", router, "/clips/clip/vocabulary?limit=3", http.StatusOK, []string{"goreng", "makan", "nasi"}, float64(3), 4},
+		{"last page", router, "/clips/clip/vocabulary?limit=3&offset=3", http.StatusOK, []string{"saya"}, nil, 4},
+		{"past the end", router, "/clips/clip/vocabulary?offset=10", http.StatusOK, []string{}, nil, 4},
+		{"bad limit", router, "/clips/clip/vocabulary?limit=0", http.StatusBadRequest, nil, nil, 0},
+		{"limit too large", router, "/clips/clip/vocabulary?limit=1001", http.StatusBadRequest, nil, nil, 0},
+		{"bad offset", router, "/clips/clip/vocabulary?offset=-1", http.StatusBadRequest, nil, nil, 0},
+		{"bad sort", router, "/clips/clip/vocabulary?sort=size", http.StatusBadRequest, nil, nil, 0},
+		{"unknown clip", router, "/clips/other/vocabulary", http.StatusNotFound, nil, nil, 0},
+		{"stateless without Redis", stateless, "/clips/clip/vocabulary", http.StatusInternalServerError, nil, nil, 0},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		tt.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var body struct {
+			Total      float64                  `json:"total"`
+			NextOffset interface{}              `json:"next_offset"`
+			Words      []models.VocabularyEntry `json:"words"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		words := []string{}
+		for _, entry := range body.Words {
+			words = append(words, entry.Text)
+		}
+		if !reflect.DeepEqual(words, tt.wantWords) || body.NextOffset != tt.wantNext || body.Total != tt.wantTotal {
+			t.Errorf("%s: words %v next %v total %v, want %v next %v total %v", tt.name, words, body.NextOffset, body.Total, tt.wantWords, tt.wantNext, tt.wantTotal)
+		}
+	}
+}
+
+func TestHandleClipVocabularyByConfidence(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{
+		FinalTranscription: "saya makan",
+		ConfidenceScore:    0.9,
+		ASRAlternatives:    map[string]string{"whisper": "saya makna"},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/clips/:audio_id/vocabulary", (&AutocompleteService{}).handleClipVocabulary)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/clips/clip/vocabulary?sort=confidence", nil))
+
+	var body struct {
+		Words []models.VocabularyEntry `json:"words"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(body.Words); i++ {
+		if body.Words[i].MeanConfidence > body.Words[i-1].MeanConfidence {
+			t.Errorf("sort=confidence lists %+v before %+v", body.Words[i-1], body.Words[i])
+		}
+	}
+	if len(body.Words) != 3 {
+		t.Errorf("sort=confidence listed %d words, want 3: %s", len(body.Words), recorder.Body)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Outbound webhook event types
+const (
+	webhookIngestCompleted   = "ingest.completed"
+	webhookFeedbackAccepted  = "feedback.accepted"
+	webhookFeedbackFinalized = "feedback.finalized"
+)
+
+// webhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256, keyed with
+// WEBHOOK_SECRET, of the X-Request-Timestamp value, a dot and the raw body
+const webhookSignatureHeader = "X-Autocomplete-Signature"
+
+// webhookAttempts is how many times a delivery is tried, backing off from a
+// second between attempts
+const webhookAttempts = 3
+
+// webhookEvent is the JSON body of a webhook
+type webhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// webhookSender delivers signed events to every configured URL from a
+// bounded queue, so callbacks never hold up a request
+type webhookSender struct {
+	urls   []string
+	secret []byte
+	events chan webhookEvent
+	client *http.Client
+
+	sent    atomic.Int64
+	failed  atomic.Int64
+	dropped atomic.Int64
+}
+
+// startWebhooks delivers events to WEBHOOK_URLS, signed with WEBHOOK_SECRET.
+// Webhooks stay off without URLs, and are refused without a secret.
+func (s *AutocompleteService) startWebhooks(ctx context.Context) error {
+	var urls []string
+	for _, url := range strings.Split(os.Getenv("WEBHOOK_URLS"), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		return fmt.Errorf("WEBHOOK_URLS needs WEBHOOK_SECRET so receivers can verify callbacks")
+	}
+
+	s.webhooks = &webhookSender{
+		urls:   urls,
+		secret: []byte(secret),
+		events: make(chan webhookEvent, envInt("WEBHOOK_QUEUE_SIZE", 1000)),
+		client: &http.Client{Timeout: envDuration("WEBHOOK_TIMEOUT", 5*time.Second)},
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-s.webhooks.events:
+				s.webhooks.deliver(ctx, event)
+			}
+		}
+	}()
+	log.Printf("Webhooks enabled for %d receivers", len(urls))
+	return nil
+}
+
+// emitWebhook queues an event, dropping it when the queue is full
+func (s *AutocompleteService) emitWebhook(eventType string, data interface{}) {
+	if s.webhooks == nil {
+		return
+	}
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		log.Printf("Error generating webhook event id: %v", err)
+		return
+	}
+
+	event := webhookEvent{ID: hex.EncodeToString(idBytes), Type: eventType, CreatedAt: time.Now(), Data: data}
+	select {
+	case s.webhooks.events <- event:
+	default:
+		s.webhooks.dropped.Add(1)
+		log.Printf("Webhook queue full, dropped %s event %s", eventType, event.ID)
+	}
+}
+
+// deliver posts the event to each receiver, retrying failures and 5xx responses
+func (w *webhookSender) deliver(ctx context.Context, event webhookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		w.failed.Add(int64(len(w.urls)))
+		log.Printf("Error encoding webhook event %s: %v", event.ID, err)
+		return
+	}
+
+	for _, url := range w.urls {
+		backoff := time.Second
+		for attempt := 1; ; attempt++ {
+			err = w.post(ctx, url, event, body)
+			if err == nil {
+				w.sent.Add(1)
+				break
+			}
+			if attempt == webhookAttempts {
+				w.failed.Add(1)
+				log.Printf("Error delivering webhook %s to %s: %v", event.ID, url, err)
+				break
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+// post sends one signed attempt. Each attempt is signed with a fresh
+// timestamp but keeps the event id as its nonce, so receivers enforcing a
+// replay window accept retries and still deduplicate them.
+func (w *webhookSender) post(ctx context.Context, url string, event webhookEvent, body []byte) error {
+	return postEvent(ctx, w.client, w.secret, url, event, body)
+}
+
+// postEvent posts an encoded event with the webhook headers, signing it
+// when there is a secret
+func postEvent(ctx context.Context, client *http.Client, secret []byte, url string, event webhookEvent, body []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Autocomplete-Event", event.Type)
+	request.Header.Set(replayNonceHeader, event.ID)
+	request.Header.Set(replayTimestampHeader, timestamp)
+	if len(secret) > 0 {
+		request.Header.Set(webhookSignatureHeader, signWebhook(secret, timestamp, body))
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("receiver returned %s", response.Status)
+	}
+	return nil
+}
+
+// signWebhook computes the signature header value for a body sent at timestamp
+func signWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookStats reports webhook deliveries, failures and dropped events
+func (s *AutocompleteService) webhookStats() map[string]interface{} {
+	if s.webhooks == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+	return map[string]interface{}{
+		"enabled":   true,
+		"receivers": len(s.webhooks.urls),
+		"queued":    len(s.webhooks.events),
+		"sent":      s.webhooks.sent.Load(),
+		"failed":    s.webhooks.failed.Load(),
+		"dropped":   s.webhooks.dropped.Load(),
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	base := signWebhook([]byte("secret"), "1700000000", []byte(`{"id":"1"}`))
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		wantSame  bool
+	}{
+		{"same input", "secret", "1700000000", `{"id":"1"}`, true},
+		{"other secret", "other", "1700000000", `{"id":"1"}`, false},
+		{"other timestamp", "secret", "1700000001", `{"id":"1"}`, false},
+		{"other body", "secret", "1700000000", `{"id":"2"}`, false},
+		{"timestamp moved into the body", "secret", "170000000", `0.{"id":"1"}`, false},
+	}
+	for _, tt := range tests {
+		got := signWebhook([]byte(tt.secret), tt.timestamp, []byte(tt.body))
+		if len(got) != len("sha256=")+64 || got[:7] != "sha256=" {
+			t.Errorf("%s: signWebhook() = %q, want sha256= and 64 hex digits", tt.name, got)
+		}
+		if (got == base) != tt.wantSame {
+			t.Errorf("%s: signature same as the base = %v, want %v", tt.name, got == base, tt.wantSame)
+		}
+	}
+}
+
+func TestStartWebhooks(t *testing.T) {
+	tests := []struct {
+		name     string
+		urls     string
+		secret   string
+		wantErr  bool
+		wantURLs []string
+	}{
+		{"not configured", "", "", false, nil},
+		{"blank urls", " , ", "secret", false, nil},
+		{"no secret", "http://orchestrator/hook", "", true, nil},
+		{"configured", " http://orchestrator/hook, http://frontend/hook ,", "secret", false, []string{"http://orchestrator/hook", "http://frontend/hook"}},
+	}
+	for _, tt := range tests {
+		t.Setenv("WEBHOOK_URLS", tt.urls)
+		t.Setenv("WEBHOOK_SECRET", tt.secret)
+		ctx, cancel := context.WithCancel(context.Background())
+		service := &AutocompleteService{}
+		err := service.startWebhooks(ctx)
+		cancel()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: startWebhooks() error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		var urls []string
+		if service.webhooks != nil {
+			urls = service.webhooks.urls
+		}
+		if !reflect.DeepEqual(urls, tt.wantURLs) {
+			t.Errorf("%s: webhook urls = %v, want %v", tt.name, urls, tt.wantURLs)
+		}
+	}
+}
+
+func TestEmitWebhookQueueFull(t *testing.T) {
+	service := &AutocompleteService{}
+	service.emitWebhook(webhookIngestCompleted, nil) // A no-op while webhooks are off
+
+	service.webhooks = &webhookSender{events: make(chan webhookEvent, 1)}
+	service.emitWebhook(webhookIngestCompleted, map[string]string{"audio_id": "clip"})
+	service.emitWebhook(webhookFeedbackAccepted, map[string]string{"audio_id": "clip"})
+
+	if queued, dropped := len(service.webhooks.events), service.webhooks.dropped.Load(); queued != 1 || dropped != 1 {
+		t.Errorf("%d events queued, %d dropped, want 1 and 1", queued, dropped)
+	}
+	if event := <-service.webhooks.events; event.Type != webhookIngestCompleted || len(event.ID) != 32 {
+		t.Errorf("queued event = %+v", event)
+	}
+}
+
+func TestWebhookDeliver(t *testing.T) {
+	secret := []byte("secret")
+	var mu sync.Mutex
+	var requests []*http.Request
+	var bodies [][]byte
+	failFirst := true
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		if failFirst {
+			failFirst = false
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer receiver.Close()
+
+	sender := &webhookSender{urls: []string{receiver.URL}, secret: secret, client: receiver.Client()}
+	event := webhookEvent{ID: "event-1", Type: webhookFeedbackAccepted, CreatedAt: time.Now(), Data: map[string]string{"audio_id": "clip"}}
+	sender.deliver(context.Background(), event)
+
+	if sender.sent.Load() != 1 || sender.failed.Load() != 0 || len(requests) != 2 {
+		t.Fatalf("%d sent, %d failed in %d attempts, want 1 sent after a retry", sender.sent.Load(), sender.failed.Load(), len(requests))
+	}
+	for i, request := range requests {
+		// Verify the way a receiver would
+		timestamp := request.Header.Get(replayTimestampHeader)
+		want := signWebhook(secret, timestamp, bodies[i])
+		if !hmac.Equal([]byte(request.Header.Get(webhookSignatureHeader)), []byte(want)) {
+			t.Errorf("attempt %d: signature %q does not verify", i+1, request.Header.Get(webhookSignatureHeader))
+		}
+		if request.Header.Get(replayNonceHeader) != event.ID || request.Header.Get("X-Autocomplete-Event") != event.Type {
+			t.Errorf("attempt %d: headers %v", i+1, request.Header)
+		}
+		var got webhookEvent
+		if err := json.Unmarshal(bodies[i], &got); err != nil || got.ID != event.ID {
+			t.Errorf("attempt %d: body %s", i+1, bodies[i])
+		}
+	}
+}
+
+func TestWebhookStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		webhooks *webhookSender
+		want     map[string]interface{}
+	}{
+		{"disabled", nil, map[string]interface{}{"enabled": false}},
+		{"enabled", &webhookSender{urls: []string{"http://a", "http://b"}, events: make(chan webhookEvent, 1)}, map[string]interface{}{
+			"enabled": true, "receivers": 2, "queued": 0, "sent": int64(0), "failed": int64(0), "dropped": int64(0),
+		}},
+	}
+	for _, tt := range tests {
+		if got := (&AutocompleteService{webhooks: tt.webhooks}).webhookStats(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: webhookStats() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
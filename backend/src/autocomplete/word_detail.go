@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+// prefixStanding is where a word stands in one prefix key of the global index
+type prefixStanding struct {
+	Prefix string  `json:"prefix"`
+	Score  float64 `json:"score"`
+	Rank   int64   `json:"rank"` // 1-based
+}
+
+// handleWordDetail reports everything known about a word of a clip, for
+// debugging ranking complaints: the models that produced it and where, its
+// ingest and verified frequencies, the feedback it received, and its score and
+// rank under each prefix of the global index.
+func (s *AutocompleteService) handleWordDetail(c *gin.Context) {
+	audioID := services.NormalizeAudioID(c.Param("audio_id"))
+	word := strings.TrimSpace(services.NormalizeText(c.Param("word")))
+	if word == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "word required"})
+		return
+	}
+	ctx := context.Background()
+
+	positionMap, err := s.clipPositionMap(ctx, audioID)
+	if err == errClipNotIndexed || err != nil && !s.Stateless {
+		c.JSON(http.StatusNotFound, gin.H{"error": "autocomplete not initialized for clip " + audioID + ", please initialize first"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	occurrences := services.FindWordOccurrences(audioID, positionMap, word)
+	if occurrences == nil {
+		occurrences = []models.WordOccurrence{}
+	}
+	seen := make(map[string]bool)
+	sources := []string{}
+	for _, occurrence := range occurrences {
+		if occurrence.Source != "" && !seen[occurrence.Source] {
+			seen[occurrence.Source] = true
+			sources = append(sources, occurrence.Source)
+		}
+	}
+	sort.Strings(sources)
+
+	frequency, standings, err := s.wordStandings(ctx, word)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	feedback, err := s.wordFeedback(ctx, audioID, word)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"audio_id":    audioID,
+		"word":        word,
+		"sources":     sources,
+		"occurrences": occurrences,
+		"frequency":   frequency,
+		"feedback":    feedback,
+		"prefixes":    standings,
+	})
+}
+
+// wordStandings reads a word's ingest and verified frequencies and its score
+// and rank under each prefix key it is indexed in
+func (s *AutocompleteService) wordStandings(ctx context.Context, word string) (gin.H, []prefixStanding, error) {
+	pipe := s.readClient().Pipeline()
+	ingested := pipe.ZScore(ctx, globalFrequencyKey, word)
+	verified := pipe.ZScore(ctx, verifiedFrequencyKey, strings.ToLower(word))
+
+	// Prefix keys hold the word's leading characters, as written by writeBatch
+	var prefixes []string
+	wordRunes := []rune(word)
+	for i := 1; i <= len(wordRunes) && i <= prefixIndexDepth; i++ {
+		prefixes = append(prefixes, string(wordRunes[:i]))
+	}
+	scores := make([]*redis.FloatCmd, len(prefixes))
+	ranks := make([]*redis.IntCmd, len(prefixes))
+	for i, prefix := range prefixes {
+		scores[i] = pipe.ZScore(ctx, prefixKey(prefix), word)
+		ranks[i] = pipe.ZRevRank(ctx, prefixKey(prefix), word)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, nil, err
+	}
+
+	standings := []prefixStanding{}
+	for i, prefix := range prefixes {
+		score, err := scores[i].Result()
+		if err != nil {
+			continue // Not indexed under this prefix, or the key expired
+		}
+		standings = append(standings, prefixStanding{Prefix: prefix, Score: score, Rank: ranks[i].Val() + 1})
+	}
+	frequency := gin.H{"ingested": ingested.Val(), "verified": verified.Val()}
+	return frequency, standings, nil
+}
+
+// wordFeedback gathers the feedback a word received in a clip: corrections
+// to or from it, positions where the reviewer accepted it, and what finalized
+// transcripts corrected it to
+func (s *AutocompleteService) wordFeedback(ctx context.Context, audioID, word string) (gin.H, error) {
+	folded := strings.ToLower(word)
+
+	logged, err := s.clipClient(audioID).LRange(ctx, clipCorrectionsKey(audioID), 0, -1).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	corrections := []correctionRecord{}
+	for _, encoded := range logged {
+		var record correctionRecord
+		if json.Unmarshal([]byte(encoded), &record) != nil {
+			continue
+		}
+		if strings.ToLower(record.OldWord) == folded || strings.ToLower(record.NewWord) == folded {
+			corrections = append(corrections, record)
+		}
+	}
+
+	accepted := []int{}
+	if !s.Stateless {
+		for position, accept := range services.SessionAccepts(audioID) {
+			if strings.ToLower(accept.Word) == folded {
+				accepted = append(accepted, position)
+			}
+		}
+		sort.Ints(accepted)
+	}
+
+	confusions, err := s.readClient().ZRevRangeWithScores(ctx, verifiedConfusionKeyPrefix+folded, 0, verifiedFollowersLimit-1).Result()
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	correctedTo := make([]gin.H, len(confusions))
+	for i, confusion := range confusions {
+		correctedTo[i] = gin.H{"word": confusion.Member, "count": confusion.Score}
+	}
+
+	return gin.H{
+		"corrections":  corrections,
+		"accepted_at":  accepted,
+		"corrected_to": correctedTo,
+	}, nil
+}
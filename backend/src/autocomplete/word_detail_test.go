@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"autocomplete/models"
+	"autocomplete/services"
+)
+
+func TestHandleWordDetailWithoutRedis(t *testing.T) {
+	services.ResetCache()
+	defer services.ResetCache()
+	services.BuildAndCacheData("clip", &models.AutocompleteData{FinalTranscription: "saya makan nasi", ConfidenceScore: 0.9})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/clips/:audio_id/words/:word", (&AutocompleteService{RedisClient: unreachableRedis(t)}).handleWordDetail)
+	stateless := gin.New()
+	stateless.GET("/clips/:audio_id/words/:word", (&AutocompleteService{Stateless: true, RedisClient: unreachableRedis(t)}).handleWordDetail)
+
+	tests := []struct {
+		name   string
+		router *gin.Engine
+		path   string
+		want   int
+	}{
+		{"blank word", router, "/clips/clip/words/%20", http.StatusBadRequest},
+		{"unknown clip", router, "/clips/other/words/makan", http.StatusNotFound},
+		// The clip is cached, but frequencies and feedback live in Redis
+		{"cached clip", router, "/clips/clip/words/makan", http.StatusInternalServerError},
+		{"stateless", stateless, "/clips/clip/words/makan", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		tt.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if recorder.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, recorder.Code, tt.want, recorder.Body)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"autocomplete/services"
+)
+
+// wordWrite is one queued word-store operation
+type wordWrite struct {
+	word       string
+	confidence float64
+}
+
+// writeQueue decouples /initialize from Redis: handlers enqueue word writes
+// and a pool of workers drains them in the background
+type writeQueue struct {
+	writes    chan wordWrite
+	processed atomic.Int64
+	flushes   atomic.Int64
+	failed    atomic.Int64
+	inline    atomic.Int64
+}
+
+// maxWriteBatch caps how many queued writes one flush coalesces
+const maxWriteBatch = 1000
+
+// prefixIndexDepth is how many leading characters of a word, phoneme string or
+// stem get their own prefix key
+const prefixIndexDepth = 10
+
+// prefixKeyPrefix holds one sorted set per word prefix, scored by confidence
+const prefixKeyPrefix = redisKeyPrefix + "prefix:"
+
+// prefixKey is the sorted set of the words starting with prefix
+func prefixKey(prefix string) string {
+	return prefixKeyPrefix + prefix
+}
+
+// indexedPrefixKey is the deepest prefix key holding word
+func indexedPrefixKey(word string) string {
+	return prefixKey(indexedRunes(word))
+}
+
+// startWriteQueue launches workers draining a queue of the given capacity.
+// Each worker gathers writes for up to window and flushes them as one batch.
+func (s *AutocompleteService) startWriteQueue(ctx context.Context, capacity, workers int, window time.Duration) {
+	s.queue = &writeQueue{writes: make(chan wordWrite, capacity)}
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for first := range s.queue.writes {
+				batch := s.queue.gather(first, window)
+				if err := s.writeBatch(ctx, batch); err != nil {
+					s.queue.failed.Add(int64(len(batch)))
+					log.Printf("Error writing batch of %d words: %v", len(batch), err)
+					continue
+				}
+				s.queue.processed.Add(int64(len(batch)))
+				s.queue.flushes.Add(1)
+			}
+		}()
+	}
+}
+
+// gather collects writes following first until the window closes or the batch is full
+func (q *writeQueue) gather(first wordWrite, window time.Duration) []wordWrite {
+	batch := []wordWrite{first}
+	if window <= 0 {
+		return batch
+	}
+
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+
+	for len(batch) < maxWriteBatch {
+		select {
+		case write, ok := <-q.writes:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, write)
+		case <-timer.C:
+			return batch
+		}
+	}
+	return batch
+}
+
+// enqueueWord queues a word write. When the queue is full the write happens
+// inline instead, so a Redis stall applies backpressure rather than growing memory.
+func (s *AutocompleteService) enqueueWord(ctx context.Context, word string, confidence float64) error {
+	select {
+	case s.queue.writes <- wordWrite{word: word, confidence: confidence}:
+		return nil
+	default:
+		s.queue.inline.Add(1)
+		return s.writeBatch(ctx, []wordWrite{{word: word, confidence: confidence}})
+	}
+}
+
+// batchPlan is what a batch of word writes does to Redis, coalesced per key:
+// global frequency increments, prefix key members in first-touched order, and
+// the members of each grouping key per key family
+type batchPlan struct {
+	frequencies    map[string]float64
+	prefixMembers  map[string]map[string]float64
+	prefixOrder    []string
+	groupFamilies  []string                                 // Key prefixes of the grouping indexes, in write order
+	groupsByFamily map[string]map[string]map[string]float64 // Key prefix → key → word → confidence
+}
+
+// planBatch coalesces writes into a batchPlan without touching Redis
+func planBatch(writes []wordWrite) *batchPlan {
+	plan := &batchPlan{
+		frequencies:    make(map[string]float64),
+		prefixMembers:  make(map[string]map[string]float64),
+		groupFamilies:  []string{phoneticKeyPrefix, phonemeKeyPrefix, stemPrefixKeyPrefix, stemFormsKeyPrefix, caseFoldKeyPrefix, trigramKeyPrefix, suffixKeyPrefix},
+		groupsByFamily: make(map[string]map[string]map[string]float64),
+	}
+	for _, family := range plan.groupFamilies {
+		plan.groupsByFamily[family] = make(map[string]map[string]float64)
+	}
+	phoneticMembers := plan.groupsByFamily[phoneticKeyPrefix]
+	phonemeMembers := plan.groupsByFamily[phonemeKeyPrefix]
+	stemPrefixMembers := plan.groupsByFamily[stemPrefixKeyPrefix]
+	stemForms := plan.groupsByFamily[stemFormsKeyPrefix]
+	caseFoldMembers := plan.groupsByFamily[caseFoldKeyPrefix]
+	trigramMembers := plan.groupsByFamily[trigramKeyPrefix]
+	suffixMembers := plan.groupsByFamily[suffixKeyPrefix]
+
+	for _, write := range writes {
+		plan.frequencies[write.word]++
+
+		// Group sound-alike spellings for homophone suggestions
+		if key := services.PhoneticKey(write.word); key != "" {
+			addGroupMember(phoneticMembers, key, write.word, write.confidence)
+		}
+
+		// Index phoneme prefixes for match_mode=phoneme
+		phonemes := []rune(services.Phonemes(write.word))
+		for i := 1; i <= len(phonemes) && i <= prefixIndexDepth; i++ {
+			addGroupMember(phonemeMembers, string(phonemes[:i]), write.word, write.confidence)
+		}
+
+		// Index stem prefixes and the stem's surface forms for stem=true
+		stem := services.Stem(write.word)
+		stemRunes := []rune(stem)
+		for i := 1; i <= len(stemRunes) && i <= prefixIndexDepth; i++ {
+			addGroupMember(stemPrefixMembers, string(stemRunes[:i]), stem, write.confidence)
+		}
+		addGroupMember(stemForms, stem, write.word, write.confidence)
+
+		// Index the word's own casing under its lower-cased prefixes for case_sensitive=true
+		folded := []rune(strings.ToLower(write.word))
+		for i := 1; i <= len(folded) && i <= prefixIndexDepth; i++ {
+			addGroupMember(caseFoldMembers, string(folded[:i]), write.word, write.confidence)
+		}
+
+		// Index every trigram for match_mode=infix
+		for _, trigram := range wordTrigrams(write.word) {
+			addGroupMember(trigramMembers, trigram, write.word, write.confidence)
+		}
+
+		// Index reversed prefixes for /suggest/suffix
+		reversed := []rune(reverseRunes(strings.ToLower(write.word)))
+		for i := 1; i <= len(reversed) && i <= prefixIndexDepth; i++ {
+			addGroupMember(suffixMembers, string(reversed[:i]), write.word, write.confidence)
+		}
+
+		// Store for prefix matching - add to all relevant prefix keys
+		wordRunes := []rune(write.word)
+		for i := 1; i <= len(wordRunes) && i <= prefixIndexDepth; i++ {
+			prefix := string(wordRunes[:i])
+			members, exists := plan.prefixMembers[prefix]
+			if !exists {
+				members = make(map[string]float64)
+				plan.prefixMembers[prefix] = members
+				plan.prefixOrder = append(plan.prefixOrder, prefix)
+			}
+			members[write.word] = write.confidence
+		}
+	}
+	return plan
+}
+
+// writeBatch stores words in the global frequency set and every prefix key
+// they match. Writes to the same keys are coalesced first: frequency increments
+// are summed, each prefix key gets a single multi-member ZADD (later writes of
+// a member win, as with sequential ZADDs) and a single EXPIRE, all in one pipeline.
+// The touched prefixes are then queued for the top-k projector, or
+// re-materialized at once when it runs inline.
+func (s *AutocompleteService) writeBatch(ctx context.Context, writes []wordWrite) error {
+	plan := planBatch(writes)
+	pipe := s.RedisClient.Pipeline()
+
+	// Store in global word frequency
+	for word, count := range plan.frequencies {
+		pipe.ZIncrBy(ctx, globalFrequencyKey, count, word)
+	}
+
+	for _, prefix := range plan.prefixOrder {
+		members := make([]*redis.Z, 0, len(plan.prefixMembers[prefix]))
+		for word, confidence := range plan.prefixMembers[prefix] {
+			members = append(members, &redis.Z{Score: confidence, Member: word})
+		}
+		key := prefixKey(prefix)
+		pipe.ZAdd(ctx, key, members...)
+		// Set expiration to 1 hour for prefix keys
+		pipe.Expire(ctx, key, time.Hour)
+	}
+
+	for _, family := range plan.groupFamilies {
+		addGroups(ctx, pipe, family, plan.groupsByFamily[family])
+	}
+
+	// The projector updates the read model later, unless it runs inline
+	if s.projector != nil && s.TopK > 0 {
+		s.markProjectionDirty(ctx, pipe, plan.prefixOrder)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	if s.projector != nil {
+		return nil
+	}
+	return s.materializeTopK(ctx, plan.prefixOrder)
+}
+
+// indexedRunes truncates text to the characters the prefix indexes go down to
+func indexedRunes(text string) string {
+	runes := []rune(text)
+	if len(runes) > prefixIndexDepth {
+		runes = runes[:prefixIndexDepth]
+	}
+	return string(runes)
+}
+
+// addGroupMember records a word's confidence under a grouping key
+func addGroupMember(groups map[string]map[string]float64, key, word string, confidence float64) {
+	if groups[key] == nil {
+		groups[key] = make(map[string]float64)
+	}
+	groups[key][word] = confidence
+}
+
+// addGroups queues one ZADD and EXPIRE per grouping key under keyPrefix
+func addGroups(ctx context.Context, pipe redis.Pipeliner, keyPrefix string, groups map[string]map[string]float64) {
+	for key, words := range groups {
+		members := make([]*redis.Z, 0, len(words))
+		for word, confidence := range words {
+			members = append(members, &redis.Z{Score: confidence, Member: word})
+		}
+		pipe.ZAdd(ctx, keyPrefix+key, members...)
+		pipe.Expire(ctx, keyPrefix+key, time.Hour)
+	}
+}
+
+// queueStats reports the write queue's depth and throughput
+func (s *AutocompleteService) queueStats() map[string]interface{} {
+	if s.queue == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+	return map[string]interface{}{
+		"enabled":   true,
+		"depth":     len(s.queue.writes),
+		"capacity":  cap(s.queue.writes),
+		"processed": s.queue.processed.Load(),
+		"flushes":   s.queue.flushes.Load(),
+		"failed":    s.queue.failed.Load(),
+		"inline":    s.queue.inline.Load(),
+	}
+}
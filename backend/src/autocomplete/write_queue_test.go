@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestIndexedPrefixKeyMatchesWrittenKeys(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"makan", "autocomplete:prefix:makan"},
+		{"kebersihannya", "autocomplete:prefix:kebersihan"},
+		{"café", "autocomplete:prefix:café"},
+		{"éééééééééééé", "autocomplete:prefix:éééééééééé"},
+	}
+	for _, tt := range tests {
+		got := indexedPrefixKey(tt.word)
+		if got != tt.want || !utf8.ValidString(got) {
+			t.Errorf("indexedPrefixKey(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+
+		// The deepest key a write creates for the word is the one read back
+		plan := planBatch([]wordWrite{{word: tt.word, confidence: 1}})
+		deepest := plan.prefixOrder[len(plan.prefixOrder)-1]
+		if prefixKey(deepest) != got {
+			t.Errorf("%q: deepest written key %q, read key %q", tt.word, prefixKey(deepest), got)
+		}
+	}
+}
+
+func TestEnqueueWordBackpressure(t *testing.T) {
+	tests := []struct {
+		name       string
+		capacity   int
+		writes     int
+		wantQueued int
+		wantInline int64
+	}{
+		{"room for every write", 3, 3, 3, 0},
+		{"full queue writes inline", 2, 5, 2, 3},
+		{"no buffer", 0, 2, 0, 2},
+	}
+	for _, tt := range tests {
+		// No workers drain the queue, so it fills up
+		service := &AutocompleteService{RedisClient: unreachableRedis(t), queue: &writeQueue{writes: make(chan wordWrite, tt.capacity)}}
+		failures := 0
+		for i := 0; i < tt.writes; i++ {
+			if err := service.enqueueWord(context.Background(), "makan", 0.9); err != nil {
+				failures++
+			}
+		}
+		stats := service.queueStats()
+		if stats["depth"] != tt.wantQueued || stats["inline"] != tt.wantInline {
+			t.Errorf("%s: depth %v, inline %v; want %d, %d", tt.name, stats["depth"], stats["inline"], tt.wantQueued, tt.wantInline)
+		}
+		// An inline write reports its failure to the caller
+		if int64(failures) != tt.wantInline {
+			t.Errorf("%s: %d enqueues failed, want %d", tt.name, failures, tt.wantInline)
+		}
+	}
+
+	if stats := (&AutocompleteService{}).queueStats(); stats["enabled"] != false {
+		t.Errorf("queueStats() without a queue = %v", stats)
+	}
+}
+
+func TestWriteQueueWorkersCountFailures(t *testing.T) {
+	service := &AutocompleteService{RedisClient: unreachableRedis(t)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.startWriteQueue(ctx, 10, 2, 0)
+
+	for _, word := range []string{"saya", "makan", "nasi"} {
+		if err := service.enqueueWord(ctx, word, 0.9); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for service.queue.failed.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if failed, processed := service.queue.failed.Load(), service.queue.processed.Load(); failed != 3 || processed != 0 {
+		t.Errorf("failed %d, processed %d; want 3, 0 without Redis", failed, processed)
+	}
+}
+
+func TestWriteQueueGather(t *testing.T) {
+	write := func(word string) wordWrite { return wordWrite{word: word, confidence: 0.5} }
+	tests := []struct {
+		name    string
+		queued  int
+		close   bool
+		window  time.Duration
+		wantLen int
+	}{
+		{"no window flushes alone", 5, false, 0, 1},
+		{"window takes what is queued", 5, false, 20 * time.Millisecond, 6},
+		{"closed queue ends the batch", 3, true, time.Hour, 4},
+		{"batch is capped", maxWriteBatch + 10, false, time.Hour, maxWriteBatch},
+	}
+	for _, tt := range tests {
+		queue := &writeQueue{writes: make(chan wordWrite, tt.queued)}
+		for i := 0; i < tt.queued; i++ {
+			queue.writes <- write("makan")
+		}
+		if tt.close {
+			close(queue.writes)
+		}
+
+		batch := queue.gather(write("saya"), tt.window)
+		if len(batch) != tt.wantLen || batch[0].word != "saya" {
+			t.Errorf("%s: gathered %d writes starting with %q, want %d starting with saya", tt.name, len(batch), batch[0].word, tt.wantLen)
+		}
+	}
+}
+
+func TestPlanBatchCoalesces(t *testing.T) {
+	plan := planBatch([]wordWrite{
+		{word: "makan", confidence: 0.5},
+		{word: "makan", confidence: 0.9},
+		{word: "mana", confidence: 0.7},
+	})
+
+	if want := map[string]float64{"makan": 2, "mana": 1}; !reflect.DeepEqual(plan.frequencies, want) {
+		t.Errorf("frequencies = %v, want %v", plan.frequencies, want)
+	}
+	// Shared prefixes are written once, in the order first touched
+	if want := []string{"m", "ma", "mak", "maka", "makan", "man", "mana"}; !reflect.DeepEqual(plan.prefixOrder, want) {
+		t.Errorf("prefix order = %v, want %v", plan.prefixOrder, want)
+	}
+	if got := plan.prefixMembers["ma"]; len(got) != 2 || got["mana"] != 0.7 {
+		t.Errorf("members of ma = %v", got)
+	}
+}